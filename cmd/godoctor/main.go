@@ -17,17 +17,28 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
 	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/danicat/godoctor/internal/config"
+	"github.com/danicat/godoctor/internal/godoc"
 	"github.com/danicat/godoctor/internal/hooks"
 	"github.com/danicat/godoctor/internal/instructions"
+	"github.com/danicat/godoctor/internal/selfprofile"
 	"github.com/danicat/godoctor/internal/server"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/docs/export"
 )
 
 var (
@@ -55,6 +66,13 @@ func runMain() int {
 }
 
 func run(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "export-docs" {
+		return exportDocs(ctx, args[1:])
+	}
+	if len(args) > 0 && args[0] == "self-profile" {
+		return selfProfile(ctx, args[1:])
+	}
+
 	cfg, err := config.Load(args)
 	if err != nil {
 		return err
@@ -98,3 +116,131 @@ func run(ctx context.Context, args []string) error {
 
 	return srv.Run(ctx)
 }
+
+// exportDocs implements the export-docs command, the command-line twin of the export_docs tool.
+func exportDocs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export-docs", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "module directory")
+	out := fs.String("out", filepath.Join("docs", "api"), "output directory, relative to -dir unless absolute")
+	format := fs.String("format", godoc.ExportMarkdown, "output format: markdown or html")
+	deps := fs.Bool("deps", false, "also export the non-standard packages the module imports")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Resolve -out like the export_docs tool does, so that -dir elsewhere does not write into the
+	// current directory.
+	if !filepath.IsAbs(*out) {
+		*out = filepath.Join(*dir, *out)
+	}
+	res, err := godoc.Export(ctx, godoc.ExportOptions{Dir: *dir, Out: *out, Format: *format, Dependencies: *deps})
+	if err != nil {
+		return err
+	}
+	fmt.Print(export.Summary(res, *out))
+	return nil
+}
+
+// selfProfile implements the self-profile command, which measures godoctor's own hot paths and
+// compares them with the previous run stored in the history file.
+func selfProfile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("self-profile", flag.ContinueOnError)
+	runs := fs.Int("runs", 10, "measured runs per scenario")
+	match := fs.String("run", "", "only run scenarios whose name matches this regular expression")
+	history := fs.String("history", filepath.Join(".godoctor", "self-profile.json"), "file keeping the results of previous runs")
+	threshold := fs.Float64("threshold", 0.25, "relative slowdown of a median that counts as a regression")
+	save := fs.Bool("save", true, "append this run to the history file")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile of all scenarios to this file")
+	memProfile := fs.String("memprofile", "", "write a heap profile to this file after the scenarios")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return fmt.Errorf("runs must be at least 1")
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		return fmt.Errorf("invalid -run pattern: %w", err)
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	cur := selfprofile.Run{
+		Time:      time.Now().UTC(),
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if out, err := exec.CommandContext(ctx, "git", "rev-parse", "--short", "HEAD").Output(); err == nil {
+		cur.Commit = strings.TrimSpace(string(out))
+	}
+	for _, sc := range selfprofile.Scenarios() {
+		if !re.MatchString(sc.Name) {
+			continue
+		}
+		res, err := selfprofile.Measure(ctx, sc, *runs)
+		if err != nil {
+			return err
+		}
+		cur.Results = append(cur.Results, res)
+		fmt.Printf("%-18s %12s/op %12d B/op %9d allocs/op  (min %s, %s)\n", res.Name,
+			time.Duration(res.MedianNs).Round(time.Microsecond), res.BytesPerOp, res.AllocsOp,
+			time.Duration(res.MinNs).Round(time.Microsecond), sc.Description)
+	}
+	if len(cur.Results) == 0 {
+		return fmt.Errorf("no scenario matches %q", *match)
+	}
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+
+	past, err := selfprofile.LoadHistory(*history)
+	if err != nil {
+		return err
+	}
+	var regressed []string
+	if len(past) > 0 {
+		prev := past[len(past)-1]
+		label := prev.Version
+		if prev.Commit != "" {
+			label += " " + prev.Commit
+		}
+		fmt.Printf("\nCompared with %s (%s):\n", label, prev.Time.Format(time.RFC3339))
+		for _, d := range selfprofile.Compare(prev, cur, *threshold) {
+			mark := ""
+			if d.Regressed {
+				mark = "  REGRESSION"
+				regressed = append(regressed, d.Name)
+			}
+			fmt.Printf("%-18s %12s -> %12s  %+6.1f%%%s\n", d.Name,
+				time.Duration(d.PrevNs).Round(time.Microsecond), time.Duration(d.CurNs).Round(time.Microsecond), d.Change*100, mark)
+		}
+	}
+	if *save {
+		if err := selfprofile.SaveHistory(*history, append(past, cur)); err != nil {
+			return fmt.Errorf("failed to save history: %w", err)
+		}
+	}
+	if len(regressed) > 0 {
+		return fmt.Errorf("%d scenario(s) regressed by more than %.0f%%: %s", len(regressed), *threshold*100, strings.Join(regressed, ", "))
+	}
+	return nil
+}
@@ -16,9 +16,13 @@ package main
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/danicat/godoctor/internal/testutil"
 )
 
 func TestRun(t *testing.T) {
@@ -39,6 +43,12 @@ func TestRun(t *testing.T) {
 			expectError: true,
 			errContains: "flag provided but not defined: -bad-flag",
 		},
+		{
+			name:        "self-profile invalid runs",
+			args:        []string{"self-profile", "-runs", "0"},
+			expectError: true,
+			errContains: "runs must be at least 1",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -60,3 +70,21 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestExportDocs_OutRelativeToDir(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":  testutil.GoMod("example.com/shop"),
+		"shop.go": "// Package shop sells things.\npackage shop\n",
+	})
+	t.Chdir(t.TempDir())
+
+	if err := run(context.Background(), []string{"export-docs", "-dir", dir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "api", "index.md")); err != nil {
+		t.Errorf("docs not written under -dir: %v", err)
+	}
+	if _, err := os.Stat("docs"); !os.IsNotExist(err) {
+		t.Errorf("docs written to the working directory (stat err: %v)", err)
+	}
+}
@@ -0,0 +1,169 @@
+// Package budget enforces per-session resource budgets set by the operator: the CPU time of the
+// commands tools run (go, gopls, git, ...) and the bytes tools write to disk. Every tool result
+// reports what is left in its metadata, and once a budget is spent further calls fail with a
+// structured BUDGET_EXCEEDED error instead of running.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MetaKey is the result metadata key under which the remaining budget is reported.
+const MetaKey = "budget"
+
+// ErrorCode identifies a call rejected because a budget is spent.
+const ErrorCode = "BUDGET_EXCEEDED"
+
+// Limits are the budgets of one session. A zero limit means unlimited.
+type Limits struct {
+	CPU   time.Duration // CPU time (user + system) of the subprocesses tools run
+	Write int64         // bytes written to disk by tools
+}
+
+// Enabled reports whether any budget is set.
+func (l Limits) Enabled() bool { return l.CPU > 0 || l.Write > 0 }
+
+// Usage is what a session has spent so far.
+type Usage struct {
+	CPU   time.Duration
+	Write int64
+}
+
+// Tracker keeps the usage of each session and enforces the limits.
+type Tracker struct {
+	limits Limits
+	mu     sync.Mutex
+	usage  map[*mcp.ServerSession]*Usage
+}
+
+// New returns a tracker enforcing limits.
+func New(limits Limits) *Tracker {
+	return &Tracker{limits: limits, usage: make(map[*mcp.ServerSession]*Usage)}
+}
+
+// Usage returns what the session has spent so far.
+func (t *Tracker) Usage(session *mcp.ServerSession) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if u := t.usage[session]; u != nil {
+		return *u
+	}
+	return Usage{}
+}
+
+// Delete forgets the usage of the session.
+func (t *Tracker) Delete(session *mcp.ServerSession) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.usage, session)
+}
+
+func (t *Tracker) charge(session *mcp.ServerSession, cpu time.Duration, written int64) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.usage[session]
+	if u == nil {
+		u = &Usage{}
+		t.usage[session] = u
+	}
+	u.CPU += cpu
+	u.Write += written
+	return *u
+}
+
+// exceeded returns the name of the first spent budget, if any.
+func (t *Tracker) exceeded(u Usage) string {
+	switch {
+	case t.limits.CPU > 0 && u.CPU >= t.limits.CPU:
+		return "cpu"
+	case t.limits.Write > 0 && u.Write >= t.limits.Write:
+		return "write"
+	}
+	return ""
+}
+
+// remaining is the metadata reported with every result. Only the budgets that are set appear.
+func (t *Tracker) remaining(u Usage) map[string]any {
+	m := make(map[string]any)
+	if t.limits.CPU > 0 {
+		m["cpuSeconds"] = max(t.limits.CPU-u.CPU, 0).Seconds()
+	}
+	if t.limits.Write > 0 {
+		m["writeBytes"] = max(t.limits.Write-u.Write, 0)
+	}
+	return m
+}
+
+// Middleware enforces the budgets on tools/call requests handled by next. Other methods are
+// untouched. Bytes written are those reported through package writes, which every tool writes
+// files with.
+//
+// Subprocess CPU time is measured for the whole server process, so with concurrent calls a command
+// is charged to every call running when it exits. Budgets may then run out early, never late. A
+// call is only rejected before it starts: the call that crosses a limit completes normally.
+func (t *Tracker) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		ctr, ok := req.(*mcp.CallToolRequest)
+		if !ok || ctr.Params == nil {
+			return next(ctx, method, req)
+		}
+		if u := t.Usage(ctr.Session); t.exceeded(u) != "" {
+			return t.reject(ctr.Params.Name, u), nil
+		}
+
+		var written counter
+		before := childCPU()
+		res, err := next(writes.With(ctx, &written), method, req)
+		after := childCPU()
+		u := t.charge(ctr.Session, after-before, written.n.Load())
+
+		if tr, ok := res.(*mcp.CallToolResult); ok && tr != nil {
+			if tr.Meta == nil {
+				tr.Meta = mcp.Meta{}
+			}
+			tr.Meta[MetaKey] = t.remaining(u)
+		}
+		return res, err
+	}
+}
+
+// counter counts the bytes a call writes.
+type counter struct{ n atomic.Int64 }
+
+func (c *counter) Wrote(n int64)                  { c.n.Add(n) }
+func (c *counter) Changed(string, []byte, []byte) {}
+
+// reject builds the result of a call refused because a budget is spent.
+func (t *Tracker) reject(tool string, u Usage) *mcp.CallToolResult {
+	budget := t.exceeded(u)
+	var limit, used any
+	var msg string
+	switch budget {
+	case "cpu":
+		limit, used = t.limits.CPU.Seconds(), u.CPU.Seconds()
+		msg = fmt.Sprintf("%s: %s not run: the session has used %s of its %s subprocess CPU budget", ErrorCode, tool, u.CPU.Round(time.Millisecond), t.limits.CPU)
+	default:
+		limit, used = t.limits.Write, u.Write
+		msg = fmt.Sprintf("%s: %s not run: the session has written %d of its %d byte budget", ErrorCode, tool, u.Write, t.limits.Write)
+	}
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: msg + ". Start a new session or ask the operator to raise the limit."}},
+		StructuredContent: map[string]any{
+			"error": map[string]any{
+				"code":   ErrorCode,
+				"budget": budget,
+				"limit":  limit,
+				"used":   used,
+			},
+		},
+		Meta: mcp.Meta{MetaKey: t.remaining(u)},
+	}
+}
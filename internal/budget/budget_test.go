@@ -0,0 +1,96 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func callRequest() *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "smart_edit", Arguments: json.RawMessage(`{}`)}}
+}
+
+func okResult() *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}
+}
+
+func TestMiddleware_Write(t *testing.T) {
+	var runs int
+	path := filepath.Join(t.TempDir(), "a.go")
+	tr := New(Limits{Write: 10})
+	h := tr.Middleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		runs++
+		if err := writes.WriteFile(ctx, path, []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return okResult(), nil
+	})
+
+	res, err := h(context.Background(), "tools/call", callRequest())
+	if err != nil || res.(*mcp.CallToolResult).IsError {
+		t.Fatalf("first call failed: %v", err)
+	}
+	// The call that reaches the limit completes and reports nothing left.
+	if got := res.(*mcp.CallToolResult).Meta[MetaKey].(map[string]any); got["writeBytes"] != int64(0) {
+		t.Errorf("remaining = %v, want 0 bytes", got)
+	}
+
+	res, err = h(context.Background(), "tools/call", callRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := res.(*mcp.CallToolResult)
+	if !r.IsError || runs != 1 {
+		t.Fatalf("second call ran (runs = %d, IsError = %v)", runs, r.IsError)
+	}
+	if text := r.Content[0].(*mcp.TextContent).Text; !strings.HasPrefix(text, "BUDGET_EXCEEDED: smart_edit not run: the session has written 10 of its 10 byte budget") {
+		t.Errorf("unexpected message: %s", text)
+	}
+	e := r.StructuredContent.(map[string]any)["error"].(map[string]any)
+	if e["code"] != ErrorCode || e["budget"] != "write" || e["used"] != int64(10) {
+		t.Errorf("structured error = %v", e)
+	}
+}
+
+func TestMiddleware_CPU(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("subprocess CPU time is not measured on Windows")
+	}
+	tr := New(Limits{CPU: time.Nanosecond})
+	h := tr.Middleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if err := exec.Command("go", "version").Run(); err != nil {
+			t.Fatal(err)
+		}
+		return okResult(), nil
+	})
+	if _, err := h(context.Background(), "tools/call", callRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if u := tr.Usage(nil); u.CPU <= 0 {
+		t.Fatalf("the command was not charged: %v", u.CPU)
+	}
+	res, _ := h(context.Background(), "tools/call", callRequest())
+	e := res.(*mcp.CallToolResult).StructuredContent.(map[string]any)["error"].(map[string]any)
+	if e["budget"] != "cpu" {
+		t.Errorf("structured error = %v", e)
+	}
+}
+
+func TestMiddleware_OtherMethods(t *testing.T) {
+	tr := New(Limits{Write: 1})
+	tr.charge(nil, 0, 5)
+	h := tr.Middleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.ListToolsResult{}, nil
+	})
+	if _, err := h(context.Background(), "tools/list", &mcp.ListToolsRequest{}); err != nil {
+		t.Errorf("tools/list was blocked: %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package budget
+
+import "time"
+
+// childCPU is not available on this platform; the CPU budget is never charged.
+func childCPU() time.Duration { return 0 }
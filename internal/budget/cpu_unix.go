@@ -0,0 +1,17 @@
+//go:build unix
+
+package budget
+
+import (
+	"syscall"
+	"time"
+)
+
+// childCPU returns the CPU time used by the terminated and waited-for children of the process.
+func childCPU() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
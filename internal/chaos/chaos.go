@@ -0,0 +1,158 @@
+// Package chaos injects controlled failures into tool calls so that MCP client developers can
+// exercise their error handling against a real server. It is enabled with the hidden --chaos flag
+// and is never active by default.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Fault is a kind of injected failure.
+type Fault string
+
+// Injected faults.
+const (
+	// FaultLatency delays the call before running it normally.
+	FaultLatency Fault = "latency"
+	// FaultSubprocess runs the call with a context that expires almost immediately, so the go,
+	// gopls or git commands it starts are killed and the handler's own failure path is taken.
+	FaultSubprocess Fault = "subprocess"
+	// FaultMalformed runs the call and then corrupts its response.
+	FaultMalformed Fault = "malformed"
+)
+
+var faults = []Fault{FaultLatency, FaultSubprocess, FaultMalformed}
+
+// Options configures the injector.
+type Options struct {
+	Rate       float64       // probability in [0, 1] that a call gets a fault
+	Seed       uint64        // seed of the fault sequence; the same seed injects the same faults
+	MaxLatency time.Duration // upper bound of injected delays
+	Log        io.Writer     // receives one line per injected fault; may be nil
+}
+
+// Injector decides, call by call, which fault to inject.
+type Injector struct {
+	opts  Options
+	mu    sync.Mutex
+	rng   *rand.Rand
+	calls int
+}
+
+// New returns an injector for the given options.
+func New(opts Options) *Injector {
+	if opts.MaxLatency <= 0 {
+		opts.MaxLatency = 5 * time.Second
+	}
+	return &Injector{opts: opts, rng: rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))}
+}
+
+// next draws the fault for the next call, or "" for none, with the random values it needs.
+func (in *Injector) next() (n int, f Fault, d time.Duration, variant int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.calls++
+	n = in.calls
+	if in.rng.Float64() >= in.opts.Rate {
+		return n, "", 0, 0
+	}
+	f = faults[in.rng.IntN(len(faults))]
+	d = time.Duration(in.rng.Int64N(int64(in.opts.MaxLatency)))
+	variant = in.rng.IntN(4)
+	return n, f, d, variant
+}
+
+// Middleware injects faults into tools/call requests handled by next. Other methods are untouched.
+func (in *Injector) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		ctr, ok := req.(*mcp.CallToolRequest)
+		if !ok || ctr.Params == nil {
+			return next(ctx, method, req)
+		}
+		n, fault, delay, variant := in.next()
+		if fault == "" {
+			return next(ctx, method, req)
+		}
+		in.logf("chaos: call %d (%s): injecting %s", n, ctr.Params.Name, fault)
+
+		switch fault {
+		case FaultLatency:
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return next(ctx, method, req)
+		case FaultSubprocess:
+			// Long enough for the handler to validate its input and start a command.
+			sctx, cancel := context.WithTimeout(ctx, delay%(50*time.Millisecond))
+			defer cancel()
+			return next(sctx, method, req)
+		default:
+			res, err := next(ctx, method, req)
+			if err != nil {
+				return res, err
+			}
+			tr, ok := res.(*mcp.CallToolResult)
+			if !ok || tr == nil {
+				return res, err
+			}
+			return malform(tr, variant)
+		}
+	}
+}
+
+// malform corrupts a tool result in one of the ways a client may have to cope with.
+func malform(res *mcp.CallToolResult, variant int) (mcp.Result, error) {
+	switch variant {
+	case 0:
+		// No content at all.
+		return &mcp.CallToolResult{}, nil
+	case 1:
+		// Text cut off mid-output, as from a crashed or killed process.
+		out := &mcp.CallToolResult{IsError: res.IsError}
+		for _, c := range res.Content {
+			if t, ok := c.(*mcp.TextContent); ok {
+				out.Content = append(out.Content, &mcp.TextContent{Text: truncate(t.Text)})
+			}
+		}
+		return out, nil
+	case 2:
+		// An error flag with the content of a successful call.
+		return &mcp.CallToolResult{IsError: !res.IsError, Content: res.Content}, nil
+	default:
+		// A protocol error instead of a result.
+		return nil, errors.New("chaos: injected internal error")
+	}
+}
+
+func truncate(s string) string {
+	if s == "" {
+		return s
+	}
+	cut := len(s) / 2
+	// Cut inside a multi-byte rune when there is one, to also exercise invalid UTF-8 handling.
+	if !utf8.RuneStart(s[cut]) {
+		return s[:cut]
+	}
+	if i := strings.IndexFunc(s[cut:], func(r rune) bool { return r >= utf8.RuneSelf }); i >= 0 {
+		return s[:cut+i+1]
+	}
+	return s[:cut]
+}
+
+func (in *Injector) logf(format string, args ...any) {
+	if in.opts.Log != nil {
+		fmt.Fprintf(in.opts.Log, format+"\n", args...)
+	}
+}
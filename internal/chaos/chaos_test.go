@@ -0,0 +1,102 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func callRequest() *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "smart_build", Arguments: json.RawMessage(`{}`)}}
+}
+
+func okHandler(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "build passed ✅ with no issues"}}}, nil
+}
+
+func TestInjector_Deterministic(t *testing.T) {
+	draw := func() []Fault {
+		in := New(Options{Rate: 0.5, Seed: 42})
+		var got []Fault
+		for i := 0; i < 50; i++ {
+			_, f, _, _ := in.next()
+			got = append(got, f)
+		}
+		return got
+	}
+	a, b := draw(), draw()
+	var injected int
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed drew different faults at call %d: %q vs %q", i+1, a[i], b[i])
+		}
+		if a[i] != "" {
+			injected++
+		}
+	}
+	if injected == 0 || injected == len(a) {
+		t.Errorf("rate 0.5 injected %d faults in %d calls", injected, len(a))
+	}
+}
+
+func TestMiddleware_RateZero(t *testing.T) {
+	h := New(Options{Rate: 0, Seed: 1}).Middleware(okHandler)
+	for i := 0; i < 20; i++ {
+		res, err := h(context.Background(), "tools/call", callRequest())
+		if err != nil || res.(*mcp.CallToolResult).IsError {
+			t.Fatalf("call %d: unexpected fault: %v", i+1, err)
+		}
+	}
+}
+
+func TestMiddleware_Faults(t *testing.T) {
+	var log strings.Builder
+	h := New(Options{Rate: 1, Seed: 7, MaxLatency: 10 * time.Millisecond, Log: &log}).Middleware(
+		func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			// Stands in for a handler running a subprocess bound to ctx.
+			select {
+			case <-ctx.Done():
+				return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "signal: killed"}}}, nil
+			case <-time.After(30 * time.Millisecond):
+			}
+			return okHandler(ctx, method, req)
+		})
+
+	for i := 0; i < 30; i++ {
+		res, err := h(context.Background(), "tools/call", callRequest())
+		if err != nil {
+			continue
+		}
+		for _, c := range res.(*mcp.CallToolResult).Content {
+			text := c.(*mcp.TextContent).Text
+			if text != "build passed ✅ with no issues" && text != "signal: killed" && !strings.HasPrefix("build passed ✅ with no issues", text) {
+				t.Errorf("unexpected content %q", text)
+			}
+		}
+	}
+	for _, f := range faults {
+		if !strings.Contains(log.String(), "injecting "+string(f)) {
+			t.Errorf("fault %q never injected in 30 calls:\n%s", f, log.String())
+		}
+	}
+
+	// Other methods are never affected.
+	if _, err := h(context.Background(), "tools/list", &mcp.ListToolsRequest{}); err != nil {
+		t.Errorf("tools/list: %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	got := truncate("build passed ✅ with no issues")
+	if utf8.ValidString(got) {
+		t.Errorf("truncate(%q) should cut inside the multi-byte rune", got)
+	}
+	if got := truncate("abcd"); got != "ab" {
+		t.Errorf("truncate(abcd) = %q, want ab", got)
+	}
+}
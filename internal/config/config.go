@@ -1,11 +1,16 @@
 // Package config handles the loading and management of the application's configuration.
 // It parses command-line arguments and defines the runtime settings for the godoctor server,
-// including server address, model selection, and tool enablement/disablement policies.
+// including server address, model selection, locale, and tool enablement/disablement policies.
 package config
 
 import (
 	"flag"
+	"fmt"
 	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/toolnames"
 )
 
 // Config holds the application configuration.
@@ -14,10 +19,22 @@ type Config struct {
 	Version       bool
 	Agents        bool
 	ListTools     bool            // List available tools for the selected profile and exit
+	Locale        string          // Default locale for tool messages; clients may override it per session
 	AllowedTools  map[string]bool // If non-empty, ONLY these tools are allowed
 	DisabledTools map[string]bool // These tools are explicitly disabled
+	NoCloud       bool            // Local-only mode: nothing is sent to external services
+	BudgetCPU     time.Duration   // Subprocess CPU time allowed per session; 0 is unlimited
+	BudgetWrite   int64           // Bytes tools may write per session; 0 is unlimited
+
+	// Chaos mode (hidden flags) injects failures into tool calls to test client error handling.
+	Chaos     bool
+	ChaosRate float64 // probability that a tool call gets a fault
+	ChaosSeed uint64  // seed of the fault sequence; 0 picks a random one
 }
 
+// hiddenFlags are accepted but left out of the usage message.
+var hiddenFlags = map[string]bool{"chaos": true, "chaos-rate": true, "chaos-seed": true}
+
 // Load parses command-line arguments and returns a Config struct.
 func Load(args []string) (*Config, error) {
 	fs := flag.NewFlagSet("godoctor", flag.ContinueOnError)
@@ -28,10 +45,30 @@ func Load(args []string) (*Config, error) {
 
 	allowFlag := fs.String("allow", "", "comma-separated list of tools to explicitly allow")
 	disableFlag := fs.String("disable", "", "comma-separated list of tools to disable")
+	noCloudFlag := fs.Bool("no-cloud", false, "local-only mode: disable every tool and code path that sends workspace content to external services")
+	budgetCPU := fs.Duration("budget-cpu", 0, "CPU time the commands run by tools may use per session, e.g. 10m (0 is unlimited)")
+	budgetWrite := fs.Int64("budget-write", 0, "bytes tools may write to disk per session (0 is unlimited)")
+	localeFlag := fs.String("locale", locale.English, "default language for tool messages ("+strings.Join(locale.Supported(), ", ")+")")
+
+	chaosFlag := fs.Bool("chaos", false, "inject random latency, subprocess failures and malformed responses into tool calls")
+	chaosRate := fs.Float64("chaos-rate", 0.2, "probability that a tool call gets a fault in chaos mode")
+	chaosSeed := fs.Uint64("chaos-seed", 0, "seed of the chaos fault sequence (0 picks a random seed)")
+	fs.Usage = func() { usage(fs) }
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
+	if *budgetCPU < 0 || *budgetWrite < 0 {
+		return nil, fmt.Errorf("invalid budget: limits must not be negative")
+	}
+	if *chaosRate < 0 || *chaosRate > 1 {
+		return nil, fmt.Errorf("invalid chaos rate %v: must be between 0 and 1", *chaosRate)
+	}
+
+	lang := locale.Match(*localeFlag)
+	if lang == "" {
+		return nil, fmt.Errorf("unsupported locale %q (supported: %s)", *localeFlag, strings.Join(locale.Supported(), ", "))
+	}
 
 	parseList := func(s string) map[string]bool {
 		m := make(map[string]bool)
@@ -52,13 +89,33 @@ func Load(args []string) (*Config, error) {
 		Version:       *versionFlag,
 		Agents:        *agentsFlag,
 		ListTools:     *listToolsFlag,
+		Locale:        lang,
 		AllowedTools:  parseList(*allowFlag),
 		DisabledTools: parseList(*disableFlag),
+		NoCloud:       *noCloudFlag,
+		BudgetCPU:     *budgetCPU,
+		BudgetWrite:   *budgetWrite,
+		Chaos:         *chaosFlag,
+		ChaosRate:     *chaosRate,
+		ChaosSeed:     *chaosSeed,
 	}
 
 	return cfg, nil
 }
 
+// usage prints the defaults of the visible flags.
+func usage(fs *flag.FlagSet) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(fs.Output())
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+	visible.PrintDefaults()
+}
+
 // IsToolEnabled checks if a tool should be enabled.
 func (c *Config) IsToolEnabled(name string) bool {
 	// 1. Explicitly Disabled
@@ -66,12 +123,17 @@ func (c *Config) IsToolEnabled(name string) bool {
 		return false
 	}
 
-	// 2. Explicitly Allowed (Whitelist mode)
+	// 2. Local-only mode wins over the whitelist
+	if c.NoCloud && toolnames.Registry[name].Cloud {
+		return false
+	}
+
+	// 3. Explicitly Allowed (Whitelist mode)
 	if len(c.AllowedTools) > 0 {
 		return c.AllowedTools[name]
 	}
 
-	// 3. Default: All enabled
+	// 4. Default: All enabled
 	return true
 }
 
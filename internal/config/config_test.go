@@ -1,7 +1,10 @@
 package config
 
 import (
+	"flag"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -49,3 +52,85 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadLocale(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: nil, want: "en"},
+		{args: []string{"--locale", "pt-BR"}, want: "pt-BR"},
+		{args: []string{"--locale", "pt_br"}, want: "pt-BR"},
+		{args: []string{"--locale", "fr"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg, err := Load(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Load(%v) succeeded, want error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Load(%v) error = %v", tt.args, err)
+		}
+		if cfg.Locale != tt.want {
+			t.Errorf("Load(%v).Locale = %q, want %q", tt.args, cfg.Locale, tt.want)
+		}
+	}
+}
+
+func TestLoadChaos(t *testing.T) {
+	cfg, err := Load([]string{"--chaos", "--chaos-rate", "0.5", "--chaos-seed", "42"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Chaos || cfg.ChaosRate != 0.5 || cfg.ChaosSeed != 42 {
+		t.Errorf("Load() chaos = %v, %v, %v; want true, 0.5, 42", cfg.Chaos, cfg.ChaosRate, cfg.ChaosSeed)
+	}
+	if _, err := Load([]string{"--chaos-rate", "1.5"}); err == nil {
+		t.Error("Load() accepted a chaos rate above 1")
+	}
+
+	// The chaos flags are hidden from the usage message.
+	var out strings.Builder
+	fs := flag.NewFlagSet("godoctor", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fs.Bool("chaos", false, "hidden")
+	fs.String("listen", "", "visible")
+	usage(fs)
+	if strings.Contains(out.String(), "chaos") || !strings.Contains(out.String(), "-listen") {
+		t.Errorf("usage() =\n%s", out.String())
+	}
+}
+
+func TestLoadNoCloud(t *testing.T) {
+	cfg, err := Load([]string{"--no-cloud", "--allow", "search_modules,smart_read"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.NoCloud {
+		t.Fatal("Load().NoCloud = false, want true")
+	}
+	// Local-only mode wins over an explicit allow.
+	if cfg.IsToolEnabled("search_modules") || cfg.IsToolEnabled("dependency_health") {
+		t.Error("tools that contact external services are enabled in local-only mode")
+	}
+	if !cfg.IsToolEnabled("smart_read") {
+		t.Error("smart_read is disabled in local-only mode")
+	}
+}
+
+func TestLoadBudget(t *testing.T) {
+	cfg, err := Load([]string{"--budget-cpu", "90s", "--budget-write", "1048576"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.BudgetCPU != 90*time.Second || cfg.BudgetWrite != 1<<20 {
+		t.Errorf("Load() budget = %v, %d; want 1m30s, 1048576", cfg.BudgetCPU, cfg.BudgetWrite)
+	}
+	if _, err := Load([]string{"--budget-write", "-1"}); err == nil {
+		t.Error("Load() accepted a negative budget")
+	}
+}
@@ -0,0 +1,177 @@
+package godoc
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxCacheEntries bounds the documentation cache. When it is full the least recently used entry
+// is evicted, so the packages an agent keeps coming back to stay cached.
+const maxCacheEntries = 512
+
+// maxPackageEntries bounds the parsed package cache, whose entries hold whole ASTs.
+const maxPackageEntries = 64
+
+// docCache memoizes parsed documentation. Entries are keyed by the state of the package
+// directory (file names, sizes and modification times), so editing a package invalidates its
+// entries without any explicit bookkeeping.
+var docCache = newLRU[*Doc](maxCacheEntries)
+
+// packageCache memoizes parsed packages and their sub-packages under the same keys as docCache,
+// minus the symbol, so that looking up several symbols of a package parses it only once.
+var packageCache = newLRU[*cachedPackage](maxPackageEntries)
+
+type cachedPackage struct {
+	*parsedPackage
+	subs []string
+}
+
+// cachedParse is parsePackageDocs behind docCache. Callers receive a copy they may modify.
+func cachedParse(ctx context.Context, importPath, pkgDir, symbolName, requestedPath string, examples bool) (*Doc, error) {
+	stamp, ok := dirStamp(pkgDir)
+	if !ok {
+		return parsePackageDocs(ctx, importPath, pkgDir, symbolName, requestedPath, examples)
+	}
+	pkgKey := strings.Join([]string{importPath, pkgDir, stamp, fmt.Sprint(examples)}, "\x00")
+	key := strings.Join([]string{pkgKey, symbolName, requestedPath}, "\x00")
+
+	d, hit := docCache.get(key)
+	if !hit {
+		p, err := loadPackage(ctx, pkgKey, importPath, pkgDir, examples)
+		if err != nil {
+			return nil, err
+		}
+		d, err = buildDoc(p.parsedPackage, importPath, symbolName, requestedPath, p.subs)
+		if err != nil {
+			return nil, err
+		}
+		docCache.add(key, d)
+	}
+	return d.clone(), nil
+}
+
+// clone returns a copy of d that shares no slices with it, so that callers can modify the
+// documentation they receive without corrupting the cached entry.
+func (d *Doc) clone() *Doc {
+	cp := *d
+	cp.Examples = slices.Clone(d.Examples)
+	cp.SubPackages = slices.Clone(d.SubPackages)
+	cp.Funcs = slices.Clone(d.Funcs)
+	cp.Types = slices.Clone(d.Types)
+	cp.Vars = slices.Clone(d.Vars)
+	cp.Consts = slices.Clone(d.Consts)
+	cp.References = slices.Clone(d.References)
+	return &cp
+}
+
+// loadPackage returns the parsed package behind packageCache.
+func loadPackage(ctx context.Context, key, importPath, pkgDir string, examples bool) (*cachedPackage, error) {
+	if p, hit := packageCache.get(key); hit {
+		return p, nil
+	}
+	parsed, err := parsePackage(importPath, pkgDir, examples)
+	if err != nil {
+		return nil, err
+	}
+	p := &cachedPackage{parsedPackage: parsed, subs: ListSubPackages(ctx, pkgDir)}
+	packageCache.add(key, p)
+	return p, nil
+}
+
+// dirStamp fingerprints a directory: its own modification time, which changes when entries are
+// added or removed, and the size and modification time of each file in it.
+func dirStamp(dir string) (string, bool) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d", info.ModTime().UnixNano())
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, "|%s:%d:%d", e.Name(), fi.Size(), fi.ModTime().UnixNano())
+	}
+	return sb.String(), true
+}
+
+// ResetCache empties the documentation cache, so the next lookups parse packages again. It is
+// used to measure cold lookups.
+func ResetCache() {
+	docCache.reset()
+	packageCache.reset()
+}
+
+// lru is a bounded, concurrency-safe map that evicts its least recently used entry when full.
+type lru[V any] struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // of *lruEntry[V], most recently used first
+	items map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRU[V any](max int) *lru[V] {
+	return &lru[V]{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the value for key and marks it as the most recently used.
+func (c *lru[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry[V]).value, true
+}
+
+// add stores value under key, evicting the least recently used entry if the cache is full.
+func (c *lru[V]) add(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key, value})
+}
+
+func (c *lru[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lru[V]) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
@@ -0,0 +1,122 @@
+package godoc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachedParse_Invalidation(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "p.go")
+	write := func(doc string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte("// "+doc+"\npackage p\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	write("Package p is first.", time.Unix(1000, 0))
+	d, err := cachedParse(ctx, "example.com/p", dir, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Description = "modified by the caller"
+	if d, _ := cachedParse(ctx, "example.com/p", dir, "", "", false); d.Description != "Package p is first.\n" {
+		t.Errorf("cached entry was modified through a returned copy: %q", d.Description)
+	}
+
+	write("Package p is second.", time.Unix(2000, 0))
+	if d, _ := cachedParse(ctx, "example.com/p", dir, "", "", false); d.Description != "Package p is second.\n" {
+		t.Errorf("expected the edit to invalidate the cache, got %q", d.Description)
+	}
+}
+
+func TestCachedParse_Package(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	dir := t.TempDir()
+	files := map[string]string{
+		"p.go":      "// Package p is small.\npackage p\n\n// A does a.\nfunc A() {}\n\n// B does b.\nfunc B() {}\n",
+		"p_test.go": "package p_test\n\nimport \"example.com/p\"\n\nfunc ExampleA() {\n\tp.A()\n\t// Output:\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	// Symbols of one package share a single parse.
+	for _, sym := range []string{"A", "B"} {
+		d, err := cachedParse(ctx, "example.com/p", dir, sym, "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Examples) != 0 {
+			t.Errorf("%s: examples extracted without being requested: %v", sym, d.Examples)
+		}
+	}
+	if n := packageCache.len(); n != 1 {
+		t.Errorf("package parsed into %d cache entries, want 1", n)
+	}
+
+	// Callers may modify the slices they receive.
+	d, err := cachedParse(ctx, "example.com/p", dir, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := slices.Clone(d.Funcs)
+	d.Funcs[0] = "modified by the caller"
+	if d, _ := cachedParse(ctx, "example.com/p", dir, "", "", false); !slices.Equal(d.Funcs, want) {
+		t.Errorf("cached entry was modified through a returned slice: %q", d.Funcs)
+	}
+
+	d, err = cachedParse(ctx, "example.com/p", dir, "A", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Examples) != 1 || d.Examples[0].Name != "A" || !strings.Contains(d.Examples[0].Code, "p.A()") {
+		t.Errorf("examples = %+v, want ExampleA", d.Examples)
+	}
+
+	// Test files are not parsed at all unless examples are requested.
+	if err := os.WriteFile(filepath.Join(dir, "broken_test.go"), []byte("package p_test\n\nfunc {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cachedParse(ctx, "example.com/p", dir, "", "", false); err != nil {
+		t.Errorf("a broken test file failed a lookup without examples: %v", err)
+	}
+	if _, err := cachedParse(ctx, "example.com/p", dir, "", "", true); err == nil {
+		t.Error("expected the broken test file to fail a lookup with examples")
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c := newLRU[int](2)
+	c.add("a", 1)
+	c.add("b", 2)
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	// b is now the least recently used entry.
+	c.add("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.get(key); !ok || got != want {
+			t.Errorf("get(%q) = %d, %v; want %d", key, got, ok, want)
+		}
+	}
+	if n := c.len(); n != 2 {
+		t.Errorf("len = %d, want 2", n)
+	}
+}
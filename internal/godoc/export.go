@@ -0,0 +1,344 @@
+package godoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/doc"
+	"go/doc/comment"
+	"html/template"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/writes"
+)
+
+// Export formats.
+const (
+	ExportMarkdown = "markdown"
+	ExportHTML     = "html"
+)
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	Dir          string // module directory
+	Out          string // output directory, created if missing
+	Format       string // ExportMarkdown (default) or ExportHTML
+	Dependencies bool   // also export the non-standard packages the module imports
+}
+
+// ExportResult summarizes an export.
+type ExportResult struct {
+	Module       string
+	Packages     []string // module packages, sorted
+	Dependencies []string // dependency packages, sorted
+	Files        int
+	Skipped      []string // packages whose documentation could not be parsed, with the reason
+}
+
+// listedPackage is the subset of `go list -json` output that Export needs.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	Name       string
+	Standard   bool
+	Module     *struct {
+		Path string
+		Main bool
+	}
+}
+
+// Export renders the documentation of every package in the module at opts.Dir, and optionally of
+// its dependencies, to a static tree under opts.Out: one index page per package at
+// <out>/<import path>/index.{md,html}, plus a top-level index. Pages are built with the same
+// parser that serves read_docs, so the published docs match what agents see.
+func Export(ctx context.Context, opts ExportOptions) (*ExportResult, error) {
+	if opts.Format == "" {
+		opts.Format = ExportMarkdown
+	}
+	if opts.Format != ExportMarkdown && opts.Format != ExportHTML {
+		return nil, fmt.Errorf("invalid format %q: must be '%s' or '%s'", opts.Format, ExportMarkdown, ExportHTML)
+	}
+	if opts.Out == "" {
+		return nil, errors.New("output directory cannot be empty")
+	}
+
+	pkgs, err := listPackages(ctx, opts.Dir, opts.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+	res := &ExportResult{}
+	for _, p := range pkgs {
+		if p.Module != nil && p.Module.Main {
+			res.Module = p.Module.Path
+			break
+		}
+	}
+
+	var paths []string
+	for _, p := range pkgs {
+		paths = append(paths, p.ImportPath)
+	}
+	sort.Strings(paths)
+
+	var entries []indexEntry
+	for _, p := range pkgs {
+		d, err := parseDir(p.ImportPath, p.Dir, "", "", subPackages(paths, p.ImportPath), true)
+		if err != nil {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("%s: %v", p.ImportPath, err))
+			continue
+		}
+		file := filepath.Join(opts.Out, filepath.FromSlash(p.ImportPath), "index."+ext(opts.Format))
+		var content []byte
+		if opts.Format == ExportHTML {
+			content, err = renderHTMLPage(d, subPackages(paths, p.ImportPath))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			content = []byte(Render(d))
+		}
+		if err := writeFile(ctx, file, content); err != nil {
+			return nil, err
+		}
+		res.Files++
+
+		dep := p.Module == nil || !p.Module.Main
+		if dep {
+			res.Dependencies = append(res.Dependencies, p.ImportPath)
+		} else {
+			res.Packages = append(res.Packages, p.ImportPath)
+		}
+		entries = append(entries, indexEntry{Path: p.ImportPath, Synopsis: new(doc.Package).Synopsis(d.Description), Dependency: dep})
+	}
+	sort.Strings(res.Packages)
+	sort.Strings(res.Dependencies)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	var index []byte
+	if opts.Format == ExportHTML {
+		index, err = renderHTMLIndex(res.Module, entries)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		index = renderMarkdownIndex(res.Module, entries)
+	}
+	if err := writeFile(ctx, filepath.Join(opts.Out, "index."+ext(opts.Format)), index); err != nil {
+		return nil, err
+	}
+	res.Files++
+	return res, nil
+}
+
+func listPackages(ctx context.Context, dir string, deps bool) ([]listedPackage, error) {
+	args := []string{"list", "-e", "-json=ImportPath,Dir,Name,Standard,Module"}
+	if deps {
+		args = append(args, "-deps")
+	}
+	args = append(args, "./...")
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	var pkgs []listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p listedPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		if p.Standard || p.Dir == "" {
+			continue
+		}
+		pkgs = append(pkgs, p)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages found in %s", dir)
+	}
+	return pkgs, nil
+}
+
+// subPackages returns the packages below importPath in paths, which must be sorted.
+func subPackages(paths []string, importPath string) []string {
+	var subs []string
+	for _, p := range paths {
+		if strings.HasPrefix(p, importPath+"/") {
+			subs = append(subs, p)
+		}
+	}
+	return subs
+}
+
+func ext(format string) string {
+	if format == ExportHTML {
+		return "html"
+	}
+	return "md"
+}
+
+func writeFile(ctx context.Context, name string, content []byte) error {
+	if err := writes.WriteFile(ctx, name, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+type indexEntry struct {
+	Path       string
+	Synopsis   string
+	Dependency bool
+}
+
+func renderMarkdownIndex(module string, entries []indexEntry) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "# %s API Documentation\n\n", module)
+	for _, dep := range []bool{false, true} {
+		var rows []indexEntry
+		for _, e := range entries {
+			if e.Dependency == dep {
+				rows = append(rows, e)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if dep {
+			buf.WriteString("## Dependencies\n\n")
+		} else {
+			buf.WriteString("## Packages\n\n")
+		}
+		buf.WriteString("| Package | Synopsis |\n| :--- | :--- |\n")
+		for _, e := range rows {
+			fmt.Fprintf(&buf, "| [%s](%s/index.md) | %s |\n", e.Path, e.Path, strings.ReplaceAll(e.Synopsis, "|", `\|`))
+		}
+		buf.WriteString("\n")
+	}
+	return []byte(buf.String())
+}
+
+var htmlTemplates = template.Must(template.New("").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 0.75rem; overflow-x: auto; }
+table { border-collapse: collapse; } td, th { padding: 0.25rem 0.75rem; text-align: left; border-bottom: 1px solid #ddd; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" .Title}}<h1>{{.Title}}</h1>
+{{range .Groups}}<h2>{{.Name}}</h2>
+<table>
+<tr><th>Package</th><th>Synopsis</th></tr>
+{{range .Entries}}<tr><td><a href="{{.Path}}/index.html">{{.Path}}</a></td><td>{{.Synopsis}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+{{end}}
+
+{{define "package"}}{{template "head" .Doc.ImportPath}}<nav><a href="{{.Root}}index.html">Index</a></nav>
+<h1>{{.Doc.ImportPath}}</h1>
+<pre><code>{{.Doc.Definition}}</code></pre>
+{{.Description}}
+{{- with .Doc.Examples}}<h2>Examples</h2>
+{{range .}}<h3>{{or .Name "Package Example"}}</h3>
+<pre><code>{{.Code}}</code></pre>
+{{if .Output}}<p><strong>Output:</strong></p>
+<pre>{{.Output}}</pre>
+{{end}}{{end}}{{end}}
+{{- with .Doc.Consts}}<h2>Constants</h2>
+{{range .}}<pre><code>{{.}}</code></pre>
+{{end}}{{end}}
+{{- with .Doc.Vars}}<h2>Variables</h2>
+{{range .}}<pre><code>{{.}}</code></pre>
+{{end}}{{end}}
+{{- with .Doc.Funcs}}<h2>Functions</h2>
+{{range .}}<pre><code>{{.}}</code></pre>
+{{end}}{{end}}
+{{- with .Doc.Types}}<h2>Types</h2>
+{{range .}}<pre><code>{{.}}</code></pre>
+{{end}}{{end}}
+{{- with .Subs}}<h2>Sub-packages</h2>
+<ul>
+{{range .}}<li><a href="{{.Href}}">{{.Path}}</a></li>
+{{end}}</ul>
+{{end -}}
+</body>
+</html>
+{{end}}
+`))
+
+func renderHTMLIndex(module string, entries []indexEntry) ([]byte, error) {
+	type group struct {
+		Name    string
+		Entries []indexEntry
+	}
+	var pkgs, deps []indexEntry
+	for _, e := range entries {
+		if e.Dependency {
+			deps = append(deps, e)
+		} else {
+			pkgs = append(pkgs, e)
+		}
+	}
+	data := struct {
+		Title  string
+		Groups []group
+	}{Title: module + " API Documentation"}
+	if len(pkgs) > 0 {
+		data.Groups = append(data.Groups, group{"Packages", pkgs})
+	}
+	if len(deps) > 0 {
+		data.Groups = append(data.Groups, group{"Dependencies", deps})
+	}
+	var buf bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&buf, "index", data); err != nil {
+		return nil, fmt.Errorf("failed to render index: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderHTMLPage(d *Doc, subs []string) ([]byte, error) {
+	type link struct{ Path, Href string }
+	var p comment.Parser
+	var pr comment.Printer
+	pr.HeadingLevel = 2
+	data := struct {
+		Doc         *Doc
+		Root        string
+		Description template.HTML
+		Subs        []link
+	}{
+		Doc:         d,
+		Root:        strings.Repeat("../", strings.Count(d.ImportPath, "/")+1),
+		Description: template.HTML(pr.HTML(p.Parse(d.Description))), //nolint:gosec // escaped by go/doc/comment
+	}
+	for _, sub := range subs {
+		rel := strings.TrimPrefix(sub, d.ImportPath+"/")
+		data.Subs = append(data.Subs, link{Path: sub, Href: path.Join(rel, "index.html")})
+	}
+	var buf bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&buf, "package", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", d.ImportPath, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package godoc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+)
+
+func TestExport(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":           testutil.GoMod("example.com/shop"),
+		"shop.go":          "// Package shop sells things.\npackage shop\n\n// Price returns the price of an item.\nfunc Price(item string) int { return 1 }\n",
+		"cart/cart.go":     "// Package cart holds <items> before checkout.\npackage cart\n\n// Cart is a shopping cart.\ntype Cart struct{ Items []string }\n",
+		"cmd/shop/main.go": "// Command shop runs the shop.\npackage main\n\nfunc main() {}\n",
+	})
+
+	for _, format := range []string{ExportMarkdown, ExportHTML} {
+		t.Run(format, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "api")
+			res, err := Export(context.Background(), ExportOptions{Dir: dir, Out: out, Format: format})
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			if res.Module != "example.com/shop" || len(res.Packages) != 3 || res.Files != 4 {
+				t.Errorf("unexpected result: %+v", res)
+			}
+
+			ext := "md"
+			if format == ExportHTML {
+				ext = "html"
+			}
+			read := func(rel string) string {
+				data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(data)
+			}
+
+			index := read("index." + ext)
+			page := read("example.com/shop/cart/index." + ext)
+			root := read("example.com/shop/index." + ext)
+			var want map[string][]string
+			if format == ExportHTML {
+				want = map[string][]string{
+					index: {`<a href="example.com/shop/cart/index.html">example.com/shop/cart</a>`, "Package cart holds &lt;items&gt; before checkout."},
+					page:  {`<a href="../../../index.html">Index</a>`, "<p>Package cart holds &lt;items&gt; before checkout.\n", "type Cart struct"},
+					root:  {`<a href="cart/index.html">example.com/shop/cart</a>`, "func Price(item string) int"},
+				}
+			} else {
+				want = map[string][]string{
+					index: {"# example.com/shop API Documentation", "| [example.com/shop/cart](example.com/shop/cart/index.md) | Package cart holds <items> before checkout. |"},
+					page:  {"# example.com/shop/cart", "type Cart struct"},
+					root:  {"func Price(item string) int", "- example.com/shop/cart"},
+				}
+			}
+			for content, subs := range want {
+				for _, s := range subs {
+					if !strings.Contains(content, s) {
+						t.Errorf("expected %q in:\n%s", s, content)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExport_InvalidFormat(t *testing.T) {
+	if _, err := Export(context.Background(), ExportOptions{Dir: ".", Out: t.TempDir(), Format: "pdf"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
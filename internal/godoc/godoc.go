@@ -27,30 +27,34 @@ import (
 	"go/token"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/danicat/godoctor/internal/textdist"
 	"golang.org/x/tools/go/packages"
 )
 
-// Load resolves an import path and returns documentation.
+// Load resolves an import path and returns documentation without examples.
 // It performs disk I/O ("go list") and parsing. Use this when starting from a string path.
 func Load(ctx context.Context, pkgPath, symbolName string) (*Doc, error) {
-	return loadInternal(ctx, pkgPath, symbolName, false)
+	return loadInternal(ctx, pkgPath, symbolName, false, false)
 }
 
 // LoadWithFallback is like Load but attempts to find parent packages if the exact match fails.
-func LoadWithFallback(ctx context.Context, pkgPath, symbolName string) (*Doc, error) {
-	return loadInternal(ctx, pkgPath, symbolName, true)
+// Examples live in test files, which are only parsed if examples is true.
+func LoadWithFallback(ctx context.Context, pkgPath, symbolName string, examples bool) (*Doc, error) {
+	return loadInternal(ctx, pkgPath, symbolName, true, examples)
 }
 
-func loadInternal(ctx context.Context, pkgPath, symbolName string, allowFallback bool) (*Doc, error) {
+func loadInternal(ctx context.Context, pkgPath, symbolName string, allowFallback, examples bool) (*Doc, error) {
 	// Try to find the package directory locally
 	pkgDir, err := resolvePackageDir(ctx, pkgPath)
 	if err != nil {
 		// Fallback: try to fetch the package in a temp directory
-		doc, fetchErr := fetchAndRetryStructured(ctx, pkgPath, symbolName, err)
+		doc, fetchErr := fetchAndRetryStructured(ctx, pkgPath, symbolName, err, examples)
 		if fetchErr == nil {
 			return doc, nil
 		}
@@ -65,7 +69,7 @@ func loadInternal(ctx context.Context, pkgPath, symbolName string, allowFallback
 
 			for i := len(parts) - 1; i >= minParts; i-- {
 				parentPath := strings.Join(parts[:i], "/")
-				if doc, err := loadInternal(ctx, parentPath, "", false); err == nil {
+				if doc, err := loadInternal(ctx, parentPath, "", false, examples); err == nil {
 					doc.ResolvedPath = pkgPath
 					return doc, nil
 				}
@@ -74,7 +78,7 @@ func loadInternal(ctx context.Context, pkgPath, symbolName string, allowFallback
 		return nil, fetchErr
 	}
 
-	result, err := parsePackageDocs(ctx, pkgPath, pkgDir, symbolName, pkgPath)
+	result, err := cachedParse(ctx, pkgPath, pkgDir, symbolName, pkgPath, examples)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse documentation: %w", err)
 	}
@@ -207,22 +211,77 @@ func resolvePackageDir(ctx context.Context, pkgPath string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-func parsePackageDocs(ctx context.Context, importPath, pkgDir, symbolName, requestedPath string) (*Doc, error) {
-	fset := token.NewFileSet()
-	//nolint:staticcheck // SA1019: parser.ParseDir is used for fast parsing of comments without type-checking
-	pkgs, err := parser.ParseDir(fset, pkgDir, nil, parser.ParseComments)
+func parsePackageDocs(ctx context.Context, importPath, pkgDir, symbolName, requestedPath string, examples bool) (*Doc, error) {
+	return parseDir(importPath, pkgDir, symbolName, requestedPath, ListSubPackages(ctx, pkgDir), examples)
+}
+
+// parseDir builds the documentation of the package in pkgDir. subs lists the packages below it,
+// which may include importPath itself. Examples are only extracted if examples is true.
+func parseDir(importPath, pkgDir, symbolName, requestedPath string, subs []string, examples bool) (*Doc, error) {
+	p, err := parsePackage(importPath, pkgDir, examples)
 	if err != nil {
-		return nil, fmt.Errorf("parser.ParseDir failed: %w", err)
+		return nil, err
 	}
+	return buildDoc(p, importPath, symbolName, requestedPath, subs)
+}
 
-	// Collect all files from all packages (e.g. "http" and "http_test")
-	var files []*ast.File
-	for _, pkg := range pkgs {
-		for _, file := range pkg.Files {
-			files = append(files, file)
+// parsedPackage is the computed documentation of a package directory, shared read-only by every
+// lookup of the package. pkg is nil if the directory has no Go files.
+type parsedPackage struct {
+	fset *token.FileSet
+	pkg  *doc.Package
+}
+
+// parsePackage parses the Go files of pkgDir concurrently and computes their documentation.
+// Test files only contribute examples, so they are skipped unless examples is true.
+func parsePackage(importPath, pkgDir string, examples bool) (*parsedPackage, error) {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading package directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || (!examples && strings.HasSuffix(name, "_test.go")) {
+			continue
 		}
+		names = append(names, name)
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, len(names))
+	errs := make([]error, len(names))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			files[i], errs[i] = parser.ParseFile(fset, filepath.Join(pkgDir, name), nil, parser.ParseComments)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("parsing package %s: %w", importPath, err)
+		}
+	}
+
+	p := &parsedPackage{fset: fset}
+	if len(files) == 0 {
+		return p, nil
 	}
+	// Files of every package in the directory (e.g. "http" and "http_test") are merged.
+	p.pkg, err = doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("doc.NewFromFiles failed: %w", err)
+	}
+	return p, nil
+}
 
+// buildDoc extracts the documentation of the package, or of one of its symbols, from p.
+func buildDoc(p *parsedPackage, importPath, symbolName, requestedPath string, subs []string) (*Doc, error) {
+	fset, targetPkg := p.fset, p.pkg
 	result := &Doc{
 		ImportPath:  importPath,
 		PkgGoDevURL: fmt.Sprintf("https://pkg.go.dev/%s", importPath),
@@ -232,15 +291,13 @@ func parsePackageDocs(ctx context.Context, importPath, pkgDir, symbolName, reque
 		result.ResolvedPath = requestedPath
 	}
 
-	// Always look for sub-packages
-	subs := ListSubPackages(ctx, pkgDir)
 	for _, sub := range subs {
 		if sub != importPath { // Exclude self
 			result.SubPackages = append(result.SubPackages, sub)
 		}
 	}
 
-	if len(files) == 0 {
+	if targetPkg == nil {
 		// If no files found, but we have subpackages, return a module overview
 		if len(result.SubPackages) > 0 {
 			result.Package = "module_root"
@@ -251,12 +308,6 @@ func parsePackageDocs(ctx context.Context, importPath, pkgDir, symbolName, reque
 		return nil, fmt.Errorf("no files found in package %s", importPath)
 	}
 
-	// Compute documentation using all files
-	targetPkg, err := doc.NewFromFiles(fset, files, importPath)
-	if err != nil {
-		return nil, fmt.Errorf("doc.NewFromFiles failed: %w", err)
-	}
-
 	pkgName := targetPkg.Name
 	if pkgName == "" {
 		parts := strings.Split(importPath, "/")
@@ -608,16 +659,8 @@ func findFuzzyMatches(query string, candidates []string) []string {
 	return matches
 }
 
-func fetchAndRetryStructured(ctx context.Context, pkgPath, symbolName string, originalErr error) (*Doc, error) {
-	tempDir, err := setupTempModule(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to setup temp module: %w", err)
-	}
-	defer func() {
-		_ = os.RemoveAll(tempDir)
-	}()
-
-	pkgDir, actualPkgPath, err := downloadPackage(ctx, tempDir, pkgPath)
+func fetchAndRetryStructured(ctx context.Context, pkgPath, symbolName string, originalErr error, examples bool) (*Doc, error) {
+	pkgDir, actualPkgPath, err := scratch.fetch(ctx, pkgPath)
 	if err != nil {
 		// Attempt to provide suggestions from standard library and local context
 		suggestions := suggestPackages(ctx, pkgPath)
@@ -630,7 +673,7 @@ func fetchAndRetryStructured(ctx context.Context, pkgPath, symbolName string, or
 			pkgPath, err, originalErr)
 	}
 
-	result, err := parsePackageDocs(ctx, actualPkgPath, pkgDir, symbolName, pkgPath)
+	result, err := cachedParse(ctx, actualPkgPath, pkgDir, symbolName, pkgPath, examples)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse documentation after download: %w", err)
 	}
@@ -690,6 +733,7 @@ func ListSubPackages(ctx context.Context, pkgDir string) []string {
 	return strings.Split(trimmed, "\n")
 }
 
+// setupTempModule creates an empty module to fetch packages into.
 func setupTempModule(ctx context.Context) (string, error) {
 	tempDir, err := os.MkdirTemp("", "godoctor_docs_*")
 	if err != nil {
@@ -707,9 +751,19 @@ func setupTempModule(ctx context.Context) (string, error) {
 
 var vanityImportRe = regexp.MustCompile(`module declares its path as:\s+([^\s]+)`)
 
-func downloadPackage(ctx context.Context, tempDir, pkgPath string) (string, string, error) {
-	getCmd := exec.CommandContext(ctx, "go", "get", pkgPath)
-	getCmd.Dir = tempDir
+// downloadPackage fetches pkgPath into the module in moduleDir with go get. The version is
+// resolved in a private copy of go.mod, so that concurrent downloads do not race on the shared one,
+// and the result is merged back afterwards.
+func downloadPackage(ctx context.Context, moduleDir, pkgPath string) (string, string, error) {
+	modfile, err := copyModFile(moduleDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to copy go.mod: %w", err)
+	}
+	defer removeModFile(modfile)
+	modFlag := "-modfile=" + modfile
+
+	getCmd := exec.CommandContext(ctx, "go", "get", modFlag, pkgPath)
+	getCmd.Dir = moduleDir
 	out, err := getCmd.CombinedOutput()
 
 	actualPath := pkgPath
@@ -721,8 +775,8 @@ func downloadPackage(ctx context.Context, tempDir, pkgPath string) (string, stri
 			actualPath = string(matches[1])
 			// Retry with correct path
 			//nolint:gosec // G204: Subprocess launched with variable is expected behavior.
-			retryCmd := exec.CommandContext(ctx, "go", "get", actualPath)
-			retryCmd.Dir = tempDir
+			retryCmd := exec.CommandContext(ctx, "go", "get", modFlag, actualPath)
+			retryCmd.Dir = moduleDir
 			if retryOut, retryErr := retryCmd.CombinedOutput(); retryErr != nil {
 				return "", "", fmt.Errorf("go get failed after vanity retry: %v\nOutput: %s", retryErr, retryOut)
 			}
@@ -731,11 +785,14 @@ func downloadPackage(ctx context.Context, tempDir, pkgPath string) (string, stri
 			return "", "", fmt.Errorf("go get failed: %v\nOutput: %s", err, out)
 		}
 	}
+	// The package is in the module cache either way; a failed merge only means that later
+	// downloads resolve their versions without it.
+	_ = mergeModFile(ctx, moduleDir, modfile)
 
 	// Try to locate as a package first
 	//nolint:gosec // G204: Subprocess launched with variable is expected behavior.
-	listCmd := exec.CommandContext(ctx, "go", "list", "-f", "{{.Dir}}", actualPath)
-	listCmd.Dir = tempDir
+	listCmd := exec.CommandContext(ctx, "go", "list", modFlag, "-f", "{{.Dir}}", actualPath)
+	listCmd.Dir = moduleDir
 	out, err = listCmd.CombinedOutput()
 	if err == nil {
 		return strings.TrimSpace(string(out)), actualPath, nil
@@ -743,8 +800,8 @@ func downloadPackage(ctx context.Context, tempDir, pkgPath string) (string, stri
 
 	// If failed, try to locate as a module (e.g. root of repo with no root package files)
 	//nolint:gosec // G204: Subprocess launched with variable is expected behavior.
-	modCmd := exec.CommandContext(ctx, "go", "list", "-m", "-f", "{{.Dir}}", actualPath)
-	modCmd.Dir = tempDir
+	modCmd := exec.CommandContext(ctx, "go", "list", modFlag, "-m", "-f", "{{.Dir}}", actualPath)
+	modCmd.Dir = moduleDir
 	out, err = modCmd.CombinedOutput()
 	if err != nil {
 		return "", "", fmt.Errorf("failed to locate package or module: %v\nOutput: %s", err, out)
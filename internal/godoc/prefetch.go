@@ -0,0 +1,216 @@
+package godoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// prefetchWorkers bounds how many packages are parsed at once.
+const prefetchWorkers = 8
+
+// ImportDoc summarizes the documentation of one import of a file.
+type ImportDoc struct {
+	ImportPath string   `json:"importPath"`
+	Package    string   `json:"package,omitempty"`
+	Synopsis   string   `json:"synopsis,omitempty"`
+	Used       []string `json:"used,omitempty"` // declarations of the symbols the file refers to
+	Error      string   `json:"error,omitempty"`
+}
+
+// Prefetch loads the documentation of every package imported by the Go file at filename,
+// concurrently, and returns a summary per import in import order. Packages are resolved in the
+// file's own module, and the parsed documentation stays cached, so later lookups of these packages
+// (e.g. through read_docs) do not parse them again.
+func Prefetch(ctx context.Context, filename string) ([]ImportDoc, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	var paths []string
+	names := make(map[string]string) // import path to its explicit local name
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || p == "C" {
+			continue
+		}
+		paths = append(paths, p)
+		if spec.Name != nil {
+			names[p] = spec.Name.Name
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	used := usedSelectors(file)
+	pkgs := listPackageDirs(ctx, filepath.Dir(filename), paths)
+
+	out := make([]ImportDoc, len(paths))
+	sem := make(chan struct{}, prefetchWorkers)
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out[i] = prefetchOne(ctx, p, pkgs[p], names[p], used)
+		}()
+	}
+	wg.Wait()
+	return out, nil
+}
+
+func prefetchOne(ctx context.Context, importPath string, pkg listedDir, localName string, used map[string][]string) ImportDoc {
+	res := ImportDoc{ImportPath: importPath}
+	dir := pkg.Dir
+	var d *Doc
+	var err error
+	if dir != "" {
+		d, err = cachedParse(ctx, importPath, dir, "", importPath, false)
+	} else {
+		d, err = Load(ctx, importPath, "")
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	// go list knows the real package name; parsing the directory can be misled by ignored files
+	// such as a "package main" generator.
+	res.Package = pkg.Name
+	if res.Package == "" {
+		res.Package = d.Package
+	}
+	res.Synopsis = new(doc.Package).Synopsis(d.Description)
+
+	if localName == "" {
+		localName = res.Package
+	}
+	if localName == "_" || localName == "." {
+		return res
+	}
+	for _, sym := range used[localName] {
+		decl := d.declaration(sym)
+		if decl == "" && dir != "" {
+			// Constructors are listed under their type rather than in Funcs; look them up directly.
+			if sd, err := cachedParse(ctx, importPath, dir, sym, importPath, false); err == nil {
+				decl = firstLine(sd.Definition)
+			}
+		}
+		if decl == "" {
+			decl = sym
+		}
+		res.Used = append(res.Used, decl)
+	}
+	return res
+}
+
+// usedSelectors returns, per package name, the sorted exported names the file selects from it.
+// Only selectors on identifiers that do not resolve to a local declaration are counted, which are
+// the ones that can refer to an import.
+func usedSelectors(file *ast.File) map[string][]string {
+	seen := make(map[string]map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		id, ok := sel.X.(*ast.Ident)
+		if !ok || id.Obj != nil || !sel.Sel.IsExported() {
+			return true
+		}
+		if seen[id.Name] == nil {
+			seen[id.Name] = make(map[string]bool)
+		}
+		seen[id.Name][sel.Sel.Name] = true
+		return true
+	})
+	out := make(map[string][]string, len(seen))
+	for pkg, syms := range seen {
+		for s := range syms {
+			out[pkg] = append(out[pkg], s)
+		}
+		sort.Strings(out[pkg])
+	}
+	return out
+}
+
+type listedDir struct{ Dir, Name string }
+
+// listPackageDirs resolves the directories and names of import paths with a single go list in
+// dir. Paths that cannot be resolved are left out.
+func listPackageDirs(ctx context.Context, dir string, paths []string) map[string]listedDir {
+	args := append([]string{"list", "-e", "-json=ImportPath,Dir,Name"}, paths...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	out, _ := cmd.Output()
+	pkgs := make(map[string]listedDir)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p struct{ ImportPath, Dir, Name string }
+		if err := dec.Decode(&p); err != nil {
+			break
+		}
+		if p.Dir != "" {
+			pkgs[p.ImportPath] = listedDir{Dir: p.Dir, Name: p.Name}
+		}
+	}
+	return pkgs
+}
+
+// declaration returns the declaration of an exported package-level name from the symbol lists,
+// shortened to its first line, or "" if it is not listed.
+func (d *Doc) declaration(name string) string {
+	for _, f := range d.Funcs {
+		if strings.HasPrefix(f, "func "+name+"(") || strings.HasPrefix(f, "func "+name+"[") {
+			return firstLine(f)
+		}
+	}
+	for _, t := range d.Types {
+		if strings.HasPrefix(t, "type "+name+" ") || strings.HasPrefix(t, "type "+name+"[") {
+			return firstLine(t)
+		}
+	}
+	re := regexp.MustCompile(`^\s*(?:(?:var|const)\s+)?` + regexp.QuoteMeta(name) + `\b`)
+	for _, group := range append(append([]string(nil), d.Vars...), d.Consts...) {
+		fields := strings.Fields(group)
+		if len(fields) == 0 {
+			continue
+		}
+		kind := fields[0]
+		for _, line := range strings.Split(group, "\n") {
+			if re.MatchString(line) {
+				// Collapse the alignment padding of grouped declarations.
+				line = strings.Join(strings.Fields(line), " ")
+				if !strings.HasPrefix(line, kind+" ") {
+					line = kind + " " + line
+				}
+				return line
+			}
+		}
+	}
+	return ""
+}
+
+// firstLine returns the first line of a declaration, eliding the body of a multi-line one.
+func firstLine(decl string) string {
+	line, rest, multi := strings.Cut(decl, "\n")
+	if multi && strings.TrimSpace(rest) != "" && strings.HasSuffix(line, "{") {
+		return line + " ... }"
+	}
+	return line
+}
@@ -0,0 +1,65 @@
+package godoc
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+)
+
+func TestPrefetch(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"net/http"
+	str "strconv"
+)
+
+func main() {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	fmt.Println(req.URL, str.Itoa(1))
+}
+`
+	dir := testutil.WriteModule(t, map[string]string{"go.mod": testutil.GoMod("example.com/app"), "main.go": src})
+
+	docs, err := Prefetch(context.Background(), filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 imports, got %d: %+v", len(docs), docs)
+	}
+	want := []struct {
+		path, synopsis string
+		used           []string
+	}{
+		{"fmt", "Package fmt implements formatted I/O", []string{"func Println(a ...any) (n int, err error)"}},
+		{"net/http", "Package http provides HTTP client and server implementations.", []string{
+			`const MethodGet = "GET"`,
+			"func NewRequest(method, url string, body io.Reader) (*Request, error)",
+		}},
+		{"strconv", "Package strconv implements conversions", []string{"func Itoa(i int) string"}},
+	}
+	for i, w := range want {
+		d := docs[i]
+		if d.Error != "" {
+			t.Errorf("%s: unexpected error %s", w.path, d.Error)
+			continue
+		}
+		if d.ImportPath != w.path || !strings.HasPrefix(d.Synopsis, w.synopsis) {
+			t.Errorf("got %s %q, want %s %q", d.ImportPath, d.Synopsis, w.path, w.synopsis)
+		}
+		if len(d.Used) != len(w.used) {
+			t.Errorf("%s: used = %q, want %q", w.path, d.Used, w.used)
+			continue
+		}
+		for j := range w.used {
+			if !strings.Contains(d.Used[j], w.used[j]) {
+				t.Errorf("%s: used[%d] = %q, want it to contain %q", w.path, j, d.Used[j], w.used[j])
+			}
+		}
+	}
+}
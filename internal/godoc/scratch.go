@@ -0,0 +1,257 @@
+package godoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// scratchMaxAge bounds the life of the scratch module, so that later lookups of a package
+	// eventually see its newer versions.
+	scratchMaxAge = time.Hour
+	// scratchMaxPackages bounds how many packages are fetched into the scratch module before it is
+	// recreated, which keeps its go.mod, and go get's version resolution, small.
+	scratchMaxPackages = 100
+	// scratchIdle is how long the scratch module is kept without lookups.
+	scratchIdle = 10 * time.Minute
+)
+
+// scratchModule is the module external packages are fetched into so that their documentation can
+// be read from the module cache. It is shared by every lookup: its go.mod accumulates the fetched
+// packages, and a package fetched once is found again without running go mod init or go get.
+// The lock only guards this bookkeeping: downloads of different packages run concurrently, and
+// concurrent lookups of the same package share one download.
+type scratchModule struct {
+	mu   sync.Mutex
+	cur  *scratchDir // nil until the first fetch and after removal
+	idle *time.Timer
+}
+
+// scratchDir is one generation of the scratch module.
+type scratchDir struct {
+	dir     string
+	created time.Time
+	fetched map[string]fetchedPackage // by requested import path
+	pending map[string]*pendingFetch  // downloads in progress, by requested import path
+	removed bool                      // replaced or idle; deleted once its last download ends
+}
+
+// fetchedPackage is where a fetched package was found.
+type fetchedPackage struct {
+	dir  string // in the module cache
+	path string // actual import path, which differs from the requested one for vanity imports
+}
+
+// pendingFetch is a download in progress; done is closed once its result is set.
+type pendingFetch struct {
+	done chan struct{}
+	pkg  fetchedPackage
+	err  error
+}
+
+var scratch = &scratchModule{}
+
+// download fetches a package into a module; a variable so that tests can stub the network out.
+var download = downloadPackage
+
+// fetch makes pkgPath available in the scratch module and returns its directory and its actual
+// import path.
+func (s *scratchModule) fetch(ctx context.Context, pkgPath string) (string, string, error) {
+	s.mu.Lock()
+	if s.idle == nil {
+		s.idle = time.AfterFunc(scratchIdle, s.remove)
+	} else {
+		s.idle.Reset(scratchIdle)
+	}
+	if s.cur != nil && (time.Since(s.cur.created) > scratchMaxAge || len(s.cur.fetched) >= scratchMaxPackages) {
+		s.removeLocked()
+	}
+
+	if s.cur != nil {
+		if p, ok := s.cur.fetched[pkgPath]; ok {
+			// The module cache may have been cleaned since.
+			if _, err := os.Stat(p.dir); err == nil {
+				s.mu.Unlock()
+				return p.dir, p.path, nil
+			}
+		}
+		if p, ok := s.cur.pending[pkgPath]; ok {
+			s.mu.Unlock()
+			select {
+			case <-p.done:
+				return p.pkg.dir, p.pkg.path, p.err
+			case <-ctx.Done():
+				return "", "", ctx.Err()
+			}
+		}
+	}
+	if s.cur == nil {
+		dir, err := setupTempModule(ctx)
+		if err != nil {
+			s.mu.Unlock()
+			return "", "", fmt.Errorf("failed to setup temp module: %w", err)
+		}
+		s.cur = &scratchDir{
+			dir:     dir,
+			created: time.Now(),
+			fetched: make(map[string]fetchedPackage),
+			pending: make(map[string]*pendingFetch),
+		}
+	}
+	g, p := s.cur, &pendingFetch{done: make(chan struct{})}
+	g.pending[pkgPath] = p
+	s.mu.Unlock()
+
+	p.pkg.dir, p.pkg.path, p.err = download(ctx, g.dir, pkgPath)
+
+	s.mu.Lock()
+	delete(g.pending, pkgPath)
+	if p.err == nil && !g.removed {
+		g.fetched[pkgPath] = p.pkg
+	}
+	if g.removed && len(g.pending) == 0 {
+		_ = os.RemoveAll(g.dir)
+	}
+	s.mu.Unlock()
+	close(p.done)
+	return p.pkg.dir, p.pkg.path, p.err
+}
+
+// remove deletes the scratch module; the next fetch creates a new one.
+func (s *scratchModule) remove() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked()
+}
+
+// removeLocked drops the current scratch module. Its directory is deleted now, or by the last of
+// the downloads still running in it.
+func (s *scratchModule) removeLocked() {
+	if s.cur == nil {
+		return
+	}
+	s.cur.removed = true
+	if len(s.cur.pending) == 0 {
+		_ = os.RemoveAll(s.cur.dir)
+	}
+	s.cur = nil
+}
+
+// Cleanup deletes the scratch module external packages are fetched into. The server calls it on
+// shutdown, since the idle timer never fires once the process exits.
+func Cleanup() {
+	scratch.mu.Lock()
+	defer scratch.mu.Unlock()
+	if scratch.idle != nil {
+		scratch.idle.Stop()
+	}
+	scratch.removeLocked()
+}
+
+// goModMu serializes the reads and updates of scratch go.mod files. The go get runs themselves
+// work on private copies and are not serialized.
+var goModMu sync.Mutex
+
+// copyModFile copies the go.mod and go.sum of the module in dir to a private pair of files in dir
+// and returns the path of the go.mod copy, to be passed to go commands with -modfile.
+func copyModFile(dir string) (string, error) {
+	goModMu.Lock()
+	defer goModMu.Unlock()
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	sum, err := os.ReadFile(filepath.Join(dir, "go.sum"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "fetch_*.mod")
+	if err != nil {
+		return "", err
+	}
+	modfile := f.Name()
+	_, err = f.Write(mod)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.WriteFile(sumFile(modfile), sum, 0644)
+	}
+	if err != nil {
+		removeModFile(modfile)
+		return "", err
+	}
+	return modfile, nil
+}
+
+// mergeModFile adds the requirements and checksums of the private modfile to the go.mod and go.sum
+// of the module in dir. It only edits files: the modules are already in the module cache.
+func mergeModFile(ctx context.Context, dir, modfile string) error {
+	cmd := exec.CommandContext(ctx, "go", "mod", "edit", "-json", "-modfile="+modfile)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", modfile, err)
+	}
+	var mf struct {
+		Require []struct{ Path, Version string }
+	}
+	if err := json.Unmarshal(out, &mf); err != nil {
+		return fmt.Errorf("failed to read %s: %w", modfile, err)
+	}
+	if len(mf.Require) == 0 {
+		return nil
+	}
+	sum, err := os.ReadFile(sumFile(modfile))
+	if err != nil {
+		return err
+	}
+
+	goModMu.Lock()
+	defer goModMu.Unlock()
+	args := []string{"mod", "edit"}
+	for _, r := range mf.Require {
+		args = append(args, "-require="+r.Path+"@"+r.Version)
+	}
+	cmd = exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to update go.mod: %v\nOutput: %s", err, out)
+	}
+
+	goSum := filepath.Join(dir, "go.sum")
+	have, err := os.ReadFile(goSum)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, line := range strings.Split(string(have), "\n") {
+		known[line] = true
+	}
+	merged := bytes.Clone(have)
+	for _, line := range strings.Split(string(sum), "\n") {
+		if line != "" && !known[line] {
+			merged = append(merged, line+"\n"...)
+			known[line] = true
+		}
+	}
+	return os.WriteFile(goSum, merged, 0644)
+}
+
+// sumFile returns the go.sum that goes with a -modfile.
+func sumFile(modfile string) string {
+	return strings.TrimSuffix(modfile, ".mod") + ".sum"
+}
+
+func removeModFile(modfile string) {
+	_ = os.Remove(modfile)
+	_ = os.Remove(sumFile(modfile))
+}
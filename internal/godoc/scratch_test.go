@@ -0,0 +1,172 @@
+package godoc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danicat/godoctor/internal/testutil"
+)
+
+func TestScratchModule(t *testing.T) {
+	pkgDir := t.TempDir()
+	var gets []string
+	var modules []string
+	oldDownload := download
+	defer func() { download = oldDownload }()
+	download = func(ctx context.Context, moduleDir, pkgPath string) (string, string, error) {
+		gets = append(gets, pkgPath)
+		modules = append(modules, moduleDir)
+		if pkgPath == "example.com/missing" {
+			return "", "", fmt.Errorf("go get failed")
+		}
+		return pkgDir, pkgPath, nil
+	}
+
+	s := &scratchModule{}
+	defer s.remove()
+	ctx := context.Background()
+	for range 3 {
+		dir, path, err := s.fetch(ctx, "example.com/lib")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != pkgDir || path != "example.com/lib" {
+			t.Errorf("fetch() = %q, %q", dir, path)
+		}
+	}
+	if len(gets) != 1 {
+		t.Errorf("a package already fetched was fetched again: %v", gets)
+	}
+	if _, err := os.Stat(filepath.Join(s.cur.dir, "go.mod")); err != nil {
+		t.Errorf("scratch module has no go.mod: %v", err)
+	}
+
+	// Failures are not remembered.
+	for range 2 {
+		if _, _, err := s.fetch(ctx, "example.com/missing"); err == nil {
+			t.Error("expected the failed fetch to fail")
+		}
+	}
+	if len(gets) != 3 || modules[0] != modules[2] {
+		t.Errorf("fetches = %v in %v, want two more in the same module", gets, modules)
+	}
+
+	// A full module is recreated.
+	first := s.cur.dir
+	for i := range scratchMaxPackages {
+		if _, _, err := s.fetch(ctx, fmt.Sprintf("example.com/lib%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.cur.dir == first {
+		t.Error("the scratch module was not recreated after filling up")
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("the old scratch module was not removed: %v", err)
+	}
+}
+
+func TestScratchModule_Concurrent(t *testing.T) {
+	pkgDir := t.TempDir()
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	gets := make(map[string]int)
+	oldDownload := download
+	defer func() { download = oldDownload }()
+	download = func(ctx context.Context, moduleDir, pkgPath string) (string, string, error) {
+		mu.Lock()
+		gets[pkgPath]++
+		mu.Unlock()
+		started <- pkgPath
+		<-release
+		return pkgDir, pkgPath, nil
+	}
+
+	s := &scratchModule{}
+	defer s.remove()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, pkg := range []string{"example.com/a", "example.com/b", "example.com/a"} {
+		wg.Go(func() {
+			if _, path, err := s.fetch(ctx, pkg); err != nil || path != pkg {
+				t.Errorf("fetch(%s) = %q, %v", pkg, path, err)
+			}
+		})
+	}
+	// Both packages download at the same time: neither waits for the other.
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("downloads of different packages do not run concurrently")
+		}
+	}
+	// The scratch module is removed while downloading; its directory outlives the downloads.
+	dir := s.cur.dir
+	s.remove()
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("the scratch module was removed under running downloads: %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if gets["example.com/a"] != 1 || gets["example.com/b"] != 1 {
+		t.Errorf("downloads = %v, want one per package", gets)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the removed scratch module was not deleted after its downloads: %v", err)
+	}
+}
+
+func TestMergeModFile(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": "module temp_docs_fetcher\n\ngo 1.22\n\nrequire example.com/a v1.0.0\n",
+		"go.sum": "example.com/a v1.0.0 h1:a=\n",
+	})
+	modfile, err := copyModFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeModFile(modfile)
+	testutil.WriteFiles(t, dir, map[string]string{
+		filepath.Base(modfile):          "module temp_docs_fetcher\n\ngo 1.22\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.2.0 // indirect\n)\n",
+		filepath.Base(sumFile(modfile)): "example.com/a v1.0.0 h1:a=\nexample.com/b v1.2.0 h1:b=\n",
+	})
+
+	if err := mergeModFile(context.Background(), dir, modfile); err != nil {
+		t.Fatal(err)
+	}
+	mod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	for _, want := range []string{"example.com/a v1.0.0", "example.com/b v1.2.0"} {
+		if !strings.Contains(string(mod), want) {
+			t.Errorf("go.mod missing %q:\n%s", want, mod)
+		}
+	}
+	if sum, _ := os.ReadFile(filepath.Join(dir, "go.sum")); string(sum) != "example.com/a v1.0.0 h1:a=\nexample.com/b v1.2.0 h1:b=\n" {
+		t.Errorf("go.sum = %q", sum)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	oldDownload := download
+	defer func() { download = oldDownload }()
+	download = func(ctx context.Context, moduleDir, pkgPath string) (string, string, error) {
+		return moduleDir, pkgPath, nil
+	}
+	if _, _, err := scratch.fetch(context.Background(), "example.com/lib"); err != nil {
+		t.Fatal(err)
+	}
+	dir := scratch.cur.dir
+
+	Cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the scratch module was not removed: %v", err)
+	}
+}
@@ -28,6 +28,10 @@ func Get(cfg *config.Config) string {
 		"for all file, directory, or path parameters. Never pass relative paths " +
 		"(e.g., '.', '', or relative paths like 'pkg/main.go'). Always pass the " +
 		"absolute path of the target workspace root or files.\n\n")
+	if cfg.NoCloud {
+		sb.WriteString("🔒 **LOCAL-ONLY MODE:** GoDoctor sends nothing to external services. Tools that need them " +
+			"are not available, and options that would upload code (such as sharing a snippet) fail.\n\n")
+	}
 
 	// 2. Navigation
 	sb.WriteString("### 🔍 Navigation: Save Tokens & Context\n")
@@ -40,6 +44,9 @@ func Get(cfg *config.Config) string {
 	if isEnabled("describe_symbol") {
 		sb.WriteString(toolnames.Registry["describe_symbol"].Instruction + "\n")
 	}
+	if isEnabled("build_context") {
+		sb.WriteString(toolnames.Registry["build_context"].Instruction + "\n")
+	}
 	sb.WriteString("\n")
 
 	// 3. Editing
@@ -47,6 +54,15 @@ func Get(cfg *config.Config) string {
 	if isEnabled("smart_edit") {
 		sb.WriteString(toolnames.Registry["smart_edit"].Instruction + "\n")
 	}
+	if isEnabled("diff") {
+		sb.WriteString(toolnames.Registry["diff"].Instruction + "\n")
+	}
+	if isEnabled("merge_edit") {
+		sb.WriteString(toolnames.Registry["merge_edit"].Instruction + "\n")
+	}
+	if isEnabled("export_session") {
+		sb.WriteString(toolnames.Registry["export_session"].Instruction + "\n")
+	}
 	sb.WriteString("\n")
 
 	// 4. Utilities
@@ -57,12 +73,36 @@ func Get(cfg *config.Config) string {
 	if isEnabled("read_docs") {
 		sb.WriteString(toolnames.Registry["read_docs"].Instruction + "\n")
 	}
+	if isEnabled("prefetch_docs") {
+		sb.WriteString(toolnames.Registry["prefetch_docs"].Instruction + "\n")
+	}
+	if isEnabled("export_docs") {
+		sb.WriteString(toolnames.Registry["export_docs"].Instruction + "\n")
+	}
 	if isEnabled("add_dependency") {
 		sb.WriteString(toolnames.Registry["add_dependency"].Instruction + "\n")
 	}
+	if isEnabled("search_modules") {
+		sb.WriteString(toolnames.Registry["search_modules"].Instruction + "\n")
+	}
+	if isEnabled("dependency_health") {
+		sb.WriteString(toolnames.Registry["dependency_health"].Instruction + "\n")
+	}
 	if isEnabled("project_init") {
 		sb.WriteString(toolnames.Registry["project_init"].Instruction + "\n")
 	}
+	if isEnabled("release_check") {
+		sb.WriteString(toolnames.Registry["release_check"].Instruction + "\n")
+	}
+	if isEnabled("suggest_version") {
+		sb.WriteString(toolnames.Registry["suggest_version"].Instruction + "\n")
+	}
+	if isEnabled("describe_pr") {
+		sb.WriteString(toolnames.Registry["describe_pr"].Instruction + "\n")
+	}
+	if isEnabled("eval_snippet") {
+		sb.WriteString(toolnames.Registry["eval_snippet"].Instruction + "\n")
+	}
 	sb.WriteString("\n")
 
 	// 5. Testing
@@ -73,6 +113,117 @@ func Get(cfg *config.Config) string {
 	if isEnabled("test_query") {
 		sb.WriteString(toolnames.Registry["test_query"].Instruction + "\n")
 	}
+	if isEnabled("bench_compare") {
+		sb.WriteString(toolnames.Registry["bench_compare"].Instruction + "\n")
+	}
+	if isEnabled("convert_test") {
+		sb.WriteString(toolnames.Registry["convert_test"].Instruction + "\n")
+	}
+	if isEnabled("leak_check") {
+		sb.WriteString(toolnames.Registry["leak_check"].Instruction + "\n")
+	}
+	if isEnabled("review_allocations") {
+		sb.WriteString(toolnames.Registry["review_allocations"].Instruction + "\n")
+	}
+	if isEnabled("fix_data_race") {
+		sb.WriteString(toolnames.Registry["fix_data_race"].Instruction + "\n")
+	}
+	sb.WriteString("\n")
+
+	// 6. Analysis
+	sb.WriteString("### 🔬 Analysis\n")
+	if isEnabled("audit_panics") {
+		sb.WriteString(toolnames.Registry["audit_panics"].Instruction + "\n")
+	}
+	if isEnabled("audit_deadlocks") {
+		sb.WriteString(toolnames.Registry["audit_deadlocks"].Instruction + "\n")
+	}
+	if isEnabled("audit_globals") {
+		sb.WriteString(toolnames.Registry["audit_globals"].Instruction + "\n")
+	}
+	if isEnabled("audit_determinism") {
+		sb.WriteString(toolnames.Registry["audit_determinism"].Instruction + "\n")
+	}
+	if isEnabled("audit_streaming") {
+		sb.WriteString(toolnames.Registry["audit_streaming"].Instruction + "\n")
+	}
+	if isEnabled("audit_strings") {
+		sb.WriteString(toolnames.Registry["audit_strings"].Instruction + "\n")
+	}
+	if isEnabled("audit_http") {
+		sb.WriteString(toolnames.Registry["audit_http"].Instruction + "\n")
+	}
+	if isEnabled("audit_sql") {
+		sb.WriteString(toolnames.Registry["audit_sql"].Instruction + "\n")
+	}
+	if isEnabled("inspect_wiring") {
+		sb.WriteString(toolnames.Registry["inspect_wiring"].Instruction + "\n")
+	}
+	if isEnabled("audit_config") {
+		sb.WriteString(toolnames.Registry["audit_config"].Instruction + "\n")
+	}
+	if isEnabled("audit_logging") {
+		sb.WriteString(toolnames.Registry["audit_logging"].Instruction + "\n")
+	}
+	if isEnabled("audit_doc_coverage") {
+		sb.WriteString(toolnames.Registry["audit_doc_coverage"].Instruction + "\n")
+	}
+	if isEnabled("audit_visibility") {
+		sb.WriteString(toolnames.Registry["audit_visibility"].Instruction + "\n")
+	}
+	if isEnabled("audit_naming") {
+		sb.WriteString(toolnames.Registry["audit_naming"].Instruction + "\n")
+	}
+	sb.WriteString("\n")
+
+	// 7. Generation
+	sb.WriteString("### 🏗️ Generation\n")
+	if isEnabled("generate_constructor") {
+		sb.WriteString(toolnames.Registry["generate_constructor"].Instruction + "\n")
+	}
+	if isEnabled("generate_enum") {
+		sb.WriteString(toolnames.Registry["generate_enum"].Instruction + "\n")
+	}
+	if isEnabled("get_snippet") {
+		sb.WriteString(toolnames.Registry["get_snippet"].Instruction + "\n")
+	}
+	if isEnabled("suggest_concurrency") {
+		sb.WriteString(toolnames.Registry["suggest_concurrency"].Instruction + "\n")
+	}
+	if isEnabled("generate_openapi_client") {
+		sb.WriteString(toolnames.Registry["generate_openapi_client"].Instruction + "\n")
+	}
+	if isEnabled("generate_openapi_mock") {
+		sb.WriteString(toolnames.Registry["generate_openapi_mock"].Instruction + "\n")
+	}
+	sb.WriteString("\n")
+
+	// 8. Refactoring
+	sb.WriteString("### 🔧 Refactoring\n")
+	if isEnabled("extract_strings") {
+		sb.WriteString(toolnames.Registry["extract_strings"].Instruction + "\n")
+	}
+	if isEnabled("extract_module") {
+		sb.WriteString(toolnames.Registry["extract_module"].Instruction + "\n")
+	}
+	if isEnabled("rewrite_import_path") {
+		sb.WriteString(toolnames.Registry["rewrite_import_path"].Instruction + "\n")
+	}
+	if isEnabled("rename_symbols") {
+		sb.WriteString(toolnames.Registry["rename_symbols"].Instruction + "\n")
+	}
+	if isEnabled("replace_dependency") {
+		sb.WriteString(toolnames.Registry["replace_dependency"].Instruction + "\n")
+	}
+	if isEnabled("rewrite_idioms") {
+		sb.WriteString(toolnames.Registry["rewrite_idioms"].Instruction + "\n")
+	}
+	if isEnabled("inline_symbol") {
+		sb.WriteString(toolnames.Registry["inline_symbol"].Instruction + "\n")
+	}
+	if isEnabled("introduce_parameter_object") {
+		sb.WriteString(toolnames.Registry["introduce_parameter_object"].Instruction + "\n")
+	}
 
 	return sb.String()
 }
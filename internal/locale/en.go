@@ -0,0 +1,87 @@
+package locale
+
+// en is the English catalog. It is the fallback for every other locale, so every key must be here.
+var en = map[string]string{
+	"report.status": "Status",
+	"status.pass":   "✅ PASS",
+	"status.warn":   "⚠️ WARN",
+	"status.fail":   "❌ FAILED",
+	"status.skip":   "⏭️ SKIPPED",
+
+	"build.title":             "Smart Build Report (`%s`)",
+	"build.autofix":           "Auto-Fix",
+	"build.tidy_failed":       "`go mod tidy` failed: %v",
+	"build.modernize":         "Modernize `%s`",
+	"build.build":             "Build",
+	"build.tests":             "Tests",
+	"build.coverage":          "Coverage",
+	"build.total_coverage":    "**Total Project Coverage**: %s",
+	"build.lint":              "Lint",
+	"build.lint_vet":          "Lint (using `go vet`)",
+	"build.source":            "Source",
+	"build.progress_compiled": "Build: %d package(s) compiled, latest `%s`",
+	"build.progress_built":    "Build: %d package(s) compiled",
+	"build.progress_tests":    "Tests: %d passed, %d failed, %d skipped in %d finished package(s)",
+
+	"hint.undefined": "**HINT:** usage of '%s' failed. Try calling `read_docs` on that package to see the correct API.",
+	"hint.import":    "**HINT:** import '%s' failed. Try calling `read_docs` on \"%s\" to verify the package path and exports.",
+
+	"audit.found_issues":   "⚠️ Found %d issue(s).",
+	"audit.found_findings": "⚠️ Found %d finding(s).",
+
+	"configdrift.title":   "Configuration Drift Audit",
+	"configdrift.summary": "Code reads %d variable(s); %d deployment file(s) define %d %s.",
+	"configdrift.entry":   "entry",
+	"configdrift.entries": "entries",
+	"configdrift.dynamic": "ℹ️ %d read(s) use a non-constant name and could not be checked.",
+	"configdrift.none":    "✅ Every variable read by the code is defined, and every defined variable is read.",
+
+	"deadlocks.title": "Deadlock Audit (`%s`)",
+	"deadlocks.none":  "✅ No lock-order inversion or channel operation under a lock found (%d lock(s) analyzed).",
+	"deadlocks.found": "⚠️ Found %d lock-order inversion(s), %d recursive acquisition(s) and %d blocking channel operation(s) inside critical sections.\n\nThese are heuristics over static calls: check that the paths can really run concurrently.",
+
+	"determinism.title": "Determinism Audit (`%s`)",
+	"determinism.none":  "✅ No direct time.Now, time.Sleep or global math/rand usage found.",
+	"determinism.found": "⚠️ Found %d nondeterministic call(s).",
+
+	"doccoverage.title":   "Documentation Coverage (`%s`)",
+	"doccoverage.none":    "No library packages found (main packages are not counted).",
+	"doccoverage.summary": "**Overall:** %s of exported symbols documented (%d/%d); %d of %d package(s) missing a package comment.",
+
+	"globals.title":   "Global State Inventory (`%s`)",
+	"globals.summary": "- Package-level variables: %d\n- init() functions: %d\n- sync.Once patterns: %d",
+
+	"httpclient.title": "HTTP Client Hygiene Audit (`%s`)",
+	"httpclient.none":  "✅ No HTTP client hygiene issues found.",
+
+	"logging.title": "Logging Review (`%s`)",
+	"logging.none":  "✅ No logging issues found.",
+
+	"naming.title": "Naming Audit (`%s`)",
+	"naming.none":  "✅ The %d exported identifier(s) follow the Go naming conventions.",
+	"naming.found": "⚠️ **%d of %d exported identifier(s)** break a naming convention.",
+
+	"panics.title":       "Panic Path Audit (`%s`)",
+	"panics.none":        "✅ No panic, log.Fatal or os.Exit calls are reachable from the exported API of library packages.",
+	"panics.unreachable": "(%d terminating call(s) exist in code not reachable from exported functions.)",
+	"panics.found":       "⚠️ Found %d terminating call(s) reachable from exported functions.",
+
+	"release.title":    "Release Readiness",
+	"release.blocking": "%d blocking item(s).",
+	"release.compared": "Compared against `%s`.",
+
+	"sqlleaks.title": "database/sql Leak Audit (`%s`)",
+	"sqlleaks.none":  "✅ No database/sql resource leaks found.",
+
+	"strbuild.title": "String Building Audit (`%s`)",
+	"strbuild.none":  "✅ No quadratic string building, Sprintf in loops or needless conversions found.",
+
+	"streaming.title":   "Streaming Audit (`%s`)",
+	"streaming.none":    "✅ No whole reads of bodies or files found on hot paths.",
+	"streaming.skipped": "(%d whole read(s) outside handlers and loops, such as loading configuration, were not reported.)",
+	"streaming.found":   "⚠️ Found %d whole read(s) on hot paths.",
+
+	"visibility.title": "Visibility Audit (`%s`)",
+	"visibility.none":  "✅ Every exported symbol is used by another package of the module.",
+	"visibility.found": "**%d of %d exported symbol(s)** are not used outside their package.",
+}
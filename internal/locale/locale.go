@@ -0,0 +1,157 @@
+// Package locale provides the message catalog used to localize user-facing text in tool results.
+// The server has a default locale set from configuration, and clients can override it per call or
+// per session with an Accept-Language-style "locale" entry in the request metadata.
+//
+// The catalog covers the smart_build report, the titles, summaries and status labels of the
+// release_check and audit reports, and the documentation hints. Findings, the output of other
+// tools and error messages are in English.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Supported locales.
+const (
+	English             = "en"
+	BrazilianPortuguese = "pt-BR"
+)
+
+// MetaKey is the request metadata key clients use to choose a locale, e.g. {"_meta": {"locale": "pt-BR"}}.
+const MetaKey = "locale"
+
+var catalogs = map[string]map[string]string{
+	English:             en,
+	BrazilianPortuguese: ptBR,
+}
+
+var (
+	mu         sync.RWMutex
+	defaultTag = English
+)
+
+// Supported returns the supported locale tags.
+func Supported() []string {
+	tags := make([]string, 0, len(catalogs))
+	for tag := range catalogs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SetDefault sets the locale used when a request does not ask for one.
+func SetDefault(lang string) error {
+	tag := Match(lang)
+	if tag == "" {
+		return fmt.Errorf("unsupported locale %q (supported: %s)", lang, strings.Join(Supported(), ", "))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultTag = tag
+	return nil
+}
+
+// Default returns the server's default locale.
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultTag
+}
+
+// Match returns the best supported locale for an Accept-Language-style list such as
+// "pt-BR,pt;q=0.9,en;q=0.8", or "" if none is supported. A bare language ("pt") or another
+// region ("pt-PT") matches the supported locale for that language.
+func Match(accept string) string {
+	type choice struct {
+		tag string
+		q   float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{strings.ReplaceAll(tag, "_", "-"), q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	for _, c := range choices {
+		lang, _, _ := strings.Cut(c.tag, "-")
+		for _, tag := range Supported() {
+			if strings.EqualFold(c.tag, tag) {
+				return tag
+			}
+		}
+		for _, tag := range Supported() {
+			if prefix, _, _ := strings.Cut(tag, "-"); strings.EqualFold(lang, prefix) {
+				return tag
+			}
+		}
+	}
+	return ""
+}
+
+// FromRequest returns the locale for a tool call: the "locale" metadata of the call, then of the
+// session's initialize request, then the HTTP Accept-Language header, then the server default.
+func FromRequest(req *mcp.CallToolRequest) string {
+	if req == nil {
+		return Default()
+	}
+	if req.Params != nil {
+		if tag := fromMeta(req.Params.Meta); tag != "" {
+			return tag
+		}
+	}
+	if req.Session != nil {
+		if p := req.Session.InitializeParams(); p != nil {
+			if tag := fromMeta(p.Meta); tag != "" {
+				return tag
+			}
+		}
+	}
+	if req.Extra != nil && req.Extra.Header != nil {
+		if tag := Match(req.Extra.Header.Get("Accept-Language")); tag != "" {
+			return tag
+		}
+	}
+	return Default()
+}
+
+func fromMeta(meta mcp.Meta) string {
+	if s, ok := meta[MetaKey].(string); ok {
+		return Match(s)
+	}
+	return ""
+}
+
+// T returns the message for key in lang, formatted with args. Keys missing from lang fall back to
+// English, and unknown keys are returned as is.
+func T(lang, key string, args ...any) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = en[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
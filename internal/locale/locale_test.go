@@ -0,0 +1,78 @@
+package locale
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for tag, catalog := range catalogs {
+		for key := range en {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s: missing %q", tag, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s: %q is not in the English catalog", tag, key)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"en":                         "en",
+		"pt-BR":                      "pt-BR",
+		"pt-br":                      "pt-BR",
+		"pt":                         "pt-BR",
+		"pt-PT":                      "pt-BR",
+		"en-US,en;q=0.9":             "en",
+		"fr-FR,pt;q=0.8,en;q=0.5":    "pt-BR",
+		"en;q=0.3, pt-BR;q=0.9":      "pt-BR",
+		"fr, de":                     "",
+		"*":                          "",
+		"pt-BR;q=0, en":              "en",
+		"ja,en-GB;q=0.7,pt-BR;q=bad": "pt-BR",
+	}
+	for accept, want := range tests {
+		if got := Match(accept); got != want {
+			t.Errorf("Match(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	if got := FromRequest(nil); got != English {
+		t.Errorf("FromRequest(nil) = %q, want %q", got, English)
+	}
+
+	req := &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Meta: mcp.Meta{MetaKey: "pt-BR"}},
+		Extra:  &mcp.RequestExtra{Header: http.Header{"Accept-Language": {"en"}}},
+	}
+	if got := FromRequest(req); got != BrazilianPortuguese {
+		t.Errorf("metadata: got %q, want %q", got, BrazilianPortuguese)
+	}
+
+	req.Params.Meta = nil
+	req.Extra.Header.Set("Accept-Language", "pt;q=0.9, fr")
+	if got := FromRequest(req); got != BrazilianPortuguese {
+		t.Errorf("header: got %q, want %q", got, BrazilianPortuguese)
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(BrazilianPortuguese, "build.tests"); got != "Testes" {
+		t.Errorf("T(pt-BR, build.tests) = %q", got)
+	}
+	if got := T("xx", "build.title", "./..."); got != "Smart Build Report (`./...`)" {
+		t.Errorf("fallback to English: got %q", got)
+	}
+	if got := T(English, "no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key: got %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package locale
+
+// ptBR is the Brazilian Portuguese catalog.
+var ptBR = map[string]string{
+	"report.status": "Status",
+	"status.pass":   "✅ OK",
+	"status.warn":   "⚠️ ATENÇÃO",
+	"status.fail":   "❌ FALHOU",
+	"status.skip":   "⏭️ IGNORADO",
+
+	"build.title":             "Relatório de Build (`%s`)",
+	"build.autofix":           "Correções Automáticas",
+	"build.tidy_failed":       "`go mod tidy` falhou: %v",
+	"build.modernize":         "Modernização `%s`",
+	"build.build":             "Build",
+	"build.tests":             "Testes",
+	"build.coverage":          "Cobertura",
+	"build.total_coverage":    "**Cobertura Total do Projeto**: %s",
+	"build.lint":              "Lint",
+	"build.lint_vet":          "Lint (usando `go vet`)",
+	"build.source":            "Código-fonte",
+	"build.progress_compiled": "Build: %d pacote(s) compilado(s), último `%s`",
+	"build.progress_built":    "Build: %d pacote(s) compilado(s)",
+	"build.progress_tests":    "Testes: %d passaram, %d falharam, %d ignorados em %d pacote(s) concluído(s)",
+
+	"hint.undefined": "**DICA:** o uso de '%s' falhou. Chame `read_docs` nesse pacote para ver a API correta.",
+	"hint.import":    "**DICA:** a importação de '%s' falhou. Chame `read_docs` em \"%s\" para verificar o caminho do pacote e seus exports.",
+
+	"audit.found_issues":   "⚠️ %d problema(s) encontrado(s).",
+	"audit.found_findings": "⚠️ %d ocorrência(s) encontrada(s).",
+
+	"configdrift.title":   "Auditoria de Divergência de Configuração",
+	"configdrift.summary": "O código lê %d variável(is); %d arquivo(s) de implantação definem %d %s.",
+	"configdrift.entry":   "entrada",
+	"configdrift.entries": "entradas",
+	"configdrift.dynamic": "ℹ️ %d leitura(s) usam um nome não constante e não puderam ser verificadas.",
+	"configdrift.none":    "✅ Toda variável lida pelo código está definida, e toda variável definida é lida.",
+
+	"deadlocks.title": "Auditoria de Deadlocks (`%s`)",
+	"deadlocks.none":  "✅ Nenhuma inversão de ordem de locks ou operação de canal sob lock encontrada (%d lock(s) analisado(s)).",
+	"deadlocks.found": "⚠️ %d inversão(ões) de ordem de locks, %d aquisição(ões) recursiva(s) e %d operação(ões) de canal bloqueante(s) dentro de seções críticas.\n\nEstas são heurísticas sobre chamadas estáticas: verifique se os caminhos podem mesmo rodar concorrentemente.",
+
+	"determinism.title": "Auditoria de Determinismo (`%s`)",
+	"determinism.none":  "✅ Nenhum uso direto de time.Now, time.Sleep ou do math/rand global encontrado.",
+	"determinism.found": "⚠️ %d chamada(s) não determinística(s) encontrada(s).",
+
+	"doccoverage.title":   "Cobertura de Documentação (`%s`)",
+	"doccoverage.none":    "Nenhum pacote de biblioteca encontrado (pacotes main não são contados).",
+	"doccoverage.summary": "**Geral:** %s dos símbolos exportados documentados (%d/%d); %d de %d pacote(s) sem comentário de pacote.",
+
+	"globals.title":   "Inventário de Estado Global (`%s`)",
+	"globals.summary": "- Variáveis de pacote: %d\n- Funções init(): %d\n- Padrões sync.Once: %d",
+
+	"httpclient.title": "Auditoria de Higiene de Clientes HTTP (`%s`)",
+	"httpclient.none":  "✅ Nenhum problema de higiene de clientes HTTP encontrado.",
+
+	"logging.title": "Revisão de Logging (`%s`)",
+	"logging.none":  "✅ Nenhum problema de logging encontrado.",
+
+	"naming.title": "Auditoria de Nomenclatura (`%s`)",
+	"naming.none":  "✅ Os %d identificador(es) exportado(s) seguem as convenções de nomenclatura do Go.",
+	"naming.found": "⚠️ **%d de %d identificador(es) exportado(s)** violam uma convenção de nomenclatura.",
+
+	"panics.title":       "Auditoria de Caminhos de Panic (`%s`)",
+	"panics.none":        "✅ Nenhuma chamada a panic, log.Fatal ou os.Exit é alcançável pela API exportada dos pacotes de biblioteca.",
+	"panics.unreachable": "(%d chamada(s) de encerramento existem em código não alcançável por funções exportadas.)",
+	"panics.found":       "⚠️ %d chamada(s) de encerramento alcançável(is) por funções exportadas.",
+
+	"release.title":    "Prontidão para Release",
+	"release.blocking": "%d item(ns) bloqueante(s).",
+	"release.compared": "Comparado com `%s`.",
+
+	"sqlleaks.title": "Auditoria de Vazamentos de database/sql (`%s`)",
+	"sqlleaks.none":  "✅ Nenhum vazamento de recursos de database/sql encontrado.",
+
+	"strbuild.title": "Auditoria de Construção de Strings (`%s`)",
+	"strbuild.none":  "✅ Nenhuma construção quadrática de strings, Sprintf em loops ou conversão desnecessária encontrada.",
+
+	"streaming.title":   "Auditoria de Streaming (`%s`)",
+	"streaming.none":    "✅ Nenhuma leitura integral de corpos ou arquivos encontrada em caminhos críticos.",
+	"streaming.skipped": "(%d leitura(s) integral(is) fora de handlers e loops, como o carregamento de configuração, não foram reportadas.)",
+	"streaming.found":   "⚠️ %d leitura(s) integral(is) encontrada(s) em caminhos críticos.",
+
+	"visibility.title": "Auditoria de Visibilidade (`%s`)",
+	"visibility.none":  "✅ Todo símbolo exportado é usado por outro pacote do módulo.",
+	"visibility.found": "**%d de %d símbolo(s) exportado(s)** não são usados fora do seu pacote.",
+}
@@ -0,0 +1,566 @@
+// Package openapi reads OpenAPI 3 documents in JSON or YAML into a model covering what Go code
+// generators need: paths and operations, parameters, JSON bodies and schemas, with local $ref
+// references resolved.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an OpenAPI document.
+type Spec struct {
+	OpenAPI      string               `json:"openapi"`
+	Swagger      string               `json:"swagger"`
+	Info         Info                 `json:"info"`
+	Servers      []Server             `json:"servers"`
+	Paths        map[string]*PathItem `json:"paths"`
+	Components   Components           `json:"components"`
+	ExternalDocs *ExternalDocs        `json:"externalDocs"`
+}
+
+// Info is the API metadata.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// ExternalDocs links to further documentation.
+type ExternalDocs struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// Components holds the reusable definitions $ref points to.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses"`
+}
+
+// PathItem holds the operations of one path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Patch      *Operation   `json:"patch"`
+	Head       *Operation   `json:"head"`
+	Options    *Operation   `json:"options"`
+}
+
+// Operation is one method on one path. Method, Path and the path-level parameters are filled in
+// by Parse.
+type Operation struct {
+	OperationID  string               `json:"operationId"`
+	Summary      string               `json:"summary"`
+	Description  string               `json:"description"`
+	Tags         []string             `json:"tags"`
+	Parameters   []*Parameter         `json:"parameters"`
+	RequestBody  *RequestBody         `json:"requestBody"`
+	Responses    map[string]*Response `json:"responses"`
+	Deprecated   bool                 `json:"deprecated"`
+	ExternalDocs *ExternalDocs        `json:"externalDocs"`
+
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation.
+type RequestBody struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response is one response of an operation.
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType is the schema and example of one content type.
+type MediaType struct {
+	Schema  *Schema `json:"schema"`
+	Example any     `json:"example"`
+}
+
+// Schema is a JSON Schema as OpenAPI uses it. Type is a string, or a list of strings in OpenAPI
+// 3.1; use Types.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 any                `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	AdditionalProperties any                `json:"additionalProperties"`
+	Enum                 []any              `json:"enum"`
+	Nullable             bool               `json:"nullable"`
+	AllOf                []*Schema          `json:"allOf"`
+	OneOf                []*Schema          `json:"oneOf"`
+	AnyOf                []*Schema          `json:"anyOf"`
+	Example              any                `json:"example"`
+	Default              any                `json:"default"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// Types returns the schema's types, without "null".
+func (s *Schema) Types() []string {
+	var out []string
+	switch t := s.Type.(type) {
+	case string:
+		out = append(out, t)
+	case []any:
+		for _, v := range t {
+			if str, ok := v.(string); ok && str != "null" {
+				out = append(out, str)
+			}
+		}
+	}
+	return out
+}
+
+// IsNullable reports whether null is a valid value, in either the 3.0 or the 3.1 spelling.
+func (s *Schema) IsNullable() bool {
+	if s.Nullable {
+		return true
+	}
+	if list, ok := s.Type.([]any); ok {
+		for _, v := range list {
+			if v == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AdditionalSchema returns the schema of additionalProperties, or nil when it is absent or false.
+// additionalProperties: true yields an empty schema (any value).
+func (s *Schema) AdditionalSchema() *Schema {
+	switch v := s.AdditionalProperties.(type) {
+	case bool:
+		if v {
+			return &Schema{}
+		}
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		var out Schema
+		if json.Unmarshal(data, &out) == nil {
+			return &out
+		}
+	}
+	return nil
+}
+
+// RefName returns the component name a $ref points to ("#/components/schemas/Pet" → "Pet").
+func RefName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// Parse reads an OpenAPI 3 document in JSON or YAML, resolves the $ref of parameters, request
+// bodies and responses, and fills in each operation's method, path and path-level parameters.
+// Schema references are kept so generators can name the types; use Spec.Schema to follow them.
+func Parse(data []byte) (*Spec, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("not a JSON or YAML document: %w", err)
+	}
+	js, err := json.Marshal(normalize(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the document: %w", err)
+	}
+	var spec Spec
+	if err := json.Unmarshal(js, &spec); err != nil {
+		return nil, fmt.Errorf("not an OpenAPI document: %w", err)
+	}
+	switch {
+	case spec.Swagger != "":
+		return nil, fmt.Errorf("this is a Swagger %s document; convert it to OpenAPI 3 first", spec.Swagger)
+	case !strings.HasPrefix(spec.OpenAPI, "3."):
+		return nil, fmt.Errorf("unsupported OpenAPI version %q; only 3.x is supported", spec.OpenAPI)
+	}
+	for path, item := range spec.Paths {
+		if item == nil {
+			continue
+		}
+		for method, op := range item.operations() {
+			op.Method, op.Path = method, path
+			params, err := spec.mergeParams(item.Parameters, op.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			op.Parameters = params
+			if op.RequestBody != nil && op.RequestBody.Ref != "" {
+				body, ok := spec.Components.RequestBodies[RefName(op.RequestBody.Ref)]
+				if !ok {
+					return nil, fmt.Errorf("%s %s: unresolved reference %s", method, path, op.RequestBody.Ref)
+				}
+				op.RequestBody = body
+			}
+			for code, resp := range op.Responses {
+				if resp != nil && resp.Ref != "" {
+					r, ok := spec.Components.Responses[RefName(resp.Ref)]
+					if !ok {
+						return nil, fmt.Errorf("%s %s: unresolved reference %s", method, path, resp.Ref)
+					}
+					op.Responses[code] = r
+				}
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// normalize turns the map[string]any YAML produces for mappings with non-string keys, such as
+// response codes written as numbers, into JSON-compatible values.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[fmt.Sprint(k)] = normalize(e)
+		}
+		return out
+	case []any:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	}
+	return v
+}
+
+func (p *PathItem) operations() map[string]*Operation {
+	out := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		http.MethodGet: p.Get, http.MethodPut: p.Put, http.MethodPost: p.Post, http.MethodDelete: p.Delete,
+		http.MethodPatch: p.Patch, http.MethodHead: p.Head, http.MethodOptions: p.Options,
+	} {
+		if op != nil {
+			out[method] = op
+		}
+	}
+	return out
+}
+
+// mergeParams resolves parameter references and lets operation parameters override path-level
+// ones with the same name and location.
+func (s *Spec) mergeParams(pathLevel, opLevel []*Parameter) ([]*Parameter, error) {
+	var out []*Parameter
+	index := make(map[string]int)
+	for _, list := range [][]*Parameter{pathLevel, opLevel} {
+		for _, p := range list {
+			if p == nil {
+				continue
+			}
+			if p.Ref != "" {
+				resolved, ok := s.Components.Parameters[RefName(p.Ref)]
+				if !ok {
+					return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+				}
+				p = resolved
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				out[i] = p
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Operations returns every operation sorted by path and method.
+func (s *Spec) Operations() []*Operation {
+	var ops []*Operation
+	for _, item := range s.Paths {
+		if item == nil {
+			continue
+		}
+		for _, op := range item.operations() {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// Schema follows a schema's $ref chain to the definition in components.
+func (s *Spec) Schema(schema *Schema) (*Schema, error) {
+	for seen := 0; schema != nil && schema.Ref != ""; seen++ {
+		if seen > 32 {
+			return nil, fmt.Errorf("reference cycle at %s", schema.Ref)
+		}
+		if !strings.HasPrefix(schema.Ref, "#/components/schemas/") {
+			return nil, fmt.Errorf("unsupported reference %s; only local #/components/schemas references are resolved", schema.Ref)
+		}
+		next, ok := s.Components.Schemas[RefName(schema.Ref)]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", schema.Ref)
+		}
+		schema = next
+	}
+	return schema, nil
+}
+
+// JSONContent returns the media type of a JSON content map: application/json, or any
+// "+json" or "/json" variant.
+func JSONContent(content map[string]*MediaType) *MediaType {
+	if mt, ok := content["application/json"]; ok {
+		return mt
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasSuffix(k, "+json") || strings.HasSuffix(k, "/json") {
+			return content[k]
+		}
+	}
+	return nil
+}
+
+// SuccessResponse returns the status code and response of the lowest 2xx response, falling back
+// to "default".
+func (op *Operation) SuccessResponse() (string, *Response) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return code, op.Responses[code]
+		}
+	}
+	if r, ok := op.Responses["default"]; ok {
+		return "default", r
+	}
+	return "", nil
+}
+
+// GoName returns the Go name of the operation: its operationId, or the method and path when it
+// has none ("GET /pets/{petId}" becomes "GetPetsPetID").
+func (op *Operation) GoName() string {
+	if op.OperationID != "" {
+		return GoName(op.OperationID)
+	}
+	return GoName(strings.ToLower(op.Method) + " " + op.Path)
+}
+
+// initialisms are spelled in capitals in Go names.
+var initialisms = map[string]bool{
+	"API": true, "DB": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "TLS": true, "TTL": true, "UI": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// GoName turns an OpenAPI name (operationId, schema, property or parameter name) into an
+// exported Go identifier: "pet_id" and "petId" both become "PetID", "2fa" becomes "X2fa".
+func GoName(name string) string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(cur) > 0 && (unicode.IsLower(cur[len(cur)-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(cur[len(cur)-1])):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	var sb strings.Builder
+	for _, w := range words {
+		if up := strings.ToUpper(w); initialisms[up] {
+			sb.WriteString(up)
+			continue
+		}
+		r := []rune(w)
+		if allUpper(w) {
+			r = []rune(strings.ToLower(w)) // "PET" → "Pet"
+		}
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	out := sb.String()
+	if out == "" {
+		return "X"
+	}
+	if !unicode.IsLetter([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+func allUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// LowerName is GoName with the first word in lower case, for variables and parameters.
+func LowerName(name string) string {
+	n := GoName(name)
+	runes := []rune(n)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) && unicode.IsLower(runes[i]) {
+		i--
+	}
+	for j := range max(i, 1) {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	return string(runes)
+}
+
+// Example returns a JSON value valid for schema: its example or default when the document gives
+// one, otherwise a value synthesized from the types, formats and enums. Every property is
+// filled in; recursive schemas stop at a fixed depth.
+func (s *Spec) Example(schema *Schema) any {
+	return s.example(schema, 0)
+}
+
+func (s *Spec) example(schema *Schema, depth int) any {
+	schema, err := s.Schema(schema)
+	if err != nil || schema == nil || depth > 8 {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.OneOf) > 0:
+		return s.example(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return s.example(schema.AnyOf[0], depth+1)
+	case len(schema.AllOf) > 0:
+		out := make(map[string]any)
+		for _, sub := range schema.AllOf {
+			if m, ok := s.example(sub, depth+1).(map[string]any); ok {
+				for k, v := range m {
+					out[k] = v
+				}
+			}
+		}
+		for k, v := range schema.Properties {
+			out[k] = s.example(v, depth+1)
+		}
+		return out
+	}
+	var typ string
+	if types := schema.Types(); len(types) > 0 {
+		typ = types[0]
+	} else if len(schema.Properties) > 0 {
+		typ = "object"
+	}
+	switch typ {
+	case "string":
+		if v, ok := exampleStrings[schema.Format]; ok {
+			return v
+		}
+		return "string"
+	case "integer":
+		if schema.Minimum != nil {
+			return int64(*schema.Minimum)
+		}
+		return 1
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		return []any{s.example(schema.Items, depth+1)}
+	case "object":
+		out := make(map[string]any)
+		for k, v := range schema.Properties {
+			out[k] = s.example(v, depth+1)
+		}
+		if add := schema.AdditionalSchema(); add != nil && len(out) == 0 {
+			out["key"] = s.example(add, depth+1)
+		}
+		return out
+	}
+	return nil
+}
+
+// exampleStrings are example values of string formats; unknown formats get "string".
+var exampleStrings = map[string]string{
+	"date-time": "2024-01-02T15:04:05Z",
+	"date":      "2024-01-02",
+	"time":      "15:04:05",
+	"uuid":      "00000000-0000-0000-0000-000000000001",
+	"email":     "user@example.com",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "c3RyaW5n",
+	"password":  "secret",
+}
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetID'
+    get:
+      operationId: showPetById
+      responses:
+        200:
+          description: A pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        default:
+          $ref: '#/components/responses/Error'
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        $ref: '#/components/requestBodies/NewPet'
+      responses:
+        '201':
+          description: Created
+components:
+  parameters:
+    PetID:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: integer
+        format: int64
+  requestBodies:
+    NewPet:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+  responses:
+    Error:
+      description: An error
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+        tag:
+          type: [string, "null"]
+`
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ops := spec.Operations()
+	if len(ops) != 2 {
+		t.Fatalf("got %d operations, want 2", len(ops))
+	}
+	create, show := ops[0], ops[1]
+	if create.OperationID != "createPet" || create.Method != "POST" || create.Path != "/pets" {
+		t.Errorf("first operation = %s %s %s", create.OperationID, create.Method, create.Path)
+	}
+	if create.RequestBody == nil || !create.RequestBody.Required || JSONContent(create.RequestBody.Content) == nil {
+		t.Errorf("request body reference not resolved: %+v", create.RequestBody)
+	}
+	if len(show.Parameters) != 1 || show.Parameters[0].Name != "petId" || show.Parameters[0].In != "path" {
+		t.Errorf("path-level parameter reference not resolved: %+v", show.Parameters)
+	}
+	code, resp := show.SuccessResponse()
+	if code != "200" || resp == nil {
+		t.Fatalf("SuccessResponse = %q, %v", code, resp)
+	}
+	if show.Responses["default"].Description != "An error" {
+		t.Errorf("response reference not resolved: %+v", show.Responses["default"])
+	}
+	schema, err := spec.Schema(JSONContent(resp.Content).Schema)
+	if err != nil {
+		t.Fatalf("Schema: %v", err)
+	}
+	tag := schema.Properties["tag"]
+	if got := tag.Types(); len(got) != 1 || got[0] != "string" || !tag.IsNullable() {
+		t.Errorf("tag types = %v, nullable = %v", got, tag.IsNullable())
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	spec, err := Parse([]byte(`{"openapi": "3.1.0", "info": {"title": "T", "version": "1"}, "paths": {}}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if spec.Info.Title != "T" {
+		t.Errorf("title = %q", spec.Info.Title)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`{"swagger": "2.0"}`, "Swagger 2.0"},
+		{`openapi: 4.0.0`, "unsupported OpenAPI version"},
+		{`openapi: 3.0.0
+paths:
+  /x:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/Missing'`, "unresolved reference"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"pet_id":       "PetID",
+		"petId":        "PetID",
+		"showPetById":  "ShowPetByID",
+		"HTTPServer":   "HTTPServer",
+		"PET":          "Pet",
+		"x-rate-limit": "XRateLimit",
+		"2fa":          "X2fa",
+		"":             "X",
+	}
+	for in, want := range tests {
+		if got := GoName(in); got != want {
+			t.Errorf("GoName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	lower := map[string]string{"PetId": "petID", "HTTPServer": "httpServer", "id": "id", "URL": "url"}
+	for in, want := range lower {
+		if got := LowerName(in); got != want {
+			t.Errorf("LowerName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExample(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := spec.Example(&Schema{Ref: "#/components/schemas/Pet"}).(map[string]any)
+	if !ok {
+		t.Fatalf("Example(Pet) = %#v, want an object", got)
+	}
+	if got["id"] != 1 || got["name"] != "string" || got["tag"] != "string" {
+		t.Errorf("Example(Pet) = %#v", got)
+	}
+	tests := []struct {
+		schema *Schema
+		want   any
+	}{
+		{&Schema{Type: "string", Format: "date-time"}, "2024-01-02T15:04:05Z"},
+		{&Schema{Type: "string", Format: "custom"}, "string"},
+		{&Schema{Type: "string", Enum: []any{"sold", "available"}}, "sold"},
+		{&Schema{Type: "integer", Example: 42}, 42},
+		{&Schema{Type: "boolean"}, true},
+	}
+	for _, tt := range tests {
+		if got := spec.Example(tt.schema); got != tt.want {
+			t.Errorf("Example(%+v) = %#v, want %#v", tt.schema, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/danicat/godoctor/internal/roots"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -21,7 +22,7 @@ const codeReviewPrompt = `You are conducting a senior-level Go code review. Appl
 - Are synchronous functions preferred over async ones?
 
 ## Error Handling
-- Are errors wrapped with fmt.Errorf("doing x: %%w", err)?
+- Are errors wrapped with fmt.Errorf("doing x: %w", err)?
 - Are error strings lowercase, no punctuation?
 - Is errors.Is / errors.As used for typed error checking?
 - Is every error checked? No silent _ drops?
@@ -55,18 +56,33 @@ func CodeReview(namespace string) *mcp.Prompt {
 	return &mcp.Prompt{
 		Name:        name,
 		Title:       "Go Code Review",
-		Description: "Senior-level Go code review checklist covering concurrency, interfaces, error handling, and GoDoctor tool integration.",
+		Description: "Senior-level Go code review checklist covering concurrency, interfaces, error handling, and GoDoctor tool integration, merged with the repository's own .godoctor/review.md guidelines.",
 		Arguments: []*mcp.PromptArgument{
 			{Name: "focus", Description: "Optional area to focus the review on (e.g. concurrency, error-handling)", Required: false},
+			{Name: "dir", Description: "Optional absolute directory of the reviewed code, where .godoctor/review.md files are looked up (default: the workspace root)", Required: false},
 		},
 	}
 }
 
-// CodeReviewHandler generates the content for the 'go_code_review' prompt.
+// CodeReviewHandler generates the content for the 'go_code_review' prompt. Guidelines found in
+// .godoctor/review.md files, from the reviewed directory up to the repository root, are merged
+// into the checklist. If one of them cannot be read or parsed, the built-in checklist is used
+// alone, with a note saying why.
 func CodeReviewHandler(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	dir, err := roots.Global.Validate(req.Session, req.Params.Arguments["dir"])
+	if err != nil {
+		return nil, err
+	}
 	prompt := codeReviewPrompt
+	if found, err := findGuidelines(dir); err != nil {
+		// A broken guidelines file should not block the review: fall back to the built-in
+		// checklist and tell the reviewer why the repository's guidelines are missing.
+		prompt += fmt.Sprintf("\n\n_Note: the repository review guidelines were ignored: %v_\n", err)
+	} else {
+		prompt = withGuidelines(prompt, found)
+	}
 	if focus := req.Params.Arguments["focus"]; focus != "" {
-		prompt = fmt.Sprintf("**Focus this review specifically on: %s**\n\n%s", focus, prompt)
+		prompt = fmt.Sprintf("**Focus this review specifically on: %s**\n\n", focus) + prompt
 	}
 
 	return &mcp.GetPromptResult{
@@ -0,0 +1,131 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func review(t *testing.T, args map[string]string) (string, error) {
+	t.Helper()
+	res, err := CodeReviewHandler(context.Background(), &mcp.GetPromptRequest{Params: &mcp.GetPromptParams{Arguments: args}})
+	if err != nil {
+		return "", err
+	}
+	return res.Messages[0].Content.(*mcp.TextContent).Text, nil
+}
+
+func TestCodeReviewHandler_Guidelines(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, ".godoctor", "review.md"), "You review for the payments team.\n\n- Money is always int64 cents.\n")
+	writeFile(t, filepath.Join(repo, "svc", ".godoctor", "review.md"), "- Handlers return 100% typed errors.\n")
+	// Outside the repository: never read.
+	writeFile(t, filepath.Join(filepath.Dir(repo), ".godoctor", "review.md"), "- Ignore me.\n")
+
+	text, err := review(t, map[string]string{"dir": filepath.Join(repo, "svc"), "focus": "errors"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"**Focus this review specifically on: errors**\n\nYou are conducting a senior-level Go code review.",
+		"fmt.Errorf(\"doing x: %w\", err)",
+		"## Repository Guidelines\n\nThis repository defines its own review guidelines. Where they disagree with anything above, they win; between these files",
+		"### From `.godoctor/review.md`\n\nYou review for the payments team.\n\n- Money is always int64 cents.\n\n### From `svc/.godoctor/review.md`\n\n- Handlers return 100% typed errors.\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Ignore me") {
+		t.Errorf("prompt includes guidelines from outside the repository:\n%s", text)
+	}
+}
+
+func TestCodeReviewHandler_Replace(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, ".godoctor", "review.md"), "- Outer rule.\n")
+	writeFile(t, filepath.Join(repo, "lib", ".godoctor", "review.md"), "---\nmode: replace\n---\n\nYou are a terse reviewer.\n")
+
+	text, err := review(t, map[string]string{"dir": filepath.Join(repo, "lib")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "### From `lib/.godoctor/review.md`\n\nYou are a terse reviewer.") {
+		t.Errorf("prompt missing the replacing guidelines:\n%s", text)
+	}
+	for _, unwanted := range []string{"Outer rule", "## Concurrency", "mode: replace"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("prompt should not contain %q:\n%s", unwanted, text)
+		}
+	}
+}
+
+func TestCodeReviewHandler_Limits(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".godoctor", "review.md"), strings.Repeat("- Keep functions short.\n", 1000))
+
+	text, err := review(t, map[string]string{"dir": repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "_(truncated at 16 KiB") {
+		t.Errorf("expected a truncation note:\n%s", text[len(text)-200:])
+	}
+	if n := strings.Count(text, "- Keep functions short."); n*len("- Keep functions short.\n") > maxGuidelinesBytes {
+		t.Errorf("%d lines exceed the cap", n)
+	}
+}
+
+func TestCodeReviewHandler_Malformed(t *testing.T) {
+	for _, tc := range []struct {
+		name, content, note string
+	}{
+		{"unknown mode", "---\nmode: override\n---\nhello\n", `invalid mode "override"`},
+		{"unclosed front matter", "---\nmode: replace\nhello\n", "front matter is not closed with ---"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := t.TempDir()
+			writeFile(t, filepath.Join(repo, ".godoctor", "review.md"), tc.content)
+
+			text, err := review(t, map[string]string{"dir": repo})
+			if err != nil {
+				t.Fatalf("malformed guidelines should not fail the prompt: %v", err)
+			}
+			if !strings.HasPrefix(text, codeReviewPrompt+"\n\n_Note: the repository review guidelines were ignored: ") {
+				t.Errorf("expected the built-in prompt followed by a note:\n%s", text)
+			}
+			if !strings.Contains(text, tc.note) {
+				t.Errorf("note missing %q:\n%s", tc.note, text)
+			}
+			if strings.Contains(text, "## Repository Guidelines") {
+				t.Errorf("prompt includes the malformed guidelines:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestCodeReviewHandler_NoGuidelines(t *testing.T) {
+	text, err := review(t, map[string]string{"dir": t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != codeReviewPrompt {
+		t.Errorf("expected the built-in prompt, got:\n%s", text)
+	}
+}
@@ -0,0 +1,148 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reviewGuidelinesFile is where a repository keeps its own review persona and guidelines.
+var reviewGuidelinesFile = filepath.Join(".godoctor", "review.md")
+
+// maxGuidelinesBytes caps what is read from each guidelines file, so a large document cannot
+// crowd the code out of the reviewer's context.
+const maxGuidelinesBytes = 16 << 10
+
+// guidelines is one review.md file.
+type guidelines struct {
+	Path      string // relative to the repository root
+	Text      string
+	Replace   bool // drop the built-in checklist and the files farther from the code
+	Truncated bool
+}
+
+// frontMatter is the optional YAML header of a review.md file.
+type frontMatter struct {
+	Mode string `yaml:"mode"` // extend (default) or replace
+}
+
+// findGuidelines collects the review.md files that apply to dir: the one in dir and those of its
+// parents up to the repository root (the nearest directory with a .git entry), farthest first.
+// Without a repository only dir itself is searched. Files before the last one that replaces its
+// predecessors are dropped.
+func findGuidelines(dir string) ([]*guidelines, error) {
+	var dirs []string
+	root := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if root == "" {
+		dirs, root = dirs[:1], dir
+	}
+
+	var found []*guidelines
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(dirs[i], reviewGuidelinesFile)
+		g, err := readGuidelines(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			g.Path = filepath.ToSlash(rel)
+		}
+		if g.Replace {
+			found = found[:0]
+		}
+		found = append(found, g)
+	}
+	return found, nil
+}
+
+// readGuidelines reads at most maxGuidelinesBytes of a review.md file and parses its front matter.
+func readGuidelines(path string) (*guidelines, error) {
+	//nolint:gosec // G304: The path is built from a validated workspace directory.
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(io.LimitReader(f, maxGuidelinesBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	g := &guidelines{Path: path}
+	if len(data) > maxGuidelinesBytes {
+		data, g.Truncated = data[:maxGuidelinesBytes], true
+		// Cut at the last full line rather than in the middle of a word or a rune.
+		if i := bytes.LastIndexByte(data, '\n'); i > 0 {
+			data = data[:i+1]
+		}
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		header, body, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			return nil, fmt.Errorf("%s: front matter is not closed with ---", path)
+		}
+		var fm frontMatter
+		if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+			return nil, fmt.Errorf("%s: invalid front matter: %w", path, err)
+		}
+		switch fm.Mode {
+		case "", "extend":
+		case "replace":
+			g.Replace = true
+		default:
+			return nil, fmt.Errorf("%s: invalid mode %q: must be 'extend' or 'replace'", path, fm.Mode)
+		}
+		text = body
+	}
+	g.Text = strings.TrimSpace(text)
+	return g, nil
+}
+
+// withGuidelines merges the repository guidelines into the built-in review prompt. Later files
+// are closer to the reviewed code and take precedence over earlier ones; all of them take
+// precedence over the built-in checklist, which a file in replace mode drops.
+func withGuidelines(prompt string, found []*guidelines) string {
+	if len(found) == 0 {
+		return prompt
+	}
+	var sb strings.Builder
+	if found[0].Replace {
+		sb.WriteString("You are conducting a Go code review following this repository's own guidelines.\n\n")
+	} else {
+		sb.WriteString(prompt)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("## Repository Guidelines\n\n")
+	if len(found) == 1 {
+		sb.WriteString("This repository defines its own review guidelines. Where they disagree with anything above, they win.\n")
+	} else {
+		sb.WriteString("This repository defines its own review guidelines. Where they disagree with anything above, they win; ")
+		sb.WriteString("between these files, the later one is closer to the reviewed code and wins.\n")
+	}
+	for _, g := range found {
+		fmt.Fprintf(&sb, "\n### From `%s`\n\n%s\n", g.Path, g.Text)
+		if g.Truncated {
+			fmt.Fprintf(&sb, "\n_(truncated at %d KiB: keep `%s` short)_\n", maxGuidelinesBytes>>10, reviewGuidelinesFile)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,260 @@
+// Package selfprofile measures godoctor's own hot paths: documentation lookups, the codemod edit
+// pipeline and module loading. The same scenarios back the package benchmarks and the
+// `godoctor self-profile` command, which keeps a history of results so that regressions in the
+// server itself show up between runs.
+package selfprofile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/godoc"
+	"github.com/danicat/godoctor/internal/tools/shared"
+)
+
+// Scenario is one measured code path.
+type Scenario struct {
+	Name        string
+	Description string
+	// Setup prepares the scenario in the scratch directory dir and returns the operation to measure.
+	Setup func(ctx context.Context, dir string) (func(ctx context.Context) error, error)
+}
+
+// Scenarios returns the measured code paths.
+func Scenarios() []Scenario {
+	return []Scenario{
+		{
+			Name:        "doc_lookup_cold",
+			Description: "read_docs of net/http.Client with an empty documentation cache",
+			Setup: func(ctx context.Context, dir string) (func(ctx context.Context) error, error) {
+				return func(ctx context.Context) error {
+					godoc.ResetCache()
+					_, err := godoc.Load(ctx, "net/http", "Client")
+					return err
+				}, nil
+			},
+		},
+		{
+			Name:        "doc_lookup_warm",
+			Description: "read_docs of net/http.Client served from the documentation cache",
+			Setup: func(ctx context.Context, dir string) (func(ctx context.Context) error, error) {
+				op := func(ctx context.Context) error {
+					_, err := godoc.Load(ctx, "net/http", "Client")
+					return err
+				}
+				return op, op(ctx)
+			},
+		},
+		{
+			Name:        "edit_pipeline",
+			Description: "format and write a 20-file changeset through shared.Changeset",
+			Setup:       setupEdit,
+		},
+		{
+			Name:        "module_load",
+			Description: "load and type-check a 20-package module, as the analysis tools do",
+			Setup: func(ctx context.Context, dir string) (func(ctx context.Context) error, error) {
+				if err := writeModule(dir); err != nil {
+					return nil, err
+				}
+				return func(ctx context.Context) error {
+					_, err := shared.LoadPackages(ctx, dir, "./...", false)
+					return err
+				}, nil
+			},
+		},
+	}
+}
+
+// scenarioPackages is the number of packages, and files, of the synthetic module.
+const scenarioPackages = 20
+
+// writeModule writes a module with scenarioPackages packages, each importing the previous one.
+func writeModule(dir string) error {
+	files := map[string]string{"go.mod": "module example.com/selfprofile\n\ngo 1.22\n"}
+	for i := 0; i < scenarioPackages; i++ {
+		files[filepath.Join(fmt.Sprintf("p%02d", i), "p.go")] = packageSource(i, 0)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageSource returns the source of package i of the synthetic module; variant changes the
+// function bodies so that successive edits differ.
+func packageSource(i, variant int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// Package p%02d is generated by godoctor self-profile.\npackage p%02d\n\n", i, i)
+	sb.WriteString("import (\n\t\"fmt\"\n\t\"strings\"\n")
+	if i > 0 {
+		fmt.Fprintf(&sb, "\n\t\"example.com/selfprofile/p%02d\"\n", i-1)
+	}
+	sb.WriteString(")\n\n")
+	for f := 0; f < 25; f++ {
+		fmt.Fprintf(&sb, "// F%d joins its arguments.\nfunc F%d(parts ...string) string {\n", f, f)
+		fmt.Fprintf(&sb, "\tif len(parts) == %d {\n\t\treturn fmt.Sprint(len(parts))\n\t}\n", variant)
+		sb.WriteString("\treturn strings.Join(parts, \",\")\n}\n\n")
+	}
+	if i > 0 {
+		fmt.Fprintf(&sb, "// Prev calls the previous package.\nfunc Prev() string { return p%02d.F0(\"x\") }\n", i-1)
+	}
+	return sb.String()
+}
+
+func setupEdit(ctx context.Context, dir string) (func(ctx context.Context) error, error) {
+	if err := writeModule(dir); err != nil {
+		return nil, err
+	}
+	variant := 0
+	return func(ctx context.Context) error {
+		variant = 1 - variant
+		cs := make(shared.Changeset, scenarioPackages)
+		for i := 0; i < scenarioPackages; i++ {
+			cs[filepath.Join(dir, fmt.Sprintf("p%02d", i), "p.go")] = []byte(packageSource(i, variant))
+		}
+		// No verification commands: the go toolchain is measured by module_load.
+		return cs.ApplyVerified(ctx, dir)
+	}, nil
+}
+
+// Result is the measurement of one scenario.
+type Result struct {
+	Name       string `json:"name"`
+	Runs       int    `json:"runs"`
+	MedianNs   int64  `json:"medianNs"`
+	MinNs      int64  `json:"minNs"`
+	BytesPerOp uint64 `json:"bytesPerOp"`
+	AllocsOp   uint64 `json:"allocsPerOp"`
+}
+
+// Measure runs the scenario's operation runs times, after one warm-up run, in a fresh scratch
+// directory.
+func Measure(ctx context.Context, sc Scenario, runs int) (Result, error) {
+	res := Result{Name: sc.Name, Runs: runs}
+	dir, err := os.MkdirTemp("", "godoctor-selfprofile-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(dir)
+
+	op, err := sc.Setup(ctx, dir)
+	if err != nil {
+		return res, fmt.Errorf("%s: setup failed: %w", sc.Name, err)
+	}
+	if err := op(ctx); err != nil {
+		return res, fmt.Errorf("%s: %w", sc.Name, err)
+	}
+
+	durations := make([]int64, 0, runs)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if err := op(ctx); err != nil {
+			return res, fmt.Errorf("%s: %w", sc.Name, err)
+		}
+		durations = append(durations, time.Since(start).Nanoseconds())
+	}
+	runtime.ReadMemStats(&after)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	res.MinNs = durations[0]
+	res.MedianNs = durations[len(durations)/2]
+	res.BytesPerOp = (after.TotalAlloc - before.TotalAlloc) / uint64(runs)
+	res.AllocsOp = (after.Mallocs - before.Mallocs) / uint64(runs)
+	return res, nil
+}
+
+// Run is one self-profile run as stored in the history file.
+type Run struct {
+	Time      time.Time `json:"time"`
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	GoVersion string    `json:"goVersion"`
+	Platform  string    `json:"platform"`
+	Results   []Result  `json:"results"`
+}
+
+// maxHistory bounds the number of runs kept in the history file.
+const maxHistory = 50
+
+// LoadHistory reads the runs stored at path, oldest first. A missing file is an empty history.
+func LoadHistory(path string) ([]Run, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("invalid history file %s: %w", path, err)
+	}
+	return runs, nil
+}
+
+// SaveHistory writes runs to path, keeping the most recent maxHistory.
+func SaveHistory(path string, runs []Run) error {
+	if len(runs) > maxHistory {
+		runs = runs[len(runs)-maxHistory:]
+	}
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Delta compares a scenario between two runs.
+type Delta struct {
+	Name      string
+	PrevNs    int64
+	CurNs     int64
+	Change    float64 // relative change of the median, e.g. 0.25 for 25% slower
+	Regressed bool
+}
+
+// Compare returns the change of each scenario present in both runs. A scenario regressed when
+// its median grew by more than threshold (e.g. 0.25).
+func Compare(prev, cur Run, threshold float64) []Delta {
+	old := make(map[string]Result, len(prev.Results))
+	for _, r := range prev.Results {
+		old[r.Name] = r
+	}
+	var deltas []Delta
+	for _, r := range cur.Results {
+		p, ok := old[r.Name]
+		if !ok || p.MedianNs == 0 {
+			continue
+		}
+		change := float64(r.MedianNs-p.MedianNs) / float64(p.MedianNs)
+		deltas = append(deltas, Delta{
+			Name:      r.Name,
+			PrevNs:    p.MedianNs,
+			CurNs:     r.MedianNs,
+			Change:    change,
+			Regressed: change > threshold,
+		})
+	}
+	return deltas
+}
@@ -0,0 +1,77 @@
+package selfprofile
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// BenchmarkScenarios runs every self-profile scenario as a sub-benchmark, e.g.
+// go test -bench Scenarios/doc_lookup ./internal/selfprofile.
+func BenchmarkScenarios(b *testing.B) {
+	ctx := context.Background()
+	for _, sc := range Scenarios() {
+		b.Run(sc.Name, func(b *testing.B) {
+			op, err := sc.Setup(ctx, b.TempDir())
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := op(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go toolchain")
+	}
+	for _, sc := range Scenarios() {
+		res, err := Measure(context.Background(), sc, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.MedianNs <= 0 || res.Runs != 1 {
+			t.Errorf("%s: unexpected result %+v", sc.Name, res)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	prev := Run{Results: []Result{{Name: "a", MedianNs: 100}, {Name: "b", MedianNs: 100}, {Name: "gone", MedianNs: 5}}}
+	cur := Run{Results: []Result{{Name: "a", MedianNs: 110}, {Name: "b", MedianNs: 150}, {Name: "new", MedianNs: 5}}}
+	deltas := Compare(prev, cur, 0.25)
+	if len(deltas) != 2 {
+		t.Fatalf("got %d deltas, want 2: %+v", len(deltas), deltas)
+	}
+	if deltas[0].Regressed || !deltas[1].Regressed || deltas[1].Change != 0.5 {
+		t.Errorf("unexpected deltas: %+v", deltas)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.json")
+	runs, err := LoadHistory(path)
+	if err != nil || runs != nil {
+		t.Fatalf("LoadHistory(missing) = %v, %v", runs, err)
+	}
+	for i := 0; i < maxHistory+3; i++ {
+		runs = append(runs, Run{Time: time.Unix(int64(i), 0).UTC(), Version: "dev"})
+	}
+	if err := SaveHistory(path, runs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxHistory || !got[0].Time.Equal(time.Unix(3, 0)) {
+		t.Errorf("got %d runs starting at %v, want %d starting at 3s", len(got), got[0].Time, maxHistory)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/config"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// paramRef matches a parameter mention such as `dry_run=true` or `output_format="json"`.
+var paramRef = regexp.MustCompile(`(?:^|[\s(,` + "`" + `])([a-z][a-z0-9_]*)=`)
+
+// paramRefs returns the parameters that text names, keyed by tool: arguments inside a call
+// such as `tool(key=...)` belong to the called tool, bare mentions to owner.
+func paramRefs(owner, text string) map[string][]string {
+	refs := make(map[string][]string)
+	var rest strings.Builder
+	for i := 0; i < len(text); {
+		open := strings.IndexByte(text[i:], '(')
+		if open < 0 {
+			rest.WriteString(text[i:])
+			break
+		}
+		open += i
+		start := open
+		for start > 0 && (text[start-1] == '_' || 'a' <= text[start-1] && text[start-1] <= 'z') {
+			start--
+		}
+		name := text[start:open]
+		if _, ok := toolnames.Registry[name]; !ok {
+			rest.WriteString(text[i : open+1])
+			i = open + 1
+			continue
+		}
+		// Collect the top-level arguments up to the matching parenthesis, skipping quoted strings.
+		var args strings.Builder
+		depth, inString, j := 0, false, open+1
+		for ; j < len(text) && (inString || depth > 0 || text[j] != ')'); j++ {
+			c := text[j]
+			switch {
+			case inString && c == '\\':
+				j++
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '(' || c == '[' || c == '{':
+				depth++
+			case c == ')' || c == ']' || c == '}':
+				depth--
+			case depth == 0:
+				args.WriteByte(c)
+			}
+		}
+		for _, m := range paramRef.FindAllStringSubmatch(" "+args.String(), -1) {
+			refs[name] = append(refs[name], m[1])
+		}
+		rest.WriteString(text[i:start])
+		i = j + 1
+	}
+	for _, m := range paramRef.FindAllStringSubmatch(rest.String(), -1) {
+		refs[owner] = append(refs[owner], m[1])
+	}
+	return refs
+}
+
+func TestRegistry_DocumentedParamsExist(t *testing.T) {
+	ctx := context.Background()
+	s := New(&config.Config{}, "test")
+	if err := s.RegisterHandlers(); err != nil {
+		t.Fatal(err)
+	}
+	clientT, serverT := mcp.NewInMemoryTransports()
+	if _, err := s.mcpServer.Connect(ctx, serverT, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cs.Close() }()
+
+	params := make(map[string][]string)
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, _ := tool.InputSchema.(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		for name := range props {
+			params[tool.Name] = append(params[tool.Name], name)
+		}
+	}
+
+	for _, def := range toolnames.Registry {
+		for _, text := range []string{def.Description, def.Instruction} {
+			for tool, names := range paramRefs(def.Name, text) {
+				if _, ok := params[tool]; !ok {
+					t.Errorf("%s: mentions tool %s, which is not registered", def.Name, tool)
+					continue
+				}
+				for _, name := range names {
+					if !slices.Contains(params[tool], name) {
+						t.Errorf("%s: documents %s(%s=...), but %s has no parameter %q", def.Name, tool, name, tool, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestParamRefs(t *testing.T) {
+	got := paramRefs("diff", "`diff(old=\"/a\", content=\"f(x=1)\")` or `read_docs(import_path=\"fmt\")`; set `output_format=\"json\"`.")
+	want := map[string][]string{"diff": {"old", "content", "output_format"}, "read_docs": {"import_path"}}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("paramRefs() = %v, want %v", got, want)
+	}
+}
@@ -8,27 +8,81 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"net/http"
+	"sort"
 	"strings"
 
+	"github.com/danicat/godoctor/internal/budget"
+	"github.com/danicat/godoctor/internal/chaos"
 	"github.com/danicat/godoctor/internal/config"
+	"github.com/danicat/godoctor/internal/godoc"
 	"github.com/danicat/godoctor/internal/instructions"
+	"github.com/danicat/godoctor/internal/locale"
 	"github.com/danicat/godoctor/internal/prompts"
 	resgodoc "github.com/danicat/godoctor/internal/resources/godoc"
 	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/transcript"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	// Tools
+	"github.com/danicat/godoctor/internal/tools/exportsession"
+	"github.com/danicat/godoctor/internal/tools/file/diff"
 	"github.com/danicat/godoctor/internal/tools/file/edit"
 	"github.com/danicat/godoctor/internal/tools/file/list"
+	"github.com/danicat/godoctor/internal/tools/file/merge"
 	"github.com/danicat/godoctor/internal/tools/file/read"
+	"github.com/danicat/godoctor/internal/tools/go/allocreview"
+	"github.com/danicat/godoctor/internal/tools/go/audit/configdrift"
+	"github.com/danicat/godoctor/internal/tools/go/audit/deadlocks"
+	"github.com/danicat/godoctor/internal/tools/go/audit/determinism"
+	"github.com/danicat/godoctor/internal/tools/go/audit/doccoverage"
+	"github.com/danicat/godoctor/internal/tools/go/audit/globals"
+	"github.com/danicat/godoctor/internal/tools/go/audit/httpclient"
+	"github.com/danicat/godoctor/internal/tools/go/audit/logging"
+	"github.com/danicat/godoctor/internal/tools/go/audit/naming"
+	"github.com/danicat/godoctor/internal/tools/go/audit/panics"
+	"github.com/danicat/godoctor/internal/tools/go/audit/sqlleaks"
+	"github.com/danicat/godoctor/internal/tools/go/audit/strbuild"
+	"github.com/danicat/godoctor/internal/tools/go/audit/streaming"
+	"github.com/danicat/godoctor/internal/tools/go/audit/visibility"
+	"github.com/danicat/godoctor/internal/tools/go/benchcmp"
+	"github.com/danicat/godoctor/internal/tools/go/contextpack"
+	"github.com/danicat/godoctor/internal/tools/go/dephealth"
 	"github.com/danicat/godoctor/internal/tools/go/docs"
+	"github.com/danicat/godoctor/internal/tools/go/docs/export"
+	"github.com/danicat/godoctor/internal/tools/go/docs/prefetch"
+	"github.com/danicat/godoctor/internal/tools/go/generate/concurrency"
+	"github.com/danicat/godoctor/internal/tools/go/generate/constructor"
+	"github.com/danicat/godoctor/internal/tools/go/generate/enum"
+	"github.com/danicat/godoctor/internal/tools/go/generate/openapiclient"
+	"github.com/danicat/godoctor/internal/tools/go/generate/openapimock"
 	"github.com/danicat/godoctor/internal/tools/go/get"
+	"github.com/danicat/godoctor/internal/tools/go/leakcheck"
+	"github.com/danicat/godoctor/internal/tools/go/modsearch"
 	"github.com/danicat/godoctor/internal/tools/go/mutation"
 	"github.com/danicat/godoctor/internal/tools/go/navigation"
 	"github.com/danicat/godoctor/internal/tools/go/project"
 	"github.com/danicat/godoctor/internal/tools/go/quality"
+	"github.com/danicat/godoctor/internal/tools/go/racefix"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/extractmod"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/i18n"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/idiom"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/importpath"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/inline"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/paramobj"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/rename"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/replacedep"
+	"github.com/danicat/godoctor/internal/tools/go/release"
+	"github.com/danicat/godoctor/internal/tools/go/release/prdesc"
+	"github.com/danicat/godoctor/internal/tools/go/release/version"
+	"github.com/danicat/godoctor/internal/tools/go/snippet"
+	"github.com/danicat/godoctor/internal/tools/go/snippetlib"
+	"github.com/danicat/godoctor/internal/tools/go/testconv"
 	"github.com/danicat/godoctor/internal/tools/go/testquery"
+	"github.com/danicat/godoctor/internal/tools/go/wiring"
 )
 
 // Server encapsulates the MCP server and its configuration.
@@ -40,6 +94,13 @@ type Server struct {
 
 // New creates a new Server instance.
 func New(cfg *config.Config, version string) *Server {
+	if cfg.Locale != "" {
+		// Load has already validated the locale.
+		_ = locale.SetDefault(cfg.Locale)
+	}
+	shared.SetLocalOnly(cfg.NoCloud)
+	// State kept per session, dropped when the session ends.
+	forget := []func(*mcp.ServerSession){roots.Global.Delete}
 	s := mcp.NewServer(&mcp.Implementation{
 		Name:    "godoctor",
 		Version: version,
@@ -47,11 +108,33 @@ func New(cfg *config.Config, version string) *Server {
 		Instructions: instructions.Get(cfg),
 		InitializedHandler: func(ctx context.Context, req *mcp.InitializedRequest) {
 			roots.Global.Sync(ctx, req.Session)
+			go forgetOnClose(req.Session, forget)
 		},
 		RootsListChangedHandler: func(ctx context.Context, req *mcp.RootsListChangedRequest) {
 			roots.Global.Sync(ctx, req.Session)
 		},
+		Capabilities: capabilities(cfg),
 	})
+	if cfg.Chaos {
+		seed := cfg.ChaosSeed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		// Logged so that a failing client run can be replayed with --chaos-seed.
+		log.Printf("chaos mode enabled: rate %.2f, seed %d", cfg.ChaosRate, seed)
+		s.AddReceivingMiddleware(chaos.New(chaos.Options{Rate: cfg.ChaosRate, Seed: seed, Log: log.Writer()}).Middleware)
+	}
+	if limits := (budget.Limits{CPU: cfg.BudgetCPU, Write: cfg.BudgetWrite}); limits.Enabled() {
+		tracker := budget.New(limits)
+		s.AddReceivingMiddleware(tracker.Middleware)
+		forget = append(forget, tracker.Delete)
+	}
+	if cfg.IsToolEnabled("export_session") {
+		// Added last so that it runs first and records what the client actually received. Calls are
+		// only worth keeping when the session can be exported.
+		s.AddReceivingMiddleware(transcript.Global.Middleware)
+		forget = append(forget, transcript.Global.Delete)
+	}
 
 	return &Server{
 		mcpServer:       s,
@@ -60,11 +143,39 @@ func New(cfg *config.Config, version string) *Server {
 	}
 }
 
+// capabilities returns the server capabilities advertised at initialization: the SDK default
+// (logging) and, in local-only mode, an experimental "localOnly" entry listing the tools it
+// disables, so that clients can tell them apart from tools missing for other reasons.
+func capabilities(cfg *config.Config) *mcp.ServerCapabilities {
+	caps := &mcp.ServerCapabilities{Logging: &mcp.LoggingCapabilities{}}
+	if !cfg.NoCloud {
+		return caps
+	}
+	disabled := []string{}
+	for name, def := range toolnames.Registry {
+		if def.Cloud {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(disabled)
+	caps.Experimental = map[string]any{"localOnly": map[string]any{"disabledTools": disabled}}
+	return caps
+}
+
+// forgetOnClose waits for the session to end and then drops the state kept for it.
+func forgetOnClose(session *mcp.ServerSession, forget []func(*mcp.ServerSession)) {
+	_ = session.Wait()
+	for _, f := range forget {
+		f(session)
+	}
+}
+
 // Run starts the MCP server using Stdio.
 func (s *Server) Run(ctx context.Context) error {
 	if err := s.RegisterHandlers(); err != nil {
 		return err
 	}
+	defer godoc.Cleanup()
 	return s.mcpServer.Run(ctx, &mcp.StdioTransport{})
 }
 
@@ -73,6 +184,7 @@ func (s *Server) ServeHTTP(ctx context.Context, addr string) error {
 	if err := s.RegisterHandlers(); err != nil {
 		return err
 	}
+	defer godoc.Cleanup()
 
 	mcpHandler := mcp.NewStreamableHTTPHandler(func(request *http.Request) *mcp.Server {
 		return s.mcpServer
@@ -124,17 +236,63 @@ func (s *Server) RegisterHandlers() error {
 
 	availableTools := []toolDef{
 		{name: "read_docs", register: docs.Register},
+		{name: "prefetch_docs", register: prefetch.Register},
+		{name: "export_docs", register: export.Register},
 		{name: "smart_read", register: read.Register},
 		{name: "smart_edit", register: edit.Register},
+		{name: "diff", register: diff.Register},
+		{name: "merge_edit", register: merge.Register},
+		{name: "export_session", register: exportsession.Register},
 		{name: "list_files", register: list.Register},
 
 		{name: "smart_build", register: quality.Register},
 
 		{name: "project_init", register: project.Register},
+		{name: "release_check", register: release.Register},
+		{name: "suggest_version", register: version.Register},
+		{name: "describe_pr", register: prdesc.Register},
+		{name: "eval_snippet", register: snippet.Register},
 		{name: "add_dependency", register: get.Register},
+		{name: "search_modules", register: modsearch.Register},
+		{name: "dependency_health", register: dephealth.Register},
 		{name: "mutation_test", register: mutation.Register},
 		{name: "test_query", register: testquery.Register},
+		{name: "bench_compare", register: benchcmp.Register},
+		{name: "convert_test", register: testconv.Register},
+		{name: "leak_check", register: leakcheck.Register},
+		{name: "review_allocations", register: allocreview.Register},
+		{name: "fix_data_race", register: racefix.Register},
 		{name: "describe_symbol", register: navigation.Register},
+		{name: "build_context", register: contextpack.Register},
+
+		{name: "audit_panics", register: panics.Register},
+		{name: "audit_deadlocks", register: deadlocks.Register},
+		{name: "audit_globals", register: globals.Register},
+		{name: "audit_determinism", register: determinism.Register},
+		{name: "audit_streaming", register: streaming.Register},
+		{name: "audit_strings", register: strbuild.Register},
+		{name: "audit_http", register: httpclient.Register},
+		{name: "audit_sql", register: sqlleaks.Register},
+		{name: "inspect_wiring", register: wiring.Register},
+		{name: "audit_config", register: configdrift.Register},
+		{name: "audit_logging", register: logging.Register},
+		{name: "audit_doc_coverage", register: doccoverage.Register},
+		{name: "audit_visibility", register: visibility.Register},
+		{name: "audit_naming", register: naming.Register},
+		{name: "generate_constructor", register: constructor.Register},
+		{name: "generate_enum", register: enum.Register},
+		{name: "get_snippet", register: snippetlib.Register},
+		{name: "suggest_concurrency", register: concurrency.Register},
+		{name: "generate_openapi_client", register: openapiclient.Register},
+		{name: "generate_openapi_mock", register: openapimock.Register},
+		{name: "extract_strings", register: i18n.Register},
+		{name: "extract_module", register: extractmod.Register},
+		{name: "rewrite_import_path", register: importpath.Register},
+		{name: "rename_symbols", register: rename.Register},
+		{name: "replace_dependency", register: replacedep.Register},
+		{name: "rewrite_idioms", register: idiom.Register},
+		{name: "inline_symbol", register: inline.Register},
+		{name: "introduce_parameter_object", register: paramobj.Register},
 	}
 
 	validTools := make(map[string]bool)
@@ -149,8 +307,13 @@ func (s *Server) RegisterHandlers() error {
 		}
 	}
 
-	// Validate disabled tools
+	// Validate disabled tools, in name order so that the error is reproducible
+	disabled := make([]string, 0, len(s.cfg.DisabledTools))
 	for name := range s.cfg.DisabledTools {
+		disabled = append(disabled, name)
+	}
+	sort.Strings(disabled)
+	for _, name := range disabled {
 		if !validTools[name] {
 			return fmt.Errorf("unknown tool disabled: %s", name)
 		}
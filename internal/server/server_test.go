@@ -1,9 +1,14 @@
 package server
 
 import (
+	"context"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/danicat/godoctor/internal/config"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestServer_RegisterHandlers_DisableTools(t *testing.T) {
@@ -47,3 +52,75 @@ func TestServer_RegisterHandlers_DisableTools(t *testing.T) {
 		})
 	}
 }
+
+func TestForgetOnClose(t *testing.T) {
+	ctx := context.Background()
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	clientT, serverT := mcp.NewInMemoryTransports()
+	ss, err := srv.Connect(ctx, serverT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forgotten := make(chan *mcp.ServerSession, 1)
+	go forgetOnClose(ss, []func(*mcp.ServerSession){func(s *mcp.ServerSession) { forgotten <- s }})
+	_ = cs.Close()
+	select {
+	case s := <-forgotten:
+		if s != ss {
+			t.Error("another session was forgotten")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session state was not dropped after the session closed")
+	}
+}
+
+func TestServer_LocalOnlyCapabilities(t *testing.T) {
+	// New sets local-only mode for the whole process.
+	defer shared.SetLocalOnly(false)
+	for _, noCloud := range []bool{false, true} {
+		ctx := context.Background()
+		s := New(&config.Config{NoCloud: noCloud}, "test")
+		if err := s.RegisterHandlers(); err != nil {
+			t.Fatal(err)
+		}
+		clientT, serverT := mcp.NewInMemoryTransports()
+		if _, err := s.mcpServer.Connect(ctx, serverT, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientT, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caps := cs.InitializeResult().Capabilities
+		_ = cs.Close()
+
+		if caps.Logging == nil {
+			t.Errorf("noCloud=%v: the logging capability is no longer advertised", noCloud)
+		}
+		localOnly, ok := caps.Experimental["localOnly"].(map[string]any)
+		if ok != noCloud {
+			t.Errorf("noCloud=%v: experimental capabilities = %v", noCloud, caps.Experimental)
+			continue
+		}
+		if !noCloud {
+			continue
+		}
+		var disabled []string
+		for _, name := range localOnly["disabledTools"].([]any) {
+			disabled = append(disabled, name.(string))
+		}
+		for _, name := range []string{"dependency_health", "generate_openapi_client", "search_modules"} {
+			if !slices.Contains(disabled, name) {
+				t.Errorf("disabledTools = %v, missing %s", disabled, name)
+			}
+		}
+		if slices.Contains(disabled, "smart_build") {
+			t.Errorf("disabledTools = %v, includes a local tool", disabled)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package testutil holds the fixtures shared by the tests of the tools.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// GoMod returns the content of a go.mod file for module path at the Go version the fixtures use.
+func GoMod(path string) string {
+	return "module " + path + "\n\ngo 1.22\n"
+}
+
+// WriteModule writes files into a new temporary directory and returns it. Files are keyed by
+// slash-separated path relative to the directory; a go.mod for example.com/test is added when
+// files has none.
+func WriteModule(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, ok := files["go.mod"]; !ok {
+		WriteFiles(t, dir, map[string]string{"go.mod": GoMod("example.com/test")})
+	}
+	WriteFiles(t, dir, files)
+	return dir
+}
+
+// WriteFiles writes files under dir, creating directories as needed. Files are keyed by
+// slash-separated path relative to dir.
+func WriteFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteModule(t *testing.T) {
+	dir := WriteModule(t, map[string]string{"pkg/a/a.go": "package a\n"})
+	for name, want := range map[string]string{
+		"go.mod":     "module example.com/test\n\ngo 1.22\n",
+		"pkg/a/a.go": "package a\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	dir = WriteModule(t, map[string]string{"go.mod": GoMod("example.com/other")})
+	got, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "module example.com/other\n\ngo 1.22\n"; string(got) != want {
+		t.Errorf("go.mod = %q, want %q", got, want)
+	}
+}
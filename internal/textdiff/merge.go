@@ -0,0 +1,165 @@
+package textdiff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict is a region that both sides changed differently.
+type Conflict struct {
+	Line   int      `json:"line"` // 1-based line of the "<<<<<<<" marker in the merged text
+	Base   []string `json:"base"`
+	Ours   []string `json:"ours"`
+	Theirs []string `json:"theirs"`
+}
+
+// Merged is the outcome of a three-way merge.
+type Merged struct {
+	Conflicts     []Conflict
+	OursChanges   int // changed regions of ours relative to base
+	TheirsChanges int // changed regions of theirs relative to base
+
+	chunks  []mergeChunk
+	newline bool
+}
+
+type mergeChunk struct {
+	lines    []string // merged lines, when there is no conflict
+	conflict *Conflict
+}
+
+// change replaces base lines [start, end) with lines.
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// Merge combines the changes that ours and theirs made to base. Changes to separate regions are
+// both applied; identical changes are applied once; different changes to the same or adjacent
+// lines are conflicts.
+func Merge(base, ours, theirs string) *Merged {
+	b := splitLines(base)
+	oc := changes(b, splitLines(ours))
+	tc := changes(b, splitLines(theirs))
+	m := &Merged{
+		OursChanges:   len(oc),
+		TheirsChanges: len(tc),
+		newline:       strings.HasSuffix(ours, "\n") || strings.HasSuffix(theirs, "\n"),
+	}
+
+	line := 1 // next line of the marked-up text
+	emit := func(lines []string) {
+		if len(lines) > 0 {
+			m.chunks = append(m.chunks, mergeChunk{lines: lines})
+			line += len(lines)
+		}
+	}
+	pos, i, j := 0, 0, 0
+	for i < len(oc) || j < len(tc) {
+		// Start a group with the earliest change, then absorb every change of either side that
+		// overlaps or touches it.
+		var start, end int
+		if j >= len(tc) || (i < len(oc) && oc[i].start <= tc[j].start) {
+			start, end = oc[i].start, oc[i].end
+		} else {
+			start, end = tc[j].start, tc[j].end
+		}
+		i0, j0 := i, j
+	absorb:
+		for {
+			switch {
+			case i < len(oc) && oc[i].start <= end:
+				end = max(end, oc[i].end)
+				i++
+			case j < len(tc) && tc[j].start <= end:
+				end = max(end, tc[j].end)
+				j++
+			default:
+				break absorb
+			}
+		}
+		emit(b[pos:start])
+		oLines := apply(b, oc[i0:i], start, end)
+		tLines := apply(b, tc[j0:j], start, end)
+		switch {
+		case j == j0:
+			emit(oLines)
+		case i == i0 || slices.Equal(oLines, tLines):
+			emit(tLines)
+		default:
+			c := &Conflict{Line: line, Base: b[start:end], Ours: oLines, Theirs: tLines}
+			m.Conflicts = append(m.Conflicts, *c)
+			m.chunks = append(m.chunks, mergeChunk{conflict: c})
+			line += len(c.Base) + len(c.Ours) + len(c.Theirs) + 4
+		}
+		pos = end
+	}
+	emit(b[pos:])
+	return m
+}
+
+// Text returns the merged text. Conflicts are written with diff3-style markers labeled with the
+// given names.
+func (m *Merged) Text(oursLabel, theirsLabel string) string {
+	var lines []string
+	for _, c := range m.chunks {
+		if c.conflict == nil {
+			lines = append(lines, c.lines...)
+			continue
+		}
+		lines = append(lines, "<<<<<<< "+oursLabel)
+		lines = append(lines, c.conflict.Ours...)
+		lines = append(lines, "||||||| base")
+		lines = append(lines, c.conflict.Base...)
+		lines = append(lines, "=======")
+		lines = append(lines, c.conflict.Theirs...)
+		lines = append(lines, ">>>>>>> "+theirsLabel)
+	}
+	text := strings.Join(lines, "\n")
+	if m.newline && len(lines) > 0 {
+		text += "\n"
+	}
+	return text
+}
+
+// changes returns the changed regions of b relative to base, in order.
+func changes(base, b []string) []change {
+	var out []change
+	pos := 0
+	var cur *change
+	for _, op := range lineOps(base, b) {
+		if op.Kind == Context {
+			if cur != nil {
+				out = append(out, *cur)
+				cur = nil
+			}
+			pos++
+			continue
+		}
+		if cur == nil {
+			cur = &change{start: pos, end: pos}
+		}
+		if op.Kind == Delete {
+			pos++
+			cur.end = pos
+		} else {
+			cur.lines = append(cur.lines, op.Text)
+		}
+	}
+	if cur != nil {
+		out = append(out, *cur)
+	}
+	return out
+}
+
+// apply returns base lines [start, end) with the given changes, which lie within that range.
+func apply(base []string, cs []change, start, end int) []string {
+	out := []string{}
+	pos := start
+	for _, c := range cs {
+		out = append(out, base[pos:c.start]...)
+		out = append(out, c.lines...)
+		pos = c.end
+	}
+	return append(out, base[pos:end]...)
+}
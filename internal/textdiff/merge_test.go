@@ -0,0 +1,72 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\ng\n"
+	tests := []struct {
+		name          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "separate regions",
+			ours:   "a\nB\nc\nd\ne\nf\ng\n",
+			theirs: "a\nb\nc\nd\ne\nF\ng\n",
+			want:   "a\nB\nc\nd\ne\nF\ng\n",
+		},
+		{
+			name:   "only ours",
+			ours:   "a\nb\nc\nx\nd\ne\nf\ng\n",
+			theirs: base,
+			want:   "a\nb\nc\nx\nd\ne\nf\ng\n",
+		},
+		{
+			name:   "theirs inserted lines above the edit",
+			ours:   "a\nb\nc\nd\ne\nf\nG\n",
+			theirs: "header\n\na\nb\nc\nd\ne\nf\ng\n",
+			want:   "header\n\na\nb\nc\nd\ne\nf\nG\n",
+		},
+		{
+			name:   "identical change",
+			ours:   "a\nb\nC\nd\ne\nf\ng\n",
+			theirs: "a\nb\nC\nd\ne\nf\ng\n",
+			want:   "a\nb\nC\nd\ne\nf\ng\n",
+		},
+		{
+			name:          "conflict",
+			ours:          "a\nb\nours\nd\ne\nf\ng\n",
+			theirs:        "a\nb\ntheirs\nd\ne\nf\ng\n",
+			want:          "a\nb\n<<<<<<< edited\nours\n||||||| base\nc\n=======\ntheirs\n>>>>>>> current\nd\ne\nf\ng\n",
+			wantConflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Merge(base, tt.ours, tt.theirs)
+			if got := m.Text("edited", "current"); got != tt.want {
+				t.Errorf("Merge() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if len(m.Conflicts) != tt.wantConflicts {
+				t.Errorf("got %d conflicts, want %d", len(m.Conflicts), tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMerge_ConflictLine(t *testing.T) {
+	m := Merge("1\n2\n3\n4\n5\n6\n7\n8\n9\n", "one\n2\n3\n4\n5\n6\n7\n8\nnine\n", "uno\n2\n3\n4\n5\n6\n7\n8\nnueve\n")
+	if len(m.Conflicts) != 2 {
+		t.Fatalf("got %d conflicts, want 2", len(m.Conflicts))
+	}
+	lines := strings.Split(m.Text("a", "b"), "\n")
+	for _, c := range m.Conflicts {
+		if !strings.HasPrefix(lines[c.Line-1], "<<<<<<<") {
+			t.Errorf("conflict line %d is %q, want a conflict marker", c.Line, lines[c.Line-1])
+		}
+	}
+}
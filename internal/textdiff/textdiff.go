@@ -0,0 +1,201 @@
+// Package textdiff computes line-based diffs and renders them as unified diff hunks.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+// maxLCSCells bounds the work of the line matching; larger changes are shown as a full
+// replacement of the differing region.
+const maxLCSCells = 4 << 20
+
+// Line kinds.
+const (
+	Context = "context"
+	Delete  = "delete"
+	Insert  = "insert"
+)
+
+// Line is one line of a hunk.
+type Line struct {
+	Kind string `json:"kind"` // Context, Delete or Insert
+	Text string `json:"text"`
+}
+
+// Hunk is a group of changes with their surrounding context. Start lines are 1-based, or 0 for an
+// empty range, as in unified diffs.
+type Hunk struct {
+	OldStart int    `json:"oldStart"`
+	OldLines int    `json:"oldLines"`
+	NewStart int    `json:"newStart"`
+	NewLines int    `json:"newLines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Header returns the "@@ -a,b +c,d @@" line of the hunk.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// String renders the hunk in unified diff format.
+func (h Hunk) String() string {
+	var sb strings.Builder
+	sb.WriteString(h.Header() + "\n")
+	for _, l := range h.Lines {
+		sb.WriteString(l.prefix() + l.Text + "\n")
+	}
+	return sb.String()
+}
+
+func (l Line) prefix() string {
+	switch l.Kind {
+	case Delete:
+		return "-"
+	case Insert:
+		return "+"
+	}
+	return " "
+}
+
+// Compute returns the hunks that turn before into after, with context unchanged lines around each
+// change. Identical inputs have no hunks.
+func Compute(before, after string, context int) []Hunk {
+	ops := lineOps(splitLines(before), splitLines(after))
+
+	// Positions of each op in the old and new files.
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.Kind != Insert {
+			aPos[i+1]++
+		}
+		if op.Kind != Delete {
+			bPos[i+1]++
+		}
+		if op.Kind != Context {
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []Hunk
+	for k := 0; k < len(changes); {
+		first, last := changes[k], changes[k]
+		for k++; k < len(changes) && changes[k]-last-1 <= 2*context; k++ {
+			last = changes[k]
+		}
+		start := max(0, first-context)
+		end := min(len(ops), last+context+1)
+		h := Hunk{
+			OldStart: aPos[start],
+			OldLines: aPos[end] - aPos[start],
+			NewStart: bPos[start],
+			NewLines: bPos[end] - bPos[start],
+			Lines:    ops[start:end],
+		}
+		if h.OldLines > 0 {
+			h.OldStart++
+		}
+		if h.NewLines > 0 {
+			h.NewStart++
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// Unified returns the unified diff hunks that turn before into after, without file headers, or
+// "" if they are identical.
+func Unified(before, after string) string {
+	var sb strings.Builder
+	for _, h := range Compute(before, after, DefaultContext) {
+		sb.WriteString(h.String())
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineOps matches the lines of a and b, keeping common prefixes and suffixes out of the quadratic
+// matching of the region in between.
+func lineOps(a, b []string) []Line {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []Line
+	for _, l := range a[:pre] {
+		ops = append(ops, Line{Context, l})
+	}
+	ops = append(ops, middleOps(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, Line{Context, l})
+	}
+	return ops
+}
+
+func middleOps(a, b []string) []Line {
+	var ops []Line
+	if len(a)*len(b) > maxLCSCells {
+		for _, l := range a {
+			ops = append(ops, Line{Delete, l})
+		}
+		for _, l := range b {
+			ops = append(ops, Line{Insert, l})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Line{Context, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Line{Delete, a[i]})
+			i++
+		default:
+			ops = append(ops, Line{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, Line{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, Line{Insert, b[j]})
+	}
+	return ops
+}
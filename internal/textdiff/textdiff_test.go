@@ -0,0 +1,49 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", ""},
+		{"created", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"deleted", "a\n", "", "@@ -1,1 +0,0 @@\n-a\n"},
+		{
+			"modified with context",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"separate hunks",
+			"a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			"A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified(tt.before, tt.after); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	hunks := Compute("a\nb\nc\n", "a\nB\nc\n", 0)
+	if len(hunks) != 1 {
+		t.Fatalf("got %d hunks, want 1", len(hunks))
+	}
+	h := hunks[0]
+	want := []Line{{Delete, "b"}, {Insert, "B"}}
+	if h.OldStart != 2 || h.OldLines != 1 || h.NewStart != 2 || h.NewLines != 1 || len(h.Lines) != 2 || h.Lines[0] != want[0] || h.Lines[1] != want[1] {
+		t.Errorf("unexpected hunk %+v", h)
+	}
+	if hunks := Compute("same\n", "same\n", DefaultContext); hunks != nil {
+		t.Errorf("identical inputs produced hunks: %+v", hunks)
+	}
+}
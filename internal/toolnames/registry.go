@@ -9,6 +9,7 @@ type ToolDef struct {
 	Title       string // Human-readable title
 	Description string // Description passed to the LLM via MCP
 	Instruction string // Guidance for the system prompt
+	Cloud       bool   // Sends workspace content (code, dependency lists, queries) to external services
 }
 
 // Registry holds all tool definitions, keyed by Name.
@@ -20,6 +21,24 @@ var Registry = map[string]ToolDef{
 		Description: "Atomic, multi-file coordinate editing transaction. Automatically applies edits, formats using gofmt/goimports, and runs type verification (gopls check ./...) across the entire workspace. If the compiler check fails, all edits are completely rolled back to backup state, and Levenshtein-based spelling suggestions are returned for misspelled symbols.",
 		Instruction: "*   **`smart_edit`**: The primary tool for modifying files.\n    *   **Capabilities:** Atomic transactions across multiple files. Validates syntax and types (gofmt/goimports/gopls check) *before* finalizing modifications on disk.\n    *   **Rollback Safety:** If any compilation errors occur, changes are rolled back completely. Returns type check errors along with helpful 'Did you mean?' suggestions.\n    *   **Usage:** `smart_edit(edits=[{\"filename\": \"/absolute/path/to/target/file.go\", \"old_content\": \"...\", \"new_content\": \"...\", \"start_line\": 10, \"end_line\": 15}])`\n    *   **CRITICAL:** In multi-root workspaces, you MUST use absolute file paths in `filename` to ensure the correct project is edited.",
 	},
+	"diff": {
+		Name:        "diff",
+		Title:       "Diff",
+		Description: "Compares two files, a file and expected content, or two directory trees, and returns unified diff hunks (markdown) or structured hunks with line kinds and per-file added/deleted counts (output_format=\"json\"). Directory comparisons honor .gitignore, report added, deleted, modified and binary files, and count identical ones. Output is capped at 2000 diff lines.",
+		Instruction: "*   **`diff`**: Compare generated output against expectations without shelling out.\n    *   **Usage:** `diff(old=\"/abs/testdata/golden\", new=\"/abs/out\")` or `diff(old=\"/abs/file.go\", content=\"expected text\")`\n    *   **Outcome:** Unified diff per changed file, or `identical`. Use `output_format=\"json\"` to inspect hunks programmatically.",
+	},
+	"merge_edit": {
+		Name:        "merge_edit",
+		Title:       "Merge Edit",
+		Description: "Applies an edit computed against stale content with a three-way merge: takes the content you based the edit on and your edited version, and merges your changes into the file's current content, keeping changes made on disk in the meantime. Clean merges are formatted and written atomically with the diff against the file on disk; conflicting regions are reported with the base, edited and current lines and the file is left untouched. Supports dry_run.",
+		Instruction: "*   **`merge_edit`**: Apply a whole-file edit when the file may have changed since you read it.\n    *   **Usage:** `merge_edit(filename=\"/abs/path/file.go\", base=\"<content you read>\", edited=\"<your version>\")`\n    *   **Outcome:** Your changes merged on top of the current file, or the conflicting regions to resolve. Prefer `smart_edit` for targeted changes to fresh content.",
+	},
+	"export_session": {
+		Name:        "export_session",
+		Title:       "Export Session",
+		Description: "Writes the tool calls of the current session to a shareable bundle: each call's tool name, arguments, result, duration and error status, plus unified diffs of the files it created, modified or deleted, together with the godoctor, Go and client versions. Markdown by default, JSON for a .json filename or output_format=\"json\". Attach the bundle to a godoctor bug report to make the run reproducible.",
+		Instruction: "*   **`export_session`**: Save a reproducible trace of this session when a tool misbehaves.\n    *   **Usage:** `export_session(filename=\"/abs/path/godoctor-session.md\")`\n    *   **Outcome:** A markdown or JSON bundle of every call, its arguments, result and file diffs. Tell the user to review it before attaching it to a bug report, since it contains full arguments and results.",
+	},
 	"smart_read": {
 		Name:        "smart_read",
 		Title:       "Read File",
@@ -40,13 +59,25 @@ var Registry = map[string]ToolDef{
 		Description: "Retrieves authoritative Go documentation for any package or symbol. Streamlines development by providing API signatures and usage examples directly within the workflow.",
 		Instruction: "*   **`read_docs`**: Access API documentation.\n    *   **Usage:** `read_docs(import_path=\"net/http\")`\n    *   **Outcome:** API reference and usage guidance.",
 	},
+	"prefetch_docs": {
+		Name:        "prefetch_docs",
+		Title:       "Prefetch Docs",
+		Description: "Loads the documentation of every package a Go file imports in one call, concurrently, resolving them in the file's own module. Returns a compact summary per import: the package synopsis and the declarations of the symbols the file actually uses. The parsed documentation stays cached, so follow-up read_docs calls are instant.",
+		Instruction: "*   **`prefetch_docs`**: Get oriented in a file's dependencies in one call instead of one read_docs per import.\n    *   **Usage:** `prefetch_docs(filename=\"/abs/path/handler.go\")`\n    *   **Outcome:** Per import, its synopsis and the signatures of the symbols the file uses. Use `read_docs` for anything deeper.",
+	},
+	"export_docs": {
+		Name:        "export_docs",
+		Title:       "Export Documentation",
+		Description: "Renders the documentation of every package in a module, and optionally its dependencies, to a static markdown or HTML tree, using the same documentation pipeline as read_docs. Use it to publish internal API docs.",
+		Instruction: "*   **`export_docs`**: Publish a module's API documentation as static files.\n    *   **Usage:** `export_docs(dir=\"/abs/path\", out=\"docs/api\", format=\"html\")`\n    *   **Outcome:** One page per package plus an index; set `dependencies=true` to include imported packages. The same export is available from the command line as `godoctor export-docs`.",
+	},
 
 	// --- GO TOOLCHAIN ---
 	"smart_build": {
 		Name:        "smart_build",
 		Title:       "Smart Build",
 		Description: "Enforces a strict sequential quality gate: Tidy -> Modernize -> Format -> Build -> Test -> Lint. All bypass flags are removed to guarantee entire workspace verification.",
-		Instruction: "*   **`smart_build`**: Complete compilation, unit test, and linting validation gate.\n    *   **Usage:** `smart_build(dir=\"/absolute/path/to/target-workspace\", packages=\"./...\")`\n    *   **Pipeline:** Automatically runs `go mod tidy` -> modernization -> `gofmt` -> `go build` -> `go test` -> linter.\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target workspace root to `dir`.",
+		Instruction: "*   **`smart_build`**: Complete compilation, unit test, and linting validation gate.\n    *   **Usage:** `smart_build(dir=\"/absolute/path/to/target-workspace\", packages=\"./...\")`\n    *   **Pipeline:** Automatically runs `go mod tidy` -> modernization -> `gofmt` -> `go build` -> `go test` -> linter.\n    *   **Output:** Pass `output_format=\"json\"` for a structured report with one section per phase.\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target workspace root to `dir`.",
 	},
 	"add_dependency": {
 		Name:        "add_dependency",
@@ -54,12 +85,50 @@ var Registry = map[string]ToolDef{
 		Description: "Manages Go module installation and manifest updates. Consolidates the workflow by immediately returning the public API documentation for the installed packages.",
 		Instruction: "*   **`add_dependency`**: Install dependencies and fetch documentation.\n    *   **Usage:** `add_dependency(dir=\"/absolute/path/to/target-workspace\", packages=[\"github.com/go-chi/chi/v5@latest\"])`\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target workspace root to `dir`.",
 	},
+	"search_modules": {
+		Name:        "search_modules",
+		Title:       "Search Modules",
+		Description: "Finds candidate modules for a need (e.g. 'HTTP router', 'YAML parsing') on pkg.go.dev and returns their import counts, latest version and release date from the module proxy, license, and warning signs such as stale or pre-v1 releases.",
+		Instruction: "*   **`search_modules`**: Ground dependency choices in real data before adding one.\n    *   **Usage:** `search_modules(query=\"YAML parsing\")`\n    *   **Outcome:** Candidates with import counts, latest version, release date and license. Pick one with the user, then install it with `add_dependency`.",
+		Cloud:       true,
+	},
+	"dependency_health": {
+		Name:        "dependency_health",
+		Title:       "Dependency Health",
+		Description: "Evaluates the health of the module's dependencies: release and commit age, open issues, importers, vulnerability history and archived status, with a risk level for each.",
+		Instruction: "*   **`dependency_health`**: Check whether existing dependencies are still safe to rely on.\n    *   **Usage:** `dependency_health()` or `dependency_health(modules=[\"github.com/pkg/errors\"])`\n    *   **Outcome:** A risk level per direct dependency with the reasons behind it. Plan upgrades or replacements for high-risk modules.",
+		Cloud:       true,
+	},
 	"project_init": {
 		Name:        "project_init",
 		Title:       "Initialize Project",
 		Description: "Bootstraps a new Go project by creating the directory, initializing the Go module, and installing essential dependencies. Layout-agnostic and does not run compilation.",
 		Instruction: "*   **`project_init`**: Bootstrap a new Go project.\n    *   **Usage:** `project_init(path=\"/absolute/path/to/new-app\", module_path=\"github.com/user/new-app\", dependencies=[\"github.com/go-chi/chi/v5\"])`\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target directory to `path`.",
 	},
+	"release_check": {
+		Name:        "release_check",
+		Title:       "Release Check",
+		Description: "Runs a pre-release gauntlet and returns a single pass/fail report listing the blocking items: builds for every target platform (GOOS/GOARCH), runs go test and go vet, runs govulncheck when installed, diffs the exported API against the latest semver tag (breaking changes block v1+ releases), and requires a changelog. Checks and platforms are configurable.",
+		Instruction: "*   **`release_check`**: Decide whether a module is ready to be released. Call it before cutting a release or tagging a version.\n    *   **Usage:** `release_check(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Options:** `checks=[\"build\",\"test\"]` to run a subset, `platforms=[\"linux/arm64\"]` to change build targets, `base=\"v1.2.0\"` to diff against a specific ref.\n    *   **Output:** READY / NOT READY with the blocking items first; fix every ❌ before tagging.",
+	},
+	"suggest_version": {
+		Name:        "suggest_version",
+		Title:       "Suggest Version",
+		Description: "Recommends the next semantic version of a module from the exported API changes since its latest release tag: major for breaking changes (minor before v1), minor for compatible additions, patch for other commits. Lists the API changes behind the recommendation. With tag=true it creates the annotated git tag, refusing on a dirty tree, an existing tag, or a v2+ tag whose module path lacks the /vN suffix.",
+		Instruction: "*   **`suggest_version`**: Pick the next version number when cutting a release.\n    *   **Usage:** `suggest_version(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Tagging:** Show the recommendation to the user first; only after they confirm, call again with `tag=true` (optionally `version=\"v1.3.0-rc.1\"`) to create the annotated tag.",
+	},
+	"describe_pr": {
+		Name:        "describe_pr",
+		Title:       "Describe Pull Request",
+		Description: "Builds a structured pull request description from a change and its review findings: summary and motivation (from the arguments or the commit messages), notable decisions (exported API changes, go.mod dependency changes, deleted and renamed files, resolved findings), test evidence (test functions added, test files updated, optionally a go test run of the changed packages) and remaining risks (open findings, breaking API changes, packages changed without test changes, TODOs added). The change is the git diff since the merge base with the target branch, uncommitted and untracked files included, or a unified diff passed as diff. Sections it cannot fill are left as HTML comment placeholders. Returns Markdown ready to paste as the PR body.",
+		Instruction: "*   **`describe_pr`**: Write the pull request description once a change is ready for review.\n    *   **Usage:** `describe_pr(dir=\"/absolute/path/to/target-workspace\", summary=\"...\", motivation=\"...\", findings=[{\"position\": \"a.go:3:1\", \"message\": \"...\", \"resolved\": true}], run_tests=true)`\n    *   **Outcome:** A Markdown PR body. Fill in any `<!-- ... -->` placeholder it leaves and show it to the user before opening or editing the pull request.",
+	},
+	"eval_snippet": {
+		Name:        "eval_snippet",
+		Title:       "Evaluate Go Snippet",
+		Description: "Builds and runs a small Go snippet in a throwaway module, offline and with a strict timeout, and returns its stdout and stderr. Accepts a full program, declarations with a main function, or bare statements; standard library imports are added automatically. Can also publish the program to the Go Playground and return a share link. Network, process and unsafe packages are rejected, but this is a guard rail, not a sandbox: the snippet runs as the server's user and can read and write files through os and io/fs.",
+		Instruction: "*   **`eval_snippet`**: Test a small hypothesis (formatting verbs, parsing behavior, library semantics) without touching the workspace.\n    *   **Usage:** `eval_snippet(code=\"fmt.Println(strconv.Quote(\\\"a\\\\tb\\\"))\")`\n    *   **Outcome:** Exit status, stdout and stderr. Only the standard library is available; network and process packages are rejected. This is not a sandbox: file access through `os` is possible, so do not run untrusted code.\n    *   **Sharing:** Pass `share=true` to get a runnable go.dev/play link for explanations or bug reports. This publishes the code, so only share code the user is happy to make public.",
+	},
 
 	// --- TESTING ---
 	"mutation_test": {
@@ -74,6 +143,212 @@ var Registry = map[string]ToolDef{
 		Description: "Queries Go test results and coverage data using SQL via testquery (tq). Uses a persistent SQLite database (testquery.db) to avoid re-running tests on every query. Set rebuild=true after code changes to refresh the database. Available tables: all_tests (package, test, action, elapsed, output), all_coverage (file, function_name, start_line, end_line, count, stmt_num), test_coverage (test_name, file, start_line, end_line, count), all_code (file, line_number, content).",
 		Instruction: "*   **`test_query`**: Query test results with SQL.\n    *   **Usage:** `test_query(dir=\"/absolute/path/to/target-workspace\", query=\"SELECT * FROM all_coverage WHERE count = 0\")`\n    *   **Caching:** Uses a persistent `testquery.db` file. First call builds it automatically. Set `rebuild=true` after code changes.\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target workspace root to `dir`.",
 	},
+	"bench_compare": {
+		Name:        "bench_compare",
+		Title:       "Benchmark Comparison",
+		Description: "Guards against performance regressions: checks out two git refs (by default HEAD and the working tree) into temporary worktrees, runs the selected benchmarks on both in alternating rounds with -benchmem, and reports the median change per metric with a Mann-Whitney U significance test, separating real improvements and regressions from noise.",
+		Instruction: "*   **`bench_compare`**: Prove an optimization helps before proposing it.\n    *   **Usage:** `bench_compare(dir=\"/abs/path\", bench=\"BenchmarkParse\", packages=\"./parser\")`\n    *   **Outcome:** Base vs head medians for ns/op, B/op and allocs/op with p-values. Only claim improvements marked ✅; `~` means the difference is noise.",
+	},
+	"convert_test": {
+		Name:        "convert_test",
+		Title:       "Convert Test",
+		Description: "Converts a test into a benchmark skeleton or a benchmark into a test, keeping its setup and fixtures. TestXxx becomes BenchmarkXxx: statements before the first call into the package under test stay as setup, the rest runs in the benchmark loop (b.Loop, range b.N or a classic loop depending on the module's Go version), assertions are dropped while error checks are kept, and t.Run subtests become b.Run sub-benchmarks. BenchmarkXxx becomes TestXxx: the b.N, b.Loop and RunParallel loops are unwrapped into a single iteration with a TODO for the assertions. The new function is inserted after the original and the package is vetted before the file is written.",
+		Instruction: "*   **`convert_test`**: Start performance work from existing coverage, or turn a benchmark into a regression test.\n    *   **Usage:** `convert_test(dir=\"/absolute/path/to/target-workspace\", package=\"./parser\", name=\"TestParse\")` creates `BenchmarkParse`; pass a `BenchmarkXxx` name for the reverse. Use `dry_run=true` to preview.\n    *   **Workflow:** Follow the notes (helpers taking `*testing.T` must take `testing.TB`), then measure with `bench_compare`.",
+	},
+	"leak_check": {
+		Name:        "leak_check",
+		Title:       "Leak Check",
+		Description: "Detects memory and goroutine leaks: runs a function or Test function in a loop through a temporary test harness, samples the live heap, heap objects and goroutine count after a full GC between batches, flags steady growth beyond a threshold, and lists the allocation sites whose live memory grew the most.",
+		Instruction: "*   **`leak_check`**: Confirm or rule out a suspected leak before chasing it.\n    *   **Usage:** `leak_check(dir=\"/abs/path\", package=\"./cache\", target=\"TestCacheEviction\")`\n    *   **Outcome:** A verdict, the heap and goroutine samples per batch, and the top growing allocation sites to inspect.",
+	},
+	"review_allocations": {
+		Name:        "review_allocations",
+		Title:       "Review Allocations",
+		Description: "Ranks the allocation hot spots of a package by combining compiler escape analysis with a full-rate allocation profile of its benchmarks, and suggests patch-ready fixes: preallocating slices, reusing buffers, avoiding interface boxing and string concatenation in loops.",
+		Instruction: "*   **`review_allocations`**: Find what to fix first when a package allocates too much.\n    *   **Usage:** `review_allocations(dir=\"/abs/path\", package=\"./parser\", bench=\"BenchmarkParse\")`\n    *   **Outcome:** Benchmark B/op and allocs/op, lines ranked by bytes allocated with their escape analysis, a suggestion per line and a unified diff where the fix is mechanical. Apply patches with `smart_edit` and measure with `bench_compare`.",
+	},
+	"fix_data_race": {
+		Name:        "fix_data_race",
+		Title:       "Fix Data Race",
+		Description: "Runs tests under the race detector and explains every distinct race: the shared variable (field, package variable or captured variable), both conflicting accesses with their stacks and surrounding code, and where the goroutines were started. Proposes a minimal mutex patch (reusing an existing mutex field where there is one) and validates it by re-running vet and the race detector through -overlay, extending it with races the first fix uncovers. Nothing is written unless apply=true and the patch passes.",
+		Instruction: "*   **`fix_data_race`**: Diagnose and fix a `-race` failure.\n    *   **Usage:** `fix_data_race(dir=\"/abs/path\", package=\"./cache\", run=\"TestConcurrentGet\", count=5)`; add `apply=true` to write a validated patch.\n    *   **Workflow:** Read the explanation of each race; if a mutex is the wrong tool (ownership should move over a channel, or a counter should be atomic), use the accesses shown to rewrite it with `smart_edit` instead.",
+	},
+
+	// --- ANALYSIS ---
+	"audit_panics": {
+		Name:        "audit_panics",
+		Title:       "Audit Panic Paths",
+		Description: "Lists every panic(), log.Fatal/log.Panic and os.Exit call in library (non-main) packages that is reachable from an exported function, together with the static call path that leads to it. Use it to enforce \"libraries don't panic\" policies.",
+		Instruction: "*   **`audit_panics`**: Find process-terminating calls reachable from the exported API of library packages.\n    *   **Usage:** `audit_panics(dir=\"/absolute/path/to/target-workspace\", packages=\"./...\")`\n    *   **Outcome:** Each site with up to three call paths from exported functions (e.g. `pkg.Parse` → `pkg.mustToken` → `panic()`).",
+	},
+	"audit_deadlocks": {
+		Name:        "audit_deadlocks",
+		Title:       "Audit Deadlocks",
+		Description: "Builds a lock-order graph from the sync.Mutex and sync.RWMutex acquisitions of a module (struct fields, embedded mutexes, package variables) and follows static calls made while a lock is held. Reports lock-order inversions (cycles in the graph), locks acquired again while already held, and blocking channel sends, receives, ranges and selects inside critical sections, each with the call path that reaches it and the code around it. The analysis is heuristic: interface calls are not followed.",
+		Instruction: "*   **`audit_deadlocks`**: Look for lock-order inversions and channel operations under a lock, e.g. when tests hang intermittently.\n    *   **Usage:** `audit_deadlocks(dir=\"/abs/path\", packages=\"./...\")`\n    *   **Outcome:** Each cycle with both acquisition paths; fix by taking the locks in one global order or by releasing the lock before the channel operation.",
+	},
+	"audit_globals": {
+		Name:        "audit_globals",
+		Title:       "Audit Global State",
+		Description: "Inventories package-level mutable variables, init() functions, and sync.Once patterns across the module. Flags test-order hazards such as globals overwritten by tests, state mutated at runtime, exported mutable variables, and sync.Once caches that tests cannot reset.",
+		Instruction: "*   **`audit_globals`**: Inventory global state before refactoring or reviewing testability.\n    *   **Usage:** `audit_globals(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Outcome:** Tables of package-level variables, init() functions, and sync.Once usage, with hazard flags (`mutated-in-tests`, `mutated-at-runtime`, `exported-mutable`, `once-cached`).",
+	},
+	"audit_determinism": {
+		Name:        "audit_determinism",
+		Title:       "Audit Determinism",
+		Description: "Flags direct uses of time.Now/Since/Until, time.Sleep/After/Tick, and the global math/rand generator in business logic, with suggestions to inject clocks and seeded random sources. Optionally rewrites a package to use an injectable Clock interface (verified by a build, rolled back on failure).",
+		Instruction: "*   **`audit_determinism`**: Find hidden dependencies on wall-clock time and global randomness that make tests flaky.\n    *   **Usage:** `audit_determinism(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Codemod:** `audit_determinism(dir=\"...\", apply_clock=\"example.com/app/billing\")` adds a `Clock` interface to the package and rewrites `time.Now`/`time.Since`/`time.Sleep` calls to use it.",
+	},
+	"audit_streaming": {
+		Name:        "audit_streaming",
+		Title:       "Audit Streaming",
+		Description: "Finds whole reads into memory (io.ReadAll, os.ReadFile and their ioutil forms) on hot paths: HTTP request and response bodies read without http.MaxBytesReader or io.LimitReader, reads inside HTTP handlers, and reads inside loops. Follows where the bytes go to suggest the streaming alternative (json/xml decoders, io.Copy, bufio.Scanner, incremental hashing), and with apply=true rewrites the simple ReadAll + Unmarshal and ReadAll + Write cases, verified with go vet. Reads elsewhere, such as loading configuration at startup, are only counted.",
+		Instruction: "*   **`audit_streaming`**: Find places that buffer whole bodies or files when they could stream, e.g. before load testing a service.\n    *   **Usage:** `audit_streaming(dir=\"/abs/path\", packages=\"./...\")`; add `apply=true` to rewrite the reads marked 🔧.\n    *   **Outcome:** Findings grouped by severity, each with the streaming replacement for what consumes the data.",
+	},
+	"audit_strings": {
+		Name:        "audit_strings",
+		Title:       "Audit String Building",
+		Description: "Finds string building that wastes time and memory: strings built with += inside loops (quadratic copying), fmt.Sprintf inside loops, and []byte/string conversions that copy only to convert back, to write, or to call a strings function that has a bytes twin. With apply=true rewrites the simple cases, verified with go vet: strings.Builder for the loops (fmt.Fprintf for formatted parts), concatenation with strconv for Sprintf calls using plain verbs, and io.WriteString, bytes.Clone or the bytes function for the conversions.",
+		Instruction: "*   **`audit_strings`**: Find and fix quadratic string building and needless conversions, e.g. after `review_allocations` points at string-heavy code.\n    *   **Usage:** `audit_strings(dir=\"/abs/path\", packages=\"./...\")`; add `apply=true` to rewrite the findings marked 🔧.\n    *   **Outcome:** Findings grouped by cost, each with its replacement; with apply, the rewritten sites and what remains.",
+	},
+	"audit_http": {
+		Name:        "audit_http",
+		Title:       "Audit HTTP Client Hygiene",
+		Description: "Audits HTTP client and server usage across a module. Flags http.DefaultClient and its helpers, clients and servers without timeouts, response bodies that are never closed, requests built without a context, and unbounded retry loops. Each finding carries a concrete fix.",
+		Instruction: "*   **`audit_http`**: Check HTTP code for the mistakes that cause hangs and connection leaks.\n    *   **Usage:** `audit_http(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Output:** Findings grouped by rule with a fix for each; pass `output_format=\"json\"` for structured output.",
+	},
+	"audit_sql": {
+		Name:        "audit_sql",
+		Title:       "Audit database/sql Leaks",
+		Description: "Finds database/sql resource leaks that are hard to spot by reading: *sql.Rows and *sql.Stmt values that are never closed, rows.Next loops without a rows.Err check, and transactions without a Rollback on error paths. Values returned or passed to other functions are assumed to be managed there.",
+		Instruction: "*   **`audit_sql`**: Check database code for leaked rows, statements and transactions.\n    *   **Usage:** `audit_sql(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Output:** Findings grouped by rule (`rows-not-closed`, `rows-err-unchecked`, `stmt-not-closed`, `tx-no-rollback`) with a fix for each; pass `output_format=\"json\"` for structured output.",
+	},
+	"inspect_wiring": {
+		Name:        "inspect_wiring",
+		Title:       "Inspect Dependency Wiring",
+		Description: "Builds a dependency graph from the module's exported NewX constructors: what each constructor provides, what it needs, which constructors consume which, and which types must be supplied by the caller. Interface parameters are matched to constructors whose result implements them. Optionally generates (and writes, build-verified) a wiring function that calls the constructors in dependency order, for use in main().",
+		Instruction: "*   **`inspect_wiring`**: Understand how components are assembled before a large refactor.\n    *   **Report:** `inspect_wiring(dir=\"/absolute/path/to/target-workspace\")` lists constructors, who needs what, and the types main() must supply.\n    *   **Generate:** `inspect_wiring(dir=\"...\", root=\"server.Server\", output=\"/absolute/path/to/cmd/app/wire.go\")` writes a `wireServer` function that builds the whole graph.",
+	},
+	"audit_config": {
+		Name:        "audit_config",
+		Title:       "Audit Configuration Drift",
+		Description: "Cross-references the environment variables the code reads (os.Getenv, os.LookupEnv, viper keys, env/envconfig struct tags) against those defined in .env files, Dockerfiles, compose files and Kubernetes manifests in the repository. Reports variables read but never defined, and defined but never read.",
+		Instruction: "*   **`audit_config`**: Catch configuration drift between code and deployment files.\n    *   **Usage:** `audit_config(dir=\"/absolute/path/to/target-repo\")`\n    *   **Output:** Variables read but never defined (will be empty in production) and variables defined but never read (dead or misspelled config). Pass `ignore=[\"PORT\"]` for platform-provided variables.",
+	},
+	"audit_logging": {
+		Name:        "audit_logging",
+		Title:       "Audit Logging",
+		Description: "Reviews log statements across a module: fmt and builtin prints in server code, errors logged without saying what failed, PII- or secret-looking keys and values in log calls, and structured-log keys (log/slog) whose naming style or spelling is inconsistent with the rest of the codebase. Each finding carries a fix.",
+		Instruction: "*   **`audit_logging`**: Review logging before shipping a service.\n    *   **Usage:** `audit_logging(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Output:** Findings grouped by rule (`print-in-server`, `error-without-context`, `pii-in-logs`, `inconsistent-key-style`, `inconsistent-key-spelling`); pass `output_format=\"json\"` for structured output.",
+	},
+	"audit_doc_coverage": {
+		Name:        "audit_doc_coverage",
+		Title:       "Audit Documentation Coverage",
+		Description: "Measures documentation coverage across a module: the fraction of exported functions, methods, types, constants and variables that have doc comments, per package and overall, and which packages lack a package comment. Returns packages ranked by how much documentation work they need, with the position of every undocumented symbol. Main packages are not counted.",
+		Instruction: "*   **`audit_doc_coverage`**: Find the documentation gaps that matter most.\n    *   **Usage:** `audit_doc_coverage(dir=\"/absolute/path/to/target-workspace\")`\n    *   **Output:** Per-package coverage table and a prioritized list of undocumented exported symbols and missing package comments; pass `output_format=\"json\"` for structured output.",
+	},
+	"audit_visibility": {
+		Name:        "audit_visibility",
+		Title:       "Audit Visibility",
+		Description: "Finds exported package-level functions, types, constants and variables that no other package of the module uses, counting external test packages as outside users, and proposes unexported names (URL → url, HTTPClient → httpClient). Types that an exported symbol's signature exposes, types embedded in structs, and main packages are left out. Renames that would clash with a keyword, a predeclared identifier, an import or a local variable are flagged for manual work. With apply=true the remaining candidates (or the listed symbols) are renamed with their uses and doc comments, verified by go vet and rolled back on failure.",
+		Instruction: "*   **`audit_visibility`**: Shrink the API surface before a v1 release.\n    *   **Usage:** `audit_visibility(dir=\"/absolute/path/to/target-workspace\")` to list candidates, then `audit_visibility(dir=..., apply=true, symbols=[\"lib.Helper\"])` to unexport them.\n    *   **Caveat:** Only uses inside the module are known; keep symbols other modules import.",
+	},
+	"audit_naming": {
+		Name:        "audit_naming",
+		Title:       "Audit Naming",
+		Description: "Checks exported identifiers (package-level names, and the methods and fields of exported types) against the Go naming conventions: initialisms in a single case (Url → URL, UserId → UserID), getters without a Get prefix (GetName() → Name()), no stutter with the package name (store.StoreConfig → store.Config), and mixed caps instead of underscores (MAX_SIZE → MaxSize). Produces a rename map keyed for rename_symbols, validated as a batch: proposals that would clash with another declaration, break an interface implementation or capture a reference are listed with the reason and left out of the map. Test files, generated files, cgo exports and methods required by an imported interface are skipped.",
+		Instruction: "*   **`audit_naming`**: Find names that break the Go conventions (initialisms, Get prefixes, stutter, underscores).\n    *   **Usage:** `audit_naming(dir=\"/absolute/path/to/target-workspace\")`, then pass the rename map it prints to `rename_symbols(dir=..., renames={...}, dry_run=true)`.\n    *   **Caveat:** Renaming exported identifiers breaks other modules that import them; drop the entries that are public API before a major version.",
+	},
+
+	// --- GENERATION ---
+	"generate_constructor": {
+		Name:        "generate_constructor",
+		Title:       "Generate Constructor",
+		Description: "Generates a NewX constructor with functional options for a struct: a With option per optional field, positional parameters for required fields with non-zero validation, and a unit test. The files are formatted, built and tested before being kept; any failure rolls them back.",
+		Instruction: "*   **`generate_constructor`**: Write a functional-options constructor instead of hand-rolling one.\n    *   **Usage:** `generate_constructor(dir=\"/absolute/path/to/target-workspace\", package=\"./server\", struct=\"Server\", required=[\"Addr\"])`\n    *   **Outcome:** `server_options.go` with `Option`, `With...` and `NewServer`, plus a passing `server_options_test.go`. Use `dry_run=true` to preview.",
+	},
+	"generate_enum": {
+		Name:        "generate_enum",
+		Title:       "Generate Enum Methods",
+		Description: "Generates String, ParseX, MarshalJSON and UnmarshalJSON for an iota-based integer enum type, plus round-trip tests. Existing stringer output for the type and its go:generate directive are removed. Files are formatted, built and tested before being kept; any failure rolls them back.",
+		Instruction: "*   **`generate_enum`**: Give an integer enum a string form and JSON encoding instead of running stringer by hand.\n    *   **Usage:** `generate_enum(dir=\"/absolute/path/to/target-workspace\", package=\"./color\", type=\"Color\")`\n    *   **Options:** `trim_prefix=\"Color\"` turns `ColorRed` into `\"Red\"`; `dry_run=true` previews the code.",
+	},
+	"get_snippet": {
+		Name:        "get_snippet",
+		Title:       "Get Snippet",
+		Description: "Renders a vetted Go pattern from a versioned snippet library (worker pool, HTTP client, errgroup fan-out, table-driven test) with your names and settings filled in. Call without a name to list the library.",
+		Instruction: "*   **`get_snippet`**: Start from a vetted pattern instead of writing concurrency or HTTP boilerplate from scratch.\n    *   **Usage:** `get_snippet()` lists the library; `get_snippet(name=\"errgroup_fanout\", package=\"fetch\", params={\"name\": \"FetchAll\", \"limit\": \"4\"})` renders one.",
+	},
+	"suggest_concurrency": {
+		Name:        "suggest_concurrency",
+		Title:       "Suggest Concurrency Primitive",
+		Description: "Inspects a function built from raw goroutines, channels and mutexes and proposes an equivalent with a higher-level primitive, with generated code: a sync.WaitGroup fan-out with an error channel, a mutex-guarded error or a semaphore channel becomes errgroup (with SetLimit); lazy initialization with sync.Once becomes sync.OnceFunc, OnceValue or OnceValues; workers ranging over a jobs channel become a worker pool with graceful shutdown from the snippet library. Each suggestion ends with a checklist of the behavior changes to verify. Nothing is written.",
+		Instruction: "*   **`suggest_concurrency`**: Replace hand-rolled goroutine plumbing with a standard primitive.\n    *   **Usage:** `suggest_concurrency(dir=\"/absolute/path/to/target-workspace\", package=\"./worker\", function=\"ProcessAll\")` (use `Type.Method` for methods).\n    *   **Workflow:** Review the checklist, apply the code with `smart_edit`, then run the tests with `-race`.",
+	},
+	"generate_openapi_client": {
+		Name:        "generate_openapi_client",
+		Title:       "Generate OpenAPI Client",
+		Description: "Generates a typed Go client package from an OpenAPI 3 document (JSON or YAML, from a workspace file or an http(s) URL) with the bundled generator: structs for the component schemas, string enums as constants, one method per operation with typed path, query, header and body parameters, and an APIError for non-2xx responses. The package is written into the workspace, rolled back unless `go vet` passes, and summarized with a usage snippet, the operation list and documentation links.",
+		Instruction: "*   **`generate_openapi_client`**: Call a REST API through a typed client instead of hand-written HTTP code.\n    *   **Usage:** `generate_openapi_client(dir=\"/absolute/path/to/target-workspace\", spec=\"api/openapi.yaml\", output=\"internal/petstore\")`; `spec` may also be an https URL.\n    *   **Workflow:** Use the returned method list and snippet, then `read_docs` on the generated package for details. Re-run after the spec changes; hand-written files are never overwritten.",
+		Cloud:       true,
+	},
+	"generate_openapi_mock": {
+		Name:        "generate_openapi_mock",
+		Title:       "Generate OpenAPI Mock Server",
+		Description: "Generates an httptest-based fake server package from an OpenAPI 3 document (JSON or YAML, from a workspace file or an http(s) URL) for the tests of code that consumes the API. Every operation is routed with http.ServeMux patterns and answers with a canned response built from the document's examples or synthesized from its schemas; tests override responses with Respond or Handle and inspect what the server received with Requests. The package and a self-test are written into the workspace and rolled back unless they pass.",
+		Instruction: "*   **`generate_openapi_mock`**: Test API consumers against a fake server instead of a live service.\n    *   **Usage:** `generate_openapi_mock(dir=\"/absolute/path/to/target-workspace\", spec=\"api/openapi.yaml\")`; pair it with `generate_openapi_client` on the same document for end-to-end tests.\n    *   **Workflow:** Start the fake with `New(t)`, point the client at `srv.URL`, override responses per test with `Respond`, and assert on `RequestsFor`.",
+		Cloud:       true,
+	},
+
+	// --- REFACTORING ---
+	"extract_strings": {
+		Name:        "extract_strings",
+		Title:       "Extract User-Facing Strings",
+		Description: "Finds user-facing string literals (fmt print calls, http.Error, io.WriteString to a ResponseWriter) and extracts them into a golang.org/x/text message catalog (gotext JSON). With apply=true, rewrites fmt and http.Error call sites to use a package-level message.Printer, verified by a build and rolled back on failure.",
+		Instruction: "*   **`extract_strings`**: Prepare a codebase for translation.\n    *   **Preview:** `extract_strings(dir=\"/absolute/path/to/target-workspace\")` lists messages and the catalog that would be written.\n    *   **Codemod:** `extract_strings(dir=\"...\", apply=true)` writes `locales/en/messages.gotext.json` and routes call sites through `printer`. Requires `golang.org/x/text` in go.mod.",
+	},
+	"extract_module": {
+		Name:        "extract_module",
+		Title:       "Extract Module",
+		Description: "Extracts a package subtree of a module into a new standalone module, either in place (a nested module) or at a new location. Writes the new go.mod with the parent's Go version and requirements, rewrites import paths in both modules when the module path changes, adds a require and a replace directive to the parent for local development, and verifies that both modules build, rolling everything back otherwise. Refuses extractions that would create a module cycle or break the internal package rule.",
+		Instruction: "*   **`extract_module`**: Split a package into its own module.\n    *   **Usage:** `extract_module(dir=\"/absolute/path/to/target-workspace\", package=\"pkg/retry\", module_path=\"github.com/user/retry\", target=\"/absolute/path/to/retry\")`\n    *   **Tip:** Run with `dry_run=true` first to review the files and import rewrites.",
+	},
+	"rewrite_import_path": {
+		Name:        "rewrite_import_path",
+		Title:       "Rewrite Import Path",
+		Description: "Renames a module path or import prefix across a whole repository: module, require and replace lines in every go.mod, Go import paths, path mentions in comments (import comments, //go:generate lines, docs), and documentation and config files such as README.md, Makefiles and CI YAML. Matches whole paths only, so renaming example.com/app leaves example.com/application alone. Dry run returns a diff; applying rebuilds every module and rolls back on failure. String literals that mention the old path are listed for manual review.",
+		Instruction: "*   **`rewrite_import_path`**: Rename a module or move packages to a new import prefix.\n    *   **Usage:** `rewrite_import_path(dir=\"/absolute/path/to/target-repo\", from=\"github.com/old-org/app\", to=\"github.com/new-org/app\", dry_run=true)`\n    *   **Workflow:** Review the dry-run diff, then call again without `dry_run`. Check the string literals it reports by hand.",
+	},
+	"rename_symbols": {
+		Name:        "rename_symbols",
+		Title:       "Rename Symbols",
+		Description: "Renames many symbols across the module in one pass from a map of old to new names: package-level functions, types, constants and variables, methods and struct fields, with every reference (tests and other packages included) and the leading word of their doc comments. The whole batch is checked before any file is touched: unknown or ambiguous keys, invalid names, clashes with other declarations or imports (after the batch), locals that would capture a reference, unexported names still used by other packages, and methods that implement an interface without the interface following. The result is verified with go vet and rolled back on failure.",
+		Instruction: "*   **`rename_symbols`**: Align many names at once, e.g. to a naming convention or after a review.\n    *   **Usage:** `rename_symbols(dir=\"/abs/path\", renames={\"store.MemStore\": \"MemoryStore\", \"Getter.GetValue\": \"Get\", \"MemStore.GetValue\": \"Get\"}, dry_run=true)`\n    *   **Workflow:** Review the plan and diff, resolve any conflict it reports (usually by adding the interface method or implementation to the map), then call again without `dry_run`.",
+	},
+	"replace_dependency": {
+		Name:        "replace_dependency",
+		Title:       "Replace Dependency",
+		Description: "Migrates the module from one library to another using a mapping of symbol equivalences, e.g. github.com/pkg/errors to errors and fmt. Rewrites every reference in place (plain replacements like \"errors.New\", or call templates like \"fmt.Errorf(\\\"%s: %w\\\", $2, $1)\"), swaps the imports, runs go mod tidy and verifies the build, rolling everything back on failure. Mapping files are JSON: {\"from\": \"import/path\", \"to\": \"module@version\" (omit for the standard library), \"imports\": [...], \"symbols\": {\"Name\": \"replacement\"}, \"notes\": [...]}. Refuses to migrate while any used symbol has no mapping. A built-in mapping covers github.com/pkg/errors.",
+		Instruction: "*   **`replace_dependency`**: Move off a deprecated or unwanted library in one verified step.\n    *   **Usage:** `replace_dependency(dir=\"/abs/path\", from=\"github.com/pkg/errors\", dry_run=true)` or `replace_dependency(mapping=\"migrate.json\")`\n    *   **Workflow:** Review the dry-run rewrites and the notes on semantic differences, extend the mapping for any unmapped symbols, then call again without `dry_run`.",
+	},
+	"rewrite_idioms": {
+		Name:        "rewrite_idioms",
+		Title:       "Rewrite Idioms",
+		Description: "Detects non-idiomatic Go with a direct mechanical fix and rewrites it, complementing modernize: error tails (`if err != nil { return err }; return nil` becomes `return err`, or `return f()` when err only carries f's result), mixed `len(s) == 0` and `s == \"\"` checks within a package (rewritten to the form the package uses most), and else branches after a return, break, continue or panic (outdented). Rewrites that could change behavior, such as collapsing a typed nil error or moving declarations that would shadow a name, are skipped. Preview returns a diff; apply writes the changeset, verified by a build and rolled back on failure.",
+		Instruction: "*   **`rewrite_idioms`**: Clean up mechanical style issues in one verified pass instead of editing each site.\n    *   **Usage:** `rewrite_idioms(dir=\"/absolute/path/to/target-workspace\")` previews; add `apply=true` to write. Limit with `rules=[\"error-tail\"]` or `packages=\"./internal/...\"`.",
+	},
+	"inline_symbol": {
+		Name:        "inline_symbol",
+		Title:       "Inline Symbol",
+		Description: "Inlines a trivial package-level function or a constant at every use in the module and removes its declaration. A function qualifies when its body is a single return of one value or a single expression statement and every use is a call; parameters are replaced by the arguments, names are qualified for other packages, and conversions and parentheses are added where the type or precedence would change. Calls whose arguments have side effects that would be duplicated, dropped or reordered, and bodies referring to unexported names from another package, are rejected. Constants are replaced by their expression or value. The change is verified with go vet and rolled back on failure.",
+		Instruction: "*   **`inline_symbol`**: Remove a trivial wrapper or a constant that no longer earns its name.\n    *   **Usage:** `inline_symbol(dir=\"/absolute/path/to/target-workspace\", package=\"./internal/calc\", symbol=\"double\", dry_run=true)`, then again without `dry_run` to write.\n    *   **Outcome:** Every use is replaced and the declaration removed; if a call cannot be inlined safely, nothing is written and the call is named.",
+	},
+	"introduce_parameter_object": {
+		Name:        "introduce_parameter_object",
+		Title:       "Introduce Parameter Object",
+		Description: "Replaces consecutive parameters of a function or method (by default all but a leading context.Context and a trailing variadic parameter) with one generated struct, declared above the function, and rewrites every call in the module to pass a struct literal. The body binds the old parameter names from the struct on its first line so it is otherwise unchanged, and arguments that were literal zero values are left out of the literals, keeping the zero value of the struct equal to the old defaults. Functions used as values rather than called are rejected. The change is verified with go vet and rolled back on failure.",
+		Instruction: "*   **`introduce_parameter_object`**: Tame a function with a long parameter list.\n    *   **Usage:** `introduce_parameter_object(dir=\"/absolute/path/to/target-workspace\", package=\"./client\", function=\"Fetch\", dry_run=true)`; pass `params` to group only some consecutive parameters and `type_name` to name the struct.\n    *   **Workflow:** Review the diff, apply without `dry_run`, then document the fields' defaults.",
+	},
 
 	// --- NAVIGATION ---
 	"describe_symbol": {
@@ -82,4 +357,10 @@ var Registry = map[string]ToolDef{
 		Description: "Returns complete gopls-backed symbol information including exact coordinates, declaration signature, package comments, and all references within the workspace.",
 		Instruction: "*   **`describe_symbol`**: Track declaration and usage reference coordinates of a symbol.\n    *   **Usage:** `describe_symbol(filename=\"/absolute/path/to/target/file.go\", line=25, col=10)`\n    *   **CRITICAL:** In multi-root workspaces, you MUST pass the absolute path of the target file to `filename`.",
 	},
+	"build_context": {
+		Name:        "build_context",
+		Title:       "Build Context",
+		Description: "Assembles a context bundle for a described task within a token budget: ranks the module's files by how well their declaration names, doc comments and paths match the task's terms (identifiers are split, so ParseConfig also matches 'config'), includes short files whole and long ones as their matching declarations, adds trimmed docs for the external APIs the top files use and the recent commits that mention the task or touched those files. Returns ordered chunks with token estimates and lists relevant files that did not fit.",
+		Instruction: "*   **`build_context`**: Start a task with the right files instead of exploring one read at a time.\n    *   **Usage:** `build_context(dir=\"/abs/path\", task=\"make ParseConfig reject empty keys\", budget=8000)`\n    *   **Outcome:** Ranked code chunks, the API signatures they use and related commits. Name the types and functions involved for sharper results; read omitted files with `smart_read` if needed.",
+	},
 }
@@ -0,0 +1,223 @@
+// Package exportsession implements the export_session tool, which writes the tool calls of the
+// current session to a bundle that can be attached to bug reports.
+package exportsession
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/transcript"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["export_session"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Filename     string `json:"filename" jsonschema:"Absolute path of the bundle to write (e.g. /path/to/project/godoctor-session.md)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Bundle format: 'markdown' or 'json' (default: 'json' for a .json filename, otherwise 'markdown')"`
+}
+
+// Bundle is an exported session.
+type Bundle struct {
+	Server    string            `json:"server"`
+	GoVersion string            `json:"goVersion"`
+	Platform  string            `json:"platform"`
+	Client    string            `json:"client,omitempty"`
+	Roots     []string          `json:"roots,omitempty"`
+	Exported  time.Time         `json:"exported"`
+	Dropped   int               `json:"dropped,omitempty"` // older calls not kept by the recorder
+	Calls     []transcript.Call `json:"calls"`
+}
+
+// Handler handles the export_session tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Filename == "" {
+		return errorResult("filename cannot be empty"), nil, nil
+	}
+	if args.OutputFormat == "" && strings.EqualFold(filepath.Ext(args.Filename), ".json") {
+		args.OutputFormat = shared.FormatJSON
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	absPath, err := roots.Global.Validate(session, args.Filename)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	b := collect(session)
+	var content []byte
+	if format == shared.FormatJSON {
+		content, err = json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		content = append(content, '\n')
+	} else {
+		content = []byte(render(b))
+	}
+	if err := writes.WriteFile(ctx, absPath, content, 0644); err != nil {
+		return errorResult(fmt.Sprintf("failed to write %s: %v", args.Filename, err)), nil, nil
+	}
+
+	var files int
+	for _, c := range b.Calls {
+		files += len(c.Files)
+	}
+	msg := fmt.Sprintf("Exported %d tool call(s) and %d file change(s) to %s.", len(b.Calls), files, absPath)
+	if b.Dropped > 0 {
+		msg += fmt.Sprintf(" The %d oldest call(s) were no longer recorded.", b.Dropped)
+	}
+	msg += "\nReview the bundle before sharing it: it contains the full arguments and results of every call."
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}, nil, nil
+}
+
+// collect builds the bundle of the session. File paths under a workspace root are made relative
+// to it so that bundles do not depend on where the project was checked out.
+func collect(session *mcp.ServerSession) Bundle {
+	b := Bundle{
+		Server:    "godoctor (devel)",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Roots:     roots.Global.Get(session),
+		Exported:  time.Now().UTC(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		b.Server = "godoctor " + info.Main.Version
+	}
+	if session != nil {
+		if p := session.InitializeParams(); p != nil && p.ClientInfo != nil {
+			b.Client = strings.TrimSpace(p.ClientInfo.Name + " " + p.ClientInfo.Version)
+		}
+	}
+	b.Calls, b.Dropped = transcript.Global.Calls(session)
+	for i := range b.Calls {
+		for j := range b.Calls[i].Files {
+			b.Calls[i].Files[j].Path = relPath(b.Roots, b.Calls[i].Files[j].Path)
+		}
+	}
+	if b.Calls == nil {
+		b.Calls = []transcript.Call{}
+	}
+	return b
+}
+
+func relPath(roots []string, path string) string {
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+func render(b Bundle) string {
+	var sb strings.Builder
+	sb.WriteString("# godoctor session\n\n")
+	fmt.Fprintf(&sb, "- **Server:** %s\n", b.Server)
+	fmt.Fprintf(&sb, "- **Go:** %s (%s)\n", b.GoVersion, b.Platform)
+	if b.Client != "" {
+		fmt.Fprintf(&sb, "- **Client:** %s\n", b.Client)
+	}
+	for _, r := range b.Roots {
+		fmt.Fprintf(&sb, "- **Root:** `%s`\n", r)
+	}
+	fmt.Fprintf(&sb, "- **Exported:** %s\n", b.Exported.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "- **Calls:** %d\n\n", len(b.Calls))
+	if b.Dropped > 0 {
+		fmt.Fprintf(&sb, "⚠️ The %d oldest call(s) of the session were not kept.\n\n", b.Dropped)
+	}
+	if len(b.Calls) == 0 {
+		sb.WriteString("No tool calls were recorded in this session.\n")
+		return sb.String()
+	}
+
+	for i, c := range b.Calls {
+		status := "ok"
+		if c.IsError {
+			status = "error"
+		}
+		fmt.Fprintf(&sb, "## %d. `%s` (%s, %d ms)\n\n", i+1, c.Tool, status, c.DurationMS)
+		fmt.Fprintf(&sb, "Started %s.\n\n", c.Started.UTC().Format(time.RFC3339))
+		if len(c.Arguments) > 0 {
+			var pretty bytes.Buffer
+			if json.Indent(&pretty, c.Arguments, "", "  ") != nil {
+				pretty.Reset()
+				pretty.Write(c.Arguments)
+			}
+			sb.WriteString("**Arguments**\n\n")
+			writeBlock(&sb, "json", pretty.String())
+		}
+		if c.Result != "" {
+			sb.WriteString("**Result**\n\n")
+			writeBlock(&sb, "", c.Result)
+		}
+		for _, f := range c.Files {
+			fmt.Fprintf(&sb, "**%s** `%s`\n\n", f.Status, f.Path)
+			if f.Diff == "" {
+				continue
+			}
+			from, to := "a/"+f.Path, "b/"+f.Path
+			switch f.Status {
+			case "created":
+				from = "/dev/null"
+			case "deleted":
+				to = "/dev/null"
+			}
+			writeBlock(&sb, "diff", fmt.Sprintf("--- %s\n+++ %s\n%s", from, to, f.Diff))
+		}
+	}
+	return sb.String()
+}
+
+// writeBlock writes text as a fenced code block whose fence is longer than any backtick run in
+// the text, so that results containing markdown stay intact.
+func writeBlock(sb *strings.Builder, lang, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(sb, "%s%s\n%s", fence, lang, text)
+	if !strings.HasSuffix(text, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fence + "\n\n")
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
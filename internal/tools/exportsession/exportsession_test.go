@@ -0,0 +1,72 @@
+package exportsession
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/transcript"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandler(t *testing.T) {
+	tmp := t.TempDir()
+	target := filepath.Join(tmp, "main.go")
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		writes.Changed(ctx, target, nil, []byte("package main\n"))
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "```go\ncode\n```"}}}, nil
+	}
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "smart_edit", Arguments: json.RawMessage(`{"filename":"main.go"}`)}}
+	if _, err := transcript.Global.Middleware(next)(context.Background(), "tools/call", req); err != nil {
+		t.Fatal(err)
+	}
+	defer transcript.Global.Delete(nil)
+
+	t.Run("markdown", func(t *testing.T) {
+		out := filepath.Join(tmp, "bundle", "session.md")
+		res, _, _ := Handler(context.Background(), nil, Params{Filename: out})
+		if res.IsError {
+			t.Fatalf("unexpected error: %v", res.Content[0].(*mcp.TextContent).Text)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md := string(data)
+		for _, want := range []string{"## 1. `smart_edit` (ok,", `"filename": "main.go"`, "````\n```go\ncode\n```\n````", "--- /dev/null", "+package main"} {
+			if !strings.Contains(md, want) {
+				t.Errorf("bundle missing %q:\n%s", want, md)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out := filepath.Join(tmp, "session.json")
+		res, _, _ := Handler(context.Background(), nil, Params{Filename: out})
+		if res.IsError {
+			t.Fatalf("unexpected error: %v", res.Content[0].(*mcp.TextContent).Text)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b Bundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			t.Fatalf("invalid JSON bundle: %v", err)
+		}
+		if len(b.Calls) != 1 || len(b.Calls[0].Files) != 1 || b.Calls[0].Files[0].Status != "created" {
+			t.Errorf("unexpected bundle: %+v", b)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		res, _, _ := Handler(context.Background(), nil, Params{Filename: filepath.Join(tmp, "x.txt"), OutputFormat: "yaml"})
+		if !res.IsError {
+			t.Error("expected an error for an invalid format")
+		}
+	})
+}
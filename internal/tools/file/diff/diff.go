@@ -0,0 +1,441 @@
+// Package diff implements the diff tool, which compares two files, a file and expected content,
+// or two directories.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["diff"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Old          string  `json:"old" jsonschema:"Absolute path of the original file or directory"`
+	New          string  `json:"new,omitempty" jsonschema:"Absolute path of the file or directory to compare with. Omit it to compare old with content"`
+	Content      *string `json:"content,omitempty" jsonschema:"Expected content to compare the old file with, instead of a new path"`
+	Context      int     `json:"context,omitempty" jsonschema:"Unchanged lines shown around each change (default 3)"`
+	OutputFormat string  `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default, unified diff) or 'json' (structured hunks)"`
+}
+
+// maxDiffLines caps the hunk lines returned over all files; later files are listed without hunks.
+const maxDiffLines = 2000
+
+// maxFiles caps the number of files compared in a directory diff.
+const maxFiles = 5000
+
+// File statuses.
+const (
+	StatusModified = "modified"
+	StatusAdded    = "added"
+	StatusDeleted  = "deleted"
+	StatusBinary   = "binary" // both sides exist and differ, but are not text
+)
+
+// FileDiff is the comparison of one file.
+type FileDiff struct {
+	Path    string          `json:"path"`
+	Status  string          `json:"status"`
+	Added   int             `json:"added"`
+	Deleted int             `json:"deleted"`
+	Hunks   []textdiff.Hunk `json:"hunks,omitempty"`
+}
+
+// Result is the outcome of a comparison.
+type Result struct {
+	Old       string     `json:"old"`
+	New       string     `json:"new"`
+	Identical bool       `json:"identical"`
+	Files     []FileDiff `json:"files"`
+	Unchanged int        `json:"unchanged,omitempty"` // identical files in a directory diff
+	Truncated bool       `json:"truncated,omitempty"` // hunks were left out to bound the output
+}
+
+// Handler handles the diff tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Old == "" {
+		return errorResult("old cannot be empty"), nil, nil
+	}
+	if (args.New == "") == (args.Content == nil) {
+		return errorResult("provide exactly one of new (a path) or content (the expected text)"), nil, nil
+	}
+	if args.Context < 0 {
+		return errorResult("context cannot be negative"), nil, nil
+	}
+	if args.Context == 0 {
+		args.Context = textdiff.DefaultContext
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	oldPath, err := roots.Global.Validate(session, args.Old)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	oldInfo, err := os.Stat(oldPath)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to read %s: %v", args.Old, err)), nil, nil
+	}
+
+	d := &differ{context: args.Context}
+	res := &Result{Old: oldPath}
+	if args.Content != nil {
+		if oldInfo.IsDir() {
+			return errorResult("content can only be compared with a file, but old is a directory"), nil, nil
+		}
+		res.New = "(content)"
+		before, err := os.ReadFile(oldPath)
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to read %s: %v", args.Old, err)), nil, nil
+		}
+		d.add(res, filepath.Base(oldPath), before, []byte(*args.Content))
+	} else {
+		newPath, err := roots.Global.Validate(session, args.New)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		res.New = newPath
+		newInfo, err := os.Stat(newPath)
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to read %s: %v", args.New, err)), nil, nil
+		}
+		switch {
+		case oldInfo.IsDir() != newInfo.IsDir():
+			return errorResult("old and new must both be files or both be directories"), nil, nil
+		case oldInfo.IsDir():
+			if err := d.dirs(ctx, res, oldPath, newPath); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+		default:
+			before, err := os.ReadFile(oldPath)
+			if err != nil {
+				return errorResult(fmt.Sprintf("failed to read %s: %v", args.Old, err)), nil, nil
+			}
+			after, err := os.ReadFile(newPath)
+			if err != nil {
+				return errorResult(fmt.Sprintf("failed to read %s: %v", args.New, err)), nil, nil
+			}
+			d.add(res, filepath.Base(newPath), before, after)
+		}
+	}
+	res.Identical = len(res.Files) == 0
+	res.Truncated = d.truncated
+
+	var output string
+	if format == shared.FormatJSON {
+		if res.Files == nil {
+			res.Files = []FileDiff{}
+		}
+		bytes, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(res, oldInfo.IsDir())
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// differ accumulates file comparisons within the output budget.
+type differ struct {
+	context   int
+	lines     int
+	truncated bool
+}
+
+// add compares one file; a nil before or after means the file only exists on the other side.
+// Identical files are not added.
+func (d *differ) add(res *Result, path string, before, after []byte) {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		res.Unchanged++
+		return
+	}
+	fd := FileDiff{Path: path, Status: StatusModified}
+	switch {
+	case before == nil:
+		fd.Status = StatusAdded
+	case after == nil:
+		fd.Status = StatusDeleted
+	}
+	if isBinary(before) || isBinary(after) {
+		if fd.Status == StatusModified {
+			fd.Status = StatusBinary
+		}
+		res.Files = append(res.Files, fd)
+		return
+	}
+
+	hunks := textdiff.Compute(string(before), string(after), d.context)
+	n := 0
+	for _, h := range hunks {
+		n += len(h.Lines)
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case textdiff.Insert:
+				fd.Added++
+			case textdiff.Delete:
+				fd.Deleted++
+			}
+		}
+	}
+	if d.lines+n > maxDiffLines {
+		d.truncated = true
+	} else {
+		d.lines += n
+		fd.Hunks = hunks
+	}
+	res.Files = append(res.Files, fd)
+}
+
+// dirs compares two directory trees.
+func (d *differ) dirs(ctx context.Context, res *Result, oldDir, newDir string) error {
+	oldFiles, err := listFiles(ctx, oldDir)
+	if err != nil {
+		return err
+	}
+	newFiles, err := listFiles(ctx, newDir)
+	if err != nil {
+		return err
+	}
+	all := make(map[string]bool, len(oldFiles))
+	for _, f := range oldFiles {
+		all[f] = true
+	}
+	for _, f := range newFiles {
+		all[f] = true
+	}
+	if len(all) > maxFiles {
+		return fmt.Errorf("too many files to compare (%d, limit %d); compare subdirectories instead", len(all), maxFiles)
+	}
+	paths := make([]string, 0, len(all))
+	for p := range all {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		before, err := readIfExists(filepath.Join(oldDir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		after, err := readIfExists(filepath.Join(newDir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		d.add(res, p, before, after)
+	}
+	return nil
+}
+
+func readIfExists(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// listFiles returns the files under dir as slash-separated relative paths, leaving out what
+// .gitignore excludes. Inside a git work tree git decides; elsewhere, or when git ignores the whole
+// directory (e.g. generated output), the patterns of dir's own .gitignore are applied.
+func listFiles(ctx context.Context, dir string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err == nil && len(out) > 0 {
+		var files []string
+		for _, f := range strings.Split(string(out), "\x00") {
+			// Tracked files deleted from the work tree are still listed by --cached.
+			if f != "" {
+				if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f))); err == nil && info.Mode().IsRegular() {
+					files = append(files, f)
+				}
+			}
+		}
+		return files, nil
+	}
+
+	ignore := loadGitignore(filepath.Join(dir, ".gitignore"))
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if e.IsDir() {
+			if e.Name() == ".git" || ignore.match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.Type().IsRegular() && !ignore.match(rel, false) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+type gitignore []ignorePattern
+
+type ignorePattern struct {
+	glob     string
+	anchored bool // contains a slash, so it matches from the root rather than any directory level
+	dirOnly  bool // ends with a slash
+}
+
+// loadGitignore reads the patterns of a .gitignore file. Negations and "**" are not supported;
+// they only occur outside git work trees when a copied tree keeps its .gitignore.
+func loadGitignore(path string) gitignore {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var g gitignore
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		p := ignorePattern{dirOnly: strings.HasSuffix(line, "/")}
+		line = strings.TrimSuffix(line, "/")
+		p.anchored = strings.Contains(line, "/")
+		p.glob = strings.TrimPrefix(line, "/")
+		g = append(g, p)
+	}
+	return g
+}
+
+// match reports whether the slash-separated relative path is ignored.
+func (g gitignore) match(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for _, p := range g {
+		for i := range parts {
+			if p.dirOnly && i == len(parts)-1 && !isDir {
+				continue
+			}
+			candidate := parts[i]
+			if p.anchored {
+				candidate = strings.Join(parts[:i+1], "/")
+			}
+			if ok, _ := filepath.Match(p.glob, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isBinary reports whether data looks like binary content, as git does: a NUL byte near the start.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+func render(res *Result, dirs bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Diff of `%s` and `%s`\n\n", res.Old, res.New)
+	if res.Identical {
+		if dirs {
+			fmt.Fprintf(&sb, "Identical: %d file(s) compared, no differences.\n", res.Unchanged)
+		} else {
+			sb.WriteString("Identical.\n")
+		}
+		return sb.String()
+	}
+
+	var added, deleted int
+	for _, f := range res.Files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	fmt.Fprintf(&sb, "%d file(s) differ (+%d -%d)", len(res.Files), added, deleted)
+	if dirs {
+		fmt.Fprintf(&sb, ", %d identical", res.Unchanged)
+	}
+	sb.WriteString(".\n\n")
+
+	for _, f := range res.Files {
+		fmt.Fprintf(&sb, "### `%s` (%s, +%d -%d)\n\n", f.Path, f.Status, f.Added, f.Deleted)
+		if f.Status == StatusBinary {
+			sb.WriteString("Binary files differ.\n\n")
+			continue
+		}
+		if len(f.Hunks) == 0 {
+			if f.Status == StatusAdded || f.Status == StatusDeleted {
+				fmt.Fprintf(&sb, "File %s.\n\n", f.Status)
+			} else {
+				sb.WriteString("Diff omitted to bound the output; compare this file on its own.\n\n")
+			}
+			continue
+		}
+		from, to := "a/"+f.Path, "b/"+f.Path
+		switch f.Status {
+		case StatusAdded:
+			from = "/dev/null"
+		case StatusDeleted:
+			to = "/dev/null"
+		}
+		fmt.Fprintf(&sb, "```diff\n--- %s\n+++ %s\n", from, to)
+		for _, h := range f.Hunks {
+			sb.WriteString(h.String())
+		}
+		sb.WriteString("```\n\n")
+	}
+	if res.Truncated {
+		fmt.Fprintf(&sb, "⚠️ Output capped at %d diff lines; files after the cap are listed without hunks.\n", maxDiffLines)
+	}
+	return sb.String()
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func text(res *mcp.CallToolResult) string {
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestHandler_Files(t *testing.T) {
+	tmp := t.TempDir()
+	oldFile, newFile := filepath.Join(tmp, "old.go"), filepath.Join(tmp, "new.go")
+	write(t, oldFile, "package a\n\nfunc A() int { return 1 }\n")
+	write(t, newFile, "package a\n\nfunc A() int { return 2 }\n")
+
+	res, _, _ := Handler(context.Background(), nil, Params{Old: oldFile, New: newFile})
+	if res.IsError {
+		t.Fatal(text(res))
+	}
+	for _, want := range []string{"1 file(s) differ (+1 -1)", "--- a/new.go", "@@ -1,3 +1,3 @@", "-func A() int { return 1 }", "+func A() int { return 2 }"} {
+		if !strings.Contains(text(res), want) {
+			t.Errorf("output missing %q:\n%s", want, text(res))
+		}
+	}
+
+	content := "package a\n\nfunc A() int { return 1 }\n"
+	res, _, _ = Handler(context.Background(), nil, Params{Old: oldFile, Content: &content, OutputFormat: "json"})
+	var got Result
+	if err := json.Unmarshal([]byte(text(res)), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, text(res))
+	}
+	if !got.Identical || len(got.Files) != 0 {
+		t.Errorf("expected identical content, got %+v", got)
+	}
+
+	content = "package b\n"
+	res, _, _ = Handler(context.Background(), nil, Params{Old: oldFile, Content: &content, OutputFormat: "json"})
+	if err := json.Unmarshal([]byte(text(res)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Identical || len(got.Files) != 1 || got.Files[0].Added != 1 || got.Files[0].Deleted != 3 || len(got.Files[0].Hunks) != 1 {
+		t.Errorf("unexpected result %+v", got)
+	}
+}
+
+func TestHandler_Dirs(t *testing.T) {
+	tmp := t.TempDir()
+	oldDir, newDir := filepath.Join(tmp, "want"), filepath.Join(tmp, "got")
+	for _, dir := range []string{oldDir, newDir} {
+		write(t, filepath.Join(dir, ".gitignore"), "*.log\nbuild/\n")
+		write(t, filepath.Join(dir, "same.txt"), "same\n")
+		write(t, filepath.Join(dir, "build", "out.bin"), "ignored "+dir)
+		write(t, filepath.Join(dir, "run.log"), "ignored "+dir)
+	}
+	write(t, filepath.Join(oldDir, "pkg", "a.go"), "package pkg\n")
+	write(t, filepath.Join(newDir, "pkg", "a.go"), "package pkg\n\nvar X = 1\n")
+	write(t, filepath.Join(oldDir, "removed.txt"), "bye\n")
+	write(t, filepath.Join(newDir, "added.txt"), "hi\n")
+	write(t, filepath.Join(oldDir, "img.png"), "\x89PNG\x00a")
+	write(t, filepath.Join(newDir, "img.png"), "\x89PNG\x00b")
+
+	res, _, _ := Handler(context.Background(), nil, Params{Old: oldDir, New: newDir, OutputFormat: "json"})
+	if res.IsError {
+		t.Fatal(text(res))
+	}
+	var got Result
+	if err := json.Unmarshal([]byte(text(res)), &got); err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[string]string)
+	for _, f := range got.Files {
+		status[f.Path] = f.Status
+	}
+	want := map[string]string{"added.txt": StatusAdded, "img.png": StatusBinary, "pkg/a.go": StatusModified, "removed.txt": StatusDeleted}
+	if len(status) != len(want) {
+		t.Errorf("got files %v, want %v", status, want)
+	}
+	for path, s := range want {
+		if status[path] != s {
+			t.Errorf("%s: status %q, want %q", path, status[path], s)
+		}
+	}
+	// .gitignore and same.txt are identical; build/ and *.log are ignored.
+	if got.Unchanged != 2 {
+		t.Errorf("unchanged = %d, want 2", got.Unchanged)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "a.txt")
+	write(t, file, "a\n")
+	content := "b\n"
+	tests := []struct {
+		name string
+		args Params
+	}{
+		{"no old", Params{New: file}},
+		{"neither new nor content", Params{Old: file}},
+		{"both new and content", Params{Old: file, New: file, Content: &content}},
+		{"content with directory", Params{Old: tmp, Content: &content}},
+		{"file with directory", Params{Old: file, New: tmp}},
+		{"missing", Params{Old: filepath.Join(tmp, "missing"), New: file}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _, _ := Handler(context.Background(), nil, tt.args)
+			if !res.IsError {
+				t.Errorf("expected an error, got:\n%s", text(res))
+			}
+		})
+	}
+}
+
+func TestGitignore(t *testing.T) {
+	g := gitignore{
+		{glob: "*.log"},
+		{glob: "build", dirOnly: true},
+		{glob: "docs/gen", anchored: true},
+	}
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"sub/a.log", false, true},
+		{"build", true, true},
+		{"build", false, false},
+		{"sub/build/x.go", false, true},
+		{"docs/gen", true, true},
+		{"sub/docs/gen", true, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/textdist"
 	"github.com/danicat/godoctor/internal/toolnames"
 	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/writes"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"golang.org/x/tools/imports"
 )
@@ -165,8 +167,16 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 		currentContents[absPath] = []byte(newContent)
 	}
 
+	// Files are processed in path order so that errors and the summary are reproducible.
+	paths := make([]string, 0, len(currentContents))
+	for absPath := range currentContents {
+		paths = append(paths, absPath)
+	}
+	sort.Strings(paths)
+
 	// 3. Auto-Format & Import check (GO ONLY)
-	for absPath, contentBytes := range currentContents {
+	for _, absPath := range paths {
+		contentBytes := currentContents[absPath]
 		if strings.HasSuffix(absPath, ".go") {
 			formatted, err := imports.Process(absPath, contentBytes, nil)
 			if err != nil {
@@ -178,15 +188,16 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 	}
 
 	// 4. Temporary Write to Disk for Verification Gate
-	for absPath, contentBytes := range currentContents {
+	for _, absPath := range paths {
+		contentBytes := currentContents[absPath]
 		if newlyCreated[absPath] {
 			if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
-				rollback(backups, newlyCreated)
+				rollback(ctx, backups, newlyCreated)
 				return errorResult(fmt.Sprintf("failed to create directory: %v", err)), nil, nil
 			}
 		}
-		if err := os.WriteFile(absPath, contentBytes, 0644); err != nil {
-			rollback(backups, newlyCreated)
+		if err := writes.Write(ctx, absPath, contentBytes, 0644); err != nil {
+			rollback(ctx, backups, newlyCreated)
 			return errorResult(fmt.Sprintf("failed to write temporary file %s: %v", filepath.Base(absPath), err)), nil, nil
 		}
 	}
@@ -196,7 +207,7 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 
 	goFiles, err := getAllGoFiles(workspaceRoot)
 	if err != nil {
-		rollback(backups, newlyCreated)
+		rollback(ctx, backups, newlyCreated)
 		return errorResult(fmt.Sprintf("failed to collect workspace Go files: %v", err)), nil, nil
 	}
 
@@ -206,7 +217,7 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			// Compiler check failed! Roll back all edits immediately.
-			rollback(backups, newlyCreated)
+			rollback(ctx, backups, newlyCreated)
 
 			errorOutput := string(out)
 			suggestions := findSuggestions(ctx, errorOutput)
@@ -216,7 +227,8 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 
 	// 6. Return success
 	var editedFiles []string
-	for absPath := range currentContents {
+	for _, absPath := range paths {
+		writes.Changed(ctx, absPath, backups[absPath], currentContents[absPath])
 		editedFiles = append(editedFiles, filepath.Base(absPath))
 	}
 	return &mcp.CallToolResult{
@@ -227,12 +239,12 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 }
 
 // rollback restores files to their original state or removes newly created files.
-func rollback(backups map[string][]byte, newlyCreated map[string]bool) {
+func rollback(ctx context.Context, backups map[string][]byte, newlyCreated map[string]bool) {
 	for path, origContent := range backups {
 		if newlyCreated[path] {
 			_ = os.Remove(path)
 		} else {
-			_ = os.WriteFile(path, origContent, 0644)
+			_ = writes.Write(ctx, path, origContent, 0644)
 		}
 	}
 }
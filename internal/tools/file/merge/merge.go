@@ -0,0 +1,134 @@
+// Package merge implements the merge_edit tool, which applies an edit computed against stale
+// content with a three-way merge instead of overwriting concurrent changes.
+package merge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["merge_edit"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Filename string `json:"filename" jsonschema:"Absolute path of the file to update"`
+	Base     string `json:"base" jsonschema:"The content of the file as it was when you read it, before your edit"`
+	Edited   string `json:"edited" jsonschema:"Your edited version of the file, computed from base"`
+	DryRun   bool   `json:"dry_run,omitempty" jsonschema:"If true, return the merge result without writing the file"`
+}
+
+// maxDiffLines caps the diff shown after a merge.
+const maxDiffLines = 300
+
+// Handler handles the merge_edit tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Filename == "" {
+		return errorResult("filename cannot be empty"), nil, nil
+	}
+	absPath, err := roots.Global.Validate(session, args.Filename)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to read %s: %v", args.Filename, err)), nil, nil
+	}
+	current := string(data)
+	name := filepath.Base(absPath)
+
+	m := textdiff.Merge(args.Base, args.Edited, current)
+	if len(m.Conflicts) > 0 {
+		return errorResult(renderConflicts(name, m)), nil, nil
+	}
+	merged := m.Text("edited", "current")
+	if merged == current {
+		return textResult(fmt.Sprintf("No change: %s already contains your edit.", name)), nil, nil
+	}
+
+	var sb strings.Builder
+	if args.DryRun {
+		fmt.Fprintf(&sb, "Dry run: your edit merges cleanly into %s.\n\n", name)
+	} else {
+		if err := (shared.Changeset{absPath: []byte(merged)}).ApplyVerified(ctx, filepath.Dir(absPath)); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		if written, err := os.ReadFile(absPath); err == nil {
+			merged = string(written)
+		}
+		fmt.Fprintf(&sb, "Merged your edit into %s.\n\n", name)
+	}
+	if m.TheirsChanges > 0 {
+		fmt.Fprintf(&sb, "The file had %d change(s) since your base; they were kept alongside the %d change(s) of your edit.\n\n", m.TheirsChanges, m.OursChanges)
+	}
+	sb.WriteString("Changes to the file on disk:\n\n```diff\n")
+	lines := strings.SplitAfter(textdiff.Unified(current, merged), "\n")
+	if len(lines) > maxDiffLines+1 {
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)\n", len(lines)-1-maxDiffLines))
+	}
+	sb.WriteString(strings.Join(lines, ""))
+	sb.WriteString("```\n")
+	if !args.DryRun && strings.HasSuffix(absPath, ".go") {
+		sb.WriteString("\nRun `smart_build` to verify the merged code compiles.\n")
+	}
+	return textResult(sb.String()), nil, nil
+}
+
+func renderConflicts(name string, m *textdiff.Merged) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Your edit conflicts with changes made to %s since your base in %d place(s); the file was not modified.\n\n", name, len(m.Conflicts))
+	for i, c := range m.Conflicts {
+		fmt.Fprintf(&sb, "## Conflict %d\n\n", i+1)
+		writeLines(&sb, "Your edit", c.Ours)
+		writeLines(&sb, "Base", c.Base)
+		writeLines(&sb, "Current file", c.Theirs)
+	}
+	sb.WriteString("Re-read the file and redo the edit against its current content, or call merge_edit again with the current content as base.")
+	return sb.String()
+}
+
+func writeLines(sb *strings.Builder, label string, lines []string) {
+	fmt.Fprintf(sb, "**%s:**\n", label)
+	if len(lines) == 0 {
+		sb.WriteString("(no lines)\n\n")
+		return
+	}
+	sb.WriteString("```\n" + strings.Join(lines, "\n") + "\n```\n\n")
+}
+
+func textResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
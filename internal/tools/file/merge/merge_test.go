@@ -0,0 +1,91 @@
+package merge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const base = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+
+func helper() int {
+	return 1
+}
+`
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	// The file gained a doc comment after the agent read it.
+	current := strings.Replace(base, "func helper", "// helper returns one.\nfunc helper", 1)
+	edited := strings.Replace(base, `"hello"`, `"hello, world"`, 1)
+
+	t.Run("clean merge", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.go")
+		if err := os.WriteFile(path, []byte(current), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, _, _ := Handler(ctx, nil, Params{Filename: path, Base: base, Edited: edited})
+		text := res.Content[0].(*mcp.TextContent).Text
+		if res.IsError {
+			t.Fatal(text)
+		}
+		got, _ := os.ReadFile(path)
+		if !strings.Contains(string(got), `"hello, world"`) || !strings.Contains(string(got), "// helper returns one.") {
+			t.Errorf("merged file lost a change:\n%s", got)
+		}
+		if !strings.Contains(text, `+	fmt.Println("hello, world")`) || !strings.Contains(text, "1 change(s) since your base") {
+			t.Errorf("unexpected output:\n%s", text)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.go")
+		if err := os.WriteFile(path, []byte(current), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, _, _ := Handler(ctx, nil, Params{Filename: path, Base: base, Edited: edited, DryRun: true})
+		if res.IsError {
+			t.Fatal(res.Content[0].(*mcp.TextContent).Text)
+		}
+		if got, _ := os.ReadFile(path); string(got) != current {
+			t.Errorf("dry run modified the file:\n%s", got)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.go")
+		onDisk := strings.Replace(base, `"hello"`, `"hi"`, 1)
+		if err := os.WriteFile(path, []byte(onDisk), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, _, _ := Handler(ctx, nil, Params{Filename: path, Base: base, Edited: edited})
+		text := res.Content[0].(*mcp.TextContent).Text
+		if !res.IsError || !strings.Contains(text, "1 place(s)") || !strings.Contains(text, `fmt.Println("hi")`) {
+			t.Errorf("expected a conflict report, got:\n%s", text)
+		}
+		if got, _ := os.ReadFile(path); string(got) != onDisk {
+			t.Errorf("conflicting merge modified the file:\n%s", got)
+		}
+	})
+
+	t.Run("already applied", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.go")
+		if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, _, _ := Handler(ctx, nil, Params{Filename: path, Base: base, Edited: edited})
+		if text := res.Content[0].(*mcp.TextContent).Text; res.IsError || !strings.Contains(text, "already contains") {
+			t.Errorf("unexpected result:\n%s", text)
+		}
+	})
+}
@@ -251,14 +251,13 @@ func enrichTypes(ctx context.Context, filename string, content []byte) string {
 		return ""
 	}
 
-	var mu sync.Mutex
-	var typeDefinitions []string
-	var uniqueDefs = make(map[string]bool)
-
+	// Each lookup writes its own slot so that definitions keep source order, whatever order the
+	// lookups finish in.
+	defs := make([]string, len(posList))
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 10)
 
-	for _, pos := range posList {
+	for i, pos := range posList {
 		wg.Add(1)
 		go func(position token.Position) {
 			defer wg.Done()
@@ -271,18 +270,22 @@ func enrichTypes(ctx context.Context, filename string, content []byte) string {
 			if err == nil {
 				defStr := strings.TrimSpace(string(out))
 				if defStr != "" && (strings.Contains(defStr, "struct {") || strings.Contains(defStr, "interface {") || strings.Contains(defStr, "func(")) {
-					mu.Lock()
-					if !uniqueDefs[defStr] {
-						uniqueDefs[defStr] = true
-						typeDefinitions = append(typeDefinitions, defStr)
-					}
-					mu.Unlock()
+					defs[i] = defStr
 				}
 			}
 		}(pos)
 	}
 	wg.Wait()
 
+	var typeDefinitions []string
+	uniqueDefs := make(map[string]bool)
+	for _, def := range defs {
+		if def != "" && !uniqueDefs[def] {
+			uniqueDefs[def] = true
+			typeDefinitions = append(typeDefinitions, def)
+		}
+	}
+
 	if len(typeDefinitions) == 0 {
 		return ""
 	}
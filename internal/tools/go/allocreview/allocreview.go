@@ -0,0 +1,304 @@
+// Package allocreview implements the review_allocations tool, which combines escape analysis with
+// an allocation profile of a package's benchmarks to rank its allocation hot spots and suggest
+// fixes for them.
+package allocreview
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["review_allocations"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir       string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package   string `json:"package" jsonschema:"The package to review, relative to dir (e.g. './parser')"`
+	Bench     string `json:"bench,omitempty" jsonschema:"Regular expression selecting the benchmarks to profile (default '.')"`
+	Benchtime string `json:"benchtime,omitempty" jsonschema:"Value for -benchtime, e.g. '100ms' or '1000x' (default: go test's default of 1s)"`
+}
+
+const (
+	runTimeout = 5 * time.Minute
+	// maxHotSpots and maxStatic cap the two lists of the report.
+	maxHotSpots = 10
+	maxStatic   = 15
+	// minShare drops profiled lines below this share of the attributed bytes.
+	minShare = 0.01
+)
+
+// Review is the result of a review: the benchmarks run, the profiled hot spots ranked by bytes
+// allocated, and the heap escapes the benchmarks did not exercise.
+type Review struct {
+	Package  string
+	Benches  []Bench
+	HotSpots []*Site
+	Static   []*Site
+	Total    float64 // bytes attributed to the package
+}
+
+// Handler handles the review_allocations tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Package == "" {
+		return errorResult("package is required"), nil, nil
+	}
+	if strings.Contains(args.Package, "...") {
+		return errorResult("package must name a single package; the allocation profile covers one test binary"), nil, nil
+	}
+	if !strings.HasPrefix(args.Package, ".") && !filepath.IsAbs(args.Package) {
+		args.Package = "./" + args.Package
+	}
+	if args.Bench == "" {
+		args.Bench = "."
+	}
+
+	rev, err := Run(ctx, absDir, args)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: render(rev)},
+		},
+	}, nil, nil
+}
+
+// Run compiles the package with escape analysis, runs its benchmarks with an allocation profile
+// at full sampling, and classifies every line that allocates.
+func Run(ctx context.Context, dir string, args Params) (*Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	pkgs, err := shared.LoadPackages(ctx, dir, args.Package, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 || len(pkgs[0].GoFiles) == 0 {
+		return nil, fmt.Errorf("%s does not match exactly one package with Go files", args.Package)
+	}
+	pkg := pkgs[0]
+	pkgDir := filepath.Dir(pkg.GoFiles[0])
+
+	build := exec.CommandContext(ctx, "go", "build", "-gcflags=-m", args.Package)
+	build.Dir = dir
+	out, err := build.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("escape analysis failed:\n%s", strings.TrimSpace(string(out)))
+	}
+	escapes := ParseEscapes(string(out), dir)
+
+	tmp, err := os.MkdirTemp("", "godoctor-allocs-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	binary, profile := filepath.Join(tmp, "pkg.test"), filepath.Join(tmp, "mem.out")
+	goArgs := []string{"test", "-run", "^$", "-bench", args.Bench, "-benchmem", "-count", "1",
+		"-memprofile", profile, "-memprofilerate", "1", "-o", binary}
+	if args.Benchtime != "" {
+		goArgs = append(goArgs, "-benchtime", args.Benchtime)
+	}
+	test := exec.CommandContext(ctx, "go", append(goArgs, args.Package)...)
+	test.Dir = dir
+	out, err = test.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("benchmarks failed:\n%s", strings.TrimSpace(string(out)))
+	}
+
+	rev := &Review{Package: pkg.PkgPath, Benches: ParseBenchmarks(string(out))}
+	keep := func(file string) bool {
+		return filepath.Dir(file) == pkgDir && !strings.HasSuffix(file, "_test.go")
+	}
+	var bytes, objects map[lineKey]*sampleTotals
+	if len(rev.Benches) > 0 {
+		if bytes, err = traces(ctx, binary, profile, "alloc_space", keep); err != nil {
+			return nil, err
+		}
+		if objects, err = traces(ctx, binary, profile, "alloc_objects", keep); err != nil {
+			return nil, err
+		}
+	}
+
+	c := newClassifier(dir, pkg)
+	sites := make(map[lineKey]*Site)
+	site := func(key lineKey) *Site {
+		if s, ok := sites[key]; ok {
+			return s
+		}
+		s := &Site{File: key.file, Line: key.line, Position: c.position(key)}
+		sites[key] = s
+		return s
+	}
+	for key, t := range bytes {
+		s := site(key)
+		s.Func, s.Bytes = t.fn, t.value
+		if o := objects[key]; o != nil {
+			s.Objects = o.value
+		}
+		rev.Total += t.value
+	}
+	for _, e := range escapes {
+		if keep(e.File) {
+			s := site(lineKey{e.File, e.Line})
+			s.Escapes = append(s.Escapes, e)
+		}
+	}
+	for _, s := range sites {
+		c.classify(s)
+		switch {
+		case s.Bytes > 0 && s.Bytes >= minShare*rev.Total:
+			rev.HotSpots = append(rev.HotSpots, s)
+		case s.Bytes == 0 && s.Kind != KindOther:
+			rev.Static = append(rev.Static, s)
+		}
+	}
+	sort.Slice(rev.HotSpots, func(i, j int) bool {
+		if rev.HotSpots[i].Bytes != rev.HotSpots[j].Bytes {
+			return rev.HotSpots[i].Bytes > rev.HotSpots[j].Bytes
+		}
+		return shared.ComparePositions(rev.HotSpots[i].Position, rev.HotSpots[j].Position) < 0
+	})
+	sort.Slice(rev.Static, func(i, j int) bool {
+		return shared.ComparePositions(rev.Static[i].Position, rev.Static[j].Position) < 0
+	})
+	return rev, nil
+}
+
+// traces reads the profile with go tool pprof and attributes each sample to a package line.
+func traces(ctx context.Context, binary, profile, index string, keep func(string) bool) (map[lineKey]*sampleTotals, error) {
+	cmd := exec.CommandContext(ctx, "go", "tool", "pprof", "-sample_index="+index, "-traces", "-lines", binary, profile)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the allocation profile:\n%s", strings.TrimSpace(string(out)))
+	}
+	return parseTraces(string(out), keep), nil
+}
+
+func render(rev *Review) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Allocation Review (`%s`)\n\n", rev.Package)
+
+	if len(rev.Benches) == 0 {
+		sb.WriteString("⚠️ No benchmark ran, so the hot spots below come from escape analysis alone. Create one with `convert_test` to rank them by what the code really allocates.\n\n")
+	} else {
+		sb.WriteString("## Benchmarks\n\n| Benchmark | B/op | allocs/op |\n|---|---:|---:|\n")
+		for _, b := range rev.Benches {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", b.Name, number(b.BytesPerOp), number(b.AllocsPerOp))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(rev.HotSpots) > 0 {
+		sb.WriteString("## Hot spots\n\nRanked by the bytes each line allocated during the benchmarks, allocations inside the standard library included.\n\n")
+		for i, s := range rev.HotSpots {
+			if i == maxHotSpots {
+				fmt.Fprintf(&sb, "... %d more line(s) above %.0f%% of the bytes.\n\n", len(rev.HotSpots)-maxHotSpots, minShare*100)
+				break
+			}
+			fmt.Fprintf(&sb, "### %d. %s in `%s` — %.1f%% of bytes (%s, %s objects) [%s]\n\n",
+				i+1, s.Position, s.Func, 100*s.Bytes/rev.Total, size(s.Bytes), number(s.Objects), s.Kind)
+			writeSite(&sb, s)
+		}
+	} else if len(rev.Benches) > 0 {
+		sb.WriteString("✅ The benchmarks allocate nothing in this package's own code.\n\n")
+	}
+
+	if len(rev.Static) > 0 {
+		title := "Heap escapes not exercised by the benchmarks"
+		if len(rev.Benches) == 0 {
+			title = "Heap escapes"
+		}
+		fmt.Fprintf(&sb, "## %s (%d)\n\n", title, len(rev.Static))
+		for i, s := range rev.Static {
+			if i == maxStatic {
+				fmt.Fprintf(&sb, "... %d more.\n\n", len(rev.Static)-maxStatic)
+				break
+			}
+			fmt.Fprintf(&sb, "### %s [%s]\n\n", s.Position, s.Kind)
+			writeSite(&sb, s)
+		}
+	}
+	if len(rev.HotSpots)+len(rev.Static) > 0 {
+		sb.WriteString("Apply the patches with `smart_edit`, then measure the change with `bench_compare`.\n")
+	}
+	return sb.String()
+}
+
+func writeSite(sb *strings.Builder, s *Site) {
+	if s.Frame != "" {
+		fmt.Fprintf(sb, "```go\n%s```\n\n", s.Frame)
+	}
+	if len(s.Escapes) > 0 {
+		msgs := make([]string, 0, len(s.Escapes))
+		for _, e := range s.Escapes {
+			msgs = append(msgs, "`"+e.Message+"`")
+		}
+		fmt.Fprintf(sb, "Escape analysis: %s\n\n", strings.Join(msgs, ", "))
+	}
+	fmt.Fprintf(sb, "**Suggestion:** %s\n\n", s.Advice)
+	if s.Patch != "" {
+		fmt.Fprintf(sb, "```diff\n%s```\n\n", s.Patch)
+	}
+}
+
+func size(b float64) string {
+	switch {
+	case b >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", b/(1<<30))
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", b/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", b/(1<<10))
+	}
+	return fmt.Sprintf("%.0f B", b)
+}
+
+func number(v float64) string {
+	switch {
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case v >= 1e4:
+		return fmt.Sprintf("%.1fk", v/1e3)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,236 @@
+package allocreview
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseBenchmarks(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: example.com/app
+BenchmarkNames-8    	  263718	      4463 ns/op	    4464 B/op	       7 allocs/op
+BenchmarkRender/small-8	   10000	    104321 ns/op	   37312 B/op	     290 allocs/op
+PASS
+ok  	example.com/app	3.021s
+`
+	got := ParseBenchmarks(out)
+	want := []Bench{
+		{Name: "BenchmarkNames", BytesPerOp: 4464, AllocsPerOp: 7},
+		{Name: "BenchmarkRender/small", BytesPerOp: 37312, AllocsPerOp: 290},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bench %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEscapes(t *testing.T) {
+	out := `# example.com/app
+./app.go:14:12: items does not escape
+./app.go:17:15: append escapes to heap
+./app.go:26:13: new(bytes.Buffer) does not escape
+./app.go:28:22: it.ID escapes to heap
+./app.go:33:6: can inline newItem
+./app.go:34:2: moved to heap: it
+`
+	got := ParseEscapes(out, "/src")
+	want := []Escape{
+		{File: "/src/app.go", Line: 17, Col: 15, Message: "append escapes to heap"},
+		{File: "/src/app.go", Line: 28, Col: 22, Message: "it.ID escapes to heap"},
+		{File: "/src/app.go", Line: 34, Col: 2, Message: "moved to heap: it"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("escape %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseTraces(t *testing.T) {
+	out := `File: app.test
+Type: alloc_space
+-----------+-------------------------------------------------------
+     bytes:  2.25kB
+   22.50kB   example.com/app.Names /src/app.go:17 (inline)
+             example.com/app.BenchmarkNames /src/app_test.go:15
+             testing.(*B).runN /usr/local/go/src/testing/benchmark.go:219
+-----------+-------------------------------------------------------
+     bytes:  64B
+    1.50MB   bytes.growSlice /usr/local/go/src/bytes/buffer.go:249
+             bytes.(*Buffer).WriteString /usr/local/go/src/bytes/buffer.go:199
+             example.com/app.Render /src/app.go:27
+             example.com/app.BenchmarkRender /src/app_test.go:21
+-----------+-------------------------------------------------------
+     bytes:  16B
+      144B   example.com/app.Names /src/app.go:17 (inline)
+             example.com/app.BenchmarkNames /src/app_test.go:15
+-----------+-------------------------------------------------------
+     bytes:  9.25kB
+         0   runtime/pprof.writeHeapInternal /usr/local/go/src/runtime/pprof/pprof.go:650
+-----------+-------------------------------------------------------
+`
+	keep := func(file string) bool { return file == "/src/app.go" }
+	got := parseTraces(out, keep)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 lines, got %+v", got)
+	}
+	if n := got[lineKey{"/src/app.go", 17}]; n == nil || n.fn != "example.com/app.Names" || n.value != 22644 {
+		t.Errorf("unexpected totals for line 17: %+v", n)
+	}
+	// Allocations inside the standard library are charged to the package line that called it.
+	if r := got[lineKey{"/src/app.go", 27}]; r == nil || r.fn != "example.com/app.Render" || r.value != 1.5e6 {
+		t.Errorf("unexpected totals for line 27: %+v", r)
+	}
+}
+
+const app = `package app
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type Item struct {
+	ID   int
+	Name string
+}
+
+func Names(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Name)
+	}
+	return out
+}
+
+func Render(items []Item) string {
+	var sb string
+	for _, it := range items {
+		buf := new(bytes.Buffer)
+		buf.WriteString(it.Name)
+		sb += fmt.Sprint(it.ID) + buf.String()
+	}
+	return sb
+}
+
+func Keep(n int) *int {
+	v := n
+	return &v
+}
+`
+
+const appTest = `package app
+
+import "testing"
+
+var items = func() []Item {
+	var out []Item
+	for i := 0; i < 100; i++ {
+		out = append(out, Item{ID: i, Name: "item"})
+	}
+	return out
+}()
+
+func BenchmarkNames(b *testing.B) {
+	for b.Loop() {
+		Names(items)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for b.Loop() {
+		Render(items)
+	}
+}
+`
+
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	files["go.mod"] = "module example.com/app\n\ngo 1.24\n"
+	return testutil.WriteModule(t, files)
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs benchmarks with an allocation profile")
+	}
+	dir := setup(t, map[string]string{"app.go": app, "app_test.go": appTest})
+	text, isErr := call(t, Params{Dir: dir, Package: ".", Benchtime: "200x"})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"| BenchmarkNames |",
+		"| BenchmarkRender |",
+		"### 1. app.go:26 in `example.com/app.Render`",
+		"[string-concat]",
+		"`fmt.Sprint` takes `any` arguments, so `it.ID` is boxed",
+		"-\t\tsb += fmt.Sprint(it.ID) + buf.String()\n+\t\tsb += strconv.Itoa(it.ID) + buf.String()",
+		"app.go:25 in `example.com/app.Render`",
+		"[reuse-buffer]",
+		"+\tbuf := new(bytes.Buffer)\n \tfor _, it := range items {\n-\t\tbuf := new(bytes.Buffer)\n+\t\tbuf.Reset()",
+		"app.go:16 in `example.com/app.Names`",
+		"[preallocate]",
+		"-\tvar out []string\n+\tout := make([]string, 0, len(items))",
+		"## Heap escapes not exercised by the benchmarks (1)",
+		"### app.go:32 [moved-to-heap]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandler_NoBenchmarks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	dir := setup(t, map[string]string{"app.go": app})
+	text, isErr := call(t, Params{Dir: dir, Package: "."})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"No benchmark ran",
+		"## Heap escapes (",
+		"### app.go:16 [preallocate]",
+		"### app.go:32 [moved-to-heap]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandler_Validation(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range []Params{
+		{Dir: dir},
+		{Dir: dir, Package: "./..."},
+	} {
+		if text, isErr := call(t, args); !isErr {
+			t.Errorf("expected an error for %+v, got:\n%s", args, text)
+		}
+	}
+}
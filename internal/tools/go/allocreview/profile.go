@@ -0,0 +1,146 @@
+package allocreview
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Bench is one benchmark result line of go test -benchmem.
+type Bench struct {
+	Name        string  `json:"name"`
+	BytesPerOp  float64 `json:"bytesPerOp"`
+	AllocsPerOp float64 `json:"allocsPerOp"`
+}
+
+// Escape is one escape analysis diagnostic of the compiler (-gcflags=-m).
+type Escape struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Col     int    `json:"col"`
+	Message string `json:"message"`
+}
+
+var (
+	benchRx  = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.*)$`)
+	escapeRx = regexp.MustCompile(`^(.+\.go):(\d+):(\d+): (.*)$`)
+	frameRx  = regexp.MustCompile(`^\s*(?:(\S+)\s+)?(\S+) (\S+\.go):(\d+)(?: \(inline\))?$`)
+)
+
+// ParseBenchmarks extracts the B/op and allocs/op of each benchmark from go test output.
+func ParseBenchmarks(out string) []Bench {
+	var benches []Bench
+	for _, line := range strings.Split(out, "\n") {
+		m := benchRx.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		b := Bench{Name: m[1]}
+		fields := strings.Fields(m[2])
+		for i := 0; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "B/op":
+				b.BytesPerOp = v
+			case "allocs/op":
+				b.AllocsPerOp = v
+			}
+		}
+		benches = append(benches, b)
+	}
+	return benches
+}
+
+// ParseEscapes extracts the heap escapes reported by the compiler. Relative paths are resolved
+// against dir, the directory the compiler ran in. Diagnostics about values that do not escape,
+// inlining and leaking parameters are dropped.
+func ParseEscapes(out, dir string) []Escape {
+	var escapes []Escape
+	for _, line := range strings.Split(out, "\n") {
+		m := escapeRx.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		msg := m[4]
+		if !strings.HasSuffix(msg, "escapes to heap") && !strings.HasPrefix(msg, "moved to heap:") {
+			continue
+		}
+		file := m[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		l, _ := strconv.Atoi(m[2])
+		c, _ := strconv.Atoi(m[3])
+		escapes = append(escapes, Escape{File: file, Line: l, Col: c, Message: msg})
+	}
+	return escapes
+}
+
+// lineKey identifies a source line.
+type lineKey struct {
+	file string
+	line int
+}
+
+// sampleTotals is the allocation volume attributed to a line, with the function containing it.
+type sampleTotals struct {
+	fn    string
+	value float64
+}
+
+// parseTraces attributes the samples of `go tool pprof -traces -lines` output to the first frame
+// accepted by keep. Allocations made by the runtime or the standard library on behalf of the
+// package are thus charged to the package line that asked for them.
+func parseTraces(out string, keep func(file string) bool) map[lineKey]*sampleTotals {
+	totals := make(map[lineKey]*sampleTotals)
+	var value float64
+	attributed := true
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "-----------+") {
+			value, attributed = 0, false
+			continue
+		}
+		if attributed {
+			continue
+		}
+		m := frameRx.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[1] != "" {
+			value = parseQuantity(m[1])
+		}
+		if !keep(m[3]) {
+			continue
+		}
+		n, _ := strconv.Atoi(m[4])
+		key := lineKey{m[3], n}
+		if totals[key] == nil {
+			totals[key] = &sampleTotals{fn: m[2]}
+		}
+		totals[key].value += value
+		attributed = true
+	}
+	return totals
+}
+
+// parseQuantity parses the values printed by pprof: "0", "96B", "12kB", "1.50MB", "3.20k".
+func parseQuantity(s string) float64 {
+	s = strings.TrimSuffix(s, "B")
+	mult := 1.0
+	for suffix, m := range map[string]float64{"k": 1e3, "K": 1e3, "M": 1e6, "G": 1e9, "T": 1e12} {
+		if strings.HasSuffix(s, suffix) {
+			s, mult = strings.TrimSuffix(s, suffix), m
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v * mult
+}
@@ -0,0 +1,631 @@
+package allocreview
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Kinds of allocation site.
+const (
+	KindPrealloc = "preallocate"
+	KindBuffer   = "reuse-buffer"
+	KindConcat   = "string-concat"
+	KindBoxing   = "interface-boxing"
+	KindEscape   = "moved-to-heap"
+	KindOther    = "other"
+)
+
+// Site is a source line that allocates, with what the profile and the compiler say about it and
+// the suggested fix.
+type Site struct {
+	Position string  `json:"position"` // file:line relative to the module
+	File     string  `json:"-"`
+	Line     int     `json:"-"`
+	Func     string  `json:"func,omitempty"`
+	Bytes    float64 `json:"bytes"`
+	Objects  float64 `json:"objects"`
+	Escapes  []Escape
+	Kind     string `json:"kind"`
+	Advice   string `json:"advice"`
+	Patch    string `json:"patch,omitempty"` // unified diff, for mechanical fixes
+	Frame    string `json:"-"`
+}
+
+// suggestion is one diagnosis of a line; a line may match several.
+type suggestion struct {
+	kind   string
+	advice string
+	edits  []shared.TextEdit
+}
+
+type classifier struct {
+	root  string
+	pkg   *packages.Package
+	files map[string]*ast.File
+	src   map[string][]byte
+}
+
+func newClassifier(root string, pkg *packages.Package) *classifier {
+	c := &classifier{root: root, pkg: pkg, files: make(map[string]*ast.File), src: make(map[string][]byte)}
+	for _, f := range pkg.Syntax {
+		name := pkg.Fset.File(f.Pos()).Name()
+		//nolint:gosec // G304: Path comes from the loaded package.
+		if src, err := os.ReadFile(name); err == nil {
+			c.files[name], c.src[name] = f, src
+		}
+	}
+	return c
+}
+
+// position returns "file:line" relative to the module.
+func (c *classifier) position(key lineKey) string {
+	rel, err := filepath.Rel(c.root, key.file)
+	if err != nil {
+		rel = key.file
+	}
+	return fmt.Sprintf("%s:%d", rel, key.line)
+}
+
+// classify fills in the kind, advice, code frame and patch of a site.
+func (c *classifier) classify(s *Site) {
+	f, src := c.files[s.File], c.src[s.File]
+	if f == nil {
+		s.Kind, s.Advice = KindOther, "The line is outside the parsed sources of the package."
+		return
+	}
+	s.Frame = shared.CodeFrame(string(src), s.Line, shared.FrameOptions{Context: 2})
+
+	var found []suggestion
+	for _, n := range c.nodesOnLine(f, s.Line) {
+		path, _ := astutil.PathEnclosingInterval(f, n.Pos(), n.End())
+		var sg *suggestion
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if sg = c.prealloc(src, n, path); sg == nil {
+				sg = c.concat(n, path)
+			}
+		case *ast.CallExpr:
+			if sg = c.buffer(src, n, path); sg == nil {
+				sg = c.boxing(src, n)
+			}
+		case *ast.UnaryExpr, *ast.DeclStmt:
+			sg = c.buffer(src, n, path)
+		}
+		if sg != nil {
+			found = append(found, *sg)
+		}
+	}
+	for _, e := range s.Escapes {
+		if name, ok := strings.CutPrefix(e.Message, "moved to heap: "); ok {
+			found = append(found, suggestion{kind: KindEscape, advice: fmt.Sprintf(
+				"`%s` lives on the heap because its address outlives the call: it is returned, stored in a longer-lived value or captured by a closure. Return it by value, or let the caller pass in the storage.", name)})
+		}
+	}
+
+	if len(found) == 0 {
+		s.Kind = KindOther
+		s.Advice = "The allocation happens in a callee on behalf of this line. Look for a variant that appends into a caller-provided buffer, or reuse the result across calls."
+		if len(s.Escapes) > 0 {
+			s.Advice = "The values named by escape analysis outlive the call. Check whether they have to, e.g. by returning values instead of pointers."
+		}
+		return
+	}
+	s.Kind = found[0].kind
+	seen := make(map[string]bool)
+	var advice []string
+	for _, sg := range found {
+		if !seen[sg.advice] {
+			seen[sg.advice] = true
+			advice = append(advice, sg.advice)
+		}
+		if s.Patch == "" && len(sg.edits) > 0 {
+			s.Patch = c.patch(s.File, sg.edits)
+		}
+	}
+	s.Advice = strings.Join(advice, " ")
+}
+
+// nodesOnLine returns the statements and expressions starting on line, outermost first.
+func (c *classifier) nodesOnLine(f *ast.File, line int) []ast.Node {
+	var nodes []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		start, end := c.pkg.Fset.Position(n.Pos()).Line, c.pkg.Fset.Position(n.End()).Line
+		if start > line || end < line {
+			return false
+		}
+		if start == line {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
+// loopOf returns the innermost loop whose body contains the end of path, within its function.
+func loopOf(path []ast.Node) ast.Stmt {
+	for i, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return nil
+		case *ast.ForStmt:
+			if i > 0 && path[i-1] == ast.Node(n.Body) {
+				return n
+			}
+		case *ast.RangeStmt:
+			if i > 0 && path[i-1] == ast.Node(n.Body) {
+				return n
+			}
+		}
+	}
+	return nil
+}
+
+func (c *classifier) text(src []byte, n ast.Node) string {
+	return string(src[c.pkg.Fset.Position(n.Pos()).Offset:c.pkg.Fset.Position(n.End()).Offset])
+}
+
+func (c *classifier) offset(p token.Pos) int { return c.pkg.Fset.Position(p).Offset }
+
+// prealloc recognizes `s = append(s, ...)` in a loop on a slice declared without capacity.
+func (c *classifier) prealloc(src []byte, as *ast.AssignStmt, path []ast.Node) *suggestion {
+	info := c.pkg.TypesInfo
+	if len(as.Lhs) != 1 || len(as.Rhs) != 1 || as.Tok != token.ASSIGN {
+		return nil
+	}
+	call, ok := as.Rhs[0].(*ast.CallExpr)
+	if !ok || len(call.Args) < 2 {
+		return nil
+	}
+	if fn, ok := call.Fun.(*ast.Ident); !ok || info.Uses[fn] != types.Universe.Lookup("append") {
+		return nil
+	}
+	lhs, ok1 := as.Lhs[0].(*ast.Ident)
+	arg, ok2 := call.Args[0].(*ast.Ident)
+	if !ok1 || !ok2 || info.Uses[lhs] == nil || info.Uses[lhs] != info.Uses[arg] {
+		return nil
+	}
+	loop := loopOf(path)
+	if loop == nil {
+		return nil
+	}
+	v := info.Uses[lhs].(*types.Var)
+	sg := &suggestion{kind: KindPrealloc}
+	capacity := c.capacity(src, loop)
+	hint := "the number of elements"
+	if capacity != "" {
+		hint = "`" + capacity + "`"
+	}
+	sg.advice = fmt.Sprintf("`%s` grows by append inside the loop, reallocating and copying each time its capacity doubles. Preallocate it with a capacity of %s.", v.Name(), hint)
+	if conditional(path, loop) {
+		sg.advice += " The append is conditional, so that capacity is an upper bound."
+	}
+	if capacity == "" {
+		return sg
+	}
+
+	// Rewrite the declaration when it is plain and in scope of the capacity expression.
+	f := c.files[c.pkg.Fset.File(as.Pos()).Name()]
+	declPath, _ := astutil.PathEnclosingInterval(f, v.Pos(), v.Pos())
+	var stmt ast.Stmt
+	var typ string
+	for _, n := range declPath {
+		switch d := n.(type) {
+		case *ast.DeclStmt:
+			gen := d.Decl.(*ast.GenDecl)
+			if spec, ok := gen.Specs[0].(*ast.ValueSpec); ok && len(gen.Specs) == 1 && len(spec.Names) == 1 && len(spec.Values) == 0 && spec.Type != nil {
+				stmt, typ = d, c.text(src, spec.Type)
+				sg.advice += " Unlike the nil slice it replaces, the result is non-nil even when the loop adds nothing."
+			}
+		case *ast.AssignStmt:
+			if d.Tok != token.DEFINE || len(d.Lhs) != 1 {
+				break
+			}
+			switch rhs := d.Rhs[0].(type) {
+			case *ast.CompositeLit:
+				if len(rhs.Elts) == 0 && rhs.Type != nil {
+					stmt, typ = d, c.text(src, rhs.Type)
+				}
+			case *ast.CallExpr:
+				if id, ok := rhs.Fun.(*ast.Ident); ok && id.Name == "make" && len(rhs.Args) == 2 && c.text(src, rhs.Args[1]) == "0" {
+					stmt, typ = d, c.text(src, rhs.Args[0])
+				}
+			}
+		}
+		if stmt != nil || n == ast.Node(f) {
+			break
+		}
+	}
+	if stmt == nil || stmt.End() > loop.Pos() || !c.inScope(capacity, loop, stmt) {
+		return sg
+	}
+	sg.edits = []shared.TextEdit{{
+		Start: c.offset(stmt.Pos()), End: c.offset(stmt.End()),
+		New: fmt.Sprintf("%s := make(%s, 0, %s)", v.Name(), typ, capacity),
+	}}
+	return sg
+}
+
+// capacity returns the source of the iteration count of a loop, or "" when it is not a plain
+// expression.
+func (c *classifier) capacity(src []byte, loop ast.Stmt) string {
+	info := c.pkg.TypesInfo
+	switch l := loop.(type) {
+	case *ast.RangeStmt:
+		if !plain(l.X) {
+			return ""
+		}
+		switch t := info.TypeOf(l.X).Underlying().(type) {
+		case *types.Slice, *types.Array, *types.Map:
+			return "len(" + c.text(src, l.X) + ")"
+		case *types.Pointer:
+			if _, ok := t.Elem().Underlying().(*types.Array); ok {
+				return "len(" + c.text(src, l.X) + ")"
+			}
+		case *types.Basic:
+			if t.Info()&types.IsInteger != 0 {
+				return c.text(src, l.X)
+			}
+		}
+	case *ast.ForStmt:
+		bin, ok := l.Cond.(*ast.BinaryExpr)
+		if !ok || bin.Op != token.LSS || !plain(bin.Y) {
+			return ""
+		}
+		if init, ok := l.Init.(*ast.AssignStmt); ok && len(init.Rhs) == 1 && c.text(src, init.Rhs[0]) == "0" {
+			return c.text(src, bin.Y)
+		}
+	}
+	return ""
+}
+
+// plain reports whether e is an identifier, a selector chain or len() of one.
+func plain(e ast.Expr) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		return plain(e.X)
+	case *ast.CallExpr:
+		id, ok := e.Fun.(*ast.Ident)
+		return ok && id.Name == "len" && len(e.Args) == 1 && plain(e.Args[0])
+	}
+	return false
+}
+
+// inScope reports whether every identifier of the capacity expression, used in the loop header,
+// is declared before stmt, where the preallocation goes.
+func (c *classifier) inScope(capacity string, loop, stmt ast.Stmt) bool {
+	ok := true
+	ast.Inspect(loop, func(n ast.Node) bool {
+		if b, isBlock := n.(*ast.BlockStmt); isBlock && b != nil {
+			return false
+		}
+		id, isIdent := n.(*ast.Ident)
+		if !isIdent || !strings.Contains(capacity, id.Name) {
+			return true
+		}
+		if obj := c.pkg.TypesInfo.Uses[id]; obj != nil && obj.Pkg() == c.pkg.Types && obj.Parent() != c.pkg.Types.Scope() && obj.Pos() > stmt.Pos() {
+			ok = false
+		}
+		return true
+	})
+	return ok
+}
+
+// conditional reports whether the end of path runs only on some iterations of loop.
+func conditional(path []ast.Node, loop ast.Stmt) bool {
+	for _, n := range path {
+		if n == ast.Node(loop) {
+			return false
+		}
+		switch n.(type) {
+		case *ast.IfStmt, *ast.CaseClause, *ast.CommClause:
+			return true
+		}
+	}
+	return false
+}
+
+// bufferTypes are the types whose allocation per iteration or call is worth reusing.
+var bufferTypes = map[string]bool{"bytes.Buffer": true, "strings.Builder": true}
+
+// bufferCalls are the constructors of buffers and buffered I/O.
+var bufferCalls = map[string]bool{
+	"bytes.NewBuffer": true, "bytes.NewBufferString": true,
+	"bufio.NewReader": true, "bufio.NewReaderSize": true, "bufio.NewWriter": true, "bufio.NewWriterSize": true, "bufio.NewScanner": true,
+}
+
+// buffer recognizes the allocation of a buffer: new(bytes.Buffer), &bytes.Buffer{},
+// var b strings.Builder, make([]byte, n) and the bufio constructors.
+func (c *classifier) buffer(src []byte, n ast.Node, path []ast.Node) *suggestion {
+	info := c.pkg.TypesInfo
+	what := ""
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		switch fn := ast.Unparen(n.Fun).(type) {
+		case *ast.Ident:
+			if len(n.Args) == 0 {
+				break
+			}
+			switch {
+			case fn.Name == "new" && bufferTypes[typeName(info.TypeOf(n.Args[0]))]:
+				what = typeName(info.TypeOf(n.Args[0]))
+			case fn.Name == "make" && types.Identical(info.TypeOf(n.Args[0]), types.NewSlice(types.Typ[types.Byte])):
+				what = "[]byte"
+			}
+		case *ast.SelectorExpr:
+			if f, ok := info.Uses[fn.Sel].(*types.Func); ok && f.Pkg() != nil && bufferCalls[f.Pkg().Name()+"."+f.Name()] {
+				what = f.Pkg().Name() + "." + f.Name()
+			} else if id, ok := fn.X.(*ast.Ident); ok && (strings.HasPrefix(fn.Sel.Name, "Write") || fn.Sel.Name == "ReadFrom" || fn.Sel.Name == "Grow") {
+				// A buffer that stays on the stack allocates when it grows: blame its declaration.
+				return c.bufferDecl(src, id, path)
+			}
+		}
+	case *ast.UnaryExpr:
+		if lit, ok := n.X.(*ast.CompositeLit); ok && n.Op == token.AND && bufferTypes[typeName(info.TypeOf(lit))] {
+			what = typeName(info.TypeOf(lit))
+		}
+	case *ast.DeclStmt:
+		gen, ok := n.Decl.(*ast.GenDecl)
+		if !ok || len(gen.Specs) != 1 {
+			break
+		}
+		if spec, ok := gen.Specs[0].(*ast.ValueSpec); ok && spec.Type != nil && len(spec.Values) == 0 && bufferTypes[typeName(info.TypeOf(spec.Type))] {
+			what = typeName(info.TypeOf(spec.Type))
+		}
+	}
+	if what == "" {
+		return nil
+	}
+
+	loop := loopOf(path)
+	if loop == nil {
+		return &suggestion{kind: KindBuffer, advice: fmt.Sprintf(
+			"Each call allocates a new %s. If the function runs per request or per item, keep buffers in a `sync.Pool` (Get, Reset, use, Put) or let the caller pass one in.", what)}
+	}
+	sg := &suggestion{kind: KindBuffer}
+	if what == "[]byte" {
+		sg.advice = "Each iteration allocates a new byte slice. Allocate it once before the loop, sized for the largest iteration, and reslice it (`buf = buf[:n]`)."
+		return sg
+	}
+	sg.advice = fmt.Sprintf("Each iteration allocates a new %s. Declare it once before the loop and reset it at the start of each iteration.", what)
+	if !bufferTypes[what] {
+		return sg
+	}
+
+	// Hoist `v := new(T)`, `v := &T{}` or `var v T` when v stays inside the iteration.
+	var stmt ast.Stmt
+	var name *ast.Ident
+	for _, p := range path {
+		if s, ok := p.(ast.Stmt); ok {
+			stmt = s
+			break
+		}
+	}
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if len(s.Lhs) == 1 && s.Tok == token.DEFINE && s.Rhs[0] == n {
+			name, _ = s.Lhs[0].(*ast.Ident)
+		}
+	case *ast.DeclStmt:
+		name = s.Decl.(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Names[0]
+	}
+	body := loopBody(loop)
+	if name == nil || len(body.List) == 0 || !containsStmt(body, stmt) || !c.iterationLocal(name, body) {
+		return sg
+	}
+	if _, obj := c.pkg.Types.Scope().Innermost(loop.Pos()).LookupParent(name.Name, loop.Pos()); obj != nil {
+		return sg // hoisting would shadow another declaration
+	}
+	start := c.offset(loop.Pos())
+	lineStart := start
+	for lineStart > 0 && (src[lineStart-1] == ' ' || src[lineStart-1] == '\t') {
+		lineStart--
+	}
+	indent := string(src[lineStart:start])
+	sg.edits = []shared.TextEdit{
+		{Start: start, End: start, New: c.text(src, stmt) + "\n" + indent},
+		{Start: c.offset(stmt.Pos()), End: c.offset(stmt.End()), New: name.Name + ".Reset()"},
+	}
+	return sg
+}
+
+// bufferDecl diagnoses the declaration of the buffer id when it is declared inside the same
+// loop as its use.
+func (c *classifier) bufferDecl(src []byte, id *ast.Ident, path []ast.Node) *suggestion {
+	v, ok := c.pkg.TypesInfo.Uses[id].(*types.Var)
+	if !ok || v.Pkg() != c.pkg.Types {
+		return nil
+	}
+	t := v.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	loop := loopOf(path)
+	if loop == nil || !bufferTypes[typeName(t)] || v.Pos() < loop.Pos() || v.Pos() > loop.End() {
+		return nil
+	}
+	f := c.files[c.pkg.Fset.File(id.Pos()).Name()]
+	declPath, _ := astutil.PathEnclosingInterval(f, v.Pos(), v.Pos())
+	for i, n := range declPath {
+		switch d := n.(type) {
+		case *ast.DeclStmt:
+			return c.buffer(src, d, declPath[i:])
+		case *ast.AssignStmt:
+			if len(d.Rhs) == 1 {
+				rhsPath, _ := astutil.PathEnclosingInterval(f, d.Rhs[0].Pos(), d.Rhs[0].End())
+				return c.buffer(src, d.Rhs[0], rhsPath)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func loopBody(loop ast.Stmt) *ast.BlockStmt {
+	switch l := loop.(type) {
+	case *ast.ForStmt:
+		return l.Body
+	case *ast.RangeStmt:
+		return l.Body
+	}
+	return nil
+}
+
+func containsStmt(body *ast.BlockStmt, stmt ast.Stmt) bool {
+	for _, s := range body.List {
+		if s == stmt {
+			return true
+		}
+	}
+	return false
+}
+
+// iterationLocal reports whether every use of the variable declared by name is a method call
+// or field access outside function literals, so no reference survives the iteration.
+func (c *classifier) iterationLocal(name *ast.Ident, body *ast.BlockStmt) bool {
+	obj := c.pkg.TypesInfo.Defs[name]
+	if obj == nil {
+		return false
+	}
+	ok := true
+	var visit func(n ast.Node, inLit bool)
+	visit = func(n ast.Node, inLit bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				visit(n.Body, true)
+				return false
+			case *ast.SelectorExpr:
+				if id, isIdent := n.X.(*ast.Ident); isIdent && c.pkg.TypesInfo.Uses[id] == obj {
+					ok = ok && !inLit
+					return false
+				}
+			case *ast.Ident:
+				if c.pkg.TypesInfo.Uses[n] == obj {
+					ok = false
+				}
+			}
+			return ok
+		})
+	}
+	visit(body, false)
+	return ok
+}
+
+// concat recognizes `s += ...` on a string inside a loop.
+func (c *classifier) concat(as *ast.AssignStmt, path []ast.Node) *suggestion {
+	if as.Tok != token.ADD_ASSIGN || len(as.Lhs) != 1 || loopOf(path) == nil {
+		return nil
+	}
+	if t, ok := c.pkg.TypesInfo.TypeOf(as.Lhs[0]).Underlying().(*types.Basic); !ok || t.Info()&types.IsString == 0 {
+		return nil
+	}
+	return &suggestion{kind: KindConcat, advice: fmt.Sprintf(
+		"`%s += ...` copies the whole string on every iteration, which is quadratic. Build it with a `strings.Builder` and call `String()` once after the loop; `audit_strings` with apply=true rewrites the simple cases.", types.ExprString(as.Lhs[0]))}
+}
+
+// boxing recognizes fmt calls formatting concrete values, which are boxed into interfaces.
+func (c *classifier) boxing(src []byte, call *ast.CallExpr) *suggestion {
+	info := c.pkg.TypesInfo
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" {
+		return nil
+	}
+	var boxed []string
+	for _, arg := range call.Args {
+		t := info.TypeOf(arg)
+		if t == nil || types.IsInterface(t) {
+			continue
+		}
+		if tv := info.Types[arg]; tv.Value != nil {
+			continue // constants are boxed without allocating
+		}
+		boxed = append(boxed, "`"+types.ExprString(arg)+"`")
+	}
+	if len(boxed) == 0 {
+		return nil
+	}
+	sg := &suggestion{kind: KindBoxing, advice: fmt.Sprintf(
+		"`fmt.%s` takes `any` arguments, so %s is boxed into an interface, and formatting goes through reflection. On hot paths use typed conversions (`strconv`) and append into a reused buffer.", fn.Name(), strings.Join(boxed, ", "))}
+
+	// fmt.Sprint(x) and fmt.Sprintf("%d"/"%v", x) of a single integer, bool or string.
+	var value ast.Expr
+	switch {
+	case fn.Name() == "Sprint" && len(call.Args) == 1:
+		value = call.Args[0]
+	case fn.Name() == "Sprintf" && len(call.Args) == 2:
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && (lit.Value == `"%d"` || lit.Value == `"%v"`) {
+			value = call.Args[1]
+		}
+	}
+	if value == nil {
+		return sg
+	}
+	basic, ok := info.TypeOf(value).(*types.Basic)
+	if !ok {
+		return sg
+	}
+	v := c.text(src, value)
+	var repl string
+	switch basic.Kind() {
+	case types.Int:
+		repl = "strconv.Itoa(" + v + ")"
+	case types.Int64:
+		repl = "strconv.FormatInt(" + v + ", 10)"
+	case types.Uint64:
+		repl = "strconv.FormatUint(" + v + ", 10)"
+	case types.Bool:
+		repl = "strconv.FormatBool(" + v + ")"
+	case types.String:
+		repl = v
+	default:
+		return sg
+	}
+	sg.edits = []shared.TextEdit{{Start: c.offset(call.Pos()), End: c.offset(call.End()), New: repl}}
+	return sg
+}
+
+// typeName returns "pkg.Name" for a named type.
+func typeName(t types.Type) string {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Name() + "." + named.Obj().Name()
+}
+
+// patch renders edits of file as a unified diff, with imports fixed up as goimports would.
+func (c *classifier) patch(file string, edits []shared.TextEdit) string {
+	before := c.src[file]
+	after, err := shared.ApplyEdits(before, edits)
+	if err != nil {
+		return ""
+	}
+	if formatted, err := imports.Process(file, after, nil); err == nil {
+		after = formatted
+	}
+	rel, err := filepath.Rel(c.root, file)
+	if err != nil {
+		rel = file
+	}
+	return "--- " + rel + "\n+++ " + rel + "\n" + textdiff.Unified(string(before), string(after))
+}
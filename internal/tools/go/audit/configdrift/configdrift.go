@@ -0,0 +1,334 @@
+// Package configdrift implements the audit_config tool, which cross-references the environment
+// variables a module reads against the ones its deployment files (.env, Dockerfile, compose and
+// Kubernetes manifests) define.
+package configdrift
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_config"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string   `json:"dir,omitempty" jsonschema:"The absolute repository directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string   `json:"packages,omitempty" jsonschema:"Package pattern to scan for reads (default: ./...)"`
+	Ignore       []string `json:"ignore,omitempty" jsonschema:"Additional variable names to ignore (system variables such as HOME and PATH are always ignored)"`
+	OutputFormat string   `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers.
+const (
+	RuleUndefined = "read-not-defined"
+	RuleUnused    = "defined-not-read"
+	viperPkg      = "github.com/spf13/viper"
+)
+
+// systemVars are provided by the OS or the platform and never need to be declared.
+var systemVars = map[string]bool{
+	"HOME": true, "PATH": true, "USER": true, "PWD": true, "SHELL": true, "TMPDIR": true,
+	"HOSTNAME": true, "TERM": true, "LANG": true, "GOPATH": true, "GOROOT": true, "GOOS": true,
+	"GOARCH": true, "GOFLAGS": true, "CI": true, "XDG_CONFIG_HOME": true, "XDG_CACHE_HOME": true,
+}
+
+// Read is a place where code reads an environment variable.
+type Read struct {
+	Name     string
+	Position string
+	Pkg      string
+	Via      string // os.Getenv, viper, struct tag, ...
+}
+
+// Handler handles the audit_config tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	reads, dynamic := CollectReads(absDir, pkgs)
+	defs, err := ScanDefinitions(absDir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	ignore := make(map[string]bool, len(systemVars)+len(args.Ignore))
+	for k := range systemVars {
+		ignore[k] = true
+	}
+	for _, k := range args.Ignore {
+		ignore[k] = true
+	}
+	findings := Compare(reads, defs, ignore)
+
+	rep := render(reads, defs, findings, dynamic, locale.FromRequest(req))
+	output, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// CollectReads finds environment variable reads with constant names: os.Getenv/LookupEnv,
+// syscall.Getenv, viper keys (mapped to their environment form) and `env`/`envconfig` struct tags.
+// It also returns the number of reads whose name is not a constant.
+func CollectReads(root string, pkgs []*packages.Package) ([]Read, int) {
+	var reads []Read
+	dynamic := 0
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+		pos := func(n ast.Node) string { return shared.RelPosition(root, pkg.Fset.Position(n.Pos())) }
+		add := func(name string, n ast.Node, via string) {
+			reads = append(reads, Read{Name: name, Position: pos(n), Pkg: pkg.PkgPath, Via: via})
+		}
+
+		prefix := viperPrefix(info, pkg.Syntax)
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch node := n.(type) {
+				case *ast.CallExpr:
+					fn := typeutil.Callee(info, node)
+					f, ok := fn.(*types.Func)
+					if !ok || f.Pkg() == nil || len(node.Args) == 0 {
+						return true
+					}
+					path, name := f.Pkg().Path(), f.Name()
+					switch {
+					case (path == "os" && (name == "Getenv" || name == "LookupEnv")) || (path == "syscall" && name == "Getenv"):
+						if key, ok := constString(info, node.Args[0]); ok {
+							add(key, node, path+"."+name)
+						} else {
+							dynamic++
+						}
+					case path == viperPkg && name == "BindEnv":
+						if len(node.Args) > 1 {
+							for _, arg := range node.Args[1:] {
+								if env, ok := constString(info, arg); ok {
+									add(env, node, "viper.BindEnv")
+								}
+							}
+						} else if key, ok := constString(info, node.Args[0]); ok {
+							add(viperEnv(prefix, key), node, "viper.BindEnv")
+						}
+					case path == viperPkg && (strings.HasPrefix(name, "Get") || name == "IsSet"):
+						if key, ok := constString(info, node.Args[0]); ok {
+							add(viperEnv(prefix, key), node, fmt.Sprintf("viper key %q", key))
+						} else {
+							dynamic++
+						}
+					}
+				case *ast.StructType:
+					for _, field := range node.Fields.List {
+						if field.Tag == nil {
+							continue
+						}
+						tag, err := strconv.Unquote(field.Tag.Value)
+						if err != nil {
+							continue
+						}
+						for _, key := range []string{"env", "envconfig"} {
+							if v, ok := reflect.StructTag(tag).Lookup(key); ok {
+								if name := strings.Split(v, ",")[0]; name != "" && name != "-" {
+									add(name, field, key+" tag")
+								}
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	sort.Slice(reads, func(i, j int) bool {
+		if reads[i].Name != reads[j].Name {
+			return reads[i].Name < reads[j].Name
+		}
+		return shared.ComparePositions(reads[i].Position, reads[j].Position) < 0
+	})
+	return reads, dynamic
+}
+
+// viperPrefix returns the constant argument of a viper SetEnvPrefix call in the package, if any.
+func viperPrefix(info *types.Info, files []*ast.File) string {
+	prefix := ""
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if f, ok := typeutil.Callee(info, call).(*types.Func); ok && f.Pkg() != nil && f.Pkg().Path() == viperPkg && f.Name() == "SetEnvPrefix" {
+				if p, ok := constString(info, call.Args[0]); ok {
+					prefix = p
+				}
+			}
+			return true
+		})
+	}
+	return prefix
+}
+
+// viperEnv maps a viper key to the environment variable AutomaticEnv consults for it, assuming the
+// common "." and "-" to "_" key replacer.
+func viperEnv(prefix, key string) string {
+	env := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if prefix != "" {
+		env = strings.ToUpper(prefix) + "_" + env
+	}
+	return env
+}
+
+func constString(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// Compare reports variables read but never defined, and defined but never read.
+func Compare(reads []Read, defs []Definition, ignore map[string]bool) []shared.Finding {
+	defined := make(map[string]bool)
+	for _, d := range defs {
+		defined[d.Name] = true
+	}
+	read := make(map[string]bool)
+	var findings []shared.Finding
+	for _, r := range reads {
+		read[r.Name] = true
+		if defined[r.Name] || ignore[r.Name] {
+			continue
+		}
+		findings = append(findings, shared.Finding{
+			Pkg:        r.Pkg,
+			Position:   r.Position,
+			Rule:       RuleUndefined,
+			Message:    fmt.Sprintf("%s is read (%s) but no deployment file defines it", r.Name, r.Via),
+			Suggestion: fmt.Sprintf("Add %s to .env.example and the deployment manifests, or document its default.", r.Name),
+		})
+	}
+	for _, d := range defs {
+		if read[d.Name] || ignore[d.Name] {
+			continue
+		}
+		findings = append(findings, shared.Finding{
+			Position:   d.Position,
+			Rule:       RuleUnused,
+			Message:    fmt.Sprintf("%s is defined but never read by the code", d.Name),
+			Suggestion: fmt.Sprintf("Remove %s from %s, or check for a typo in the name the code reads.", d.Name, d.Source),
+		})
+	}
+	return findings
+}
+
+func render(reads []Read, defs []Definition, findings []shared.Finding, dynamic int, lang string) *shared.Report {
+	if findings == nil {
+		findings = []shared.Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "configdrift.title"), Lang: lang, Status: shared.StatusPass, Data: findings}
+	names := make(map[string]bool)
+	for _, r := range reads {
+		names[r.Name] = true
+	}
+	files := make(map[string]bool)
+	for _, d := range defs {
+		files[d.File] = true
+	}
+	entries := locale.T(lang, plural(len(defs), "configdrift.entry", "configdrift.entries"))
+	summary := locale.T(lang, "configdrift.summary", len(names), len(files), len(defs), entries)
+	if dynamic > 0 {
+		summary += "\n\n" + locale.T(lang, "configdrift.dynamic", dynamic)
+	}
+	if len(findings) == 0 {
+		summary += "\n\n" + locale.T(lang, "configdrift.none")
+	}
+	rep.Summary = summary
+
+	var undefined, unused []string
+	for _, f := range findings {
+		if f.Rule == RuleUndefined {
+			undefined = append(undefined, f.Position+": "+f.Message)
+		} else {
+			unused = append(unused, f.Position+": "+f.Message)
+		}
+	}
+	if len(unused) > 0 {
+		rep.Status = shared.StatusWarn
+	}
+	if len(undefined) > 0 {
+		rep.Status = shared.StatusFail
+		rep.Add(fmt.Sprintf("Read but Never Defined (%d)", len(undefined)), shared.StatusFail).Items = undefined
+	}
+	if len(unused) > 0 {
+		rep.Add(fmt.Sprintf("Defined but Never Read (%d)", len(unused)), shared.StatusWarn).Items = unused
+	}
+	return rep
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package configdrift
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const appSrc = `package app
+
+import "os"
+
+type Config struct {
+	Region string ` + "`env:\"AWS_REGION,required\"`" + `
+}
+
+func Load() (string, string, string) {
+	dsn := os.Getenv("DATABASE_URL")
+	token, _ := os.LookupEnv("API_TOKEN")
+	key := "FEATURE_" + "FLAGS"
+	_ = os.Getenv(os.Args[0])
+	return dsn, token, os.Getenv(key) + os.Getenv("HOME")
+}
+`
+
+const composeSrc = `services:
+  app:
+    image: app
+    environment:
+      - DATABASE_URL=postgres://db
+      - LOG_LEVEL=debug
+  worker:
+    environment:
+      QUEUE_NAME: jobs
+`
+
+const deploymentSrc = `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - name: API_TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: token
+                  key: value
+            - name: AWS_REGION
+              value: us-east-1
+`
+
+func TestHandler(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":                 testutil.GoMod("example.com/app"),
+		"app/app.go":             appSrc,
+		"docker-compose.yml":     composeSrc,
+		"deploy/deployment.yaml": deploymentSrc,
+		".env.example":           "# local defaults\nexport DATABASE_URL=postgres://localhost\n",
+		"Dockerfile":             "FROM golang\nENV CGO_ENABLED=0 FEATURE_FLAGS=none\n",
+		"vendor/x/.env":          "VENDORED=1\n",
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Ignore: []string{"CGO_ENABLED"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"## Defined but Never Read (3): ⚠️ WARN",
+		"Dockerfile:2: FEATURE_FLAGS is defined but never read",
+		"docker-compose.yml:6: LOG_LEVEL is defined but never read",
+		"docker-compose.yml:9: QUEUE_NAME is defined but never read",
+		"2 read(s) use a non-constant name",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"Read but Never Defined", "HOME", "VENDORED", "CGO_ENABLED", "token is defined"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected output not to contain %q, got:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestHandler_Undefined(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":  testutil.GoMod("example.com/app"),
+		"main.go": "package main\n\nimport \"os\"\n\nfunc main() { _ = os.Getenv(\"SECRET_KEY\") }\n",
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(out, "main.go:5:19: SECRET_KEY is read (os.Getenv) but no deployment file defines it") {
+		t.Errorf("expected undefined variable to be reported, got:\n%s", out)
+	}
+}
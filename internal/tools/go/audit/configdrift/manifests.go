@@ -0,0 +1,210 @@
+package configdrift
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/tools/shared"
+)
+
+// Definition is an environment variable set by a deployment file.
+type Definition struct {
+	Name     string
+	File     string // relative to the repository root
+	Position string // file:line
+	Source   string // human-readable file kind
+}
+
+var (
+	envName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	skippedDirs  = map[string]bool{".git": true, "vendor": true, "node_modules": true, "testdata": true}
+	yamlNameLine = regexp.MustCompile(`^-\s+name:\s*["']?([A-Za-z_][A-Za-z0-9_]*)["']?\s*$`)
+)
+
+// ScanDefinitions walks root for .env files, Dockerfiles and YAML manifests (compose and
+// Kubernetes) and returns every environment variable they define.
+func ScanDefinitions(root string) ([]Definition, error) {
+	var defs []Definition
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parse := parserFor(d.Name())
+		if parse == nil {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		defs = append(defs, parse(rel, strings.Split(string(content), "\n"))...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan deployment files: %w", err)
+	}
+	sort.Slice(defs, func(i, j int) bool { return shared.ComparePositions(defs[i].Position, defs[j].Position) < 0 })
+	return defs, nil
+}
+
+type fileParser func(rel string, lines []string) []Definition
+
+func parserFor(name string) fileParser {
+	lower := strings.ToLower(name)
+	switch {
+	case lower == ".env" || strings.HasPrefix(lower, ".env.") || strings.HasSuffix(lower, ".env"):
+		return parseDotenv
+	case lower == "dockerfile" || strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile"):
+		return parseDockerfile
+	case strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml"):
+		return parseYAML
+	}
+	return nil
+}
+
+func define(rel string, line int, name, source string) Definition {
+	return Definition{Name: name, File: rel, Position: fmt.Sprintf("%s:%d", rel, line), Source: source}
+}
+
+// parseDotenv reads KEY=VALUE lines, with optional "export" prefixes and # comments.
+func parseDotenv(rel string, lines []string) []Definition {
+	var defs []Definition
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, _, ok := strings.Cut(line, "=")
+		if key = strings.TrimSpace(key); ok && envName.MatchString(key) {
+			defs = append(defs, define(rel, i+1, key, rel))
+		}
+	}
+	return defs
+}
+
+// parseDockerfile reads ENV instructions in both the "ENV KEY=VALUE ..." and "ENV KEY VALUE" forms.
+func parseDockerfile(rel string, lines []string) []Definition {
+	var defs []Definition
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "ENV") {
+			continue
+		}
+		if !strings.Contains(fields[1], "=") {
+			if envName.MatchString(fields[1]) {
+				defs = append(defs, define(rel, i+1, fields[1], "the Dockerfile"))
+			}
+			continue
+		}
+		for _, f := range fields[1:] {
+			if key, _, ok := strings.Cut(f, "="); ok && envName.MatchString(key) {
+				defs = append(defs, define(rel, i+1, key, "the Dockerfile"))
+			}
+		}
+	}
+	return defs
+}
+
+// parseYAML extracts variables from compose "environment:" blocks (list or map form), Kubernetes
+// container "env:" lists (- name: KEY) and ConfigMap "data:" keys. It works on indentation rather
+// than a full YAML parse, which is enough for the shapes these files take in practice.
+func parseYAML(rel string, lines []string) []Definition {
+	configMap := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "kind: ConfigMap" {
+			configMap = true
+		}
+	}
+
+	var defs []Definition
+	block := ""      // key of the block being read
+	blockIndent := 0 // indentation of that key
+	childIndent := -1
+	for i, raw := range lines {
+		line := strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "---" {
+			block = ""
+			continue
+		}
+
+		if block != "" {
+			// Sequences may sit at the same indentation as their parent key.
+			inside := indent > blockIndent || (indent == blockIndent && strings.HasPrefix(trimmed, "- "))
+			if !inside {
+				block = ""
+			} else {
+				if childIndent < 0 {
+					childIndent = indent
+				}
+				if name := blockEntry(block, trimmed, indent == childIndent); name != "" {
+					defs = append(defs, define(rel, i+1, name, rel))
+				}
+				continue
+			}
+		}
+
+		key := strings.TrimPrefix(trimmed, "- ")
+		switch key {
+		case "environment:", "env:":
+			block, blockIndent, childIndent = strings.TrimSuffix(key, ":"), indent, -1
+		case "data:":
+			if configMap {
+				block, blockIndent, childIndent = "data", indent, -1
+			}
+		}
+	}
+	return defs
+}
+
+// blockEntry returns the variable defined by a line inside an env, environment or data block.
+// direct reports whether the line is an immediate child of the block key.
+func blockEntry(block, trimmed string, direct bool) string {
+	switch block {
+	case "env":
+		if m := yamlNameLine.FindStringSubmatch(trimmed); m != nil {
+			return m[1]
+		}
+	case "environment":
+		if !direct {
+			return ""
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			item = strings.Trim(item, `"'`)
+			key, _, _ := strings.Cut(item, "=")
+			if envName.MatchString(key) {
+				return key
+			}
+			return ""
+		}
+		fallthrough
+	case "data":
+		if !direct {
+			return ""
+		}
+		if key, _, ok := strings.Cut(trimmed, ":"); ok && envName.MatchString(strings.Trim(key, `"'`)) {
+			return strings.Trim(key, `"'`)
+		}
+	}
+	return ""
+}
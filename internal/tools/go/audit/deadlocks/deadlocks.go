@@ -0,0 +1,784 @@
+// Package deadlocks implements the audit_deadlocks tool, which builds a lock-order graph from the
+// mutex acquisitions of a module and reports lock-order inversions and blocking channel
+// operations inside critical sections.
+package deadlocks
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_deadlocks"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// maxFindings caps each section of the report.
+const maxFindings = 30
+
+// Step is one acquisition or channel operation, with the call path that reaches it from the
+// function holding the lock.
+type Step struct {
+	Position string   `json:"position"` // file:line:col of the acquisition or operation
+	Path     []string `json:"path"`     // functions from the holder down to the one performing the step
+}
+
+// Edge records that Second is acquired while First is held.
+type Edge struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+	HeldAt string `json:"held_at"` // where First was acquired
+	Step   Step   `json:"step"`
+}
+
+// Inversion is a cycle in the lock-order graph: goroutines taking the locks along different
+// edges of the cycle can each wait for a lock the other holds.
+type Inversion struct {
+	Locks []string `json:"locks"`
+	Edges []Edge   `json:"edges"`
+}
+
+// Blocking is a channel operation that can block while a lock is held.
+type Blocking struct {
+	Op     string `json:"op"` // "send", "receive", "range" or "select"
+	Lock   string `json:"lock"`
+	HeldAt string `json:"held_at"`
+	Step   Step   `json:"step"`
+}
+
+// Report is the result of the analysis.
+type Report struct {
+	Inversions []Inversion `json:"inversions"`
+	Recursive  []Edge      `json:"recursive"` // a lock acquired again while held
+	Blocking   []Blocking  `json:"blocking"`
+	Locks      int         `json:"locks"` // distinct locks seen
+}
+
+// Handler handles the audit_deadlocks tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	rep := render(absDir, pattern, Analyze(absDir, pkgs), locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// held is a lock held at some point of a function body.
+type held struct {
+	lock string
+	at   string
+	expr string // receiver expression, to tell re-locking the same value from another instance
+}
+
+type acquisition struct {
+	lock string
+	at   string
+}
+
+type chanOp struct {
+	op string
+	at string
+}
+
+type call struct {
+	callee string
+	at     string
+	held   []held
+}
+
+// function summarizes the locking behaviour of one function body.
+type function struct {
+	acquires []acquisition
+	chanOps  []chanOp
+	calls    []call
+	edges    []Edge
+	blocking []Blocking
+}
+
+// Analyze builds the lock-order graph of the packages. The analysis is a heuristic: it follows
+// static calls inside the module, treats nested blocks as releasing nothing they did not take,
+// and ignores function literals, which may run on other goroutines.
+func Analyze(root string, pkgs []*packages.Package) Report {
+	a := &analyzer{root: root, funcs: make(map[string]*function), names: make(map[string]string), module: make(map[string]bool)}
+	for _, pkg := range pkgs {
+		a.module[pkg.PkgPath] = true
+	}
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				s := &scanner{a: a, pkg: pkg, fn: &function{}, name: fn.FullName()}
+				s.block(fd.Body.List, nil)
+				a.funcs[s.name] = s.fn
+			}
+		}
+	}
+	return a.report()
+}
+
+type analyzer struct {
+	root   string
+	funcs  map[string]*function
+	names  map[string]string // lock key → display name
+	module map[string]bool
+}
+
+// reach returns the acquisitions and channel operations reachable from fn through static calls,
+// each with the shortest call path leading to it. Channel operations are keyed by position.
+func (a *analyzer) reach(fn string) (map[string]Step, map[string]Blocking) {
+	locks := make(map[string]Step)
+	ops := make(map[string]Blocking)
+	parent := map[string]string{fn: ""}
+	queue := []string{fn}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		info := a.funcs[cur]
+		if info == nil {
+			continue
+		}
+		var path []string
+		for f := cur; f != ""; f = parent[f] {
+			path = append([]string{f}, path...)
+		}
+		for _, acq := range info.acquires {
+			if _, ok := locks[acq.lock]; !ok {
+				locks[acq.lock] = Step{Position: acq.at, Path: path}
+			}
+		}
+		for _, op := range info.chanOps {
+			if _, ok := ops[op.at]; !ok {
+				ops[op.at] = Blocking{Op: op.op, Step: Step{Position: op.at, Path: path}}
+			}
+		}
+		for _, c := range info.calls {
+			if _, visited := parent[c.callee]; !visited {
+				parent[c.callee] = cur
+				queue = append(queue, c.callee)
+			}
+		}
+	}
+	return locks, ops
+}
+
+func (a *analyzer) report() Report {
+	names := make([]string, 0, len(a.funcs))
+	for name := range a.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	graph := make(map[string]map[string]Edge)
+	addEdge := func(e Edge) {
+		if graph[e.First] == nil {
+			graph[e.First] = make(map[string]Edge)
+		}
+		if old, ok := graph[e.First][e.Second]; !ok || len(e.Step.Path) < len(old.Step.Path) {
+			graph[e.First][e.Second] = e
+		}
+	}
+	var rep Report
+	seenBlocking := make(map[string]bool)
+	addBlocking := func(b Blocking) {
+		key := b.Lock + "|" + b.Step.Position
+		if !seenBlocking[key] {
+			seenBlocking[key] = true
+			rep.Blocking = append(rep.Blocking, b)
+		}
+	}
+
+	for _, name := range names {
+		fn := a.funcs[name]
+		for _, e := range fn.edges {
+			if e.First == e.Second {
+				rep.Recursive = append(rep.Recursive, e)
+				continue
+			}
+			addEdge(e)
+		}
+		for _, b := range fn.blocking {
+			addBlocking(b)
+		}
+		for _, c := range fn.calls {
+			if len(c.held) == 0 {
+				continue
+			}
+			locks, ops := a.reach(c.callee)
+			for _, h := range c.held {
+				for _, lock := range sortedKeys(locks) {
+					step := locks[lock]
+					e := Edge{First: h.lock, Second: lock, HeldAt: h.at,
+						Step: Step{Position: step.Position, Path: append([]string{name}, step.Path...)}}
+					if lock == h.lock {
+						rep.Recursive = append(rep.Recursive, e)
+						continue
+					}
+					addEdge(e)
+				}
+				for _, at := range sortedKeys(ops) {
+					b := ops[at]
+					b.Lock, b.HeldAt = h.lock, h.at
+					b.Step.Path = append([]string{name}, b.Step.Path...)
+					addBlocking(b)
+				}
+			}
+		}
+	}
+
+	for _, scc := range components(graph) {
+		rep.Inversions = append(rep.Inversions, cycle(graph, scc))
+	}
+	a.rename(&rep)
+	rep.Locks = len(a.names)
+	sort.Slice(rep.Blocking, func(i, j int) bool {
+		return shared.ComparePositions(rep.Blocking[i].Step.Position, rep.Blocking[j].Step.Position) < 0
+	})
+	sort.SliceStable(rep.Recursive, func(i, j int) bool {
+		return shared.ComparePositions(rep.Recursive[i].Step.Position, rep.Recursive[j].Step.Position) < 0
+	})
+	return rep
+}
+
+// rename replaces the lock keys of the report with their display names.
+func (a *analyzer) rename(rep *Report) {
+	edge := func(e *Edge) {
+		e.First, e.Second = a.names[e.First], a.names[e.Second]
+	}
+	for i := range rep.Inversions {
+		inv := &rep.Inversions[i]
+		for j := range inv.Locks {
+			inv.Locks[j] = a.names[inv.Locks[j]]
+		}
+		for j := range inv.Edges {
+			edge(&inv.Edges[j])
+		}
+	}
+	for i := range rep.Recursive {
+		edge(&rep.Recursive[i])
+	}
+	for i := range rep.Blocking {
+		rep.Blocking[i].Lock = a.names[rep.Blocking[i].Lock]
+	}
+}
+
+// components returns the strongly connected components of the graph that contain a cycle, each
+// sorted, in a stable order (Tarjan's algorithm).
+func components(graph map[string]map[string]Edge) [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var out [][]string
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range sortedKeys(graph[v]) {
+			if _, ok := index[w]; !ok {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var scc []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			out = append(out, scc)
+		}
+	}
+	for _, v := range sortedKeys(graph) {
+		if _, ok := index[v]; !ok {
+			visit(v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+// cycle returns the shortest cycle through the first lock of a component.
+func cycle(graph map[string]map[string]Edge, scc []string) Inversion {
+	in := make(map[string]bool)
+	for _, v := range scc {
+		in[v] = true
+	}
+	start := scc[0]
+	parent := map[string]string{}
+	queue := []string{start}
+	var last string
+	for len(queue) > 0 && last == "" {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range sortedKeys(graph[cur]) {
+			if !in[next] {
+				continue
+			}
+			if next == start {
+				last = cur
+				break
+			}
+			if _, seen := parent[next]; !seen {
+				parent[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	locks := []string{start}
+	for cur := last; cur != start; cur = parent[cur] {
+		locks = append([]string{cur}, locks...)
+	}
+	locks = append([]string{start}, locks[:len(locks)-1]...)
+	inv := Inversion{Locks: locks}
+	for i, l := range locks {
+		inv.Edges = append(inv.Edges, graph[l][locks[(i+1)%len(locks)]])
+	}
+	return inv
+}
+
+// scanner walks one function body in source order, tracking the locks held.
+type scanner struct {
+	a    *analyzer
+	pkg  *packages.Package
+	fn   *function
+	name string
+	lits int // function literals scanned so far
+}
+
+func (s *scanner) pos(p token.Pos) string {
+	return shared.RelPosition(s.a.root, s.pkg.Fset.Position(p))
+}
+
+// block scans a statement list and returns the locks held after it. Nested blocks get a copy of
+// the held locks: an unlock on an early-return branch does not release the lock for the
+// statements that follow.
+func (s *scanner) block(list []ast.Stmt, h []held) []held {
+	for _, st := range list {
+		h = s.stmt(st, h)
+	}
+	return h
+}
+
+func (s *scanner) nested(list []ast.Stmt, h []held) {
+	s.block(list, append([]held(nil), h...))
+}
+
+func (s *scanner) stmt(st ast.Stmt, h []held) []held {
+	switch st := st.(type) {
+	case *ast.ExprStmt:
+		if c, ok := ast.Unparen(st.X).(*ast.CallExpr); ok {
+			if method, lock, expr := s.lockCall(c); lock != "" {
+				switch method {
+				case "Lock", "RLock":
+					at := s.pos(c.Pos())
+					for _, x := range h {
+						if x.lock == lock && x.expr != expr {
+							continue // two values of one type, such as parent and child nodes
+						}
+						s.fn.edges = append(s.fn.edges, Edge{First: x.lock, Second: lock, HeldAt: x.at,
+							Step: Step{Position: at, Path: []string{s.name}}})
+					}
+					s.fn.acquires = append(s.fn.acquires, acquisition{lock: lock, at: at})
+					return append(h, held{lock: lock, at: at, expr: expr})
+				case "Unlock", "RUnlock":
+					for i := len(h) - 1; i >= 0; i-- {
+						if h[i].lock == lock {
+							return append(h[:i:i], h[i+1:]...)
+						}
+					}
+					return h
+				}
+			}
+		}
+		s.expr(st.X, h)
+	case *ast.DeferStmt:
+		// A deferred unlock keeps the lock until the function returns; other deferred calls
+		// run then too, when the held set is unknown.
+		s.literals(st.Call)
+	case *ast.GoStmt:
+		// The new goroutine holds none of our locks.
+		s.literals(st.Call)
+	case *ast.SendStmt:
+		s.expr(st.Chan, h)
+		s.expr(st.Value, h)
+		s.chanOp("send", st.Arrow, h)
+	case *ast.BlockStmt:
+		s.nested(st.List, h)
+	case *ast.LabeledStmt:
+		return s.stmt(st.Stmt, h)
+	case *ast.IfStmt:
+		if st.Init != nil {
+			h = s.stmt(st.Init, h)
+		}
+		s.expr(st.Cond, h)
+		s.nested(st.Body.List, h)
+		if st.Else != nil {
+			s.stmt(st.Else, append([]held(nil), h...))
+		}
+	case *ast.ForStmt:
+		if st.Init != nil {
+			h = s.stmt(st.Init, h)
+		}
+		if st.Cond != nil {
+			s.expr(st.Cond, h)
+		}
+		s.nested(st.Body.List, h)
+	case *ast.RangeStmt:
+		s.expr(st.X, h)
+		if t := s.pkg.TypesInfo.TypeOf(st.X); t != nil {
+			if _, ok := t.Underlying().(*types.Chan); ok {
+				s.chanOp("range", st.For, h)
+			}
+		}
+		s.nested(st.Body.List, h)
+	case *ast.SwitchStmt:
+		if st.Init != nil {
+			h = s.stmt(st.Init, h)
+		}
+		if st.Tag != nil {
+			s.expr(st.Tag, h)
+		}
+		for _, c := range st.Body.List {
+			s.nested(c.(*ast.CaseClause).Body, h)
+		}
+	case *ast.TypeSwitchStmt:
+		if st.Init != nil {
+			h = s.stmt(st.Init, h)
+		}
+		for _, c := range st.Body.List {
+			s.nested(c.(*ast.CaseClause).Body, h)
+		}
+	case *ast.SelectStmt:
+		blocking := true
+		for _, c := range st.Body.List {
+			if c.(*ast.CommClause).Comm == nil {
+				blocking = false // a default case never waits
+			}
+		}
+		if blocking {
+			s.chanOp("select", st.Select, h)
+		}
+		for _, c := range st.Body.List {
+			s.nested(c.(*ast.CommClause).Body, h)
+		}
+	default:
+		s.expr(st, h)
+	}
+	return h
+}
+
+// expr records the calls and receives inside n. Function literals are scanned on their own,
+// holding nothing: they may run later or on another goroutine.
+func (s *scanner) expr(n ast.Node, h []held) {
+	if n == nil {
+		return
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			s.literal(n)
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				s.chanOp("receive", n.OpPos, h)
+			}
+		case *ast.CallExpr:
+			callee := typeutil.StaticCallee(s.pkg.TypesInfo, n)
+			if callee == nil || callee.Pkg() == nil || !s.a.module[callee.Pkg().Path()] {
+				return true
+			}
+			s.fn.calls = append(s.fn.calls, call{callee: callee.Origin().FullName(), at: s.pos(n.Pos()), held: append([]held(nil), h...)})
+		}
+		return true
+	})
+}
+
+// literals scans the function literals inside n.
+func (s *scanner) literals(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			s.literal(lit)
+			return false
+		}
+		return true
+	})
+}
+
+// literal scans a function literal as a function of its own, named like the runtime does.
+func (s *scanner) literal(lit *ast.FuncLit) {
+	s.lits++
+	sub := &scanner{a: s.a, pkg: s.pkg, fn: &function{}, name: s.name + ".func" + strconv.Itoa(s.lits)}
+	sub.block(lit.Body.List, nil)
+	s.a.funcs[sub.name] = sub.fn
+	s.lits += sub.lits
+}
+
+func (s *scanner) chanOp(op string, p token.Pos, h []held) {
+	at := s.pos(p)
+	s.fn.chanOps = append(s.fn.chanOps, chanOp{op: op, at: at})
+	for _, x := range h {
+		s.fn.blocking = append(s.fn.blocking, Blocking{Op: op, Lock: x.lock, HeldAt: x.at,
+			Step: Step{Position: at, Path: []string{s.name}}})
+	}
+}
+
+// lockCall recognizes calls of the Lock, RLock, Unlock and RUnlock methods of sync.Mutex and
+// sync.RWMutex, and names the lock: the struct field or package variable holding the mutex, the
+// type embedding it, or the local variable.
+func (s *scanner) lockCall(c *ast.CallExpr) (method, lock, expr string) {
+	sel, ok := ast.Unparen(c.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", "", ""
+	}
+	fn, ok := s.pkg.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "sync" {
+		return "", "", ""
+	}
+	switch fn.Name() {
+	case "Lock", "RLock", "Unlock", "RUnlock":
+	default:
+		return "", "", ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil || !isMutex(recv.Type()) {
+		return "", "", ""
+	}
+	expr = types.ExprString(sel.X)
+	info := s.pkg.TypesInfo
+	if selection, ok := info.Selections[sel]; ok && len(selection.Index()) > 1 {
+		// A promoted method: the mutex is embedded, the lock is named after the outer type.
+		if name := typeName(info.TypeOf(sel.X)); name != "" {
+			return fn.Name(), s.a.name(name, name), expr
+		}
+		return "", "", ""
+	}
+	switch x := ast.Unparen(sel.X).(type) {
+	case *ast.SelectorExpr:
+		if field, ok := info.Selections[x]; ok && field.Kind() == types.FieldVal {
+			if name := typeName(field.Recv()); name != "" {
+				return fn.Name(), s.a.name(name+"."+x.Sel.Name, name+"."+x.Sel.Name), expr
+			}
+		}
+		if v, ok := info.Uses[x.Sel].(*types.Var); ok && v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+			return fn.Name(), s.a.name(v.Pkg().Path()+"."+v.Name(), v.Pkg().Name()+"."+v.Name()), expr
+		}
+	case *ast.Ident:
+		if v, ok := info.Uses[x].(*types.Var); ok && v.Pkg() != nil {
+			if v.Parent() == v.Pkg().Scope() {
+				return fn.Name(), s.a.name(v.Pkg().Path()+"."+v.Name(), v.Pkg().Name()+"."+v.Name()), expr
+			}
+			// A local mutex is only ordered against the locks of its own function.
+			key := s.name + "." + v.Name() + "@" + strconv.Itoa(int(v.Pos()))
+			return fn.Name(), s.a.name(key, v.Name()+" (local to "+s.name+")"), expr
+		}
+	}
+	return "", "", ""
+}
+
+// name registers the display name of a lock key and returns the key.
+func (a *analyzer) name(key, display string) string {
+	if _, ok := a.names[key]; !ok {
+		a.names[key] = display
+	}
+	return key
+}
+
+// typeName returns "pkg.Type" for a (pointer to a) named type, using the short package name;
+// lock keys built from it are unique enough within one module.
+func typeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Name() + "." + named.Obj().Name()
+}
+
+func isMutex(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "sync" &&
+		(named.Obj().Name() == "Mutex" || named.Obj().Name() == "RWMutex")
+}
+
+func render(root, pattern string, rep Report, lang string) *shared.Report {
+	r := &shared.Report{Title: locale.T(lang, "deadlocks.title", pattern), Lang: lang, Status: shared.StatusPass, Data: rep}
+	if len(rep.Inversions) == 0 && len(rep.Recursive) == 0 && len(rep.Blocking) == 0 {
+		r.Summary = locale.T(lang, "deadlocks.none", rep.Locks)
+		return r
+	}
+	r.Status = shared.StatusWarn
+	r.Summary = locale.T(lang, "deadlocks.found", len(rep.Inversions), len(rep.Recursive), len(rep.Blocking))
+
+	if len(rep.Inversions) > 0 {
+		sec := r.Add("Lock-order inversions", "")
+		for i, inv := range rep.Inversions {
+			if i == maxFindings {
+				sec.Add("", "").Text = fmt.Sprintf("... %d more.", len(rep.Inversions)-maxFindings)
+				break
+			}
+			var sb strings.Builder
+			for _, e := range inv.Edges {
+				fmt.Fprintf(&sb, "- `%s` (held since %s) then `%s` at %s\n", e.First, e.HeldAt, e.Second, e.Step.Position)
+				fmt.Fprintf(&sb, "  - via `%s`\n", strings.Join(e.Step.Path, "` → `"))
+				writeFrame(&sb, root, e.Step.Position)
+			}
+			sb.WriteString("\nAcquire these locks in one global order, or release one before taking the other.\n")
+			sec.Add(fmt.Sprintf("%d. `%s`", i+1, strings.Join(append(inv.Locks, inv.Locks[0]), "` → `")), "").Text = sb.String()
+		}
+	}
+	if len(rep.Recursive) > 0 {
+		var sb strings.Builder
+		sb.WriteString("Go mutexes are not re-entrant: taking a lock the goroutine already holds blocks forever if it is the same value.\n\n")
+		for i, e := range rep.Recursive {
+			if i == maxFindings {
+				fmt.Fprintf(&sb, "... %d more.\n", len(rep.Recursive)-maxFindings)
+				break
+			}
+			fmt.Fprintf(&sb, "- `%s` held since %s, acquired again at %s via `%s`\n", e.First, e.HeldAt, e.Step.Position, strings.Join(e.Step.Path, "` → `"))
+			writeFrame(&sb, root, e.Step.Position)
+		}
+		r.Add("Recursive acquisitions", "").Text = sb.String()
+	}
+	if len(rep.Blocking) > 0 {
+		var sb strings.Builder
+		sb.WriteString("A goroutine blocked on a channel keeps the lock, stalling every goroutine that needs it — including the one that would unblock the channel.\n\n")
+		for i, b := range rep.Blocking {
+			if i == maxFindings {
+				fmt.Fprintf(&sb, "... %d more.\n", len(rep.Blocking)-maxFindings)
+				break
+			}
+			fmt.Fprintf(&sb, "- %s at %s while holding `%s` (since %s) via `%s`\n", b.Op, b.Step.Position, b.Lock, b.HeldAt, strings.Join(b.Step.Path, "` → `"))
+			writeFrame(&sb, root, b.Step.Position)
+		}
+		r.Add("Channel operations inside critical sections", "").Text = sb.String()
+	}
+	return r
+}
+
+// writeFrame writes an indented code frame around a "file:line:col" position relative to root.
+func writeFrame(sb *strings.Builder, root, position string) {
+	parts := strings.Split(position, ":")
+	if len(parts) < 3 {
+		return
+	}
+	line, errLine := strconv.Atoi(parts[len(parts)-2])
+	col, errCol := strconv.Atoi(parts[len(parts)-1])
+	if errLine != nil || errCol != nil {
+		return
+	}
+	content, err := os.ReadFile(filepath.Join(root, strings.Join(parts[:len(parts)-2], ":")))
+	if err != nil {
+		return
+	}
+	frame := shared.CodeFrame(string(content), line, shared.FrameOptions{Context: 1, Column: col})
+	sb.WriteString("  ```go\n")
+	for _, l := range strings.Split(strings.TrimSuffix(frame, "\n"), "\n") {
+		sb.WriteString("  " + l + "\n")
+	}
+	sb.WriteString("  ```\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package deadlocks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	files["go.mod"] = testutil.GoMod("example.com/audit")
+	return testutil.WriteModule(t, files)
+}
+
+func run(t *testing.T, dir string) string {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"bank/bank.go": `package bank
+
+import "sync"
+
+type Account struct {
+	mu      sync.Mutex
+	balance int
+}
+
+type Ledger struct {
+	sync.RWMutex
+	entries []int
+	events  chan int
+}
+
+var registryMu sync.Mutex
+
+func (a *Account) Deposit(l *Ledger, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.balance += n
+	l.record(n)
+}
+
+func (l *Ledger) record(n int) {
+	l.Lock()
+	l.entries = append(l.entries, n)
+	l.Unlock()
+}
+
+func (l *Ledger) Audit(a *Account) int {
+	l.RLock()
+	defer l.RUnlock()
+	a.mu.Lock()
+	b := a.balance
+	a.mu.Unlock()
+	return b
+}
+
+func (l *Ledger) Publish(n int) {
+	l.Lock()
+	defer l.Unlock()
+	l.notify(n)
+}
+
+func (l *Ledger) notify(n int) {
+	l.events <- n
+}
+
+func Register(a *Account) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	a.reset()
+}
+
+func (a *Account) reset() {
+	if a.balance < 0 {
+		registryMu.Lock()
+		registryMu.Unlock()
+	}
+}
+
+func Transfer(from, to *Account) {
+	from.mu.Lock()
+	to.mu.Lock()
+	to.mu.Unlock()
+	from.mu.Unlock()
+}
+
+func (a *Account) TryLater(done chan bool) {
+	a.mu.Lock()
+	if a.balance == 0 {
+		a.mu.Unlock()
+		<-done
+		return
+	}
+	a.mu.Unlock()
+	select {
+	case <-done:
+	default:
+	}
+	go func() {
+		a.mu.Lock()
+		done <- true
+		a.mu.Unlock()
+	}()
+}
+`,
+	})
+	out := run(t, dir)
+
+	wants := []string{
+		"1 lock-order inversion(s), 1 recursive acquisition(s) and 2 blocking channel operation(s)",
+		"### 1. `bank.Account.mu` → `bank.Ledger` → `bank.Account.mu`",
+		"- `bank.Account.mu` (held since bank/bank.go:19:2) then `bank.Ledger` at bank/bank.go:26:2",
+		"  - via `(*example.com/audit/bank.Account).Deposit` → `(*example.com/audit/bank.Ledger).record`",
+		"- `bank.Ledger` (held since bank/bank.go:32:2) then `bank.Account.mu` at bank/bank.go:34:2",
+		"- `bank.registryMu` held since bank/bank.go:51:2, acquired again at bank/bank.go:58:3 via `example.com/audit/bank.Register` → `(*example.com/audit/bank.Account).reset`",
+		"- send at bank/bank.go:47:11 while holding `bank.Ledger` (since bank/bank.go:41:2) via `(*example.com/audit/bank.Ledger).Publish` → `(*example.com/audit/bank.Ledger).notify`",
+		"  > 47 | \tl.events <- n",
+		"- send at bank/bank.go:84:8 while holding `bank.Account.mu` (since bank/bank.go:83:3) via `(*example.com/audit/bank.Account).TryLater.func1`",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	// Transfer nests two accounts, the receive in TryLater follows an early unlock and the
+	// select has a default case.
+	for _, unwanted := range []string{"bank/bank.go:65", "bank/bank.go:74", "bank/bank.go:78"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected finding at %s:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestHandler_Clean(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go": `package lib
+
+import "sync"
+
+type Cache struct {
+	mu sync.Mutex
+	m  map[string]int
+}
+
+func (c *Cache) Get(k string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m[k]
+}
+`,
+	})
+	out := run(t, dir)
+	if !strings.Contains(out, "✅") || !strings.Contains(out, "(1 lock(s) analyzed)") {
+		t.Errorf("expected clean report, got:\n%s", out)
+	}
+}
@@ -0,0 +1,354 @@
+// Package determinism implements the audit_determinism tool, which flags direct reads of the
+// wall clock and global random sources in business logic and can introduce an injectable clock.
+package determinism
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_determinism"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	IncludeMain  bool   `json:"include_main,omitempty" jsonschema:"Also audit main packages (skipped by default, as wiring code legitimately reads the clock)"`
+	ApplyClock   string `json:"apply_clock,omitempty" jsonschema:"Optional: import path of a package to rewrite so that time.Now/Since/Sleep go through an injectable Clock interface"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers.
+const (
+	RuleTimeNow   = "time-now"
+	RuleTimeSleep = "time-sleep"
+	RuleRand      = "global-rand"
+)
+
+var suggestions = map[string]string{
+	RuleTimeNow:   "Inject a clock (an interface with `Now() time.Time`, or a `now func() time.Time` field) so tests can pin the time.",
+	RuleTimeSleep: "Accept a context and wait on a timer from an injected clock, so tests do not block on real time.",
+	RuleRand:      "Inject a `*rand.Rand` built from a seeded source (e.g. `rand.New(rand.NewPCG(seed1, seed2))`) instead of the global generator.",
+}
+
+// clockFuncs are the time functions rewritten by the clock codemod.
+var clockFuncs = map[string]bool{"Now": true, "Since": true, "Sleep": true}
+
+// Handler handles the audit_determinism tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var applied string
+	if args.ApplyClock != "" {
+		pkg := findPackage(pkgs, args.ApplyClock)
+		if pkg == nil {
+			return errorResult(fmt.Sprintf("package %q not found in pattern %q", args.ApplyClock, pattern)), nil, nil
+		}
+		changes, count, err := ClockCodemod(pkg)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		if err := changes.Apply(ctx, absDir); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		applied = fmt.Sprintf("✅ Introduced an injectable `Clock` in `%s` and rewrote %d call(s) across %d file(s).", pkg.PkgPath, count, len(changes))
+		// Reload so the report reflects the rewritten sources.
+		if pkgs, err = shared.LoadPackages(ctx, absDir, pattern, false); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+	}
+
+	findings := Analyze(absDir, pkgs, args.IncludeMain)
+	rep := render(pattern, findings, applied, locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// Analyze reports direct clock and global random source usage.
+func Analyze(root string, pkgs []*packages.Package, includeMain bool) []shared.Finding {
+	var findings []shared.Finding
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil || (pkg.Name == "main" && !includeMain) {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if fd, ok := n.(*ast.FuncDecl); ok && isAdapter(pkg.TypesInfo, fd) {
+					return false
+				}
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				rule, name := classify(pkg.TypesInfo, call)
+				if rule == "" {
+					return true
+				}
+				findings = append(findings, shared.Finding{
+					Pkg:        pkg.PkgPath,
+					Position:   shared.RelPosition(root, pkg.Fset.Position(call.Pos())),
+					Rule:       rule,
+					Message:    fmt.Sprintf("direct call to %s", name),
+					Suggestion: suggestions[rule],
+				})
+				return true
+			})
+		}
+	}
+	shared.SortFindings(findings)
+	return findings
+}
+
+// isAdapter reports whether fd is a one-line wrapper that does nothing but forward to a flagged
+// function, such as `func (realClock) Now() time.Time { return time.Now() }`. Such adapters are the
+// injection point itself and are not flagged.
+func isAdapter(info *types.Info, fd *ast.FuncDecl) bool {
+	if fd.Body == nil || len(fd.Body.List) != 1 {
+		return false
+	}
+	var expr ast.Expr
+	switch stmt := fd.Body.List[0].(type) {
+	case *ast.ExprStmt:
+		expr = stmt.X
+	case *ast.ReturnStmt:
+		if len(stmt.Results) != 1 {
+			return false
+		}
+		expr = stmt.Results[0]
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	// Arguments must be forwarded as-is; anything else is logic, not an adapter.
+	for _, arg := range call.Args {
+		if _, ok := arg.(*ast.Ident); !ok {
+			return false
+		}
+	}
+	rule, _ := classify(info, call)
+	return rule != ""
+}
+
+// classify returns the rule violated by call and the qualified callee name.
+func classify(info *types.Info, call *ast.CallExpr) (string, string) {
+	fn := typeutil.StaticCallee(info, call)
+	if fn == nil || fn.Pkg() == nil {
+		return "", ""
+	}
+	// Methods (e.g. on an injected *rand.Rand or time.Time) are fine.
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		return "", ""
+	}
+	path, name := fn.Pkg().Path(), fn.Name()
+	qualified := path + "." + name
+	switch path {
+	case "time":
+		switch name {
+		case "Now", "Since", "Until":
+			return RuleTimeNow, qualified
+		case "Sleep", "After", "Tick":
+			return RuleTimeSleep, qualified
+		}
+	case "math/rand", "math/rand/v2":
+		// Constructors are how a seeded source gets injected.
+		if strings.HasPrefix(name, "New") {
+			return "", ""
+		}
+		return RuleRand, qualified
+	}
+	return "", ""
+}
+
+const clockSource = `package %s
+
+import "time"
+
+// Clock abstracts the wall clock so that tests can substitute a fake.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)           { time.Sleep(d) }
+
+// clock is the time source used by this package. Tests may replace it with a fake.
+var clock Clock = realClock{}
+`
+
+// ClockCodemod rewrites time.Now/Since/Sleep calls in pkg to go through a package-level
+// Clock and adds clock.go declaring it. It returns the changes and the number of rewritten calls.
+func ClockCodemod(pkg *packages.Package) (shared.Changeset, int, error) {
+	if pkg.Types == nil || pkg.TypesInfo == nil {
+		return nil, 0, fmt.Errorf("package %s has no type information", pkg.PkgPath)
+	}
+	for _, name := range []string{"clock", "Clock", "realClock"} {
+		if pkg.Types.Scope().Lookup(name) != nil {
+			return nil, 0, fmt.Errorf("package %s already declares %q; refusing to introduce a conflicting clock", pkg.PkgPath, name)
+		}
+	}
+	if len(pkg.GoFiles) == 0 {
+		return nil, 0, fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+
+	changes := make(shared.Changeset)
+	count := 0
+	for _, file := range pkg.Syntax {
+		filename := pkg.Fset.Position(file.Pos()).Filename
+		var selectors []*ast.SelectorExpr
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !clockFuncs[sel.Sel.Name] {
+				return true
+			}
+			if fn := typeutil.StaticCallee(pkg.TypesInfo, call); fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "time" {
+				selectors = append(selectors, sel)
+			}
+			return true
+		})
+		if len(selectors) == 0 {
+			continue
+		}
+
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		tokFile := pkg.Fset.File(file.Pos())
+		// Rewrite back to front so earlier offsets stay valid.
+		for i := len(selectors) - 1; i >= 0; i-- {
+			x := selectors[i].X
+			start, end := tokFile.Offset(x.Pos()), tokFile.Offset(x.End())
+			src = append(src[:start], append([]byte("clock"), src[end:]...)...)
+		}
+		changes[filename] = src
+		count += len(selectors)
+	}
+	if count == 0 {
+		return nil, 0, fmt.Errorf("no time.Now, time.Since or time.Sleep calls found in %s", pkg.PkgPath)
+	}
+
+	clockFile := filepath.Join(filepath.Dir(pkg.GoFiles[0]), "clock.go")
+	if _, err := os.Stat(clockFile); err == nil {
+		return nil, 0, fmt.Errorf("%s already exists", clockFile)
+	}
+	changes[clockFile] = []byte(fmt.Sprintf(clockSource, pkg.Name))
+	return changes, count, nil
+}
+
+func findPackage(pkgs []*packages.Package, path string) *packages.Package {
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == path {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// render builds the report; applied describes the clock rewrite made before the analysis, if any.
+func render(pattern string, findings []shared.Finding, applied, lang string) *shared.Report {
+	if findings == nil {
+		findings = []shared.Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "determinism.title", pattern), Lang: lang, Status: shared.StatusPass, Data: findings}
+	if applied != "" {
+		applied += "\n\n"
+	}
+	if len(findings) == 0 {
+		rep.Summary = applied + locale.T(lang, "determinism.none")
+		return rep
+	}
+
+	byRule := make(map[string][]shared.Finding)
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = applied + locale.T(lang, "determinism.found", len(findings))
+	for _, rule := range []string{RuleTimeNow, RuleTimeSleep, RuleRand} {
+		list := byRule[rule]
+		if len(list) == 0 {
+			continue
+		}
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(list)), "")
+		sec.Text = "**Suggestion:** " + suggestions[rule]
+		for _, f := range list {
+			sec.Items = append(sec.Items, f.Position+": "+f.Message)
+		}
+	}
+	if len(byRule[RuleTimeNow])+len(byRule[RuleTimeSleep]) > 0 {
+		rep.Add("", "").Text = "💡 Run again with `apply_clock=\"<import path>\"` to introduce an injectable `Clock` into a package automatically."
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
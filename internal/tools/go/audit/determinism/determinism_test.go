@@ -0,0 +1,96 @@
+package determinism
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const billingSrc = `package billing
+
+import (
+	"math/rand"
+	"time"
+)
+
+func Due(days int) time.Time {
+	return time.Now().Add(time.Duration(days) * 24 * time.Hour)
+}
+
+func Backoff() {
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+}
+
+func Seeded(seed int64) int {
+	return rand.New(rand.NewSource(seed)).Intn(10)
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":             testutil.GoMod("example.com/app"),
+		"billing/billing.go": billingSrc,
+		"main.go":            "package main\n\nimport \"time\"\n\nfunc main() { _ = time.Now() }\n",
+	})
+}
+
+func TestHandler_Audit(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"billing/billing.go:9:9: direct call to time.Now",
+		"billing/billing.go:13:2: direct call to time.Sleep",
+		"direct call to math/rand.Intn",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "main.go") {
+		t.Errorf("main packages are skipped by default, got:\n%s", out)
+	}
+	if strings.Contains(out, "math/rand.New") {
+		t.Errorf("seeded constructors must not be flagged, got:\n%s", out)
+	}
+}
+
+func TestHandler_ApplyClock(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, ApplyClock: "example.com/app/billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "billing", "billing.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "clock.Now()") || !strings.Contains(string(src), "clock.Sleep(") {
+		t.Errorf("expected calls to be rewritten, got:\n%s", src)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "billing", "clock.go")); err != nil {
+		t.Errorf("expected clock.go to be created: %v", err)
+	}
+
+	out := res.Content[0].(*mcp.TextContent).Text
+	if strings.Contains(out, "direct call to time.Now") {
+		t.Errorf("expected time.Now findings to be gone after the codemod, got:\n%s", out)
+	}
+}
@@ -0,0 +1,292 @@
+// Package doccoverage implements the audit_doc_coverage tool, which measures how many exported
+// symbols carry doc comments and which packages lack a package comment, and ranks packages by how
+// much documentation work they need.
+package doccoverage
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_doc_coverage"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Symbol is an exported declaration without a doc comment.
+type Symbol struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // func, method, type, const, var
+	Position string `json:"position"`
+}
+
+// PackageCoverage is the documentation coverage of one package.
+type PackageCoverage struct {
+	Path           string   `json:"package"`
+	Name           string   `json:"name"`
+	Exported       int      `json:"exported"`
+	Documented     int      `json:"documented"`
+	PackageComment bool     `json:"package_comment"`
+	Undocumented   []Symbol `json:"undocumented,omitempty"`
+}
+
+// Priority scores how much a package needs attention: every undocumented symbol counts once and a
+// missing package comment counts as much as five, since it is the first thing readers of the
+// package documentation see.
+func (p *PackageCoverage) Priority() int {
+	score := len(p.Undocumented)
+	if !p.PackageComment {
+		score += 5
+	}
+	return score
+}
+
+// Report is the module-wide result.
+type Report struct {
+	Exported   int                `json:"exported"`
+	Documented int                `json:"documented"`
+	Packages   []*PackageCoverage `json:"packages"`
+}
+
+// Handler handles the audit_doc_coverage tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	report := Analyze(absDir, pkgs)
+
+	rep := render(pattern, report, locale.FromRequest(req))
+	output, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Analyze computes documentation coverage for pkgs, skipping main packages since their exported
+// identifiers are not importable API. Packages are sorted by descending priority.
+func Analyze(root string, pkgs []*packages.Package) *Report {
+	report := &Report{Packages: []*PackageCoverage{}}
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" || len(pkg.Syntax) == 0 {
+			continue
+		}
+		pc := &PackageCoverage{Path: pkg.PkgPath, Name: pkg.Name}
+		pos := func(n ast.Node) string { return shared.RelPosition(root, pkg.Fset.Position(n.Pos())) }
+		count := func(name, kind string, n ast.Node, doc *ast.CommentGroup) {
+			pc.Exported++
+			if hasDoc(doc) {
+				pc.Documented++
+				return
+			}
+			pc.Undocumented = append(pc.Undocumented, Symbol{Name: name, Kind: kind, Position: pos(n)})
+		}
+
+		for _, file := range pkg.Syntax {
+			if hasDoc(file.Doc) {
+				pc.PackageComment = true
+			}
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if !d.Name.IsExported() {
+						continue
+					}
+					if d.Recv == nil {
+						count(d.Name.Name, "func", d.Name, d.Doc)
+					} else if recv := receiverName(d.Recv); token.IsExported(recv) {
+						count(recv+"."+d.Name.Name, "method", d.Name, d.Doc)
+					}
+				case *ast.GenDecl:
+					countGenDecl(d, count)
+				}
+			}
+		}
+		sort.Slice(pc.Undocumented, func(i, j int) bool {
+			return shared.ComparePositions(pc.Undocumented[i].Position, pc.Undocumented[j].Position) < 0
+		})
+		report.Exported += pc.Exported
+		report.Documented += pc.Documented
+		report.Packages = append(report.Packages, pc)
+	}
+	sort.SliceStable(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		if a.Priority() != b.Priority() {
+			return a.Priority() > b.Priority()
+		}
+		return a.Path < b.Path
+	})
+	return report
+}
+
+// countGenDecl counts the exported names of a type, const or var declaration. In a grouped
+// declaration the group's comment documents every spec that has no comment of its own.
+func countGenDecl(d *ast.GenDecl, count func(name, kind string, n ast.Node, doc *ast.CommentGroup)) {
+	if d.Tok == token.IMPORT {
+		return
+	}
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if s.Name.IsExported() {
+				count(s.Name.Name, "type", s.Name, docOf(s.Doc, d.Doc))
+			}
+		case *ast.ValueSpec:
+			for _, name := range s.Names {
+				if name.IsExported() {
+					// A trailing line comment is the idiomatic way to document an entry in a const block.
+					count(name.Name, d.Tok.String(), name, docOf(s.Doc, docOf(s.Comment, d.Doc)))
+				}
+			}
+		}
+	}
+}
+
+func docOf(own, group *ast.CommentGroup) *ast.CommentGroup {
+	if hasDoc(own) {
+		return own
+	}
+	return group
+}
+
+func hasDoc(doc *ast.CommentGroup) bool {
+	return doc != nil && strings.TrimSpace(doc.Text()) != ""
+}
+
+// receiverName returns the base type name of a method receiver, without pointer or type parameters.
+func receiverName(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func render(pattern string, report *Report, lang string) *shared.Report {
+	rep := &shared.Report{Title: locale.T(lang, "doccoverage.title", pattern), Lang: lang, Status: shared.StatusPass, Data: report}
+	if len(report.Packages) == 0 {
+		rep.Summary = locale.T(lang, "doccoverage.none")
+		return rep
+	}
+
+	missingPkgDoc := 0
+	for _, pc := range report.Packages {
+		if !pc.PackageComment {
+			missingPkgDoc++
+		}
+	}
+	rep.Summary = locale.T(lang, "doccoverage.summary",
+		percent(report.Documented, report.Exported), report.Documented, report.Exported, missingPkgDoc, len(report.Packages))
+
+	table := &shared.Table{Columns: []string{"Package", "Coverage", "Documented", "Package Comment"}}
+	for _, pc := range report.Packages {
+		pkgDoc := "✅"
+		if !pc.PackageComment {
+			pkgDoc = "❌"
+		}
+		table.AddRow("`"+pc.Path+"`", percent(pc.Documented, pc.Exported), fmt.Sprintf("%d/%d", pc.Documented, pc.Exported), pkgDoc)
+	}
+	rep.Add("", "").Table = table
+
+	if missingPkgDoc == 0 && report.Documented == report.Exported {
+		rep.Add("", "").Text = "✅ Every exported symbol and package is documented."
+		return rep
+	}
+
+	rep.Status = shared.StatusWarn
+	priorities := rep.Add("Priorities", "")
+	priorities.Text = "Packages are ordered by undocumented symbols, with a missing package comment weighing as much as five."
+	for _, pc := range report.Packages {
+		if pc.Priority() == 0 {
+			continue
+		}
+		sec := priorities.Add("`"+pc.Path+"`", "")
+		if !pc.PackageComment {
+			sec.Items = append(sec.Items, fmt.Sprintf("Missing package comment (add `// Package %s ...` to one file, or a doc.go)", pc.Name))
+		}
+		for _, s := range pc.Undocumented {
+			sec.Items = append(sec.Items, fmt.Sprintf("%s: %s `%s`", s.Position, s.Kind, s.Name))
+		}
+	}
+	return rep
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "100%"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(n)/float64(total))
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
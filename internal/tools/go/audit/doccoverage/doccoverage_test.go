@@ -0,0 +1,75 @@
+package doccoverage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandler(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/app"),
+		"good/good.go": `// Package good is fully documented.
+package good
+
+// Kinds of things.
+const (
+	A = iota
+	B
+)
+
+// Thing is a thing.
+type Thing struct{}
+
+// Do does it.
+func (Thing) Do() {}
+`,
+		"bad/bad.go": `package bad
+
+const Limit = 3 // Limit caps retries.
+
+type Client struct{}
+
+func (c *Client) Send() {}
+
+func (c *client) Close() {}
+
+type client struct{}
+
+// New returns a Client.
+func New() *Client { return nil }
+
+var Default = New()
+`,
+		"main.go": "package main\n\nfunc Run() {}\n\nfunc main() {}\n",
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"**Overall:** 67% of exported symbols documented (6/9); 1 of 2 package(s) missing a package comment.",
+		"| `example.com/app/bad` | 40% | 2/5 | ❌ |",
+		"| `example.com/app/good` | 100% | 4/4 | ✅ |",
+		"- Missing package comment (add `// Package bad ...` to one file, or a doc.go)",
+		"- bad/bad.go:5:6: type `Client`",
+		"- bad/bad.go:7:18: method `Client.Send`",
+		"- bad/bad.go:16:5: var `Default`",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"Limit", "Close", "Run", "### `example.com/app/good`"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected output not to contain %q, got:\n%s", unwanted, out)
+		}
+	}
+}
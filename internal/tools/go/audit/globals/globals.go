@@ -0,0 +1,474 @@
+// Package globals implements the audit_globals tool, which inventories package-level mutable
+// state, init() functions and sync.Once usage, flagging patterns that make tests order-dependent.
+package globals
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_globals"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Hazard flags attached to package-level variables.
+const (
+	HazardMutatedInTests   = "mutated-in-tests"
+	HazardMutatedAtRuntime = "mutated-at-runtime"
+	HazardExportedMutable  = "exported-mutable"
+	HazardOnceCached       = "once-cached"
+)
+
+// Global is a package-level variable.
+type Global struct {
+	Pkg      string   `json:"package"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Position string   `json:"position"`
+	Kind     string   `json:"kind"`   // "var", "sentinel-error", "regexp", "sync.Once"
+	Writes   []string `json:"writes"` // positions of assignments outside the declaration
+	Hazards  []string `json:"hazards"`
+}
+
+// InitFunc is an init() function declaration.
+type InitFunc struct {
+	Pkg      string   `json:"package"`
+	Position string   `json:"position"`
+	Assigns  []string `json:"assigns"` // package-level variables assigned by the function
+	InTest   bool     `json:"in_test"`
+}
+
+// OnceUse is a sync.Once value or a sync.OnceFunc/OnceValue/OnceValues call.
+type OnceUse struct {
+	Pkg      string `json:"package"`
+	Position string `json:"position"`
+	Detail   string `json:"detail"`
+}
+
+// Inventory is the full result of the audit.
+type Inventory struct {
+	Globals []*Global  `json:"globals"`
+	Inits   []InitFunc `json:"inits"`
+	Onces   []OnceUse  `json:"onces"`
+}
+
+// Handler handles the audit_globals tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	inv := Analyze(absDir, pkgs)
+
+	rep := render(pattern, inv, locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// Analyze builds the inventory. pkgs is expected to be loaded with tests so that writes
+// performed by test files are detected.
+func Analyze(root string, pkgs []*packages.Package) *Inventory {
+	inv := &Inventory{}
+	globals := make(map[string]*Global)
+	module := make(map[string]bool)
+	for _, pkg := range pkgs {
+		module[pkg.PkgPath] = true
+	}
+	seenFiles := make(map[string]bool)
+	seenWrites := make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		// Skip the synthesized test main packages.
+		if pkg.TypesInfo == nil || strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			inTest := strings.HasSuffix(filename, "_test.go")
+			// A non-test file appears both in the package and its test variant; inspect it once.
+			if seenFiles[filename] {
+				continue
+			}
+			seenFiles[filename] = true
+
+			if !inTest {
+				collectDecls(root, pkg, file, globals, inv)
+			}
+			collectFuncs(root, pkg, file, inTest, module, globals, seenWrites, inv)
+		}
+	}
+
+	for _, g := range globals {
+		classify(g)
+		inv.Globals = append(inv.Globals, g)
+	}
+	sort.Slice(inv.Globals, func(i, j int) bool {
+		if inv.Globals[i].Pkg != inv.Globals[j].Pkg {
+			return inv.Globals[i].Pkg < inv.Globals[j].Pkg
+		}
+		return inv.Globals[i].Name < inv.Globals[j].Name
+	})
+	sort.Slice(inv.Inits, func(i, j int) bool { return shared.ComparePositions(inv.Inits[i].Position, inv.Inits[j].Position) < 0 })
+	sort.Slice(inv.Onces, func(i, j int) bool { return shared.ComparePositions(inv.Onces[i].Position, inv.Onces[j].Position) < 0 })
+	return inv
+}
+
+func collectDecls(root string, pkg *packages.Package, file *ast.File, globals map[string]*Global, inv *Inventory) {
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		if gd.Tok == token.TYPE {
+			collectOnceFields(root, pkg, gd, inv)
+			continue
+		}
+		if gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if name.Name == "_" {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[name].(*types.Var)
+				if !ok {
+					continue
+				}
+				var value ast.Expr
+				if i < len(vs.Values) {
+					value = vs.Values[i]
+				}
+				// A placeholder may already exist if a test package wrote to it first.
+				g, ok := globals[key(obj)]
+				if !ok {
+					g = &Global{}
+					globals[key(obj)] = g
+				}
+				g.Pkg = pkg.PkgPath
+				g.Name = name.Name
+				g.Type = types.TypeString(obj.Type(), types.RelativeTo(pkg.Types))
+				g.Position = shared.RelPosition(root, pkg.Fset.Position(name.Pos()))
+				g.Kind = kindOf(pkg.TypesInfo, obj, value)
+				if g.Kind == "sync.Once" {
+					inv.Onces = append(inv.Onces, OnceUse{Pkg: pkg.PkgPath, Position: g.Position, Detail: "var " + g.Name + " sync.Once"})
+				}
+			}
+		}
+	}
+}
+
+// collectOnceFields records struct fields of type sync.Once, a common lazy-initialization pattern.
+func collectOnceFields(root string, pkg *packages.Package, gd *ast.GenDecl, inv *Inventory) {
+	for _, spec := range gd.Specs {
+		ts := spec.(*ast.TypeSpec)
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range st.Fields.List {
+			tv, ok := pkg.TypesInfo.Types[field.Type]
+			if !ok || types.TypeString(tv.Type, nil) != "sync.Once" {
+				continue
+			}
+			for _, name := range field.Names {
+				inv.Onces = append(inv.Onces, OnceUse{
+					Pkg:      pkg.PkgPath,
+					Position: shared.RelPosition(root, pkg.Fset.Position(name.Pos())),
+					Detail:   "field " + ts.Name.Name + "." + name.Name + " sync.Once",
+				})
+			}
+		}
+	}
+}
+
+func collectFuncs(root string, pkg *packages.Package, file *ast.File, inTest bool, module map[string]bool,
+	globals map[string]*Global, seenWrites map[string]bool, inv *Inventory) {
+	// sync.OnceFunc/OnceValue/OnceValues calls.
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if fn := typeutil.StaticCallee(pkg.TypesInfo, call); fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "sync" &&
+			strings.HasPrefix(fn.Name(), "Once") {
+			inv.Onces = append(inv.Onces, OnceUse{
+				Pkg:      pkg.PkgPath,
+				Position: shared.RelPosition(root, pkg.Fset.Position(call.Pos())),
+				Detail:   "sync." + fn.Name() + " call",
+			})
+		}
+		return true
+	})
+
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		isInit := fd.Recv == nil && fd.Name.Name == "init"
+		var initFn *InitFunc
+		if isInit {
+			inv.Inits = append(inv.Inits, InitFunc{
+				Pkg:      pkg.PkgPath,
+				Position: shared.RelPosition(root, pkg.Fset.Position(fd.Pos())),
+				InTest:   inTest,
+			})
+			initFn = &inv.Inits[len(inv.Inits)-1]
+		}
+
+		for _, w := range writes(fd.Body) {
+			obj := pkgVar(pkg.TypesInfo, w)
+			if obj == nil || !module[obj.Pkg().Path()] {
+				continue
+			}
+			pos := shared.RelPosition(root, pkg.Fset.Position(w.Pos()))
+			if seenWrites[pos] {
+				continue
+			}
+			seenWrites[pos] = true
+			if isInit {
+				initFn.Assigns = append(initFn.Assigns, obj.Name())
+				continue
+			}
+			g, ok := globals[key(obj)]
+			if !ok {
+				// Declared in a package that is inspected later; create a placeholder.
+				g = &Global{Pkg: obj.Pkg().Path(), Name: obj.Name(), Type: obj.Type().String(), Kind: "var"}
+				globals[key(obj)] = g
+			}
+			if inTest {
+				addHazard(g, HazardMutatedInTests)
+			} else {
+				addHazard(g, HazardMutatedAtRuntime)
+			}
+			g.Writes = append(g.Writes, pos)
+		}
+	}
+}
+
+// writes returns the target expressions of every assignment or increment in body.
+func writes(body *ast.BlockStmt) []ast.Expr {
+	var out []ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			if s.Tok == token.DEFINE {
+				return true
+			}
+			out = append(out, s.Lhs...)
+		case *ast.IncDecStmt:
+			out = append(out, s.X)
+		}
+		return true
+	})
+	return out
+}
+
+// pkgVar resolves the root identifier of a written expression (x, x.f, x[i], pkg.X)
+// to a package-level variable, or nil.
+func pkgVar(info *types.Info, expr ast.Expr) *types.Var {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+			continue
+		case *ast.IndexExpr:
+			expr = e.X
+			continue
+		case *ast.StarExpr:
+			expr = e.X
+			continue
+		case *ast.SelectorExpr:
+			if v, ok := info.Uses[e.Sel].(*types.Var); ok && isPackageLevel(v) {
+				return v
+			}
+			expr = e.X
+			continue
+		case *ast.Ident:
+			if v, ok := info.Uses[e].(*types.Var); ok && isPackageLevel(v) {
+				return v
+			}
+		}
+		return nil
+	}
+}
+
+func isPackageLevel(v *types.Var) bool {
+	return v.Pkg() != nil && !v.IsField() && v.Parent() == v.Pkg().Scope()
+}
+
+func key(v *types.Var) string {
+	return v.Pkg().Path() + "." + v.Name()
+}
+
+func kindOf(info *types.Info, obj *types.Var, value ast.Expr) string {
+	if types.TypeString(obj.Type(), nil) == "sync.Once" {
+		return "sync.Once"
+	}
+	if call, ok := value.(*ast.CallExpr); ok {
+		if fn := typeutil.StaticCallee(info, call); fn != nil && fn.Pkg() != nil {
+			switch fn.Pkg().Path() + "." + fn.Name() {
+			case "errors.New", "fmt.Errorf":
+				return "sentinel-error"
+			case "regexp.MustCompile", "regexp.MustCompilePOSIX":
+				return "regexp"
+			}
+		}
+	}
+	return "var"
+}
+
+func classify(g *Global) {
+	if g.Kind == "sync.Once" {
+		addHazard(g, HazardOnceCached)
+	}
+	if token.IsExported(g.Name) && g.Kind == "var" {
+		addHazard(g, HazardExportedMutable)
+	}
+	sort.Strings(g.Hazards)
+}
+
+func addHazard(g *Global, h string) {
+	for _, existing := range g.Hazards {
+		if existing == h {
+			return
+		}
+	}
+	g.Hazards = append(g.Hazards, h)
+}
+
+var hazardHelp = map[string]string{
+	HazardMutatedInTests:   "tests overwrite this value; they must restore it (t.Cleanup) and cannot use t.Parallel safely",
+	HazardMutatedAtRuntime: "written after initialization; shared mutable state needs synchronization and leaks between tests",
+	HazardExportedMutable:  "exported and mutable; any importing package can change it",
+	HazardOnceCached:       "sync.Once caches state for the process lifetime; tests cannot reset it, so results depend on test order",
+}
+
+func render(pattern string, inv *Inventory, lang string) *shared.Report {
+	rep := &shared.Report{
+		Title:  locale.T(lang, "globals.title", pattern),
+		Lang:   lang,
+		Status: shared.StatusPass,
+		Summary: locale.T(lang, "globals.summary",
+			len(inv.Globals), len(inv.Inits), len(inv.Onces)),
+		Data: inv,
+	}
+
+	if len(inv.Globals) > 0 {
+		table := &shared.Table{Columns: []string{"Variable", "Type", "Kind", "Declared", "Hazards"}}
+		for _, g := range inv.Globals {
+			hazards := "-"
+			if len(g.Hazards) > 0 {
+				hazards = "⚠️ " + strings.Join(g.Hazards, ", ")
+			}
+			table.AddRow("`"+g.Pkg+"."+g.Name+"`", "`"+shared.TableCell(g.Type)+"`", g.Kind, g.Position, hazards)
+		}
+		rep.Add("Package-Level Variables", "").Table = table
+	}
+
+	var hazards *shared.Section
+	for _, g := range inv.Globals {
+		if len(g.Hazards) == 0 {
+			continue
+		}
+		if hazards == nil {
+			rep.Status = shared.StatusWarn
+			hazards = rep.Add("Test-Order Hazards", shared.StatusWarn)
+		}
+		sec := hazards.Add("`"+g.Pkg+"."+g.Name+"`", "")
+		for _, h := range g.Hazards {
+			sec.Items = append(sec.Items, fmt.Sprintf("**%s**: %s", h, hazardHelp[h]))
+		}
+		if len(g.Writes) > 0 {
+			sec.Items = append(sec.Items, "Written at: "+strings.Join(g.Writes, ", "))
+		}
+	}
+
+	if len(inv.Inits) > 0 {
+		sec := rep.Add("init() Functions", "")
+		for _, in := range inv.Inits {
+			item := fmt.Sprintf("`%s` at %s", in.Pkg, in.Position)
+			if in.InTest {
+				item += " (test file)"
+			}
+			if len(in.Assigns) > 0 {
+				item += " — assigns: " + strings.Join(in.Assigns, ", ")
+			}
+			sec.Items = append(sec.Items, item)
+		}
+	}
+
+	if len(inv.Onces) > 0 {
+		sec := rep.Add("sync.Once Patterns", "")
+		for _, o := range inv.Onces {
+			sec.Items = append(sec.Items, fmt.Sprintf("`%s` at %s: %s", o.Pkg, o.Position, o.Detail))
+		}
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
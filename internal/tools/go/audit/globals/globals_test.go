@@ -0,0 +1,79 @@
+package globals
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandler(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/state"),
+		"store/store.go": `package store
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrMissing = errors.New("missing")
+
+var Runner = "default"
+
+var cache map[string]string
+
+var loadOnce sync.Once
+
+type Client struct {
+	once sync.Once
+}
+
+func init() {
+	cache = map[string]string{}
+}
+
+func Put(k, v string) {
+	cache[k] = v
+}
+`,
+		"store/store_test.go": `package store
+
+import "testing"
+
+func TestPut(t *testing.T) {
+	Runner = "mock"
+	Put("a", "b")
+}
+`,
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"| `example.com/state/store.ErrMissing` | `error` | sentinel-error |",
+		"### `example.com/state/store.Runner`\n\n- **exported-mutable**",
+		"- **mutated-in-tests**",
+		"### `example.com/state/store.cache`\n\n- **mutated-at-runtime**",
+		"### `example.com/state/store.loadOnce`\n\n- **once-cached**",
+		"assigns: cache",
+		"field Client.once sync.Once",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "ErrMissing`\n") {
+		t.Errorf("sentinel errors must not be flagged as hazards, got:\n%s", out)
+	}
+}
@@ -0,0 +1,377 @@
+// Package httpclient implements the audit_http tool, which checks HTTP client hygiene:
+// shared default clients, missing timeouts, unclosed response bodies and unbounded retries.
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_http"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers.
+const (
+	RuleDefaultClient   = "default-client"
+	RuleClientTimeout   = "client-no-timeout"
+	RuleServerTimeout   = "server-no-timeout"
+	RuleBodyNotClosed   = "body-not-closed"
+	RuleNoContext       = "request-no-context"
+	RuleUnboundedRetry  = "unbounded-retry"
+	httpPkg             = "net/http"
+	defaultClientFixMsg = "Create a dedicated `&http.Client{Timeout: 30 * time.Second}` once and reuse it."
+)
+
+var fixes = map[string]string{
+	RuleDefaultClient:  defaultClientFixMsg,
+	RuleClientTimeout:  "Set `Timeout` on the client (or per-request deadlines via context) so a stalled server cannot hang the caller forever.",
+	RuleServerTimeout:  "Set `ReadHeaderTimeout` (and ideally `ReadTimeout`/`WriteTimeout`/`IdleTimeout`) to protect against slowloris-style clients.",
+	RuleBodyNotClosed:  "Add `defer resp.Body.Close()` right after checking the error, so the connection returns to the pool.",
+	RuleNoContext:      "Use `http.NewRequestWithContext(ctx, ...)` so the request is cancelled with its caller.",
+	RuleUnboundedRetry: "Bound the loop with a maximum attempt count, back off between attempts, and stop when `ctx.Done()` is closed.",
+}
+
+// Handler handles the audit_http tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	findings := Analyze(absDir, pkgs)
+
+	rep := render(pattern, findings, locale.FromRequest(req))
+	output, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Analyze runs all HTTP hygiene checks over pkgs.
+func Analyze(root string, pkgs []*packages.Package) []shared.Finding {
+	var findings []shared.Finding
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		report := func(node ast.Node, rule, msg string) {
+			findings = append(findings, shared.Finding{
+				Pkg:        pkg.PkgPath,
+				Position:   shared.RelPosition(root, pkg.Fset.Position(node.Pos())),
+				Rule:       rule,
+				Message:    msg,
+				Suggestion: fixes[rule],
+			})
+		}
+		for _, file := range pkg.Syntax {
+			checkFile(pkg.TypesInfo, file, report)
+			for _, decl := range file.Decls {
+				if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body != nil {
+					checkBodies(pkg.TypesInfo, fd.Body, report)
+				}
+			}
+		}
+	}
+	shared.SortFindings(findings)
+	return findings
+}
+
+type reporter func(node ast.Node, rule, msg string)
+
+// checkFile runs the expression-level checks: default client usage, client/server literals
+// without timeouts and requests built without a context.
+func checkFile(info *types.Info, file *ast.File, report reporter) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			if v, ok := info.Uses[node.Sel].(*types.Var); ok && isHTTP(v) && v.Name() == "DefaultClient" {
+				report(node, RuleDefaultClient, "use of http.DefaultClient, which has no timeout and is shared process-wide")
+			}
+		case *ast.CallExpr:
+			fn := typeutil.StaticCallee(info, node)
+			if fn == nil || !isHTTP(fn) || isMethod(fn) {
+				return true
+			}
+			switch fn.Name() {
+			case "Get", "Post", "PostForm", "Head":
+				report(node, RuleDefaultClient, fmt.Sprintf("http.%s uses http.DefaultClient, which has no timeout", fn.Name()))
+			case "NewRequest":
+				report(node, RuleNoContext, "http.NewRequest creates a request that cannot be cancelled")
+			}
+		case *ast.CompositeLit:
+			tv, ok := info.Types[node]
+			if !ok {
+				return true
+			}
+			switch namedHTTP(tv.Type) {
+			case "Client":
+				if !hasField(node, "Timeout") {
+					report(node, RuleClientTimeout, "http.Client literal without Timeout")
+				}
+			case "Server":
+				if !hasField(node, "ReadHeaderTimeout") && !hasField(node, "ReadTimeout") {
+					report(node, RuleServerTimeout, "http.Server literal without ReadHeaderTimeout or ReadTimeout")
+				}
+			}
+		}
+		return true
+	})
+}
+
+// checkBodies runs the flow-sensitive checks of a function body: unclosed response bodies and
+// retry loops without a bound.
+func checkBodies(info *types.Info, body *ast.BlockStmt, report reporter) {
+	responses := make(map[*types.Var]*ast.CallExpr)
+	closed := make(map[*types.Var]bool)
+	escaped := make(map[*types.Var]bool)
+
+	markEscape := func(expr ast.Expr) {
+		if id, ok := ast.Unparen(expr).(*ast.Ident); ok {
+			if v, ok := info.Uses[id].(*types.Var); ok {
+				escaped[v] = true
+			}
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Rhs) == 1 {
+				if call, ok := node.Rhs[0].(*ast.CallExpr); ok && returnsResponse(info, call) && len(node.Lhs) > 0 {
+					if id, ok := node.Lhs[0].(*ast.Ident); ok {
+						if v := varOf(info, id); v != nil {
+							responses[v] = call
+						}
+					}
+				}
+			}
+			for _, rhs := range node.Rhs {
+				markEscape(rhs)
+			}
+		case *ast.ReturnStmt:
+			for _, r := range node.Results {
+				markEscape(r)
+			}
+		case *ast.CallExpr:
+			if v := closedBody(info, node); v != nil {
+				closed[v] = true
+			}
+			for _, arg := range node.Args {
+				markEscape(arg)
+			}
+		case *ast.CompositeLit:
+			for _, elt := range node.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					markEscape(kv.Value)
+				} else {
+					markEscape(elt)
+				}
+			}
+		case *ast.ForStmt:
+			if node.Cond == nil && containsHTTPCall(info, node.Body) && !checksContext(info, node.Body) {
+				report(node, RuleUnboundedRetry, "infinite loop issuing HTTP requests without an attempt limit or context check")
+			}
+		}
+		return true
+	})
+
+	for v, call := range responses {
+		if !closed[v] && !escaped[v] {
+			report(call, RuleBodyNotClosed, fmt.Sprintf("response %q is never closed (missing %s.Body.Close())", v.Name(), v.Name()))
+		}
+	}
+}
+
+func varOf(info *types.Info, id *ast.Ident) *types.Var {
+	if v, ok := info.Defs[id].(*types.Var); ok {
+		return v
+	}
+	v, _ := info.Uses[id].(*types.Var)
+	return v
+}
+
+// closedBody returns the variable v if call is v.Body.Close().
+func closedBody(info *types.Info, call *ast.CallExpr) *types.Var {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Close" {
+		return nil
+	}
+	inner, ok := sel.X.(*ast.SelectorExpr)
+	if !ok || inner.Sel.Name != "Body" {
+		return nil
+	}
+	id, ok := inner.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, _ := info.Uses[id].(*types.Var)
+	return v
+}
+
+func returnsResponse(info *types.Info, call *ast.CallExpr) bool {
+	tv, ok := info.Types[call]
+	if !ok {
+		return false
+	}
+	t := tv.Type
+	if tuple, ok := t.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return false
+		}
+		t = tuple.At(0).Type()
+	}
+	ptr, ok := t.(*types.Pointer)
+	return ok && namedHTTP(ptr.Elem()) == "Response"
+}
+
+func containsHTTPCall(info *types.Info, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && returnsResponse(info, call) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// checksContext reports whether the loop body consults a context (ctx.Done() or ctx.Err()).
+func checksContext(info *types.Info, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return !found
+		}
+		if fn := typeutil.Callee(info, call); fn != nil && (fn.Name() == "Done" || fn.Name() == "Err") {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+				if tv, ok := info.Types[sel.X]; ok && types.TypeString(tv.Type, nil) == "context.Context" {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func hasField(lit *ast.CompositeLit, name string) bool {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if id, ok := kv.Key.(*ast.Ident); ok && id.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isHTTP(obj types.Object) bool {
+	return obj.Pkg() != nil && obj.Pkg().Path() == httpPkg
+}
+
+func isMethod(fn *types.Func) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	return ok && sig.Recv() != nil
+}
+
+// namedHTTP returns the type name if t is a named type from net/http, or "".
+func namedHTTP(t types.Type) string {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || !isHTTP(named.Obj()) {
+		return ""
+	}
+	return named.Obj().Name()
+}
+
+func render(pattern string, findings []shared.Finding, lang string) *shared.Report {
+	if findings == nil {
+		findings = []shared.Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "httpclient.title", pattern), Lang: lang, Status: shared.StatusPass, Data: findings}
+	if len(findings) == 0 {
+		rep.Summary = locale.T(lang, "httpclient.none")
+		return rep
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "audit.found_issues", len(findings))
+
+	byRule := make(map[string][]shared.Finding)
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	rules := make([]string, 0, len(byRule))
+	for rule := range byRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(byRule[rule])), "")
+		sec.Text = "**Fix:** " + fixes[rule]
+		for _, f := range byRule[rule] {
+			sec.Items = append(sec.Items, f.Position+": "+f.Message)
+		}
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
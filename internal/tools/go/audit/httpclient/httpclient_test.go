@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const fetchSrc = `package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+var good = &http.Client{Timeout: 10 * time.Second}
+
+func Leaky(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
+
+func Closed(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := good.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func Passed(url string) (*http.Response, error) {
+	resp, err := good.Get(url)
+	return resp, err
+}
+
+func Retry(url string) {
+	client := &http.Client{}
+	for {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+	}
+}
+
+func Bounded(ctx context.Context, url string) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		resp, err := http.DefaultClient.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+	}
+}
+
+func Serve() error {
+	srv := &http.Server{Addr: ":8080"}
+	return srv.ListenAndServe()
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"fetch/fetch.go": fetchSrc,
+	})
+}
+
+func TestHandler_Markdown(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"fetch/fetch.go:13:15: http.Get uses http.DefaultClient",
+		"fetch/fetch.go:13:15: response \"resp\" is never closed",
+		"fetch/fetch.go:39:13: http.Client literal without Timeout",
+		"fetch/fetch.go:40:2: infinite loop issuing HTTP requests",
+		"fetch/fetch.go:41:13: http.NewRequest creates a request that cannot be cancelled",
+		"fetch/fetch.go:55:16: use of http.DefaultClient",
+		"fetch/fetch.go:64:10: http.Server literal without ReadHeaderTimeout",
+		"**Fix:**",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, "## unbounded-retry (1)") {
+		t.Errorf("loops that check the context must not be flagged, got:\n%s", out)
+	}
+	if strings.Count(out, "is never closed") != 1 {
+		t.Errorf("only the leaky response should be flagged, got:\n%s", out)
+	}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, OutputFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Data []shared.Finding `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &rep); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	findings := rep.Data
+	for _, f := range findings {
+		if f.Suggestion == "" {
+			t.Errorf("finding %s has no fix", f.Position)
+		}
+	}
+	if len(findings) != 7 {
+		t.Errorf("expected 7 findings, got %d: %+v", len(findings), findings)
+	}
+}
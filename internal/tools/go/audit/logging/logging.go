@@ -0,0 +1,450 @@
+// Package logging implements the audit_logging tool, a focused review of log statements: stdout
+// prints in server code, errors logged without context, PII-looking values in log fields, and
+// inconsistent key naming in structured logs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_logging"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers.
+const (
+	RulePrint      = "print-in-server"
+	RuleNoContext  = "error-without-context"
+	RulePII        = "pii-in-logs"
+	RuleKeyStyle   = "inconsistent-key-style"
+	RuleKeySpelled = "inconsistent-key-spelling"
+)
+
+var fixes = map[string]string{
+	RulePrint:      "Use the structured logger (log/slog) so output has levels, timestamps and fields, and can be routed away from stdout.",
+	RuleNoContext:  "Say what failed: `slog.Error(\"load config\", \"path\", path, \"err\", err)` instead of logging the bare error.",
+	RulePII:        "Do not log personal data or secrets; log an opaque ID, a hash, or a redacted form instead.",
+	RuleKeyStyle:   "Use one key style across the codebase so logs can be queried consistently.",
+	RuleKeySpelled: "Pick one spelling for the key and use it everywhere.",
+}
+
+var (
+	piiName  = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|api_key|ssn|credit_?card|card_?number|cvv|email|phone|address|birth|dob)`)
+	wordRe   = regexp.MustCompile(`[A-Za-z]{2,}`)
+	verbRe   = regexp.MustCompile(`%[-+# 0-9.*\[\]]*[a-zA-Z%]`)
+	snakeRe  = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)+$`)
+	camelRe  = regexp.MustCompile(`^[a-z][a-z0-9]*([A-Z][a-z0-9]*)+$`)
+	kebabRe  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)+$`)
+	dottedRe = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9_]+)+$`)
+)
+
+// slogAttrFuncs build a slog.Attr from a key and a value.
+var slogAttrFuncs = map[string]bool{
+	"String": true, "Int": true, "Int64": true, "Uint64": true, "Float64": true, "Bool": true,
+	"Time": true, "Duration": true, "Any": true, "Group": true,
+}
+
+// logKey is a structured log key seen in the code.
+type logKey struct {
+	name string
+	pos  string
+	pkg  string
+}
+
+// Handler handles the audit_logging tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	findings := Analyze(absDir, pkgs)
+
+	rep := render(pattern, findings, locale.FromRequest(req))
+	output, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Analyze runs all logging checks over pkgs.
+func Analyze(root string, pkgs []*packages.Package) []shared.Finding {
+	var findings []shared.Finding
+	var keys []logKey
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+		server := isServer(pkg)
+		report := func(node ast.Node, rule, msg string) {
+			findings = append(findings, shared.Finding{
+				Pkg:        pkg.PkgPath,
+				Position:   shared.RelPosition(root, pkg.Fset.Position(node.Pos())),
+				Rule:       rule,
+				Message:    msg,
+				Suggestion: fixes[rule],
+			})
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if id, ok := call.Fun.(*ast.Ident); ok && server {
+					if b, ok := info.Uses[id].(*types.Builtin); ok && (b.Name() == "println" || b.Name() == "print") {
+						report(call, RulePrint, fmt.Sprintf("builtin %s writes to stderr without structure", b.Name()))
+					}
+					return true
+				}
+				fn, ok := typeutil.Callee(info, call).(*types.Func)
+				if !ok || fn.Pkg() == nil {
+					return true
+				}
+				path, name := fn.Pkg().Path(), fn.Name()
+				switch {
+				case path == "fmt" && server && (name == "Print" || name == "Println" || name == "Printf"):
+					report(call, RulePrint, fmt.Sprintf("fmt.%s in server code writes unstructured output to stdout", name))
+				case path == "log" && isLogFunc(name):
+					checkStdlog(info, call, name, report)
+				case path == "log/slog":
+					if msgIdx := slogMessageIndex(fn); msgIdx >= 0 {
+						checkSlog(info, call, msgIdx, report, func(k string, node ast.Node) {
+							keys = append(keys, logKey{name: k, pos: shared.RelPosition(root, pkg.Fset.Position(node.Pos())), pkg: pkg.PkgPath})
+						})
+					}
+				}
+				return true
+			})
+		}
+	}
+	findings = append(findings, checkKeys(keys)...)
+	shared.SortFindings(findings)
+	return findings
+}
+
+// isServer reports whether pkg serves requests, judged by whether it imports net/http or gRPC.
+func isServer(pkg *packages.Package) bool {
+	for path := range pkg.Imports {
+		if path == "net/http" || path == "google.golang.org/grpc" {
+			return true
+		}
+	}
+	return false
+}
+
+func isLogFunc(name string) bool {
+	for _, prefix := range []string{"Print", "Fatal", "Panic"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStdlog flags standard library log calls that log an error with no explanatory text, and
+// arguments that look like personal data.
+func checkStdlog(info *types.Info, call *ast.CallExpr, name string, report func(ast.Node, string, string)) {
+	if len(call.Args) == 0 {
+		return
+	}
+	hasErr := false
+	hasText := false
+	for i, arg := range call.Args {
+		if isError(info, arg) {
+			hasErr = true
+		}
+		if s, ok := constString(info, arg); ok {
+			if i == 0 && strings.HasSuffix(name, "f") {
+				s = verbRe.ReplaceAllString(s, "")
+			}
+			if wordRe.MatchString(s) {
+				hasText = true
+			}
+		}
+		if id := piiIdent(arg); id != "" {
+			report(arg, RulePII, fmt.Sprintf("%q looks like personal or secret data", id))
+		}
+	}
+	if hasErr && !hasText {
+		report(call, RuleNoContext, fmt.Sprintf("log.%s logs an error without saying what failed", name))
+	}
+}
+
+// slogMessageIndex returns the index of the message argument of a slog logging function or
+// *slog.Logger method, or -1 if fn does not log.
+func slogMessageIndex(fn *types.Func) int {
+	switch fn.Name() {
+	case "Debug", "Info", "Warn", "Error":
+		return 0
+	case "DebugContext", "InfoContext", "WarnContext", "ErrorContext":
+		return 1
+	case "Log":
+		return 2
+	case "With":
+		return -2 // key/value pairs only
+	}
+	return -1
+}
+
+// checkSlog inspects a slog call: the message must explain an error if one is logged, keys are
+// recorded for the consistency check, and key names and values are checked for PII.
+func checkSlog(info *types.Info, call *ast.CallExpr, msgIdx int, report func(ast.Node, string, string), addKey func(string, ast.Node)) {
+	start := msgIdx + 1
+	if msgIdx == -2 {
+		start = 0
+	} else if msgIdx >= len(call.Args) {
+		return
+	}
+
+	hasErr := false
+	for i := start; i < len(call.Args); {
+		arg := call.Args[i]
+		key, value, next := "", ast.Expr(nil), i+1
+		if s, ok := constString(info, arg); ok && i+1 < len(call.Args) {
+			key, value, next = s, call.Args[i+1], i+2
+		} else if attrCall, ok := ast.Unparen(arg).(*ast.CallExpr); ok && len(attrCall.Args) == 2 {
+			if fn, ok := typeutil.Callee(info, attrCall).(*types.Func); ok && fn.Pkg() != nil && fn.Pkg().Path() == "log/slog" && slogAttrFuncs[fn.Name()] {
+				if s, ok := constString(info, attrCall.Args[0]); ok {
+					key, value = s, attrCall.Args[1]
+				}
+			}
+		}
+		if key != "" {
+			addKey(key, arg)
+			if piiName.MatchString(key) {
+				report(arg, RulePII, fmt.Sprintf("log key %q looks like personal or secret data", key))
+			} else if id := piiIdent(value); id != "" {
+				report(value, RulePII, fmt.Sprintf("%q looks like personal or secret data", id))
+			}
+			if isError(info, value) {
+				hasErr = true
+			}
+		}
+		i = next
+	}
+
+	if msgIdx < 0 {
+		return
+	}
+	msg := call.Args[msgIdx]
+	s, ok := constString(info, msg)
+	switch {
+	case !ok && isErrorString(info, msg):
+		report(msg, RuleNoContext, "the log message is the error text itself; nothing says what failed")
+	case ok && hasErr && !wordRe.MatchString(s):
+		report(msg, RuleNoContext, "an error is logged with an empty message")
+	}
+}
+
+// checkKeys reports structured log keys whose style differs from the majority, and keys that are
+// spelled differently for what is evidently the same field.
+func checkKeys(keys []logKey) []shared.Finding {
+	styleCount := make(map[string]int)
+	bySpelling := make(map[string]map[string]bool)
+	for _, k := range keys {
+		if s := keyStyle(k.name); s != "" {
+			styleCount[s]++
+		}
+		norm := strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(k.name))
+		if bySpelling[norm] == nil {
+			bySpelling[norm] = make(map[string]bool)
+		}
+		bySpelling[norm][k.name] = true
+	}
+
+	majority := ""
+	for style, n := range styleCount {
+		if n > styleCount[majority] || (n == styleCount[majority] && style < majority) {
+			majority = style
+		}
+	}
+
+	var findings []shared.Finding
+	reported := make(map[string]bool)
+	for _, k := range keys {
+		if style := keyStyle(k.name); style != "" && majority != "" && style != majority {
+			findings = append(findings, shared.Finding{
+				Pkg:        k.pkg,
+				Position:   k.pos,
+				Rule:       RuleKeyStyle,
+				Message:    fmt.Sprintf("key %q is %s, but most keys are %s", k.name, style, majority),
+				Suggestion: fixes[RuleKeyStyle],
+			})
+		}
+		norm := strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(k.name))
+		if spellings := bySpelling[norm]; len(spellings) > 1 && !reported[k.name] {
+			reported[k.name] = true
+			var others []string
+			for s := range spellings {
+				if s != k.name {
+					others = append(others, fmt.Sprintf("%q", s))
+				}
+			}
+			sort.Strings(others)
+			findings = append(findings, shared.Finding{
+				Pkg:        k.pkg,
+				Position:   k.pos,
+				Rule:       RuleKeySpelled,
+				Message:    fmt.Sprintf("key %q is also spelled %s", k.name, strings.Join(others, ", ")),
+				Suggestion: fixes[RuleKeySpelled],
+			})
+		}
+	}
+	return findings
+}
+
+// keyStyle classifies a multi-word key. Single-word keys fit every style and return "".
+func keyStyle(key string) string {
+	switch {
+	case snakeRe.MatchString(key):
+		return "snake_case"
+	case camelRe.MatchString(key):
+		return "camelCase"
+	case kebabRe.MatchString(key):
+		return "kebab-case"
+	case dottedRe.MatchString(key):
+		return "dotted.case"
+	}
+	return ""
+}
+
+// piiIdent returns the name of an identifier or field selector whose name suggests personal data.
+func piiIdent(expr ast.Expr) string {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		if piiName.MatchString(e.Name) {
+			return e.Name
+		}
+	case *ast.SelectorExpr:
+		if piiName.MatchString(e.Sel.Name) {
+			return e.Sel.Name
+		}
+	}
+	return ""
+}
+
+func isError(info *types.Info, expr ast.Expr) bool {
+	if expr == nil {
+		return false
+	}
+	tv, ok := info.Types[expr]
+	if !ok || tv.Type == nil {
+		return false
+	}
+	return types.Implements(tv.Type, types.Universe.Lookup("error").Type().Underlying().(*types.Interface))
+}
+
+// isErrorString reports whether expr is err.Error().
+func isErrorString(info *types.Info, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Error" && len(call.Args) == 0 && isError(info, sel.X)
+}
+
+func constString(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func render(pattern string, findings []shared.Finding, lang string) *shared.Report {
+	if findings == nil {
+		findings = []shared.Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "logging.title", pattern), Lang: lang, Status: shared.StatusPass, Data: findings}
+	if len(findings) == 0 {
+		rep.Summary = locale.T(lang, "logging.none")
+		return rep
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "audit.found_issues", len(findings))
+
+	byRule := make(map[string][]shared.Finding)
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	rules := make([]string, 0, len(byRule))
+	for rule := range byRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(byRule[rule])), "")
+		sec.Text = "**Fix:** " + fixes[rule]
+		for _, f := range byRule[rule] {
+			sec.Items = append(sec.Items, f.Position+": "+f.Message)
+		}
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const apiSrc = `package api
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+type User struct{ ID, Email string }
+
+func Handle(w http.ResponseWriter, r *http.Request, u User, err error) {
+	fmt.Println("handling request")
+	log.Println(err)
+	log.Printf("load user %s: %v", u.ID, err)
+	slog.Error(err.Error())
+	slog.Info("login", "user_id", u.ID, "email", u.Email)
+	slog.Info("logout", "userId", u.ID)
+	slog.Warn("slow", slog.String("request_id", r.URL.Path), "elapsed_ms", 12)
+}
+`
+
+const cliSrc = `package main
+
+import "fmt"
+
+func main() { fmt.Println("hello") }
+`
+
+func TestHandler(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":     testutil.GoMod("example.com/app"),
+		"api/api.go": apiSrc,
+		"main.go":    cliSrc,
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"api/api.go:13:2: fmt.Println in server code",
+		"api/api.go:14:2: log.Println logs an error without saying what failed",
+		"api/api.go:16:13: the log message is the error text itself",
+		`api/api.go:17:38: log key "email" looks like personal or secret data`,
+		`api/api.go:18:22: key "userId" is camelCase, but most keys are snake_case`,
+		`api/api.go:17:21: key "user_id" is also spelled "userId"`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"main.go", "api/api.go:15:"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected output not to contain %q, got:\n%s", unwanted, out)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+// Package naming implements the audit_naming tool, which checks exported identifiers against the
+// Go naming conventions and proposes a rename map for the rename_symbols tool.
+package naming
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/refactor/rename"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_naming"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rules.
+const (
+	RuleInitialism = "initialism" // Url → URL, Id → ID
+	RuleGetter     = "getter"     // GetName() → Name()
+	RuleStutter    = "stutter"    // store.StoreConfig → store.Config
+	RuleUnderscore = "underscore" // MAX_SIZE → MaxSize
+)
+
+// Proposal is an exported identifier that breaks a convention, with the name that follows them.
+type Proposal struct {
+	Key       string   `json:"key"` // the rename_symbols key
+	Name      string   `json:"name"`
+	NewName   string   `json:"new_name"`
+	Kind      string   `json:"kind"` // func, type, const, var, method, field
+	Position  string   `json:"position"`
+	Rules     []string `json:"rules"`
+	Conflicts []string `json:"conflicts,omitempty"` // why rename_symbols would refuse it
+}
+
+// Report is the module-wide result. Renames holds the proposals without conflicts, ready to pass
+// to rename_symbols.
+type Report struct {
+	Checked   int               `json:"checked"`
+	Proposals []*Proposal       `json:"proposals"`
+	Renames   map[string]string `json:"renames"`
+}
+
+// Handler handles the audit_naming tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	targets, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	all, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	report, err := Analyze(absDir, targets, all)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var next []shared.NextTool
+	if len(report.Renames) > 0 {
+		next = append(next, shared.NextTool{
+			Tool:      "rename_symbols",
+			Arguments: map[string]any{"dir": absDir, "renames": report.Renames, "dry_run": true},
+			Reason:    "preview the proposed renames, then call again without dry_run to apply them",
+		})
+	}
+	rep := render(pattern, report, locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return shared.WithNextTools(textResult(out), next...), nil, nil
+}
+
+// Analyze checks the exported identifiers declared in targets: package-level names, and the
+// methods and fields of exported types. all must include every package of the module with
+// tests; the proposed map is validated against it with rename.Prepare, and proposals that would
+// not go through are kept out of Renames with the reason. Test files, generated files and cgo
+// exports are not checked, nor are methods that an imported interface requires.
+func Analyze(root string, targets, all []*packages.Package) (*Report, error) {
+	report := &Report{Proposals: []*Proposal{}, Renames: map[string]string{}}
+	names := make(map[string]int)
+	for _, pkg := range all {
+		if pkg.ID == pkg.PkgPath {
+			names[pkg.Name]++
+		}
+	}
+
+	for _, pkg := range targets {
+		if pkg.Types == nil {
+			continue
+		}
+		qual := pkg.Name
+		if names[pkg.Name] > 1 && strings.Contains(pkg.PkgPath, "/") {
+			qual = pkg.PkgPath
+		}
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.File(file.Pos()).Name()
+			if strings.HasSuffix(filename, "_test.go") || ast.IsGenerated(file) {
+				continue
+			}
+			for _, d := range declared(pkg, file) {
+				report.Checked++
+				newName, rules := propose(pkg, d)
+				if len(rules) == 0 {
+					continue
+				}
+				key := qual + "." + d.name
+				if d.owner != "" {
+					key = qual + "." + d.owner + "." + d.name
+				}
+				report.Proposals = append(report.Proposals, &Proposal{
+					Key:      key,
+					Name:     d.name,
+					NewName:  newName,
+					Kind:     d.kind,
+					Position: shared.RelPosition(root, pkg.Fset.Position(d.ident.Pos())),
+					Rules:    rules,
+				})
+			}
+		}
+	}
+	sort.Slice(report.Proposals, func(i, j int) bool { return report.Proposals[i].Key < report.Proposals[j].Key })
+
+	// A conflict can come from another entry of the batch, so validation repeats on what is
+	// left until the map is clean.
+	pending := make(map[string]*Proposal)
+	for _, p := range report.Proposals {
+		pending[p.Key] = p
+	}
+	for len(pending) > 0 {
+		renames := make(map[string]string, len(pending))
+		for k, p := range pending {
+			renames[k] = p.NewName
+		}
+		plan, err := rename.Prepare(root, all, renames)
+		if err != nil {
+			return nil, err
+		}
+		if plan.Conflicts() == 0 {
+			report.Renames = renames
+			break
+		}
+		for _, r := range plan.Renames {
+			if len(r.Conflicts) > 0 {
+				pending[r.Key].Conflicts = r.Conflicts
+				delete(pending, r.Key)
+			}
+		}
+	}
+	return report, nil
+}
+
+// decl is an exported identifier to check.
+type decl struct {
+	ident *ast.Ident
+	name  string
+	kind  string
+	owner string       // the type declaring a method or field
+	named *types.Named // the receiver of a concrete method
+}
+
+// declared returns the exported identifiers declared in file.
+func declared(pkg *packages.Package, file *ast.File) []decl {
+	var out []decl
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() || hasDirective(d.Doc, "//export ") {
+				continue
+			}
+			if d.Recv == nil {
+				out = append(out, decl{ident: d.Name, name: d.Name.Name, kind: "func"})
+				continue
+			}
+			fn, ok := pkg.TypesInfo.Defs[d.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			named := receiver(fn)
+			if named == nil || !named.Obj().Exported() {
+				continue
+			}
+			out = append(out, decl{ident: d.Name, name: d.Name.Name, kind: "method", owner: named.Obj().Name(), named: named})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					out = append(out, decl{ident: s.Name, name: s.Name.Name, kind: "type"})
+					out = append(out, members(s)...)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							out = append(out, decl{ident: name, name: name.Name, kind: d.Tok.String()})
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// members returns the exported fields of a struct type and the exported methods of an interface.
+func members(s *ast.TypeSpec) []decl {
+	var out []decl
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		for _, f := range t.Fields.List {
+			for _, name := range f.Names {
+				if name.IsExported() {
+					out = append(out, decl{ident: name, name: name.Name, kind: "field", owner: s.Name.Name})
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		for _, m := range t.Methods.List {
+			for _, name := range m.Names {
+				if name.IsExported() {
+					out = append(out, decl{ident: name, name: name.Name, kind: "method", owner: s.Name.Name})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// propose applies every rule to d in turn and returns the resulting name with the rules that
+// changed it.
+func propose(pkg *packages.Package, d decl) (string, []string) {
+	name := d.name
+	var rules []string
+	if d.kind == "method" && d.named != nil && requiredByImport(pkg, d.named, d.name) {
+		return name, nil
+	}
+	if n := withoutUnderscores(name); n != name {
+		name, rules = n, append(rules, RuleUnderscore)
+	}
+	if n := withInitialisms(name); n != name {
+		name, rules = n, append(rules, RuleInitialism)
+	}
+	if d.owner == "" {
+		if n := withoutStutter(pkg.Name, name); n != name {
+			name, rules = n, append(rules, RuleStutter)
+		}
+	}
+	if d.kind == "method" && isGetter(pkg, d) {
+		name, rules = name[len("Get"):], append(rules, RuleGetter)
+	}
+	return name, rules
+}
+
+// commonInitialisms are the initialisms that the Go style keeps in a single case.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"JWT": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "UUID": true, "URI": true, "URL": true, "UTF8": true, "VM": true,
+	"XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// withInitialisms writes the common initialisms of name in upper case: UserId → UserID,
+// HttpUrl → HTTPURL, Ids → IDs.
+func withInitialisms(name string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		upper := strings.ToUpper(w)
+		switch {
+		case w == upper:
+		case commonInitialisms[upper]:
+			words[i] = upper
+		case len(w) > 2 && strings.HasSuffix(w, "s") && commonInitialisms[upper[:len(upper)-1]]:
+			words[i] = upper[:len(upper)-1] + "s"
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits a mixed-caps name where a lower case letter or digit meets a capital, and
+// before the last capital of a run followed by a lower case letter: HTTPServerId → HTTP Server Id.
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		if !unicode.IsUpper(cur) {
+			continue
+		}
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// withoutUnderscores joins the parts of an underscored name in mixed caps, lowering the parts
+// written in all caps: MAX_SIZE → MaxSize, Parse_Header → ParseHeader.
+func withoutUnderscores(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if part == strings.ToUpper(part) {
+			part = strings.ToLower(part)
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	if sb.Len() == 0 {
+		return name
+	}
+	return sb.String()
+}
+
+// withoutStutter drops the package name from the front of a package-level name, since callers
+// already write it: store.StoreConfig → store.Config, http.HTTPClient → http.Client.
+func withoutStutter(pkgName, name string) string {
+	if len(name) <= len(pkgName) || !strings.EqualFold(name[:len(pkgName)], pkgName) {
+		return name
+	}
+	rest := name[len(pkgName):]
+	if r := []rune(rest)[0]; !unicode.IsUpper(r) {
+		return name
+	}
+	return rest
+}
+
+// isGetter reports whether the method d is a getter named GetX: no parameters and a single
+// result. Get alone, and getters whose type already has an X, are left alone.
+func isGetter(pkg *packages.Package, d decl) bool {
+	if !strings.HasPrefix(d.name, "Get") || len(d.name) == len("Get") || !unicode.IsUpper([]rune(d.name[len("Get"):])[0]) {
+		return false
+	}
+	fn, ok := pkg.TypesInfo.Defs[d.ident].(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	recv := sig.Recv().Type()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	obj, _, _ := types.LookupFieldOrMethod(recv, true, pkg.Types, d.name[len("Get"):])
+	return obj == nil
+}
+
+// receiver returns the named type that declares the method fn.
+func receiver(fn *types.Func) *types.Named {
+	t := fn.Type().(*types.Signature).Recv().Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, _ := types.Unalias(t).(*types.Named)
+	return named
+}
+
+// requiredByImport reports whether an interface declared by a package that pkg imports has a
+// method called name that named satisfies: renaming it would break that implementation.
+func requiredByImport(pkg *packages.Package, named *types.Named, name string) bool {
+	for _, imp := range pkg.Imports {
+		if imp.Types == nil {
+			continue
+		}
+		scope := imp.Types.Scope()
+		for _, n := range scope.Names() {
+			tn, ok := scope.Lookup(n).(*types.TypeName)
+			if !ok || !tn.Exported() {
+				continue
+			}
+			iface, ok := tn.Type().Underlying().(*types.Interface)
+			if !ok || iface.NumMethods() == 0 {
+				continue
+			}
+			has := false
+			for i := 0; i < iface.NumMethods(); i++ {
+				if iface.Method(i).Name() == name {
+					has = true
+					break
+				}
+			}
+			if has && (types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasDirective(doc *ast.CommentGroup, prefix string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func render(pattern string, report *Report, lang string) *shared.Report {
+	rep := &shared.Report{Title: locale.T(lang, "naming.title", pattern), Lang: lang, Status: shared.StatusPass, Data: report}
+	if len(report.Proposals) == 0 {
+		rep.Summary = locale.T(lang, "naming.none", report.Checked)
+		return rep
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "naming.found", len(report.Proposals), report.Checked)
+	table := &shared.Table{Columns: []string{"Symbol", "Kind", "Position", "Rules", "Proposal"}}
+	for _, p := range report.Proposals {
+		proposal := fmt.Sprintf("`%s`", p.NewName)
+		if len(p.Conflicts) > 0 {
+			proposal += " ⚠️ " + strings.Join(p.Conflicts, "; ")
+		}
+		table.AddRow("`"+p.Key+"`", p.Kind, p.Position, strings.Join(p.Rules, ", "), proposal)
+	}
+	sec := rep.Add("", "")
+	sec.Table = table
+	if len(report.Renames) == 0 {
+		rep.Add("", "").Text = "No proposal can be renamed automatically; rename them by hand or pick other names."
+		return rep
+	}
+	if n := len(report.Proposals) - len(report.Renames); n > 0 {
+		rep.Add("", "").Text = fmt.Sprintf("%d proposal(s) marked ⚠️ are left out of the map below.", n)
+	}
+	data, _ := json.MarshalIndent(report.Renames, "", "  ")
+	rep.Add("Rename map", "").Text = "```json\n" + string(data) + "\n```\n\n" +
+		"💡 Pass the map as `renames` to `rename_symbols` (with `dry_run=true` first to review the diff). " +
+		"Renaming exported identifiers breaks code outside this module that uses them."
+	return rep
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,176 @@
+package naming
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const storeSrc = `package store
+
+import "fmt"
+
+const MAX_ENTRIES = 100
+
+// StoreConfig configures a Store.
+type StoreConfig struct {
+	BaseUrl string
+	UserId  int
+}
+
+// Store keeps values.
+type Store struct {
+	cfg  StoreConfig
+	name string
+}
+
+// GetName returns the name of the store.
+func (s *Store) GetName() string { return s.name }
+
+// GetConfig returns the configuration.
+func (s *Store) GetConfig() StoreConfig { return s.cfg }
+
+// Config clashes with the getter above.
+func (s *Store) Config() StoreConfig { return s.cfg }
+
+// GetValue has a parameter, so it is not a getter.
+func (s *Store) GetValue(key string) string { return key }
+
+// String implements fmt.Stringer.
+func (s *Store) String() string { return fmt.Sprint(s.name) }
+
+// NewHttpClient returns nothing useful.
+func NewHttpClient() *Store { return &Store{} }
+
+// Client collides with the stutter fix of StoreClient.
+type Client struct{}
+
+// StoreClient stutters.
+type StoreClient struct{}
+
+type Namer interface {
+	GetId() string
+}
+`
+
+const useSrc = `package app
+
+import "example.com/app/store"
+
+func Use() string {
+	s := store.NewHttpClient()
+	_ = store.StoreConfig{BaseUrl: "x", UserId: store.MAX_ENTRIES}
+	return s.GetName()
+}
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"store/store.go": storeSrc,
+		"app/app.go":     useSrc,
+	})
+}
+
+func call(t *testing.T, args Params) string {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeModule(t)
+	out := call(t, Params{Dir: dir})
+	for _, want := range []string{
+		"⚠️ **8 of 16 exported identifier(s)** break a naming convention.",
+		"| `store.MAX_ENTRIES` | const | store/store.go:5:7 | underscore | `MaxEntries` |",
+		"| `store.StoreConfig` | type | store/store.go:8:6 | stutter | `Config` |",
+		"| `store.StoreConfig.BaseUrl` | field | store/store.go:9:2 | initialism | `BaseURL` |",
+		"| `store.Store.GetName` | method | store/store.go:20:17 | getter | `Name` |",
+		"| `store.NewHttpClient` | func | store/store.go:35:6 | initialism | `NewHTTPClient` |",
+		"| `store.Namer.GetId` | method | store/store.go:44:2 | initialism, getter | `ID` |",
+		"| `store.StoreClient` | type | store/store.go:41:6 | stutter | `Client` ⚠️ `Client` is already declared at store/store.go:38:6 |",
+		"1 proposal(s) marked ⚠️ are left out of the map below.",
+		"Pass the map as `renames` to `rename_symbols`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"GetConfig", "GetValue", "`store.Store` "} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output should not mention %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestHandler_SuggestsRename(t *testing.T) {
+	dir := writeModule(t)
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, ok := res.Meta[shared.NextToolsKey].([]shared.NextTool)
+	if !ok || len(next) != 1 || next[0].Tool != "rename_symbols" {
+		t.Fatalf("suggested_next_tools = %#v", res.Meta[shared.NextToolsKey])
+	}
+	if renames := next[0].Arguments["renames"].(map[string]string); renames["store.MAX_ENTRIES"] != "MaxEntries" {
+		t.Errorf("renames = %v", renames)
+	}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	dir := writeModule(t)
+	var rep struct {
+		Data Report `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(call(t, Params{Dir: dir, OutputFormat: "json"})), &rep); err != nil {
+		t.Fatal(err)
+	}
+	report := rep.Data
+	want := map[string]string{
+		"store.MAX_ENTRIES":         "MaxEntries",
+		"store.StoreConfig":         "Config",
+		"store.StoreConfig.BaseUrl": "BaseURL",
+		"store.StoreConfig.UserId":  "UserID",
+		"store.Store.GetName":       "Name",
+		"store.NewHttpClient":       "NewHTTPClient",
+		"store.Namer.GetId":         "ID",
+	}
+	if len(report.Renames) != len(want) {
+		t.Errorf("got renames %v, want %v", report.Renames, want)
+	}
+	for k, v := range want {
+		if report.Renames[k] != v {
+			t.Errorf("renames[%q] = %q, want %q", k, report.Renames[k], v)
+		}
+	}
+}
+
+func TestWithInitialisms(t *testing.T) {
+	for in, want := range map[string]string{
+		"UserId":       "UserID",
+		"HttpUrl":      "HTTPURL",
+		"Ids":          "IDs",
+		"APIKey":       "APIKey",
+		"JsonHTTPCall": "JSONHTTPCall",
+		"Identity":     "Identity",
+		"Utf8Decoder":  "UTF8Decoder",
+	} {
+		if got := withInitialisms(in); got != want {
+			t.Errorf("withInitialisms(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Package panics implements the audit_panics tool, which reports panic, log.Fatal and os.Exit
+// calls in library packages that are reachable from the exported API.
+package panics
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_panics"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// maxPathsPerSite caps how many exported entry points are listed for a single site.
+const maxPathsPerSite = 3
+
+// Site is a terminating call (panic, log.Fatal, os.Exit) found inside a function.
+type Site struct {
+	Kind     string `json:"kind"`     // e.g. "panic", "log.Fatalf", "os.Exit"
+	Position string `json:"position"` // file:line:col relative to the audited directory
+	Func     string `json:"func"`     // full name of the enclosing function
+	Pkg      string `json:"package"`  // import path of the enclosing package
+}
+
+// Finding is a reachable site together with the call paths that lead to it.
+type Finding struct {
+	Site  Site       `json:"site"`
+	Paths [][]string `json:"paths"` // each path starts at an exported function and ends at Site.Func
+}
+
+// Handler handles the audit_panics tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	findings, unreachable := Analyze(absDir, pkgs)
+	rep := render(absDir, pattern, findings, unreachable, locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// Analyze builds a static call graph of the non-main packages and returns the terminating
+// sites reachable from exported functions, plus the number of sites that are not reachable.
+func Analyze(root string, pkgs []*packages.Package) ([]Finding, int) {
+	g := &graph{
+		edges: make(map[string][]string),
+		sites: make(map[string][]Site),
+	}
+	module := make(map[string]bool)
+	for _, pkg := range pkgs {
+		module[pkg.PkgPath] = true
+	}
+
+	var entries []string
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" || pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				name := fn.FullName()
+				if isEntryPoint(fn) {
+					entries = append(entries, name)
+				}
+				g.collect(root, pkg, module, name, fd.Body)
+			}
+		}
+	}
+	sort.Strings(entries)
+
+	paths := make(map[string][][]string)
+	for _, entry := range entries {
+		for fn, path := range g.reachable(entry) {
+			if len(g.sites[fn]) > 0 && len(paths[fn]) < maxPathsPerSite {
+				paths[fn] = append(paths[fn], path)
+			}
+		}
+	}
+
+	var findings []Finding
+	unreachable := 0
+	for fn, sites := range g.sites {
+		for _, s := range sites {
+			if len(paths[fn]) == 0 {
+				unreachable++
+				continue
+			}
+			findings = append(findings, Finding{Site: s, Paths: paths[fn]})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Site.Pkg != findings[j].Site.Pkg {
+			return findings[i].Site.Pkg < findings[j].Site.Pkg
+		}
+		return shared.ComparePositions(findings[i].Site.Position, findings[j].Site.Position) < 0
+	})
+	return findings, unreachable
+}
+
+// graph is a name-keyed call graph. Functions are keyed by types.Func.FullName so that
+// references resolved through export data in other packages map to the same node.
+type graph struct {
+	edges map[string][]string
+	sites map[string][]Site
+}
+
+func (g *graph) collect(root string, pkg *packages.Package, module map[string]bool, caller string, body *ast.BlockStmt) {
+	seen := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if kind := terminatingKind(pkg.TypesInfo, call); kind != "" {
+			g.sites[caller] = append(g.sites[caller], Site{
+				Kind:     kind,
+				Position: shared.RelPosition(root, pkg.Fset.Position(call.Pos())),
+				Func:     caller,
+				Pkg:      pkg.PkgPath,
+			})
+			return true
+		}
+		callee := typeutil.StaticCallee(pkg.TypesInfo, call)
+		if callee == nil || callee.Pkg() == nil || !module[callee.Pkg().Path()] {
+			return true
+		}
+		name := callee.Origin().FullName()
+		if !seen[name] {
+			seen[name] = true
+			g.edges[caller] = append(g.edges[caller], name)
+		}
+		return true
+	})
+}
+
+// reachable returns the shortest call path from entry to every function reachable from it.
+func (g *graph) reachable(entry string) map[string][]string {
+	parent := map[string]string{entry: ""}
+	queue := []string{entry}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range g.edges[cur] {
+			if _, visited := parent[next]; visited {
+				continue
+			}
+			parent[next] = cur
+			queue = append(queue, next)
+		}
+	}
+
+	paths := make(map[string][]string, len(parent))
+	for fn := range parent {
+		var path []string
+		for cur := fn; cur != ""; cur = parent[cur] {
+			path = append([]string{cur}, path...)
+		}
+		paths[fn] = path
+	}
+	return paths
+}
+
+// terminatingKind reports whether call is a panic, log.Fatal*/log.Panic* or os.Exit call.
+func terminatingKind(info *types.Info, call *ast.CallExpr) string {
+	if ident, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+		if b, ok := info.Uses[ident].(*types.Builtin); ok && b.Name() == "panic" {
+			return "panic"
+		}
+		return ""
+	}
+	callee := typeutil.StaticCallee(info, call)
+	if callee == nil || callee.Pkg() == nil {
+		return ""
+	}
+	name := callee.Name()
+	switch callee.Pkg().Path() {
+	case "os":
+		if name == "Exit" {
+			return "os.Exit"
+		}
+	case "log":
+		if strings.HasPrefix(name, "Fatal") || strings.HasPrefix(name, "Panic") {
+			return "log." + name
+		}
+	}
+	return ""
+}
+
+// isEntryPoint reports whether fn is part of the exported API: an exported function,
+// or an exported method on an exported type.
+func isEntryPoint(fn *types.Func) bool {
+	if !fn.Exported() {
+		return false
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return true
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+func render(root, pattern string, findings []Finding, unreachable int, lang string) *shared.Report {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "panics.title", pattern), Lang: lang, Status: shared.StatusPass, Data: findings}
+	if len(findings) == 0 {
+		rep.Summary = locale.T(lang, "panics.none")
+		if unreachable > 0 {
+			rep.Summary += "\n\n" + locale.T(lang, "panics.unreachable", unreachable)
+		}
+		return rep
+	}
+
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "panics.found", len(findings))
+	var pkg *shared.Section
+	for _, f := range findings {
+		if pkg == nil || pkg.Title != "`"+f.Site.Pkg+"`" {
+			pkg = rep.Add("`"+f.Site.Pkg+"`", "")
+		}
+		sec := pkg.Add(fmt.Sprintf("`%s` in `%s` (%s)", f.Site.Kind, f.Site.Func, f.Site.Position), "")
+		if frame := siteFrame(root, f.Site.Position); frame != "" {
+			sec.Text = "```go\n" + frame + "```"
+		}
+		for _, path := range f.Paths {
+			sec.Items = append(sec.Items, fmt.Sprintf("`%s` → `%s()`", strings.Join(path, "` → `"), f.Site.Kind))
+		}
+	}
+	if unreachable > 0 {
+		rep.Add("", "").Text = fmt.Sprintf("%d additional terminating call(s) are not reachable from exported functions.", unreachable)
+	}
+	return rep
+}
+
+// siteFrame returns a small code frame around a "file:line:col" position relative to root.
+func siteFrame(root, position string) string {
+	parts := strings.Split(position, ":")
+	if len(parts) < 3 {
+		return ""
+	}
+	line, errLine := strconv.Atoi(parts[len(parts)-2])
+	col, errCol := strconv.Atoi(parts[len(parts)-1])
+	if errLine != nil || errCol != nil {
+		return ""
+	}
+	content, err := os.ReadFile(filepath.Join(root, strings.Join(parts[:len(parts)-2], ":")))
+	if err != nil {
+		return ""
+	}
+	return shared.CodeFrame(string(content), line, shared.FrameOptions{Context: 1, Column: col})
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package panics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	files["go.mod"] = testutil.GoMod("example.com/audit")
+	return testutil.WriteModule(t, files)
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go": `package lib
+
+import (
+	"log"
+
+	"example.com/audit/lib/inner"
+)
+
+func Parse(s string) string {
+	return mustToken(s)
+}
+
+func Load() {
+	inner.Boot()
+}
+
+func mustToken(s string) string {
+	if s == "" {
+		panic("empty")
+	}
+	return s
+}
+
+func unused() {
+	log.Fatal("never reached from the API")
+}
+`,
+		"lib/inner/inner.go": `package inner
+
+import "os"
+
+func Boot() {
+	os.Exit(1)
+}
+`,
+		"cmd/app/main.go": `package main
+
+import "os"
+
+func main() {
+	os.Exit(2)
+}
+`,
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"`example.com/audit/lib.Parse` → `example.com/audit/lib.mustToken` → `panic()`",
+		"`example.com/audit/lib.Load` → `example.com/audit/lib/inner.Boot` → `os.Exit()`",
+		"1 additional terminating call(s) are not reachable",
+		"> 19 | \t\tpanic(\"empty\")\n     | \t\t^",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "cmd/app") {
+		t.Errorf("main packages must not be audited, got:\n%s", out)
+	}
+}
+
+func TestHandler_Clean(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go": "package lib\n\nfunc Add(a, b int) int { return a + b }\n",
+	})
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(out, "✅") {
+		t.Errorf("expected clean report, got:\n%s", out)
+	}
+}
+
+func TestHandler_Locale(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"lib/lib.go": "package lib\n\nfunc Must(ok bool) {\n\tif !ok {\n\t\tpanic(\"not ok\")\n\t}\n}\n",
+	})
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Meta: mcp.Meta{"locale": "pt-BR"}}}
+	res, _, _ := Handler(context.Background(), req, Params{Dir: dir})
+	out := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{"# Auditoria de Caminhos de Panic", "⚠️ 1 chamada(s) de encerramento alcançável(is) por funções exportadas."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
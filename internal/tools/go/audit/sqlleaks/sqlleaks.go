@@ -0,0 +1,317 @@
+// Package sqlleaks implements the audit_sql tool, which finds database/sql resources that are
+// leaked: rows and statements that are never closed, row iteration without an Err check, and
+// transactions that are never rolled back.
+package sqlleaks
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_sql"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers.
+const (
+	RuleRowsNotClosed = "rows-not-closed"
+	RuleRowsErr       = "rows-err-unchecked"
+	RuleStmtNotClosed = "stmt-not-closed"
+	RuleTxNoRollback  = "tx-no-rollback"
+	sqlPkg            = "database/sql"
+)
+
+var fixes = map[string]string{
+	RuleRowsNotClosed: "Add `defer rows.Close()` right after checking the query error; an unclosed *sql.Rows holds its connection until garbage collection.",
+	RuleRowsErr:       "Check `rows.Err()` after the `rows.Next()` loop; a loop that ends early because of a network or scan error is otherwise indistinguishable from the end of the result set.",
+	RuleStmtNotClosed: "Add `defer stmt.Close()` after a successful Prepare, or keep the statement in a long-lived struct that closes it on shutdown.",
+	RuleTxNoRollback:  "Add `defer tx.Rollback()` right after Begin; it is a no-op once Commit succeeds and releases the connection on every error path.",
+}
+
+// resource describes a database/sql value whose lifetime the caller owns.
+type resource struct {
+	v       *types.Var
+	kind    string // Rows, Stmt or Tx
+	created ast.Node
+	calls   map[string]bool
+	escaped bool
+}
+
+// Handler handles the audit_sql tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	findings := Analyze(absDir, pkgs)
+
+	rep := render(pattern, findings, locale.FromRequest(req))
+	output, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Analyze runs the database/sql leak checks over every function in pkgs.
+func Analyze(root string, pkgs []*packages.Package) []shared.Finding {
+	var findings []shared.Finding
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				for _, res := range track(pkg.TypesInfo, fd.Body) {
+					for _, rule := range violations(res) {
+						findings = append(findings, shared.Finding{
+							Pkg:        pkg.PkgPath,
+							Position:   shared.RelPosition(root, pkg.Fset.Position(res.created.Pos())),
+							Rule:       rule,
+							Message:    message(rule, res.v.Name()),
+							Suggestion: fixes[rule],
+						})
+					}
+				}
+			}
+		}
+	}
+	shared.SortFindings(findings)
+	return findings
+}
+
+// track finds every local variable in body that is assigned a *sql.Rows, *sql.Stmt or *sql.Tx
+// from a call, and records which methods are called on it and whether it leaves the function.
+func track(info *types.Info, body *ast.BlockStmt) []*resource {
+	byVar := make(map[*types.Var]*resource)
+	var order []*resource
+
+	markEscape := func(expr ast.Expr) {
+		if id, ok := ast.Unparen(expr).(*ast.Ident); ok {
+			if v, ok := info.Uses[id].(*types.Var); ok {
+				if res := byVar[v]; res != nil {
+					res.escaped = true
+				}
+			}
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Rhs) == 1 && len(node.Lhs) > 0 {
+				if call, ok := node.Rhs[0].(*ast.CallExpr); ok {
+					if kind := resultKind(info, call); kind != "" {
+						if id, ok := node.Lhs[0].(*ast.Ident); ok {
+							if v := varOf(info, id); v != nil && byVar[v] == nil {
+								res := &resource{v: v, kind: kind, created: call, calls: make(map[string]bool)}
+								byVar[v] = res
+								order = append(order, res)
+							}
+						}
+					}
+				}
+			}
+			for _, rhs := range node.Rhs {
+				markEscape(rhs)
+			}
+		case *ast.ReturnStmt:
+			for _, r := range node.Results {
+				markEscape(r)
+			}
+		case *ast.CallExpr:
+			if sel, ok := node.Fun.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					if v, ok := info.Uses[id].(*types.Var); ok && byVar[v] != nil {
+						byVar[v].calls[sel.Sel.Name] = true
+					}
+				}
+			}
+			for _, arg := range node.Args {
+				markEscape(arg)
+			}
+		case *ast.CompositeLit:
+			for _, elt := range node.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					markEscape(kv.Value)
+				} else {
+					markEscape(elt)
+				}
+			}
+		}
+		return true
+	})
+	return order
+}
+
+// violations returns the rules a tracked resource breaks. Resources handed to other code are
+// assumed to be managed there.
+func violations(res *resource) []string {
+	if res.escaped {
+		return nil
+	}
+	var rules []string
+	switch res.kind {
+	case "Rows":
+		if !res.calls["Close"] {
+			rules = append(rules, RuleRowsNotClosed)
+		}
+		if res.calls["Next"] && !res.calls["Err"] {
+			rules = append(rules, RuleRowsErr)
+		}
+	case "Stmt":
+		if !res.calls["Close"] {
+			rules = append(rules, RuleStmtNotClosed)
+		}
+	case "Tx":
+		if !res.calls["Rollback"] {
+			rules = append(rules, RuleTxNoRollback)
+		}
+	}
+	return rules
+}
+
+func message(rule, name string) string {
+	switch rule {
+	case RuleRowsNotClosed:
+		return fmt.Sprintf("rows %q are never closed", name)
+	case RuleRowsErr:
+		return fmt.Sprintf("rows %q are iterated without checking %s.Err()", name, name)
+	case RuleStmtNotClosed:
+		return fmt.Sprintf("statement %q is never closed", name)
+	case RuleTxNoRollback:
+		return fmt.Sprintf("transaction %q has no Rollback; error paths leave it open", name)
+	}
+	return rule
+}
+
+// resultKind returns "Rows", "Stmt" or "Tx" if the first result of call is a pointer to that
+// database/sql type.
+func resultKind(info *types.Info, call *ast.CallExpr) string {
+	tv, ok := info.Types[call]
+	if !ok {
+		return ""
+	}
+	t := tv.Type
+	if tuple, ok := t.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return ""
+		}
+		t = tuple.At(0).Type()
+	}
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	named, ok := types.Unalias(ptr.Elem()).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != sqlPkg {
+		return ""
+	}
+	switch name := named.Obj().Name(); name {
+	case "Rows", "Stmt", "Tx":
+		return name
+	}
+	return ""
+}
+
+func varOf(info *types.Info, id *ast.Ident) *types.Var {
+	if v, ok := info.Defs[id].(*types.Var); ok {
+		return v
+	}
+	v, _ := info.Uses[id].(*types.Var)
+	return v
+}
+
+func render(pattern string, findings []shared.Finding, lang string) *shared.Report {
+	if findings == nil {
+		findings = []shared.Finding{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "sqlleaks.title", pattern), Lang: lang, Status: shared.StatusPass, Data: findings}
+	if len(findings) == 0 {
+		rep.Summary = locale.T(lang, "sqlleaks.none")
+		return rep
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "audit.found_issues", len(findings))
+
+	byRule := make(map[string][]shared.Finding)
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	rules := make([]string, 0, len(byRule))
+	for rule := range byRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(byRule[rule])), "")
+		sec.Text = "**Fix:** " + fixes[rule]
+		for _, f := range byRule[rule] {
+			sec.Items = append(sec.Items, f.Position+": "+f.Message)
+		}
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
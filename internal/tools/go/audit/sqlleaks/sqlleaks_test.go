@@ -0,0 +1,112 @@
+package sqlleaks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const storeSrc = `package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+func Names(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM users")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, nil
+}
+
+func Count(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+func Lookup(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query("SELECT 1")
+	return rows, err
+}
+
+func Insert(db *sql.DB, name string) error {
+	stmt, err := db.Prepare("INSERT INTO users(name) VALUES (?)")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(name)
+	return err
+}
+
+func Transfer(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE a SET x = 1"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func SafeTransfer(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE a SET x = 1"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+`
+
+func TestHandler(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"store/store.go": storeSrc,
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"store/store.go:9:15: rows \"rows\" are never closed",
+		"store/store.go:9:15: rows \"rows\" are iterated without checking rows.Err()",
+		"store/store.go:43:15: statement \"stmt\" is never closed",
+		"store/store.go:52:13: transaction \"tx\" has no Rollback",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, "Found 4 issue(s)") {
+		t.Errorf("expected exactly 4 issues, got:\n%s", out)
+	}
+}
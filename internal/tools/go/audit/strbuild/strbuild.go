@@ -0,0 +1,921 @@
+// Package strbuild implements the audit_strings tool, which flags quadratic string building,
+// fmt.Sprintf in loops and needless []byte/string conversions, and rewrites the simple cases.
+package strbuild
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_strings"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	Apply        bool   `json:"apply,omitempty" jsonschema:"Rewrite the findings marked as fixable: strings.Builder for += in loops, concatenation for simple fmt.Sprintf calls, and the direct API for conversions"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers, from the most to the least costly.
+const (
+	RuleConcat  = "concat-in-loop"
+	RuleSprintf = "sprintf-in-loop"
+	RuleChurn   = "conversion-churn"
+)
+
+var rules = []string{RuleConcat, RuleSprintf, RuleChurn}
+
+var descriptions = map[string]string{
+	RuleConcat:  "Strings built with += inside loops: every iteration copies everything built so far, which is quadratic in time and garbage.",
+	RuleSprintf: "fmt.Sprintf inside loops: every call boxes its arguments into interfaces and interprets the format at run time.",
+	RuleChurn:   "Conversions between []byte and string that copy the data only to convert it back or to call an API that has a variant for the original type.",
+}
+
+// Site is a finding together with its rewrite, if it has a simple one.
+type Site struct {
+	shared.Finding
+	// Fix is the rewrite of a simple case, or nil.
+	Fix *Fix `json:"-"`
+}
+
+// Fix is a codemod for one finding.
+type Fix struct {
+	File    string
+	Edits   []shared.TextEdit
+	Summary string
+}
+
+// Handler handles the audit_strings tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	sites := Analyze(absDir, pkgs)
+
+	var applied strings.Builder
+	if args.Apply {
+		changes, n, err := codemod(sites)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		if n == 0 {
+			applied.WriteString("No finding has a simple rewrite; nothing was changed.")
+		} else {
+			if err := changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			fmt.Fprintf(&applied, "✅ Rewrote %d site(s) across %d file(s):\n", n, len(changes))
+			for _, s := range sites {
+				if s.Fix != nil {
+					fmt.Fprintf(&applied, "\n- %s: %s", s.Position, s.Fix.Summary)
+				}
+			}
+			// Reload so the report reflects the rewritten sources.
+			if pkgs, err = shared.LoadPackages(ctx, absDir, pattern, false); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			sites = Analyze(absDir, pkgs)
+		}
+	}
+	rep := render(pattern, sites, applied.String(), locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// Analyze reports the string building and conversion findings of the packages.
+func Analyze(root string, pkgs []*packages.Package) []Site {
+	var sites []Site
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			tf := pkg.Fset.File(file.Pos())
+			//nolint:gosec // G304: File path comes from the loaded package.
+			src, err := os.ReadFile(tf.Name())
+			if err != nil {
+				continue
+			}
+			a := &fileAnalysis{root: root, pkg: pkg, file: file, tf: tf, src: src, info: pkg.TypesInfo}
+			sites = append(sites, a.run()...)
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool { return shared.ComparePositions(sites[i].Position, sites[j].Position) < 0 })
+	return sites
+}
+
+type fileAnalysis struct {
+	root string
+	pkg  *packages.Package
+	file *ast.File
+	tf   *token.File
+	src  []byte
+	info *types.Info
+}
+
+// concatGroup gathers the += statements building one variable inside one loop.
+type concatGroup struct {
+	v        *types.Var
+	loop     ast.Stmt
+	stmts    []*ast.AssignStmt
+	operands [][]ast.Expr
+}
+
+func (a *fileAnalysis) run() []Site {
+	var sites []Site
+	var groups []*concatGroup
+	byKey := make(map[[2]any]*concatGroup)
+	concatStmts := make(map[ast.Node]bool)
+
+	var stack []ast.Node
+	ast.Inspect(a.file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			loop := loopOf(stack)
+			if loop == nil {
+				return true
+			}
+			v, operands := a.concat(n)
+			if v == nil {
+				return true
+			}
+			key := [2]any{v, loop}
+			g := byKey[key]
+			if g == nil {
+				g = &concatGroup{v: v, loop: loop}
+				byKey[key] = g
+				groups = append(groups, g)
+			}
+			g.stmts = append(g.stmts, n)
+			g.operands = append(g.operands, operands)
+			concatStmts[n] = true
+		case *ast.CallExpr:
+			if s, ok := a.churn(n); ok {
+				sites = append(sites, s)
+				return true
+			}
+			if isCall(a.info, n, "fmt", "Sprintf") && loopOf(stack) != nil && !within(stack, concatStmts) {
+				sites = append(sites, a.sprintf(n, stack[len(stack)-2]))
+			}
+		}
+		return true
+	})
+	for _, g := range groups {
+		sites = append(sites, a.concatSite(g))
+	}
+	return sites
+}
+
+func (a *fileAnalysis) off(p token.Pos) int { return a.tf.Offset(p) }
+
+func (a *fileAnalysis) text(n ast.Node) string { return string(a.src[a.off(n.Pos()):a.off(n.End())]) }
+
+func (a *fileAnalysis) position(n ast.Node) string {
+	return shared.RelPosition(a.root, a.pkg.Fset.Position(n.Pos()))
+}
+
+// indent returns the whitespace before the line of p.
+func (a *fileAnalysis) indent(p token.Pos) string {
+	start := a.off(p)
+	i := start
+	for i > 0 && (a.src[i-1] == ' ' || a.src[i-1] == '\t') {
+		i--
+	}
+	return string(a.src[i:start])
+}
+
+// loopOf returns the innermost loop whose body contains the top of the stack, within the
+// enclosing function.
+func loopOf(stack []ast.Node) ast.Stmt {
+	for i := len(stack) - 1; i > 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return nil
+		case *ast.BlockStmt:
+			switch loop := stack[i-1].(type) {
+			case *ast.ForStmt:
+				if loop.Body == n {
+					return loop
+				}
+			case *ast.RangeStmt:
+				if loop.Body == n {
+					return loop
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func within(stack []ast.Node, set map[ast.Node]bool) bool {
+	for _, n := range stack {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// isCall reports whether call is a call of the package-level function pkg.name.
+func isCall(info *types.Info, call *ast.CallExpr, pkg, name string) bool {
+	fn := typeutil.StaticCallee(info, call)
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == pkg && fn.Name() == name
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && (b.Kind() == types.String || b.Kind() == types.UntypedString)
+}
+
+func isBytes(t types.Type) bool {
+	return t != nil && types.Identical(t.Underlying(), types.NewSlice(types.Typ[types.Byte]))
+}
+
+// concat recognizes `s += x` and `s = s + x` on a string variable and returns the variable and
+// the appended operands.
+func (a *fileAnalysis) concat(as *ast.AssignStmt) (*types.Var, []ast.Expr) {
+	if len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+		return nil, nil
+	}
+	id, ok := as.Lhs[0].(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	v, ok := a.info.Uses[id].(*types.Var)
+	if !ok {
+		return nil, nil
+	}
+	if t, ok := v.Type().Underlying().(*types.Basic); !ok || t.Info()&types.IsString == 0 {
+		return nil, nil
+	}
+	switch as.Tok {
+	case token.ADD_ASSIGN:
+		return v, flatten(a.info, as.Rhs[0])
+	case token.ASSIGN:
+		operands := flatten(a.info, as.Rhs[0])
+		if first, ok := ast.Unparen(operands[0]).(*ast.Ident); ok && len(operands) > 1 && a.info.Uses[first] == v {
+			return v, operands[1:]
+		}
+	}
+	return nil, nil
+}
+
+// flatten splits a string concatenation into its operands.
+func flatten(info *types.Info, e ast.Expr) []ast.Expr {
+	if bin, ok := ast.Unparen(e).(*ast.BinaryExpr); ok && bin.Op == token.ADD {
+		if t, ok := info.TypeOf(bin).Underlying().(*types.Basic); ok && t.Info()&types.IsString != 0 {
+			return append(flatten(info, bin.X), flatten(info, bin.Y)...)
+		}
+	}
+	return []ast.Expr{e}
+}
+
+func (a *fileAnalysis) concatSite(g *concatGroup) Site {
+	sprintf := false
+	for _, ops := range g.operands {
+		for _, op := range ops {
+			if call, ok := ast.Unparen(op).(*ast.CallExpr); ok && isCall(a.info, call, "fmt", "Sprintf") {
+				sprintf = true
+			}
+		}
+	}
+	msg := fmt.Sprintf("`%s` is built with += inside the loop, copying the whole string on every iteration", g.v.Name())
+	if len(g.stmts) > 1 {
+		msg += fmt.Sprintf(" (%d statements)", len(g.stmts))
+	}
+	suggestion := "Accumulate into a `strings.Builder` declared before the loop and read it once with `String()` after it"
+	if sprintf {
+		suggestion += "; write the formatted parts with `fmt.Fprintf(&sb, ...)` instead of `fmt.Sprintf`"
+	}
+	s := Site{Finding: shared.Finding{
+		Pkg:        a.pkg.PkgPath,
+		Position:   a.position(g.stmts[0]),
+		Rule:       RuleConcat,
+		Message:    msg,
+		Suggestion: suggestion + ".",
+	}}
+	s.Fix = a.concatFix(g)
+	return s
+}
+
+// concatFix rewrites the loop to build the string in a strings.Builder:
+//
+//	var sb strings.Builder
+//	sb.WriteString(s)        // unless s is known to be empty
+//	for ... {
+//		sb.WriteString(x)    // was s += x
+//	}
+//	s = sb.String()
+//
+// The variable must be a plain local string that the loop only appends to, and nothing may
+// observe it before the assignment after the loop.
+func (a *fileAnalysis) concatFix(g *concatGroup) *Fix {
+	v := g.v
+	if !types.Identical(v.Type(), types.Typ[types.String]) || v.Parent() == a.pkg.Types.Scope() || v.Pos() > g.loop.Pos() {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(a.file, g.loop.Pos(), g.loop.End())
+	var target ast.Stmt = g.loop
+	parent := path[1]
+	label := ""
+	if l, ok := parent.(*ast.LabeledStmt); ok {
+		target, parent, label = l, path[2], l.Label.Name
+	}
+	var list []ast.Stmt
+	switch p := parent.(type) {
+	case *ast.BlockStmt:
+		list = p.List
+	case *ast.CaseClause:
+		list = p.Body
+	case *ast.CommClause:
+		list = p.Body
+	default:
+		return nil
+	}
+	var fn ast.Node
+	for _, n := range path {
+		if _, ok := n.(*ast.FuncDecl); ok {
+			fn = n
+			break
+		}
+		if _, ok := n.(*ast.FuncLit); ok {
+			fn = n
+			break
+		}
+	}
+	if fn == nil {
+		return nil
+	}
+
+	// Inside the loop, v may only appear as the target of the rewritten statements.
+	allowed := make(map[*ast.Ident]bool)
+	for _, st := range g.stmts {
+		allowed[st.Lhs[0].(*ast.Ident)] = true
+		if st.Tok == token.ASSIGN {
+			allowed[ast.Unparen(flatten(a.info, st.Rhs[0])[0]).(*ast.Ident)] = true
+		}
+	}
+	for id, obj := range a.info.Uses {
+		if obj == v && id.Pos() >= g.loop.Pos() && id.End() <= g.loop.End() && !allowed[id] {
+			return nil
+		}
+	}
+	// Leaving the loop other than at its end skips the final assignment: that is only harmless
+	// when v is not a result and no closure can read it.
+	exits := false
+	ast.Inspect(g.loop, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			exits = true
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO || (n.Label != nil && n.Label.Name != label) {
+				exits = true
+			}
+		}
+		return true
+	})
+	if exits && (a.isResult(fn, v) || a.capturedIn(fn, v)) {
+		return nil
+	}
+
+	name := a.builderName(target, v)
+	if name == "" {
+		return nil
+	}
+	empty := false
+	for i, st := range list {
+		if st == target && i > 0 {
+			empty = a.declaresEmpty(list[i-1], v)
+		}
+	}
+
+	indent := a.indent(target.Pos())
+	head := "var " + name + " strings.Builder\n" + indent
+	if !empty {
+		head += name + ".WriteString(" + v.Name() + ")\n" + indent
+	}
+	edits := []shared.TextEdit{
+		{Start: a.off(target.Pos()), End: a.off(target.Pos()), New: head},
+		{Start: a.off(target.End()), End: a.off(target.End()), New: "\n" + indent + v.Name() + " = " + name + ".String()"},
+	}
+	for i, st := range g.stmts {
+		var writes []string
+		for _, op := range g.operands[i] {
+			writes = append(writes, a.write(name, op))
+		}
+		edits = append(edits, shared.TextEdit{
+			Start: a.off(st.Pos()), End: a.off(st.End()),
+			New: strings.Join(writes, "\n"+a.indent(st.Pos())),
+		})
+	}
+	return &Fix{File: a.tf.Name(), Edits: edits, Summary: fmt.Sprintf("`%s +=` in a loop → `strings.Builder`", v.Name())}
+}
+
+// write returns the statement appending op to the builder name.
+func (a *fileAnalysis) write(name string, op ast.Expr) string {
+	op = ast.Unparen(op)
+	if call, ok := op.(*ast.CallExpr); ok {
+		if isCall(a.info, call, "fmt", "Sprintf") {
+			return "fmt.Fprintf(&" + name + ", " + string(a.src[a.off(call.Lparen)+1:a.off(call.Rparen)]) + ")"
+		}
+		// string(r) of a rune or byte.
+		if tv, ok := a.info.Types[call.Fun]; ok && tv.IsType() && len(call.Args) == 1 && a.info.Types[op].Value == nil {
+			if b, ok := a.info.TypeOf(call.Args[0]).(*types.Basic); ok {
+				switch b.Kind() {
+				case types.Int32:
+					return name + ".WriteRune(" + a.text(call.Args[0]) + ")"
+				case types.Uint8:
+					return name + ".WriteByte(" + a.text(call.Args[0]) + ")"
+				}
+			}
+		}
+	}
+	if isString(a.info.TypeOf(op)) {
+		return name + ".WriteString(" + a.text(op) + ")"
+	}
+	return name + ".WriteString(string(" + a.text(op) + "))"
+}
+
+// isResult reports whether v is a named result of fn.
+func (a *fileAnalysis) isResult(fn ast.Node, v *types.Var) bool {
+	var ft *ast.FuncType
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		ft = fn.Type
+	case *ast.FuncLit:
+		ft = fn.Type
+	}
+	if ft.Results == nil {
+		return false
+	}
+	for _, field := range ft.Results.List {
+		for _, id := range field.Names {
+			if a.info.Defs[id] == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// capturedIn reports whether a function literal inside fn refers to v.
+func (a *fileAnalysis) capturedIn(fn ast.Node, v *types.Var) bool {
+	found := false
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok && lit != fn {
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && a.info.Uses[id] == v {
+					found = true
+				}
+				return !found
+			})
+		}
+		return !found
+	})
+	return found
+}
+
+// builderName picks a name for the builder that neither shadows nor is shadowed.
+func (a *fileAnalysis) builderName(target ast.Stmt, v *types.Var) string {
+	scope := a.pkg.Types.Scope().Innermost(target.Pos())
+	for _, name := range []string{"sb", "b", v.Name() + "Builder"} {
+		if scope != nil {
+			if _, obj := scope.LookupParent(name, target.Pos()); obj != nil {
+				continue
+			}
+		}
+		used := false
+		ast.Inspect(target, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == name {
+				used = true
+			}
+			return !used
+		})
+		if !used {
+			return name
+		}
+	}
+	return ""
+}
+
+// declaresEmpty reports whether stmt declares v as the empty string.
+func (a *fileAnalysis) declaresEmpty(stmt ast.Stmt, v *types.Var) bool {
+	emptyLit := func(e ast.Expr) bool {
+		lit, ok := e.(*ast.BasicLit)
+		return ok && (lit.Value == `""` || lit.Value == "``")
+	}
+	switch st := stmt.(type) {
+	case *ast.DeclStmt:
+		gen, ok := st.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR || len(gen.Specs) != 1 {
+			return false
+		}
+		spec := gen.Specs[0].(*ast.ValueSpec)
+		return len(spec.Names) == 1 && a.info.Defs[spec.Names[0]] == v && (len(spec.Values) == 0 || emptyLit(spec.Values[0]))
+	case *ast.AssignStmt:
+		if st.Tok != token.DEFINE || len(st.Lhs) != 1 || len(st.Rhs) != 1 {
+			return false
+		}
+		id, ok := st.Lhs[0].(*ast.Ident)
+		return ok && a.info.Defs[id] == v && emptyLit(st.Rhs[0])
+	}
+	return false
+}
+
+// sprintf reports a fmt.Sprintf call inside a loop, with a rewrite to concatenation when the
+// format only uses plain verbs on strings, integers and booleans.
+func (a *fileAnalysis) sprintf(call *ast.CallExpr, parent ast.Node) Site {
+	s := Site{Finding: shared.Finding{
+		Pkg:        a.pkg.PkgPath,
+		Position:   a.position(call),
+		Rule:       RuleSprintf,
+		Message:    "fmt.Sprintf runs on every iteration",
+		Suggestion: "Hoist it out of the loop if its arguments do not change, or append into a reused buffer with `fmt.Appendf(buf[:0], ...)` or the `strconv.Append*` functions.",
+	}}
+	expr, parts := a.concatenation(call)
+	if expr == "" {
+		return s
+	}
+	if parts > 1 && needsParens(parent, call) {
+		expr = "(" + expr + ")"
+	}
+	s.Suggestion = fmt.Sprintf("The format only uses plain verbs: concatenate instead, `%s`.", expr)
+	s.Fix = &Fix{
+		File:    a.tf.Name(),
+		Edits:   []shared.TextEdit{{Start: a.off(call.Pos()), End: a.off(call.End()), New: expr}},
+		Summary: "`fmt.Sprintf` → concatenation",
+	}
+	return s
+}
+
+// concatenation translates fmt.Sprintf(format, args...) into an equivalent concatenation and
+// returns it with its number of operands, or "" when the format uses flags, widths or verbs
+// whose output depends on more than the argument's basic type.
+func (a *fileAnalysis) concatenation(call *ast.CallExpr) (string, int) {
+	if len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return "", 0
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", 0
+	}
+	format, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", 0
+	}
+	var parts []string
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, strconv.Quote(literal.String()))
+			literal.Reset()
+		}
+	}
+	args := call.Args[1:]
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return "", 0
+		}
+		i++
+		verb := format[i]
+		if verb == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		if len(args) == 0 {
+			return "", 0
+		}
+		arg := args[0]
+		args = args[1:]
+		part := a.convert(verb, arg)
+		if part == "" {
+			return "", 0
+		}
+		flush()
+		parts = append(parts, part)
+	}
+	if len(args) > 0 {
+		return "", 0
+	}
+	flush()
+	if len(parts) == 0 {
+		return `""`, 1
+	}
+	return strings.Join(parts, " + "), len(parts)
+}
+
+// convert returns the string expression that formats arg like the verb does.
+func (a *fileAnalysis) convert(verb byte, arg ast.Expr) string {
+	b, ok := a.info.TypeOf(arg).(*types.Basic)
+	if !ok {
+		return ""
+	}
+	x := a.text(arg)
+	switch {
+	case (verb == 's' || verb == 'v') && isString(b):
+		return x
+	case verb == 'q' && isString(b):
+		return "strconv.Quote(" + x + ")"
+	case (verb == 'd' || verb == 'v') && (b.Kind() == types.Int || b.Kind() == types.UntypedInt):
+		return "strconv.Itoa(" + x + ")"
+	case (verb == 'd' || verb == 'v') && b.Kind() == types.Int64:
+		return "strconv.FormatInt(" + x + ", 10)"
+	case (verb == 'd' || verb == 'v') && b.Kind() == types.Uint64:
+		return "strconv.FormatUint(" + x + ", 10)"
+	case (verb == 't' || verb == 'v') && (b.Kind() == types.Bool || b.Kind() == types.UntypedBool):
+		return "strconv.FormatBool(" + x + ")"
+	}
+	return ""
+}
+
+// needsParens reports whether a concatenation replacing call needs parentheses in parent.
+func needsParens(parent ast.Node, call *ast.CallExpr) bool {
+	switch p := parent.(type) {
+	case *ast.AssignStmt, *ast.ReturnStmt, *ast.ValueSpec, *ast.KeyValueExpr, *ast.CompositeLit, *ast.SendStmt:
+		return false
+	case *ast.CallExpr:
+		return p.Fun == ast.Expr(call)
+	case *ast.BinaryExpr:
+		return p.Op != token.ADD
+	}
+	return true
+}
+
+// Functions of package strings that package bytes mirrors with []byte in place of every string
+// argument. The value is the number of leading string arguments.
+var mirrored = map[string]int{
+	"Contains": 2, "ContainsAny": 2, "ContainsRune": 1, "Count": 2, "EqualFold": 2,
+	"HasPrefix": 2, "HasSuffix": 2, "Index": 2, "IndexAny": 2, "IndexByte": 1, "IndexRune": 1,
+	"LastIndex": 2, "LastIndexAny": 2, "LastIndexByte": 1,
+}
+
+// churn recognizes conversions that copy data needlessly:
+//
+//	[]byte(string(b))         → bytes.Clone(b)
+//	string([]byte(s))         → s
+//	w.Write([]byte(s))        → io.WriteString(w, s), or w.WriteString(s)
+//	strings.F(string(b), ...) → bytes.F(b, ...)
+func (a *fileAnalysis) churn(call *ast.CallExpr) (Site, bool) {
+	site := func(msg, suggestion, repl, summary string) (Site, bool) {
+		return Site{
+			Finding: shared.Finding{Pkg: a.pkg.PkgPath, Position: a.position(call), Rule: RuleChurn, Message: msg, Suggestion: suggestion},
+			Fix: &Fix{
+				File:    a.tf.Name(),
+				Edits:   []shared.TextEdit{{Start: a.off(call.Pos()), End: a.off(call.End()), New: repl}},
+				Summary: summary,
+			},
+		}, true
+	}
+
+	if a.conversion(call) != nil {
+		if inner := a.conversion(call.Args[0]); inner != nil {
+			outer, mid, x := a.info.TypeOf(call), a.info.TypeOf(inner), a.info.TypeOf(inner.Args[0])
+			switch {
+			case types.Identical(outer, types.NewSlice(types.Typ[types.Byte])) && isString(mid) && isBytes(x):
+				return site("`"+a.text(call)+"` copies the bytes twice to end up with a copy of the slice",
+					"Use `bytes.Clone`, which copies once.", "bytes.Clone("+a.text(inner.Args[0])+")", "round trip → `bytes.Clone`")
+			case types.Identical(outer, types.Typ[types.String]) && isBytes(mid) && types.Identical(x, types.Typ[types.String]):
+				return site("`"+a.text(call)+"` copies the string twice to get the same string back",
+					"Strings are immutable: use the original string.", a.text(inner.Args[0]), "round trip → original string")
+			}
+		}
+		return Site{}, false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return Site{}, false
+	}
+	fn, ok := typeutil.Callee(a.info, call).(*types.Func)
+	if !ok {
+		return Site{}, false
+	}
+	sig := fn.Type().(*types.Signature)
+
+	// w.Write([]byte(s))
+	if sig.Recv() != nil && fn.Name() == "Write" && len(call.Args) == 1 {
+		conv := a.conversion(call.Args[0])
+		if conv == nil || !isBytes(a.info.TypeOf(call.Args[0])) || !isString(a.info.TypeOf(conv.Args[0]).Underlying()) {
+			return Site{}, false
+		}
+		s := a.text(conv.Args[0])
+		if !isString(a.info.TypeOf(conv.Args[0])) {
+			s = "string(" + s + ")"
+		}
+		w := a.info.TypeOf(sel.X)
+		if m := types.NewMethodSet(w).Lookup(nil, "WriteString"); m != nil {
+			return site("`"+a.text(call.Args[0])+"` copies the string only to write it",
+				"Call `WriteString`, which writes the string without the copy.", a.text(sel.X)+".WriteString("+s+")", "`Write([]byte(s))` → `WriteString(s)`")
+		}
+		if types.NewMethodSet(w).Lookup(nil, "Write") == nil {
+			return Site{}, false // Write has a pointer receiver on an addressable value
+		}
+		return site("`"+a.text(call.Args[0])+"` copies the string only to write it",
+			"Use `io.WriteString`, which calls the writer's `WriteString` method when it has one.", "io.WriteString("+a.text(sel.X)+", "+s+")", "`Write([]byte(s))` → `io.WriteString`")
+	}
+
+	// strings.F(string(b), ...)
+	n, ok := mirrored[fn.Name()]
+	if sig.Recv() != nil || fn.Pkg() == nil || fn.Pkg().Path() != "strings" || !ok || len(call.Args) < n {
+		return Site{}, false
+	}
+	first := a.conversion(call.Args[0])
+	if first == nil || !isBytes(a.info.TypeOf(first.Args[0])) {
+		return Site{}, false
+	}
+	args := []string{a.text(first.Args[0])}
+	for _, arg := range call.Args[1:n] {
+		switch {
+		case a.conversion(arg) != nil && isBytes(a.info.TypeOf(a.conversion(arg).Args[0])):
+			args = append(args, a.text(a.conversion(arg).Args[0]))
+		case a.info.Types[arg].Value != nil:
+			args = append(args, "[]byte("+a.text(arg)+")")
+		default:
+			return Site{}, false // converting the other argument would just move the copy
+		}
+	}
+	for _, arg := range call.Args[n:] {
+		args = append(args, a.text(arg))
+	}
+	return site("`"+a.text(call.Args[0])+"` copies the bytes only to call strings."+fn.Name(),
+		"Call `bytes."+fn.Name()+"` on the slice.", "bytes."+fn.Name()+"("+strings.Join(args, ", ")+")", "`strings."+fn.Name()+"(string(b))` → `bytes."+fn.Name()+"`")
+}
+
+// conversion returns e as a conversion between string and []byte, or nil.
+func (a *fileAnalysis) conversion(e ast.Expr) *ast.CallExpr {
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	tv, ok := a.info.Types[call.Fun]
+	if !ok || !tv.IsType() {
+		return nil
+	}
+	to, from := a.info.TypeOf(call), a.info.TypeOf(call.Args[0])
+	if (isBytes(to) && isString(from.Underlying())) || (isString(to.Underlying()) && isBytes(from)) {
+		return call
+	}
+	return nil
+}
+
+// codemod gathers the fixes into one changeset. A fix overlapping one taken before it, such as
+// a Sprintf inside a rewritten Write call, is dropped; running the tool again picks it up.
+func codemod(sites []Site) (shared.Changeset, int, error) {
+	byFile := make(map[string][]shared.TextEdit)
+	count := 0
+	for i, s := range sites {
+		if s.Fix == nil {
+			continue
+		}
+		if overlaps(byFile[s.Fix.File], s.Fix.Edits) {
+			sites[i].Fix = nil
+			continue
+		}
+		byFile[s.Fix.File] = append(byFile[s.Fix.File], s.Fix.Edits...)
+		count++
+	}
+	changes := make(shared.Changeset)
+	for file, edits := range byFile {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		out, err := shared.ApplyEdits(src, edits)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to rewrite %s: %w", file, err)
+		}
+		changes[file] = out
+	}
+	return changes, count, nil
+}
+
+func overlaps(taken, edits []shared.TextEdit) bool {
+	for _, t := range taken {
+		for _, e := range edits {
+			if e.Start < t.End && t.Start < e.End || e.Start == t.Start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func render(pattern string, sites []Site, applied, lang string) *shared.Report {
+	if sites == nil {
+		sites = []Site{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "strbuild.title", pattern), Lang: lang, Status: shared.StatusPass, Data: sites}
+	if applied != "" {
+		applied += "\n\n"
+	}
+	if len(sites) == 0 {
+		rep.Summary = applied + locale.T(lang, "strbuild.none")
+		return rep
+	}
+
+	byRule := make(map[string][]Site)
+	fixable := 0
+	for _, s := range sites {
+		byRule[s.Rule] = append(byRule[s.Rule], s)
+		if s.Fix != nil {
+			fixable++
+		}
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = applied + locale.T(lang, "audit.found_findings", len(sites))
+	for _, rule := range rules {
+		list := byRule[rule]
+		if len(list) == 0 {
+			continue
+		}
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(list)), "")
+		sec.Text = descriptions[rule]
+		for _, s := range list {
+			mark := ""
+			if s.Fix != nil {
+				mark = " 🔧"
+			}
+			sec.Items = append(sec.Items, fmt.Sprintf("%s: %s%s\n  - %s", s.Position, s.Message, mark, s.Suggestion))
+		}
+	}
+	if fixable > 0 {
+		rep.Add("", "").Text = fmt.Sprintf("💡 Run again with `apply=true` to rewrite the %d finding(s) marked 🔧.", fixable)
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package strbuild
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const report = `package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Row struct {
+	Name  string
+	Count int
+}
+
+func Table(rows []Row) string {
+	out := ""
+	for _, r := range rows {
+		out += r.Name + ": "
+		out += fmt.Sprintf("%5d\n", r.Count)
+	}
+	return out
+}
+
+func Keys(rows []Row) []string {
+	var keys []string
+	for i, r := range rows {
+		keys = append(keys, fmt.Sprintf("%s-%d", r.Name, i))
+	}
+	return keys
+}
+
+func Join(words []string) (s string) {
+	for _, w := range words {
+		if w == "" {
+			return
+		}
+		s += w
+	}
+	return s
+}
+
+func Write(w io.Writer, b []byte, s string) error {
+	if strings.HasPrefix(string(b), "#") {
+		return nil
+	}
+	if _, err := w.Write([]byte(s)); err != nil {
+		return err
+	}
+	_ = string([]byte(s))
+	return nil
+}
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":        testutil.GoMod("example.com/app"),
+		"app/report.go": report,
+	})
+}
+
+func run(t *testing.T, args Params) string {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeModule(t)
+	out := run(t, Params{Dir: dir})
+
+	wants := []string{
+		"⚠️ Found 6 finding(s).",
+		"## concat-in-loop (2)",
+		"- app/report.go:17:3: `out` is built with += inside the loop, copying the whole string on every iteration (2 statements) 🔧\n  - Accumulate into a `strings.Builder` declared before the loop and read it once with `String()` after it; write the formatted parts with `fmt.Fprintf(&sb, ...)` instead of `fmt.Sprintf`.",
+		// The named result would miss the text built before the early return.
+		"- app/report.go:36:3: `s` is built with += inside the loop, copying the whole string on every iteration\n",
+		"## sprintf-in-loop (1)",
+		"- app/report.go:26:23: fmt.Sprintf runs on every iteration 🔧\n  - The format only uses plain verbs: concatenate instead, `r.Name + \"-\" + strconv.Itoa(i)`.",
+		"## conversion-churn (3)",
+		"- app/report.go:42:5: `string(b)` copies the bytes only to call strings.HasPrefix 🔧",
+		"- app/report.go:45:15: `[]byte(s)` copies the string only to write it 🔧",
+		"- app/report.go:48:6: `string([]byte(s))` copies the string twice to get the same string back 🔧",
+		"rewrite the 5 finding(s) marked 🔧",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := writeModule(t)
+	out := run(t, Params{Dir: dir, Apply: true})
+	if !strings.Contains(out, "✅ Rewrote 5 site(s) across 1 file(s)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "app", "report.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\tout := \"\"\n\tvar sb strings.Builder\n\tfor _, r := range rows {\n\t\tsb.WriteString(r.Name)\n\t\tsb.WriteString(\": \")\n\t\tfmt.Fprintf(&sb, \"%5d\\n\", r.Count)\n\t}\n\tout = sb.String()\n\treturn out",
+		"keys = append(keys, r.Name+\"-\"+strconv.Itoa(i))",
+		"if bytes.HasPrefix(b, []byte(\"#\")) {",
+		"if _, err := io.WriteString(w, s); err != nil {",
+		"_ = s\n",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("rewritten file missing %q:\n%s", want, got)
+		}
+	}
+	if !strings.Contains(out, "Found 1 finding(s).") {
+		t.Errorf("unexpected report after rewriting:\n%s", out)
+	}
+}
@@ -0,0 +1,745 @@
+// Package streaming implements the audit_streaming tool, which flags whole reads of files and
+// bodies into memory on hot paths and suggests, or applies, streaming alternatives.
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_streaming"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...)"`
+	Apply        bool   `json:"apply,omitempty" jsonschema:"Rewrite the simple cases: io.ReadAll followed by json/xml.Unmarshal becomes a decoder, followed by a Write becomes io.Copy"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Rule identifiers, from the most to the least severe.
+const (
+	RuleBody    = "unbounded-body"
+	RuleHandler = "per-request"
+	RuleLoop    = "in-loop"
+)
+
+var rules = []string{RuleBody, RuleHandler, RuleLoop}
+
+var descriptions = map[string]string{
+	RuleBody:    "HTTP bodies read whole without a size limit: a single large or malicious request exhausts memory.",
+	RuleHandler: "Whole reads in HTTP handlers: memory grows with request concurrency times input size.",
+	RuleLoop:    "Whole reads inside loops: each iteration allocates a full copy of its input.",
+}
+
+// Read is a whole read of a reader or file together with how its result is used.
+type Read struct {
+	shared.Finding
+	// Fix is the rewrite of a simple case, or nil.
+	Fix *Fix `json:"-"`
+}
+
+// Fix is a codemod replacing a whole read with a streaming call.
+type Fix struct {
+	File    string
+	Edits   []shared.TextEdit
+	Summary string
+}
+
+// Handler handles the audit_streaming tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	reads, skipped := Analyze(absDir, pkgs)
+
+	var applied strings.Builder
+	if args.Apply {
+		changes, n, err := codemod(reads)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		if n == 0 {
+			applied.WriteString("No read matched a codemod; nothing was rewritten.")
+		} else {
+			if err := changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			fmt.Fprintf(&applied, "✅ Rewrote %d read(s) to stream across %d file(s):\n", n, len(changes))
+			for _, r := range reads {
+				if r.Fix != nil {
+					fmt.Fprintf(&applied, "\n- %s: %s", r.Position, r.Fix.Summary)
+				}
+			}
+			applied.WriteString("\n\nRead errors are now returned by the streaming call, so they take its error path.")
+			// Reload so the report reflects the rewritten sources.
+			if pkgs, err = shared.LoadPackages(ctx, absDir, pattern, false); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			reads, skipped = Analyze(absDir, pkgs)
+		}
+	}
+	rep := render(pattern, reads, skipped, applied.String(), locale.FromRequest(req))
+	out, err := rep.Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: out},
+		},
+	}, nil, nil
+}
+
+// Analyze reports the whole reads on hot paths: unbounded HTTP bodies, reads inside HTTP handlers
+// and reads inside loops. It also returns how many other whole reads, such as loading a config
+// file at startup, were not reported.
+func Analyze(root string, pkgs []*packages.Package) ([]Read, int) {
+	var reads []Read
+	skipped := 0
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			var stack []ast.Node
+			ast.Inspect(file, func(n ast.Node) bool {
+				if n == nil {
+					stack = stack[:len(stack)-1]
+					return true
+				}
+				stack = append(stack, n)
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				name := wholeRead(pkg.TypesInfo, call)
+				if name == "" {
+					return true
+				}
+				a := &analysis{pkg: pkg, file: file, call: call, name: name, path: stack}
+				if r, ok := a.run(root); ok {
+					reads = append(reads, r)
+				} else {
+					skipped++
+				}
+				return true
+			})
+		}
+	}
+	sort.Slice(reads, func(i, j int) bool { return shared.ComparePositions(reads[i].Position, reads[j].Position) < 0 })
+	return reads, skipped
+}
+
+// wholeRead returns the qualified name of a call reading a whole reader or file, or "".
+func wholeRead(info *types.Info, call *ast.CallExpr) string {
+	fn := typeutil.StaticCallee(info, call)
+	if fn == nil || fn.Pkg() == nil {
+		return ""
+	}
+	switch path, name := fn.Pkg().Path(), fn.Name(); {
+	case path == "io" && name == "ReadAll",
+		path == "io/ioutil" && name == "ReadAll",
+		path == "os" && name == "ReadFile",
+		path == "io/ioutil" && name == "ReadFile":
+		return fn.Pkg().Name() + "." + name
+	}
+	return ""
+}
+
+// analysis examines one whole read. path holds the nodes from the file down to the call.
+type analysis struct {
+	pkg  *packages.Package
+	file *ast.File
+	call *ast.CallExpr
+	name string
+	path []ast.Node
+}
+
+func (a *analysis) run(root string) (Read, bool) {
+	fn, body := a.enclosingFunc()
+	if body == nil {
+		return Read{}, false
+	}
+	rule := ""
+	switch {
+	case a.readsBody() && !bounded(a.pkg.TypesInfo, body):
+		rule = RuleBody
+	case isHandler(a.pkg.TypesInfo, fn):
+		rule = RuleHandler
+	case a.inLoop():
+		rule = RuleLoop
+	default:
+		return Read{}, false
+	}
+
+	stmt, sink := a.sink(body)
+	msg := a.name + " reads the whole input into memory"
+	if sink.name != "" {
+		msg += "; the result only goes to " + sink.name
+	}
+	r := Read{Finding: shared.Finding{
+		Pkg:        a.pkg.PkgPath,
+		Position:   shared.RelPosition(root, a.pkg.Fset.Position(a.call.Pos())),
+		Rule:       rule,
+		Message:    msg,
+		Suggestion: suggestion(rule, a.name, sink.kind),
+	}}
+	if stmt != nil && sink.call != nil {
+		r.Fix = a.fix(body, stmt, sink)
+	}
+	return r, true
+}
+
+// enclosingFunc returns the innermost function around the call and its body.
+func (a *analysis) enclosingFunc() (ast.Node, *ast.BlockStmt) {
+	for i := len(a.path) - 1; i >= 0; i-- {
+		switch fn := a.path[i].(type) {
+		case *ast.FuncDecl:
+			return fn, fn.Body
+		case *ast.FuncLit:
+			return fn, fn.Body
+		}
+	}
+	return nil, nil
+}
+
+// readsBody reports whether the call reads the Body of an *http.Request or *http.Response.
+func (a *analysis) readsBody() bool {
+	if len(a.call.Args) != 1 {
+		return false
+	}
+	sel, ok := ast.Unparen(a.call.Args[0]).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Body" {
+		return false
+	}
+	name := namedType(a.pkg.TypesInfo.TypeOf(sel.X))
+	return name == "net/http.Request" || name == "net/http.Response"
+}
+
+// inLoop reports whether the call runs in the body of a loop of its own function.
+func (a *analysis) inLoop() bool {
+	for i := len(a.path) - 1; i > 0; i-- {
+		switch n := a.path[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		case *ast.BlockStmt:
+			switch loop := a.path[i-1].(type) {
+			case *ast.ForStmt:
+				if loop.Body == n {
+					return true
+				}
+			case *ast.RangeStmt:
+				if loop.Body == n {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// bounded reports whether the function limits what it reads with http.MaxBytesReader or
+// io.LimitReader.
+func bounded(info *types.Info, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if fn := typeutil.StaticCallee(info, call); fn != nil && fn.Pkg() != nil {
+				q := fn.Pkg().Path() + "." + fn.Name()
+				found = found || q == "net/http.MaxBytesReader" || q == "io.LimitReader"
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isHandler reports whether fn has the parameters of an http.HandlerFunc.
+func isHandler(info *types.Info, fn ast.Node) bool {
+	var ft *ast.FuncType
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		ft = fn.Type
+	case *ast.FuncLit:
+		ft = fn.Type
+	}
+	writer, request := false, false
+	for _, field := range ft.Params.List {
+		switch namedType(info.TypeOf(field.Type)) {
+		case "net/http.ResponseWriter":
+			writer = true
+		case "net/http.Request":
+			request = true
+		}
+	}
+	return writer && request
+}
+
+// namedType returns "path.Name" for a (pointer to a) named type.
+func namedType(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+// Sink kinds.
+const (
+	sinkDecode = "decode"
+	sinkWrite  = "write"
+	sinkLines  = "lines"
+	sinkHash   = "hash"
+)
+
+// sinkUse is the single use of the read data.
+type sinkUse struct {
+	kind string
+	name string // "json.Unmarshal"
+	call *ast.CallExpr
+	pkg  string // package of a decoder: "json" or "xml"
+}
+
+// sink finds the statement assigning the read and the only use of the data.
+func (a *analysis) sink(body *ast.BlockStmt) (*ast.AssignStmt, sinkUse) {
+	parent := a.path[len(a.path)-2]
+	assign, ok := parent.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 || assign.Rhs[0] != ast.Expr(a.call) || len(assign.Lhs) != 2 {
+		return nil, sinkUse{}
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok {
+		return nil, sinkUse{}
+	}
+	info := a.pkg.TypesInfo
+	data, ok := info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return nil, sinkUse{}
+	}
+	var uses []*ast.Ident
+	for id, obj := range info.Uses {
+		if obj == data && id.Pos() >= body.Pos() && id.End() <= body.End() {
+			uses = append(uses, id)
+		}
+	}
+	if len(uses) != 1 {
+		return assign, sinkUse{}
+	}
+	return assign, a.classify(uses[0])
+}
+
+// classify names what the data flows into at use.
+func (a *analysis) classify(use *ast.Ident) sinkUse {
+	info := a.pkg.TypesInfo
+	var path []ast.Node
+	ast.Inspect(a.file, func(n ast.Node) bool {
+		if n == nil || n.Pos() > use.Pos() || n.End() < use.End() {
+			return false
+		}
+		path = append(path, n)
+		return true
+	})
+	// Walk up through string(data) conversions to the call consuming the data.
+	var arg ast.Node = use
+	for i := len(path) - 2; i >= 0; i-- {
+		call, ok := path[i].(*ast.CallExpr)
+		if !ok {
+			return sinkUse{}
+		}
+		if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+			arg = call
+			continue
+		}
+		// Callee, unlike StaticCallee, also resolves interface methods such as
+		// http.ResponseWriter.Write.
+		fn, ok := typeutil.Callee(info, call).(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			return sinkUse{}
+		}
+		q := fn.Pkg().Path() + "." + fn.Name()
+		short := fn.Pkg().Name() + "." + fn.Name()
+		direct := len(call.Args) > 0 && call.Args[0] == arg && arg == ast.Node(use)
+		sig := fn.Type().(*types.Signature)
+		switch {
+		case q == "encoding/json.Unmarshal" || q == "encoding/xml.Unmarshal":
+			if direct {
+				return sinkUse{kind: sinkDecode, name: short, call: call, pkg: fn.Pkg().Name()}
+			}
+			return sinkUse{kind: sinkDecode, name: short}
+		case sig.Recv() != nil && fn.Name() == "Write" && len(call.Args) == 1:
+			if direct {
+				return sinkUse{kind: sinkWrite, name: "a Write call", call: call}
+			}
+			return sinkUse{kind: sinkWrite, name: "a Write call"}
+		case q == "strings.Split" || q == "bytes.Split" || q == "strings.Fields" || q == "bytes.Fields" ||
+			q == "strings.SplitN" || q == "bytes.SplitN" || q == "strings.Lines" || q == "bytes.Lines":
+			return sinkUse{kind: sinkLines, name: short}
+		case strings.HasPrefix(fn.Pkg().Path(), "crypto/") && strings.HasPrefix(fn.Name(), "Sum"):
+			return sinkUse{kind: sinkHash, name: short}
+		}
+		return sinkUse{}
+	}
+	return sinkUse{}
+}
+
+func suggestion(rule, name string, sink string) string {
+	var s string
+	switch sink {
+	case sinkDecode:
+		s = "Decode straight from the reader with `json.NewDecoder(r).Decode(&v)` (or `xml.NewDecoder`)"
+	case sinkWrite:
+		s = "Copy the reader to the writer with `io.Copy(w, r)`"
+	case sinkLines:
+		s = "Process the input line by line with `bufio.NewScanner(r)`"
+	case sinkHash:
+		s = "Feed the hash incrementally: `h := sha256.New(); io.Copy(h, r); h.Sum(nil)`"
+	default:
+		s = "Pass the `io.Reader` to the code consuming the data instead of a `[]byte`"
+	}
+	if strings.HasSuffix(name, "ReadFile") && sink != "" {
+		s += ", opening the file with `os.Open` and closing it when done"
+	}
+	if rule == RuleBody {
+		s += "; bound what remains with `http.MaxBytesReader` or `io.LimitReader`"
+	}
+	return s + "."
+}
+
+// fix builds the rewrite of the simple cases:
+//
+//	data, err := io.ReadAll(r)
+//	if err != nil { ... }            // optional, dropped
+//	err = json.Unmarshal(data, &v)   // becomes json.NewDecoder(r).Decode(&v)
+//	w.Write(data)                    // or becomes io.Copy(w, r)
+//
+// The reader must be a plain variable or field, and the data used only by the statement
+// following the read and its error check.
+func (a *analysis) fix(body *ast.BlockStmt, stmt *ast.AssignStmt, sink sinkUse) *Fix {
+	if !strings.HasSuffix(a.name, "ReadAll") || stmt.Tok != token.DEFINE || a.pkg.TypesInfo.Defs[stmt.Lhs[0].(*ast.Ident)] == nil {
+		return nil
+	}
+	reader := a.call.Args[0]
+	if !simple(reader) {
+		return nil
+	}
+	list := blockOf(body, stmt)
+	idx := -1
+	for i, s := range list {
+		if s == ast.Stmt(stmt) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	info := a.pkg.TypesInfo
+	errIdent, ok := stmt.Lhs[1].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	errVar, _ := info.ObjectOf(errIdent).(*types.Var)
+	last := ast.Stmt(stmt)
+	next := idx + 1
+	if next < len(list) {
+		if check, ok := list[next].(*ast.IfStmt); ok && check.Init == nil && isErrCheck(info, check.Cond, errVar) {
+			last = check
+			next++
+		}
+	}
+	// Declarations without values, such as the decoding target, may come before the consumer.
+	for next < len(list) && bareDecl(list[next]) {
+		next++
+	}
+	if next >= len(list) {
+		return nil
+	}
+	consumer := list[next]
+	if sink.call.Pos() < consumer.Pos() || sink.call.End() > consumer.End() {
+		return nil
+	}
+
+	// The read may have declared err; the consumer then declares it.
+	tf := a.pkg.Fset.File(a.file.Pos())
+	src, err := os.ReadFile(tf.Name())
+	if err != nil {
+		return nil
+	}
+	off := func(p token.Pos) int { return tf.Offset(p) }
+	var edits []shared.TextEdit
+	if errVar != nil && info.Defs[errIdent] != nil && errIdent.Name != "_" {
+		for id, obj := range info.Uses {
+			if obj == errVar && (id.Pos() < stmt.Pos() || id.Pos() >= last.End()) {
+				// Used after the read and its check: only a plain assignment can take over.
+				as, ok := consumer.(*ast.AssignStmt)
+				if !ok || as.Tok != token.ASSIGN || !declaresOnly(info, as, errVar) {
+					return nil
+				}
+				edits = append(edits, shared.TextEdit{Start: off(as.TokPos), End: off(as.TokPos) + 1, New: ":="})
+				break
+			}
+		}
+	}
+
+	readerSrc := string(src[off(reader.Pos()):off(reader.End())])
+	var replacement, summary string
+	switch sink.kind {
+	case sinkDecode:
+		target := string(src[off(sink.call.Args[1].Pos()):off(sink.call.Args[1].End())])
+		replacement = fmt.Sprintf("%s.NewDecoder(%s).Decode(%s)", sink.pkg, readerSrc, target)
+		summary = fmt.Sprintf("`%s` + `%s` → `%s.NewDecoder(%s).Decode`", a.name, sink.name, sink.pkg, readerSrc)
+	case sinkWrite:
+		switch c := consumer.(type) {
+		case *ast.ExprStmt:
+		case *ast.AssignStmt:
+			if id, ok := c.Lhs[0].(*ast.Ident); !ok || id.Name != "_" {
+				return nil // io.Copy counts bytes in an int64
+			}
+		default:
+			return nil
+		}
+		writer := sink.call.Fun.(*ast.SelectorExpr).X
+		replacement = fmt.Sprintf("io.Copy(%s, %s)", src[off(writer.Pos()):off(writer.End())], readerSrc)
+		summary = fmt.Sprintf("`%s` + `Write` → `io.Copy`", a.name)
+	default:
+		return nil
+	}
+	edits = append(edits, shared.TextEdit{Start: off(sink.call.Pos()), End: off(sink.call.End()), New: replacement})
+
+	// Drop the read and its error check, whole lines included.
+	start := off(stmt.Pos())
+	for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+		start--
+	}
+	end := off(last.End())
+	if end < len(src) && src[end] == '\n' {
+		end++
+	}
+	edits = append(edits, shared.TextEdit{Start: start, End: end})
+	return &Fix{File: tf.Name(), Edits: edits, Summary: summary}
+}
+
+// simple reports whether e is an identifier or a chain of field selections, which can be
+// evaluated later without side effects.
+func simple(e ast.Expr) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return simple(e.X)
+	}
+	return false
+}
+
+// blockOf returns the statement list directly containing stmt.
+func blockOf(body *ast.BlockStmt, stmt ast.Stmt) []ast.Stmt {
+	var list []ast.Stmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		if list != nil {
+			return false
+		}
+		var stmts []ast.Stmt
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			stmts = n.List
+		case *ast.CaseClause:
+			stmts = n.Body
+		case *ast.CommClause:
+			stmts = n.Body
+		}
+		for _, s := range stmts {
+			if s == stmt {
+				list = stmts
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// bareDecl reports whether stmt is a var declaration without initial values.
+func bareDecl(stmt ast.Stmt) bool {
+	decl, ok := stmt.(*ast.DeclStmt)
+	if !ok {
+		return false
+	}
+	gen, ok := decl.Decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.VAR {
+		return false
+	}
+	for _, spec := range gen.Specs {
+		if vs, ok := spec.(*ast.ValueSpec); !ok || len(vs.Values) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isErrCheck reports whether cond is `err != nil` for the given variable.
+func isErrCheck(info *types.Info, cond ast.Expr, errVar *types.Var) bool {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.NEQ || errVar == nil {
+		return false
+	}
+	id, ok := bin.X.(*ast.Ident)
+	nilID, isNil := bin.Y.(*ast.Ident)
+	return ok && isNil && nilID.Name == "nil" && info.Uses[id] == errVar
+}
+
+// declaresOnly reports whether turning the assignment into a short variable declaration would
+// declare exactly errVar: every other left-hand side must be the blank identifier.
+func declaresOnly(info *types.Info, as *ast.AssignStmt, errVar *types.Var) bool {
+	found := false
+	for _, lhs := range as.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		switch {
+		case !ok:
+			return false
+		case info.Uses[id] == errVar:
+			found = true
+		case id.Name != "_":
+			return false
+		}
+	}
+	return found
+}
+
+// codemod gathers the fixes into one changeset.
+func codemod(reads []Read) (shared.Changeset, int, error) {
+	byFile := make(map[string][]shared.TextEdit)
+	count := 0
+	for _, r := range reads {
+		if r.Fix != nil {
+			byFile[r.Fix.File] = append(byFile[r.Fix.File], r.Fix.Edits...)
+			count++
+		}
+	}
+	changes := make(shared.Changeset)
+	for file, edits := range byFile {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		out, err := shared.ApplyEdits(src, edits)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to rewrite %s: %w", file, err)
+		}
+		changes[file] = out
+	}
+	return changes, count, nil
+}
+
+func render(pattern string, reads []Read, skipped int, applied, lang string) *shared.Report {
+	if reads == nil {
+		reads = []Read{}
+	}
+	rep := &shared.Report{Title: locale.T(lang, "streaming.title", pattern), Lang: lang, Status: shared.StatusPass, Data: reads}
+	if applied != "" {
+		applied += "\n\n"
+	}
+	if len(reads) == 0 {
+		rep.Summary = applied + locale.T(lang, "streaming.none")
+		if skipped > 0 {
+			rep.Summary += "\n\n" + locale.T(lang, "streaming.skipped", skipped)
+		}
+		return rep
+	}
+
+	byRule := make(map[string][]Read)
+	fixable := 0
+	for _, r := range reads {
+		byRule[r.Rule] = append(byRule[r.Rule], r)
+		if r.Fix != nil {
+			fixable++
+		}
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = applied + locale.T(lang, "streaming.found", len(reads))
+	for _, rule := range rules {
+		list := byRule[rule]
+		if len(list) == 0 {
+			continue
+		}
+		sec := rep.Add(fmt.Sprintf("%s (%d)", rule, len(list)), "")
+		sec.Text = descriptions[rule]
+		for _, r := range list {
+			mark := ""
+			if r.Fix != nil {
+				mark = " 🔧"
+			}
+			sec.Items = append(sec.Items, fmt.Sprintf("%s: %s%s\n  - %s", r.Position, r.Message, mark, r.Suggestion))
+		}
+	}
+	var notes []string
+	if skipped > 0 {
+		notes = append(notes, fmt.Sprintf("%d other whole read(s) outside handlers and loops were not reported.", skipped))
+	}
+	if fixable > 0 {
+		notes = append(notes, fmt.Sprintf("💡 Run again with `apply=true` to rewrite the %d read(s) marked 🔧.", fixable))
+	}
+	if len(notes) > 0 {
+		rep.Add("", "").Text = strings.Join(notes, "\n\n")
+	}
+	return rep
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
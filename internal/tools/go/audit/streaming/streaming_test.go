@@ -0,0 +1,149 @@
+package streaming
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const server = `package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type Order struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+func LoadConfig(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func CreateOrder(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var o Order
+	if err := json.Unmarshal(data, &o); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func Proxy(w http.ResponseWriter, r *http.Request) {
+	resp, err := http.Get("http://backend" + r.URL.Path)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	_, err = w.Write(body)
+	if err != nil {
+		return
+	}
+}
+
+func Upload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	w.Write(data)
+}
+
+func Count(paths []string) (int, error) {
+	n := 0
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return 0, err
+		}
+		n += len(strings.Split(string(data), "\n"))
+	}
+	return n, nil
+}
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":        testutil.GoMod("example.com/app"),
+		"app/server.go": server,
+	})
+}
+
+func run(t *testing.T, args Params) string {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeModule(t)
+	out := run(t, Params{Dir: dir})
+
+	wants := []string{
+		"⚠️ Found 4 whole read(s) on hot paths.",
+		"## unbounded-body (2)",
+		"- app/server.go:20:15: io.ReadAll reads the whole input into memory; the result only goes to json.Unmarshal 🔧",
+		"- app/server.go:38:15: io.ReadAll reads the whole input into memory; the result only goes to a Write call 🔧",
+		"## per-request (1)",
+		"- app/server.go:50:15: io.ReadAll reads the whole input into memory; the result only goes to a Write call 🔧\n  - Copy the reader to the writer with `io.Copy(w, r)`.\n",
+		"## in-loop (1)",
+		"- app/server.go:60:16: os.ReadFile reads the whole input into memory; the result only goes to strings.Split\n  - Process the input line by line with `bufio.NewScanner(r)`, opening the file with `os.Open`",
+		"1 other whole read(s) outside handlers and loops were not reported.",
+		"rewrite the 3 read(s) marked 🔧",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := writeModule(t)
+	out := run(t, Params{Dir: dir, Apply: true})
+	if !strings.Contains(out, "✅ Rewrote 3 read(s) to stream across 1 file(s)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "app", "server.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func CreateOrder(w http.ResponseWriter, r *http.Request) {\n\tvar o Order\n\tif err := json.NewDecoder(r.Body).Decode(&o); err != nil {",
+		"defer resp.Body.Close()\n\t_, err = io.Copy(w, resp.Body)",
+		"r.Body = http.MaxBytesReader(w, r.Body, 1<<20)\n\tio.Copy(w, r.Body)\n}",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("rewritten file missing %q:\n%s", want, got)
+		}
+	}
+	// The loop splits lines, which has no codemod.
+	if !strings.Contains(out, "Found 1 whole read(s) on hot paths.") {
+		t.Errorf("unexpected report after rewriting:\n%s", out)
+	}
+}
@@ -0,0 +1,577 @@
+// Package visibility implements the audit_visibility tool, which finds exported symbols that no
+// other package of the module uses and can unexport them, shrinking the API surface before a
+// stable release.
+package visibility
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["audit_visibility"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string   `json:"dir,omitempty" jsonschema:"The absolute module directory to audit. Always pass absolute paths in multi-root workspaces."`
+	Packages     string   `json:"packages,omitempty" jsonschema:"Package pattern to audit (default: ./...). Uses are always searched in the whole module."`
+	OutputFormat string   `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+	Apply        bool     `json:"apply,omitempty" jsonschema:"Unexport the candidates, verified by go vet and rolled back on failure"`
+	Symbols      []string `json:"symbols,omitempty" jsonschema:"With apply, only unexport these symbols, as pkgname.Name or Name (default: every candidate that can be renamed)"`
+}
+
+// Candidate is an exported symbol that no other package of the module uses.
+type Candidate struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // func, type, const, var
+	Position string `json:"position"`
+	Uses     int    `json:"uses"` // uses inside its own package, tests included
+	NewName  string `json:"new_name,omitempty"`
+	Blocked  string `json:"blocked,omitempty"` // why it cannot be renamed automatically
+
+	obj  types.Object
+	pkg  *packages.Package
+	decl *ast.Ident
+	doc  *ast.CommentGroup
+}
+
+// PackageReport lists the candidates of one package.
+type PackageReport struct {
+	Path       string       `json:"package"`
+	Name       string       `json:"name"`
+	Exported   int          `json:"exported"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+// Report is the module-wide result.
+type Report struct {
+	Exported   int              `json:"exported"`
+	Candidates int              `json:"candidates"`
+	Packages   []*PackageReport `json:"packages"`
+}
+
+// Handler handles the audit_visibility tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	targets, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	audited := make(map[string]bool)
+	for _, p := range targets {
+		audited[p.PkgPath] = true
+	}
+	all, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	report := Analyze(absDir, all, audited)
+
+	if !args.Apply {
+		var next []shared.NextTool
+		if renamable(report) {
+			next = append(next, shared.NextTool{
+				Tool:      "audit_visibility",
+				Arguments: map[string]any{"dir": absDir, "packages": pattern, "apply": true},
+				Reason:    "unexport the candidates that can be renamed, verified by go vet",
+			})
+		}
+		rep := render(pattern, report, locale.FromRequest(req))
+		out, err := rep.Render(format)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		return shared.WithNextTools(textResult(out), next...), nil, nil
+	}
+
+	var chosen []*Candidate
+	for _, pr := range report.Packages {
+		for _, c := range pr.Candidates {
+			if c.Blocked == "" && (len(args.Symbols) == 0 || slices.Contains(args.Symbols, c.Name) || slices.Contains(args.Symbols, pr.Name+"."+c.Name)) {
+				chosen = append(chosen, c)
+			}
+		}
+	}
+	if len(chosen) == 0 {
+		return errorResult("no candidate to unexport; run without apply to see the report"), nil, nil
+	}
+	changes, err := Unexport(all, chosen)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if err := changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ Unexported %d symbol(s) in %d file(s); `go vet ./...` passes.\n\n", len(chosen), len(changes))
+	for _, c := range chosen {
+		fmt.Fprintf(&sb, "- %s: `%s` → `%s`\n", c.Position, c.Name, c.NewName)
+	}
+	sb.WriteString("\nCode outside this module that used these symbols no longer compiles; note the change in the release notes.\n")
+	return textResult(sb.String()), nil, nil
+}
+
+// renamable reports whether apply would unexport anything.
+func renamable(report *Report) bool {
+	for _, pr := range report.Packages {
+		for _, c := range pr.Candidates {
+			if c.Blocked == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Analyze finds the exported package-level symbols of the audited packages that no other package
+// in all uses. all must include every package of the module with tests: a use from an external
+// test package (package foo_test) counts as a use from outside. Main packages, declarations in
+// test and generated files, and types embedded in structs are not reported.
+func Analyze(root string, all []*packages.Package, audited map[string]bool) *Report {
+	report := &Report{Packages: []*PackageReport{}}
+	byPos := make(map[token.Position]*Candidate)
+	var reports []*PackageReport
+
+	for _, pkg := range all {
+		if !audited[pkg.PkgPath] || pkg.ID != pkg.PkgPath || pkg.Name == "main" || pkg.Types == nil {
+			continue
+		}
+		pr := &PackageReport{Path: pkg.PkgPath, Name: pkg.Name, Candidates: []*Candidate{}}
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.File(file.Pos()).Name()
+			if strings.HasSuffix(filename, "_test.go") || ast.IsGenerated(file) {
+				continue
+			}
+			for _, c := range declared(file) {
+				obj := pkg.TypesInfo.Defs[c.decl]
+				if obj == nil || obj.Parent() != pkg.Types.Scope() {
+					continue
+				}
+				c.obj, c.pkg = obj, pkg
+				c.Position = shared.RelPosition(root, pkg.Fset.Position(c.decl.Pos()))
+				pr.Exported++
+				byPos[pkg.Fset.Position(obj.Pos())] = c
+				pr.Candidates = append(pr.Candidates, c)
+			}
+		}
+		reports = append(reports, pr)
+	}
+
+	// Uses are counted once per source position, since a file belongs to several package variants.
+	outside := make(map[*Candidate]bool)
+	seen := make(map[token.Position]bool)
+	for _, pkg := range all {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if obj == nil || obj.Pkg() == nil {
+				continue
+			}
+			c := byPos[pkg.Fset.Position(obj.Pos())]
+			if c == nil {
+				continue
+			}
+			if pkg.Types.Path() != obj.Pkg().Path() {
+				outside[c] = true
+				continue
+			}
+			if pos := pkg.Fset.Position(id.Pos()); !seen[pos] {
+				seen[pos] = true
+				c.Uses++
+			}
+		}
+		for _, obj := range pkg.TypesInfo.Defs {
+			if v, ok := obj.(*types.Var); ok && v.Embedded() {
+				if named, ok := types.Unalias(derefType(v.Type())).(*types.Named); ok {
+					if c := byPos[pkg.Fset.Position(named.Obj().Pos())]; c != nil {
+						outside[c] = true // renaming the type would rename the promoted field
+					}
+				}
+			}
+		}
+	}
+
+	for _, pr := range reports {
+		pr.Candidates = slices.DeleteFunc(pr.Candidates, func(c *Candidate) bool { return outside[c] })
+		pr.Candidates = withoutExposed(pr.Candidates)
+		for _, c := range pr.Candidates {
+			c.NewName = unexportName(c.Name)
+			c.Blocked = blocked(all, c)
+		}
+		report.Exported += pr.Exported
+		report.Candidates += len(pr.Candidates)
+		if len(pr.Candidates) > 0 {
+			report.Packages = append(report.Packages, pr)
+		}
+	}
+	sort.Slice(report.Packages, func(i, j int) bool { return report.Packages[i].Path < report.Packages[j].Path })
+	return report
+}
+
+// withoutExposed drops candidate types that the signature of an exported symbol staying exported
+// mentions: callers outside the package see them through that API even if they never name them.
+// Dropping a type can expose others, so it repeats until nothing changes.
+func withoutExposed(candidates []*Candidate) []*Candidate {
+	if len(candidates) == 0 {
+		return candidates
+	}
+	scope := candidates[0].obj.Pkg().Scope()
+	for {
+		isCandidate := make(map[types.Object]bool)
+		for _, c := range candidates {
+			isCandidate[c.obj] = true
+		}
+		exposed := make(map[types.Object]bool)
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if !obj.Exported() || isCandidate[obj] {
+				continue
+			}
+			w := &walker{owner: obj, exposed: exposed, seen: make(map[types.Type]bool)}
+			if tn, ok := obj.(*types.TypeName); ok {
+				w.walk(tn.Type().Underlying())
+				if named, ok := tn.Type().(*types.Named); ok {
+					for m := range named.Methods() {
+						if m.Exported() {
+							w.walk(m.Type())
+						}
+					}
+				}
+				continue
+			}
+			w.walk(obj.Type())
+		}
+		kept := slices.DeleteFunc(slices.Clone(candidates), func(c *Candidate) bool { return exposed[c.obj] })
+		if len(kept) == len(candidates) {
+			return candidates
+		}
+		candidates = kept
+	}
+}
+
+// walker marks the named types a type mentions through its exported parts.
+type walker struct {
+	owner   types.Object
+	exposed map[types.Object]bool
+	seen    map[types.Type]bool
+}
+
+func (w *walker) walk(t types.Type) {
+	if t == nil || w.seen[t] {
+		return
+	}
+	w.seen[t] = true
+	switch t := t.(type) {
+	case *types.Alias:
+		w.walk(types.Unalias(t))
+	case *types.Named:
+		if t.Obj() != w.owner {
+			w.exposed[t.Obj()] = true
+		}
+		for arg := range t.TypeArgs().Types() {
+			w.walk(arg)
+		}
+	case *types.Pointer:
+		w.walk(t.Elem())
+	case *types.Slice:
+		w.walk(t.Elem())
+	case *types.Array:
+		w.walk(t.Elem())
+	case *types.Chan:
+		w.walk(t.Elem())
+	case *types.Map:
+		w.walk(t.Key())
+		w.walk(t.Elem())
+	case *types.Signature:
+		for v := range t.Params().Variables() {
+			w.walk(v.Type())
+		}
+		for v := range t.Results().Variables() {
+			w.walk(v.Type())
+		}
+	case *types.Struct:
+		for f := range t.Fields() {
+			if f.Exported() {
+				w.walk(f.Type())
+			}
+		}
+	case *types.Interface:
+		for m := range t.Methods() {
+			if m.Exported() {
+				w.walk(m.Type())
+			}
+		}
+	}
+}
+
+// declared returns the exported package-level names declared in file.
+func declared(file *ast.File) []*Candidate {
+	var out []*Candidate
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.IsExported() && !hasDirective(d.Doc, "//export ") {
+				out = append(out, &Candidate{Name: d.Name.Name, Kind: "func", decl: d.Name, doc: d.Doc})
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						out = append(out, &Candidate{Name: s.Name.Name, Kind: "type", decl: s.Name, doc: docOf(s.Doc, d)})
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							out = append(out, &Candidate{Name: name.Name, Kind: d.Tok.String(), decl: name, doc: docOf(s.Doc, d)})
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func docOf(own *ast.CommentGroup, d *ast.GenDecl) *ast.CommentGroup {
+	if own != nil || d.Lparen.IsValid() {
+		return own
+	}
+	return d.Doc
+}
+
+func hasDirective(doc *ast.CommentGroup, prefix string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// unexportName lowercases the leading capital of name, or its leading initialism
+// (URL → url, HTTPClient → httpClient, ID → id).
+func unexportName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n-- // the last capital starts the next word
+	}
+	for i := range max(n, 1) {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// blocked explains why c cannot be renamed to its new name, or returns "".
+func blocked(all []*packages.Package, c *Candidate) string {
+	name := c.NewName
+	switch {
+	case token.IsKeyword(name):
+		return fmt.Sprintf("`%s` is a keyword", name)
+	case types.Universe.Lookup(name) != nil:
+		return fmt.Sprintf("`%s` would shadow the predeclared identifier", name)
+	}
+	path := c.obj.Pkg().Path()
+	for _, pkg := range all {
+		if pkg.Types == nil || pkg.Types.Path() != path {
+			continue
+		}
+		if obj := pkg.Types.Scope().Lookup(name); obj != nil {
+			return fmt.Sprintf("`%s` is already declared at %s", name, filepath.Base(pkg.Fset.Position(obj.Pos()).String()))
+		}
+		for _, file := range pkg.Syntax {
+			for _, imp := range file.Imports {
+				if importedName(pkg, imp) == name {
+					return fmt.Sprintf("`%s` is the name of an import in %s", name, filepath.Base(pkg.Fset.Position(file.Pos()).Filename))
+				}
+			}
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if pkg.Fset.Position(obj.Pos()) != pkg.Fset.Position(c.obj.Pos()) {
+				continue
+			}
+			scope := pkg.Types.Scope().Innermost(id.Pos())
+			if scope == nil {
+				continue
+			}
+			if _, other := scope.LookupParent(name, id.Pos()); other != nil {
+				return fmt.Sprintf("a local `%s` shadows it at %s", name, filepath.Base(pkg.Fset.Position(id.Pos()).String()))
+			}
+		}
+	}
+	return ""
+}
+
+func importedName(pkg *packages.Package, imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	if obj, ok := pkg.TypesInfo.Implicits[imp].(*types.PkgName); ok {
+		return obj.Name()
+	}
+	return ""
+}
+
+// Unexport renames the candidates, their uses in every package variant, and the leading word of
+// their doc comments.
+func Unexport(all []*packages.Package, candidates []*Candidate) (shared.Changeset, error) {
+	byPos := make(map[token.Position]*Candidate)
+	for _, c := range candidates {
+		byPos[c.pkg.Fset.Position(c.obj.Pos())] = c
+	}
+	edits := make(map[string]map[int]shared.TextEdit)
+	add := func(fset *token.FileSet, pos token.Pos, end int, text string) {
+		p := fset.Position(pos)
+		if edits[p.Filename] == nil {
+			edits[p.Filename] = make(map[int]shared.TextEdit)
+		}
+		edits[p.Filename][p.Offset] = shared.TextEdit{Start: p.Offset, End: p.Offset + end, New: text}
+	}
+	for _, c := range candidates {
+		add(c.pkg.Fset, c.decl.Pos(), len(c.Name), c.NewName)
+		if c.doc != nil && len(c.doc.List) > 0 {
+			first := c.doc.List[0]
+			if strings.HasPrefix(first.Text, "// "+c.Name+" ") {
+				add(c.pkg.Fset, first.Pos()+3, len(c.Name), c.NewName)
+			}
+		}
+	}
+	for _, pkg := range all {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if obj == nil {
+				continue
+			}
+			if c := byPos[pkg.Fset.Position(obj.Pos())]; c != nil {
+				add(pkg.Fset, id.Pos(), len(c.Name), c.NewName)
+			}
+		}
+	}
+
+	changes := make(shared.Changeset)
+	for filename, byStart := range edits {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		var list []shared.TextEdit
+		for _, e := range byStart {
+			list = append(list, e)
+		}
+		out, err := shared.ApplyEdits(src, list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		changes[filename] = out
+	}
+	return changes, nil
+}
+
+func render(pattern string, report *Report, lang string) *shared.Report {
+	rep := &shared.Report{Title: locale.T(lang, "visibility.title", pattern), Lang: lang, Status: shared.StatusPass, Data: report}
+	if report.Candidates == 0 {
+		rep.Summary = locale.T(lang, "visibility.none")
+		return rep
+	}
+	rep.Status = shared.StatusWarn
+	rep.Summary = locale.T(lang, "visibility.found", report.Candidates, report.Exported)
+	for _, pr := range report.Packages {
+		table := &shared.Table{Columns: []string{"Symbol", "Kind", "Position", "Uses in package", "Proposal"}}
+		for _, c := range pr.Candidates {
+			proposal := fmt.Sprintf("rename to `%s`", c.NewName)
+			switch {
+			case c.Blocked != "":
+				proposal = "⚠️ rename by hand: " + c.Blocked
+			case c.Uses == 0:
+				proposal = "unused: delete it, or rename to `" + c.NewName + "`"
+			}
+			table.AddRow("`"+c.Name+"`", c.Kind, c.Position, strconv.Itoa(c.Uses), proposal)
+		}
+		rep.Add("`"+pr.Path+"`", "").Table = table
+	}
+	rep.Add("", "").Text = "Only uses inside this module are known: keep symbols that other modules import, and those meant as API. " +
+		"Call again with `apply=true` (optionally with `symbols`) to unexport them."
+	return rep
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
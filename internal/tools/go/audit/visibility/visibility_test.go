@@ -0,0 +1,153 @@
+package visibility
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const libSrc = `// Package lib is a library.
+package lib
+
+import "strings"
+
+// Client talks to the server.
+type Client struct{ URL string }
+
+// New returns a Client.
+func New() *Client { return &Client{} }
+
+// Helper is only used here.
+func Helper(s string) string { return strings.ToLower(s) }
+
+// MaxRetries caps retries.
+const MaxRetries = 3
+
+func (c *Client) Do() string { return Helper(c.URL) + string(rune(MaxRetries)) }
+
+// HTTPTimeout is unused.
+var HTTPTimeout = 10
+
+type Base struct{}
+
+type Wrapper struct{ Base }
+
+func Strings() {}
+
+func Len() int { return 0 }
+
+func Count() int { return 1 }
+
+func use() int {
+	count := 2
+	return count + Count()
+}
+
+func ForTests() {}
+`
+
+const libTestSrc = `package lib_test
+
+import (
+	"testing"
+
+	"example.com/app/lib"
+)
+
+func TestForTests(t *testing.T) { lib.ForTests() }
+`
+
+const mainSrc = `package main
+
+import "example.com/app/lib"
+
+func main() { println(lib.New().Do()) }
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.24\n",
+		"lib/lib.go":      libSrc,
+		"lib/lib_test.go": libTestSrc,
+		"main.go":         mainSrc,
+	})
+}
+
+func TestHandler_Report(t *testing.T) {
+	dir := setup(t)
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	for _, want := range []string{
+		"**7 of 11 exported symbol(s)** are not used outside their package.",
+		"| `Helper` | func | lib/lib.go:13:6 | 1 | rename to `helper` |",
+		"| `MaxRetries` | const | lib/lib.go:16:7 | 1 | rename to `maxRetries` |",
+		"| `HTTPTimeout` | var | lib/lib.go:21:5 | 0 | unused: delete it, or rename to `httpTimeout` |",
+		"| `Wrapper` | type |",
+		"| `Strings` | func | lib/lib.go:27:6 | 0 | ⚠️ rename by hand: `strings` is the name of an import in lib.go |",
+		"| `Len` | func | lib/lib.go:29:6 | 0 | ⚠️ rename by hand: `len` would shadow the predeclared identifier |",
+		"| `Count` | func | lib/lib.go:31:6 | 1 | ⚠️ rename by hand: a local `count` shadows it at lib.go:35:17 |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	// Used from another package, exposed by New, embedded, or used by an external test.
+	for _, name := range []string{"New", "Client", "Base", "ForTests"} {
+		if strings.Contains(out, "| `"+name+"` |") {
+			t.Errorf("%s should not be a candidate:\n%s", name, out)
+		}
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t)
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Apply: true, Symbols: []string{"Helper", "lib.MaxRetries", "Len"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError || !strings.Contains(out, "Unexported 2 symbol(s) in 1 file(s)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "lib", "lib.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"// helper is only used here.\nfunc helper(s string) string",
+		"// maxRetries caps retries.\nconst maxRetries = 3",
+		"return helper(c.URL) + string(rune(maxRetries))",
+		"func Len() int",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in lib.go, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestUnexportName(t *testing.T) {
+	tests := map[string]string{
+		"Helper":     "helper",
+		"URL":        "url",
+		"HTTPClient": "httpClient",
+		"ID":         "id",
+		"X":          "x",
+		"UserID":     "userID",
+	}
+	for in, want := range tests {
+		if got := unexportName(in); got != want {
+			t.Errorf("unexportName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
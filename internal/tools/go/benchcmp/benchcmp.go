@@ -0,0 +1,386 @@
+// Package benchcmp implements the bench_compare tool, which runs a benchmark set on two revisions
+// of a module and reports the statistically significant differences.
+package benchcmp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["bench_compare"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory inside a git repository. Always pass absolute paths in multi-root workspaces."`
+	Base         string `json:"base,omitempty" jsonschema:"The baseline git ref (default HEAD)"`
+	Head         string `json:"head,omitempty" jsonschema:"The git ref to compare against the baseline (default: the working tree, including uncommitted changes)"`
+	Bench        string `json:"bench,omitempty" jsonschema:"Regular expression selecting the benchmarks to run (default '.')"`
+	Packages     string `json:"packages,omitempty" jsonschema:"Package pattern (default './...')"`
+	Count        int    `json:"count,omitempty" jsonschema:"Runs per revision (default 6, min 4, max 20). More runs detect smaller changes."`
+	Benchtime    string `json:"benchtime,omitempty" jsonschema:"Value for -benchtime, e.g. '100ms' or '1000x' (default: go test's default of 1s)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+const (
+	defaultCount = 6
+	minCount     = 4
+	maxCount     = 20
+	// alpha is the significance level; differences with a higher p-value are reported as noise.
+	alpha = 0.05
+)
+
+// Delta is the comparison of one metric of one benchmark.
+type Delta struct {
+	Benchmark string  `json:"benchmark"`
+	Unit      string  `json:"unit"`
+	Base      float64 `json:"base_median"`
+	Head      float64 `json:"head_median"`
+	Change    float64 `json:"change_percent"`
+	P         float64 `json:"p_value"`
+	Verdict   string  `json:"verdict"` // "improved", "regressed" or "no change"
+	Samples   [2]int  `json:"samples"` // base and head sample counts
+}
+
+// Verdicts.
+const (
+	Improved  = "improved"
+	Regressed = "regressed"
+	NoChange  = "no change"
+)
+
+// Comparison is the result of a run.
+type Comparison struct {
+	Base    string   `json:"base"`
+	Head    string   `json:"head"`
+	Count   int      `json:"count"`
+	Deltas  []Delta  `json:"deltas"`
+	Missing []string `json:"missing,omitempty"` // benchmarks that ran on only one side
+}
+
+// Handler handles the bench_compare tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Base == "" {
+		args.Base = "HEAD"
+	}
+	if args.Bench == "" {
+		args.Bench = "."
+	}
+	if args.Packages == "" {
+		args.Packages = "./..."
+	}
+	if args.Count == 0 {
+		args.Count = defaultCount
+	}
+	args.Count = min(max(args.Count, minCount), maxCount)
+
+	cmp, err := Compare(ctx, absDir, args, shared.NewProgress(ctx, req))
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var output string
+	if format == shared.FormatJSON {
+		bytes, err := json.MarshalIndent(cmp, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(cmp)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Compare checks out the refs into temporary worktrees and runs the benchmarks on both. Runs
+// alternate between the revisions so that drift in machine load affects both sides alike; each
+// run is reported to progress.
+func Compare(ctx context.Context, dir string, args Params, progress *shared.Progress) (*Comparison, error) {
+	prefix, err := git(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %s", dir, strings.TrimSpace(prefix))
+	}
+	prefix = strings.TrimSpace(prefix)
+
+	baseDir, cleanup, err := worktree(ctx, dir, args.Base)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	baseDir = filepath.Join(baseDir, prefix)
+
+	headDir, head := dir, args.Head
+	if head == "" {
+		head = "working tree"
+	} else {
+		wt, cleanup, err := worktree(ctx, dir, args.Head)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		headDir = filepath.Join(wt, prefix)
+	}
+
+	goArgs := []string{"test", "-run", "^$", "-bench", args.Bench, "-benchmem", "-count", "1"}
+	if args.Benchtime != "" {
+		goArgs = append(goArgs, "-benchtime", args.Benchtime)
+	}
+	goArgs = append(goArgs, args.Packages)
+
+	base, headSamples := make(samples), make(samples)
+	for i := 0; i < args.Count; i++ {
+		for _, side := range []struct {
+			dir string
+			ref string
+			s   samples
+		}{{baseDir, args.Base, base}, {headDir, head, headSamples}} {
+			progress.Step(fmt.Sprintf("Run %d/%d on %s", i+1, args.Count, side.ref))
+			cmd := exec.CommandContext(ctx, "go", goArgs...)
+			cmd.Dir = side.dir
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				return nil, fmt.Errorf("benchmarks failed on %s:\n%s", side.ref, strings.TrimSpace(string(out)))
+			}
+			side.s.parse(string(out))
+		}
+	}
+	if len(base) == 0 && len(headSamples) == 0 {
+		return nil, fmt.Errorf("no benchmarks matched %q in %s", args.Bench, args.Packages)
+	}
+	return &Comparison{Base: args.Base, Head: head, Count: args.Count, Deltas: diff(base, headSamples), Missing: missing(base, headSamples)}, nil
+}
+
+// worktree checks out ref into a temporary worktree of the repository at dir.
+func worktree(ctx context.Context, dir, ref string) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "godoctor-bench-*")
+	if err != nil {
+		return "", nil, err
+	}
+	tree := filepath.Join(tmp, "tree")
+	cleanup := func() {
+		_, _ = git(context.Background(), dir, "worktree", "remove", "--force", tree)
+		_ = os.RemoveAll(tmp)
+	}
+	if out, err := git(ctx, dir, "worktree", "add", "--detach", tree, ref); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to check out %s: %s", ref, strings.TrimSpace(out))
+	}
+	return tree, cleanup, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// samples maps a benchmark key ("pkg.BenchmarkName") and unit to the measured values.
+type samples map[string]map[string][]float64
+
+// parse reads benchmark result lines from go test output. The -N GOMAXPROCS suffix is kept, so
+// runs with different -cpu values are compared separately.
+func (s samples) parse(out string) {
+	pkg := ""
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := fields[0]
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			if s[name] == nil {
+				s[name] = make(map[string][]float64)
+			}
+			s[name][fields[i+1]] = append(s[name][fields[i+1]], v)
+		}
+	}
+}
+
+// diff compares every metric measured on both sides.
+func diff(base, head samples) []Delta {
+	var out []Delta
+	for name, units := range base {
+		for unit, b := range units {
+			h := head[name][unit]
+			if len(h) == 0 {
+				continue
+			}
+			d := Delta{Benchmark: name, Unit: unit, Base: median(b), Head: median(h), Samples: [2]int{len(b), len(h)}}
+			if d.Base != 0 {
+				d.Change = (d.Head - d.Base) / d.Base * 100
+			}
+			d.P = MannWhitney(b, h)
+			d.Verdict = NoChange
+			if d.P <= alpha && d.Head != d.Base {
+				better := d.Head < d.Base
+				if higherIsBetter(unit) {
+					better = !better
+				}
+				d.Verdict = Regressed
+				if better {
+					d.Verdict = Improved
+				}
+			}
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Benchmark != out[j].Benchmark {
+			return out[i].Benchmark < out[j].Benchmark
+		}
+		return unitRank(out[i].Unit) < unitRank(out[j].Unit)
+	})
+	return out
+}
+
+func missing(base, head samples) []string {
+	var out []string
+	for name := range base {
+		if head[name] == nil {
+			out = append(out, name+" (removed)")
+		}
+	}
+	for name := range head {
+		if base[name] == nil {
+			out = append(out, name+" (new)")
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// higherIsBetter reports whether larger values of unit are better, as for throughput (MB/s).
+func higherIsBetter(unit string) bool { return strings.HasSuffix(unit, "/s") }
+
+func unitRank(unit string) string {
+	switch unit {
+	case "ns/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
+
+func median(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+func render(c *Comparison) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Benchmark Comparison: %s → %s\n\n", c.Base, c.Head)
+	var improved, regressed int
+	for _, d := range c.Deltas {
+		switch d.Verdict {
+		case Improved:
+			improved++
+		case Regressed:
+			regressed++
+		}
+	}
+	switch {
+	case regressed > 0:
+		fmt.Fprintf(&sb, "**Verdict:** ❌ %d regression(s), %d improvement(s)\n\n", regressed, improved)
+	case improved > 0:
+		fmt.Fprintf(&sb, "**Verdict:** ✅ %d improvement(s), no regressions\n\n", improved)
+	default:
+		sb.WriteString("**Verdict:** ➖ no statistically significant change\n\n")
+	}
+
+	icons := map[string]string{Improved: "✅", Regressed: "❌", NoChange: "~"}
+	sb.WriteString("| Benchmark | Unit | Base | Head | Change | p |\n| :--- | :--- | ---: | ---: | ---: | ---: |\n")
+	for _, d := range c.Deltas {
+		change := "~"
+		if d.Verdict != NoChange {
+			change = fmt.Sprintf("%s %+.1f%%", icons[d.Verdict], d.Change)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %.3f |\n", d.Benchmark, d.Unit, formatValue(d.Base), formatValue(d.Head), change, d.P)
+	}
+	if len(c.Missing) > 0 {
+		fmt.Fprintf(&sb, "\nNot compared (ran on one side only): %s\n", strings.Join(c.Missing, ", "))
+	}
+	fmt.Fprintf(&sb, "\nMedians of %d alternating runs per revision. Changes are significant when the Mann-Whitney U test gives p ≤ %.2f; `~` marks differences indistinguishable from noise. Increase `count` to detect smaller changes.\n", c.Count, alpha)
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package benchcmp
+
+import (
+	"context"
+	"math"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const benchSrc = `package sink
+
+import "testing"
+
+var Sink []byte
+
+func BenchmarkAlloc(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Sink = make([]byte, SIZE)
+	}
+}
+`
+
+func TestHandler(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		testutil.WriteFiles(t, dir, map[string]string{name: content})
+	}
+	write("go.mod", testutil.GoMod("example.com/sink"))
+	// Zero-length slices do not allocate; 64-byte slices stored in a global do.
+	write("sink_test.go", strings.Replace(benchSrc, "SIZE", "0", 1))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "base"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write("sink_test.go", strings.Replace(benchSrc, "SIZE", "64", 1))
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Count: 4, Benchtime: "100x"})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	for _, want := range []string{
+		"# Benchmark Comparison: HEAD → working tree",
+		"**Verdict:** ❌",
+		"| example.com/sink.BenchmarkAlloc",
+		"| allocs/op | 0 | 1 | ❌ ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		// Complete separation of 5 and 5 samples: 2 of the C(10,5) = 252 arrangements are as extreme.
+		{"separated", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{"interleaved", []float64{1, 3, 5, 7}, []float64{2, 4, 6, 8}, 0.686},
+		{"identical", []float64{5, 5, 5}, []float64{5, 5, 5}, 1},
+	}
+	for _, tt := range tests {
+		if got := MannWhitney(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("%s: MannWhitney = %.4f, want %.4f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	s := make(samples)
+	s.parse(`goos: linux
+pkg: example.com/a
+BenchmarkX-8   	 1000	      1234 ns/op	      56 B/op	       2 allocs/op
+BenchmarkY-8   	  500	      2000 ns/op	  12.50 MB/s
+PASS
+pkg: example.com/b
+BenchmarkX-8   	 1000	      99 ns/op
+`)
+	if got := s["example.com/a.BenchmarkX-8"]["allocs/op"]; len(got) != 1 || got[0] != 2 {
+		t.Errorf("allocs/op = %v", got)
+	}
+	if got := s["example.com/a.BenchmarkY-8"]["MB/s"]; len(got) != 1 || got[0] != 12.5 {
+		t.Errorf("MB/s = %v", got)
+	}
+	if got := s["example.com/b.BenchmarkX-8"]["ns/op"]; len(got) != 1 || got[0] != 99 {
+		t.Errorf("ns/op = %v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package benchcmp
+
+import (
+	"math"
+	"sort"
+)
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test for samples a and b: the
+// probability of seeing a difference in ranks at least this large if both came from the same
+// distribution. Like benchstat, it makes no assumption about the shape of the distribution, which
+// suits benchmark timings with their long tails. Small samples without ties use the exact
+// distribution of U; otherwise the normal approximation with a tie correction is used.
+func MannWhitney(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type obs struct {
+		v     float64
+		first bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Rank with ties sharing their average rank, and collect tie sizes for the correction.
+	var r1, tieSum float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+	u := r1 - float64(n1*(n1+1))/2
+
+	if !ties && n1 <= 50 && n2 <= 50 {
+		return exactP(u, n1, n2)
+	}
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance == 0 {
+		// Every value is identical.
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactP computes the two-sided p-value of U from its exact null distribution, counting the
+// arrangements of n1 and n2 observations that give each value of U.
+func exactP(u float64, n1, n2 int) float64 {
+	maxU := n1 * n2
+	// counts[i][j][k]: arrangements of i and j observations with U = k, built up one observation
+	// at a time. Only the previous row over i is needed.
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				// The largest observation is either from the first sample, beating all j of the
+				// second, or from the second sample.
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+	dist := prev[n2]
+	var total, lower, upper float64
+	for k, c := range dist {
+		total += c
+		if float64(k) <= u {
+			lower += c
+		}
+		if float64(k) >= u {
+			upper += c
+		}
+	}
+	return math.Min(1, 2*math.Min(lower, upper)/total)
+}
@@ -0,0 +1,540 @@
+// Package contextpack implements the build_context tool, which assembles the code, API docs and
+// history relevant to a described task into ordered chunks that fit a token budget.
+package contextpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/godoc"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["build_context"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Task         string `json:"task" jsonschema:"The task in a sentence or two, naming the behavior, types or functions involved"`
+	Budget       int    `json:"budget,omitempty" jsonschema:"Token budget for the bundle (default 8000, max 50000)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+const (
+	defaultBudget = 8000
+	maxBudget     = 50000
+	// wholeFileLines is the size up to which a relevant file is included whole rather than as
+	// its matching declarations.
+	wholeFileLines = 120
+	docFiles       = 3
+	maxCommits     = 8
+	commitScan     = 300
+)
+
+// Budget shares for API docs and commits. Whatever they leave unused goes to code.
+const (
+	docShare    = 0.2
+	commitShare = 0.1
+)
+
+// Chunk kinds.
+const (
+	KindCode    = "code"
+	KindDocs    = "docs"
+	KindCommits = "commits"
+)
+
+// Chunk is one piece of the bundle.
+type Chunk struct {
+	Kind    string   `json:"kind"`
+	Title   string   `json:"title"`
+	Score   int      `json:"score,omitempty"`
+	Matches []string `json:"matches,omitempty"`
+	Tokens  int      `json:"tokens"`
+	Content string   `json:"content"`
+}
+
+// Pack is the assembled context.
+type Pack struct {
+	Task    string   `json:"task"`
+	Terms   []string `json:"terms"`
+	Budget  int      `json:"budget"`
+	Used    int      `json:"used"`
+	Chunks  []Chunk  `json:"chunks"`
+	Omitted []string `json:"omitted,omitempty"` // relevant files that did not fit
+}
+
+// Handler handles the build_context tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if strings.TrimSpace(args.Task) == "" {
+		return errorResult("task cannot be empty"), nil, nil
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Budget <= 0 {
+		args.Budget = defaultBudget
+	}
+	args.Budget = min(args.Budget, maxBudget)
+
+	pack, err := Build(ctx, absDir, args.Task, args.Budget)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var output string
+	if format == shared.FormatJSON {
+		bytes, err := json.MarshalIndent(pack, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(pack)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Build ranks the module's files against the task, then fills the budget with the best code
+// chunks, trimmed docs for the APIs those files use, and the commits that touched them.
+func Build(ctx context.Context, dir, task string, budget int) (*Pack, error) {
+	terms := Terms(task)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no searchable terms in task %q; name the behavior, types or functions involved", task)
+	}
+	files, err := rankFiles(dir, terms)
+	if err != nil {
+		return nil, err
+	}
+	pack := &Pack{Task: task, Terms: terms, Budget: budget}
+	if len(files) == 0 {
+		return pack, nil
+	}
+
+	var top []string
+	for _, f := range files[:min(docFiles, len(files))] {
+		top = append(top, f.path)
+	}
+	docs := docsChunk(ctx, dir, top, int(float64(budget)*docShare))
+	commits := commitsChunk(ctx, dir, terms, files, int(float64(budget)*commitShare))
+
+	remaining := budget - docs.Tokens - commits.Tokens
+	for _, f := range files {
+		c := f.chunk(dir)
+		if c.Tokens > remaining {
+			pack.Omitted = append(pack.Omitted, c.Title)
+			continue
+		}
+		pack.Chunks = append(pack.Chunks, c)
+		remaining -= c.Tokens
+	}
+	for _, c := range []Chunk{docs, commits} {
+		if c.Content != "" {
+			pack.Chunks = append(pack.Chunks, c)
+		}
+	}
+	for _, c := range pack.Chunks {
+		pack.Used += c.Tokens
+	}
+	return pack, nil
+}
+
+// estimateTokens approximates the token count of text at four bytes per token, which is close
+// for Go source and English prose.
+func estimateTokens(text string) int { return (len(text) + 3) / 4 }
+
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true, "from": true,
+	"into": true, "when": true, "should": true, "must": true, "add": true, "fix": true, "make": true,
+	"use": true, "using": true, "new": true, "support": true, "code": true, "file": true, "function": true,
+	"func": true, "method": true, "are": true, "not": true, "can": true, "all": true, "any": true,
+	"its": true, "but": true, "have": true, "has": true, "does": true, "instead": true, "also": true,
+}
+
+// Terms extracts the search terms of a task: lowercased words and the parts of identifiers
+// ("parseConfig" also yields "parse" and "config"), without stopwords or words under three letters.
+func Terms(task string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(w string) {
+		w = strings.ToLower(w)
+		if len(w) < 3 || stopwords[w] || seen[w] {
+			return
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	for _, word := range strings.FieldsFunc(task, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }) {
+		add(word)
+		for _, part := range splitIdent(word) {
+			add(part)
+		}
+	}
+	return out
+}
+
+// splitIdent splits an identifier at underscores and case changes: "HTTPServerName" yields
+// "HTTP", "Server" and "Name".
+func splitIdent(id string) []string {
+	var parts []string
+	for _, seg := range strings.Split(id, "_") {
+		runes := []rune(seg)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// rankedFile is a Go file scored against the task terms.
+type rankedFile struct {
+	path    string
+	src     []byte
+	lines   int
+	score   int
+	matches map[string]bool
+	decls   []rankedDecl
+}
+
+type rankedDecl struct {
+	start, end int // byte offsets, including the doc comment
+	score      int
+}
+
+var skippedDirs = map[string]bool{".git": true, "vendor": true, "testdata": true, "node_modules": true}
+
+// rankFiles scores every Go file in the module. A declaration scores 5 per term in its name
+// (10 if the whole name is a term), 1 per term in its doc comment, and 1 per term in its body; a
+// file scores the sum of its declarations plus 3 per term in its path. Test files count half, so
+// implementation comes first.
+func rankFiles(root string, terms []string) ([]*rankedFile, error) {
+	var files []*rankedFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if f := rankFile(root, path, src, terms); f != nil {
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].score != files[j].score {
+			return files[i].score > files[j].score
+		}
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+func rankFile(root, path string, src []byte, terms []string) *rankedFile {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	tf := fset.File(file.Pos())
+	f := &rankedFile{path: path, src: src, lines: tf.LineCount(), matches: make(map[string]bool)}
+
+	rel, _ := filepath.Rel(root, path)
+	pathWords := strings.ToLower(rel)
+	for _, t := range terms {
+		if strings.Contains(pathWords, t) {
+			f.score += 3
+			f.matches[t] = true
+		}
+	}
+	for _, decl := range file.Decls {
+		var name string
+		var doc *ast.CommentGroup
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name, doc = d.Name.Name, d.Doc
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = recvName(d.Recv.List[0].Type) + name
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			doc = d.Doc
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					name += s.Name.Name
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						name += n.Name
+					}
+				}
+			}
+		}
+		start := decl.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		rd := rankedDecl{start: tf.Offset(start), end: tf.Offset(decl.End())}
+		lowerName := strings.ToLower(name)
+		docText := strings.ToLower(doc.Text())
+		body := strings.ToLower(string(src[tf.Offset(decl.Pos()):rd.end]))
+		for _, t := range terms {
+			switch {
+			case lowerName == t:
+				rd.score += 10
+			case strings.Contains(lowerName, t):
+				rd.score += 5
+			case strings.Contains(docText, t):
+				rd.score++
+			case strings.Contains(body, t):
+				rd.score++
+			default:
+				continue
+			}
+			f.matches[t] = true
+		}
+		if rd.score > 0 {
+			f.decls = append(f.decls, rd)
+			f.score += rd.score
+		}
+	}
+	if strings.HasSuffix(path, "_test.go") {
+		f.score /= 2
+	}
+	if f.score == 0 {
+		return nil
+	}
+	return f
+}
+
+func recvName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(t.X)
+	case *ast.IndexExpr:
+		return recvName(t.X)
+	case *ast.IndexListExpr:
+		return recvName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// chunk renders a file: whole if it is short, otherwise its package clause and the declarations
+// that matched, in source order.
+func (f *rankedFile) chunk(root string) Chunk {
+	rel, _ := filepath.Rel(root, f.path)
+	c := Chunk{Kind: KindCode, Title: filepath.ToSlash(rel), Score: f.score}
+	for t := range f.matches {
+		c.Matches = append(c.Matches, t)
+	}
+	sort.Strings(c.Matches)
+
+	if f.lines <= wholeFileLines {
+		c.Content = string(f.src)
+	} else {
+		var sb strings.Builder
+		for _, line := range strings.Split(string(f.src), "\n") {
+			if strings.HasPrefix(line, "package ") {
+				sb.WriteString(line + "\n")
+				break
+			}
+		}
+		for _, d := range f.decls {
+			fmt.Fprintf(&sb, "\n// ... line %d\n%s\n", shared.GetLineFromOffset(string(f.src), d.start), f.src[d.start:d.end])
+		}
+		c.Content = sb.String()
+	}
+	c.Tokens = estimateTokens(c.Content)
+	return c
+}
+
+// docsChunk summarizes the packages the top files import from outside the module, with the
+// declarations of the symbols they use, trimmed to limit tokens.
+func docsChunk(ctx context.Context, dir string, files []string, limit int) Chunk {
+	c := Chunk{Kind: KindDocs, Title: "APIs used by the top files"}
+	modPath := modulePath(dir)
+	seen := make(map[string]bool)
+	var sb strings.Builder
+	for _, file := range files {
+		docs, err := godoc.Prefetch(ctx, file)
+		if err != nil {
+			continue
+		}
+		for _, d := range docs {
+			if seen[d.ImportPath] || d.Error != "" || (modPath != "" && (d.ImportPath == modPath || strings.HasPrefix(d.ImportPath, modPath+"/"))) {
+				continue
+			}
+			seen[d.ImportPath] = true
+			var entry strings.Builder
+			fmt.Fprintf(&entry, "%s: %s\n", d.ImportPath, d.Synopsis)
+			for _, u := range d.Used {
+				fmt.Fprintf(&entry, "    %s\n", u)
+			}
+			if estimateTokens(sb.String()+entry.String()) > limit {
+				break
+			}
+			sb.WriteString(entry.String())
+		}
+	}
+	c.Content = sb.String()
+	c.Tokens = estimateTokens(c.Content)
+	return c
+}
+
+func modulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(data)
+}
+
+// commitsChunk lists recent commits whose subject mentions a task term or that touched one of the
+// ranked files, most relevant first.
+func commitsChunk(ctx context.Context, dir string, terms []string, files []*rankedFile, limit int) Chunk {
+	c := Chunk{Kind: KindCommits, Title: "Related commits"}
+	cmd := exec.CommandContext(ctx, "git", "log", "-n", fmt.Sprint(commitScan), "--date=short", "--format=\x1e%h %ad %s", "--name-only", "--relative", "--", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return c
+	}
+	fileScore := make(map[string]int)
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f.path)
+		fileScore[filepath.ToSlash(rel)] = f.score
+	}
+	type commit struct {
+		line  string
+		score int
+		order int
+	}
+	var commits []commit
+	for i, rec := range strings.Split(string(out), "\x1e")[1:] {
+		lines := strings.Split(strings.TrimSpace(rec), "\n")
+		cm := commit{line: lines[0], order: i}
+		subject := strings.ToLower(lines[0])
+		for _, t := range terms {
+			if strings.Contains(subject, t) {
+				cm.score += 5
+			}
+		}
+		for _, name := range lines[1:] {
+			if s := fileScore[strings.TrimSpace(name)]; s > 0 {
+				cm.score += min(s, 5)
+			}
+		}
+		if cm.score > 0 {
+			commits = append(commits, cm)
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].score > commits[j].score })
+	var sb strings.Builder
+	for _, cm := range commits[:min(maxCommits, len(commits))] {
+		if estimateTokens(sb.String()+cm.line+"\n") > limit {
+			break
+		}
+		sb.WriteString(cm.line + "\n")
+	}
+	c.Content = sb.String()
+	c.Tokens = estimateTokens(c.Content)
+	return c
+}
+
+func render(p *Pack) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Context: %s\n\n", p.Task)
+	fmt.Fprintf(&sb, "- Terms: %s\n- Tokens: ~%d of %d\n", strings.Join(p.Terms, ", "), p.Used, p.Budget)
+	if len(p.Omitted) > 0 {
+		fmt.Fprintf(&sb, "- Relevant but over budget: %s\n", strings.Join(p.Omitted, ", "))
+	}
+	sb.WriteString("\n")
+	if len(p.Chunks) == 0 {
+		sb.WriteString("No code matched the task. Rephrase it with the names of the types, functions or behavior involved.\n")
+		return sb.String()
+	}
+	for i, c := range p.Chunks {
+		switch c.Kind {
+		case KindCode:
+			fmt.Fprintf(&sb, "## %d. `%s` (score %d: %s)\n\n```go\n%s\n```\n\n", i+1, c.Title, c.Score, strings.Join(c.Matches, ", "), strings.TrimRight(c.Content, "\n"))
+		default:
+			fmt.Fprintf(&sb, "## %d. %s\n\n```text\n%s\n```\n\n", i+1, c.Title, strings.TrimRight(c.Content, "\n"))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
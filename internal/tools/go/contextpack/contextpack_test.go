@@ -0,0 +1,91 @@
+package contextpack
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		testutil.WriteFiles(t, dir, map[string]string{name: content})
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	write("go.mod", testutil.GoMod("example.com/app"))
+	write("server.go", "package app\n\n// Serve starts the server.\nfunc Serve() {}\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "Add server")
+	write("config.go", `package app
+
+import "strings"
+
+// ParseConfig parses key=value lines.
+func ParseConfig(s string) map[string]string {
+	out := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		k, v, _ := strings.Cut(line, "=")
+		out[k] = v
+	}
+	return out
+}
+`)
+	git("add", ".")
+	git("commit", "-q", "-m", "Add config parsing")
+	return dir
+}
+
+func TestHandler(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := setup(t)
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Task: "Make ParseConfig reject empty keys"})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	for _, want := range []string{
+		"- Terms: parseconfig, parse, config, reject, empty, keys",
+		"## 1. `config.go` (score",
+		"## 2. APIs used by the top files\n\n```text\nstrings: Package strings implements simple functions to manipulate UTF-8 encoded strings.\n    func Cut(s, sep string) (before, after string, found bool)\n",
+		"## 3. Related commits",
+		"Add config parsing",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "server.go") || strings.Contains(out, "Add server") {
+		t.Errorf("unrelated file or commit included:\n%s", out)
+	}
+
+	res, _, _ = Handler(context.Background(), nil, Params{Dir: dir, Task: "ParseConfig", Budget: 40})
+	out = res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(out, "- Relevant but over budget: config.go") {
+		t.Errorf("expected config.go to be omitted under a tiny budget:\n%s", out)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("Add retry to the HTTPClient when fetching user_profiles")
+	want := []string{"retry", "httpclient", "http", "client", "fetching", "user_profiles", "user", "profiles"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,487 @@
+// Package dephealth implements the dependency_health tool, which scores the maintenance and
+// security risk of a module's dependencies from the module proxy, GitHub, pkg.go.dev and OSV.
+package dephealth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["dependency_health"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string   `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Modules      []string `json:"modules,omitempty" jsonschema:"Module paths to evaluate (default: every direct requirement in go.mod, up to 20)"`
+	OutputFormat string   `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Endpoints, variables so tests can point them at a local server.
+var (
+	ProxyURL    = "https://proxy.golang.org"
+	GitHubAPI   = "https://api.github.com"
+	PkgGoDevURL = "https://pkg.go.dev"
+	OSVURL      = "https://api.osv.dev/v1/query"
+)
+
+const maxModules = 20
+
+// Risk levels, ordered.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+var riskOrder = map[string]int{RiskLow: 0, RiskMedium: 1, RiskHigh: 2}
+
+// Vuln is a known vulnerability in a module.
+type Vuln struct {
+	ID       string `json:"id"`
+	Summary  string `json:"summary,omitempty"`
+	Affected bool   `json:"affects_current_version"`
+	Fixed    string `json:"fixed,omitempty"`
+}
+
+// Health is the evaluation of one dependency. Fields that could not be fetched are left empty and
+// listed in Unknown.
+type Health struct {
+	Module        string    `json:"module"`
+	Version       string    `json:"version"`
+	Latest        string    `json:"latest,omitempty"`
+	LatestTime    time.Time `json:"latest_release,omitempty"`
+	Repo          string    `json:"repo,omitempty"`
+	Archived      bool      `json:"archived"`
+	LastPush      time.Time `json:"last_commit,omitempty"`
+	OpenIssues    int       `json:"open_issues"`
+	ImportedBy    int       `json:"imported_by"`
+	Vulns         []Vuln    `json:"vulnerabilities,omitempty"`
+	Risk          string    `json:"risk"`
+	Reasons       []string  `json:"reasons,omitempty"`
+	Unknown       []string  `json:"unknown,omitempty"`
+	hasImportedBy bool
+	hasGitHub     bool
+}
+
+// Handler handles the dependency_health tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	deps, err := requirements(absDir, args.Modules)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(deps) == 0 {
+		return errorResult("no dependencies to evaluate"), nil, nil
+	}
+	truncated := len(deps) > maxModules
+	if truncated {
+		deps = deps[:maxModules]
+	}
+
+	results := Evaluate(ctx, deps, time.Now())
+
+	var output string
+	if format == shared.FormatJSON {
+		bytes, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(results, truncated)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// requirements returns the required version of each module in go.mod: the named modules, or every
+// direct requirement.
+func requirements(dir string, names []string) ([]module.Version, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	mf, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	var deps []module.Version
+	if len(names) == 0 {
+		for _, r := range mf.Require {
+			if !r.Indirect {
+				deps = append(deps, r.Mod)
+			}
+		}
+		return deps, nil
+	}
+	for _, name := range names {
+		found := false
+		for _, r := range mf.Require {
+			if r.Mod.Path == name {
+				deps = append(deps, r.Mod)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not required in go.mod", name)
+		}
+	}
+	return deps, nil
+}
+
+// Evaluate gathers health signals for each dependency concurrently and scores them.
+func Evaluate(ctx context.Context, deps []module.Version, now time.Time) []*Health {
+	results := make([]*Health, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		h := &Health{Module: dep.Path, Version: dep.Version}
+		results[i] = h
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var inner sync.WaitGroup
+			for _, fetch := range []func(context.Context, *Health) error{fetchLatest, fetchGitHub, fetchImportedBy, fetchVulns} {
+				inner.Add(1)
+				go func() {
+					defer inner.Done()
+					_ = fetch(ctx, h)
+				}()
+			}
+			inner.Wait()
+			score(h, now)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func fetchLatest(ctx context.Context, h *Health) error {
+	escaped, err := module.EscapePath(h.Module)
+	if err != nil {
+		return err
+	}
+	body, status, err := shared.HTTPRequest(ctx, http.MethodGet, ProxyURL+"/"+escaped+"/@latest", nil, nil, 64<<10)
+	if err != nil || status != http.StatusOK {
+		return fmt.Errorf("proxy: %v %d", err, status)
+	}
+	var info struct {
+		Version string
+		Time    time.Time
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	h.Latest, h.LatestTime = info.Version, info.Time
+	return nil
+}
+
+// githubRepo returns "owner/repo" for modules hosted on GitHub.
+func githubRepo(mod string) string {
+	parts := strings.Split(mod, "/")
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return ""
+	}
+	return parts[1] + "/" + parts[2]
+}
+
+func fetchGitHub(ctx context.Context, h *Health) error {
+	repo := githubRepo(h.Module)
+	if repo == "" {
+		return nil
+	}
+	h.Repo = "https://github.com/" + repo
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	body, status, err := shared.HTTPRequest(ctx, http.MethodGet, GitHubAPI+"/repos/"+repo, nil, header, 1<<20)
+	if err != nil || status != http.StatusOK {
+		return fmt.Errorf("github: %v %d", err, status)
+	}
+	var info struct {
+		Archived   bool      `json:"archived"`
+		PushedAt   time.Time `json:"pushed_at"`
+		OpenIssues int       `json:"open_issues_count"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	h.Archived, h.LastPush, h.OpenIssues = info.Archived, info.PushedAt, info.OpenIssues
+	h.hasGitHub = true
+	return nil
+}
+
+var importedByRe = regexp.MustCompile(`Imported by:?\s*(?:<[^>]*>\s*)*([\d,]+)`)
+
+func fetchImportedBy(ctx context.Context, h *Health) error {
+	body, status, err := shared.HTTPRequest(ctx, http.MethodGet, PkgGoDevURL+"/"+h.Module, nil, nil, 4<<20)
+	if err != nil || status != http.StatusOK {
+		return fmt.Errorf("pkg.go.dev: %v %d", err, status)
+	}
+	m := importedByRe.FindSubmatch(body)
+	if m == nil {
+		return fmt.Errorf("pkg.go.dev: no importer count")
+	}
+	h.ImportedBy, _ = strconv.Atoi(strings.ReplaceAll(string(m[1]), ",", ""))
+	h.hasImportedBy = true
+	return nil
+}
+
+// osvVuln is the subset of an OSV record that fetchVulns reads.
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Affected []struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string     `json:"type"`
+			Events []osvEvent `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// osvEvent starts or ends an affected range.
+type osvEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+func fetchVulns(ctx context.Context, h *Health) error {
+	query, _ := json.Marshal(map[string]any{"package": map[string]string{"name": h.Module, "ecosystem": "Go"}})
+	header := http.Header{"Content-Type": {"application/json"}}
+	body, status, err := shared.HTTPRequest(ctx, http.MethodPost, OSVURL, bytes.NewReader(query), header, 4<<20)
+	if err != nil || status != http.StatusOK {
+		h.Unknown = append(h.Unknown, "vulnerabilities")
+		return fmt.Errorf("osv: %v %d", err, status)
+	}
+	var resp struct {
+		Vulns []osvVuln `json:"vulns"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		h.Unknown = append(h.Unknown, "vulnerabilities")
+		return err
+	}
+	for _, v := range resp.Vulns {
+		vuln := Vuln{ID: v.ID, Summary: v.Summary}
+		for _, a := range v.Affected {
+			if a.Package.Name != h.Module {
+				continue
+			}
+			for _, r := range a.Ranges {
+				if r.Type != "SEMVER" {
+					continue
+				}
+				affected, fixed := inRange(h.Version, r.Events)
+				vuln.Affected = vuln.Affected || affected
+				if fixed != "" {
+					vuln.Fixed = fixed
+				}
+			}
+		}
+		h.Vulns = append(h.Vulns, vuln)
+	}
+	sort.Slice(h.Vulns, func(i, j int) bool {
+		if h.Vulns[i].Affected != h.Vulns[j].Affected {
+			return h.Vulns[i].Affected
+		}
+		return h.Vulns[i].ID < h.Vulns[j].ID
+	})
+	return nil
+}
+
+// inRange reports whether version falls in an OSV SEMVER range, whose events are unprefixed
+// versions ("0" meaning the beginning), and returns the first fix after it.
+func inRange(version string, events []osvEvent) (bool, string) {
+	affected := false
+	fix := ""
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || semver.Compare(version, "v"+e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if semver.Compare(version, "v"+e.Fixed) >= 0 {
+				affected = false
+			} else if affected && fix == "" {
+				fix = "v" + e.Fixed
+			}
+		}
+	}
+	if !affected {
+		fix = ""
+	}
+	return affected, fix
+}
+
+// score sets the risk level from the signals. The overall risk is the highest level any signal
+// reaches; missing data is reported but does not raise the risk.
+func score(h *Health, now time.Time) {
+	h.Risk = RiskLow
+	raise := func(level, reason string) {
+		if riskOrder[level] > riskOrder[h.Risk] {
+			h.Risk = level
+		}
+		h.Reasons = append(h.Reasons, reason)
+	}
+	years := func(t time.Time) float64 { return now.Sub(t).Hours() / 24 / 365 }
+
+	if h.Archived {
+		raise(RiskHigh, "the repository is archived; it will not receive fixes")
+	}
+	for _, v := range h.Vulns {
+		if v.Affected {
+			msg := fmt.Sprintf("%s affects %s", v.ID, h.Version)
+			if v.Fixed != "" {
+				msg += fmt.Sprintf("; fixed in %s", v.Fixed)
+			}
+			raise(RiskHigh, msg)
+		}
+	}
+	if past := len(h.Vulns) - countAffected(h.Vulns); past >= 3 {
+		raise(RiskLow, fmt.Sprintf("%d past vulnerabilities, all fixed in the version in use", past))
+	}
+
+	if h.Latest == "" {
+		h.Unknown = append(h.Unknown, "latest release")
+	} else {
+		if y := years(h.LatestTime); y >= 2 {
+			raise(RiskMedium, fmt.Sprintf("no release in %.1f years", y))
+		}
+		if semver.Major(h.Latest) != semver.Major(h.Version) {
+			raise(RiskMedium, fmt.Sprintf("a new major version (%s) is available", h.Latest))
+		} else if semver.Compare(h.Latest, h.Version) > 0 {
+			raise(RiskLow, fmt.Sprintf("behind the latest release %s", h.Latest))
+		}
+	}
+	if module.IsPseudoVersion(h.Version) {
+		raise(RiskLow, "pinned to a pseudo-version rather than a release")
+	}
+
+	if h.Repo != "" && !h.hasGitHub {
+		h.Unknown = append(h.Unknown, "repository activity")
+	} else if h.hasGitHub {
+		if y := years(h.LastPush); y >= 1 {
+			raise(RiskMedium, fmt.Sprintf("no commits in %.1f years", y))
+		}
+		if h.OpenIssues >= 500 {
+			raise(RiskLow, fmt.Sprintf("%d open issues and pull requests", h.OpenIssues))
+		}
+	}
+
+	if !h.hasImportedBy {
+		h.Unknown = append(h.Unknown, "importers")
+	} else if h.ImportedBy < 10 {
+		raise(RiskMedium, fmt.Sprintf("only %d known importers; few others will notice or fix problems", h.ImportedBy))
+	}
+	sort.Strings(h.Unknown)
+}
+
+func countAffected(vulns []Vuln) int {
+	n := 0
+	for _, v := range vulns {
+		if v.Affected {
+			n++
+		}
+	}
+	return n
+}
+
+func render(results []*Health, truncated bool) string {
+	var sb strings.Builder
+	sb.WriteString("# Dependency Health\n\n")
+	icons := map[string]string{RiskLow: "🟢", RiskMedium: "🟡", RiskHigh: "🔴"}
+	sb.WriteString("| Module | Version | Latest | Risk |\n| :--- | :--- | :--- | :--- |\n")
+	for _, h := range results {
+		fmt.Fprintf(&sb, "| `%s` | %s | %s | %s %s |\n", h.Module, h.Version, h.Latest, icons[h.Risk], h.Risk)
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\nOnly the first %d direct dependencies were evaluated; pass `modules` to choose others.\n", maxModules)
+	}
+	for _, h := range results {
+		fmt.Fprintf(&sb, "\n## %s `%s`\n\n", icons[h.Risk], h.Module)
+		if !h.LatestTime.IsZero() {
+			fmt.Fprintf(&sb, "- Latest release: %s (%s)\n", h.Latest, h.LatestTime.Format("2006-01-02"))
+		}
+		if h.hasGitHub {
+			fmt.Fprintf(&sb, "- Repository: %s — last commit %s, %d open issues", h.Repo, h.LastPush.Format("2006-01-02"), h.OpenIssues)
+			if h.Archived {
+				sb.WriteString(", **archived**")
+			}
+			sb.WriteString("\n")
+		}
+		if h.hasImportedBy {
+			fmt.Fprintf(&sb, "- Imported by: %d\n", h.ImportedBy)
+		}
+		if len(h.Vulns) > 0 {
+			fmt.Fprintf(&sb, "- Vulnerabilities: %d known, %d affecting %s\n", len(h.Vulns), countAffected(h.Vulns), h.Version)
+		}
+		for _, r := range h.Reasons {
+			fmt.Fprintf(&sb, "- ⚠️ %s\n", r)
+		}
+		if len(h.Unknown) > 0 {
+			fmt.Fprintf(&sb, "- Could not check: %s\n", strings.Join(h.Unknown, ", "))
+		}
+	}
+	return sb.String()
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
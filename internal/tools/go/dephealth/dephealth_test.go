@@ -0,0 +1,148 @@
+package dephealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/module"
+)
+
+func fakeServices(t *testing.T) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/proxy/github.com/acme/old/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"v2.0.0","Time":"2020-01-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/proxy/example.com/fresh/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"v0.4.0","Time":"2025-11-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/github/repos/acme/old", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"archived":true,"pushed_at":"2020-02-01T00:00:00Z","open_issues_count":42}`)
+	})
+	mux.HandleFunc("/pkgsite/github.com/acme/old", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="?tab=importedby">Imported by: <strong>1,234</strong></a>`)
+	})
+	mux.HandleFunc("/pkgsite/example.com/fresh", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="?tab=importedby">Imported by: 3</a>`)
+	})
+	mux.HandleFunc("/osv", func(w http.ResponseWriter, r *http.Request) {
+		var q struct{ Package struct{ Name string } }
+		_ = json.NewDecoder(r.Body).Decode(&q)
+		if q.Package.Name != "github.com/acme/old" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprint(w, `{"vulns":[
+			{"id":"GO-2021-0001","summary":"old bug","affected":[{"package":{"name":"github.com/acme/old","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"1.1.0"}]}]}]},
+			{"id":"GO-2022-0002","summary":"path traversal","affected":[{"package":{"name":"github.com/acme/old","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"1.0.0"},{"fixed":"1.3.0"}]}]}]}
+		]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	old := []string{ProxyURL, GitHubAPI, PkgGoDevURL, OSVURL}
+	ProxyURL, GitHubAPI, PkgGoDevURL, OSVURL = srv.URL+"/proxy", srv.URL+"/github", srv.URL+"/pkgsite", srv.URL+"/osv"
+	t.Cleanup(func() { ProxyURL, GitHubAPI, PkgGoDevURL, OSVURL = old[0], old[1], old[2], old[3] })
+}
+
+func TestEvaluate(t *testing.T) {
+	fakeServices(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := Evaluate(context.Background(), []module.Version{
+		{Path: "github.com/acme/old", Version: "v1.2.0"},
+		{Path: "example.com/fresh", Version: "v0.3.1"},
+	}, now)
+
+	old := results[0]
+	if old.Risk != RiskHigh || !old.Archived || old.ImportedBy != 1234 || old.OpenIssues != 42 {
+		t.Errorf("unexpected health for old: %+v", old)
+	}
+	wantReasons := []string{
+		"the repository is archived; it will not receive fixes",
+		"GO-2022-0002 affects v1.2.0; fixed in v1.3.0",
+		"no release in 6.0 years",
+		"a new major version (v2.0.0) is available",
+		"no commits in 5.9 years",
+	}
+	if strings.Join(old.Reasons, "\n") != strings.Join(wantReasons, "\n") {
+		t.Errorf("reasons = %q\nwant %q", old.Reasons, wantReasons)
+	}
+	if len(old.Vulns) != 2 || !old.Vulns[0].Affected || old.Vulns[1].Affected {
+		t.Errorf("vulns = %+v", old.Vulns)
+	}
+
+	fresh := results[1]
+	if fresh.Risk != RiskMedium {
+		t.Errorf("fresh risk = %s, reasons %q", fresh.Risk, fresh.Reasons)
+	}
+	if want := "only 3 known importers; few others will notice or fix problems"; !contains(fresh.Reasons, want) {
+		t.Errorf("fresh reasons %q missing %q", fresh.Reasons, want)
+	}
+	if want := "behind the latest release v0.4.0"; !contains(fresh.Reasons, want) {
+		t.Errorf("fresh reasons %q missing %q", fresh.Reasons, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	fakeServices(t)
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/app") + "\nrequire (\n\tgithub.com/acme/old v1.2.0\n\texample.com/fresh v0.3.1\n\texample.com/indirect v1.0.0 // indirect\n)\n",
+	})
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir})
+	out := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{
+		"| `github.com/acme/old` | v1.2.0 | v2.0.0 | 🔴 high |",
+		"| `example.com/fresh` | v0.3.1 | v0.4.0 | 🟡 medium |",
+		"- Vulnerabilities: 2 known, 1 affecting v1.2.0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "example.com/indirect") {
+		t.Errorf("indirect requirement evaluated:\n%s", out)
+	}
+
+	res, _, _ = Handler(context.Background(), nil, Params{Dir: dir, Modules: []string{"example.com/missing"}})
+	if !res.IsError {
+		t.Error("expected an error for a module that is not required")
+	}
+}
+
+func TestInRange(t *testing.T) {
+	events := []osvEvent{{Introduced: "0"}, {Fixed: "1.1.0"}, {Introduced: "1.5.0"}, {Fixed: "1.5.2"}}
+	tests := []struct {
+		version  string
+		affected bool
+		fix      string
+	}{
+		{"v1.0.0", true, "v1.1.0"},
+		{"v1.2.0", false, ""},
+		{"v1.5.1", true, "v1.5.2"},
+		{"v1.6.0", false, ""},
+	}
+	for _, tt := range tests {
+		affected, fix := inRange(tt.version, events)
+		if affected != tt.affected || fix != tt.fix {
+			t.Errorf("inRange(%s) = %v, %q; want %v, %q", tt.version, affected, fix, tt.affected, tt.fix)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
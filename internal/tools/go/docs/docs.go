@@ -5,10 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/danicat/godoctor/internal/godoc"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -24,9 +24,11 @@ func Register(server *mcp.Server) {
 
 // Params defines the input parameters for the read_docs tool.
 type Params struct {
-	ImportPath string `json:"import_path" jsonschema:"Import path of the package (e.g. 'fmt')"`
-	SymbolName string `json:"symbol_name,omitempty" jsonschema:"Optional symbol name to lookup"`
-	Format     string `json:"format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+	ImportPath   string `json:"import_path" jsonschema:"Import path of the package (e.g. 'fmt')"`
+	SymbolName   string `json:"symbol_name,omitempty" jsonschema:"Optional symbol name to lookup"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+	Format       string `json:"format,omitempty" jsonschema:"Deprecated: use output_format"`
+	Examples     *bool  `json:"examples,omitempty" jsonschema:"Include the usage examples from the package's test files (default: true; pass false for faster lookups in large packages)"`
 }
 
 // Handler handles the read_docs tool execution.
@@ -40,22 +42,22 @@ func Handler(ctx context.Context, _ *mcp.CallToolRequest, args Params) (*mcp.Cal
 		}, nil, nil
 	}
 
-	// Default to markdown
-	if args.Format == "" {
-		args.Format = "markdown"
+	if args.OutputFormat == "" {
+		args.OutputFormat = args.Format
 	}
-	args.Format = strings.ToLower(args.Format)
-	if args.Format != "markdown" && args.Format != "json" {
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: "invalid format: must be 'markdown' or 'json'"},
+				&mcp.TextContent{Text: err.Error()},
 			},
 		}, nil, nil
 	}
 
 	// Use LoadWithFallback for flexibility on typos
-	doc, err := godoc.LoadWithFallback(ctx, args.ImportPath, args.SymbolName)
+	examples := args.Examples == nil || *args.Examples
+	doc, err := godoc.LoadWithFallback(ctx, args.ImportPath, args.SymbolName, examples)
 	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -67,7 +69,7 @@ func Handler(ctx context.Context, _ *mcp.CallToolRequest, args Params) (*mcp.Cal
 
 	var output string
 
-	if args.Format == "json" {
+	if format == shared.FormatJSON {
 		bytes, err := json.MarshalIndent(doc, "", "  ")
 		if err != nil {
 			return &mcp.CallToolResult{
@@ -0,0 +1,96 @@
+// Package export implements the export_docs tool, which renders a module's documentation to a
+// static markdown or HTML tree.
+package export
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/godoc"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["export_docs"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Out          string `json:"out,omitempty" jsonschema:"Output directory (default: docs/api inside dir)"`
+	Format       string `json:"format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'html'"`
+	Dependencies bool   `json:"dependencies,omitempty" jsonschema:"Also export the non-standard packages the module imports"`
+}
+
+// Handler handles the export_docs tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	out := args.Out
+	if out == "" {
+		out = filepath.Join(absDir, "docs", "api")
+	} else if !filepath.IsAbs(out) {
+		out = filepath.Join(absDir, out)
+	}
+	if out, err = roots.Global.Validate(session, out); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	res, err := godoc.Export(ctx, godoc.ExportOptions{
+		Dir:          absDir,
+		Out:          out,
+		Format:       strings.ToLower(args.Format),
+		Dependencies: args.Dependencies,
+	})
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: Summary(res, out)},
+		},
+	}, nil, nil
+}
+
+// Summary describes an export for the tool result and the export-docs command.
+func Summary(res *godoc.ExportResult, out string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Documentation Exported: `%s`\n\n", res.Module)
+	fmt.Fprintf(&sb, "- Output: `%s`\n- Packages: %d\n- Dependencies: %d\n- Files written: %d\n", out, len(res.Packages), len(res.Dependencies), res.Files)
+	if len(res.Skipped) > 0 {
+		fmt.Fprintf(&sb, "\n## ⚠️ Skipped (%d)\n\n", len(res.Skipped))
+		for _, s := range res.Skipped {
+			fmt.Fprintf(&sb, "- %s\n", s)
+		}
+	}
+	return sb.String()
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,120 @@
+// Package prefetch implements the prefetch_docs tool, which loads the documentation of every
+// package a file imports in one call.
+package prefetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/godoc"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["prefetch_docs"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Filename     string `json:"filename" jsonschema:"Absolute path to the Go file whose imports to load"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Handler handles the prefetch_docs tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Filename == "" {
+		return errorResult("filename cannot be empty"), nil, nil
+	}
+	if !strings.HasSuffix(args.Filename, ".go") {
+		return errorResult("filename must be a Go file (*.go)"), nil, nil
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	absPath, err := roots.Global.Validate(session, args.Filename)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	docs, err := godoc.Prefetch(ctx, absPath)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var output string
+	if format == shared.FormatJSON {
+		if docs == nil {
+			docs = []godoc.ImportDoc{}
+		}
+		bytes, err := json.MarshalIndent(docs, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(filepath.Base(absPath), docs)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+func render(name string, docs []godoc.ImportDoc) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Imports of `%s`\n\n", name)
+	if len(docs) == 0 {
+		sb.WriteString("The file has no imports.\n")
+		return sb.String()
+	}
+	for _, d := range docs {
+		fmt.Fprintf(&sb, "## `%s`\n\n", d.ImportPath)
+		if d.Error != "" {
+			fmt.Fprintf(&sb, "⚠️ Could not load documentation: %s\n\n", firstLine(d.Error))
+			continue
+		}
+		if d.Synopsis != "" {
+			sb.WriteString(d.Synopsis + "\n\n")
+		}
+		for _, u := range d.Used {
+			fmt.Fprintf(&sb, "- `%s`\n", u)
+		}
+		if len(d.Used) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("Full documentation for these packages is cached; `read_docs` on them or their symbols returns without re-parsing.\n")
+	return sb.String()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
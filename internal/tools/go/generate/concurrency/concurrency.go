@@ -0,0 +1,1034 @@
+// Package concurrency implements the suggest_concurrency tool. It inspects a function built from
+// raw goroutines, channels and mutexes and proposes an equivalent written with a higher-level
+// primitive (errgroup, sync.OnceValue and friends, or a worker pool with graceful shutdown),
+// with generated code and a checklist of behavior changes to verify.
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"go/version"
+	"os"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/snippetlib"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["suggest_concurrency"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir      string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package  string `json:"package" jsonschema:"Package containing the function, as an import path or a ./relative pattern"`
+	Function string `json:"function" jsonschema:"Name of the function, or Type.Method for a method"`
+}
+
+// Suggestion kinds.
+const (
+	KindErrgroup   = "errgroup"
+	KindOnce       = "once"
+	KindWorkerPool = "worker-pool"
+)
+
+// Suggestion is one proposed replacement.
+type Suggestion struct {
+	Kind      string
+	Title     string
+	Detected  string
+	Code      string
+	Requires  string // import or toolchain requirement, if any
+	Checklist []string
+}
+
+// Handler handles the suggest_concurrency tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Function == "" {
+		return errorResult("package and function are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, args.Package, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(pkgs) != 1 {
+		return errorResult(fmt.Sprintf("package pattern %q matched %d packages; pass a single package", args.Package, len(pkgs))), nil, nil
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return errorResult(fmt.Sprintf("package %s has errors: %v", pkg.PkgPath, pkg.Errors[0])), nil, nil
+	}
+
+	suggestions, err := Suggest(pkg, args.Function)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Concurrency Suggestions for `%s`\n\n", args.Function)
+	if len(suggestions) == 0 {
+		sb.WriteString("No pattern with a higher-level replacement was found. Recognized patterns are:\n\n")
+		sb.WriteString("- a `sync.WaitGroup` fan-out, optionally with an error channel or a semaphore channel (→ errgroup)\n")
+		sb.WriteString("- lazy initialization with `sync.Once` (→ `sync.OnceFunc`, `sync.OnceValue`, `sync.OnceValues`)\n")
+		sb.WriteString("- workers ranging over a jobs channel (→ worker pool with graceful shutdown)\n")
+		return textResult(sb.String()), nil, nil
+	}
+	for _, s := range suggestions {
+		fmt.Fprintf(&sb, "## %s\n\n**Detected:** %s\n\n", s.Title, s.Detected)
+		if s.Requires != "" {
+			fmt.Fprintf(&sb, "**Requires:** %s\n\n", s.Requires)
+		}
+		fmt.Fprintf(&sb, "```go\n%s\n```\n\n### Checklist\n\n", strings.TrimSuffix(s.Code, "\n"))
+		for _, c := range s.Checklist {
+			fmt.Fprintf(&sb, "- [ ] %s\n", c)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("The code is a suggestion: apply it with `smart_edit`, then run the tests with `-race`.\n")
+	return textResult(sb.String()), nil, nil
+}
+
+// Suggest inspects the named function of pkg and returns the applicable suggestions.
+func Suggest(pkg *packages.Package, name string) ([]Suggestion, error) {
+	fd, file := findFunc(pkg, name)
+	if fd == nil {
+		return nil, fmt.Errorf("function %s not found in package %s", name, pkg.PkgPath)
+	}
+	if fd.Body == nil {
+		return nil, fmt.Errorf("function %s has no body", name)
+	}
+	tokFile := pkg.Fset.File(file.Pos())
+	//nolint:gosec // G304: File path comes from the loaded package.
+	src, err := os.ReadFile(tokFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", tokFile.Name(), err)
+	}
+	a := &analyzer{pkg: pkg, info: pkg.TypesInfo, fd: fd, file: file, tokFile: tokFile, src: src}
+
+	var out []Suggestion
+	for _, s := range []*Suggestion{a.errgroup(), a.once(), a.workerPool()} {
+		if s != nil {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func findFunc(pkg *packages.Package, name string) (*ast.FuncDecl, *ast.File) {
+	recv, method, isMethod := strings.Cut(name, ".")
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			switch {
+			case !isMethod && fd.Recv == nil && fd.Name.Name == name:
+				return fd, file
+			case isMethod && fd.Recv != nil && fd.Name.Name == method && recvName(fd) == recv:
+				return fd, file
+			}
+		}
+	}
+	return nil, nil
+}
+
+func recvName(fd *ast.FuncDecl) string {
+	if len(fd.Recv.List) == 0 {
+		return ""
+	}
+	expr := fd.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+type analyzer struct {
+	pkg     *packages.Package
+	info    *types.Info
+	fd      *ast.FuncDecl
+	file    *ast.File
+	tokFile *token.File
+	src     []byte
+}
+
+func (a *analyzer) offset(pos token.Pos) int { return a.tokFile.Offset(pos) }
+
+func (a *analyzer) text(n ast.Node) string {
+	return string(a.src[a.offset(n.Pos()):a.offset(n.End())])
+}
+
+func (a *analyzer) qual(p *types.Package) string {
+	if p == a.pkg.Types {
+		return ""
+	}
+	return p.Name()
+}
+
+// editor collects non-overlapping edits to the function's source.
+type editor struct {
+	a     *analyzer
+	edits []shared.TextEdit
+}
+
+func (e *editor) replace(from, to token.Pos, text string) bool {
+	return e.add(shared.TextEdit{Start: e.a.offset(from), End: e.a.offset(to), New: text})
+}
+
+// remove deletes a statement together with its indentation and line break.
+func (e *editor) remove(n ast.Node) bool {
+	start := e.a.offset(n.Pos())
+	for start > 0 && (e.a.src[start-1] == ' ' || e.a.src[start-1] == '\t') {
+		start--
+	}
+	if start > 0 && e.a.src[start-1] == '\n' {
+		start--
+	}
+	return e.add(shared.TextEdit{Start: start, End: e.a.offset(n.End()), New: ""})
+}
+
+func (e *editor) add(edit shared.TextEdit) bool {
+	for _, x := range e.edits {
+		if edit.Start < x.End && x.Start < edit.End {
+			return false
+		}
+	}
+	e.edits = append(e.edits, edit)
+	return true
+}
+
+// covered reports whether n lies inside an existing edit.
+func (e *editor) covered(n ast.Node) bool {
+	start, end := e.a.offset(n.Pos()), e.a.offset(n.End())
+	for _, x := range e.edits {
+		if start >= x.Start && end <= x.End {
+			return true
+		}
+	}
+	return false
+}
+
+// result applies the edits to the function's source and formats it.
+func (e *editor) result() string {
+	base := e.a.offset(e.a.fd.Pos())
+	src := e.a.src[base:e.a.offset(e.a.fd.End())]
+	edits := make([]shared.TextEdit, len(e.edits))
+	for i, x := range e.edits {
+		edits[i] = shared.TextEdit{Start: x.Start - base, End: x.End - base, New: x.New}
+	}
+	out, err := shared.ApplyEdits(src, edits)
+	if err != nil {
+		return string(src)
+	}
+	return formatDecls(string(out))
+}
+
+func formatDecls(code string) string {
+	const header = "package p\n\n"
+	formatted, err := format.Source([]byte(header + code))
+	if err != nil {
+		return code
+	}
+	return strings.TrimPrefix(string(formatted), header)
+}
+
+// errgroup rewrites a sync.WaitGroup fan-out to golang.org/x/sync/errgroup. Goroutines that call
+// wg.Done become g.Go functions, errors sent on an error channel are returned instead, and a
+// buffered chan struct{} used as a semaphore becomes g.SetLimit.
+func (a *analyzer) errgroup() *Suggestion {
+	var wg types.Object
+	var wgDecl ast.Stmt
+	for _, stmt := range a.fd.Body.List {
+		if obj := a.declared(stmt, func(t types.Type) bool { return isNamed(t, "sync", "WaitGroup") }); obj != nil {
+			wg, wgDecl = obj, stmt
+			break
+		}
+	}
+	if wg == nil {
+		return nil
+	}
+
+	var goStmts []*ast.GoStmt
+	var waits []*ast.ExprStmt
+	inspectFunc(a.fd.Body, func(n ast.Node, inLit bool) {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			if lit, ok := n.Call.Fun.(*ast.FuncLit); ok && a.calls(lit.Body, wg, "Done") {
+				goStmts = append(goStmts, n)
+			}
+		case *ast.ExprStmt:
+			if a.isMethodCall(n.X, wg, "Wait") {
+				waits = append(waits, n)
+			}
+		}
+	})
+	if len(goStmts) == 0 || len(waits) == 0 {
+		return nil
+	}
+
+	errCh, sem, limit := a.channels()
+	mutexErr := false
+	for _, gs := range goStmts {
+		mutexErr = mutexErr || a.guardsError(gs.Call.Fun.(*ast.FuncLit).Body)
+	}
+	// Without errors to propagate or a limit to enforce, the WaitGroup is already the right tool.
+	if errCh == nil && sem == nil && !mutexErr {
+		return nil
+	}
+
+	g := a.freeName("g", "eg", "group")
+	perIteration := version.Compare(a.info.FileVersions[a.file], "go1.22") >= 0
+	ed := &editor{a: a}
+	var detected []string
+	detected = append(detected, fmt.Sprintf("`sync.WaitGroup` `%s` waiting for %d goroutine(s)", wg.Name(), len(goStmts)))
+
+	waitReturn := a.waitReturn(g)
+
+	// Statements that only plumb the error channel or the semaphore go away; one that also waits
+	// becomes the g.Wait call.
+	for _, stmt := range a.fd.Body.List {
+		if stmt == wgDecl || a.containsAny(stmt, goStmts) {
+			continue
+		}
+		usesErr := errCh != nil && a.uses(stmt, errCh)
+		usesSem := sem != nil && a.uses(stmt, sem)
+		if !usesErr && !usesSem {
+			continue
+		}
+		if a.waitsOn(stmt, waits) {
+			ed.replace(stmt.Pos(), stmt.End(), waitReturn)
+		} else {
+			ed.remove(stmt)
+		}
+	}
+	if errCh != nil {
+		detected = append(detected, fmt.Sprintf("error channel `%s`", errCh.Name()))
+	}
+
+	decl := fmt.Sprintf("var %s errgroup.Group", g)
+	if ctxName := a.contextParam(); ctxName != "" {
+		decl = fmt.Sprintf("%s, %s := errgroup.WithContext(%s)", g, ctxName, ctxName)
+	}
+	if sem != nil {
+		decl += fmt.Sprintf("\n%s.SetLimit(%s)", g, limit)
+		detected = append(detected, fmt.Sprintf("semaphore channel `%s` limiting concurrency to %s", sem.Name(), limit))
+	}
+	ed.replace(wgDecl.Pos(), wgDecl.End(), decl)
+
+	inspectFunc(a.fd.Body, func(n ast.Node, inLit bool) {
+		if ed.covered(n) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.ExprStmt:
+			if a.isMethodCall(n.X, wg, "Add") || a.isMethodCall(n.X, wg, "Done") {
+				ed.remove(n)
+			}
+			if sem != nil && isRecv(n.X, a.info, sem) {
+				ed.remove(n)
+			}
+		case *ast.DeferStmt:
+			if a.isMethodCall(n.Call, wg, "Done") || (sem != nil && a.onlyReceives(n.Call, sem)) {
+				ed.remove(n)
+			}
+		case *ast.SendStmt:
+			if sem != nil && a.refersTo(n.Chan, sem) {
+				ed.remove(n)
+			}
+		}
+	})
+	for _, gs := range goStmts {
+		lit := gs.Call.Fun.(*ast.FuncLit)
+		var bind strings.Builder
+		i := 0
+		for _, field := range lit.Type.Params.List {
+			for _, name := range field.Names {
+				if i < len(gs.Call.Args) && name.Name != "_" {
+					if arg := a.text(gs.Call.Args[i]); arg != name.Name || !perIteration {
+						fmt.Fprintf(&bind, "%s := %s\n", name.Name, arg)
+					}
+				}
+				i++
+			}
+		}
+		ed.replace(gs.Pos(), lit.Body.Lbrace+1, bind.String()+g+".Go(func() error {")
+		a.rewriteReturns(ed, lit.Body, errCh)
+		if list := lit.Body.List; len(list) == 0 || !isReturn(list[len(list)-1]) {
+			ed.replace(lit.Body.Rbrace, gs.End(), "return nil\n})")
+		} else {
+			ed.replace(lit.Body.Rbrace, gs.End(), "})")
+		}
+	}
+	waitInGoroutine := false
+	for _, w := range waits {
+		if ed.covered(w) {
+			continue
+		}
+		if a.inFuncLit(w) {
+			waitInGoroutine = true
+			ed.replace(w.Pos(), w.End(), "_ = "+g+".Wait()")
+		} else {
+			ed.replace(w.Pos(), w.End(), waitReturn)
+		}
+	}
+	if mutexErr {
+		detected = append(detected, "an error recorded under a mutex")
+	}
+
+	checklist := []string{
+		fmt.Sprintf("`%s.Wait()` returns only the first error. If callers need every error, keep collecting them and return `errors.Join` of the collected errors.", g),
+		"With `errgroup.WithContext`, the first error cancels the context: make sure the goroutine bodies stop when it is done, and that nothing after `Wait` uses the canceled context.",
+		"Panics inside a `Go` function are not recovered by errgroup; they crash the program as before.",
+		"Goroutine arguments are now evaluated when the function runs instead of when the goroutine starts; check that they are not modified in between.",
+		"Results written from the goroutines still need distinct slice indexes or a mutex.",
+	}
+	if mutexErr {
+		checklist = append(checklist, "Return the error from the `Go` function instead of recording it under the mutex, then drop the mutex if nothing else uses it.")
+	}
+	if waitInGoroutine {
+		checklist = append(checklist, fmt.Sprintf("`Wait` is called inside a goroutine (e.g. to close a results channel); call `%s.Wait()` again after consuming the results to get the error. Calling it twice is safe.", g))
+	}
+	checklist = append(checklist, "Run the tests with `-race`.")
+
+	return &Suggestion{
+		Kind:      KindErrgroup,
+		Title:     "Use errgroup",
+		Detected:  strings.Join(detected, ", ") + ".",
+		Code:      ed.result(),
+		Requires:  "`import \"golang.org/x/sync/errgroup\"` (add the module with `add_dependency`)",
+		Checklist: checklist,
+	}
+}
+
+// rewriteReturns turns bare returns of a goroutine body into "return nil" and sends on the error
+// channel into returns of the error.
+func (a *analyzer) rewriteReturns(ed *editor, body *ast.BlockStmt, errCh types.Object) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if ret, ok := n.(*ast.ReturnStmt); ok && len(ret.Results) == 0 {
+			ed.replace(ret.Pos(), ret.End(), "return nil")
+		}
+		list := stmtList(n)
+		for i, stmt := range list {
+			send, ok := stmt.(*ast.SendStmt)
+			if !ok || errCh == nil || !a.refersTo(send.Chan, errCh) {
+				continue
+			}
+			ed.replace(send.Pos(), send.End(), "return "+a.text(send.Value))
+			if i+1 < len(list) {
+				if ret, ok := list[i+1].(*ast.ReturnStmt); ok && len(ret.Results) == 0 {
+					ed.remove(ret)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// waitReturn is the statement replacing the top-level wg.Wait call.
+func (a *analyzer) waitReturn(g string) string {
+	sig, _ := a.info.Defs[a.fd.Name].Type().(*types.Signature)
+	if sig == nil || sig.Results().Len() == 0 || !isError(sig.Results().At(sig.Results().Len()-1).Type()) {
+		return fmt.Sprintf("if err := %s.Wait(); err != nil {\n// TODO: handle err\n}", g)
+	}
+	var results []string
+	for i := 0; i < sig.Results().Len()-1; i++ {
+		results = append(results, zero(sig.Results().At(i).Type(), a.qual))
+	}
+	results = append(results, "err")
+	return fmt.Sprintf("if err := %s.Wait(); err != nil {\nreturn %s\n}", g, strings.Join(results, ", "))
+}
+
+// once rewrites a function that lazily computes a value with sync.Once into a function variable
+// built with sync.OnceFunc, sync.OnceValue or sync.OnceValues.
+func (a *analyzer) once() *Suggestion {
+	list := a.fd.Body.List
+	if len(list) == 0 || len(list) > 2 {
+		return nil
+	}
+	exprStmt, ok := list[0].(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Do" {
+		return nil
+	}
+	if tv, ok := a.info.Types[sel.X]; !ok || !isNamed(tv.Type, "sync", "Once") {
+		return nil
+	}
+	lit, ok := call.Args[0].(*ast.FuncLit)
+	if !ok {
+		return nil
+	}
+	sig := a.info.Defs[a.fd.Name].Type().(*types.Signature)
+	name := a.fd.Name.Name
+
+	checklist := []string{
+		"If the function panics, every later call panics again with the same value (with `sync.Once` later calls returned the zero value).",
+		fmt.Sprintf("Remove `%s` and the variables it guarded once nothing else uses them; tests that reset them must reset the function variable instead.", a.text(sel.X)),
+		"A function variable cannot be reassigned safely while in use; only replace it in tests that do not run in parallel.",
+	}
+	s := &Suggestion{
+		Kind:      KindOnce,
+		Detected:  fmt.Sprintf("`%s.Do` guarding lazy initialization.", a.text(sel.X)),
+		Requires:  "Go 1.21 or later in go.mod",
+		Checklist: checklist,
+	}
+	if a.fd.Recv != nil || sig.Params().Len() > 0 || sig.TypeParams().Len() > 0 {
+		s.Title = "Use sync.OnceValue"
+		s.Detected += " It is a method or takes parameters, so it cannot become a package-level function variable directly."
+		s.Code = "// Store the result of sync.OnceValue in a field set by the constructor, e.g.\n" +
+			"// s.load = sync.OnceValue(func() T { ... })\n// and call s.load() where the method is used."
+		return s
+	}
+
+	body := strings.TrimSpace(string(a.src[a.offset(lit.Body.Lbrace)+1 : a.offset(lit.Body.Rbrace)]))
+	results := sig.Results()
+	var ret *ast.ReturnStmt
+	if len(list) == 2 {
+		if ret, ok = list[1].(*ast.ReturnStmt); !ok || len(ret.Results) != results.Len() {
+			return nil
+		}
+	} else if results.Len() != 0 {
+		return nil
+	}
+	switch results.Len() {
+	case 0:
+		s.Title = "Use sync.OnceFunc"
+		s.Code = fmt.Sprintf("var %s = sync.OnceFunc(func() {\n%s\n})", name, body)
+	case 1, 2:
+		typ := types.TypeString(results.At(0).Type(), a.qual)
+		fn := "sync.OnceValue"
+		if results.Len() == 2 {
+			if !isError(results.At(1).Type()) {
+				return nil
+			}
+			typ += ", error"
+			fn = "sync.OnceValues"
+		}
+		if results.Len() == 2 {
+			typ = "(" + typ + ")"
+		}
+		s.Title = "Use " + fn
+		s.Code = fmt.Sprintf("var %s = %s(func() %s {\n%s\n})", name, fn, typ, a.returnBody(lit.Body, ret, body))
+	default:
+		return nil
+	}
+	s.Code = formatDecls(s.Code)
+	s.Checklist = append(s.Checklist, fmt.Sprintf("Callers keep calling `%s()` unchanged.", name))
+	return s
+}
+
+// returnBody returns the body of the OnceValue function: "return expr" when the Do function only
+// assigns the returned variables, otherwise the original body followed by the original return.
+func (a *analyzer) returnBody(body *ast.BlockStmt, ret *ast.ReturnStmt, text string) string {
+	if len(body.List) == 1 {
+		if assign, ok := body.List[0].(*ast.AssignStmt); ok && assign.Tok == token.ASSIGN && len(assign.Lhs) == len(ret.Results) {
+			same := true
+			for i, lhs := range assign.Lhs {
+				if a.text(lhs) != a.text(ret.Results[i]) {
+					same = false
+				}
+			}
+			if same {
+				var rhs []string
+				for _, r := range assign.Rhs {
+					rhs = append(rhs, a.text(r))
+				}
+				return "return " + strings.Join(rhs, ", ")
+			}
+		}
+	}
+	return text + "\n" + a.text(ret)
+}
+
+// workerPool suggests the worker_pool snippet for workers started in a loop that range over a
+// jobs channel.
+func (a *analyzer) workerPool() *Suggestion {
+	var jobs types.Object
+	var workers string
+	inspectFunc(a.fd.Body, func(n ast.Node, inLit bool) {
+		if jobs != nil || inLit {
+			return
+		}
+		loop, count := a.countedLoop(n)
+		if loop == nil {
+			return
+		}
+		ast.Inspect(loop, func(n ast.Node) bool {
+			gs, ok := n.(*ast.GoStmt)
+			if !ok || jobs != nil {
+				return jobs == nil
+			}
+			lit, ok := gs.Call.Fun.(*ast.FuncLit)
+			if !ok {
+				return false
+			}
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				if r, ok := n.(*ast.RangeStmt); ok && jobs == nil {
+					if tv, ok := a.info.Types[r.X]; ok {
+						if _, isChan := tv.Type.Underlying().(*types.Chan); isChan {
+							if obj := a.objectOf(r.X); obj != nil {
+								jobs, workers = obj, count
+							}
+						}
+					}
+				}
+				return jobs == nil
+			})
+			return false
+		})
+	})
+	if jobs == nil {
+		return nil
+	}
+
+	name := "Pool"
+	if a.pkg.Types.Scope().Lookup(name) != nil || a.pkg.Types.Scope().Lookup("New"+name) != nil {
+		name = upperFirst(a.fd.Name.Name) + "Pool"
+	}
+	values := map[string]string{"name": name}
+	if isPositiveInt(workers) {
+		values["workers"] = workers
+	}
+	snippet, _ := snippetlib.Lookup("worker_pool")
+	code, err := snippet.Render(a.pkg.Name, values)
+	if err != nil {
+		return nil
+	}
+
+	job := "job"
+	inspectFunc(a.fd.Body, func(n ast.Node, inLit bool) {
+		if send, ok := n.(*ast.SendStmt); ok && job == "job" && a.refersTo(send.Chan, jobs) {
+			job = a.text(send.Value)
+		}
+	})
+	usage := fmt.Sprintf(`pool := New%s(%s)
+// Replace each send on %s with:
+if err := pool.Submit(ctx, func(ctx context.Context) {
+	process(ctx, %s) // the body of the worker loop
+}); err != nil {
+	return err
+}
+// Replace close(%s) and the wait for the workers with:
+if err := pool.Shutdown(ctx); err != nil {
+	return err
+}`, name, workers, jobs.Name(), job, jobs.Name())
+
+	return &Suggestion{
+		Kind:     KindWorkerPool,
+		Title:    "Use a worker pool with graceful shutdown",
+		Detected: fmt.Sprintf("%s worker goroutine(s) ranging over the channel `%s`.", workers, jobs.Name()),
+		Code:     code + "\n// Usage in " + a.fd.Name.Name + ":\n//\n" + commentOut(usage),
+		Checklist: []string{
+			"`Submit` blocks while every worker is busy and the queue is full; pass a context with a deadline where the caller must not block.",
+			"`Shutdown` drains the queued jobs; when its context expires it cancels the jobs' context and returns the context error.",
+			"Jobs must watch their context to stop promptly on shutdown.",
+			"Results and errors are no longer returned through channels: capture them in the submitted function (e.g. into a slice index or under a mutex).",
+			"Check for leftover goroutines with `leak_check` and run the tests with `-race`.",
+		},
+	}
+}
+
+// countedLoop returns n and its iteration count when n is "for i := 0; i < N; i++" or
+// "for range N".
+func (a *analyzer) countedLoop(n ast.Node) (ast.Node, string) {
+	switch loop := n.(type) {
+	case *ast.ForStmt:
+		if cond, ok := loop.Cond.(*ast.BinaryExpr); ok && cond.Op == token.LSS {
+			return loop, a.text(cond.Y)
+		}
+	case *ast.RangeStmt:
+		if tv, ok := a.info.Types[loop.X]; ok {
+			if basic, ok := tv.Type.Underlying().(*types.Basic); ok && basic.Info()&types.IsInteger != 0 {
+				return loop, a.text(loop.X)
+			}
+		}
+	}
+	return nil, ""
+}
+
+// channels finds an error channel and a semaphore channel (a buffered chan struct{}) declared at
+// the top level of the function, with the semaphore's capacity.
+func (a *analyzer) channels() (errCh, sem types.Object, limit string) {
+	for _, stmt := range a.fd.Body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			continue
+		}
+		id, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := a.info.Defs[id]
+		if obj == nil {
+			continue
+		}
+		ch, ok := obj.Type().Underlying().(*types.Chan)
+		if !ok {
+			continue
+		}
+		switch elem := ch.Elem().Underlying().(type) {
+		case *types.Interface:
+			if isError(ch.Elem()) && errCh == nil {
+				errCh = obj
+			}
+		case *types.Struct:
+			call, ok := assign.Rhs[0].(*ast.CallExpr)
+			if elem.NumFields() == 0 && ok && len(call.Args) == 2 && sem == nil {
+				sem, limit = obj, a.text(call.Args[1])
+			}
+		}
+	}
+	return errCh, sem, limit
+}
+
+// declared returns the object declared by stmt whose type matches.
+func (a *analyzer) declared(stmt ast.Stmt, match func(types.Type) bool) types.Object {
+	var found types.Object
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok && found == nil {
+			if obj := a.info.Defs[id]; obj != nil && match(obj.Type()) {
+				found = obj
+			}
+		}
+		return found == nil
+	})
+	return found
+}
+
+func (a *analyzer) objectOf(expr ast.Expr) types.Object {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return a.info.Uses[e]
+	case *ast.SelectorExpr:
+		return a.info.Uses[e.Sel]
+	}
+	return nil
+}
+
+func (a *analyzer) refersTo(expr ast.Expr, obj types.Object) bool {
+	return a.objectOf(expr) == obj
+}
+
+func (a *analyzer) uses(n ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && (a.info.Uses[id] == obj || a.info.Defs[id] == obj) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (a *analyzer) isMethodCall(expr ast.Expr, recv types.Object, method string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method && a.refersTo(sel.X, recv)
+}
+
+func (a *analyzer) calls(n ast.Node, recv types.Object, method string) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if expr, ok := n.(ast.Expr); ok && a.isMethodCall(expr, recv, method) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// onlyReceives reports whether call is func() { <-ch }().
+func (a *analyzer) onlyReceives(call *ast.CallExpr, ch types.Object) bool {
+	lit, ok := call.Fun.(*ast.FuncLit)
+	if !ok || len(lit.Body.List) != 1 {
+		return false
+	}
+	stmt, ok := lit.Body.List[0].(*ast.ExprStmt)
+	return ok && isRecv(stmt.X, a.info, ch)
+}
+
+func isRecv(expr ast.Expr, info *types.Info, ch types.Object) bool {
+	u, ok := expr.(*ast.UnaryExpr)
+	if !ok || u.Op != token.ARROW {
+		return false
+	}
+	id, ok := ast.Unparen(u.X).(*ast.Ident)
+	return ok && info.Uses[id] == ch
+}
+
+// guardsError reports whether body assigns an error variable between Lock and Unlock calls.
+func (a *analyzer) guardsError(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		list := stmtList(n)
+		locked := false
+		for _, stmt := range list {
+			if es, ok := stmt.(*ast.ExprStmt); ok {
+				if call, ok := es.X.(*ast.CallExpr); ok {
+					if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+						switch sel.Sel.Name {
+						case "Lock":
+							locked = true
+						case "Unlock":
+							locked = false
+						}
+					}
+				}
+			}
+			if locked && a.assignsError(stmt) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func (a *analyzer) assignsError(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.ASSIGN {
+			for _, lhs := range assign.Lhs {
+				if tv, ok := a.info.Types[lhs]; ok && isError(tv.Type) {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func (a *analyzer) containsAny(n ast.Node, goStmts []*ast.GoStmt) bool {
+	for _, gs := range goStmts {
+		if n.Pos() <= gs.Pos() && gs.End() <= n.End() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) waitsOn(n ast.Node, waits []*ast.ExprStmt) bool {
+	for _, w := range waits {
+		if n.Pos() <= w.Pos() && w.End() <= n.End() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) inFuncLit(n ast.Node) bool {
+	inside := false
+	ast.Inspect(a.fd.Body, func(x ast.Node) bool {
+		if lit, ok := x.(*ast.FuncLit); ok && lit.Pos() <= n.Pos() && n.End() <= lit.End() {
+			inside = true
+		}
+		return !inside
+	})
+	return inside
+}
+
+// contextParam returns the name of the function's context.Context parameter, if any.
+func (a *analyzer) contextParam() string {
+	for _, field := range a.fd.Type.Params.List {
+		if tv, ok := a.info.Types[field.Type]; ok && isNamed(tv.Type, "context", "Context") {
+			for _, name := range field.Names {
+				if name.Name != "_" {
+					return name.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// freeName returns the first candidate not used as an identifier in the function.
+func (a *analyzer) freeName(candidates ...string) string {
+	used := make(map[string]bool)
+	ast.Inspect(a.fd, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			used[id.Name] = true
+		}
+		return true
+	})
+	for _, c := range candidates {
+		if !used[c] {
+			return c
+		}
+	}
+	return candidates[len(candidates)-1] + "2"
+}
+
+// inspectFunc visits the nodes of body, reporting whether each is inside a function literal.
+func inspectFunc(body *ast.BlockStmt, fn func(n ast.Node, inLit bool)) {
+	var stack []ast.Node
+	lits := 0
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			if _, ok := stack[len(stack)-1].(*ast.FuncLit); ok {
+				lits--
+			}
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		fn(n, lits > 0)
+		if _, ok := n.(*ast.FuncLit); ok {
+			lits++
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
+
+func stmtList(n ast.Node) []ast.Stmt {
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		return n.List
+	case *ast.CaseClause:
+		return n.Body
+	case *ast.CommClause:
+		return n.Body
+	}
+	return nil
+}
+
+func isReturn(stmt ast.Stmt) bool {
+	_, ok := stmt.(*ast.ReturnStmt)
+	return ok
+}
+
+func isNamed(t types.Type, pkgPath, name string) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// zero returns the zero value of t as Go source.
+func zero(t types.Type, qual types.Qualifier) string {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + types.TypeString(t, qual) + ")"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil"
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qual) + "{}"
+	}
+	return "*new(" + types.TypeString(t, qual) + ")"
+}
+
+func isPositiveInt(s string) bool {
+	if s == "" || s == "0" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func commentOut(code string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(code, "\n") {
+		sb.WriteString("//\t" + line + "\n")
+	}
+	return sb.String()
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
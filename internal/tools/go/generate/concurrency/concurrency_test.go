@@ -0,0 +1,212 @@
+package concurrency
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const workSrc = `package work
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type Item struct{ ID int }
+
+func process(ctx context.Context, it Item) error {
+	if it.ID < 0 {
+		return fmt.Errorf("bad item %d", it.ID)
+	}
+	return nil
+}
+
+// ProcessAll processes items concurrently.
+func ProcessAll(ctx context.Context, items []Item) ([]string, error) {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(items))
+	sem := make(chan struct{}, 4)
+	out := make([]string, len(items))
+	for i, it := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, it Item) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := process(ctx, it); err != nil {
+				errCh <- err
+				return
+			}
+			out[i] = fmt.Sprint(it.ID)
+		}(i, it)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		return nil, err
+	}
+	return out, nil
+}
+
+var (
+	cfgOnce sync.Once
+	cfg     map[string]string
+	cfgErr  error
+)
+
+func loadConfig() (map[string]string, error) { return map[string]string{}, nil }
+
+// Config loads the configuration once.
+func Config() (map[string]string, error) {
+	cfgOnce.Do(func() {
+		cfg, cfgErr = loadConfig()
+	})
+	return cfg, cfgErr
+}
+
+// Run starts workers reading from a jobs channel.
+func Run(ctx context.Context, items []Item) {
+	jobs := make(chan Item)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range jobs {
+				_ = process(ctx, it)
+			}
+		}()
+	}
+	for _, it := range items {
+		jobs <- it
+	}
+	close(jobs)
+	wg.Wait()
+}
+`
+
+func suggest(t *testing.T, function string) string {
+	t.Helper()
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":       testutil.GoMod("example.com/app"),
+		"work/work.go": workSrc,
+	})
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "./work", Function: function})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	return out
+}
+
+func codeBlock(t *testing.T, out, heading string) string {
+	t.Helper()
+	_, section, ok := strings.Cut(out, heading)
+	if !ok {
+		t.Fatalf("missing section %q in:\n%s", heading, out)
+	}
+	_, code, _ := strings.Cut(section, "```go\n")
+	code, _, _ = strings.Cut(code, "```")
+	return code
+}
+
+func TestHandler_Errgroup(t *testing.T) {
+	out := suggest(t, "ProcessAll")
+	for _, want := range []string{
+		"error channel `errCh`, semaphore channel `sem` limiting concurrency to 4.",
+		"golang.org/x/sync/errgroup",
+		"- [ ] `g.Wait()` returns only the first error.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	code := codeBlock(t, out, "## Use errgroup")
+	for _, unwanted := range []string{"wg.", "errCh", "sem", "i := i"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("expected %q to be rewritten away, got:\n%s", unwanted, code)
+		}
+	}
+	if testing.Short() {
+		return
+	}
+
+	// The suggestion compiles in place of the original.
+	sum, err := os.ReadFile("../../../../../go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var syncSum []string
+	for _, line := range strings.Split(string(sum), "\n") {
+		if strings.HasPrefix(line, "golang.org/x/sync ") {
+			syncSum = append(syncSum, line)
+		}
+	}
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n\nrequire golang.org/x/sync v0.20.0\n",
+		"go.sum": strings.Join(syncSum, "\n") + "\n",
+		"work/work.go": `package work
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type Item struct{ ID int }
+
+func process(ctx context.Context, it Item) error { return fmt.Errorf("%d", it.ID) }
+
+` + code,
+	})
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("suggested code does not compile: %v\n%s\n%s", err, out, code)
+	}
+}
+
+func TestHandler_Once(t *testing.T) {
+	out := suggest(t, "Config")
+	want := "var Config = sync.OnceValues(func() (map[string]string, error) {\n\treturn loadConfig()\n})"
+	if code := codeBlock(t, out, "## Use sync.OnceValues"); !strings.Contains(code, want) {
+		t.Errorf("expected %q, got:\n%s", want, code)
+	}
+}
+
+func TestHandler_WorkerPool(t *testing.T) {
+	out := suggest(t, "Run")
+	// The workers never fail, so errgroup has nothing to add.
+	if strings.Contains(out, "## Use errgroup") {
+		t.Errorf("unexpected errgroup suggestion:\n%s", out)
+	}
+	code := codeBlock(t, out, "## Use a worker pool with graceful shutdown")
+	for _, want := range []string{"package work", "func NewPool(workers int) *Pool {", "workers = 8", "//\tpool := NewPool(8)", "process(ctx, it)"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("expected code to contain %q, got:\n%s", want, code)
+		}
+	}
+}
+
+func TestHandler_NotFound(t *testing.T) {
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":       testutil.GoMod("example.com/app"),
+		"work/work.go": workSrc,
+	})
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Package: "./work", Function: "Missing"})
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "function Missing not found") {
+		t.Errorf("expected a not-found error, got: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+}
@@ -0,0 +1,514 @@
+// Package constructor implements the generate_constructor tool, which writes a NewX constructor
+// with functional options for a struct, validation of its required fields, and a unit test.
+package constructor
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["generate_constructor"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir      string   `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package  string   `json:"package" jsonschema:"Package containing the struct, as an import path or a ./relative pattern"`
+	Struct   string   `json:"struct" jsonschema:"Name of the struct to generate a constructor for"`
+	Required []string `json:"required,omitempty" jsonschema:"Fields passed positionally to the constructor and validated as non-zero; all other fields get a With option"`
+	DryRun   bool     `json:"dry_run,omitempty" jsonschema:"Return the generated code without writing it"`
+}
+
+// field is a struct field as seen by the generator.
+type field struct {
+	Name     string
+	Type     types.Type
+	TypeStr  string
+	Required bool
+}
+
+// Plan is the generated code for one struct.
+type Plan struct {
+	Struct     string
+	OptionType string
+	Source     string
+	Test       string // empty if no test could be synthesized
+	SkipReason string
+	SourcePath string
+	TestPath   string
+}
+
+// Handler handles the generate_constructor tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Struct == "" {
+		return errorResult("package and struct are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, args.Package, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(pkgs) != 1 {
+		return errorResult(fmt.Sprintf("package pattern %q matched %d packages; pass a single package", args.Package, len(pkgs))), nil, nil
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return errorResult(fmt.Sprintf("package %s has errors: %v", pkg.PkgPath, pkg.Errors[0])), nil, nil
+	}
+
+	plan, err := Generate(pkg, args.Struct, args.Required)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Constructor for `%s`\n\n", args.Struct)
+	if args.DryRun {
+		sb.WriteString("Dry run: nothing was written.\n\n")
+	} else {
+		cs := shared.Changeset{plan.SourcePath: []byte(plan.Source)}
+		verify := [][]string{{"build", "./..."}}
+		if plan.Test != "" {
+			cs[plan.TestPath] = []byte(plan.Test)
+			verify = append(verify, []string{"test", "-count=1", "-run", "^TestNew" + upperFirst(args.Struct) + "$", pkg.PkgPath})
+		}
+		if err := cs.ApplyVerified(ctx, absDir, verify...); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Wrote `%s`", rel(absDir, plan.SourcePath))
+		if plan.Test != "" {
+			fmt.Fprintf(&sb, " and `%s` (tests pass)", rel(absDir, plan.TestPath))
+		}
+		sb.WriteString(".\n\n")
+	}
+	if plan.SkipReason != "" {
+		fmt.Fprintf(&sb, "⚠️ No test generated: %s\n\n", plan.SkipReason)
+	}
+	fmt.Fprintf(&sb, "## %s\n\n```go\n%s```\n", filepath.Base(plan.SourcePath), plan.Source)
+	if plan.Test != "" {
+		fmt.Fprintf(&sb, "\n## %s\n\n```go\n%s```\n", filepath.Base(plan.TestPath), plan.Test)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// Generate builds the constructor, options and test for structName in pkg.
+func Generate(pkg *packages.Package, structName string, required []string) (*Plan, error) {
+	obj := pkg.Types.Scope().Lookup(structName)
+	if obj == nil {
+		return nil, fmt.Errorf("type %s not found in package %s", structName, pkg.PkgPath)
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type", structName)
+	}
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", structName)
+	}
+	if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is generic; generic constructors are not supported", structName)
+	}
+
+	qual := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+
+	wanted := make(map[string]bool, len(required))
+	for _, r := range required {
+		wanted[r] = true
+	}
+	var fields []field
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Name() == "_" || isSyncType(f.Type()) {
+			continue
+		}
+		fields = append(fields, field{
+			Name:     f.Name(),
+			Type:     f.Type(),
+			TypeStr:  types.TypeString(f.Type(), qual),
+			Required: wanted[f.Name()],
+		})
+		delete(wanted, f.Name())
+	}
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("struct %s has no field(s) %s", structName, strings.Join(missing, ", "))
+	}
+
+	exported := token.IsExported(structName)
+	ctorName := exportAs("New"+upperFirst(structName), exported)
+	optionType := exportAs("Option", exported)
+	scope := pkg.Types.Scope()
+	if scope.Lookup(optionType) != nil {
+		optionType = exportAs(upperFirst(structName)+"Option", exported)
+	}
+	taken := []string{ctorName, optionType}
+	for _, f := range fields {
+		if !f.Required {
+			taken = append(taken, optionName(f.Name, exported))
+		}
+	}
+	for _, name := range taken {
+		if scope.Lookup(name) != nil {
+			return nil, fmt.Errorf("%s is already declared in package %s", name, pkg.PkgPath)
+		}
+	}
+
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+	pkgDir := filepath.Dir(pkg.GoFiles[0])
+	base := shared.SnakeCase(structName)
+
+	plan := &Plan{
+		Struct:     structName,
+		OptionType: optionType,
+		SourcePath: filepath.Join(pkgDir, base+"_options.go"),
+		TestPath:   filepath.Join(pkgDir, base+"_options_test.go"),
+	}
+	src, err := imports.Process(plan.SourcePath, []byte(renderSource(pkg.Name, structName, ctorName, optionType, exported, fields)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated constructor: %w", err)
+	}
+	plan.Source = string(src)
+	test, reason := renderTest(pkg.Name, structName, ctorName, exported, fields)
+	if test != "" {
+		out, err := imports.Process(plan.TestPath, []byte(test), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format generated test: %w", err)
+		}
+		plan.Test = string(out)
+	}
+	plan.SkipReason = reason
+	return plan, nil
+}
+
+func renderSource(pkgName, structName, ctorName, optionType string, exported bool, fields []field) string {
+	recv := receiverName(structName, fields)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+
+	fmt.Fprintf(&sb, "// %s configures a %s created by %s.\n", optionType, structName, ctorName)
+	fmt.Fprintf(&sb, "type %s func(*%s)\n", optionType, structName)
+
+	var required []field
+	for _, f := range fields {
+		if f.Required {
+			required = append(required, f)
+			continue
+		}
+		name := optionName(f.Name, exported)
+		fmt.Fprintf(&sb, "\n// %s sets the %s of the %s.\n", name, f.Name, structName)
+		fmt.Fprintf(&sb, "func %s(v %s) %s {\n", name, f.TypeStr, optionType)
+		fmt.Fprintf(&sb, "\treturn func(%s *%s) {\n\t\t%s.%s = v\n\t}\n}\n", recv, structName, recv, f.Name)
+	}
+
+	params := make([]string, 0, len(required)+1)
+	for _, f := range required {
+		params = append(params, paramName(f.Name)+" "+f.TypeStr)
+	}
+	params = append(params, "opts ..."+optionType)
+
+	sb.WriteString("\n")
+	if len(required) > 0 {
+		names := make([]string, len(required))
+		for i, f := range required {
+			names[i] = paramName(f.Name)
+		}
+		fmt.Fprintf(&sb, "// %s creates a %s. %s %s required; optional fields are set with %s values.\n",
+			ctorName, structName, joinAnd(names), plural(len(names), "is", "are"), optionType)
+	} else {
+		fmt.Fprintf(&sb, "// %s creates a %s configured by opts.\n", ctorName, structName)
+	}
+	fmt.Fprintf(&sb, "func %s(%s) (*%s, error) {\n", ctorName, strings.Join(params, ", "), structName)
+	for _, f := range required {
+		if cond := zeroCheck(paramName(f.Name), f.Type); cond != "" {
+			fmt.Fprintf(&sb, "\tif %s {\n\t\treturn nil, errors.New(%q)\n\t}\n", cond, paramName(f.Name)+" is required")
+		}
+	}
+	fmt.Fprintf(&sb, "\t%s := &%s{", recv, structName)
+	if len(required) > 0 {
+		sb.WriteString("\n")
+		for _, f := range required {
+			fmt.Fprintf(&sb, "\t\t%s: %s,\n", f.Name, paramName(f.Name))
+		}
+		sb.WriteString("\t")
+	}
+	sb.WriteString("}\n")
+	fmt.Fprintf(&sb, "\tfor _, opt := range opts {\n\t\topt(%s)\n\t}\n", recv)
+	fmt.Fprintf(&sb, "\treturn %s, nil\n}\n", recv)
+	return sb.String()
+}
+
+// renderTest writes a table of constructor calls: one that succeeds with every option applied,
+// and one per validated required field that must fail when the field is zero. It returns a reason
+// instead if a value for some required field cannot be synthesized.
+func renderTest(pkgName, structName, ctorName string, exported bool, fields []field) (string, string) {
+	var required, optional []field
+	samples := make(map[string]string)
+	for _, f := range fields {
+		sample, ok := sampleValue(f.Type, f.TypeStr)
+		if f.Required {
+			if !ok {
+				return "", fmt.Sprintf("cannot synthesize a test value for required field %s (%s)", f.Name, f.TypeStr)
+			}
+			required = append(required, f)
+		} else if ok {
+			optional = append(optional, f)
+		}
+		if ok {
+			samples[f.Name] = sample
+		}
+	}
+
+	args := func(zero string) string {
+		parts := make([]string, 0, len(required))
+		for _, f := range required {
+			if f.Name == zero {
+				parts = append(parts, zeroValue(f.Type, f.TypeStr))
+			} else {
+				parts = append(parts, samples[f.Name])
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+	fmt.Fprintf(&sb, "func TestNew%s(t *testing.T) {\n", upperFirst(structName))
+
+	call := args("")
+	var opts []string
+	for _, f := range optional {
+		opts = append(opts, fmt.Sprintf("%s(%s)", optionName(f.Name, exported), samples[f.Name]))
+	}
+	if len(opts) > 0 {
+		if call != "" {
+			call += ", "
+		}
+		call += strings.Join(opts, ", ")
+	}
+	fmt.Fprintf(&sb, "\tgot, err := %s(%s)\n", ctorName, call)
+	sb.WriteString("\tif err != nil {\n\t\tt.Fatalf(\"unexpected error: %v\", err)\n\t}\n")
+	for _, f := range append(append([]field{}, required...), optional...) {
+		fmt.Fprintf(&sb, "\tif !reflect.DeepEqual(got.%s, %s) {\n", f.Name, samples[f.Name])
+		fmt.Fprintf(&sb, "\t\tt.Errorf(\"%s = %%v, want %%v\", got.%s, %s)\n\t}\n", f.Name, f.Name, samples[f.Name])
+	}
+
+	for _, f := range required {
+		if zeroCheck(paramName(f.Name), f.Type) == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n\tif _, err := %s(%s); err == nil {\n", ctorName, args(f.Name))
+		fmt.Fprintf(&sb, "\t\tt.Error(\"expected an error when %s is missing\")\n\t}\n", paramName(f.Name))
+	}
+	sb.WriteString("}\n")
+	return sb.String(), ""
+}
+
+// isSyncType reports whether t is a sync or sync/atomic type, which must not be copied and so
+// never gets an option.
+func isSyncType(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	path := named.Obj().Pkg().Path()
+	return path == "sync" || path == "sync/atomic"
+}
+
+// zeroCheck returns the condition that is true when name holds the zero value of t, or "" if
+// the type has no meaningful "missing" value (bools, structs, arrays).
+func zeroCheck(name string, t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return name + ` == ""`
+		case u.Info()&types.IsNumeric != 0:
+			return name + " == 0"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return name + " == nil"
+	}
+	return ""
+}
+
+// sampleValue returns a non-zero literal of type t, if one can be written without knowing
+// anything about the type's invariants.
+func sampleValue(t types.Type, typeStr string) (string, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return conv(typeStr, `"test"`, "string"), true
+		case u.Info()&types.IsBoolean != 0:
+			return conv(typeStr, "true", "bool"), true
+		case u.Info()&types.IsNumeric != 0:
+			return conv(typeStr, "1", "int"), true
+		}
+	case *types.Pointer:
+		if _, ok := u.Elem().Underlying().(*types.Struct); ok {
+			return "&" + strings.TrimPrefix(typeStr, "*") + "{}", true
+		}
+	case *types.Slice:
+		return typeStr + "{}", true
+	case *types.Map:
+		return typeStr + "{}", true
+	case *types.Struct:
+		return typeStr + "{}", true
+	}
+	return "", false
+}
+
+// conv wraps an untyped literal in a conversion unless typeStr is the literal's default type,
+// so that the value compares equal to the stored field under reflect.DeepEqual.
+func conv(typeStr, lit, defaultType string) string {
+	if typeStr == defaultType {
+		return lit
+	}
+	return typeStr + "(" + lit + ")"
+}
+
+func zeroValue(t types.Type, typeStr string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		}
+		return "0"
+	case *types.Struct, *types.Array:
+		return typeStr + "{}"
+	}
+	return "nil"
+}
+
+func receiverName(structName string, fields []field) string {
+	r := string(unicode.ToLower([]rune(structName)[0]))
+	for _, f := range fields {
+		if f.Required && paramName(f.Name) == r {
+			return "obj"
+		}
+	}
+	if r == "v" || r == "t" || token.IsKeyword(r) {
+		return "obj"
+	}
+	return r
+}
+
+func paramName(fieldName string) string {
+	runes := []rune(fieldName)
+	// Lower the leading run of capitals so that e.g. "DBConn" becomes "dbConn" and "URL" becomes "url".
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i--
+	}
+	for j := 0; j < i; j++ {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	name := string(runes)
+	if token.IsKeyword(name) || name == "opts" || name == "errors" {
+		name += "Value"
+	}
+	return name
+}
+
+func optionName(fieldName string, exported bool) string {
+	return exportAs("With"+upperFirst(fieldName), exported)
+}
+
+func exportAs(name string, exported bool) string {
+	if exported {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func joinAnd(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
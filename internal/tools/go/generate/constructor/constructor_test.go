@@ -0,0 +1,124 @@
+package constructor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const serverSrc = `package server
+
+import (
+	"sync"
+	"time"
+)
+
+type Level int
+
+type Server struct {
+	mu      sync.Mutex
+	Addr    string
+	Port    int
+	Timeout time.Duration
+	Level   Level
+	Tags    []string
+	Verbose bool
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":           testutil.GoMod("example.com/app"),
+		"server/server.go": serverSrc,
+	})
+}
+
+func TestHandler_Generate(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{
+		Dir:      dir,
+		Package:  "./server",
+		Struct:   "Server",
+		Required: []string{"Addr", "Port"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "server", "server_options.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wants := []string{
+		"type Option func(*Server)",
+		"func WithTimeout(v time.Duration) Option",
+		"func NewServer(addr string, port int, opts ...Option) (*Server, error)",
+		`return nil, errors.New("addr is required")`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "WithMu") {
+		t.Errorf("sync fields must not get options, got:\n%s", src)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "server", "server_options_test.go")); err != nil {
+		t.Errorf("expected test file: %v", err)
+	}
+	if !strings.Contains(out, "tests pass") {
+		t.Errorf("expected generated tests to run, got:\n%s", out)
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "./server", Struct: "Server", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "server", "server_options.go")); !os.IsNotExist(err) {
+		t.Errorf("dry run must not write files")
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	dir := setup(t)
+
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{"unknown field", Params{Dir: dir, Package: "./server", Struct: "Server", Required: []string{"Host"}}, "no field(s) Host"},
+		{"not a struct", Params{Dir: dir, Package: "./server", Struct: "Level"}, "is not a struct"},
+		{"missing type", Params{Dir: dir, Package: "./server", Struct: "Client"}, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _, err := Handler(context.Background(), nil, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := res.Content[0].(*mcp.TextContent).Text
+			if !res.IsError || !strings.Contains(out, tt.want) {
+				t.Errorf("expected error containing %q, got:\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+// Package enum implements the generate_enum tool, which writes String, MarshalJSON, UnmarshalJSON
+// and ParseX for an iota-based enum type, together with round-trip tests.
+package enum
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["generate_enum"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir        string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package    string `json:"package" jsonschema:"Package containing the enum, as an import path or a ./relative pattern"`
+	Type       string `json:"type" jsonschema:"Name of the integer enum type"`
+	TrimPrefix string `json:"trim_prefix,omitempty" jsonschema:"Prefix to remove from constant names in their string form (like stringer -trimprefix)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Return the generated code without writing it"`
+}
+
+// Value is one enum member.
+type Value struct {
+	Const string // Go constant name
+	Name  string // string form
+	Lit   string // value as a Go literal
+
+	val constant.Value
+}
+
+// Plan is the set of file changes for one enum.
+type Plan struct {
+	Type       string
+	Values     []Value
+	Source     string
+	Test       string
+	SourcePath string
+	TestPath   string
+	Changes    shared.Changeset // generated files plus removed stringer output and directives
+	Replaced   []string         // human-readable notes on what was replaced
+}
+
+// Handler handles the generate_enum tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Type == "" {
+		return errorResult("package and type are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, args.Package, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(pkgs) != 1 {
+		return errorResult(fmt.Sprintf("package pattern %q matched %d packages; pass a single package", args.Package, len(pkgs))), nil, nil
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return errorResult(fmt.Sprintf("package %s has errors: %v", pkg.PkgPath, pkg.Errors[0])), nil, nil
+	}
+
+	plan, err := Generate(pkg, args.Type, args.TrimPrefix)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Enum methods for `%s` (%d values)\n\n", args.Type, len(plan.Values))
+	if args.DryRun {
+		sb.WriteString("Dry run: nothing was written.\n\n")
+	} else {
+		testRun := []string{"test", "-count=1", "-run", "^Test" + upperFirst(args.Type) + "Enum$", pkg.PkgPath}
+		if err := plan.Changes.ApplyVerified(ctx, absDir, []string{"build", "./..."}, testRun); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Wrote `%s` and `%s` (tests pass).\n\n", rel(absDir, plan.SourcePath), rel(absDir, plan.TestPath))
+	}
+	for _, note := range plan.Replaced {
+		fmt.Fprintf(&sb, "- %s\n", note)
+	}
+	if len(plan.Replaced) > 0 {
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "## %s\n\n```go\n%s```\n", filepath.Base(plan.SourcePath), plan.Source)
+	fmt.Fprintf(&sb, "\n## %s\n\n```go\n%s```\n", filepath.Base(plan.TestPath), plan.Test)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// Generate builds the enum methods and tests for typeName in pkg. Existing stringer output for the
+// type and its go:generate directive are scheduled for removal.
+func Generate(pkg *packages.Package, typeName, trimPrefix string) (*Plan, error) {
+	obj, ok := pkg.Types.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", typeName, pkg.PkgPath)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a defined type", typeName)
+	}
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 {
+		return nil, fmt.Errorf("%s must have an integer underlying type", typeName)
+	}
+	unsigned := basic.Info()&types.IsUnsigned != 0
+
+	values, err := collectValues(pkg.Types, named, trimPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+	pkgDir := filepath.Dir(pkg.GoFiles[0])
+	base := shared.SnakeCase(typeName)
+	plan := &Plan{
+		Type:       typeName,
+		Values:     values,
+		SourcePath: filepath.Join(pkgDir, base+"_enum.go"),
+		TestPath:   filepath.Join(pkgDir, base+"_enum_test.go"),
+		Changes:    shared.Changeset{},
+	}
+
+	// Existing methods either come from stringer, in which case the generated file is replaced,
+	// or were written by hand, in which case the tool refuses to overwrite them.
+	generated := make(map[string]bool)
+	for _, file := range pkg.Syntax {
+		if ast.IsGenerated(file) && strings.Contains(commentText(file), "stringer") {
+			generated[pkg.Fset.File(file.Pos()).Name()] = true
+		}
+	}
+	mset := types.NewMethodSet(types.NewPointer(named))
+	for _, name := range []string{"String", "MarshalJSON", "UnmarshalJSON"} {
+		sel := mset.Lookup(pkg.Types, name)
+		if sel == nil {
+			continue
+		}
+		file := pkg.Fset.Position(sel.Obj().Pos()).Filename
+		if name == "String" && generated[file] {
+			plan.Changes[file] = nil
+			plan.Replaced = append(plan.Replaced, fmt.Sprintf("Removed stringer output `%s`.", filepath.Base(file)))
+			continue
+		}
+		return nil, fmt.Errorf("%s already has a %s method at %s", typeName, name, pkg.Fset.Position(sel.Obj().Pos()))
+	}
+	parseName := "Parse" + upperFirst(typeName)
+	if !token.IsExported(typeName) {
+		parseName = "parse" + upperFirst(typeName)
+	}
+	if pkg.Types.Scope().Lookup(parseName) != nil {
+		return nil, fmt.Errorf("%s is already declared in package %s", parseName, pkg.PkgPath)
+	}
+
+	if err := dropDirectives(pkg, typeName, plan); err != nil {
+		return nil, err
+	}
+
+	src, err := imports.Process(plan.SourcePath, []byte(renderSource(pkg.Name, typeName, parseName, unsigned, values)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated methods: %w", err)
+	}
+	test, err := imports.Process(plan.TestPath, []byte(renderTest(pkg.Name, typeName, parseName, values)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated test: %w", err)
+	}
+	plan.Source, plan.Test = string(src), string(test)
+	plan.Changes[plan.SourcePath] = src
+	plan.Changes[plan.TestPath] = test
+	return plan, nil
+}
+
+// collectValues returns the package-level constants of type named, ordered by value. When several
+// constants share a value, the first declared one names it.
+func collectValues(pkg *types.Package, named *types.Named, trimPrefix string) ([]Value, error) {
+	var consts []*types.Const
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), named) && name != "_" {
+			consts = append(consts, c)
+		}
+	}
+	if len(consts) == 0 {
+		return nil, fmt.Errorf("no constants of type %s found", named.Obj().Name())
+	}
+	sort.SliceStable(consts, func(i, j int) bool {
+		if cmp := constant.Compare(consts[i].Val(), token.LSS, consts[j].Val()); cmp {
+			return true
+		}
+		if constant.Compare(consts[i].Val(), token.EQL, consts[j].Val()) {
+			return consts[i].Pos() < consts[j].Pos()
+		}
+		return false
+	})
+
+	var values []Value
+	names := make(map[string]string)
+	for i, c := range consts {
+		if i > 0 && constant.Compare(consts[i-1].Val(), token.EQL, c.Val()) {
+			continue
+		}
+		name := strings.TrimPrefix(c.Name(), trimPrefix)
+		if name == "" {
+			name = c.Name()
+		}
+		if prev, dup := names[name]; dup {
+			return nil, fmt.Errorf("constants %s and %s both map to the string %q", prev, c.Name(), name)
+		}
+		names[name] = c.Name()
+		values = append(values, Value{Const: c.Name(), Name: name, Lit: c.Val().ExactString(), val: c.Val()})
+	}
+	return values, nil
+}
+
+// dropDirectives removes "//go:generate stringer -type=T" lines that target only this type, since
+// regenerating would conflict with the new methods.
+func dropDirectives(pkg *packages.Package, typeName string, plan *Plan) error {
+	for _, file := range pkg.Syntax {
+		path := pkg.Fset.File(file.Pos()).Name()
+		if _, deleted := plan.Changes[path]; deleted {
+			continue
+		}
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if !isStringerDirective(c.Text, typeName) {
+					continue
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", path, err)
+				}
+				lines := strings.Split(string(content), "\n")
+				line := pkg.Fset.Position(c.Pos()).Line - 1
+				lines = append(lines[:line], lines[line+1:]...)
+				plan.Changes[path] = []byte(strings.Join(lines, "\n"))
+				plan.Replaced = append(plan.Replaced, fmt.Sprintf("Removed `%s` from `%s`.", c.Text, filepath.Base(path)))
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func isStringerDirective(text, typeName string) bool {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || fields[0] != "//go:generate" {
+		return false
+	}
+	tool := fields[1]
+	if tool == "go" && len(fields) > 3 && fields[2] == "run" {
+		tool = fields[3]
+	}
+	if !strings.HasSuffix(strings.Split(tool, "@")[0], "stringer") {
+		return false
+	}
+	for i, f := range fields {
+		if f == "-type="+typeName || (f == "-type" && i+1 < len(fields) && fields[i+1] == typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderSource(pkgName, typeName, parseName string, unsigned bool, values []Value) string {
+	recv := strings.ToLower(string([]rune(typeName)[0]))
+	if recv == "s" || recv == "v" || recv == "d" {
+		recv = "e"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+
+	fmt.Fprintf(&sb, "// String returns the name of the %s.\n", typeName)
+	fmt.Fprintf(&sb, "func (%s %s) String() string {\n\tswitch %s {\n", recv, typeName, recv)
+	for _, v := range values {
+		fmt.Fprintf(&sb, "\tcase %s:\n\t\treturn %q\n", v.Const, v.Name)
+	}
+	if unsigned {
+		fmt.Fprintf(&sb, "\t}\n\treturn \"%s(\" + strconv.FormatUint(uint64(%s), 10) + \")\"\n}\n\n", typeName, recv)
+	} else {
+		fmt.Fprintf(&sb, "\t}\n\treturn \"%s(\" + strconv.FormatInt(int64(%s), 10) + \")\"\n}\n\n", typeName, recv)
+	}
+
+	fmt.Fprintf(&sb, "// %s returns the %s named s.\n", parseName, typeName)
+	fmt.Fprintf(&sb, "func %s(s string) (%s, error) {\n\tswitch s {\n", parseName, typeName)
+	for _, v := range values {
+		fmt.Fprintf(&sb, "\tcase %q:\n\t\treturn %s, nil\n", v.Name, v.Const)
+	}
+	fmt.Fprintf(&sb, "\t}\n\treturn 0, fmt.Errorf(\"invalid %s %%q\", s)\n}\n\n", typeName)
+
+	fmt.Fprintf(&sb, "// MarshalJSON encodes the %s as its name. Values without a name are an error.\n", typeName)
+	fmt.Fprintf(&sb, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, typeName)
+	fmt.Fprintf(&sb, "\ts := %s.String()\n\tif _, err := %s(s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn json.Marshal(s)\n}\n\n", recv, parseName)
+
+	fmt.Fprintf(&sb, "// UnmarshalJSON decodes a %s from its name.\n", typeName)
+	fmt.Fprintf(&sb, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, typeName)
+	sb.WriteString("\tvar s string\n\tif err := json.Unmarshal(data, &s); err != nil {\n")
+	fmt.Fprintf(&sb, "\t\treturn fmt.Errorf(\"%s should be a string, got %%s\", data)\n\t}\n", typeName)
+	fmt.Fprintf(&sb, "\tv, err := %s(s)\n\tif err != nil {\n\t\treturn err\n\t}\n\t*%s = v\n\treturn nil\n}\n", parseName, recv)
+	return sb.String()
+}
+
+func renderTest(pkgName, typeName, parseName string, values []Value) string {
+	consts := make([]string, len(values))
+	for i, v := range values {
+		consts[i] = v.Const
+	}
+	unknown := nextValue(values)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+	fmt.Fprintf(&sb, "func Test%sEnum(t *testing.T) {\n", upperFirst(typeName))
+	fmt.Fprintf(&sb, "\tfor _, v := range []%s{%s} {\n", typeName, strings.Join(consts, ", "))
+	sb.WriteString("\t\ts := v.String()\n")
+	fmt.Fprintf(&sb, "\t\tgot, err := %s(s)\n", parseName)
+	fmt.Fprintf(&sb, "\t\tif err != nil || got != v {\n\t\t\tt.Errorf(\"%s(%%q) = %%v, %%v; want %%v\", s, got, err, v)\n\t\t}\n", parseName)
+	sb.WriteString("\t\tdata, err := json.Marshal(v)\n\t\tif err != nil {\n\t\t\tt.Fatalf(\"json.Marshal(%v): %v\", v, err)\n\t\t}\n")
+	fmt.Fprintf(&sb, "\t\tvar back %s\n", typeName)
+	sb.WriteString("\t\tif err := json.Unmarshal(data, &back); err != nil || back != v {\n")
+	sb.WriteString("\t\t\tt.Errorf(\"json round trip of %v = %v, %v\", v, back, err)\n\t\t}\n\t}\n\n")
+	fmt.Fprintf(&sb, "\tif _, err := %s(\"not a %s\"); err == nil {\n", parseName, typeName)
+	sb.WriteString("\t\tt.Error(\"expected an error for an unknown name\")\n\t}\n")
+	fmt.Fprintf(&sb, "\tif _, err := json.Marshal(%s(%s)); err == nil {\n", typeName, unknown)
+	sb.WriteString("\t\tt.Error(\"expected an error when marshaling an unnamed value\")\n\t}\n}\n")
+	return sb.String()
+}
+
+// nextValue returns a literal one past the largest enum value, which has no name.
+func nextValue(values []Value) string {
+	return constant.BinaryOp(values[len(values)-1].val, token.ADD, constant.MakeInt64(1)).ExactString()
+}
+
+func commentText(file *ast.File) string {
+	var sb strings.Builder
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		sb.WriteString(group.Text())
+	}
+	return sb.String()
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
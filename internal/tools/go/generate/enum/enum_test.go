@@ -0,0 +1,132 @@
+package enum
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const colorSrc = `package color
+
+//go:generate stringer -type=Color -trimprefix=Color
+
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorBlue
+	ColorDefault = ColorRed
+)
+`
+
+const stringerSrc = `// Code generated by "stringer -type=Color -trimprefix=Color"; DO NOT EDIT.
+
+package color
+
+import "strconv"
+
+func (i Color) String() string {
+	return "Color(" + strconv.Itoa(int(i)) + ")"
+}
+`
+
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	files["go.mod"] = testutil.GoMod("example.com/app")
+	return testutil.WriteModule(t, files)
+}
+
+func TestHandler_ReplacesStringer(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"color/color.go":        colorSrc,
+		"color/color_string.go": stringerSrc,
+	})
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "./color", Type: "Color", TrimPrefix: "Color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "color", "color_enum.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wants := []string{
+		"func (c Color) String() string",
+		`case ColorGreen:
+		return "Green"`,
+		"func ParseColor(s string) (Color, error)",
+		"func (c Color) MarshalJSON() ([]byte, error)",
+		"func (c *Color) UnmarshalJSON(data []byte) error",
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "ColorDefault") {
+		t.Errorf("aliases must not produce duplicate cases, got:\n%s", src)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "color", "color_string.go")); !os.IsNotExist(err) {
+		t.Errorf("expected stringer output to be removed")
+	}
+	decl, err := os.ReadFile(filepath.Join(dir, "color", "color.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(decl), "go:generate") {
+		t.Errorf("expected go:generate directive to be removed, got:\n%s", decl)
+	}
+	if !strings.Contains(out, "tests pass") {
+		t.Errorf("expected generated tests to run, got:\n%s", out)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"kinds/kinds.go": `package kinds
+
+type Name string
+
+type Kind uint8
+
+func (k Kind) String() string { return "kind" }
+
+const KindA Kind = 1
+
+type Empty int
+`,
+	})
+
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{"Name", "integer underlying type"},
+		{"Kind", "already has a String method"},
+		{"Empty", "no constants of type Empty"},
+		{"Missing", "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "./kinds", Type: tt.typ})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := res.Content[0].(*mcp.TextContent).Text
+			if !res.IsError || !strings.Contains(out, tt.want) {
+				t.Errorf("expected error containing %q, got:\n%s", tt.want, out)
+			}
+		})
+	}
+}
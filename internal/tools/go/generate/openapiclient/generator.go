@@ -0,0 +1,681 @@
+package openapiclient
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/openapi"
+	"golang.org/x/tools/imports"
+)
+
+// GeneratedMarker starts the first line of every generated file; files carrying it may be
+// overwritten by a later run.
+const GeneratedMarker = "// Code generated by godoctor generate_openapi_client"
+
+// Method describes one generated client method.
+type Method struct {
+	Name      string
+	HTTP      string // "GET /pets/{petId}"
+	Summary   string
+	Signature string // without the receiver
+	Call      string // an example call, "client.ShowPetByID(ctx, petID)"
+	Returns   bool   // whether the method returns a decoded body
+}
+
+// Result is a generated client package.
+type Result struct {
+	Files   map[string][]byte // base name → formatted source
+	Methods []Method
+	Types   []string
+	BaseURL string // first absolute server URL, or ""
+	Notes   []string
+}
+
+// generator accumulates the declarations of one package.
+type generator struct {
+	spec      *openapi.Spec
+	pkg       string
+	types     strings.Builder
+	methods   strings.Builder
+	declared  map[string]bool   // package-level names in use
+	component map[string]string // component schema name → Go type name
+	inline    map[*openapi.Schema]string
+	result    *Result
+	noted     map[string]bool
+}
+
+// clientNames are declared by the client.go skeleton.
+var clientNames = []string{
+	"Client", "Option", "RequestEditor", "APIError", "New", "WithHTTPClient", "WithRequestEditor",
+	"DefaultBaseURL", "formatValue", "encodeJSON",
+}
+
+// Generate renders a client package named pkg for spec. source names the document in the
+// generated header.
+func Generate(spec *openapi.Spec, pkg, source string) (*Result, error) {
+	g := &generator{
+		spec:      spec,
+		pkg:       pkg,
+		declared:  make(map[string]bool),
+		component: make(map[string]string),
+		inline:    make(map[*openapi.Schema]string),
+		result:    &Result{Files: make(map[string][]byte)},
+		noted:     make(map[string]bool),
+	}
+	for _, n := range clientNames {
+		g.declared[n] = true
+	}
+
+	names := make([]string, 0, len(spec.Components.Schemas))
+	for n := range spec.Components.Schemas {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		g.component[n] = g.name(openapi.GoName(n))
+	}
+	for _, n := range names {
+		g.define(g.component[n], spec.Components.Schemas[n], "the "+n+" schema")
+	}
+
+	ops := spec.Operations()
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("the document defines no operations")
+	}
+	for _, op := range ops {
+		g.operation(op)
+	}
+
+	for _, s := range spec.Servers {
+		if strings.HasPrefix(s.URL, "http://") || strings.HasPrefix(s.URL, "https://") {
+			if strings.Contains(s.URL, "{") {
+				g.note("server %s uses variables; pass the expanded base URL to New", s.URL)
+				continue
+			}
+			g.result.BaseURL = s.URL
+			break
+		}
+	}
+
+	header := fmt.Sprintf("%s from %s; DO NOT EDIT.\n\n", GeneratedMarker, filepath.Base(source))
+	title := spec.Info.Title
+	if title == "" {
+		title = "the API"
+	} else {
+		title = "the " + title + " API"
+	}
+	if spec.Info.Version != "" {
+		title += ", version " + spec.Info.Version
+	}
+	client := header + fmt.Sprintf("// Package %s is a client for %s.\npackage %s\n\n", pkg, title, pkg) +
+		skeleton(g.result.BaseURL) + g.methods.String()
+	types := header + "package " + pkg + "\n\n" + typesImports + g.types.String()
+	for name, src := range map[string]string{"client.go": client, "types.go": types} {
+		out, err := imports.Process(name, []byte(src), nil)
+		if err != nil {
+			return nil, fmt.Errorf("generated %s does not parse: %w", name, err)
+		}
+		g.result.Files[name] = out
+	}
+	sort.Strings(g.result.Types)
+	return g.result, nil
+}
+
+func (g *generator) note(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !g.noted[msg] {
+		g.noted[msg] = true
+		g.result.Notes = append(g.result.Notes, msg)
+	}
+}
+
+// name reserves a package-level name, numbering it when taken.
+func (g *generator) name(want string) string {
+	name := want
+	for i := 2; g.declared[name]; i++ {
+		name = fmt.Sprintf("%s%d", want, i)
+	}
+	g.declared[name] = true
+	return name
+}
+
+// goType returns the Go type of a schema, declaring named types for inline objects and enums
+// under hint.
+func (g *generator) goType(s *openapi.Schema, hint string) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		if name, ok := g.component[openapi.RefName(s.Ref)]; ok && strings.HasPrefix(s.Ref, "#/components/schemas/") {
+			return name
+		}
+		g.note("reference %s is not a local schema; typed as any", s.Ref)
+		return "any"
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		g.note("%s uses oneOf/anyOf; it is kept as json.RawMessage for the caller to decode", hint)
+		return "json.RawMessage"
+	}
+	if isStruct(s) || isEnum(s) {
+		if name, ok := g.inline[s]; ok {
+			return name // reached again through allOf
+		}
+		name := g.name(hint)
+		g.inline[s] = name
+		g.define(name, s, "an inline schema")
+		return name
+	}
+	if len(s.AllOf) == 1 {
+		return g.goType(s.AllOf[0], hint)
+	}
+	types := s.Types()
+	if len(types) > 1 {
+		return "any"
+	}
+	var typ string
+	if len(types) == 1 {
+		typ = types[0]
+	}
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32"
+		case "int64":
+			return "int64"
+		}
+		return "int"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(s.Items, hint+"Item")
+	case "object", "":
+		if add := s.AdditionalSchema(); add != nil {
+			return "map[string]" + g.goType(add, hint+"Value")
+		}
+		if typ == "object" {
+			return "map[string]any"
+		}
+	}
+	return "any"
+}
+
+func isStruct(s *openapi.Schema) bool {
+	return s.Ref == "" && len(s.OneOf) == 0 && len(s.AnyOf) == 0 && (len(s.Properties) > 0 || len(s.AllOf) > 1)
+}
+
+func isEnum(s *openapi.Schema) bool {
+	types := s.Types()
+	return s.Ref == "" && len(s.Enum) > 0 && len(types) == 1 && types[0] == "string"
+}
+
+// nilable reports whether a Go type already has a nil value, so optional values need no pointer.
+func nilable(typ string) bool {
+	return typ == "any" || typ == "json.RawMessage" || strings.HasPrefix(typ, "[]") ||
+		strings.HasPrefix(typ, "map[") || strings.HasPrefix(typ, "*")
+}
+
+func pointer(typ string) string {
+	if nilable(typ) {
+		return typ
+	}
+	return "*" + typ
+}
+
+// comment renders text as // lines with the given indentation.
+func comment(indent, text string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		sb.WriteString(strings.TrimRight(indent+"// "+strings.TrimSpace(line), " ") + "\n")
+	}
+	return sb.String()
+}
+
+// define declares the already reserved type name for s.
+func (g *generator) define(name string, s *openapi.Schema, what string) {
+	g.result.Types = append(g.result.Types, name)
+	doc := fmt.Sprintf("// %s is %s.\n", name, what)
+	if s.Description != "" {
+		doc += "//\n" + comment("", s.Description)
+	}
+	switch {
+	case isStruct(s):
+		g.defineStruct(name, s, doc)
+	case isEnum(s):
+		g.defineEnum(name, s, doc)
+	case s.Ref != "":
+		fmt.Fprintf(&g.types, "%stype %s = %s\n\n", doc, name, g.goType(s, name))
+	default:
+		typ := g.goType(s, name+"Item")
+		if typ == name {
+			typ = "any"
+		}
+		fmt.Fprintf(&g.types, "%stype %s %s\n\n", doc, name, typ)
+	}
+}
+
+func (g *generator) defineStruct(name string, s *openapi.Schema, doc string) {
+	props, required := g.fields(s)
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var body strings.Builder
+	used := make(map[string]bool)
+	for _, k := range keys {
+		p := props[k]
+		field := openapi.GoName(k)
+		for i := 2; used[field]; i++ {
+			field = fmt.Sprintf("%s%d", openapi.GoName(k), i)
+		}
+		used[field] = true
+		typ := g.goType(p, name+openapi.GoName(k))
+		tag := k
+		if !required[k] {
+			tag += ",omitempty"
+		}
+		if !required[k] || p.IsNullable() || typ == name {
+			typ = pointer(typ) // a struct cannot contain itself
+		}
+		if p.Description != "" {
+			body.WriteString(comment("\t", p.Description))
+		}
+		fmt.Fprintf(&body, "\t%s %s `json:%s`\n", field, typ, strconv.Quote(tag))
+	}
+	fmt.Fprintf(&g.types, "%stype %s struct {\n%s}\n\n", doc, name, body.String())
+}
+
+// fields merges the properties and required lists of s and its allOf members.
+func (g *generator) fields(s *openapi.Schema) (map[string]*openapi.Schema, map[string]bool) {
+	props := make(map[string]*openapi.Schema)
+	required := make(map[string]bool)
+	var walk func(s *openapi.Schema, depth int)
+	walk = func(s *openapi.Schema, depth int) {
+		resolved, err := g.spec.Schema(s)
+		if err != nil {
+			g.note("%v; the properties it contributes are skipped", err)
+			return
+		}
+		if resolved == nil || depth > 16 {
+			return
+		}
+		for _, sub := range resolved.AllOf {
+			walk(sub, depth+1)
+		}
+		if len(resolved.OneOf) > 0 || len(resolved.AnyOf) > 0 {
+			g.note("oneOf/anyOf inside allOf is not merged into the struct")
+		}
+		for k, v := range resolved.Properties {
+			props[k] = v
+		}
+		for _, r := range resolved.Required {
+			required[r] = true
+		}
+	}
+	walk(s, 0)
+	return props, required
+}
+
+func (g *generator) defineEnum(name string, s *openapi.Schema, doc string) {
+	fmt.Fprintf(&g.types, "%stype %s string\n\n", doc, name)
+	fmt.Fprintf(&g.types, "// %s values.\nconst (\n", name)
+	for _, v := range s.Enum {
+		if v == nil {
+			continue
+		}
+		value := fmt.Sprint(v)
+		fmt.Fprintf(&g.types, "\t%s %s = %s\n", g.name(name+openapi.GoName(value)), name, strconv.Quote(value))
+	}
+	g.types.WriteString(")\n\n")
+}
+
+// reservedArgs are the identifiers generated method bodies use.
+var reservedArgs = map[string]bool{
+	"c": true, "ctx": true, "params": true, "body": true, "path": true, "query": true, "header": true,
+	"payload": true, "out": true, "err": true, "r": true, "v": true, "bytes": true, "context": true,
+	"fmt": true, "http": true, "io": true, "json": true, "strings": true, "time": true, "url": true,
+	"formatValue": true, "encodeJSON": true,
+}
+
+// operation declares the client method, and its parameter struct, for op.
+func (g *generator) operation(op *openapi.Operation) {
+	name := g.name(op.GoName())
+	m := Method{Name: name, HTTP: op.Method + " " + op.Path, Summary: strings.TrimSpace(op.Summary)}
+
+	var pathParams, otherParams []*openapi.Parameter
+	byName := make(map[string]*openapi.Parameter)
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			byName[p.Name] = p
+		case "query", "header":
+			otherParams = append(otherParams, p)
+		default:
+			g.note("%s: %s parameter %q is not supported; set it with WithRequestEditor", name, p.In, p.Name)
+		}
+	}
+
+	var sig, call []string
+	sig = append(sig, "ctx context.Context")
+	call = append(call, "ctx")
+	args := make(map[string]string) // path parameter name → argument
+	types := make(map[string]string)
+	var pathExpr []string
+	rest := op.Path
+	for {
+		i := strings.Index(rest, "{")
+		j := strings.Index(rest, "}")
+		if i < 0 || j < i {
+			break
+		}
+		if i > 0 {
+			pathExpr = append(pathExpr, strconv.Quote(rest[:i]))
+		}
+		pname := rest[i+1 : j]
+		rest = rest[j+1:]
+		arg, ok := args[pname]
+		if !ok {
+			p := byName[pname]
+			if p == nil {
+				g.note("%s: path parameter %q is not declared; it is typed as string", name, pname)
+				p = &openapi.Parameter{Name: pname, Schema: &openapi.Schema{Type: "string"}}
+			}
+			pathParams = append(pathParams, p)
+			arg = openapi.LowerName(pname)
+			if reservedArgs[arg] || token.IsKeyword(arg) {
+				arg += "Param"
+			}
+			args[pname] = arg
+			types[pname] = g.goType(p.Schema, name+openapi.GoName(pname))
+			sig = append(sig, arg+" "+types[pname])
+			call = append(call, arg)
+		}
+		if types[pname] == "string" {
+			pathExpr = append(pathExpr, "url.PathEscape("+arg+")")
+		} else {
+			pathExpr = append(pathExpr, "url.PathEscape(formatValue("+arg+"))")
+		}
+	}
+	if rest != "" || len(pathExpr) == 0 {
+		pathExpr = append(pathExpr, strconv.Quote(rest))
+	}
+
+	var code strings.Builder
+	fmt.Fprintf(&code, "\tpath := %s\n", strings.Join(pathExpr, " + "))
+	query, header := "nil", "nil"
+	if len(otherParams) > 0 {
+		paramsType := g.name(name + "Params")
+		sig = append(sig, "params *"+paramsType)
+		call = append(call, "nil")
+		var fields, set strings.Builder
+		used := make(map[string]bool)
+		var usesQuery, usesHeader bool
+		for _, p := range otherParams {
+			field := openapi.GoName(p.Name)
+			for i := 2; used[field]; i++ {
+				field = fmt.Sprintf("%s%d", openapi.GoName(p.Name), i)
+			}
+			used[field] = true
+			typ := g.goType(p.Schema, paramsType+field)
+			if !p.Required {
+				typ = pointer(typ)
+			}
+			if p.Description != "" {
+				fields.WriteString(comment("\t", p.Description))
+			}
+			fmt.Fprintf(&fields, "\t%s %s\n", field, typ)
+
+			target := "query"
+			if p.In == "header" {
+				target, usesHeader = "header", true
+			} else {
+				usesQuery = true
+			}
+			key := strconv.Quote(p.Name)
+			switch {
+			case strings.HasPrefix(typ, "[]") && typ != "[]byte":
+				fmt.Fprintf(&set, "\t\tfor _, v := range params.%s {\n\t\t\t%s.Add(%s, formatValue(v))\n\t\t}\n", field, target, key)
+			case strings.HasPrefix(typ, "*"):
+				fmt.Fprintf(&set, "\t\tif params.%s != nil {\n\t\t\t%s.Set(%s, formatValue(*params.%s))\n\t\t}\n", field, target, key, field)
+			case nilable(typ):
+				fmt.Fprintf(&set, "\t\tif params.%s != nil {\n\t\t\t%s.Set(%s, formatValue(params.%s))\n\t\t}\n", field, target, key, field)
+			default:
+				fmt.Fprintf(&set, "\t\t%s.Set(%s, formatValue(params.%s))\n", target, key, field)
+			}
+		}
+		fmt.Fprintf(&g.types, "// %s holds the query and header parameters of %s. Optional parameters are\n// pointers and are omitted when nil.\ntype %s struct {\n%s}\n\n",
+			paramsType, name, paramsType, fields.String())
+		if usesQuery {
+			code.WriteString("\tquery := url.Values{}\n")
+			query = "query"
+		}
+		if usesHeader {
+			code.WriteString("\theader := http.Header{}\n")
+			header = "header"
+		}
+		fmt.Fprintf(&code, "\tif params != nil {\n%s\t}\n", set.String())
+	}
+
+	var result, zero string
+	if _, resp := op.SuccessResponse(); resp != nil {
+		if mt := openapi.JSONContent(resp.Content); mt != nil && mt.Schema != nil {
+			result = g.goType(mt.Schema, name+"Response")
+			zero = "nil"
+		}
+	}
+	returns := "error"
+	if result != "" {
+		returns = "(" + pointer(result) + ", error)"
+	}
+	fail := "return err"
+	if result != "" {
+		fail = "return " + zero + ", err"
+	}
+
+	payload, contentType := "nil", `""`
+	if rb := op.RequestBody; rb != nil && len(rb.Content) > 0 {
+		payload = "payload"
+		if mt := openapi.JSONContent(rb.Content); mt != nil {
+			typ := g.goType(mt.Schema, name+"Request")
+			if !rb.Required {
+				typ = pointer(typ)
+			}
+			sig = append(sig, "body "+typ)
+			call = append(call, "body")
+			contentType = strconv.Quote("application/json")
+			if !rb.Required {
+				fmt.Fprintf(&code, "\tvar payload io.Reader\n\tif body != nil {\n\t\tr, err := encodeJSON(body)\n\t\tif err != nil {\n\t\t\t%s\n\t\t}\n\t\tpayload = r\n\t}\n", fail)
+			} else {
+				fmt.Fprintf(&code, "\tpayload, err := encodeJSON(body)\n\tif err != nil {\n\t\t%s\n\t}\n", fail)
+			}
+		} else {
+			keys := make([]string, 0, len(rb.Content))
+			for k := range rb.Content {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			sig = append(sig, "body io.Reader")
+			call = append(call, "body")
+			contentType = strconv.Quote(keys[0])
+			payload = "body"
+		}
+	}
+
+	doCall := fmt.Sprintf("c.do(ctx, %s, path, %s, %s, %s, %s, %%s)", strconv.Quote(op.Method), query, header, payload, contentType)
+	if result != "" {
+		fmt.Fprintf(&code, "\tvar out %s\n\tif err := %s; err != nil {\n\t\treturn nil, err\n\t}\n", result, fmt.Sprintf(doCall, "&out"))
+		if nilable(result) {
+			code.WriteString("\treturn out, nil\n")
+		} else {
+			code.WriteString("\treturn &out, nil\n")
+		}
+	} else {
+		fmt.Fprintf(&code, "\treturn %s\n", fmt.Sprintf(doCall, "nil"))
+	}
+
+	m.Signature = fmt.Sprintf("%s(%s) %s", name, strings.Join(sig, ", "), returns)
+	m.Call = fmt.Sprintf("client.%s(%s)", name, strings.Join(call, ", "))
+	m.Returns = result != ""
+	g.result.Methods = append(g.result.Methods, m)
+
+	doc := fmt.Sprintf("// %s calls %s.\n", name, m.HTTP)
+	if m.Summary != "" {
+		doc = fmt.Sprintf("// %s calls %s: %s\n", name, m.HTTP, strings.TrimSuffix(oneLine(m.Summary), ".")+".")
+	}
+	if op.Deprecated {
+		doc += "//\n// Deprecated: the API marks this operation as deprecated.\n"
+	}
+	fmt.Fprintf(&g.methods, "%sfunc (c *Client) %s {\n%s}\n\n", doc, m.Signature, code.String())
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+const typesImports = `import (
+	"encoding/json"
+	"time"
+)
+
+`
+
+// skeleton returns the client.go declarations shared by every generated package.
+func skeleton(baseURL string) string {
+	var sb strings.Builder
+	sb.WriteString(`import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+`)
+	if baseURL != "" {
+		fmt.Fprintf(&sb, "// DefaultBaseURL is the first server the API description lists.\nconst DefaultBaseURL = %s\n\n", strconv.Quote(baseURL))
+	}
+	sb.WriteString(`// Client calls the API. Create one with New; it is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	editors    []RequestEditor
+}
+
+// RequestEditor changes a request before it is sent, for example to add authentication.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRequestEditor adds a function that edits every request before it is sent.
+func WithRequestEditor(fn RequestEditor) Option {
+	return func(c *Client) { c.editors = append(c.editors, fn) }
+}
+
+// New returns a client for the API at baseURL.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is returned for responses outside the 2xx range.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+// do sends a request and decodes a JSON response into out, unless out is nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader, contentType string, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	for _, edit := range c.editors {
+		if err := edit(ctx, req); err != nil {
+			return err
+		}
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return &APIError{StatusCode: resp.StatusCode, Body: data}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// encodeJSON returns the JSON encoding of v as a request body.
+func encodeJSON(v any) (io.Reader, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// formatValue renders a parameter value for a URL or header.
+func formatValue(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+`)
+	return sb.String()
+}
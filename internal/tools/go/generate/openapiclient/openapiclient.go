@@ -0,0 +1,264 @@
+// Package openapiclient implements the generate_openapi_client tool, which turns an OpenAPI 3
+// document into a typed Go client package inside the workspace and checks that it builds.
+package openapiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/openapi"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+)
+
+// maxSpecSize caps the size of a downloaded document.
+const maxSpecSize = 16 << 20
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["generate_openapi_client"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Spec    string `json:"spec" jsonschema:"Path (relative to dir) or http(s) URL of an OpenAPI 3 document in JSON or YAML"`
+	Output  string `json:"output,omitempty" jsonschema:"Directory of the generated package, relative to dir (default: internal/<api title>)"`
+	Package string `json:"package,omitempty" jsonschema:"Package name (default: the base name of output)"`
+	DryRun  bool   `json:"dry_run,omitempty" jsonschema:"Return the generated code without writing it"`
+}
+
+// Handler handles the generate_openapi_client tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Spec == "" {
+		return errorResult("spec is required: a path or URL of an OpenAPI 3 document"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	data, err := LoadSpec(ctx, session, absDir, args.Spec)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		return errorResult(fmt.Sprintf("%s: %v", args.Spec, err)), nil, nil
+	}
+
+	output := args.Output
+	if output == "" {
+		output = filepath.Join("internal", PackageName(spec.Info.Title))
+	}
+	outDir := filepath.Join(absDir, output)
+	if r, err := filepath.Rel(absDir, outDir); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return errorResult(fmt.Sprintf("output %q must be inside %s", output, absDir)), nil, nil
+	}
+	pkgName := args.Package
+	if pkgName == "" {
+		pkgName = PackageName(filepath.Base(outDir))
+	}
+	importPath, err := ImportPath(outDir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	res, err := Generate(spec, pkgName, args.Spec)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	cs := shared.Changeset{}
+	names := make([]string, 0, len(res.Files))
+	for name := range res.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(outDir, name)
+		if existing, err := os.ReadFile(path); err == nil && !strings.HasPrefix(string(existing), GeneratedMarker) {
+			return errorResult(fmt.Sprintf("%s exists and was not generated by this tool; choose another output directory", rel(absDir, path))), nil, nil
+		}
+		cs[path] = res.Files[name]
+	}
+
+	var sb strings.Builder
+	title := spec.Info.Title
+	if title == "" {
+		title = "API"
+	}
+	fmt.Fprintf(&sb, "# Go client for %s %s\n\n", title, spec.Info.Version)
+	if args.DryRun {
+		sb.WriteString("Dry run: nothing was written.\n\n")
+	} else {
+		if err := cs.ApplyVerified(ctx, absDir, []string{"vet", "./" + filepath.ToSlash(rel(absDir, outDir))}); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Generated `%s` in `%s` (%s): %d operations, %d types; `go vet` passes.\n\n",
+			importPath, rel(absDir, outDir), strings.Join(names, ", "), len(res.Methods), len(res.Types))
+	}
+
+	writeUsage(&sb, pkgName, importPath, res)
+
+	sb.WriteString("\n## Docs\n\n")
+	fmt.Fprintf(&sb, "- Generated API: call `read_docs` with package `%s`.\n", importPath)
+	if d := spec.ExternalDocs; d != nil && d.URL != "" {
+		desc := d.Description
+		if desc == "" {
+			desc = "API documentation"
+		}
+		fmt.Fprintf(&sb, "- %s: %s\n", oneLine(desc), d.URL)
+	}
+	fmt.Fprintf(&sb, "- Source document: %s\n", args.Spec)
+
+	if len(res.Notes) > 0 {
+		sb.WriteString("\n## Notes\n\n")
+		for _, n := range res.Notes {
+			fmt.Fprintf(&sb, "- %s\n", n)
+		}
+	}
+	if args.DryRun {
+		for _, name := range names {
+			fmt.Fprintf(&sb, "\n## %s\n\n```go\n%s```\n", name, res.Files[name])
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// writeUsage renders a usage snippet and the list of operations.
+func writeUsage(sb *strings.Builder, pkgName, importPath string, res *Result) {
+	example := res.Methods[0]
+	for _, m := range res.Methods {
+		if m.Returns && strings.HasPrefix(m.HTTP, http.MethodGet+" ") {
+			example = m
+			break
+		}
+	}
+	baseURL := pkgName + ".DefaultBaseURL"
+	if res.BaseURL == "" {
+		baseURL = `"https://api.example.com"`
+	}
+	sb.WriteString("## Usage\n\n```go\nimport \"" + importPath + "\"\n\n")
+	fmt.Fprintf(sb, "client := %s.New(%s)\n", pkgName, baseURL)
+	if example.Returns {
+		fmt.Fprintf(sb, "result, err := %s\n", example.Call)
+	} else {
+		fmt.Fprintf(sb, "err := %s\n", example.Call)
+	}
+	sb.WriteString("```\n\n")
+	fmt.Fprintf(sb, "Add authentication or headers with `%[1]s.WithRequestEditor`, and a custom `*http.Client` with `%[1]s.WithHTTPClient`. Responses outside 2xx are returned as `*%[1]s.APIError`.\n\n", pkgName)
+
+	sb.WriteString("## Operations\n\n| Method | HTTP | Summary |\n|---|---|---|\n")
+	for _, m := range res.Methods {
+		fmt.Fprintf(sb, "| `%s` | `%s` | %s |\n", m.Signature, m.HTTP, strings.ReplaceAll(oneLine(m.Summary), "|", `\|`))
+	}
+}
+
+// LoadSpec reads an OpenAPI document from an http(s) URL or from a path inside the workspace
+// roots, relative paths being resolved against dir.
+func LoadSpec(ctx context.Context, session *mcp.ServerSession, dir, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, status, err := shared.HTTPRequest(ctx, http.MethodGet, source, nil, nil, maxSpecSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", source, err)
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("failed to download %s: status %d", source, status)
+		}
+		return data, nil
+	}
+	path := source
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path, err := roots.Global.Validate(session, path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// ImportPath returns the import path of a package directory from the enclosing go.mod.
+func ImportPath(dir string) (string, error) {
+	for root := dir; ; {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			mod := modfile.ModulePath(data)
+			if mod == "" {
+				return "", fmt.Errorf("%s/go.mod has no module path", root)
+			}
+			r, _ := filepath.Rel(root, dir)
+			if r == "." {
+				return mod, nil
+			}
+			return mod + "/" + filepath.ToSlash(r), nil
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+		root = parent
+	}
+}
+
+// PackageName turns a title or directory name into a package name: "Swagger Petstore" becomes
+// "swaggerpetstore".
+func PackageName(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		}
+	}
+	name := sb.String()
+	switch {
+	case name == "":
+		return "apiclient"
+	case unicode.IsDigit(rune(name[0])):
+		return "api" + name
+	}
+	return name
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,210 @@
+package openapiclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://petstore.example.com/v1
+externalDocs:
+  url: https://petstore.example.com/docs
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List all pets
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, format: int32}
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items: {type: string}
+        - name: X-Request-ID
+          in: header
+          required: true
+          schema: {type: string}
+      responses:
+        '200':
+          description: A page of pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/NewPet'}
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: integer, format: int64}
+    get:
+      operationId: showPetById
+      summary: Info for a specific pet
+      responses:
+        '200':
+          description: A pet
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+    delete:
+      operationId: deletePet
+      responses:
+        '204':
+          description: Deleted
+components:
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        status:
+          type: string
+          enum: [available, sold]
+        born: {type: string, format: date-time}
+    Pet:
+      description: A pet in the store.
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          required: [id]
+          properties:
+            id: {type: integer, format: int64}
+            owner:
+              type: object
+              properties:
+                name: {type: string}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":        "module example.com/app\n\ngo 1.24\n",
+		"petstore.yaml": petstore,
+	})
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestHandler_Generate(t *testing.T) {
+	dir := setup(t)
+	text, isErr := call(t, Params{Dir: dir, Spec: "petstore.yaml"})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"✅ Generated `example.com/app/internal/petstore`",
+		"client := petstore.New(petstore.DefaultBaseURL)",
+		"`ListPets(ctx context.Context, params *ListPetsParams) ([]Pet, error)`",
+		"`ShowPetByID(ctx context.Context, petID int64) (*Pet, error)`",
+		"`CreatePet(ctx context.Context, body NewPet) (*Pet, error)`",
+		"`DeletePet(ctx context.Context, petID int64) error`",
+		"https://petstore.example.com/docs",
+		"`read_docs` with package `example.com/app/internal/petstore`",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+
+	types, err := os.ReadFile(filepath.Join(dir, "internal", "petstore", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by godoctor generate_openapi_client from petstore.yaml; DO NOT EDIT.",
+		"NewPetStatusAvailable NewPetStatus = \"available\"",
+		"Born   *time.Time    `json:\"born,omitempty\"`",
+		"ID     int64         `json:\"id\"`",
+		"Owner  *PetOwner     `json:\"owner,omitempty\"`",
+		"XRequestID string",
+		"Tags       []string",
+	} {
+		if !strings.Contains(string(types), want) {
+			t.Errorf("types.go missing %q:\n%s", want, types)
+		}
+	}
+
+	// A second run overwrites the files it generated.
+	if text, isErr := call(t, Params{Dir: dir, Spec: "petstore.yaml"}); isErr {
+		t.Fatalf("regeneration failed: %s", text)
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := setup(t)
+	text, isErr := call(t, Params{Dir: dir, Spec: "petstore.yaml", Output: "pkg/pets", Package: "pets", DryRun: true})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	if !strings.Contains(text, "Dry run") || !strings.Contains(text, "package pets") {
+		t.Errorf("unexpected dry run output:\n%s", text)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg", "pets")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote files: %v", err)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	dir := setup(t)
+	handWritten := filepath.Join(dir, "internal", "petstore", "client.go")
+	if err := os.MkdirAll(filepath.Dir(handWritten), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(handWritten, []byte("package petstore\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "swagger.json"), []byte(`{"swagger": "2.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args Params
+		want string
+	}{
+		{Params{Dir: dir}, "spec is required"},
+		{Params{Dir: dir, Spec: "swagger.json"}, "convert it to OpenAPI 3"},
+		{Params{Dir: dir, Spec: "petstore.yaml", Output: "../elsewhere"}, "must be inside"},
+		{Params{Dir: dir, Spec: "petstore.yaml"}, "was not generated by this tool"},
+	}
+	for _, tt := range tests {
+		text, isErr := call(t, tt.args)
+		if !isErr || !strings.Contains(text, tt.want) {
+			t.Errorf("%+v: got %q (error %v), want %q", tt.args, text, isErr, tt.want)
+		}
+	}
+}
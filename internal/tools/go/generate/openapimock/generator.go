@@ -0,0 +1,388 @@
+package openapimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/openapi"
+	"golang.org/x/tools/imports"
+)
+
+// generatedMarker starts the first line of every generated file; files carrying it may be
+// overwritten by a later run.
+const generatedMarker = "// Code generated by godoctor generate_openapi_mock"
+
+// Route is one operation served by the fake.
+type Route struct {
+	Name    string // Operation constant
+	HTTP    string // "GET /pets/{petId}"
+	Pattern string // http.ServeMux pattern
+	Status  int    // default status
+	Example string // default JSON body, or ""
+}
+
+// Result is a generated fake server package.
+type Result struct {
+	Files  map[string][]byte // base name → formatted source
+	Routes []Route
+	Notes  []string
+}
+
+// serverNames are declared by the server.go skeleton.
+var serverNames = map[string]bool{
+	"Operation": true, "Response": true, "Request": true, "Server": true, "New": true,
+	"routes": true, "defaults": true, "respond": true, "TestDefaults": true, "TestRespond": true,
+}
+
+var identRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Generate renders a fake server package named pkg for spec. source names the document in the
+// generated header.
+func Generate(spec *openapi.Spec, pkg, source string) (*Result, error) {
+	ops := spec.Operations()
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("the document defines no operations")
+	}
+	res := &Result{Files: make(map[string][]byte)}
+	declared := make(map[string]bool)
+	for n := range serverNames {
+		declared[n] = true
+	}
+	for _, op := range ops {
+		name := op.GoName()
+		for i := 2; declared[name]; i++ {
+			name = fmt.Sprintf("%s%d", op.GoName(), i)
+		}
+		declared[name] = true
+		r := Route{Name: name, HTTP: op.Method + " " + op.Path, Pattern: op.Method + " " + pattern(op.Path), Status: 200}
+		if code, resp := op.SuccessResponse(); resp != nil {
+			if n, err := strconv.Atoi(code); err == nil {
+				r.Status = n
+			}
+			if mt := openapi.JSONContent(resp.Content); mt != nil {
+				example := mt.Example
+				if example == nil {
+					example = spec.Example(mt.Schema)
+				}
+				data, err := json.Marshal(example)
+				if err != nil {
+					return nil, fmt.Errorf("%s: example response: %w", r.HTTP, err)
+				}
+				r.Example = string(data)
+			} else if len(resp.Content) > 0 {
+				res.Notes = append(res.Notes, fmt.Sprintf("%s: the response is not JSON; the default response has no body", r.HTTP))
+			}
+		}
+		res.Routes = append(res.Routes, r)
+	}
+
+	header := fmt.Sprintf("%s from %s; DO NOT EDIT.\n\n", generatedMarker, filepath.Base(source))
+	title := spec.Info.Title
+	if title == "" {
+		title = "API"
+	}
+	api := "the " + title + " API"
+	if spec.Info.Version != "" {
+		api += ", version " + spec.Info.Version
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	fmt.Fprintf(&sb, `// Package %s is a fake of %s, for tests.
+//
+// Every operation answers with a canned response built from the examples in the API
+// description until a test replaces it with Respond or Handle.
+package %s
+
+`, pkg, api, pkg)
+	sb.WriteString(skeletonImports)
+	sb.WriteString("// Operations of the API.\nconst (\n")
+	for _, r := range res.Routes {
+		fmt.Fprintf(&sb, "\t%s Operation = %s // %s\n", r.Name, strconv.Quote(r.Name), r.HTTP)
+	}
+	sb.WriteString(")\n\n// routes maps every operation to its http.ServeMux pattern.\nvar routes = map[Operation]string{\n")
+	for _, r := range res.Routes {
+		fmt.Fprintf(&sb, "\t%s: %s,\n", r.Name, strconv.Quote(r.Pattern))
+	}
+	sb.WriteString("}\n\n// defaults are the responses of a new Server.\nvar defaults = map[Operation]Response{\n")
+	for _, r := range res.Routes {
+		if r.Example == "" {
+			fmt.Fprintf(&sb, "\t%s: {Status: %d},\n", r.Name, r.Status)
+		} else {
+			fmt.Fprintf(&sb, "\t%s: {Status: %d, Body: json.RawMessage(%s)},\n", r.Name, r.Status, goString(r.Example))
+		}
+	}
+	sb.WriteString("}\n\n")
+	// The title ends up in a comment and in a format string.
+	safe := strings.NewReplacer(`"`, "", `\`, "", "%", "", "\n", " ").Replace(title)
+	sb.WriteString(strings.ReplaceAll(skeleton, "{{title}}", safe))
+
+	var tb strings.Builder
+	tb.WriteString(header)
+	fmt.Fprintf(&tb, "package %s\n\n%s", pkg, testImports)
+	tb.WriteString("func TestDefaults(t *testing.T) {\n\ts := New(t)\n\ttests := []struct {\n\t\top     Operation\n\t\tmethod string\n\t\tpath   string\n\t\tstatus int\n\t}{\n")
+	for _, r := range res.Routes {
+		method, path, _ := strings.Cut(r.HTTP, " ")
+		fmt.Fprintf(&tb, "\t\t{%s, %s, %s, %d},\n", r.Name, strconv.Quote(method), strconv.Quote(samplePath(path)), r.Status)
+	}
+	tb.WriteString(testBody)
+	first := res.Routes[0]
+	method, path, _ := strings.Cut(first.HTTP, " ")
+	fmt.Fprintf(&tb, respondTest, first.Name, strconv.Quote(method), strconv.Quote(samplePath(path)))
+
+	for name, src := range map[string]string{"server.go": sb.String(), "server_test.go": tb.String()} {
+		out, err := imports.Process(name, []byte(src), nil)
+		if err != nil {
+			return nil, fmt.Errorf("generated %s does not parse: %w", name, err)
+		}
+		res.Files[name] = out
+	}
+	return res, nil
+}
+
+// pattern turns an OpenAPI path template into an http.ServeMux pattern. Parameter names that
+// are not Go identifiers are renamed, since ServeMux wildcards must be.
+func pattern(path string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(path, "{")
+		j := strings.Index(path, "}")
+		if i < 0 || j < i {
+			break
+		}
+		name := path[i+1 : j]
+		if !identRx.MatchString(name) {
+			name = openapi.LowerName(name)
+		}
+		sb.WriteString(path[:i] + "{" + name + "}")
+		path = path[j+1:]
+	}
+	sb.WriteString(path)
+	if strings.HasSuffix(sb.String(), "/") {
+		sb.WriteString("{$}") // match the path exactly, not the subtree
+	}
+	return sb.String()
+}
+
+// samplePath fills the parameters of a path template with "1".
+func samplePath(path string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(path, "{")
+		j := strings.Index(path, "}")
+		if i < 0 || j < i {
+			break
+		}
+		sb.WriteString(path[:i] + "1")
+		path = path[j+1:]
+	}
+	sb.WriteString(path)
+	return sb.String()
+}
+
+// goString quotes s as a raw string when it can.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+const skeletonImports = `import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// Operation names an API operation.
+type Operation string
+
+`
+
+const skeleton = `// Response is a canned response. Body is encoded as JSON unless it is a []byte or a
+// json.RawMessage; a zero Status means 200.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   any
+}
+
+// Request is a request the server received.
+type Request struct {
+	Operation Operation // empty when no operation matched
+	Method    string
+	Path      string
+	Query     url.Values
+	Header    http.Header
+	Body      []byte
+}
+
+// Server is a running fake of the {{title}} API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	t        testing.TB
+	mu       sync.Mutex
+	handlers map[Operation]http.HandlerFunc
+	requests []Request
+}
+
+// New starts a fake server and closes it when the test ends. Point the client under test at
+// s.URL. Requests that match no operation fail the test.
+func New(t testing.TB) *Server {
+	s := &Server{t: t, handlers: make(map[Operation]http.HandlerFunc)}
+	mux := http.NewServeMux()
+	for op, pattern := range routes {
+		s.handlers[op] = respond(defaults[op])
+		mux.Handle(pattern, s.serve(op))
+	}
+	mux.Handle("/", s.serve(""))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Respond makes op answer with status and body from now on.
+func (s *Server) Respond(op Operation, status int, body any) {
+	s.Handle(op, respond(Response{Status: status, Body: body}))
+}
+
+// Handle makes op call h from now on, for responses that depend on the request. Path
+// parameters are available through r.PathValue.
+func (s *Server) Handle(op Operation, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[op] = h
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor returns the requests received so far for op, in order.
+func (s *Server) RequestsFor(op Operation) []Request {
+	var out []Request
+	for _, r := range s.Requests() {
+		if r.Operation == op {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (s *Server) serve(op Operation) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Operation: op,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.Query(),
+			Header:    r.Header.Clone(),
+			Body:      body,
+		})
+		h := s.handlers[op]
+		s.mu.Unlock()
+		if h == nil {
+			s.t.Errorf("fake {{title}} server: no operation matches %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	})
+}
+
+func respond(resp Response) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var data []byte
+		switch b := resp.Body.(type) {
+		case nil:
+		case []byte:
+			data = b
+		case json.RawMessage:
+			data = b
+		default:
+			var err error
+			if data, err = json.Marshal(b); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		if data != nil && w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		status := resp.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		w.Write(data)
+	}
+}
+`
+
+const testImports = `import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+`
+
+const testBody = `	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, s.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := s.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, resp.StatusCode, tt.status)
+		}
+		if got := s.RequestsFor(tt.op); len(got) != 1 {
+			t.Errorf("%s %s: recorded %d requests for %s, want 1", tt.method, tt.path, len(got), tt.op)
+		}
+	}
+}
+
+`
+
+const respondTest = `func TestRespond(t *testing.T) {
+	s := New(t)
+	s.Respond(%s, http.StatusTeapot, map[string]string{"message": "canned"})
+	req, err := http.NewRequest(%s, s.URL+%s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTeapot || !strings.Contains(string(body), "canned") {
+		t.Errorf("got %%d %%s, want the canned response", resp.StatusCode, body)
+	}
+}
+`
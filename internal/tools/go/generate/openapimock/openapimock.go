@@ -0,0 +1,238 @@
+// Package openapimock implements the generate_openapi_mock tool, which turns an OpenAPI 3
+// document into an httptest-based fake server package with canned responses, for the tests of
+// code that consumes the API.
+package openapimock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/openapi"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/generate/openapiclient"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["generate_openapi_mock"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Spec    string `json:"spec" jsonschema:"Path (relative to dir) or http(s) URL of an OpenAPI 3 document in JSON or YAML"`
+	Output  string `json:"output,omitempty" jsonschema:"Directory of the generated package, relative to dir (default: internal/<api title>mock)"`
+	Package string `json:"package,omitempty" jsonschema:"Package name (default: the base name of output)"`
+	Client  string `json:"client,omitempty" jsonschema:"Directory of a client generated by generate_openapi_client, relative to dir, for the usage example (default: internal/<api title> when it exists)"`
+	DryRun  bool   `json:"dry_run,omitempty" jsonschema:"Return the generated code without writing it"`
+}
+
+// Handler handles the generate_openapi_mock tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Spec == "" {
+		return errorResult("spec is required: a path or URL of an OpenAPI 3 document"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	data, err := openapiclient.LoadSpec(ctx, session, absDir, args.Spec)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		return errorResult(fmt.Sprintf("%s: %v", args.Spec, err)), nil, nil
+	}
+
+	apiName := openapiclient.PackageName(spec.Info.Title)
+	output := args.Output
+	if output == "" {
+		output = filepath.Join("internal", apiName+"mock")
+	}
+	outDir := filepath.Join(absDir, output)
+	if r, err := filepath.Rel(absDir, outDir); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return errorResult(fmt.Sprintf("output %q must be inside %s", output, absDir)), nil, nil
+	}
+	pkgName := args.Package
+	if pkgName == "" {
+		pkgName = openapiclient.PackageName(filepath.Base(outDir))
+	}
+	importPath, err := openapiclient.ImportPath(outDir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	// The routes use the method and wildcard patterns of http.ServeMux.
+	if v := goVersion(outDir); v != "" && semver.Compare("v"+v, "v1.22") < 0 {
+		return errorResult(fmt.Sprintf("the fake server needs go 1.22 or later in go.mod (found go %s) for http.ServeMux patterns", v)), nil, nil
+	}
+
+	res, err := Generate(spec, pkgName, args.Spec)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	cs := shared.Changeset{}
+	names := make([]string, 0, len(res.Files))
+	for name := range res.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(outDir, name)
+		if existing, err := os.ReadFile(path); err == nil && !strings.HasPrefix(string(existing), generatedMarker) {
+			return errorResult(fmt.Sprintf("%s exists and was not generated by this tool; choose another output directory", rel(absDir, path))), nil, nil
+		}
+		cs[path] = res.Files[name]
+	}
+
+	var sb strings.Builder
+	title := spec.Info.Title
+	if title == "" {
+		title = "API"
+	}
+	fmt.Fprintf(&sb, "# Fake %s server\n\n", title)
+	if args.DryRun {
+		sb.WriteString("Dry run: nothing was written.\n\n")
+	} else {
+		pkgDir := "./" + filepath.ToSlash(rel(absDir, outDir))
+		if err := cs.ApplyVerified(ctx, absDir, []string{"vet", pkgDir}, []string{"test", "-count=1", pkgDir}); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Generated `%s` in `%s` (%s): %d operations; its tests pass.\n\n",
+			importPath, rel(absDir, outDir), strings.Join(names, ", "), len(res.Routes))
+	}
+
+	clientDir := args.Client
+	if clientDir == "" {
+		clientDir = filepath.Join("internal", apiName)
+	}
+	writeUsage(&sb, pkgName, importPath, clientPackage(filepath.Join(absDir, clientDir)), res)
+
+	if len(res.Notes) > 0 {
+		sb.WriteString("\n## Notes\n\n")
+		for _, n := range res.Notes {
+			fmt.Fprintf(&sb, "- %s\n", n)
+		}
+	}
+	if args.DryRun {
+		for _, name := range names {
+			fmt.Fprintf(&sb, "\n## %s\n\n```go\n%s```\n", name, res.Files[name])
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// client is a package generated by generate_openapi_client.
+type client struct {
+	Name       string
+	ImportPath string
+}
+
+// clientPackage returns the generated client in dir, or nil.
+func clientPackage(dir string) *client {
+	data, err := os.ReadFile(filepath.Join(dir, "client.go"))
+	if err != nil || !strings.HasPrefix(string(data), openapiclient.GeneratedMarker) {
+		return nil
+	}
+	path, err := openapiclient.ImportPath(dir)
+	if err != nil {
+		return nil
+	}
+	return &client{Name: openapiclient.PackageName(filepath.Base(dir)), ImportPath: path}
+}
+
+// writeUsage renders an example test and the list of routes.
+func writeUsage(sb *strings.Builder, pkgName, importPath string, c *client, res *Result) {
+	r := res.Routes[0]
+	sb.WriteString("## Usage\n\n```go\nimport (\n\t\"net/http\"\n\t\"testing\"\n\n")
+	fmt.Fprintf(sb, "\t%q\n", importPath)
+	if c != nil {
+		fmt.Fprintf(sb, "\t%q\n", c.ImportPath)
+	}
+	sb.WriteString(")\n\n")
+	fmt.Fprintf(sb, "func Test%s(t *testing.T) {\n\tsrv := %s.New(t)\n", r.Name, pkgName)
+	fmt.Fprintf(sb, "\tsrv.Respond(%s.%s, http.StatusInternalServerError, map[string]string{\"message\": \"boom\"})\n\n", pkgName, r.Name)
+	if c != nil {
+		fmt.Fprintf(sb, "\tclient := %s.New(srv.URL)\n\t_ = client // call client.%s and check how the error is handled\n\n", c.Name, r.Name)
+	} else {
+		sb.WriteString("\t// Point the code under test at srv.URL and exercise it.\n\n")
+	}
+	fmt.Fprintf(sb, "\tif got := srv.RequestsFor(%s.%s); len(got) != 1 {\n\t\tt.Errorf(\"got %%d requests, want 1\", len(got))\n\t}\n}\n```\n\n", pkgName, r.Name)
+	sb.WriteString("Every operation answers with the canned response below until a test calls `Respond` (fixed status and body) or `Handle` (an `http.HandlerFunc`; path parameters through `r.PathValue`). `Requests` and `RequestsFor` return what the server received, and requests matching no operation fail the test.\n\n")
+
+	sb.WriteString("## Operations\n\n| Operation | HTTP | Default response |\n|---|---|---|\n")
+	for _, r := range res.Routes {
+		resp := fmt.Sprintf("%d", r.Status)
+		if r.Example != "" {
+			example := r.Example
+			if len(example) > 80 {
+				example = example[:77] + "..."
+			}
+			resp += " `" + strings.ReplaceAll(example, "|", `\|`) + "`"
+		}
+		fmt.Fprintf(sb, "| `%s` | `%s` | %s |\n", r.Name, r.HTTP, resp)
+	}
+}
+
+// goVersion returns the go directive of the go.mod enclosing dir, or "".
+func goVersion(dir string) string {
+	for root := dir; ; {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			f, err := modfile.ParseLax("go.mod", data, nil)
+			if err != nil || f.Go == nil {
+				return ""
+			}
+			return f.Go.Version
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return ""
+		}
+		root = parent
+	}
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,191 @@
+package openapimock
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/danicat/godoctor/internal/tools/go/generate/openapiclient"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: A page of pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+  /pets/{pet-id}:
+    get:
+      operationId: showPetById
+      parameters:
+        - name: pet-id
+          in: path
+          required: true
+          schema: {type: integer}
+      responses:
+        '200':
+          description: A pet
+          content:
+            application/json:
+              example: {id: 7, name: Rex}
+              schema: {$ref: '#/components/schemas/Pet'}
+    delete:
+      operationId: deletePet
+      parameters:
+        - name: pet-id
+          in: path
+          required: true
+          schema: {type: integer}
+      responses:
+        '204':
+          description: Deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer, format: int64}
+        name: {type: string}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":        "module example.com/app\n\ngo 1.24\n",
+		"petstore.yaml": petstore,
+	})
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestHandler_Generate(t *testing.T) {
+	dir := setup(t)
+	text, isErr := call(t, Params{Dir: dir, Spec: "petstore.yaml"})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"✅ Generated `example.com/app/internal/petstoremock`",
+		"| `ListPets` | `GET /pets` | 200 `[{\"id\":1,\"name\":\"string\"}]` |",
+		"| `ShowPetByID` | `GET /pets/{pet-id}` | 200 `{\"id\":7,\"name\":\"Rex\"}` |",
+		"| `DeletePet` | `DELETE /pets/{pet-id}` | 204 |",
+		"// Point the code under test at srv.URL",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	server, err := os.ReadFile(filepath.Join(dir, "internal", "petstoremock", "server.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(server), `ShowPetByID: "GET /pets/{petID}",`) {
+		t.Errorf("path parameter not renamed to a Go identifier:\n%s", server)
+	}
+}
+
+// TestHandler_EndToEnd generates a client and a fake for the same document and runs a consumer
+// test that uses both.
+func TestHandler_EndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	dir := setup(t)
+	ctx := context.Background()
+	res, _, err := openapiclient.Handler(ctx, nil, openapiclient.Params{Dir: dir, Spec: "petstore.yaml"})
+	if err != nil || res.IsError {
+		t.Fatalf("client generation failed: %v %v", err, res.Content)
+	}
+	text, isErr := call(t, Params{Dir: dir, Spec: "petstore.yaml"})
+	if isErr || !strings.Contains(text, "client := petstore.New(srv.URL)") {
+		t.Fatalf("unexpected output (error %v):\n%s", isErr, text)
+	}
+
+	consumer := `package consumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"example.com/app/internal/petstore"
+	"example.com/app/internal/petstoremock"
+)
+
+func TestConsumer(t *testing.T) {
+	srv := petstoremock.New(t)
+	client := petstore.New(srv.URL)
+	pet, err := client.ShowPetByID(context.Background(), 7)
+	if err != nil || pet.Name != "Rex" {
+		t.Fatalf("ShowPetByID = %+v, %v", pet, err)
+	}
+	srv.Respond(petstoremock.ShowPetByID, http.StatusNotFound, map[string]string{"message": "gone"})
+	_, err = client.ShowPetByID(context.Background(), 8)
+	var apiErr *petstore.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %v, want a 404 APIError", err)
+	}
+	if got := srv.RequestsFor(petstoremock.ShowPetByID); len(got) != 2 || got[1].Path != "/pets/8" {
+		t.Errorf("recorded %+v", got)
+	}
+}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "consumer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "consumer", "consumer_test.go"), []byte(consumer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "test", "./consumer")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("consumer test failed: %v\n%s", err, out)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	dir := setup(t)
+	oldGo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(oldGo, "go.mod"), []byte("module example.com/old\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args Params
+		want string
+	}{
+		{Params{Dir: dir}, "spec is required"},
+		{Params{Dir: dir, Spec: "missing.yaml"}, "no such file"},
+		{Params{Dir: oldGo, Spec: filepath.Join(dir, "petstore.yaml")}, "needs go 1.22"},
+	}
+	for _, tt := range tests {
+		text, isErr := call(t, tt.args)
+		if !isErr || !strings.Contains(text, tt.want) {
+			t.Errorf("%+v: got %q (error %v), want %q", tt.args, text, isErr, tt.want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/danicat/godoctor/internal/godoc"
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/writes"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -75,7 +76,10 @@ func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.C
 	cmd := exec.CommandContext(ctx, "go", cmdArgs...)
 	cmd.Dir = absDir
 
+	// go get rewrites go.mod and go.sum.
+	done := writes.Watch(ctx, absDir)
 	output, err := cmd.CombinedOutput()
+	done()
 	var sb strings.Builder
 	isError := false
 	if err != nil {
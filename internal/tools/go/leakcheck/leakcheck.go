@@ -0,0 +1,436 @@
+// Package leakcheck implements the leak_check tool, which runs a function or test in a loop,
+// samples the heap and goroutine count between batches, and reports steady growth together with
+// the allocation sites responsible for it.
+package leakcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["leak_check"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package      string `json:"package,omitempty" jsonschema:"Directory of the package declaring the target, relative to dir (default '.')"`
+	Target       string `json:"target" jsonschema:"Function to run: a Test function (e.g. TestHandleRequest) or a function without parameters"`
+	Iterations   int    `json:"iterations,omitempty" jsonschema:"Total calls after warm-up (default 1000)"`
+	Batches      int    `json:"batches,omitempty" jsonschema:"Number of memory samples, taken after each batch of calls (default 10, min 4, max 50)"`
+	Threshold    int    `json:"threshold,omitempty" jsonschema:"Heap growth, as a percentage of the first sample, above which steady growth is reported as a leak (default 10)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+const (
+	defaultIterations = 1000
+	defaultBatches    = 10
+	minBatches        = 4
+	maxBatches        = 50
+	defaultThreshold  = 10
+	// minGrowth ignores growth too small to matter, such as lazily initialized package state.
+	minGrowth   = 64 << 10
+	topSites    = 10
+	runTimeout  = 5 * time.Minute
+	harnessFile = "godoctor_leak_harness_test.go"
+	harnessTest = "TestGodoctorLeakHarness"
+)
+
+// Sample is the memory state after a batch of calls, measured after a full garbage collection.
+type Sample struct {
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapObjects uint64 `json:"heap_objects"`
+	Goroutines  int    `json:"goroutines"`
+}
+
+// Site is an allocation site whose live heap grew during the run. Bytes are sampled by the
+// runtime memory profiler, so they are estimates.
+type Site struct {
+	Location string `json:"location"`
+	Growth   int64  `json:"growth_bytes"`
+}
+
+// Result is the outcome of a leak check.
+type Result struct {
+	Target        string   `json:"target"`
+	Iterations    int      `json:"iterations"`
+	Samples       []Sample `json:"samples"`
+	Sites         []Site   `json:"growing_sites,omitempty"`
+	HeapGrowth    int64    `json:"heap_growth_bytes"`
+	HeapLeak      bool     `json:"heap_leak"`
+	GoroutineLeak bool     `json:"goroutine_leak"`
+}
+
+// Handler handles the leak_check tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Target == "" {
+		return errorResult("target cannot be empty"), nil, nil
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Iterations <= 0 {
+		args.Iterations = defaultIterations
+	}
+	if args.Batches == 0 {
+		args.Batches = defaultBatches
+	}
+	args.Batches = min(max(args.Batches, minBatches), maxBatches)
+	if args.Threshold <= 0 {
+		args.Threshold = defaultThreshold
+	}
+
+	res, err := Run(ctx, absDir, args, shared.NewProgress(ctx, req))
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var output string
+	if format == shared.FormatJSON {
+		bytes, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(res, args.Threshold)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Run writes a temporary test harness next to the target, runs it with go test, and analyzes the
+// samples it records. The harness file is always removed afterwards. Each phase is reported to
+// progress.
+func Run(ctx context.Context, dir string, args Params, progress *shared.Progress) (*Result, error) {
+	pkgDir := filepath.Join(dir, args.Package)
+	pkgName, call, err := findTarget(pkgDir, args.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	harness := filepath.Join(pkgDir, harnessFile)
+	if _, err := os.Stat(harness); err == nil {
+		return nil, fmt.Errorf("%s already exists; remove it or wait for the running check to finish", harness)
+	}
+	perBatch := max(args.Iterations/args.Batches, 1)
+	src := fmt.Sprintf(harnessSource, pkgName, harnessTest, call, perBatch, args.Batches)
+	if err := writes.Write(ctx, harness, []byte(src), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write harness: %w", err)
+	}
+	defer os.Remove(harness)
+
+	out, err := os.CreateTemp("", "godoctor-leak-*.json")
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	progress.Step(fmt.Sprintf("Running %s %d times in %d batches", args.Target, perBatch*args.Batches, args.Batches))
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", "test", "-count=1", "-run", "^"+harnessTest+"$", "-timeout", runTimeout.String(), ".")
+	cmd.Dir = pkgDir
+	cmd.Env = append(os.Environ(), "GODOCTOR_LEAK_OUT="+out.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("harness failed:\n%s", strings.TrimSpace(string(output)))
+	}
+
+	progress.Step("Analyzing the samples")
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read harness results: %w", err)
+	}
+	// The harness wrote the results; count them against the call.
+	writes.Count(ctx, int64(len(data)))
+	var raw struct {
+		Samples []struct {
+			HeapAlloc, HeapObjects uint64
+			Goroutines             int
+		}
+		Sites map[string]int64
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode harness results: %w", err)
+	}
+	res := &Result{Target: args.Target, Iterations: perBatch * args.Batches}
+	for _, s := range raw.Samples {
+		res.Samples = append(res.Samples, Sample{HeapAlloc: s.HeapAlloc, HeapObjects: s.HeapObjects, Goroutines: s.Goroutines})
+	}
+	res.analyze(args.Threshold)
+	for loc, growth := range raw.Sites {
+		if growth > 0 {
+			res.Sites = append(res.Sites, Site{Location: relLocation(dir, loc), Growth: growth})
+		}
+	}
+	sort.Slice(res.Sites, func(i, j int) bool {
+		if res.Sites[i].Growth != res.Sites[j].Growth {
+			return res.Sites[i].Growth > res.Sites[j].Growth
+		}
+		return res.Sites[i].Location < res.Sites[j].Location
+	})
+	if len(res.Sites) > topSites {
+		res.Sites = res.Sites[:topSites]
+	}
+	return res, nil
+}
+
+// findTarget locates the target function in the package at dir and returns the name of the
+// package that declares it and the statement that calls it from a test.
+func findTarget(dir, target string) (string, string, error) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil || len(files) == 0 {
+		return "", "", fmt.Errorf("no Go files in %s", dir)
+	}
+	for _, name := range files {
+		file, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != target {
+				continue
+			}
+			params := fn.Type.Params.List
+			switch {
+			case len(params) == 0:
+				return file.Name.Name, target + "()", nil
+			case len(params) == 1 && len(params[0].Names) <= 1 && isTestingT(params[0].Type) && strings.HasSuffix(name, "_test.go"):
+				return file.Name.Name, target + "(t)", nil
+			}
+			return "", "", fmt.Errorf("%s must be a Test function or take no parameters", target)
+		}
+	}
+	return "", "", fmt.Errorf("function %s not found in %s", target, dir)
+}
+
+func isTestingT(expr ast.Expr) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == "testing" && sel.Sel.Name == "T"
+}
+
+// analyze flags a heap leak when the heap grew by more than threshold percent of the first sample
+// and rose in at least three quarters of the batches; one-off growth, such as a cache filling up,
+// rises in a few batches and then stays flat. Goroutines leak when their count never drops and
+// ends higher than it started.
+func (r *Result) analyze(threshold int) {
+	s := r.Samples
+	if len(s) < 2 {
+		return
+	}
+	first, last := s[0], s[len(s)-1]
+	r.HeapGrowth = int64(last.HeapAlloc) - int64(first.HeapAlloc)
+	rises, goroutinesDrop := 0, false
+	for i := 1; i < len(s); i++ {
+		if s[i].HeapAlloc > s[i-1].HeapAlloc {
+			rises++
+		}
+		if s[i].Goroutines < s[i-1].Goroutines {
+			goroutinesDrop = true
+		}
+	}
+	limit := max(int64(first.HeapAlloc)*int64(threshold)/100, minGrowth)
+	r.HeapLeak = r.HeapGrowth > limit && rises*4 >= (len(s)-1)*3
+	r.GoroutineLeak = last.Goroutines > first.Goroutines && !goroutinesDrop
+}
+
+// relLocation shortens the file in a "function (file:line)" location to a path relative to root.
+func relLocation(root, loc string) string {
+	return strings.Replace(loc, root+string(filepath.Separator), "", 1)
+}
+
+func render(r *Result, threshold int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Leak Check: `%s`\n\n", r.Target)
+	switch {
+	case r.HeapLeak && r.GoroutineLeak:
+		sb.WriteString("**Verdict:** ❌ heap and goroutine leak\n\n")
+	case r.HeapLeak:
+		sb.WriteString("**Verdict:** ❌ heap leak\n\n")
+	case r.GoroutineLeak:
+		sb.WriteString("**Verdict:** ❌ goroutine leak\n\n")
+	default:
+		sb.WriteString("**Verdict:** ✅ no steady growth\n\n")
+	}
+	if len(r.Samples) > 0 {
+		first, last := r.Samples[0], r.Samples[len(r.Samples)-1]
+		fmt.Fprintf(&sb, "- Calls: %d after warm-up, in %d batches\n", r.Iterations, len(r.Samples))
+		fmt.Fprintf(&sb, "- Live heap: %s → %s (%+d bytes; threshold %d%%)\n", size(first.HeapAlloc), size(last.HeapAlloc), r.HeapGrowth, threshold)
+		fmt.Fprintf(&sb, "- Heap objects: %d → %d\n", first.HeapObjects, last.HeapObjects)
+		fmt.Fprintf(&sb, "- Goroutines: %d → %d\n\n", first.Goroutines, last.Goroutines)
+	}
+
+	sb.WriteString("## Samples\n\n| Batch | Live heap | Objects | Goroutines |\n| ---: | ---: | ---: | ---: |\n")
+	for i, s := range r.Samples {
+		fmt.Fprintf(&sb, "| %d | %s | %d | %d |\n", i+1, size(s.HeapAlloc), s.HeapObjects, s.Goroutines)
+	}
+	if len(r.Sites) > 0 {
+		sb.WriteString("\n## Top Growing Allocation Sites\n\n| Site | Growth (≈) |\n| :--- | ---: |\n")
+		for _, s := range r.Sites {
+			fmt.Fprintf(&sb, "| `%s` | %s |\n", s.Location, size(uint64(s.Growth)))
+		}
+	}
+	if r.HeapLeak {
+		sb.WriteString("\nMemory retained by these sites is still reachable after garbage collection. Look for slices, maps or caches that grow with each call and are never trimmed.\n")
+	}
+	if r.GoroutineLeak {
+		sb.WriteString("\nGoroutines started by the target never exit. Check for blocked channel operations and missing context cancellation.\n")
+	}
+	return sb.String()
+}
+
+func size(b uint64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(b)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", b)
+}
+
+// harnessSource is the temporary test that drives the target. Imports are aliased so they cannot
+// clash with identifiers declared by the package under test.
+const harnessSource = `package %s
+
+import (
+	godoctorjson "encoding/json"
+	godoctorfmt "fmt"
+	godoctoros "os"
+	godoctorruntime "runtime"
+	godoctorstrings "strings"
+	"testing"
+)
+
+func init() { godoctorruntime.MemProfileRate = 4096 }
+
+func %s(t *testing.T) {
+	run := func() { %s }
+	const perBatch, batches = %d, %d
+
+	type sample struct{ HeapAlloc, HeapObjects uint64; Goroutines int }
+	measure := func() sample {
+		godoctorruntime.GC()
+		godoctorruntime.GC()
+		var ms godoctorruntime.MemStats
+		godoctorruntime.ReadMemStats(&ms)
+		return sample{ms.HeapAlloc, ms.HeapObjects, godoctorruntime.NumGoroutine()}
+	}
+	// sites returns the live bytes per allocation site, keyed by the first frame outside the
+	// runtime. The profile reflects the last completed GC cycle, so collect twice first.
+	sites := func() map[string]int64 {
+		godoctorruntime.GC()
+		godoctorruntime.GC()
+		n, _ := godoctorruntime.MemProfile(nil, true)
+		var recs []godoctorruntime.MemProfileRecord
+		for {
+			recs = make([]godoctorruntime.MemProfileRecord, n+64)
+			var ok bool
+			if n, ok = godoctorruntime.MemProfile(recs, true); ok {
+				recs = recs[:n]
+				break
+			}
+		}
+		out := make(map[string]int64)
+		for _, r := range recs {
+			frames := godoctorruntime.CallersFrames(r.Stack())
+			site := "runtime"
+			for {
+				f, more := frames.Next()
+				if !godoctorstrings.HasPrefix(f.Function, "runtime.") {
+					site = godoctorfmt.Sprintf("%%s (%%s:%%d)", f.Function, f.File, f.Line)
+					break
+				}
+				if !more {
+					break
+				}
+			}
+			out[site] += r.InUseBytes()
+		}
+		return out
+	}
+
+	for i := 0; i < perBatch; i++ {
+		run()
+	}
+	before := sites()
+	var samples []sample
+	for b := 0; b < batches; b++ {
+		for i := 0; i < perBatch; i++ {
+			run()
+		}
+		samples = append(samples, measure())
+	}
+	growth := sites()
+	for site, bytes := range before {
+		growth[site] -= bytes
+	}
+
+	data, err := godoctorjson.Marshal(map[string]any{"Samples": samples, "Sites": growth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := godoctoros.WriteFile(godoctoros.Getenv("GODOCTOR_LEAK_OUT"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
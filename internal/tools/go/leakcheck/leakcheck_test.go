@@ -0,0 +1,148 @@
+package leakcheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/leaky"),
+		"leaky.go": `package leaky
+
+var cache [][]byte
+
+// Remember keeps every buffer it allocates.
+func Remember() { cache = append(cache, make([]byte, 8<<10)) }
+
+// Scratch allocates a buffer it drops immediately.
+func Scratch() int { return len(make([]byte, 8<<10)) }
+
+// Spawn starts a goroutine that never exits.
+func Spawn() { go func() { select {} }() }
+`,
+		"leaky_test.go": `package leaky
+
+import "testing"
+
+func TestScratch(t *testing.T) {
+	if Scratch() == 0 {
+		t.Fatal("empty")
+	}
+}
+`,
+	})
+}
+
+func TestHandler(t *testing.T) {
+	dir := setup(t)
+	tests := []struct {
+		target string
+		wants  []string
+	}{
+		{"Remember", []string{"**Verdict:** ❌ heap leak", "| `example.com/leaky.Remember (leaky.go:6)` |"}},
+		{"Scratch", []string{"**Verdict:** ✅ no steady growth"}},
+		{"TestScratch", []string{"**Verdict:** ✅ no steady growth"}},
+		{"Spawn", []string{"goroutine leak\n", "- Goroutines: "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Target: tt.target, Iterations: 200, Batches: 5})
+			out := res.Content[0].(*mcp.TextContent).Text
+			if res.IsError {
+				t.Fatalf("unexpected error: %s", out)
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %q in:\n%s", want, out)
+				}
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, harnessFile)); !os.IsNotExist(err) {
+		t.Errorf("harness file was not removed: %v", err)
+	}
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Target: "Missing"})
+	if !res.IsError {
+		t.Error("expected an error for a missing target")
+	}
+}
+
+func TestHandler_Progress(t *testing.T) {
+	dir := setup(t)
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	Register(server)
+
+	var mu sync.Mutex
+	var messages []string
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(ctx context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, req.Params.Message)
+		},
+	})
+	t1, t2 := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, t1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = serverSession.Close() }()
+	clientSession, err := client.Connect(ctx, t2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = clientSession.Close() }()
+
+	params := &mcp.CallToolParams{Name: "leak_check", Arguments: map[string]any{"dir": dir, "target": "Scratch", "iterations": 200, "batches": 5}}
+	params.SetProgressToken("leak-1")
+	res, err := clientSession.CallTool(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+
+	want := []string{"Running Scratch 200 times in 5 batches", "Analyzing the samples"}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]string(nil), messages...)
+		mu.Unlock()
+		if len(got) >= len(want) || time.Now().After(deadline) {
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("progress messages = %q, want %q", got, want)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	flat := []Sample{{1 << 20, 100, 2}, {2 << 20, 200, 2}, {2 << 20, 200, 2}, {2 << 20, 200, 2}, {2 << 20, 200, 2}}
+	r := &Result{Samples: flat}
+	r.analyze(10)
+	if r.HeapLeak || r.GoroutineLeak {
+		t.Errorf("one-off growth reported as a leak: %+v", r)
+	}
+
+	growing := []Sample{{1 << 20, 100, 2}, {2 << 20, 200, 3}, {3 << 20, 300, 4}, {4 << 20, 400, 5}, {5 << 20, 500, 6}}
+	r = &Result{Samples: growing}
+	r.analyze(10)
+	if !r.HeapLeak || !r.GoroutineLeak || r.HeapGrowth != 4<<20 {
+		t.Errorf("steady growth not reported as a leak: %+v", r)
+	}
+}
@@ -0,0 +1,281 @@
+// Package modsearch implements the search_modules tool, which finds candidate modules for a need
+// on pkg.go.dev and checks their latest release on the module proxy.
+package modsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/module"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["search_modules"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Query        string `json:"query" jsonschema:"What the module should do, e.g. 'HTTP router' or 'YAML parsing'"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of candidates (default 5, max 10)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Endpoints, variables so tests can point them at a local server.
+var (
+	SearchURL = "https://pkg.go.dev/search"
+	ProxyURL  = "https://proxy.golang.org"
+)
+
+const (
+	defaultLimit = 5
+	maxLimit     = 10
+	staleAfter   = 2 * 365 * 24 * time.Hour
+)
+
+// Candidate is a module that may meet the need.
+type Candidate struct {
+	Package    string    `json:"package"`
+	Module     string    `json:"module,omitempty"`
+	Synopsis   string    `json:"synopsis,omitempty"`
+	ImportedBy int       `json:"imported_by"`
+	Version    string    `json:"latest_version,omitempty"`
+	Released   time.Time `json:"released,omitempty"`
+	License    string    `json:"license,omitempty"`
+	Notes      []string  `json:"notes,omitempty"`
+}
+
+// Handler handles the search_modules tool execution.
+func Handler(ctx context.Context, _ *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	if strings.TrimSpace(args.Query) == "" {
+		return errorResult("query cannot be empty"), nil, nil
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+
+	cands, err := Search(ctx, args.Query, limit)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	Enrich(ctx, cands, time.Now())
+
+	var output string
+	if format == shared.FormatJSON {
+		if cands == nil {
+			cands = []*Candidate{}
+		}
+		bytes, err := json.MarshalIndent(cands, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to marshal JSON: %v", err)), nil, nil
+		}
+		output = string(bytes)
+	} else {
+		output = render(args.Query, cands)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Search queries pkg.go.dev for packages matching query and returns up to limit candidates in
+// relevance order.
+func Search(ctx context.Context, query string, limit int) ([]*Candidate, error) {
+	u := SearchURL + "?" + url.Values{"q": {query}, "m": {"package"}, "limit": {strconv.Itoa(limit)}}.Encode()
+	body, status, err := get(ctx, u, 4<<20)
+	if err != nil {
+		return nil, fmt.Errorf("pkg.go.dev search failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("pkg.go.dev search failed: HTTP %d", status)
+	}
+	var cands []*Candidate
+	for _, c := range parseSearch(body) {
+		if len(cands) == limit {
+			break
+		}
+		cands = append(cands, c)
+	}
+	return cands, nil
+}
+
+var (
+	titleRe      = regexp.MustCompile(`<a\s[^>]*data-test-id="snippet-title"[^>]*>`)
+	hrefRe       = regexp.MustCompile(`href="/([^"?#]+)`)
+	importedByRe = regexp.MustCompile(`Imported by\s*(?:<[^>]*>\s*)*([\d,]+)`)
+	tagRe        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// parseSearch extracts results from a pkg.go.dev search page. It keys on the data-test-id
+// attributes the site uses for its own tests, which are more stable than its styling.
+func parseSearch(page string) []*Candidate {
+	var out []*Candidate
+	for _, block := range strings.Split(page, `class="SearchSnippet"`)[1:] {
+		tag := titleRe.FindString(block)
+		m := hrefRe.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		c := &Candidate{
+			Package:  html.UnescapeString(m[1]),
+			Synopsis: field(block, "snippet-synopsis", "</p>"),
+			Version:  field(block, "snippet-version", "</span>"),
+			License:  field(block, "snippet-license", "</span>"),
+		}
+		if m := importedByRe.FindStringSubmatch(block); m != nil {
+			c.ImportedBy, _ = strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+		}
+		if t, err := time.Parse("Jan _2, 2006", field(block, "snippet-published", "</span>")); err == nil {
+			c.Released = t
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// field returns the text of the element marked with data-test-id=id, up to end.
+func field(block, id, end string) string {
+	i := strings.Index(block, `data-test-id="`+id+`"`)
+	if i < 0 {
+		return ""
+	}
+	rest := block[i:]
+	if j := strings.Index(rest, ">"); j >= 0 {
+		rest = rest[j+1:]
+	}
+	if j := strings.Index(rest, end); j >= 0 {
+		rest = rest[:j]
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(tagRe.ReplaceAllString(rest, " "))), " ")
+}
+
+// Enrich looks up each candidate's module and latest release on the module proxy, which is the
+// source of truth for versions, and adds notes about signals worth a second look.
+func Enrich(ctx context.Context, cands []*Candidate, now time.Time) {
+	var wg sync.WaitGroup
+	for _, c := range cands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if mod, version, released, ok := latest(ctx, c.Package); ok {
+				c.Module, c.Version, c.Released = mod, version, released
+			} else {
+				c.Notes = append(c.Notes, "could not confirm the latest release on the module proxy")
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range cands {
+		if !c.Released.IsZero() && now.Sub(c.Released) > staleAfter {
+			c.Notes = append(c.Notes, fmt.Sprintf("no release since %s", c.Released.Format("Jan 2006")))
+		}
+		if strings.HasPrefix(c.Version, "v0.") {
+			c.Notes = append(c.Notes, "pre-v1: the API may change between minor versions")
+		}
+		if strings.Contains(c.Version, "-") {
+			c.Notes = append(c.Notes, "no tagged release; the latest version is a pseudo-version or prerelease")
+		}
+		if c.License == "" || strings.Contains(strings.ToLower(c.License), "unknown") {
+			c.Notes = append(c.Notes, "license not recognized by pkg.go.dev; check before depending on it")
+		}
+	}
+}
+
+// latest finds the module providing pkg by asking the proxy for @latest on pkg and its parents.
+func latest(ctx context.Context, pkg string) (mod, version string, released time.Time, ok bool) {
+	for path := pkg; strings.Contains(path, "/"); path = path[:strings.LastIndex(path, "/")] {
+		escaped, err := module.EscapePath(path)
+		if err != nil {
+			return "", "", time.Time{}, false
+		}
+		body, status, err := get(ctx, ProxyURL+"/"+escaped+"/@latest", 64<<10)
+		if err != nil {
+			return "", "", time.Time{}, false
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		var info struct {
+			Version string
+			Time    time.Time
+		}
+		if err := json.Unmarshal([]byte(body), &info); err != nil {
+			return "", "", time.Time{}, false
+		}
+		return path, info.Version, info.Time, true
+	}
+	return "", "", time.Time{}, false
+}
+
+func get(ctx context.Context, u string, limit int64) (string, int, error) {
+	body, status, err := shared.HTTPRequest(ctx, http.MethodGet, u, nil, nil, limit)
+	return string(body), status, err
+}
+
+func render(query string, cands []*Candidate) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Module Candidates: %q\n\n", query)
+	if len(cands) == 0 {
+		sb.WriteString("No packages found on pkg.go.dev. Try a broader or different description of the need.\n")
+		return sb.String()
+	}
+	sb.WriteString("| Package | Imported by | Latest | Released | License |\n| :--- | ---: | :--- | :--- | :--- |\n")
+	for _, c := range cands {
+		released := ""
+		if !c.Released.IsZero() {
+			released = c.Released.Format("2006-01-02")
+		}
+		fmt.Fprintf(&sb, "| [`%s`](https://pkg.go.dev/%s) | %d | %s | %s | %s |\n", c.Package, c.Package, c.ImportedBy, c.Version, released, c.License)
+	}
+	sb.WriteString("\n## Details\n\n")
+	for _, c := range cands {
+		fmt.Fprintf(&sb, "- **`%s`**", c.Package)
+		if c.Synopsis != "" {
+			sb.WriteString(": " + c.Synopsis)
+		}
+		sb.WriteString("\n")
+		if c.Module != "" && c.Module != c.Package {
+			fmt.Fprintf(&sb, "  - Module: `%s`\n", c.Module)
+		}
+		for _, n := range c.Notes {
+			fmt.Fprintf(&sb, "  - ⚠️ %s\n", n)
+		}
+	}
+	sb.WriteString("\nImport counts come from pkg.go.dev and favor older modules; weigh them with release activity and the API fit. Use `add_dependency` to install the chosen module.\n")
+	return sb.String()
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
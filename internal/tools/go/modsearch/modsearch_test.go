@@ -0,0 +1,104 @@
+package modsearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const searchPage = `<html><body>
+<div class="SearchSnippet">
+  <div class="SearchSnippet-headerContainer">
+    <h2>
+      <a href="/github.com/gorilla/mux" data-gtmc="search result" data-test-id="snippet-title">
+        mux <span class="SearchSnippet-header-path">(github.com/gorilla/mux)</span>
+      </a>
+    </h2>
+  </div>
+  <p class="SearchSnippet-synopsis" data-test-id="snippet-synopsis">Package mux implements a request router &amp; dispatcher.</p>
+  <div class="SearchSnippet-infoLabel">
+    <a href="/github.com/gorilla/mux?tab=importedby" aria-label="Go to Imported By">
+      <span class="go-textSubtle">Imported by </span><strong>23,456</strong>
+    </a>
+    <span class="go-textSubtle" data-test-id="snippet-version"><strong>v1.8.0</strong></span>
+    <span class="go-textSubtle" data-test-id="snippet-published"><strong>Nov 3, 2020</strong></span>
+    <span class="go-textSubtle" data-test-id="snippet-license">
+      <a href="/github.com/gorilla/mux?tab=licenses" aria-label="Go to Licenses">BSD-3-Clause</a>
+    </span>
+  </div>
+</div>
+<div class="SearchSnippet">
+  <h2><a data-test-id="snippet-title" href="/github.com/go-chi/chi/v5/middleware">middleware</a></h2>
+  <p class="SearchSnippet-synopsis" data-test-id="snippet-synopsis">Package middleware provides chi middlewares.</p>
+  <a href="/github.com/go-chi/chi/v5/middleware?tab=importedby">Imported by <strong>9</strong></a>
+  <span data-test-id="snippet-version"><strong>v5.0.12</strong></span>
+  <span data-test-id="snippet-license"><a>MIT</a></span>
+</div>
+</body></html>`
+
+func TestHandler(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			query = r.URL.Query().Get("q")
+			fmt.Fprint(w, searchPage)
+		case "/github.com/gorilla/mux/@latest":
+			fmt.Fprint(w, `{"Version":"v1.8.1","Time":"2023-10-18T03:59:13Z"}`)
+		case "/github.com/go-chi/chi/v5/@latest":
+			fmt.Fprint(w, `{"Version":"v5.1.0","Time":"2024-07-07T00:00:00Z"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	oldSearch, oldProxy := SearchURL, ProxyURL
+	SearchURL, ProxyURL = srv.URL+"/search", srv.URL
+	defer func() { SearchURL, ProxyURL = oldSearch, oldProxy }()
+
+	res, _, _ := Handler(context.Background(), nil, Params{Query: "HTTP router"})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	if query != "HTTP router" {
+		t.Errorf("searched for %q", query)
+	}
+	for _, want := range []string{
+		"| [`github.com/gorilla/mux`](https://pkg.go.dev/github.com/gorilla/mux) | 23456 | v1.8.1 | 2023-10-18 | BSD-3-Clause |",
+		"| [`github.com/go-chi/chi/v5/middleware`](https://pkg.go.dev/github.com/go-chi/chi/v5/middleware) | 9 | v5.1.0 | 2024-07-07 | MIT |",
+		"- **`github.com/gorilla/mux`**: Package mux implements a request router & dispatcher.",
+		"  - Module: `github.com/go-chi/chi/v5`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestEnrichNotes(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	old := ProxyURL
+	ProxyURL = srv.URL
+	defer func() { ProxyURL = old }()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Candidate{Package: "example.com/old/pkg", Version: "v0.3.0", Released: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)}
+	Enrich(context.Background(), []*Candidate{c}, now)
+	want := []string{
+		"could not confirm the latest release on the module proxy",
+		"no release since May 2021",
+		"pre-v1: the API may change between minor versions",
+		"license not recognized by pkg.go.dev; check before depending on it",
+	}
+	if strings.Join(c.Notes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("notes = %q, want %q", c.Notes, want)
+	}
+}
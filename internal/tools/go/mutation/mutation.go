@@ -9,6 +9,7 @@ import (
 
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -42,11 +43,17 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 		return errorResult(err.Error()), nil, nil
 	}
 
+	progress := shared.NewProgress(ctx, req)
+	progress.Step("Running mutation tests")
 	cmd := exec.CommandContext(ctx, "go", "run", "github.com/danicat/selene/cmd/selene@latest", "./...")
 	cmd.Dir = absDir
-	out, runErr := cmd.CombinedOutput()
+	out, runErr := shared.RunLines(cmd, func(line string) {
+		if line = strings.TrimSpace(line); line != "" {
+			progress.Tick(line)
+		}
+	})
 
-	output := filterNoise(string(out))
+	output := filterNoise(out)
 
 	if runErr != nil && output == "" {
 		return errorResult(fmt.Sprintf("mutation testing failed to run: %v", runErr)), nil, nil
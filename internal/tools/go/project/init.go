@@ -7,10 +7,12 @@ import (
 	"github.com/danicat/godoctor/internal/godoc"
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/writes"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -64,6 +66,8 @@ func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.C
 	if _, err := os.Stat(filepath.Join(absPath, "go.mod")); err == nil {
 		return errorResult("project already initialized (go.mod exists)"), nil, nil
 	}
+	// go mod init, go get and go mod tidy write the module files.
+	defer writes.Watch(ctx, absPath)()
 	if out, err := CommandRunner.Run(ctx, absPath, "go", "mod", "init", args.ModulePath); err != nil {
 		return errorResult(fmt.Sprintf("failed to init module: %v\nOutput: %s", err, out)), nil, nil
 	}
@@ -99,7 +103,12 @@ func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.C
 			fmt.Fprintf(&sb, "  - ⚠️ `go mod tidy` failed: %v\n", err)
 		}
 		// Process docs (deduplicated)
+		pkgPaths := make([]string, 0, len(docsNeeded))
 		for pkgPath := range docsNeeded {
+			pkgPaths = append(pkgPaths, pkgPath)
+		}
+		sort.Strings(pkgPaths)
+		for _, pkgPath := range pkgPaths {
 			if docContent, _ := godoc.GetDocumentationWithFallback(ctx, pkgPath); docContent != "" {
 				sb.WriteString("\n")
 				sb.WriteString(docContent)
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/danicat/godoctor/internal/locale"
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/toolnames"
 	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/writes"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -26,14 +29,18 @@ func Register(server *mcp.Server) {
 
 // Params defines the input parameters.
 type Params struct {
-	Dir      string `json:"dir,omitempty" jsonschema:"The absolute directory path to build in. Always pass absolute paths in multi-root workspaces."`
-	Packages string `json:"packages,omitempty" jsonschema:"Packages to build (default: ./...)"`
+	Dir          string `json:"dir,omitempty" jsonschema:"The absolute directory path to build in. Always pass absolute paths in multi-root workspaces."`
+	Packages     string `json:"packages,omitempty" jsonschema:"Packages to build (default: ./...)"`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
 }
 
 // Runner defines the interface for running commands.
 type Runner interface {
 	Run(ctx context.Context, dir, name string, args ...string) error
 	RunWithOutput(ctx context.Context, dir, name string, args ...string) (string, error)
+	// RunStreaming is RunWithOutput that also passes each line of output to onLine as it is
+	// printed.
+	RunStreaming(ctx context.Context, dir string, onLine func(string), name string, args ...string) (string, error)
 	LookPath(file string) (string, error)
 }
 
@@ -52,6 +59,12 @@ func (r *stdRunner) RunWithOutput(ctx context.Context, dir, name string, args ..
 	return string(out), err
 }
 
+func (r *stdRunner) RunStreaming(ctx context.Context, dir string, onLine func(string), name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return shared.RunLines(cmd, onLine)
+}
+
 func (r *stdRunner) LookPath(file string) (string, error) {
 	return exec.LookPath(file)
 }
@@ -63,6 +76,10 @@ func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.C
 	if req != nil {
 		session = req.Session
 	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return result(err.Error(), true), nil, nil
+	}
 	dir := args.Dir
 	if dir == "" {
 		dir = "."
@@ -77,32 +94,55 @@ func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.C
 		pkgs = "./..."
 	}
 
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "# Smart Build Report (`%s`)\n\n", pkgs)
+	lang := locale.FromRequest(req)
+	rep := &shared.Report{Title: locale.T(lang, "build.title", pkgs), Lang: lang, Status: shared.StatusPass}
+	progress := shared.NewProgress(ctx, req)
 
-	runAutoFix(ctx, dir, &sb)
+	progress.Step(locale.T(lang, "build.autofix"))
+	runAutoFix(ctx, dir, rep)
 
-	if err := runBuild(ctx, dir, pkgs, &sb); err != nil {
-		//nolint:nilerr // Returning a JSON formatted tool error rather than an actual Go error
-		return result(sb.String(), true), nil, nil
+	progress.Step(locale.T(lang, "build.build"))
+	err = runBuild(ctx, dir, pkgs, rep, progress)
+	if err == nil {
+		progress.Step(locale.T(lang, "build.tests"))
+		err = runTestsPhase(ctx, dir, pkgs, rep, progress)
 	}
-
-	if err := runTestsPhase(ctx, dir, pkgs, &sb); err != nil {
-		//nolint:nilerr // Returning a JSON formatted tool error rather than an actual Go error
-		return result(sb.String(), true), nil, nil
+	if err == nil {
+		progress.Step(locale.T(lang, "build.lint"))
+		err = runLinterPhase(ctx, dir, pkgs, rep)
 	}
-
-	if err := runLinterPhase(ctx, dir, pkgs, &sb); err != nil {
-		//nolint:nilerr // Returning a JSON formatted tool error rather than an actual Go error
-		return result(sb.String(), true), nil, nil
+	if err != nil {
+		rep.Status = shared.StatusFail
 	}
 
-	return result(sb.String(), false), nil, nil
+	out, renderErr := rep.Render(format)
+	if renderErr != nil {
+		return result(renderErr.Error(), true), nil, nil
+	}
+	// A failing phase is reported as a tool error result rather than an actual Go error.
+	var next []shared.NextTool
+	for _, sec := range rep.Sections {
+		if sec.Status == shared.StatusFail {
+			next = append(next, shared.SuggestFromOutput(dir, sec.Output)...)
+		}
+	}
+	return shared.WithNextTools(result(out, err != nil), next...), nil, nil
 }
 
-func runAutoFix(ctx context.Context, dir string, sb *strings.Builder) {
+func runAutoFix(ctx context.Context, dir string, rep *shared.Report) {
+	// go mod tidy, the modernize fixes and gofmt rewrite files in place.
+	defer writes.Watch(ctx, dir)()
+
+	var fix *shared.Section
+	warn := func() *shared.Section {
+		if fix == nil {
+			fix = rep.Add(locale.T(rep.Lang, "build.autofix"), shared.StatusWarn)
+		}
+		return fix
+	}
+
 	if err := CommandRunner.Run(ctx, dir, "go", "mod", "tidy"); err != nil {
-		fmt.Fprintf(sb, "### ⚠️ Auto-Fix: `go mod tidy` Failed\n> %v\n\n", err)
+		warn().Items = append(warn().Items, locale.T(rep.Lang, "build.tidy_failed", err))
 	}
 
 	// Run Modernize directly from the CLI tool
@@ -113,7 +153,10 @@ func runAutoFix(ctx context.Context, dir string, sb *strings.Builder) {
 		if err != nil {
 			// We don't want to fail the whole build for a lint fix error, just warn the user.
 			if !strings.Contains(err.Error(), "exit status 3") {
-				fmt.Fprintf(sb, "  - ⚠️ Modernize `%s` Warning: %v\n    %s\n", cmd, err, strings.TrimSpace(out))
+				sub := warn().Add(locale.T(rep.Lang, "build.modernize", cmd), shared.StatusWarn)
+				sub.Text = err.Error()
+				sub.Output = out
+				sub.Collapsed = true
 			}
 		}
 	}
@@ -128,41 +171,98 @@ func runAutoFix(ctx context.Context, dir string, sb *strings.Builder) {
 	}
 }
 
-func runBuild(ctx context.Context, dir, pkgs string, sb *strings.Builder) error {
-	sb.WriteString("### 🛠️ Build: ")
-	buildOut, buildErr := CommandRunner.RunWithOutput(ctx, dir, "go", "build", pkgs)
+func runBuild(ctx context.Context, dir, pkgs string, rep *shared.Report, progress *shared.Progress) error {
+	// -v prints each package as it is compiled; those lines are progress, not output.
+	var compiled []string
+	out, buildErr := CommandRunner.RunStreaming(ctx, dir, func(line string) {
+		if isPackageLine(line) {
+			compiled = append(compiled, line)
+			progress.Tick(locale.T(rep.Lang, "build.progress_compiled", len(compiled), line))
+		}
+	}, "go", "build", "-v", pkgs)
+	progress.Step(locale.T(rep.Lang, "build.progress_built", len(compiled)))
+	var kept []string
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if !isPackageLine(strings.TrimSuffix(line, "\n")) {
+			kept = append(kept, line)
+		}
+	}
+	buildOut := strings.Join(kept, "")
 	if buildErr != nil {
-		sb.WriteString("❌ FAILED\n\n")
-		sb.WriteString(formatOutput(buildOut))
-		sb.WriteString(shared.GetDocHintFromOutput(buildOut))
+		sec := rep.Add(locale.T(rep.Lang, "build.build"), shared.StatusFail)
+		sec.Output = buildOut
+		sec.Text = shared.GetDocHintFromOutput(rep.Lang, buildOut)
+		addSourceFrames(rep, sec, dir, buildOut)
 		return buildErr
 	}
-	sb.WriteString("✅ PASS\n\n")
+	rep.Add(locale.T(rep.Lang, "build.build"), shared.StatusPass)
 	return nil
 }
 
-func runTestsPhase(ctx context.Context, dir, pkgs string, sb *strings.Builder) error {
-	sb.WriteString("### 🧪 Tests: ")
+// isPackageLine reports whether a line of `go build -v` output is the import path of a compiled
+// package rather than a message.
+func isPackageLine(line string) bool {
+	return line != "" && !strings.ContainsAny(line, " \t:#")
+}
+
+// testCounts tallies the tests and packages reported by `go test -v` so far.
+type testCounts struct {
+	passed, failed, skipped, packages int
+}
+
+// add counts one line of output and reports whether it finished a test or a package.
+func (c *testCounts) add(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "--- PASS:"):
+		c.passed++
+	case strings.HasPrefix(trimmed, "--- FAIL:"):
+		c.failed++
+	case strings.HasPrefix(trimmed, "--- SKIP:"):
+		c.skipped++
+	case strings.HasPrefix(line, "ok  \t"), strings.HasPrefix(line, "FAIL\t"), strings.HasPrefix(line, "?   \t"):
+		c.packages++
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *testCounts) message(lang string) string {
+	return locale.T(lang, "build.progress_tests", c.passed, c.failed, c.skipped, c.packages)
+}
 
+func runTestsPhase(ctx context.Context, dir, pkgs string, rep *shared.Report, progress *shared.Progress) error {
 	// Create a temporary file for coverage
 	covFile := "coverage.out"
 	defer func() {
-		_ = os.Remove(covFile)
+		path := filepath.Join(dir, covFile)
+		if fi, err := os.Stat(path); err == nil {
+			writes.Count(ctx, fi.Size())
+		}
+		_ = os.Remove(path)
 	}()
 
 	// -v for verbose, -coverprofile for coverage
 	testArgs := []string{"test", "-v", "-coverprofile=" + covFile, pkgs}
-	testOut, testErr := CommandRunner.RunWithOutput(ctx, dir, "go", testArgs...)
+	var counts testCounts
+	testOut, testErr := CommandRunner.RunStreaming(ctx, dir, func(line string) {
+		if counts.add(line) {
+			progress.Tick(counts.message(rep.Lang))
+		}
+	}, "go", testArgs...)
+	progress.Step(counts.message(rep.Lang))
 
 	if testErr != nil {
-		sb.WriteString("❌ FAILED\n\n")
-		sb.WriteString(formatOutput(testOut))
+		sec := rep.Add(locale.T(rep.Lang, "build.tests"), shared.StatusFail)
+		sec.Output = testOut
+		addSourceFrames(rep, sec, dir, testOut)
 		return testErr
 	}
-	sb.WriteString("✅ PASS\n\n")
+	sec := rep.Add(locale.T(rep.Lang, "build.tests"), shared.StatusPass)
 
 	// Process coverage
-	sb.WriteString("#### Coverage\n")
+	cov := sec.Add(locale.T(rep.Lang, "build.coverage"), "")
 
 	// 1. Get Total Coverage from go tool cover -func
 	funcOut, funcErr := CommandRunner.RunWithOutput(ctx, dir, "go", "tool", "cover", "-func="+covFile)
@@ -174,7 +274,7 @@ func runTestsPhase(ctx context.Context, dir, pkgs string, sb *strings.Builder) e
 				// Format: "total: (statements) 80.0%"
 				parts := strings.Fields(lastLine)
 				if len(parts) >= 3 {
-					fmt.Fprintf(sb, "- **Total Project Coverage**: %s\n", parts[len(parts)-1])
+					cov.Items = append(cov.Items, locale.T(rep.Lang, "build.total_coverage", parts[len(parts)-1]))
 				}
 			}
 		}
@@ -182,7 +282,6 @@ func runTestsPhase(ctx context.Context, dir, pkgs string, sb *strings.Builder) e
 
 	// 2. Parse per-package coverage from test output
 	lines := strings.Split(testOut, "\n")
-	hasCoverage := false
 	for _, line := range lines {
 		if strings.Contains(line, "\tcoverage: ") {
 			parts := strings.Fields(line)
@@ -190,46 +289,44 @@ func runTestsPhase(ctx context.Context, dir, pkgs string, sb *strings.Builder) e
 				pkg := parts[1]
 				covStr := parts[4] // "50.0%"
 				if covStr != "0.0%" && covStr != "[no" {
-					if !hasCoverage {
-						sb.WriteString("- **Packages**:\n")
-						hasCoverage = true
-					}
-					fmt.Fprintf(sb, "  - `%s`: %s\n", pkg, covStr)
+					cov.Items = append(cov.Items, fmt.Sprintf("`%s`: %s", pkg, covStr))
 				}
 			}
 		}
 	}
-	sb.WriteString("\n")
+	if len(cov.Items) == 0 {
+		sec.Sections = nil
+	}
 	return nil
 }
 
-func runLinterPhase(ctx context.Context, dir, pkgs string, sb *strings.Builder) error {
-	sb.WriteString("### 🧹 Lint: ")
-
+func runLinterPhase(ctx context.Context, dir, pkgs string, rep *shared.Report) error {
+	title := locale.T(rep.Lang, "build.lint")
 	lintCmd := "golangci-lint"
 	lintArgs := []string{"run", pkgs}
 
 	if _, err := CommandRunner.LookPath("golangci-lint"); err != nil {
 		lintCmd = "go"
 		lintArgs = []string{"vet", pkgs}
-		sb.WriteString("(using `go vet`) ")
+		title = locale.T(rep.Lang, "build.lint_vet")
 	}
 
 	lintOut, lintErr := CommandRunner.RunWithOutput(ctx, dir, lintCmd, lintArgs...)
 	if lintErr != nil {
-		sb.WriteString("⚠️ ISSUES FOUND\n\n")
-		sb.WriteString(formatOutput(lintOut))
+		sec := rep.Add(title, shared.StatusWarn)
+		sec.Output = lintOut
+		addSourceFrames(rep, sec, dir, lintOut)
 		return lintErr
 	}
-	sb.WriteString("✅ PASS\n")
+	rep.Add(title, shared.StatusPass)
 	return nil
 }
 
-func formatOutput(out string) string {
-	if out == "" {
-		return ""
+// addSourceFrames adds code frames for the first source positions mentioned in tool output.
+func addSourceFrames(rep *shared.Report, sec *shared.Section, dir, out string) {
+	if frames := shared.FramesFromOutput(dir, out, 5, shared.FrameOptions{}); frames != "" {
+		sec.Add(locale.T(rep.Lang, "build.source"), "").Text = frames
 	}
-	return "```text\n" + strings.TrimSpace(out) + "\n```\n"
 }
 
 func result(content string, isError bool) *mcp.CallToolResult {
@@ -2,10 +2,16 @@ package quality
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/danicat/godoctor/internal/tools/shared"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -42,6 +48,14 @@ func (r *mockRunner) RunWithOutput(ctx context.Context, dir, name string, args .
 	return output, err
 }
 
+func (r *mockRunner) RunStreaming(ctx context.Context, dir string, onLine func(string), name string, args ...string) (string, error) {
+	out, err := r.RunWithOutput(ctx, dir, name, args...)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		onLine(line)
+	}
+	return out, err
+}
+
 func (r *mockRunner) LookPath(file string) (string, error) {
 	return "/usr/bin/" + file, nil
 }
@@ -100,3 +114,190 @@ func TestHandler_BuildFail(t *testing.T) {
 		t.Errorf("Expected build failure in output, got:\n%s", out)
 	}
 }
+
+func TestHandler_BuildFailShowsSource(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tfmt.Println(x)\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	CommandRunner = &mockRunner{
+		outputs: map[string]string{
+			"go build": "# example.com/app\n./main.go:4:14: undefined: x\n",
+		},
+		errors: map[string]error{
+			"go build": fmt.Errorf("exit status 1"),
+		},
+	}
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir})
+	out := res.Content[0].(*mcp.TextContent).Text
+	want := "### Source\n\nmain.go:4\n```go\n  2 | \n  3 | func main() {\n> 4 | \tfmt.Println(x)\n    | \t            ^\n  5 | }\n```"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output to contain %q, got:\n%s", want, out)
+	}
+}
+
+func TestHandler_BuildFailSuggestsNextTools(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	dir := t.TempDir()
+	src := "package main\n\nimport \"gopkg.in/yaml.v3\"\n\nfunc main() { yaml.Dump(nil) }\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	CommandRunner = &mockRunner{
+		outputs: map[string]string{
+			"go build": "# example.com/app\n./main.go:5:20: undefined: yaml.Dump\n",
+		},
+		errors: map[string]error{
+			"go build": fmt.Errorf("exit status 1"),
+		},
+	}
+
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir})
+	next, ok := res.Meta[shared.NextToolsKey].([]shared.NextTool)
+	if !ok || len(next) != 1 {
+		t.Fatalf("suggested_next_tools = %#v", res.Meta[shared.NextToolsKey])
+	}
+	if next[0].Tool != "read_docs" || next[0].Arguments["import_path"] != "gopkg.in/yaml.v3" || next[0].Arguments["symbol_name"] != "Dump" {
+		t.Errorf("unexpected suggestion: %+v", next[0])
+	}
+}
+
+func TestHandler_SuccessSuggestsNothing(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	CommandRunner = &mockRunner{outputs: map[string]string{"go test": "PASS"}}
+	res, _, _ := Handler(context.Background(), nil, Params{})
+	if _, ok := res.Meta[shared.NextToolsKey]; ok {
+		t.Errorf("unexpected suggestions on success: %v", res.Meta)
+	}
+}
+
+func TestHandler_JSONFormat(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	CommandRunner = &mockRunner{
+		outputs: map[string]string{
+			"go build": "syntax error",
+		},
+		errors: map[string]error{
+			"go build": fmt.Errorf("exit status 1"),
+		},
+	}
+
+	res, _, _ := Handler(context.Background(), nil, Params{OutputFormat: "json"})
+	if !res.IsError {
+		t.Error("Expected error result for build failure")
+	}
+	var rep shared.Report
+	if err := json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &rep); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if rep.Status != shared.StatusFail {
+		t.Errorf("status = %q, want fail", rep.Status)
+	}
+	if len(rep.Sections) != 1 || rep.Sections[0].Title != "Build" || rep.Sections[0].Output != "syntax error" {
+		t.Errorf("unexpected sections: %+v", rep.Sections)
+	}
+}
+
+func TestHandler_Localized(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	CommandRunner = &mockRunner{
+		outputs: map[string]string{
+			"go build": "",
+			"go test":  "PASS",
+		},
+	}
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Meta: mcp.Meta{"locale": "pt-BR"}}}
+	res, _, _ := Handler(context.Background(), req, Params{})
+	out := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{"# Relatório de Build", "## Testes: ✅ OK"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Progress(t *testing.T) {
+	oldRunner := CommandRunner
+	defer func() { CommandRunner = oldRunner }()
+
+	CommandRunner = &mockRunner{
+		outputs: map[string]string{
+			"go build": "example.com/app/store\nexample.com/app\n",
+			"go test":  "=== RUN   TestGet\n--- PASS: TestGet (0.00s)\n=== RUN   TestPut\n--- SKIP: TestPut (0.00s)\nPASS\nok  \texample.com/app/store\t0.01s\n?   \texample.com/app\t[no test files]\n",
+		},
+	}
+
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	Register(server)
+
+	var mu sync.Mutex
+	var messages []string
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(ctx context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, req.Params.Message)
+		},
+	})
+	t1, t2 := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, t1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = serverSession.Close() }()
+	clientSession, err := client.Connect(ctx, t2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = clientSession.Close() }()
+
+	params := &mcp.CallToolParams{Name: "smart_build", Arguments: map[string]any{"dir": t.TempDir()}}
+	params.SetProgressToken("build-1")
+	res, err := clientSession.CallTool(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	// Compiled packages are progress, not build output.
+	if out := res.Content[0].(*mcp.TextContent).Text; strings.Contains(out, "example.com/app/store\n") {
+		t.Errorf("compiled packages leaked into the report:\n%s", out)
+	}
+
+	want := []string{
+		"Auto-Fix",
+		"Build",
+		"Build: 2 package(s) compiled",
+		"Tests",
+		"Tests: 1 passed, 0 failed, 1 skipped in 2 finished package(s)",
+		"Lint",
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]string(nil), messages...)
+		mu.Unlock()
+		if len(got) >= len(want) || time.Now().After(deadline) {
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("progress messages = %q, want %q", got, want)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
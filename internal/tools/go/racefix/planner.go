@@ -0,0 +1,588 @@
+package racefix
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// planner maps race reports to the variables involved and plans a mutex for each.
+type planner struct {
+	root   string
+	fset   *token.FileSet
+	files  map[string]*srcFile // absolute path → file
+	guards map[string]*guard   // variable position → guard
+	notes  []string
+	noted  map[string]bool
+	// hunks are the line changes of the last validated patch, to map its reports back.
+	hunks map[string][]textdiff.Hunk
+}
+
+type srcFile struct {
+	pkg  *packages.Package
+	file *ast.File
+	src  []byte
+}
+
+// guard is the mutex protecting one shared variable, and the statements it must wrap.
+type guard struct {
+	v     *types.Var
+	kind  string // "field", "package variable" or "captured variable"
+	name  string // "Counter.count" for fields
+	decl  string // declaration position
+	file  string // file declaring the variable
+	mutex string // mutex name: a field name for fields, a variable name otherwise
+	// reuse is set when the struct already has a mutex field.
+	reuse bool
+	// structPos identifies the struct of a field, so its fields share one new mutex.
+	structPos token.Pos
+	insert    int // offset where the mutex declaration goes, for new mutexes
+	indent    string
+	sites     map[token.Pos]*site
+}
+
+// site is a statement to run under the lock.
+type site struct {
+	file string
+	stmt ast.Stmt
+	lock string // expression of the mutex, "c.mu"
+}
+
+// explanation is a race together with what the planner made of it.
+type explanation struct {
+	race   Race
+	guard  *guard
+	sites  []siteRef
+	reason string
+}
+
+type siteRef struct {
+	file string
+	line int
+}
+
+// ref is a use of a variable on the line of an access.
+type ref struct {
+	v     *types.Var
+	base  ast.Expr // receiver of a field selector
+	write bool
+}
+
+func newPlanner(root string, pkgs []*packages.Package) *planner {
+	p := &planner{
+		root:   root,
+		files:  make(map[string]*srcFile),
+		guards: make(map[string]*guard),
+		noted:  make(map[string]bool),
+	}
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue // generated test main
+		}
+		p.fset = pkg.Fset
+		for _, f := range pkg.Syntax {
+			name := pkg.Fset.File(f.Pos()).Name()
+			// Prefer test variants: they type-check the package's own test files with it.
+			if old, ok := p.files[name]; ok && (strings.Contains(old.pkg.ID, "[") || !strings.Contains(pkg.ID, "[")) {
+				continue
+			}
+			//nolint:gosec // G304: Path comes from the loaded package.
+			src, err := os.ReadFile(name)
+			if err != nil {
+				continue
+			}
+			p.files[name] = &srcFile{pkg: pkg, file: f, src: src}
+		}
+	}
+	return p
+}
+
+func (p *planner) note(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !p.noted[msg] {
+		p.noted[msg] = true
+		p.notes = append(p.notes, msg)
+	}
+}
+
+// add explains each race and records the statements to guard.
+func (p *planner) add(races []Race) []explanation {
+	out := make([]explanation, 0, len(races))
+	for _, r := range races {
+		out = append(out, p.explain(r))
+	}
+	return out
+}
+
+// extend adds the sites of races found while validating, and returns how many are new.
+func (p *planner) extend(races []Race) int {
+	before := p.siteCount()
+	p.add(races)
+	return p.siteCount() - before
+}
+
+func (p *planner) siteCount() int {
+	n := 0
+	for _, g := range p.guards {
+		n += len(g.sites)
+	}
+	return n
+}
+
+func (p *planner) explain(r Race) explanation {
+	e := explanation{race: r}
+	type located struct {
+		file string
+		line int
+		stmt ast.Stmt
+		refs []ref
+	}
+	var sites []located
+	for _, a := range r.Accesses {
+		frames := p.moduleFrames(a.Stack)
+		var loc located
+		for _, f := range frames {
+			if _, ok := p.files[f.File]; ok {
+				loc.file, loc.line = f.File, f.Line
+				loc.stmt, loc.refs = p.analyze(f.File, f.Line)
+				break
+			}
+		}
+		sites = append(sites, loc)
+		e.sites = append(e.sites, siteRef{loc.file, loc.line})
+	}
+	if len(sites) != 2 || sites[0].file == "" || sites[1].file == "" {
+		e.reason = "At least one access happens outside the workspace; guard the data where your code hands it over."
+		return e
+	}
+
+	// The shared variable is one both accesses use; prefer one that is written.
+	var chosen *ref
+	for i := range sites[0].refs {
+		a := &sites[0].refs[i]
+		for _, b := range sites[1].refs {
+			if p.key(a.v) != p.key(b.v) {
+				continue
+			}
+			if chosen == nil || (a.write || b.write) && !chosen.write {
+				c := *a
+				c.write = a.write || b.write
+				chosen = &c
+			}
+		}
+	}
+	if chosen == nil {
+		e.reason = "The two statements share no variable visible at both sites: the memory is reached through pointers, slices or arguments. Find the owner of the data and guard it there."
+		return e
+	}
+	g, reason := p.guardFor(chosen.v)
+	if g == nil {
+		e.reason = reason
+		return e
+	}
+	e.guard = g
+	for _, s := range sites {
+		var base ast.Expr
+		for _, rf := range s.refs {
+			if p.key(rf.v) == p.key(chosen.v) {
+				base = rf.base
+				break
+			}
+		}
+		if reason := p.addSite(g, s.file, s.stmt, base); reason != "" {
+			p.note("%s:%d: %s", p.rel(s.file), s.line, reason)
+		}
+	}
+	return e
+}
+
+func (p *planner) key(v *types.Var) string {
+	return p.fset.Position(v.Pos()).String()
+}
+
+// analyze returns the statement on line of file and the shared variables it uses on that line.
+func (p *planner) analyze(file string, line int) (ast.Stmt, []ref) {
+	f := p.files[file]
+	tf := p.fset.File(f.file.Pos())
+	if line < 1 || line > tf.LineCount() {
+		return nil, nil
+	}
+	off := tf.Offset(tf.LineStart(line))
+	for off < len(f.src) && (f.src[off] == ' ' || f.src[off] == '\t') {
+		off++
+	}
+	pos := tf.Pos(off)
+	path, _ := astutil.PathEnclosingInterval(f.file, pos, pos)
+	var stmt ast.Stmt
+	var fn ast.Node
+	for i, n := range path {
+		if s, ok := n.(ast.Stmt); ok && stmt == nil && i+1 < len(path) {
+			switch path[i+1].(type) {
+			case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+				stmt = s
+			}
+		}
+		if _, ok := n.(*ast.FuncLit); ok && fn == nil {
+			fn = n
+		}
+		if _, ok := n.(*ast.FuncDecl); ok && fn == nil {
+			fn = n
+		}
+	}
+	if stmt == nil || fn == nil {
+		return nil, nil
+	}
+
+	written := make(map[ast.Expr]bool)
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				written[target(lhs)] = true
+			}
+		case *ast.IncDecStmt:
+			written[target(n.X)] = true
+		}
+		return true
+	})
+
+	info := f.pkg.TypesInfo
+	var refs []ref
+	onLine := func(pos token.Pos) bool { return p.fset.Position(pos).Line == line }
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if v, ok := info.Uses[n.Sel].(*types.Var); ok && v.IsField() && onLine(n.Sel.Pos()) && !synchronized(v.Type()) {
+				refs = append(refs, ref{v: v, base: n.X, write: written[n]})
+			}
+		case *ast.Ident:
+			v, ok := info.Uses[n].(*types.Var)
+			if !ok || v.IsField() || !onLine(n.Pos()) || synchronized(v.Type()) || v.Pkg() == nil {
+				return true
+			}
+			packageLevel := v.Parent() == v.Pkg().Scope()
+			captured := v.Pos() < fn.Pos() || v.Pos() >= fn.End()
+			if packageLevel || captured {
+				refs = append(refs, ref{v: v, write: written[n]})
+			}
+		}
+		return true
+	})
+	return stmt, refs
+}
+
+// target strips index, star and paren expressions from an assignment target: writing m[k] or
+// *p writes through m or p.
+func target(e ast.Expr) ast.Expr {
+	for {
+		switch x := e.(type) {
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		case *ast.ParenExpr:
+			e = x.X
+		default:
+			return e
+		}
+	}
+}
+
+// synchronized reports whether values of t synchronize themselves: channels and the types of
+// sync and sync/atomic.
+func synchronized(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if _, ok := t.Underlying().(*types.Chan); ok {
+		return true
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		path := named.Obj().Pkg().Path()
+		return path == "sync" || path == "sync/atomic"
+	}
+	return false
+}
+
+func isMutex(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "sync" &&
+		(named.Obj().Name() == "Mutex" || named.Obj().Name() == "RWMutex")
+}
+
+// guardFor returns the guard of v, planning where its mutex is declared, or the reason there
+// cannot be one.
+func (p *planner) guardFor(v *types.Var) (*guard, string) {
+	key := p.key(v)
+	if g, ok := p.guards[key]; ok {
+		return g, ""
+	}
+	declPos := p.fset.Position(v.Pos())
+	f, ok := p.files[declPos.Filename]
+	if !ok {
+		return nil, fmt.Sprintf("`%s` is declared outside the workspace.", v.Name())
+	}
+	g := &guard{v: v, decl: p.rel(declPos.Filename) + ":" + fmt.Sprint(declPos.Line), file: declPos.Filename, sites: make(map[token.Pos]*site)}
+	path, _ := astutil.PathEnclosingInterval(f.file, v.Pos(), v.Pos())
+	switch {
+	case v.IsField():
+		var field *ast.Field
+		var st *ast.StructType
+		var spec *ast.TypeSpec
+		for _, n := range path {
+			switch n := n.(type) {
+			case *ast.Field:
+				if field == nil {
+					field = n
+				}
+			case *ast.StructType:
+				if st == nil {
+					st = n
+				}
+			case *ast.TypeSpec:
+				if spec == nil {
+					spec = n
+				}
+			}
+		}
+		if field == nil || st == nil || spec == nil {
+			return nil, fmt.Sprintf("`%s` is a field of an anonymous struct; guard the variable holding it.", v.Name())
+		}
+		g.kind, g.name, g.structPos = "field", spec.Name.Name+"."+v.Name(), st.Pos()
+		names := make(map[string]bool)
+		for _, fld := range st.Fields.List {
+			for _, n := range fld.Names {
+				names[n.Name] = true
+				if isMutex(f.pkg.TypesInfo.TypeOf(fld.Type)) && g.mutex == "" {
+					g.mutex, g.reuse = n.Name, true
+				}
+			}
+		}
+		if g.mutex == "" {
+			// Fields of one struct share the mutex planned for the first of them.
+			for _, other := range p.guards {
+				if other.structPos == st.Pos() && other.kind == "field" {
+					g.mutex, g.reuse = other.mutex, true
+				}
+			}
+		}
+		if g.mutex == "" {
+			g.mutex = "mu"
+			if names["mu"] {
+				g.mutex = v.Name() + "Mu"
+			}
+			g.insert, g.indent = lineStart(f.src, p.fset.Position(field.Pos()).Offset)
+		}
+	case v.Parent() == v.Pkg().Scope():
+		var decl *ast.GenDecl
+		for _, n := range path {
+			if d, ok := n.(*ast.GenDecl); ok {
+				decl = d
+				break
+			}
+		}
+		if decl == nil {
+			return nil, fmt.Sprintf("the declaration of `%s` was not found.", v.Name())
+		}
+		g.kind, g.name = "package variable", v.Name()
+		g.mutex = p.freeName(v.Pkg().Scope(), v.Name()+"Mu")
+		g.insert = p.fset.Position(decl.End()).Offset
+	default:
+		g.kind, g.name = "captured variable", v.Name()
+		var stmt ast.Stmt
+		var body *ast.BlockStmt
+	walk:
+		for i, n := range path {
+			switch n := n.(type) {
+			case *ast.FuncType:
+				// A parameter: declare the mutex first thing in the body.
+				if i+1 < len(path) {
+					switch fn := path[i+1].(type) {
+					case *ast.FuncLit:
+						body = fn.Body
+					case *ast.FuncDecl:
+						body = fn.Body
+					}
+				}
+				break walk
+			case ast.Stmt:
+				if i+1 < len(path) {
+					switch path[i+1].(type) {
+					case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+						stmt = n
+						break walk
+					}
+				}
+			}
+		}
+		switch s := stmt.(type) {
+		case *ast.DeclStmt, *ast.AssignStmt:
+			g.insert = p.fset.Position(s.End()).Offset
+			_, g.indent = lineStart(f.src, p.fset.Position(s.Pos()).Offset)
+		case nil:
+			if body == nil || len(body.List) == 0 {
+				return nil, fmt.Sprintf("no place was found to declare a mutex next to `%s`.", v.Name())
+			}
+			g.insert = p.fset.Position(body.Lbrace).Offset + 1
+			_, g.indent = lineStart(f.src, p.fset.Position(body.List[0].Pos()).Offset)
+		default:
+			return nil, fmt.Sprintf("`%s` is declared in the header of a statement; move the declaration out to guard it.", v.Name())
+		}
+		scope := f.pkg.Types.Scope().Innermost(v.Pos())
+		g.mutex = p.freeName(scope, v.Name()+"Mu")
+	}
+	p.guards[key] = g
+	return g, ""
+}
+
+// freeName returns name, numbered if the scope or another guard already uses it.
+func (p *planner) freeName(scope *types.Scope, name string) string {
+	taken := func(n string) bool {
+		if scope != nil {
+			if _, obj := scope.LookupParent(n, token.NoPos); obj != nil {
+				return true
+			}
+		}
+		for _, g := range p.guards {
+			if g.mutex == n && g.kind != "field" {
+				return true
+			}
+		}
+		return false
+	}
+	out := name
+	for i := 2; taken(out); i++ {
+		out = fmt.Sprintf("%s%d", name, i)
+	}
+	return out
+}
+
+// addSite records that stmt must hold g's mutex, or returns why it cannot.
+func (p *planner) addSite(g *guard, file string, stmt ast.Stmt, base ast.Expr) string {
+	if stmt == nil {
+		return "the racy statement was not found"
+	}
+	if _, ok := g.sites[stmt.Pos()]; ok {
+		return ""
+	}
+	switch s := stmt.(type) {
+	case *ast.AssignStmt, *ast.IncDecStmt, *ast.ExprStmt, *ast.SendStmt, *ast.DeclStmt, *ast.GoStmt, *ast.DeferStmt, *ast.ReturnStmt:
+	case *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt:
+		if jumps(s) {
+			return "the access is in the header of a statement that returns or branches; lock around it by hand"
+		}
+	default:
+		return "the access is in a loop header; lock around it by hand"
+	}
+	lock := g.mutex
+	if g.kind == "field" {
+		if base == nil {
+			return "the field is not accessed through a selector"
+		}
+		f := p.files[file]
+		start, end := p.fset.Position(base.Pos()).Offset, p.fset.Position(base.End()).Offset
+		lock = string(f.src[start:end]) + "." + g.mutex
+	}
+	g.sites[stmt.Pos()] = &site{file: file, stmt: stmt, lock: lock}
+	return ""
+}
+
+// jumps reports whether a statement contains a return or branch statement outside function
+// literals, which would skip an Unlock placed after it.
+func jumps(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt, *ast.BranchStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// changeset renders the mutex declarations and the Lock/Unlock calls.
+func (p *planner) changeset() (shared.Changeset, error) {
+	edits := make(map[string][]shared.TextEdit)
+	keys := sortedKeys(p.guards)
+
+	// New struct mutexes list every field they guard.
+	guarded := make(map[token.Pos][]string)
+	for _, k := range keys {
+		if g := p.guards[k]; g.kind == "field" && len(g.sites) > 0 {
+			guarded[g.structPos] = append(guarded[g.structPos], g.v.Name())
+		}
+	}
+	for _, k := range keys {
+		g := p.guards[k]
+		if len(g.sites) == 0 {
+			continue
+		}
+		if !g.reuse {
+			var text string
+			switch g.kind {
+			case "field":
+				text = fmt.Sprintf("%s%s sync.Mutex // guards %s\n", g.indent, g.mutex, strings.Join(guarded[g.structPos], ", "))
+			case "package variable":
+				text = fmt.Sprintf("\n\n// %s guards %s.\nvar %s sync.Mutex", g.mutex, g.v.Name(), g.mutex)
+			default:
+				text = fmt.Sprintf("\n%svar %s sync.Mutex", g.indent, g.mutex)
+			}
+			edits[g.file] = append(edits[g.file], shared.TextEdit{Start: g.insert, End: g.insert, New: text})
+		}
+		positions := make([]token.Pos, 0, len(g.sites))
+		for pos := range g.sites {
+			positions = append(positions, pos)
+		}
+		sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+		for _, pos := range positions {
+			s := g.sites[pos]
+			src := p.files[s.file].src
+			start := p.fset.Position(s.stmt.Pos()).Offset
+			end := p.fset.Position(s.stmt.End()).Offset
+			_, indent := lineStart(src, start)
+			if _, ok := s.stmt.(*ast.ReturnStmt); ok {
+				edits[s.file] = append(edits[s.file], shared.TextEdit{Start: start, End: start,
+					New: fmt.Sprintf("%s.Lock()\n%sdefer %s.Unlock()\n%s", s.lock, indent, s.lock, indent)})
+				continue
+			}
+			edits[s.file] = append(edits[s.file],
+				shared.TextEdit{Start: start, End: start, New: s.lock + ".Lock()\n" + indent},
+				shared.TextEdit{Start: end, End: end, New: "\n" + indent + s.lock + ".Unlock()"})
+		}
+	}
+
+	cs := shared.Changeset{}
+	for file, list := range edits {
+		out, err := shared.ApplyEdits(p.files[file].src, list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the patch of %s: %w", p.rel(file), err)
+		}
+		cs[file] = out
+	}
+	return cs, nil
+}
+
+// lineStart returns the offset of the line containing off and its indentation.
+func lineStart(src []byte, off int) (int, string) {
+	start := off
+	for start > 0 && src[start-1] != '\n' {
+		start--
+	}
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return start, string(src[start:end])
+}
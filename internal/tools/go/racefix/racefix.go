@@ -0,0 +1,406 @@
+// Package racefix implements the fix_data_race tool, which runs tests under the race detector,
+// explains each race with the conflicting accesses and the code around them, and proposes a
+// mutex patch that is validated by running the detector again.
+package racefix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["fix_data_race"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package string `json:"package,omitempty" jsonschema:"Package pattern to test (default ./...)"`
+	Run     string `json:"run,omitempty" jsonschema:"Regular expression selecting the tests, as for go test -run"`
+	Count   int    `json:"count,omitempty" jsonschema:"Times to run the tests; races depend on timing, so raise it when reports are intermittent (default 1, max 20)"`
+	Apply   bool   `json:"apply,omitempty" jsonschema:"Write the patch if the race detector passes with it; by default it is only proposed"`
+}
+
+const (
+	maxCount = 20
+	// maxRounds bounds the detect-and-extend loop: races hidden behind the first ones only show
+	// up once those are fixed.
+	maxRounds    = 3
+	runTimeout   = "5m"
+	checkTimeout = "2m" // a patch that deadlocks fails validation instead of hanging
+	maxRaces     = 10
+	maxDiffLines = 400
+)
+
+// Handler handles the fix_data_race tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Package == "" {
+		args.Package = "./..."
+	}
+	if args.Count <= 0 {
+		args.Count = 1
+	}
+	args.Count = min(args.Count, maxCount)
+
+	command := "go test -race -count=" + strconv.Itoa(args.Count)
+	if args.Run != "" {
+		command += " -run " + args.Run
+	}
+	command += " " + args.Package
+	out, failed, err := goTest(ctx, absDir, args, "", runTimeout)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	races := ParseReports(out)
+	if len(races) == 0 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "✅ No data race detected by `%s`.\n", command)
+		if failed {
+			fmt.Fprintf(&sb, "\nThe tests failed for another reason:\n\n```\n%s\n```\n", tail(out, 30))
+		} else if args.Count == 1 {
+			sb.WriteString("\nRaces depend on scheduling; if one was reported before, retry with a higher `count`.\n")
+		}
+		return textResult(sb.String()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	p := newPlanner(absDir, pkgs)
+	explained := p.add(races)
+
+	// Validate the patch with the detector, extending it with the races it uncovers.
+	var patch shared.Changeset
+	validated := false
+	var failure string
+	for round := 1; round <= maxRounds; round++ {
+		patch, err = p.changeset()
+		if err != nil {
+			failure = err.Error()
+			break
+		}
+		if len(patch) == 0 {
+			break
+		}
+		out, failed, err := p.check(ctx, args, patch)
+		if err != nil {
+			failure = err.Error()
+			break
+		}
+		if !failed {
+			validated = true
+			break
+		}
+		more := p.remap(ParseReports(out))
+		if len(more) == 0 || round == maxRounds || p.extend(more) == 0 {
+			failure = tail(out, 40)
+			break
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Data races\n\n`%s` reported %d distinct race(s).\n\n", command, len(races))
+	for i, e := range explained {
+		if i == maxRaces {
+			fmt.Fprintf(&sb, "... %d more race(s) not shown.\n\n", len(explained)-maxRaces)
+			break
+		}
+		p.writeRace(&sb, i+1, e)
+	}
+
+	sb.WriteString("## Proposed patch\n\n")
+	switch {
+	case len(patch) == 0:
+		sb.WriteString("No mutex patch could be derived; use the accesses above to choose the synchronization (a mutex, sync/atomic, or passing ownership over a channel).\n")
+	case validated && args.Apply:
+		if err := patch.ApplyVerified(ctx, absDir, []string{"vet", args.Package}); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ `%s` passes with the patch; it was applied to %d file(s).\n\n", command, len(patch))
+	case validated:
+		fmt.Fprintf(&sb, "✅ `%s` passes with this patch. Nothing was written; re-run with `apply=true` or apply it with `smart_edit`.\n\n", command)
+	default:
+		sb.WriteString("⚠️ The patch does not pass validation yet; treat it as a starting point.\n\n")
+		if failure != "" {
+			fmt.Fprintf(&sb, "```\n%s\n```\n\n", failure)
+		}
+	}
+	if len(patch) > 0 {
+		sb.WriteString(p.diff(patch))
+	}
+	if len(p.notes) > 0 {
+		sb.WriteString("\n## Notes\n\n")
+		for _, n := range p.notes {
+			fmt.Fprintf(&sb, "- %s\n", n)
+		}
+	}
+	return textResult(sb.String()), nil, nil
+}
+
+// goTest runs the tests under the race detector, with the files of an overlay replaced. It
+// reports whether they failed; err is only set when go could not be run.
+func goTest(ctx context.Context, dir string, args Params, overlay, timeout string) (string, bool, error) {
+	cmdArgs := []string{"test", "-race", "-count=" + strconv.Itoa(args.Count), "-timeout", timeout}
+	if overlay != "" {
+		cmdArgs = append(cmdArgs, "-overlay", overlay)
+	}
+	if args.Run != "" {
+		cmdArgs = append(cmdArgs, "-run", args.Run)
+	}
+	cmdArgs = append(cmdArgs, args.Package)
+	cmd := exec.CommandContext(ctx, "go", cmdArgs...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", false, fmt.Errorf("failed to run go test: %w", err)
+	}
+	return string(out), err != nil, nil
+}
+
+// check runs vet and the race detector with the patch laid over the workspace through
+// -overlay, so nothing is written.
+func (p *planner) check(ctx context.Context, args Params, patch shared.Changeset) (string, bool, error) {
+	tmp, err := os.MkdirTemp("", "godoctor-racefix-")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.RemoveAll(tmp)
+	replace := make(map[string]string)
+	p.hunks = make(map[string][]textdiff.Hunk)
+	for i, path := range patch.Files() {
+		src, err := imports.Process(path, patch[path], nil)
+		if err != nil {
+			return "", false, fmt.Errorf("the patch of %s does not parse: %w", p.rel(path), err)
+		}
+		p.hunks[path] = textdiff.Compute(string(p.files[path].src), string(src), 0)
+		name := filepath.Join(tmp, fmt.Sprintf("%d_%s", i, filepath.Base(path)))
+		if err := writes.Write(ctx, name, src, 0644); err != nil {
+			return "", false, err
+		}
+		replace[path] = name
+	}
+	data, err := json.Marshal(map[string]any{"Replace": replace})
+	if err != nil {
+		return "", false, err
+	}
+	overlay := filepath.Join(tmp, "overlay.json")
+	if err := writes.Write(ctx, overlay, data, 0644); err != nil {
+		return "", false, err
+	}
+
+	vet := exec.CommandContext(ctx, "go", "vet", "-overlay", overlay, args.Package)
+	vet.Dir = p.root
+	if out, err := vet.CombinedOutput(); err != nil {
+		return string(out), true, nil
+	}
+	return goTest(ctx, p.root, args, overlay, checkTimeout)
+}
+
+// remap translates the lines of reports from a patched run back to the original sources.
+func (p *planner) remap(races []Race) []Race {
+	fix := func(stack []Frame) {
+		for i, f := range stack {
+			if hunks, ok := p.hunks[f.File]; ok {
+				stack[i].Line = originalLine(hunks, f.Line)
+			}
+		}
+	}
+	for _, r := range races {
+		for _, a := range r.Accesses {
+			fix(a.Stack)
+		}
+		for _, c := range r.Creations {
+			fix(c.Stack)
+		}
+	}
+	return races
+}
+
+// originalLine returns the line of the original file that line of the patched file comes from.
+// Inserted lines map to the original line that follows them.
+func originalLine(hunks []textdiff.Hunk, line int) int {
+	shift := 0
+	for _, h := range hunks {
+		newStart := h.NewStart
+		if h.NewLines == 0 {
+			newStart++ // a pure deletion starts after NewStart
+		}
+		if line < newStart {
+			break
+		}
+		if line < newStart+h.NewLines {
+			if h.OldLines == 0 {
+				return h.OldStart + 1
+			}
+			return h.OldStart
+		}
+		shift = h.OldStart + h.OldLines - (newStart + h.NewLines)
+		if h.OldLines == 0 {
+			shift++
+		}
+	}
+	return line + shift
+}
+
+// diff renders the patch as unified diffs.
+func (p *planner) diff(patch shared.Changeset) string {
+	var lines []string
+	for _, path := range patch.Files() {
+		src, err := imports.Process(path, patch[path], nil)
+		if err != nil {
+			src = patch[path]
+		}
+		lines = append(lines, "--- "+p.rel(path), "+++ "+p.rel(path))
+		d := textdiff.Unified(string(p.files[path].src), string(src))
+		lines = append(lines, strings.Split(strings.TrimSuffix(d, "\n"), "\n")...)
+	}
+	if len(lines) > maxDiffLines {
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxDiffLines))
+	}
+	return "```diff\n" + strings.Join(lines, "\n") + "\n```\n"
+}
+
+// writeRace renders one race: the variable, both accesses with their code, and where the
+// goroutines were started.
+func (p *planner) writeRace(sb *strings.Builder, n int, e explanation) {
+	if e.guard != nil {
+		fmt.Fprintf(sb, "## %d. `%s` (%s declared at %s)\n\n", n, e.guard.name, e.guard.kind, e.guard.decl)
+	} else {
+		fmt.Fprintf(sb, "## %d. Unidentified memory at %s\n\n", n, e.race.Addr)
+	}
+	shown := make(map[string]bool)
+	for i, a := range e.race.Accesses {
+		when := ""
+		if i == 1 {
+			when = "previous "
+		}
+		fmt.Fprintf(sb, "- **%s%s** by %s", when, a.Kind, a.Goroutine)
+		frames := p.moduleFrames(a.Stack)
+		if len(frames) == 0 {
+			sb.WriteString(" outside the workspace\n")
+			continue
+		}
+		fmt.Fprintf(sb, " at `%s` in `%s`", p.frameLoc(frames[0]), shortFunc(frames[0].Func))
+		for _, f := range frames[1:min(len(frames), 4)] {
+			fmt.Fprintf(sb, " ← `%s`", p.frameLoc(f))
+		}
+		sb.WriteString("\n")
+	}
+	for _, c := range e.race.Creations {
+		if frames := p.moduleFrames(c.Stack); len(frames) > 0 {
+			fmt.Fprintf(sb, "- %s started at `%s` in `%s`\n", c.Goroutine, p.frameLoc(frames[0]), shortFunc(frames[0].Func))
+		}
+	}
+	sb.WriteString("\n")
+	for _, s := range e.sites {
+		key := s.file + ":" + strconv.Itoa(s.line)
+		if s.file == "" || shown[key] {
+			continue
+		}
+		shown[key] = true
+		f := p.files[s.file]
+		fmt.Fprintf(sb, "`%s`:\n\n```go\n%s```\n\n", p.rel(s.file), shared.CodeFrame(string(f.src), s.line, shared.FrameOptions{Context: 2}))
+	}
+	if e.reason != "" {
+		fmt.Fprintf(sb, "%s\n\n", e.reason)
+	}
+}
+
+// moduleFrames returns the frames of a stack that lie in the workspace.
+func (p *planner) moduleFrames(stack []Frame) []Frame {
+	var out []Frame
+	for _, f := range stack {
+		if strings.HasPrefix(f.File, p.root+string(filepath.Separator)) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (p *planner) frameLoc(f Frame) string {
+	return p.rel(f.File) + ":" + strconv.Itoa(f.Line)
+}
+
+func (p *planner) rel(path string) string {
+	if r, err := filepath.Rel(p.root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+// shortFunc drops the import path from a function name: "example.com/app.(*C).Inc" becomes
+// "app.(*C).Inc".
+func shortFunc(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = append([]string{"..."}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package racefix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const report = `=== RUN   TestCounter
+==================
+WARNING: DATA RACE
+Read at 0x00c000014118 by goroutine 8:
+  example.com/app.(*Counter).Inc()
+      /tmp/app/counter.go:13 +0x44
+  example.com/app.TestCounter.func1()
+      /tmp/app/counter_test.go:15 +0x7b
+
+Previous write at 0x00c000014118 by goroutine 7:
+  example.com/app.(*Counter).Inc()
+      /tmp/app/counter.go:13 +0x56
+  example.com/app.TestCounter.func1()
+      /tmp/app/counter_test.go:15 +0x7b
+
+Goroutine 8 (running) created at:
+  example.com/app.TestCounter()
+      /tmp/app/counter_test.go:13 +0x9e
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+
+Goroutine 7 (finished) created at:
+  example.com/app.TestCounter()
+      /tmp/app/counter_test.go:13 +0x9e
+==================
+==================
+WARNING: DATA RACE
+Read at 0x00c000014118 by goroutine 9:
+  example.com/app.(*Counter).Inc()
+      /tmp/app/counter.go:13 +0x44
+
+Previous write at 0x00c000014118 by goroutine 7:
+  example.com/app.(*Counter).Inc()
+      /tmp/app/counter.go:13 +0x56
+==================
+--- FAIL: TestCounter (0.00s)
+    testing.go:1865: race detected during execution of test
+`
+
+func TestParseReports(t *testing.T) {
+	races := ParseReports(report)
+	if len(races) != 1 {
+		t.Fatalf("got %d races, want the duplicate folded into 1: %+v", len(races), races)
+	}
+	r := races[0]
+	if r.Addr != "0x00c000014118" || len(r.Accesses) != 2 || len(r.Creations) != 2 {
+		t.Fatalf("unexpected race: %+v", r)
+	}
+	read, write := r.Accesses[0], r.Accesses[1]
+	if read.Kind != "read" || read.Goroutine != "goroutine 8" || write.Kind != "write" {
+		t.Errorf("unexpected accesses: %+v", r.Accesses)
+	}
+	want := Frame{Func: "example.com/app.(*Counter).Inc", File: "/tmp/app/counter.go", Line: 13}
+	if read.Stack[0] != want || len(read.Stack) != 2 {
+		t.Errorf("got stack %+v, want %+v first", read.Stack, want)
+	}
+	if c := r.Creations[0]; c.Goroutine != "goroutine 8" || c.Stack[0].Line != 13 || len(c.Stack) != 2 {
+		t.Errorf("unexpected creation: %+v", c)
+	}
+}
+
+func TestOriginalLine(t *testing.T) {
+	before := "a\nb\nc\nd\n"
+	after := "a\nlock\nb\nunlock\nc\nd\n"
+	hunks := textdiff.Compute(before, after, 0)
+	for patched, want := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 3, 6: 4} {
+		if got := originalLine(hunks, patched); got != want {
+			t.Errorf("originalLine(%d) = %d, want %d", patched, got, want)
+		}
+	}
+}
+
+const counter = `package app
+
+import "sync"
+
+// Counter counts events.
+type Counter struct {
+	name  string
+	count int
+}
+
+// Inc adds one.
+func (c *Counter) Inc() {
+	c.count++
+}
+
+// Value returns the count.
+func (c *Counter) Value() int {
+	return c.count
+}
+
+var total int
+
+// Run increments from n goroutines.
+func Run(n int) int {
+	var wg sync.WaitGroup
+	hits := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hits++
+			total += 1
+		}()
+	}
+	wg.Wait()
+	return hits
+}
+`
+
+const counterTest = `package app
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	var c Counter
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Inc()
+			_ = c.Value()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRun(t *testing.T) {
+	if got := Run(8); got == 0 {
+		t.Fatal(got)
+	}
+}
+`
+
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	files["go.mod"] = "module example.com/app\n\ngo 1.24\n"
+	return testutil.WriteModule(t, files)
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestHandler_Fix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test -race")
+	}
+	dir := setup(t, map[string]string{"counter.go": counter, "counter_test.go": counterTest})
+	text, isErr := call(t, Params{Dir: dir, Count: 3, Apply: true})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"`Counter.count` (field declared at counter.go:8)",
+		"`hits` (captured variable declared at counter.go:26)",
+		"`total` (package variable declared at counter.go:21)",
+		"passes with the patch; it was applied to 1 file(s)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "counter.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"mu    sync.Mutex // guards count",
+		"c.mu.Lock()\n\tc.count++\n\tc.mu.Unlock()",
+		// Value only races once Inc is locked; the second round adds it.
+		"c.mu.Lock()\n\tdefer c.mu.Unlock()\n\treturn c.count",
+		"var totalMu sync.Mutex",
+		"hits := 0\n\tvar hitsMu sync.Mutex",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("patched file missing %q:\n%s", want, got)
+		}
+	}
+	if text, _ := call(t, Params{Dir: dir, Count: 3}); !strings.Contains(text, "No data race") {
+		t.Errorf("races remain after applying the patch:\n%s", text)
+	}
+}
+
+func TestHandler_NoPatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test -race")
+	}
+	src := `package app
+
+func Fill(s []int) {
+	done := make(chan bool)
+	go func() {
+		s[0] = 1
+		done <- true
+	}()
+	s[0] = 2
+	<-done
+}
+`
+	test := `package app
+
+import "testing"
+
+func TestFill(t *testing.T) { Fill(make([]int, 1)) }
+`
+	dir := setup(t, map[string]string{"fill.go": src, "fill_test.go": test})
+	text, isErr := call(t, Params{Dir: dir})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{"Unidentified memory", "No mutex patch could be derived"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
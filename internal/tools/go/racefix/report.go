@@ -0,0 +1,94 @@
+package racefix
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is one stack frame of a race report.
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Access is one of the two conflicting memory accesses of a race.
+type Access struct {
+	Kind      string  `json:"kind"` // "read", "write", "atomic write", ...
+	Goroutine string  `json:"goroutine"`
+	Stack     []Frame `json:"stack"`
+}
+
+// Creation is where a goroutine involved in a race was started.
+type Creation struct {
+	Goroutine string  `json:"goroutine"`
+	Stack     []Frame `json:"stack"`
+}
+
+// Race is one "WARNING: DATA RACE" report of the race detector.
+type Race struct {
+	Addr      string     `json:"addr"`
+	Accesses  []Access   `json:"accesses"`
+	Creations []Creation `json:"creations,omitempty"`
+}
+
+var (
+	accessRx  = regexp.MustCompile(`^(?:Previous )?([A-Za-z ]+?) at (0x[0-9a-f]+) by (goroutine \d+|main goroutine):$`)
+	createdRx = regexp.MustCompile(`^Goroutine (\d+) \((?:running|finished)\) created at:$`)
+	fileRx    = regexp.MustCompile(`^\s+(.+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// ParseReports extracts the data race reports from the output of a -race binary. Identical
+// reports, which a racy loop produces many times, are returned once.
+func ParseReports(output string) []Race {
+	var races []Race
+	seen := make(map[string]bool)
+	var cur *Race
+	var stack *[]Frame // the stack being read; headers start a new one
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "WARNING: DATA RACE":
+			cur, stack = &Race{}, nil
+		case cur == nil:
+		case strings.HasPrefix(trimmed, "=================="):
+			if key := cur.key(); len(cur.Accesses) == 2 && !seen[key] {
+				seen[key] = true
+				races = append(races, *cur)
+			}
+			cur, stack = nil, nil
+		case accessRx.MatchString(trimmed):
+			m := accessRx.FindStringSubmatch(trimmed)
+			cur.Addr = m[2]
+			cur.Accesses = append(cur.Accesses, Access{Kind: strings.ToLower(m[1]), Goroutine: m[3]})
+			stack = &cur.Accesses[len(cur.Accesses)-1].Stack
+		case createdRx.MatchString(trimmed):
+			g := "goroutine " + createdRx.FindStringSubmatch(trimmed)[1]
+			cur.Creations = append(cur.Creations, Creation{Goroutine: g})
+			stack = &cur.Creations[len(cur.Creations)-1].Stack
+		case stack == nil || trimmed == "":
+		case fileRx.MatchString(line):
+			if n := len(*stack); n > 0 && (*stack)[n-1].File == "" {
+				m := fileRx.FindStringSubmatch(line)
+				(*stack)[n-1].File = m[1]
+				(*stack)[n-1].Line, _ = strconv.Atoi(m[2])
+			}
+		default:
+			*stack = append(*stack, Frame{Func: strings.TrimSuffix(trimmed, "()")})
+		}
+	}
+	return races
+}
+
+// key identifies a race by the top frame of both accesses.
+func (r *Race) key() string {
+	var parts []string
+	for _, a := range r.Accesses {
+		if len(a.Stack) > 0 {
+			parts = append(parts, a.Kind+"@"+a.Stack[0].File+":"+strconv.Itoa(a.Stack[0].Line))
+		}
+	}
+	return strings.Join(parts, "|")
+}
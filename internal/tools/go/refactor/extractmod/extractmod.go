@@ -0,0 +1,422 @@
+// Package extractmod implements the extract_module tool, which turns a package subtree of a module
+// into a standalone module: it writes the new go.mod, rewrites import paths on both sides, wires the
+// two together with a replace directive for local development and verifies that both build.
+package extractmod
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["extract_module"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir        string `json:"dir,omitempty" jsonschema:"The absolute root directory of the module to extract from (where go.mod lives). Always pass absolute paths in multi-root workspaces."`
+	Package    string `json:"package" jsonschema:"Directory of the package subtree to extract, relative to dir (e.g. pkg/retry)"`
+	ModulePath string `json:"module_path,omitempty" jsonschema:"Module path of the new module (default: the subtree's current import path)"`
+	Target     string `json:"target,omitempty" jsonschema:"Absolute directory for the new module (default: the package directory itself, creating a nested module in place)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"If true, report the plan without writing any files"`
+}
+
+// pseudoVersion is the placeholder version required for a module that is resolved through a
+// replace directive.
+const pseudoVersion = "v0.0.0-00010101000000-000000000000"
+
+// Plan describes an extraction.
+type Plan struct {
+	OldPath   string   // import path of the subtree today
+	NewPath   string   // module path of the new module
+	Source    string   // absolute directory of the subtree
+	Target    string   // absolute directory of the new module
+	Moved     []string // files of the new module, relative to Target
+	Importers []string // files of the parent module that import the subtree, relative to the parent
+	Replace   string   // replace directive target, relative to the parent module
+	Changes   shared.Changeset
+}
+
+// Handler handles the extract_module tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" {
+		return errorResult("package cannot be empty"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	target := args.Target
+	if target != "" {
+		if target, err = roots.Global.Validate(session, target); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+	}
+
+	plan, err := Prepare(ctx, absDir, args.Package, args.ModulePath, target)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.DryRun {
+		return textResult(render(plan, true)), nil, nil
+	}
+
+	if err := plan.Changes.ApplyVerified(ctx, absDir, []string{"build", "./..."}, []string{"-C", plan.Target, "build", "./..."}); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if plan.Target != plan.Source {
+		removeEmptyDirs(plan.Source, absDir)
+	}
+	return textResult(render(plan, false)), nil, nil
+}
+
+// Prepare computes the changes that extract the subtree at pkgDir (relative to root) into a module
+// with path newPath at target. Empty newPath and target keep the current import path and location.
+func Prepare(ctx context.Context, root, pkgDir, newPath, target string) (*Plan, error) {
+	gomodPath := filepath.Join(root, "go.mod")
+	data, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a module root: %w", root, err)
+	}
+	parent, err := modfile.Parse(gomodPath, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	if parent.Module == nil {
+		return nil, fmt.Errorf("go.mod in %s has no module directive", root)
+	}
+
+	source := filepath.Join(root, filepath.FromSlash(pkgDir))
+	rel, err := filepath.Rel(root, source)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("package %q must be a subdirectory of %s", pkgDir, root)
+	}
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("package directory %s does not exist", source)
+	}
+	if _, err := os.Stat(filepath.Join(source, "go.mod")); err == nil {
+		return nil, fmt.Errorf("%s is already a separate module", source)
+	}
+
+	plan := &Plan{
+		OldPath: parent.Module.Mod.Path + "/" + filepath.ToSlash(rel),
+		NewPath: newPath,
+		Source:  source,
+		Target:  target,
+		Changes: make(shared.Changeset),
+	}
+	if plan.NewPath == "" {
+		plan.NewPath = plan.OldPath
+	}
+	if err := module.CheckPath(plan.NewPath); err != nil {
+		return nil, fmt.Errorf("invalid module path: %w", err)
+	}
+	if plan.Target == "" {
+		plan.Target = source
+	}
+	if plan.Target != source {
+		if entries, err := os.ReadDir(plan.Target); err == nil && len(entries) > 0 {
+			return nil, fmt.Errorf("target %s already exists and is not empty", plan.Target)
+		}
+	}
+
+	if err := checkImports(ctx, root, parent.Module.Mod.Path, plan); err != nil {
+		return nil, err
+	}
+
+	// Move (or rewrite in place) every file of the subtree.
+	err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != source {
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir // a nested module stays where it is
+				}
+			}
+			return nil
+		}
+		relFile, _ := filepath.Rel(source, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		newContent, changed, err := rewriteFile(path, content, plan.OldPath, plan.NewPath)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(plan.Target, relFile)
+		plan.Moved = append(plan.Moved, filepath.ToSlash(relFile))
+		if dest != path {
+			plan.Changes[path] = nil
+			plan.Changes[dest] = newContent
+		} else if changed {
+			plan.Changes[path] = newContent
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	gomod, err := newGoMod(parent, plan.NewPath)
+	if err != nil {
+		return nil, err
+	}
+	plan.Changes[filepath.Join(plan.Target, "go.mod")] = gomod
+	if sum, err := os.ReadFile(filepath.Join(root, "go.sum")); err == nil {
+		plan.Changes[filepath.Join(plan.Target, "go.sum")] = sum
+	}
+
+	// Point the parent's importers at the new module.
+	for _, file := range plan.Importers {
+		path := filepath.Join(root, file)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if newContent, changed, err := rewriteFile(path, content, plan.OldPath, plan.NewPath); err != nil {
+			return nil, err
+		} else if changed {
+			plan.Changes[path] = newContent
+		}
+	}
+	if len(plan.Importers) > 0 {
+		replace, err := filepath.Rel(root, plan.Target)
+		if err != nil {
+			return nil, err
+		}
+		replace = filepath.ToSlash(replace)
+		if !strings.HasPrefix(replace, "../") {
+			replace = "./" + replace
+		}
+		plan.Replace = replace
+		if err := parent.AddRequire(plan.NewPath, pseudoVersion); err != nil {
+			return nil, err
+		}
+		if err := parent.AddReplace(plan.NewPath, "", replace, ""); err != nil {
+			return nil, err
+		}
+		parent.Cleanup()
+		out, err := parent.Format()
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes[gomodPath] = out
+	}
+	return plan, nil
+}
+
+// checkImports records the parent files that import the subtree and rejects extractions that would
+// leave the new module importing the rest of the parent (a module cycle) or make the subtree an
+// internal package the parent may no longer import.
+func checkImports(ctx context.Context, root, parentPath string, plan *Plan) error {
+	pkgs, err := shared.LoadPackages(ctx, root, "./...", true)
+	if err != nil {
+		return err
+	}
+	inSubtree := func(path string) bool { return path == plan.OldPath || strings.HasPrefix(path, plan.OldPath+"/") }
+	inParent := func(path string) bool { return path == parentPath || strings.HasPrefix(path, parentPath+"/") }
+
+	var cycles []string
+	importers := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue // synthesized test main
+		}
+		pkgPath := strings.TrimSuffix(pkg.PkgPath, "_test")
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			for _, spec := range file.Imports {
+				imp, _ := strconv.Unquote(spec.Path.Value)
+				switch {
+				case inSubtree(pkgPath) && inParent(imp) && !inSubtree(imp):
+					cycles = append(cycles, fmt.Sprintf("%s imports %s", shared.RelPosition(root, pkg.Fset.Position(spec.Pos())), imp))
+				case !inSubtree(pkgPath) && inSubtree(imp):
+					newImp := plan.NewPath + strings.TrimPrefix(imp, plan.OldPath)
+					if !internalAllowed(pkgPath, newImp) {
+						return fmt.Errorf("%s could no longer import %s: it would be an internal package of another module; choose a module path without /internal/", pkgPath, newImp)
+					}
+					rel, _ := filepath.Rel(root, filename)
+					importers[filepath.ToSlash(rel)] = true
+				}
+			}
+		}
+	}
+	if len(cycles) > 0 {
+		sort.Strings(cycles)
+		cycles = dedupe(cycles)
+		return fmt.Errorf("the subtree depends on other packages of %s, so the new module would import its parent:\n- %s\nMove those dependencies into the subtree first, or invert them behind an interface",
+			parentPath, strings.Join(cycles, "\n- "))
+	}
+	for f := range importers {
+		plan.Importers = append(plan.Importers, f)
+	}
+	sort.Strings(plan.Importers)
+	return nil
+}
+
+// internalAllowed applies the Go internal package rule: a path containing an "internal" element can
+// only be imported from within the tree rooted at the parent of that element.
+func internalAllowed(importer, imported string) bool {
+	i := strings.LastIndex(imported, "/internal/")
+	if i < 0 && strings.HasSuffix(imported, "/internal") {
+		i = len(imported) - len("/internal")
+	}
+	if i < 0 {
+		return true
+	}
+	prefix := imported[:i]
+	return importer == prefix || strings.HasPrefix(importer, prefix+"/")
+}
+
+// rewriteFile replaces imports of oldPath (and its subpackages) with newPath in a Go file. Other files
+// are returned unchanged.
+func rewriteFile(path string, content []byte, oldPath, newPath string) ([]byte, bool, error) {
+	if !strings.HasSuffix(path, ".go") {
+		return content, false, nil
+	}
+	out, changed, err := shared.RewriteImports(path, content, oldPath, newPath, false)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to rewrite imports in %s: %w", path, err)
+	}
+	return out, changed, nil
+}
+
+// newGoMod builds the go.mod of the extracted module. It inherits the parent's Go version and
+// requirements, so that it builds with the same dependency versions; `go mod tidy` prunes the ones
+// it does not need.
+func newGoMod(parent *modfile.File, path string) ([]byte, error) {
+	f := new(modfile.File)
+	if err := f.AddModuleStmt(path); err != nil {
+		return nil, err
+	}
+	if parent.Go != nil {
+		if err := f.AddGoStmt(parent.Go.Version); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range parent.Require {
+		f.AddNewRequire(r.Mod.Path, r.Mod.Version, r.Indirect)
+	}
+	f.SetRequireSeparateIndirect(f.Require)
+	f.Cleanup()
+	return f.Format()
+}
+
+// removeEmptyDirs deletes dir, its subdirectories and its ancestors below root if no files are left
+// in them.
+func removeEmptyDirs(dir, root string) {
+	var dirs []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i]) // fails, as intended, if the directory is not empty
+	}
+	for parent := filepath.Dir(dir); parent != root && strings.HasPrefix(parent, root); parent = filepath.Dir(parent) {
+		if os.Remove(parent) != nil {
+			return
+		}
+	}
+}
+
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func render(plan *Plan, dryRun bool) string {
+	var sb strings.Builder
+	if dryRun {
+		sb.WriteString("# Module Extraction Plan (dry run)\n\n")
+	} else {
+		sb.WriteString("# Module Extracted\n\n")
+	}
+	fmt.Fprintf(&sb, "- **New module:** `%s`\n", plan.NewPath)
+	fmt.Fprintf(&sb, "- **Location:** `%s`\n", plan.Target)
+	if plan.NewPath != plan.OldPath {
+		fmt.Fprintf(&sb, "- **Import path:** `%s` → `%s`\n", plan.OldPath, plan.NewPath)
+	}
+	sb.WriteString("\n")
+
+	fmt.Fprintf(&sb, "## Files (%d)\n\n", len(plan.Moved))
+	for _, f := range plan.Moved {
+		fmt.Fprintf(&sb, "- %s\n", f)
+	}
+	sb.WriteString("- go.mod (new)\n\n")
+
+	if len(plan.Importers) > 0 {
+		fmt.Fprintf(&sb, "## Parent Module\n\n`go.mod` gains `require %s %s` and `replace %s => %s` for local development.\n\n",
+			plan.NewPath, pseudoVersion, plan.NewPath, plan.Replace)
+		if plan.NewPath != plan.OldPath {
+			sb.WriteString("Rewritten importers:\n\n")
+			for _, f := range plan.Importers {
+				fmt.Fprintf(&sb, "- %s\n", f)
+			}
+			sb.WriteString("\n")
+		}
+	} else {
+		sb.WriteString("No package of the parent module imports the subtree, so its go.mod is unchanged.\n\n")
+	}
+
+	if dryRun {
+		sb.WriteString("Run again without `dry_run` to apply. Both modules are built afterwards and every change is rolled back if either fails.\n")
+		return sb.String()
+	}
+	sb.WriteString("✅ Both modules build.\n\n**Next steps:** run `go mod tidy` in both modules to prune requirements, publish the new module and tag it, then replace the `replace` directive with a real version.\n")
+	return sb.String()
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package extractmod
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+var app = map[string]string{
+	"go.mod": testutil.GoMod("example.com/app"),
+	"main.go": `package main
+
+import "example.com/app/pkg/retry"
+
+func main() { retry.Do(3) }
+`,
+	"pkg/retry/retry.go": `package retry
+
+import "example.com/app/pkg/retry/backoff"
+
+// Do retries n times.
+func Do(n int) int { return backoff.Delay(n) }
+`,
+	"pkg/retry/backoff/backoff.go": `package backoff
+
+// Delay returns the delay for attempt n.
+func Delay(n int) int { return n * n }
+`,
+	"pkg/retry/testdata/golden.txt": "golden\n",
+}
+
+func TestHandler_MoveAndRename(t *testing.T) {
+	dir := testutil.WriteModule(t, app)
+	target := filepath.Join(t.TempDir(), "retry")
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "pkg/retry", ModulePath: "example.com/retry", Target: target})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("extraction failed:\n%s", out)
+	}
+	if !strings.Contains(out, "✅ Both modules build.") {
+		t.Errorf("expected success, got:\n%s", out)
+	}
+
+	if got := read(t, filepath.Join(dir, "main.go")); !strings.Contains(got, `import "example.com/retry"`) {
+		t.Errorf("importer not rewritten:\n%s", got)
+	}
+	if got := read(t, filepath.Join(target, "retry.go")); !strings.Contains(got, `import "example.com/retry/backoff"`) {
+		t.Errorf("self-import not rewritten:\n%s", got)
+	}
+	if got := read(t, filepath.Join(target, "go.mod")); !strings.Contains(got, "module example.com/retry\n\ngo 1.22") {
+		t.Errorf("unexpected go.mod for the new module:\n%s", got)
+	}
+	read(t, filepath.Join(target, "testdata", "golden.txt"))
+
+	gomod := read(t, filepath.Join(dir, "go.mod"))
+	rel, _ := filepath.Rel(dir, target)
+	for _, want := range []string{"require example.com/retry " + pseudoVersion, "replace example.com/retry => " + filepath.ToSlash(rel)} {
+		if !strings.Contains(gomod, want) {
+			t.Errorf("expected parent go.mod to contain %q, got:\n%s", want, gomod)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg")); !os.IsNotExist(err) {
+		t.Errorf("expected the emptied source directories to be removed")
+	}
+}
+
+func TestHandler_InPlace(t *testing.T) {
+	dir := testutil.WriteModule(t, app)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "pkg/retry"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("extraction failed:\n%s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	if got := read(t, filepath.Join(dir, "main.go")); got != app["main.go"] {
+		t.Errorf("importer should be unchanged, got:\n%s", got)
+	}
+	if got := read(t, filepath.Join(dir, "go.mod")); !strings.Contains(got, "replace example.com/app/pkg/retry => ./pkg/retry") {
+		t.Errorf("expected replace directive, got:\n%s", got)
+	}
+	if got := read(t, filepath.Join(dir, "pkg/retry/go.mod")); !strings.Contains(got, "module example.com/app/pkg/retry") {
+		t.Errorf("unexpected nested go.mod:\n%s", got)
+	}
+}
+
+func TestHandler_RejectsCycle(t *testing.T) {
+	files := map[string]string{}
+	for k, v := range app {
+		files[k] = v
+	}
+	files["util/util.go"] = "package util\n\nfunc Max(a, b int) int { return max(a, b) }\n"
+	files["pkg/retry/backoff/backoff.go"] = `package backoff
+
+import "example.com/app/util"
+
+func Delay(n int) int { return util.Max(n, 1) }
+`
+	dir := testutil.WriteModule(t, files)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Package: "pkg/retry", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(out, "pkg/retry/backoff/backoff.go:3:8 imports example.com/app/util") {
+		t.Errorf("expected a module cycle error, got:\n%s", out)
+	}
+}
@@ -0,0 +1,392 @@
+// Package i18n implements the extract_strings tool. It finds user-facing string literals (messages
+// printed with fmt or written to HTTP responses), extracts them into a golang.org/x/text message
+// catalog, and can rewrite the call sites to go through a message.Printer.
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["extract_strings"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir        string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Packages   string `json:"packages,omitempty" jsonschema:"Package pattern to scan (default: ./...)"`
+	Lang       string `json:"lang,omitempty" jsonschema:"BCP 47 tag of the source language (default: en)"`
+	CatalogDir string `json:"catalog_dir,omitempty" jsonschema:"Directory for the catalog, relative to dir (default: locales)"`
+	Apply      bool   `json:"apply,omitempty" jsonschema:"Write the catalog and rewrite call sites to use a message.Printer (requires golang.org/x/text in go.mod)"`
+}
+
+// printerVar is the package-level message.Printer introduced by the codemod.
+const printerVar = "printer"
+
+// Message is one extracted string.
+type Message struct {
+	Text     string
+	Position string
+	Call     string // the call the literal is passed to, e.g. fmt.Printf
+	Pkg      string
+	// edits rewrite the call to use the printer; nil if the site is reported but not rewritten.
+	edits    []shared.TextEdit
+	filename string
+}
+
+// catalog mirrors the gotext JSON format read by golang.org/x/text/cmd/gotext.
+type catalog struct {
+	Language string           `json:"language"`
+	Messages []catalogMessage `json:"messages"`
+}
+
+type catalogMessage struct {
+	ID          string `json:"id"`
+	Message     string `json:"message"`
+	Translation string `json:"translation"`
+}
+
+var (
+	formatVerb = regexp.MustCompile(`%[-+# 0-9.*\[\]]*[a-zA-Z%]`)
+	word       = regexp.MustCompile(`[A-Za-z]{2,}`)
+)
+
+// printfFuncs are the fmt functions that message.Printer provides with catalog lookup.
+var printfFuncs = map[string]bool{"Printf": true, "Sprintf": true, "Fprintf": true}
+
+// printFuncs are the fmt functions whose single literal argument can be moved into a Printf format.
+var printFuncs = map[string]bool{"Print": true, "Sprint": true, "Fprint": true, "Println": true, "Sprintln": true, "Fprintln": true}
+
+// Handler handles the extract_strings tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+	lang := args.Lang
+	if lang == "" {
+		lang = "en"
+	}
+	catalogDir := args.CatalogDir
+	if catalogDir == "" {
+		catalogDir = "locales"
+	}
+	catalogPath := filepath.Join(absDir, catalogDir, lang, "messages.gotext.json")
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	msgs := Extract(absDir, pkgs)
+
+	var sb strings.Builder
+	sb.WriteString("# User-Facing Strings\n\n")
+	if len(msgs) == 0 {
+		sb.WriteString("No user-facing string literals found.\n")
+		return textResult(sb.String()), nil, nil
+	}
+
+	cat, err := buildCatalog(catalogPath, lang, msgs)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	catJSON, err := json.MarshalIndent(cat, "", "    ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to marshal catalog: %v", err)), nil, nil
+	}
+	catJSON = append(catJSON, '\n')
+
+	rewritable := 0
+	for _, m := range msgs {
+		if len(m.edits) > 0 {
+			rewritable++
+		}
+	}
+
+	if args.Apply {
+		cmd := exec.CommandContext(ctx, "go", "list", "-m", "golang.org/x/text")
+		cmd.Dir = absDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errorResult(fmt.Sprintf("golang.org/x/text is not a dependency of this module; add it first (add_dependency with golang.org/x/text@latest):\n%s", strings.TrimSpace(string(out)))), nil, nil
+		}
+		changes, err := Codemod(pkgs, msgs, lang)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		changes[catalogPath] = catJSON
+		if err := changes.Apply(ctx, absDir); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Rewrote %d call site(s) to use `%s` and wrote `%s`.\n\n", rewritable, printerVar, rel(absDir, catalogPath))
+		sb.WriteString("Next: add translations with `go run golang.org/x/text/cmd/gotext update -lang=" + lang + ",<other tags>` and register the generated catalog.\n\n")
+	} else {
+		fmt.Fprintf(&sb, "Found %d message(s); %d call site(s) can be rewritten with `apply=true`.\n\n", len(cat.Messages), rewritable)
+	}
+
+	sb.WriteString("| Location | Call | Message |\n| :--- | :--- | :--- |\n")
+	for _, m := range msgs {
+		note := ""
+		if len(m.edits) == 0 {
+			note = " (report only)"
+		}
+		fmt.Fprintf(&sb, "| %s | `%s`%s | %s |\n", m.Position, m.Call, note, strings.ReplaceAll(strconv.Quote(m.Text), "|", "\\|"))
+	}
+	if !args.Apply {
+		fmt.Fprintf(&sb, "\n## Catalog (`%s`)\n\n```json\n%s```\n", rel(absDir, catalogPath), catJSON)
+	}
+	return textResult(sb.String()), nil, nil
+}
+
+// Extract finds string literals passed to fmt print functions or written to HTTP responses.
+func Extract(root string, pkgs []*packages.Package) []Message {
+	var msgs []Message
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+		for _, file := range pkg.Syntax {
+			tokFile := pkg.Fset.File(file.Pos())
+			offset := func(n ast.Node) (int, int) { return tokFile.Offset(n.Pos()), tokFile.Offset(n.End()) }
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				fn := typeutil.StaticCallee(info, call)
+				if fn == nil || fn.Pkg() == nil {
+					return true
+				}
+				add := func(lit ast.Expr, text string, edits []shared.TextEdit) {
+					msgs = append(msgs, Message{
+						Text:     text,
+						Position: shared.RelPosition(root, pkg.Fset.Position(lit.Pos())),
+						Call:     fn.Pkg().Name() + "." + fn.Name(),
+						Pkg:      pkg.PkgPath,
+						edits:    edits,
+						filename: tokFile.Name(),
+					})
+				}
+				path, name := fn.Pkg().Path(), fn.Name()
+				writerArg := 0
+				if strings.HasPrefix(name, "F") {
+					writerArg = 1
+				}
+				switch {
+				case path == "fmt" && printfFuncs[name] && len(call.Args) > writerArg:
+					if text, ok := literal(info, call.Args[writerArg]); ok && userFacing(text) {
+						start, end := offset(call.Fun)
+						add(call.Args[writerArg], text, []shared.TextEdit{{Start: start, End: end, New: printerVar + "." + name}})
+					}
+				case path == "fmt" && printFuncs[name] && len(call.Args) == writerArg+1:
+					text, ok := literal(info, call.Args[writerArg])
+					if !ok || !userFacing(text) {
+						return true
+					}
+					var edits []shared.TextEdit
+					if !strings.Contains(text, "%") {
+						format := text
+						if strings.HasSuffix(name, "ln") {
+							format += "\n"
+						}
+						newName := strings.TrimSuffix(name, "ln") + "f"
+						fs, fe := offset(call.Fun)
+						as, ae := offset(call.Args[writerArg])
+						edits = []shared.TextEdit{{Start: fs, End: fe, New: printerVar + "." + newName}, {Start: as, End: ae, New: strconv.Quote(format)}}
+						text = format
+					}
+					add(call.Args[writerArg], text, edits)
+				case path == "net/http" && name == "Error" && len(call.Args) == 3:
+					text, ok := literal(info, call.Args[1])
+					if !ok || !userFacing(text) {
+						return true
+					}
+					var edits []shared.TextEdit
+					if !strings.Contains(text, "%") {
+						as, ae := offset(call.Args[1])
+						edits = []shared.TextEdit{{Start: as, End: ae, New: printerVar + ".Sprintf(" + strconv.Quote(text) + ")"}}
+					}
+					add(call.Args[1], text, edits)
+				case path == "io" && name == "WriteString" && len(call.Args) == 2 && isResponseWriter(info, call.Args[0]):
+					if text, ok := literal(info, call.Args[1]); ok && userFacing(text) {
+						add(call.Args[1], text, nil)
+					}
+				}
+				return true
+			})
+		}
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return shared.ComparePositions(msgs[i].Position, msgs[j].Position) < 0 })
+	return msgs
+}
+
+// Codemod rewrites the call sites of msgs to use a package-level message.Printer, adding an
+// i18n.go file that declares it to every package that needs one.
+func Codemod(pkgs []*packages.Package, msgs []Message, lang string) (shared.Changeset, error) {
+	byFile := make(map[string][]shared.TextEdit)
+	needs := make(map[string]bool)
+	for _, m := range msgs {
+		if len(m.edits) == 0 {
+			continue
+		}
+		byFile[m.filename] = append(byFile[m.filename], m.edits...)
+		needs[m.Pkg] = true
+	}
+	if len(byFile) == 0 {
+		return nil, fmt.Errorf("no call sites can be rewritten automatically")
+	}
+
+	changes := make(shared.Changeset)
+	for filename, edits := range byFile {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		out, err := shared.ApplyEdits(src, edits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		changes[filename] = out
+	}
+
+	for _, pkg := range pkgs {
+		if !needs[pkg.PkgPath] {
+			continue
+		}
+		if obj := pkg.Types.Scope().Lookup(printerVar); obj != nil {
+			return nil, fmt.Errorf("package %s already declares %q; refusing to introduce a conflicting printer", pkg.PkgPath, printerVar)
+		}
+		path := filepath.Join(filepath.Dir(pkg.GoFiles[0]), "i18n.go")
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+		changes[path] = []byte(fmt.Sprintf(printerSource, pkg.Name, lang))
+	}
+	return changes, nil
+}
+
+const printerSource = `package %s
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// printer formats user-facing messages, translating them through the registered message catalog.
+var printer = message.NewPrinter(language.MustParse(%q))
+`
+
+// buildCatalog merges the extracted messages into the catalog at path, keeping any existing
+// translations.
+func buildCatalog(path, lang string, msgs []Message) (*catalog, error) {
+	cat := &catalog{Language: lang}
+	//nolint:gosec // G304: Path is derived from the validated workspace directory.
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, cat); err != nil {
+			return nil, fmt.Errorf("failed to parse existing catalog %s: %w", path, err)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, m := range cat.Messages {
+		seen[m.ID] = true
+	}
+	for _, m := range msgs {
+		if seen[m.Text] {
+			continue
+		}
+		seen[m.Text] = true
+		cat.Messages = append(cat.Messages, catalogMessage{ID: m.Text, Message: m.Text, Translation: m.Text})
+	}
+	return cat, nil
+}
+
+func literal(info *types.Info, expr ast.Expr) (string, bool) {
+	if _, ok := ast.Unparen(expr).(*ast.BasicLit); !ok {
+		return "", false
+	}
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// userFacing reports whether s reads like prose rather than a format-only string, key or path:
+// once format verbs are removed it must contain a word, and either a space or a leading capital.
+func userFacing(s string) bool {
+	text := strings.TrimSpace(formatVerb.ReplaceAllString(s, ""))
+	if !word.MatchString(text) || strings.Contains(text, "://") {
+		return false
+	}
+	if strings.Contains(text, " ") {
+		return true
+	}
+	return text[0] >= 'A' && text[0] <= 'Z' && !strings.ContainsAny(text, "/._")
+}
+
+func isResponseWriter(info *types.Info, expr ast.Expr) bool {
+	tv, ok := info.Types[expr]
+	return ok && types.TypeString(tv.Type, nil) == "net/http.ResponseWriter"
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const greetSrc = `package greet
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+func Hello(name string) string {
+	return fmt.Sprintf("Hello, %s!", name)
+}
+
+func Banner() {
+	fmt.Println("Welcome to the app")
+	fmt.Printf("%d\n", 42)
+	fmt.Println("config.yaml")
+}
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "Page not found", http.StatusNotFound)
+	io.WriteString(w, "All good here")
+}
+`
+
+func setup(t *testing.T, withText bool) string {
+	t.Helper()
+	files := map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"greet/greet.go": greetSrc,
+	}
+	if withText {
+		files["go.mod"] = "module example.com/app\n\ngo 1.25.0\n\nrequire golang.org/x/text v0.36.0\n"
+		files["go.sum"] = "golang.org/x/text v0.36.0 h1:JfKh3XmcRPqZPKevfXVpI1wXPTqbkE5f7JA92a55Yxg=\n" +
+			"golang.org/x/text v0.36.0/go.mod h1:NIdBknypM8iqVmPiuco0Dh6P5Jcdk8lJL0CUebqK164=\n"
+	}
+	return testutil.WriteModule(t, files)
+}
+
+func TestHandler_Preview(t *testing.T) {
+	dir := setup(t, false)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"Found 4 message(s); 3 call site(s) can be rewritten",
+		"| greet/greet.go:10:21 | `fmt.Sprintf` | \"Hello, %s!\" |",
+		"| greet/greet.go:14:14 | `fmt.Println` | \"Welcome to the app\\n\" |",
+		"| greet/greet.go:20:16 | `http.Error` | \"Page not found\" |",
+		"`io.WriteString` (report only)",
+		`"id": "Hello, %s!"`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"config.yaml", `"%d\n"`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected %q not to be extracted, got:\n%s", unwanted, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "locales")); !os.IsNotExist(err) {
+		t.Errorf("preview must not write the catalog")
+	}
+}
+
+func TestHandler_ApplyRequiresText(t *testing.T) {
+	dir := setup(t, false)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Apply: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "golang.org/x/text is not a dependency") {
+		t.Errorf("expected a dependency error, got: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t, true)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Apply: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "greet", "greet.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wants := []string{
+		`printer.Sprintf("Hello, %s!", name)`,
+		`printer.Printf("Welcome to the app\n")`,
+		`http.Error(w, printer.Sprintf("Page not found"), http.StatusNotFound)`,
+		`fmt.Println("config.yaml")`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected rewritten code to contain %q, got:\n%s", want, src)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "locales", "en", "messages.gotext.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cat catalog
+	if err := json.Unmarshal(data, &cat); err != nil {
+		t.Fatal(err)
+	}
+	if cat.Language != "en" || len(cat.Messages) != 4 {
+		t.Errorf("unexpected catalog: %+v", cat)
+	}
+}
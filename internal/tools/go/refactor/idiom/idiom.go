@@ -0,0 +1,655 @@
+// Package idiom implements the rewrite_idioms tool. It detects non-idiomatic constructs that have
+// a direct mechanical fix, complementing modernize (which updates code to newer language and
+// library features), and can apply the fixes as one verified changeset.
+package idiom
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["rewrite_idioms"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir      string   `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Packages string   `json:"packages,omitempty" jsonschema:"Package pattern to scan (default: ./...)"`
+	Rules    []string `json:"rules,omitempty" jsonschema:"Rules to run: error-tail, empty-string, else-after-return (default: all)"`
+	Apply    bool     `json:"apply,omitempty" jsonschema:"Rewrite the files, verified by a build and rolled back on failure"`
+}
+
+// Rule identifiers.
+const (
+	RuleErrorTail       = "error-tail"
+	RuleEmptyString     = "empty-string"
+	RuleElseAfterReturn = "else-after-return"
+)
+
+var allRules = []string{RuleErrorTail, RuleEmptyString, RuleElseAfterReturn}
+
+// maxDiffLines caps the preview diff.
+const maxDiffLines = 400
+
+// Finding is one rewritable construct.
+type Finding struct {
+	Rule     string
+	Position string
+	Before   string
+	After    string
+
+	filename string
+	edits    []shared.TextEdit
+}
+
+// Handler handles the rewrite_idioms tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	rules := args.Rules
+	if len(rules) == 0 {
+		rules = allRules
+	}
+	for _, r := range rules {
+		if !slices.Contains(allRules, r) {
+			return errorResult(fmt.Sprintf("unknown rule %q; available: %s", r, strings.Join(allRules, ", "))), nil, nil
+		}
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, args.Packages, true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	findings := Detect(absDir, pkgs, rules)
+
+	var sb strings.Builder
+	sb.WriteString("# Idiom Rewrites\n\n")
+	if len(findings) == 0 {
+		sb.WriteString("No non-idiomatic patterns found.\n")
+		return textResult(sb.String()), nil, nil
+	}
+	changes, deferred, err := Rewrite(findings)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var next []shared.NextTool
+	if args.Apply {
+		if err := changes.Apply(ctx, absDir); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Applied %d rewrite(s) in %d file(s); the module still builds.\n\n", len(findings)-deferred, len(changes))
+	} else {
+		fmt.Fprintf(&sb, "Found %d rewrite(s) in %d file(s). Call again with `apply=true` to write them.\n\n", len(findings), len(changes))
+		next = append(next, shared.NextTool{
+			Tool:      "rewrite_idioms",
+			Arguments: map[string]any{"dir": absDir, "packages": args.Packages, "rules": rules, "apply": true},
+			Reason:    "write the previewed rewrites, verified by a build",
+		})
+	}
+	if deferred > 0 {
+		fmt.Fprintf(&sb, "%d rewrite(s) overlap another one and were left for a second run.\n\n", deferred)
+		if args.Apply {
+			next = append(next, shared.NextTool{
+				Tool:      "rewrite_idioms",
+				Arguments: map[string]any{"dir": absDir, "packages": args.Packages, "rules": rules, "apply": true},
+				Reason:    "apply the rewrites left for a second run",
+			})
+		}
+	}
+
+	sb.WriteString("| Location | Rule | Before | After |\n| :--- | :--- | :--- | :--- |\n")
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "| %s | %s | `%s` | `%s` |\n", f.Position, f.Rule, cell(f.Before), cell(f.After))
+	}
+	if !args.Apply {
+		sb.WriteString("\n## Diff\n\n```diff\n")
+		var lines []string
+		for _, path := range changes.Files() {
+			//nolint:gosec // G304: Path comes from the loaded package.
+			old, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, "--- "+rel(absDir, path), "+++ "+rel(absDir, path))
+			diff := textdiff.Unified(string(old), string(changes[path]))
+			lines = append(lines, strings.Split(strings.TrimSuffix(diff, "\n"), "\n")...)
+		}
+		if len(lines) > maxDiffLines {
+			lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxDiffLines))
+		}
+		sb.WriteString(strings.Join(lines, "\n") + "\n```\n")
+	}
+	return shared.WithNextTools(textResult(sb.String()), next...), nil, nil
+}
+
+// Detect returns the findings of the given rules in pkgs, sorted by position. Each file is
+// scanned once even when it belongs to several package variants.
+func Detect(root string, pkgs []*packages.Package, rules []string) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	// Packages mixing both empty-string checks are rewritten to the form they use most.
+	lenForm := make(map[string][]Finding)
+	cmpForm := make(map[string][]Finding)
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			tokFile := pkg.Fset.File(file.Pos())
+			if tokFile == nil || seen[tokFile.Name()] || ast.IsGenerated(file) {
+				continue
+			}
+			seen[tokFile.Name()] = true
+			//nolint:gosec // G304: File path comes from the loaded package.
+			src, err := os.ReadFile(tokFile.Name())
+			if err != nil {
+				continue
+			}
+			d := &detector{
+				root:    root,
+				fset:    pkg.Fset,
+				info:    pkg.TypesInfo,
+				file:    file,
+				tokFile: tokFile,
+				src:     src,
+				uses:    make(map[types.Object]int),
+			}
+			for _, obj := range d.info.Uses {
+				d.uses[obj]++
+			}
+			if slices.Contains(rules, RuleErrorTail) {
+				findings = append(findings, d.errorTails()...)
+			}
+			if slices.Contains(rules, RuleElseAfterReturn) {
+				findings = append(findings, d.elseAfterReturn()...)
+			}
+			if slices.Contains(rules, RuleEmptyString) {
+				l, c := d.emptyStrings()
+				lenForm[pkg.PkgPath] = append(lenForm[pkg.PkgPath], l...)
+				cmpForm[pkg.PkgPath] = append(cmpForm[pkg.PkgPath], c...)
+			}
+		}
+	}
+	for path, l := range lenForm {
+		switch c := cmpForm[path]; {
+		case len(c) == 0:
+		case len(l) <= len(c):
+			findings = append(findings, l...)
+		default:
+			findings = append(findings, c...)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].filename != findings[j].filename {
+			return findings[i].filename < findings[j].filename
+		}
+		return findings[i].edits[0].Start < findings[j].edits[0].Start
+	})
+	return findings
+}
+
+// Rewrite applies the findings to their files. Findings whose edits overlap an earlier one are
+// skipped and counted in deferred; running the rewrite again picks them up.
+func Rewrite(findings []Finding) (changes shared.Changeset, deferred int, err error) {
+	byFile := make(map[string][]shared.TextEdit)
+	for _, f := range findings {
+		edits := byFile[f.filename]
+		if overlaps(edits, f.edits) {
+			deferred++
+			continue
+		}
+		byFile[f.filename] = append(edits, f.edits...)
+	}
+	changes = make(shared.Changeset)
+	for filename, edits := range byFile {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		out, err := shared.ApplyEdits(src, edits)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		// Outdented else blocks are re-indented by gofmt.
+		if formatted, err := format.Source(out); err == nil {
+			out = formatted
+		}
+		changes[filename] = out
+	}
+	return changes, deferred, nil
+}
+
+func overlaps(kept, edits []shared.TextEdit) bool {
+	for _, a := range kept {
+		for _, b := range edits {
+			if a.Start < b.End && b.Start < a.End {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type detector struct {
+	root    string
+	fset    *token.FileSet
+	info    *types.Info
+	file    *ast.File
+	tokFile *token.File
+	src     []byte
+	uses    map[types.Object]int
+}
+
+func (d *detector) finding(rule string, pos token.Pos, before, after string, edits ...shared.TextEdit) Finding {
+	return Finding{
+		Rule:     rule,
+		Position: shared.RelPosition(d.root, d.fset.Position(pos)),
+		Before:   before,
+		After:    after,
+		filename: d.tokFile.Name(),
+		edits:    edits,
+	}
+}
+
+func (d *detector) offset(pos token.Pos) int { return d.tokFile.Offset(pos) }
+
+func (d *detector) text(n ast.Node) string {
+	return string(d.src[d.offset(n.Pos()):d.offset(n.End())])
+}
+
+// hasComment reports whether a comment lies within [from, to), which a rewrite would drop.
+func (d *detector) hasComment(from, to token.Pos) bool {
+	for _, cg := range d.file.Comments {
+		if cg.Pos() < to && cg.End() > from {
+			return true
+		}
+	}
+	return false
+}
+
+// errorTails finds "if err != nil { return err }; return nil" at the end of a statement list in a
+// function returning only an error, and collapses it into "return err". When err is declared by
+// the if statement or the statement just before it and used nowhere else, the call that produced
+// it is returned directly.
+func (d *detector) errorTails() []Finding {
+	var findings []Finding
+	d.funcs(func(sig *types.Signature, body *ast.BlockStmt) {
+		if sig.Results().Len() != 1 || !isError(sig.Results().At(0).Type()) {
+			return
+		}
+		ast.Inspect(body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			list := stmtList(n)
+			for i := 0; i+1 < len(list); i++ {
+				if f, ok := d.errorTail(list, i); ok {
+					findings = append(findings, f)
+				}
+			}
+			return true
+		})
+	})
+	return findings
+}
+
+func (d *detector) errorTail(list []ast.Stmt, i int) (Finding, bool) {
+	ifStmt, ok := list[i].(*ast.IfStmt)
+	if !ok || ifStmt.Else != nil || len(ifStmt.Body.List) != 1 {
+		return Finding{}, false
+	}
+	ret, ok := list[i+1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 || !d.isNil(ret.Results[0]) {
+		return Finding{}, false
+	}
+	cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ || !d.isNil(cond.Y) {
+		return Finding{}, false
+	}
+	errIdent, ok := cond.X.(*ast.Ident)
+	if !ok {
+		return Finding{}, false
+	}
+	obj := d.info.Uses[errIdent]
+	if obj == nil || !isError(obj.Type()) {
+		return Finding{}, false
+	}
+	inner, ok := ifStmt.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(inner.Results) != 1 {
+		return Finding{}, false
+	}
+	if id, ok := inner.Results[0].(*ast.Ident); !ok || d.info.Uses[id] != obj {
+		return Finding{}, false
+	}
+
+	start := ifStmt.Pos()
+	result := errIdent.Name
+	switch {
+	case ifStmt.Init != nil:
+		call, ok := d.definingCall(ifStmt.Init, obj)
+		if !ok {
+			return Finding{}, false
+		}
+		result = d.text(call)
+	case i > 0:
+		if call, ok := d.definingCall(list[i-1], obj); ok {
+			start = list[i-1].Pos()
+			result = d.text(call)
+		}
+	}
+	if d.hasComment(start, ret.End()) {
+		return Finding{}, false
+	}
+	before := strings.Join(strings.Fields(string(d.src[d.offset(start):d.offset(ret.End())])), " ")
+	after := "return " + result
+	return d.finding(RuleErrorTail, start, before, after, shared.TextEdit{Start: d.offset(start), End: d.offset(ret.End()), New: after}), true
+}
+
+// definingCall returns the value of "err := value" when stmt declares obj that way and obj is used
+// only by the error tail (the condition and the return).
+func (d *detector) definingCall(stmt ast.Stmt, obj types.Object) (ast.Expr, bool) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil, false
+	}
+	id, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || d.info.Defs[id] != obj || d.uses[obj] != 2 {
+		return nil, false
+	}
+	tv, ok := d.info.Types[assign.Rhs[0]]
+	if !ok || !isError(tv.Type) {
+		return nil, false
+	}
+	return assign.Rhs[0], true
+}
+
+// elseAfterReturn finds "if c { ...; return } else { ... }" and outdents the else branch. If
+// statements with an init clause are skipped, as are else blocks whose declarations could clash
+// with or shadow names of the enclosing block.
+func (d *detector) elseAfterReturn() []Finding {
+	var findings []Finding
+	ast.Inspect(d.file, func(n ast.Node) bool {
+		list := stmtList(n)
+		for i, stmt := range list {
+			if ifStmt, ok := stmt.(*ast.IfStmt); ok {
+				findings = d.elseChain(findings, ifStmt, i == len(list)-1)
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// elseChain adds the finding for ifStmt, which is last in its statement list if last, and
+// continues down an else-if chain, whose ifs become statements of the same list once outdented.
+func (d *detector) elseChain(findings []Finding, ifStmt *ast.IfStmt, last bool) []Finding {
+	if ifStmt.Else == nil || ifStmt.Init != nil || !d.terminates(ifStmt.Body) {
+		return findings
+	}
+	bodyEnd := d.offset(ifStmt.Body.Rbrace) + 1
+	switch els := ifStmt.Else.(type) {
+	case *ast.IfStmt:
+		if d.hasComment(ifStmt.Body.Rbrace+1, els.Pos()) {
+			return findings
+		}
+		findings = append(findings, d.finding(RuleElseAfterReturn, ifStmt.Body.Rbrace, "} else if", "}\nif",
+			shared.TextEdit{Start: bodyEnd, End: d.offset(els.Pos()), New: "\n"}))
+		return d.elseChain(findings, els, last)
+	case *ast.BlockStmt:
+		if d.hasComment(ifStmt.Body.Rbrace+1, els.Lbrace) || d.redeclares(els, last) {
+			return findings
+		}
+		// Drop "else {" and the closing brace with the indentation and line break before it.
+		closing := d.offset(els.Rbrace)
+		for closing > 0 && (d.src[closing-1] == ' ' || d.src[closing-1] == '\t') {
+			closing--
+		}
+		if closing > d.offset(els.Lbrace)+1 && d.src[closing-1] == '\n' {
+			closing--
+		}
+		findings = append(findings, d.finding(RuleElseAfterReturn, ifStmt.Body.Rbrace, "} else { ... }", "} ...",
+			shared.TextEdit{Start: bodyEnd, End: d.offset(els.Lbrace) + 1, New: ""},
+			shared.TextEdit{Start: closing, End: d.offset(els.Rbrace) + 1, New: ""}))
+	}
+	return findings
+}
+
+// terminates reports whether the block ends in a return, branch or panic.
+func (d *detector) terminates(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+		if !ok {
+			return false
+		}
+		b, ok := d.info.Uses[id].(*types.Builtin)
+		return ok && b.Name() == "panic"
+	}
+	return false
+}
+
+// redeclares reports whether moving the declarations of the else block into the enclosing scope
+// could change the program: they clash with a name of that scope, or shadow an outer name for
+// the statements after the if.
+func (d *detector) redeclares(els *ast.BlockStmt, last bool) bool {
+	scope := d.info.Scopes[els]
+	if scope == nil || scope.Parent() == nil || scope.Parent().Parent() == nil {
+		return true
+	}
+	// The parent of the else block is the if statement's implicit scope.
+	enclosing := scope.Parent().Parent()
+	for _, stmt := range els.List {
+		var idents []*ast.Ident
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			if s.Tok == token.DEFINE {
+				for _, lhs := range s.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						idents = append(idents, id)
+					}
+				}
+			}
+		case *ast.DeclStmt:
+			ast.Inspect(s, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && d.info.Defs[id] != nil {
+					idents = append(idents, id)
+				}
+				return true
+			})
+		}
+		for _, id := range idents {
+			if id.Name != "_" && (!last || enclosing.Lookup(id.Name) != nil) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// emptyStrings returns the rewrites of string emptiness checks in both directions: those written
+// with len (to s == "") and those comparing with "" (to len(s) == 0). The caller keeps the ones
+// that make the package consistent.
+func (d *detector) emptyStrings() (lenForm, cmpForm []Finding) {
+	ast.Inspect(d.file, func(n ast.Node) bool {
+		bin, ok := n.(*ast.BinaryExpr)
+		if !ok {
+			return true
+		}
+		edit := func(after string) shared.TextEdit {
+			return shared.TextEdit{Start: d.offset(bin.Pos()), End: d.offset(bin.End()), New: after}
+		}
+		if arg, ok := d.lenOfString(bin.X); ok && d.isZero(bin.Y) {
+			var after string
+			switch bin.Op {
+			case token.EQL:
+				after = d.text(arg) + ` == ""`
+			case token.NEQ, token.GTR:
+				after = d.text(arg) + ` != ""`
+			default:
+				return true
+			}
+			lenForm = append(lenForm, d.finding(RuleEmptyString, bin.Pos(), d.text(bin), after, edit(after)))
+			return true
+		}
+		if (bin.Op == token.EQL || bin.Op == token.NEQ) && d.isString(bin.X) && isEmptyLit(bin.Y) {
+			after := "len(" + d.text(bin.X) + ") " + bin.Op.String() + " 0"
+			cmpForm = append(cmpForm, d.finding(RuleEmptyString, bin.Pos(), d.text(bin), after, edit(after)))
+		}
+		return true
+	})
+	return lenForm, cmpForm
+}
+
+func (d *detector) lenOfString(expr ast.Expr) (ast.Expr, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, false
+	}
+	id, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	if b, ok := d.info.Uses[id].(*types.Builtin); !ok || b.Name() != "len" {
+		return nil, false
+	}
+	return call.Args[0], d.isString(call.Args[0])
+}
+
+// isString reports whether expr is a non-constant string value.
+func (d *detector) isString(expr ast.Expr) bool {
+	tv, ok := d.info.Types[expr]
+	if !ok || tv.Value != nil || !tv.IsValue() {
+		return false
+	}
+	basic, ok := tv.Type.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+func (d *detector) isZero(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "0"
+}
+
+func (d *detector) isNil(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = d.info.Uses[id].(*types.Nil)
+	return ok
+}
+
+func isEmptyLit(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.STRING && (lit.Value == `""` || lit.Value == "``")
+}
+
+// funcs calls fn for every function declaration and literal with a body.
+func (d *detector) funcs(fn func(*types.Signature, *ast.BlockStmt)) {
+	ast.Inspect(d.file, func(n ast.Node) bool {
+		switch f := n.(type) {
+		case *ast.FuncDecl:
+			if obj, ok := d.info.Defs[f.Name].(*types.Func); ok && f.Body != nil {
+				fn(obj.Type().(*types.Signature), f.Body)
+			}
+		case *ast.FuncLit:
+			if sig, ok := d.info.Types[f].Type.(*types.Signature); ok {
+				fn(sig, f.Body)
+			}
+		}
+		return true
+	})
+}
+
+func stmtList(n ast.Node) []ast.Stmt {
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		return n.List
+	case *ast.CaseClause:
+		return n.Body
+	case *ast.CommClause:
+		return n.Body
+	}
+	return nil
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "`", "'")
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+package idiom
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const storeSrc = `package store
+
+import (
+	"errors"
+	"os"
+)
+
+type myErr struct{}
+
+func (*myErr) Error() string { return "boom" }
+
+func Save(path string) error {
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Remove(path string) error {
+	err := os.Remove(path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func Check(path string) error {
+	_, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func Typed() error {
+	var err *myErr
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func Kind(name string) string {
+	if name == "" {
+		return "empty"
+	} else if name == "x" {
+		return "x"
+	} else {
+		v := "other"
+		return v
+	}
+}
+
+func Loop(names []string) int {
+	n := 0
+	for _, v := range names {
+		if len(v) == 0 {
+			continue
+		} else {
+			n := len(v)
+			_ = n
+		}
+		n++
+	}
+	return n
+}
+
+func Named(s string) bool {
+	if s == "" {
+		return false
+	}
+	return errors.New(s) != nil
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"store/store.go": storeSrc,
+	})
+}
+
+func TestHandler_Preview(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	wants := []string{
+		"Found 6 rewrite(s) in 1 file(s)",
+		"| store/store.go:13:2 | error-tail | `if err := os.WriteFile(path, nil, 0644); err != nil { return err } return nil` | `return os.WriteFile(path, nil, 0644)` |",
+		"| store/store.go:20:2 | error-tail |",
+		"`return os.Remove(path)`",
+		"| store/store.go:29:2 | error-tail |",
+		"| store/store.go:46:2 | else-after-return | `} else if` |",
+		"| store/store.go:48:2 | else-after-return | `} else { ... }` |",
+		"| store/store.go:57:6 | empty-string | `len(v) == 0` | `v == \"\"` |",
+		"+\treturn err",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	// A typed nil pointer must not be collapsed, and an else block declaring a name used after
+	// the if must not be outdented.
+	for _, unwanted := range []string{"store.go:37:", "store.go:59:"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected finding at %s:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Apply: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "store", "store.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	for _, want := range []string{
+		"func Save(path string) error {\n\treturn os.WriteFile(path, nil, 0644)\n}",
+		"func Check(path string) error {\n\t_, err := os.Stat(path)\n\treturn err\n}",
+		"\t\treturn \"empty\"\n\t}\n\tif name == \"x\" {\n\t\treturn \"x\"\n\t}\n\tv := \"other\"\n\treturn v\n}",
+		"if v == \"\" {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected rewritten file to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	// A second run finds nothing left to do.
+	res, _, _ = Handler(context.Background(), nil, Params{Dir: dir})
+	if out := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(out, "No non-idiomatic patterns found") {
+		t.Errorf("expected no findings after apply, got:\n%s", out)
+	}
+}
+
+func TestHandler_UnknownRule(t *testing.T) {
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: t.TempDir(), Rules: []string{"nope"}})
+	if !res.IsError {
+		t.Error("expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,274 @@
+// Package importpath implements the rewrite_import_path tool, which renames a module path or import
+// prefix across a repository: go.mod files, Go imports and comments, and documentation.
+package importpath
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/module"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["rewrite_import_path"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir    string `json:"dir,omitempty" jsonschema:"The absolute repository root. Always pass absolute paths in multi-root workspaces."`
+	From   string `json:"from" jsonschema:"The module path or import prefix to replace (e.g. github.com/old-org/project)"`
+	To     string `json:"to" jsonschema:"The new module path or import prefix (e.g. github.com/new-org/project)"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"If true, return a diff of the changes without writing any files"`
+}
+
+// maxDiffLines caps the diff returned by a dry run.
+const maxDiffLines = 400
+
+var (
+	skippedDirs = map[string]bool{".git": true, "vendor": true, "node_modules": true}
+	docExts     = map[string]bool{
+		".md": true, ".markdown": true, ".txt": true, ".rst": true, ".adoc": true,
+		".yml": true, ".yaml": true, ".toml": true, ".json": true, ".sh": true, ".tmpl": true,
+	}
+	docNames = map[string]bool{"Makefile": true, "Dockerfile": true, "Containerfile": true, "Taskfile": true}
+)
+
+// Rewrite is the set of changes for one rename.
+type Rewrite struct {
+	Changes   shared.Changeset
+	Originals map[string][]byte
+	Modules   []string // directories containing a go.mod
+	GoMods    int
+	GoFiles   int
+	Docs      int
+	Literals  []string // string literals that still mention the old path; not rewritten
+}
+
+// Handler handles the rewrite_import_path tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.From == "" || args.To == "" {
+		return errorResult("from and to cannot be empty"), nil, nil
+	}
+	if args.From == args.To {
+		return errorResult("from and to are the same"), nil, nil
+	}
+	if err := module.CheckImportPath(args.To); err != nil {
+		return errorResult(fmt.Sprintf("invalid import path %q: %v", args.To, err)), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	rw, err := Prepare(absDir, args.From, args.To)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(rw.Changes) == 0 {
+		return textResult(fmt.Sprintf("No mentions of `%s` found in %s.", args.From, absDir)), nil, nil
+	}
+	if args.DryRun {
+		return textResult(render(rw, absDir, args, true)), nil, nil
+	}
+
+	var verify [][]string
+	for _, m := range rw.Modules {
+		verify = append(verify, []string{"-C", m, "build", "./..."})
+	}
+	if err := rw.Changes.ApplyVerified(ctx, absDir, verify...); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return textResult(render(rw, absDir, args, false)), nil, nil
+}
+
+// Prepare walks root and computes the rewrite of from to to in every go.mod, Go file and
+// documentation file.
+func Prepare(root, from, to string) (*Rewrite, error) {
+	rw := &Rewrite{Changes: make(shared.Changeset), Originals: make(map[string][]byte)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := d.Name()
+		isGoMod := name == "go.mod"
+		isGo := strings.HasSuffix(name, ".go")
+		isDoc := docExts[strings.ToLower(filepath.Ext(name))] || docNames[name] || strings.HasPrefix(name, "Dockerfile")
+		if isGoMod && !strings.Contains(filepath.ToSlash(path), "/testdata/") {
+			rw.Modules = append(rw.Modules, filepath.Dir(path))
+		}
+		if !isGoMod && !isGo && !isDoc {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var out []byte
+		switch {
+		case isGo:
+			var changed bool
+			out, changed, err = shared.RewriteImports(path, content, from, to, true)
+			if err != nil {
+				// Files that do not parse (e.g. templates in testdata) are left alone.
+				return nil
+			}
+			rw.Literals = append(rw.Literals, literalMentions(root, path, out, from)...)
+			if !changed {
+				return nil
+			}
+			rw.GoFiles++
+		default:
+			text, n := shared.ReplacePathPrefix(string(content), from, to)
+			if n == 0 {
+				return nil
+			}
+			out = []byte(text)
+			if isGoMod {
+				rw.GoMods++
+			} else {
+				rw.Docs++
+			}
+		}
+		rw.Changes[path] = out
+		rw.Originals[path] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	sort.Strings(rw.Literals)
+	return rw, nil
+}
+
+// literalMentions finds string literals that mention from. They may be import paths built at run
+// time or user-facing text, so they are reported for review rather than rewritten.
+func literalMentions(root, path string, src []byte, from string) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if _, ok := n.(*ast.ImportSpec); ok {
+			return false
+		}
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			if _, count := shared.ReplacePathPrefix(s, from, ""); count > 0 {
+				out = append(out, fmt.Sprintf("%s: %s", shared.RelPosition(root, fset.Position(lit.Pos())), lit.Value))
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// lineDiff renders the changed lines of a file. Rewrites never add or remove lines, so lines are
+// compared pairwise.
+func lineDiff(rel string, old, cur []byte) []string {
+	oldLines := strings.Split(string(old), "\n")
+	newLines := strings.Split(string(cur), "\n")
+	out := []string{"--- a/" + rel, "+++ b/" + rel}
+	for i := 0; i < len(oldLines) && i < len(newLines); i++ {
+		if oldLines[i] != newLines[i] {
+			out = append(out, fmt.Sprintf("@@ -%d +%d @@", i+1, i+1), "-"+oldLines[i], "+"+newLines[i])
+		}
+	}
+	return out
+}
+
+func render(rw *Rewrite, root string, args Params, dryRun bool) string {
+	var sb strings.Builder
+	if dryRun {
+		fmt.Fprintf(&sb, "# Import Path Rewrite (dry run): `%s` → `%s`\n\n", args.From, args.To)
+	} else {
+		fmt.Fprintf(&sb, "# Import Path Rewritten: `%s` → `%s`\n\n", args.From, args.To)
+	}
+	fmt.Fprintf(&sb, "- go.mod files: %d\n- Go files: %d\n- Documentation and config files: %d\n\n", rw.GoMods, rw.GoFiles, rw.Docs)
+
+	if dryRun {
+		var lines []string
+		for _, path := range rw.Changes.Files() {
+			rel, _ := filepath.Rel(root, path)
+			lines = append(lines, lineDiff(filepath.ToSlash(rel), rw.Originals[path], rw.Changes[path])...)
+		}
+		sb.WriteString("```diff\n")
+		for i, l := range lines {
+			if i == maxDiffLines {
+				fmt.Fprintf(&sb, "... %d more line(s)\n", len(lines)-maxDiffLines)
+				break
+			}
+			sb.WriteString(l + "\n")
+		}
+		sb.WriteString("```\n\n")
+	} else {
+		fmt.Fprintf(&sb, "✅ Applied and verified: %d module(s) build.\n\n", len(rw.Modules))
+	}
+
+	if len(rw.Literals) > 0 {
+		fmt.Fprintf(&sb, "## ⚠️ String Literals Not Rewritten (%d)\n\nReview these by hand; they may be import paths built at run time or user-facing text.\n\n", len(rw.Literals))
+		for _, l := range rw.Literals {
+			fmt.Fprintf(&sb, "- %s\n", l)
+		}
+		sb.WriteString("\n")
+	}
+	if dryRun {
+		sb.WriteString("Run again without `dry_run` to apply. Every module in the repository is built afterwards and all changes are rolled back if any build fails.\n")
+	}
+	return sb.String()
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
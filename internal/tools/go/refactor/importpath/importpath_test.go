@@ -0,0 +1,90 @@
+package importpath
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	files := map[string]string{
+		"go.mod": testutil.GoMod("github.com/old/app"),
+		"main.go": `// Command app does things.
+package main // import "github.com/old/app"
+
+//go:generate go run github.com/old/app/cmd/gen
+
+import (
+	"fmt"
+
+	"github.com/old/app/pkg/greet"
+	"github.com/old/application/other"
+)
+
+func main() {
+	fmt.Println(greet.Hello(), other.X, "see github.com/old/app/docs")
+}
+`,
+		"pkg/greet/greet.go": "package greet\n\n// Hello greets.\nfunc Hello() string { return \"hi\" }\n",
+		"README.md":          "Install with `go install github.com/old/app@latest`.\nSee https://github.com/old/app.\nNot github.com/old/application.\n",
+	}
+	// A sibling module that must not be touched by the prefix match.
+	files["application/go.mod"] = testutil.GoMod("github.com/old/application")
+	files["application/other/other.go"] = "package other\n\nconst X = 1\n"
+	files["go.mod"] += "\nrequire github.com/old/application v0.0.0\n\nreplace github.com/old/application => ./application\n"
+	return testutil.WriteModule(t, files)
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := setup(t)
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, From: "github.com/old/app", To: "github.com/new/app", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	wants := []string{
+		"- go.mod files: 1\n- Go files: 1\n- Documentation and config files: 1",
+		"-module github.com/old/app\n+module github.com/new/app",
+		"+package main // import \"github.com/new/app\"",
+		"+//go:generate go run github.com/new/app/cmd/gen",
+		"-\t\"github.com/old/app/pkg/greet\"\n+\t\"github.com/new/app/pkg/greet\"",
+		"+Install with `go install github.com/new/app@latest`.",
+		"+See https://github.com/new/app.",
+		"main.go:14:38: \"see github.com/old/app/docs\"",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"github.com/new/application", "Not github.com/new"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected output not to contain %q, got:\n%s", unwanted, out)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "go.mod")); !strings.Contains(string(b), "module github.com/old/app") {
+		t.Error("dry run modified go.mod")
+	}
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t)
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, From: "github.com/old/app", To: "github.com/new/app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError || !strings.Contains(out, "✅ Applied and verified: 2 module(s) build.") {
+		t.Fatalf("expected success, got:\n%s", out)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if !strings.Contains(string(b), `"github.com/new/app/pkg/greet"`) || !strings.Contains(string(b), `"github.com/old/application/other"`) {
+		t.Errorf("unexpected main.go:\n%s", b)
+	}
+}
@@ -0,0 +1,1060 @@
+// Package inline implements the inline_symbol tool, which replaces every use of a trivial function
+// or a constant with its body or value and removes the declaration.
+package inline
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["inline_symbol"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package string `json:"package" jsonschema:"Package declaring the symbol (e.g. ./internal/calc)"`
+	Symbol  string `json:"symbol" jsonschema:"Name of the package-level function or constant to inline"`
+	DryRun  bool   `json:"dry_run,omitempty" jsonschema:"If true, return a diff of the changes without writing any files"`
+}
+
+// maxDiffLines caps the preview diff.
+const maxDiffLines = 400
+
+// Site is one replaced use of the symbol.
+type Site struct {
+	Position string
+	Before   string
+	After    string
+}
+
+// Plan is the outcome of inlining a symbol.
+type Plan struct {
+	Kind    string // "function" or "constant"
+	Sites   []Site
+	Changes shared.Changeset
+	Notes   []string
+}
+
+// Handler handles the inline_symbol tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Symbol == "" {
+		return errorResult("package and symbol are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	target, err := shared.LoadPackages(ctx, absDir, args.Package, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(target) != 1 {
+		return errorResult(fmt.Sprintf("%q matched %d packages; name exactly one", args.Package, len(target))), nil, nil
+	}
+	pkgs, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	plan, err := Inline(absDir, pkgs, target[0].PkgPath, args.Symbol)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Inline %s `%s`\n\n", plan.Kind, args.Symbol)
+	if args.DryRun {
+		fmt.Fprintf(&sb, "Dry run: %d use(s) would be replaced and the declaration removed.\n\n", len(plan.Sites))
+	} else {
+		if err := plan.Changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Replaced %d use(s) in %d file(s) and removed the declaration; `go vet ./...` passes.\n\n", len(plan.Sites), len(plan.Changes))
+	}
+	for _, n := range plan.Notes {
+		fmt.Fprintf(&sb, "- %s\n", n)
+	}
+	if len(plan.Notes) > 0 {
+		sb.WriteString("\n")
+	}
+	if len(plan.Sites) > 0 {
+		sb.WriteString("| Location | Before | After |\n| :--- | :--- | :--- |\n")
+		for _, s := range plan.Sites {
+			fmt.Fprintf(&sb, "| %s | `%s` | `%s` |\n", s.Position, cell(s.Before), cell(s.After))
+		}
+	}
+	if args.DryRun {
+		sb.WriteString("\n## Diff\n\n```diff\n")
+		var lines []string
+		for _, path := range plan.Changes.Files() {
+			//nolint:gosec // G304: Path comes from the loaded package.
+			old, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, "--- "+rel(absDir, path), "+++ "+rel(absDir, path))
+			diff := textdiff.Unified(string(old), string(plan.Changes[path]))
+			lines = append(lines, strings.Split(strings.TrimSuffix(diff, "\n"), "\n")...)
+		}
+		if len(lines) > maxDiffLines {
+			lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxDiffLines))
+		}
+		sb.WriteString(strings.Join(lines, "\n") + "\n```\n")
+	}
+	return textResult(sb.String()), nil, nil
+}
+
+// Inline plans replacing every use of the package-level function or constant name declared in
+// the package pkgPath with its body or value, and removing the declaration. pkgs must include
+// every package of the module, with tests, so that no use is missed.
+//
+// A function can be inlined when its body is a single return of one value or a single expression
+// statement, and every use of it is a call. A call is rejected when an argument with side effects
+// would be evaluated more than once, not at all, or out of order, or when a name the body refers
+// to means something else at the call site.
+func Inline(root string, pkgs []*packages.Package, pkgPath, name string) (*Plan, error) {
+	in := &inliner{root: root, pkgs: pkgs, name: name, sources: make(map[string][]byte)}
+	if err := in.findDecl(pkgPath); err != nil {
+		return nil, err
+	}
+	var err error
+	if in.fn != nil {
+		err = in.checkFunc()
+	} else {
+		err = in.checkConst()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	edits := make(map[string]map[int]shared.TextEdit)
+	var sites []Site
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			tokFile := pkg.Fset.File(file.Pos())
+			if tokFile == nil {
+				continue
+			}
+			for id, obj := range pkg.TypesInfo.Uses {
+				if id.Pos() < file.Pos() || id.Pos() > file.End() || !in.isTarget(pkg.Fset, obj) {
+					continue
+				}
+				s, err := in.newSite(pkg, file, tokFile, id)
+				if err != nil {
+					return nil, err
+				}
+				if _, done := edits[s.filename][s.start]; done {
+					continue // the same file in another package variant
+				}
+				after, err := in.replacement(s)
+				if err != nil {
+					return nil, fmt.Errorf("cannot inline %s at %s: %v", name, s.position, err)
+				}
+				if edits[s.filename] == nil {
+					edits[s.filename] = make(map[int]shared.TextEdit)
+				}
+				edits[s.filename][s.start] = shared.TextEdit{Start: s.start, End: s.end, New: after}
+				sites = append(sites, Site{Position: s.position, Before: s.text(s.node), After: after})
+			}
+		}
+	}
+
+	declFile := in.declTok.Name()
+	if edits[declFile] == nil {
+		edits[declFile] = make(map[int]shared.TextEdit)
+	}
+	start, end, err := in.removal()
+	if err != nil {
+		return nil, err
+	}
+	edits[declFile][start] = shared.TextEdit{Start: start, End: end}
+
+	plan := &Plan{Kind: in.kind(), Changes: make(shared.Changeset)}
+	for filename, byStart := range edits {
+		src, err := in.source(filename)
+		if err != nil {
+			return nil, err
+		}
+		var list []shared.TextEdit
+		for _, e := range byStart {
+			list = append(list, e)
+		}
+		out, err := shared.ApplyEdits(src, list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		// Imports only the removed declaration or the inlined body used are fixed up here so the
+		// preview matches what is written.
+		if formatted, err := imports.Process(filename, out, nil); err == nil {
+			out = formatted
+		}
+		plan.Changes[filename] = out
+	}
+	sort.Slice(sites, func(i, j int) bool { return shared.ComparePositions(sites[i].Position, sites[j].Position) < 0 })
+	plan.Sites = sites
+	if len(sites) == 0 {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("`%s` is not used anywhere in the module; only its declaration is removed.", name))
+	}
+	if token.IsExported(name) {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("`%s` is exported: code outside this module that uses it will no longer compile.", name))
+	}
+	if in.lostComments {
+		plan.Notes = append(plan.Notes, "Comments inside the inlined body are not copied to the call sites.")
+	}
+	return plan, nil
+}
+
+// inliner holds the declaration being inlined.
+type inliner struct {
+	root    string
+	pkgs    []*packages.Package
+	name    string
+	sources map[string][]byte
+
+	declPkg  *packages.Package
+	declFile *ast.File
+	declTok  *token.File
+	obj      types.Object
+	declPos  token.Position
+
+	// Exactly one of fn and spec is set.
+	fn   *ast.FuncDecl
+	spec *ast.ValueSpec
+	decl *ast.GenDecl // the declaration holding spec
+
+	// body is the expression or statement that replaces a use; tmpl describes its names.
+	body         ast.Node
+	tmpl         *template
+	stmt         bool // fn's body is an expression statement
+	lostComments bool
+}
+
+func (in *inliner) kind() string {
+	if in.fn != nil {
+		return "function"
+	}
+	return "constant"
+}
+
+// findDecl locates the declaration of the symbol, preferring the package variant without tests.
+func (in *inliner) findDecl(pkgPath string) error {
+	var candidates []*packages.Package
+	for _, pkg := range in.pkgs {
+		if pkg.PkgPath == pkgPath && pkg.Types != nil {
+			if pkg.ID == pkgPath {
+				candidates = append([]*packages.Package{pkg}, candidates...)
+			} else {
+				candidates = append(candidates, pkg)
+			}
+		}
+	}
+	for _, pkg := range candidates {
+		obj := pkg.Types.Scope().Lookup(in.name)
+		if obj == nil {
+			continue
+		}
+		switch obj.(type) {
+		case *types.Func, *types.Const:
+		default:
+			return fmt.Errorf("%s is a %s; only package-level functions and constants can be inlined", in.name, objKind(obj))
+		}
+		in.declPkg, in.obj = pkg, obj
+		in.declPos = pkg.Fset.Position(obj.Pos())
+		for _, file := range pkg.Syntax {
+			if file.Pos() <= obj.Pos() && obj.Pos() < file.End() {
+				in.declFile = file
+				in.declTok = pkg.Fset.File(file.Pos())
+			}
+		}
+		if in.declFile == nil {
+			return fmt.Errorf("declaration of %s not found in the package sources", in.name)
+		}
+		path, _ := astutil.PathEnclosingInterval(in.declFile, obj.Pos(), obj.Pos())
+		for _, n := range path {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				in.fn = n
+			case *ast.ValueSpec:
+				in.spec = n
+			case *ast.GenDecl:
+				in.decl = n
+			}
+		}
+		return nil
+	}
+	if strings.Contains(in.name, ".") {
+		return fmt.Errorf("methods cannot be inlined; %s must be a package-level function or constant", in.name)
+	}
+	return fmt.Errorf("%s is not declared at package level in %s", in.name, pkgPath)
+}
+
+func objKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Var:
+		return "variable"
+	case *types.TypeName:
+		return "type"
+	}
+	return "symbol"
+}
+
+// checkFunc verifies that the function is trivial and prepares its body template.
+func (in *inliner) checkFunc() error {
+	fn, sig := in.fn, in.obj.Type().(*types.Signature)
+	switch {
+	case fn.Type.TypeParams != nil:
+		return fmt.Errorf("%s is generic; inline it by hand", in.name)
+	case fn.Body == nil:
+		return fmt.Errorf("%s has no Go body", in.name)
+	case sig.Variadic():
+		return fmt.Errorf("%s is variadic; inline it by hand", in.name)
+	case len(fn.Body.List) != 1:
+		return fmt.Errorf("%s is not trivial: its body must be a single return or expression statement, it has %d statements", in.name, len(fn.Body.List))
+	}
+	switch s := fn.Body.List[0].(type) {
+	case *ast.ReturnStmt:
+		if len(s.Results) != 1 || sig.Results().Len() != 1 {
+			return fmt.Errorf("%s is not trivial: it must return exactly one value", in.name)
+		}
+		in.body = s.Results[0]
+	case *ast.ExprStmt:
+		if sig.Results().Len() != 0 {
+			return fmt.Errorf("%s is not trivial: its body must be a single return or expression statement", in.name)
+		}
+		in.body, in.stmt = s.X, true
+	default:
+		return fmt.Errorf("%s is not trivial: its body must be a single return or expression statement", in.name)
+	}
+	for _, cg := range in.declFile.Comments {
+		if cg.Pos() > fn.Body.Lbrace && cg.End() < fn.Body.Rbrace {
+			in.lostComments = true
+		}
+	}
+	var err error
+	in.tmpl, err = in.template(in.body)
+	return err
+}
+
+// checkConst verifies that the constant can be removed on its own and prepares its value.
+func (in *inliner) checkConst() error {
+	if len(in.spec.Names) != 1 {
+		return fmt.Errorf("%s is declared together with other constants in one spec; split the declaration first", in.name)
+	}
+	if in.decl.Lparen.IsValid() {
+		for _, spec := range in.decl.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if len(vs.Values) == 0 || in.usesIota(vs) {
+				return fmt.Errorf("%s is part of an iota block; removing it would change the values of the other constants", in.name)
+			}
+		}
+	}
+	if len(in.spec.Values) == 1 && !in.usesIota(in.spec) {
+		in.body = in.spec.Values[0]
+		// A template that cannot be built falls back to the constant's value.
+		in.tmpl, _ = in.template(in.body)
+	}
+	return nil
+}
+
+func (in *inliner) usesIota(vs *ast.ValueSpec) bool {
+	found := false
+	for _, v := range vs.Values {
+		ast.Inspect(v, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && in.declPkg.TypesInfo.Uses[id] == types.Universe.Lookup("iota") {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// isTarget reports whether obj, from any package variant, is the symbol being inlined.
+func (in *inliner) isTarget(fset *token.FileSet, obj types.Object) bool {
+	if obj == nil || obj.Name() != in.name || obj.Pkg() == nil || obj.Pkg().Path() != in.obj.Pkg().Path() {
+		return false
+	}
+	return fset.Position(obj.Pos()) == in.declPos
+}
+
+// Reference kinds of a name in the inlined body.
+const (
+	refParam    = iota // a parameter, replaced by the argument
+	refPkgLevel        // a package-level object of the declaring package
+	refImport          // the name of an imported package
+	refUniverse        // a predeclared identifier
+)
+
+type ref struct {
+	kind       int
+	start, end int // offsets relative to the template text
+	name       string
+	param      int    // refParam: index of the parameter
+	path       string // refImport: imported path
+	pos        token.Position
+	parent     ast.Node // refParam: where the argument goes
+	node       ast.Node
+}
+
+// template is the source of the inlined body with the names it refers to.
+type template struct {
+	text   string
+	refs   []ref
+	locals map[string]bool // names declared inside the body
+	uses   []int           // uses of each parameter
+	order  []int           // parameters in the order of their first use
+}
+
+func (in *inliner) template(body ast.Node) (*template, error) {
+	info := in.declPkg.TypesInfo
+	src, err := in.source(in.declTok.Name())
+	if err != nil {
+		return nil, err
+	}
+	base := in.declTok.Offset(body.Pos())
+	t := &template{
+		text:   string(src[base:in.declTok.Offset(body.End())]),
+		locals: make(map[string]bool),
+	}
+	params := make(map[types.Object]int)
+	if in.fn != nil {
+		sig := in.obj.Type().(*types.Signature)
+		t.uses = make([]int, sig.Params().Len())
+		for i := range sig.Params().Len() {
+			params[sig.Params().At(i)] = i
+		}
+	}
+
+	var stack []ast.Node
+	var failure error
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		parent := ast.Node(nil)
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+		id, ok := n.(*ast.Ident)
+		if !ok || failure != nil {
+			return failure == nil
+		}
+		if obj := info.Defs[id]; obj != nil {
+			t.locals[id.Name] = true
+			return true
+		}
+		obj := info.Uses[id]
+		if obj == nil {
+			return true
+		}
+		r := ref{
+			start: in.declTok.Offset(id.Pos()) - base,
+			end:   in.declTok.Offset(id.End()) - base,
+			name:  id.Name,
+			pos:   in.declPkg.Fset.Position(obj.Pos()),
+		}
+		if sel, ok := parent.(*ast.SelectorExpr); ok && sel.Sel == id {
+			return true // a field, method or qualified name; resolved through its operand
+		}
+		switch {
+		case obj == in.obj:
+			failure = fmt.Errorf("%s is recursive", in.name)
+		case isParam(params, obj):
+			r.kind, r.param = refParam, params[obj]
+			r.parent, r.node = parent, id
+			if t.uses[r.param] == 0 {
+				t.order = append(t.order, r.param)
+			}
+			t.uses[r.param]++
+		case obj.Parent() == types.Universe:
+			r.kind = refUniverse
+		case obj.Parent() == in.declPkg.Types.Scope():
+			r.kind = refPkgLevel
+		default:
+			pkgName, ok := obj.(*types.PkgName)
+			if !ok {
+				return true // declared inside the body
+			}
+			r.kind, r.path = refImport, pkgName.Imported().Path()
+			r.name = pkgName.Imported().Name()
+		}
+		t.refs = append(t.refs, r)
+		return true
+	})
+	return t, failure
+}
+
+func isParam(params map[types.Object]int, obj types.Object) bool {
+	_, ok := params[obj]
+	return ok
+}
+
+// site is one use of the symbol.
+type site struct {
+	pkg      *packages.Package
+	file     *ast.File
+	tokFile  *token.File
+	src      []byte
+	filename string
+	position string
+
+	node       ast.Node // the replaced expression: the call, or the (qualified) name of a constant
+	parent     ast.Node
+	start, end int
+	cross      bool   // the use is in another package
+	qual       string // qualifier of the declaring package at the site; empty for dot imports
+}
+
+func (s *site) text(n ast.Node) string {
+	return string(s.src[s.tokFile.Offset(n.Pos()):s.tokFile.Offset(n.End())])
+}
+
+func (in *inliner) newSite(pkg *packages.Package, file *ast.File, tokFile *token.File, id *ast.Ident) (*site, error) {
+	src, err := in.source(tokFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	s := &site{
+		pkg:      pkg,
+		file:     file,
+		tokFile:  tokFile,
+		src:      src,
+		filename: tokFile.Name(),
+		position: shared.RelPosition(in.root, pkg.Fset.Position(id.Pos())),
+		cross:    pkg.Types.Path() != in.obj.Pkg().Path(),
+	}
+	path, _ := astutil.PathEnclosingInterval(file, id.Pos(), id.End())
+	node, i := ast.Node(id), 1
+	if sel, ok := path[i].(*ast.SelectorExpr); ok && sel.Sel == id {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			s.qual = x.Name
+		}
+		node, i = sel, i+1
+	}
+	if in.fn != nil {
+		call, ok := path[i].(*ast.CallExpr)
+		if !ok || call.Fun != node {
+			return nil, fmt.Errorf("cannot inline %s: it is used as a value at %s, not called", in.name, s.position)
+		}
+		node, i = call, i+1
+	}
+	s.node, s.parent = node, path[i]
+	s.start, s.end = tokFile.Offset(node.Pos()), tokFile.Offset(node.End())
+	return s, nil
+}
+
+// replacement returns the text that replaces the use at s.
+func (in *inliner) replacement(s *site) (string, error) {
+	if in.fn == nil {
+		return in.constValue(s)
+	}
+	call := s.node.(*ast.CallExpr)
+	if in.stmt {
+		if _, ok := s.parent.(*ast.ExprStmt); !ok {
+			return "", fmt.Errorf("%s has no result, so only calls used as statements can be inlined", in.name)
+		}
+	} else if _, ok := s.parent.(*ast.ExprStmt); ok && !isCallOrRecv(in.body) {
+		return "", fmt.Errorf("the call is used as a statement and its result %q is not", in.tmpl.text)
+	}
+	switch s.parent.(type) {
+	case *ast.GoStmt, *ast.DeferStmt:
+		return "", fmt.Errorf("go and defer statements evaluate the arguments early; inline it by hand")
+	}
+	if call.Ellipsis.IsValid() {
+		return "", fmt.Errorf("the call spreads a slice with ...")
+	}
+
+	args := make([]string, len(call.Args))
+	impure := 0
+	for i, arg := range call.Args {
+		args[i] = s.text(arg)
+		if in.pure(s.pkg.TypesInfo, arg) {
+			continue
+		}
+		impure++
+		switch in.tmpl.uses[i] {
+		case 0:
+			return "", fmt.Errorf("argument %s has side effects but the body does not use it", args[i])
+		case 1:
+		default:
+			return "", fmt.Errorf("argument %s has side effects and the body uses it %d times", args[i], in.tmpl.uses[i])
+		}
+	}
+	if impure > 1 {
+		last := -1
+		for _, p := range in.tmpl.order {
+			if in.pure(s.pkg.TypesInfo, call.Args[p]) {
+				continue
+			}
+			if p < last {
+				return "", fmt.Errorf("the body evaluates the arguments in a different order than the call")
+			}
+			last = p
+		}
+	}
+	for i, arg := range call.Args {
+		if shadowed := in.capturedBy(arg); shadowed != "" {
+			return "", fmt.Errorf("argument %s refers to %s, which the body declares itself", args[i], shadowed)
+		}
+	}
+
+	text, err := in.render(s, func(r ref) string {
+		arg := call.Args[r.param]
+		if needsParens(r.parent, r.node, arg) {
+			return "(" + args[r.param] + ")"
+		}
+		return args[r.param]
+	})
+	if err != nil {
+		return "", err
+	}
+	if in.stmt {
+		return text, nil
+	}
+	result := in.obj.Type().(*types.Signature).Results().At(0).Type()
+	return in.wrap(s, text, in.body.(ast.Expr), result)
+}
+
+// constValue returns the expression that replaces a use of the constant at s: its declared
+// expression when that can be written at s, otherwise its value.
+func (in *inliner) constValue(s *site) (string, error) {
+	if in.tmpl != nil {
+		if text, err := in.render(s, nil); err == nil {
+			return in.wrap(s, text, in.body.(ast.Expr), in.obj.Type())
+		}
+	}
+	c := in.obj.(*types.Const)
+	var lit string
+	switch v := c.Val(); v.Kind() {
+	case constant.Bool, constant.Int:
+		lit = v.ExactString()
+	case constant.String:
+		lit = strconv.Quote(constant.StringVal(v))
+	case constant.Float:
+		f, exact := constant.Float64Val(v)
+		if !exact {
+			return "", fmt.Errorf("the value of %s cannot be written exactly as a literal", in.name)
+		}
+		lit = strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(lit, ".e") {
+			lit += ".0"
+		}
+	default:
+		return "", fmt.Errorf("the value of %s cannot be written as a literal", in.name)
+	}
+	if b, ok := c.Type().(*types.Basic); ok && (b.Info()&types.IsUntyped != 0 || b == types.Default(untypedOf(c.Val()))) {
+		if strings.HasPrefix(lit, "-") && needsParens(s.parent, s.node, &ast.UnaryExpr{Op: token.SUB}) {
+			return "(" + lit + ")", nil
+		}
+		return lit, nil
+	}
+	return types.TypeString(c.Type(), in.qualifier(s)) + "(" + lit + ")", nil
+}
+
+// render writes the template for the site, qualifying and checking every name it refers to.
+// param renders parameters; it is nil for constants.
+func (in *inliner) render(s *site, param func(ref) string) (string, error) {
+	t := in.tmpl
+	scope := s.pkg.Types.Scope().Innermost(s.node.Pos())
+	var sb strings.Builder
+	last := 0
+	for _, r := range t.refs {
+		sb.WriteString(t.text[last:r.start])
+		last = r.end
+		name := r.name
+		switch r.kind {
+		case refParam:
+			sb.WriteString(param(r))
+			continue
+		case refPkgLevel:
+			if s.cross {
+				if !token.IsExported(name) {
+					return "", fmt.Errorf("the body refers to %s, which is not exported", name)
+				}
+				if s.qual != "" {
+					name = s.qual + "." + name
+					break
+				}
+			}
+			if !in.resolves(s, scope, name, func(obj types.Object) bool { return s.pkg.Fset.Position(obj.Pos()) == r.pos }) {
+				return "", fmt.Errorf("%s means something else at the call site", name)
+			}
+		case refUniverse:
+			if !in.resolves(s, scope, name, func(obj types.Object) bool { return obj.Parent() == types.Universe }) {
+				return "", fmt.Errorf("%s is redeclared at the call site", name)
+			}
+		case refImport:
+			name = importName(s.file, r.path, r.name)
+			ok := in.resolves(s, scope, name, func(obj types.Object) bool {
+				pn, ok := obj.(*types.PkgName)
+				return ok && pn.Imported().Path() == r.path
+			})
+			if !ok && scope != nil {
+				if _, obj := scope.LookupParent(name, s.node.Pos()); obj != nil {
+					return "", fmt.Errorf("package %s is shadowed by %s at the call site", r.path, name)
+				}
+			}
+		}
+		sb.WriteString(name)
+	}
+	sb.WriteString(t.text[last:])
+	return sb.String(), nil
+}
+
+// resolves reports whether name, looked up at the site, is an object accepted by want.
+func (in *inliner) resolves(s *site, scope *types.Scope, name string, want func(types.Object) bool) bool {
+	if scope == nil {
+		return false
+	}
+	_, obj := scope.LookupParent(name, s.node.Pos())
+	return obj != nil && want(obj)
+}
+
+// wrap converts the inlined expression to typ when its own type differs, and parenthesizes it
+// where the site's operator would otherwise bind tighter.
+func (in *inliner) wrap(s *site, text string, expr ast.Expr, typ types.Type) (string, error) {
+	if t := in.naturalType(expr); t != nil && !types.Identical(t, typ) && !types.Identical(types.Default(t), typ) {
+		if s.cross && !exportedType(typ) {
+			return "", fmt.Errorf("the result type %s is not exported", typ)
+		}
+		return types.TypeString(typ, in.qualifier(s)) + "(" + text + ")", nil
+	}
+	if needsParens(s.parent, s.node, expr) {
+		return "(" + text + ")", nil
+	}
+	return text, nil
+}
+
+// naturalType returns the type expr has on its own. The type checker records untyped constants
+// and nil with the type of the context they are used in (the declared type of a constant, the
+// result type of a function), which is not the type they would get at a call site.
+func (in *inliner) naturalType(expr ast.Expr) types.Type {
+	info := in.declPkg.TypesInfo
+	tv, ok := info.Types[expr]
+	switch {
+	case !ok:
+		return nil
+	case tv.IsNil():
+		return types.Typ[types.UntypedNil]
+	case tv.Value == nil:
+		return tv.Type
+	}
+	typed := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			typed = true // a conversion
+		case *ast.Ident:
+			if c, ok := info.Uses[n].(*types.Const); ok {
+				if b, ok := c.Type().(*types.Basic); !ok || b.Info()&types.IsUntyped == 0 {
+					typed = true
+				}
+			}
+		}
+		return !typed
+	})
+	if typed {
+		return tv.Type
+	}
+	return in.untyped(expr)
+}
+
+// untyped returns the untyped type of a constant expression free of typed operands. Its value
+// cannot be used: the type checker converts it to the type of the context.
+func (in *inliner) untyped(expr ast.Expr) types.Type {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.FLOAT:
+			return types.Typ[types.UntypedFloat]
+		case token.IMAG:
+			return types.Typ[types.UntypedComplex]
+		case token.CHAR:
+			return types.Typ[types.UntypedRune]
+		case token.STRING:
+			return types.Typ[types.UntypedString]
+		}
+		return types.Typ[types.UntypedInt]
+	case *ast.Ident:
+		if obj := in.declPkg.TypesInfo.Uses[e]; obj != nil && obj.Type() != nil {
+			return obj.Type()
+		}
+	case *ast.ParenExpr:
+		return in.untyped(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.NOT {
+			return types.Typ[types.UntypedBool]
+		}
+		return in.untyped(e.X)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.LAND, token.LOR:
+			return types.Typ[types.UntypedBool]
+		case token.SHL, token.SHR:
+			return in.untyped(e.X)
+		}
+		// The operand of the later kind wins: int < rune < float < complex.
+		x, y := in.untyped(e.X), in.untyped(e.Y)
+		if xb, ok := x.(*types.Basic); ok {
+			if yb, ok := y.(*types.Basic); ok && yb.Kind() > xb.Kind() {
+				return y
+			}
+		}
+		return x
+	}
+	return untypedOf(in.declPkg.TypesInfo.Types[expr].Value)
+}
+
+// untypedOf returns the untyped type of a constant value.
+func untypedOf(v constant.Value) types.Type {
+	switch v.Kind() {
+	case constant.Bool:
+		return types.Typ[types.UntypedBool]
+	case constant.String:
+		return types.Typ[types.UntypedString]
+	case constant.Float:
+		return types.Typ[types.UntypedFloat]
+	case constant.Complex:
+		return types.Typ[types.UntypedComplex]
+	}
+	return types.Typ[types.UntypedInt]
+}
+
+// qualifier names packages as the site's file imports them.
+func (in *inliner) qualifier(s *site) types.Qualifier {
+	return func(p *types.Package) string {
+		switch {
+		case p.Path() == s.pkg.Types.Path():
+			return ""
+		case p.Path() == in.obj.Pkg().Path() && s.qual != "":
+			return s.qual
+		}
+		return importName(s.file, p.Path(), p.Name())
+	}
+}
+
+// importName returns the name under which file imports path, or def if it does not import it.
+func importName(file *ast.File, path, def string) string {
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path {
+			if imp.Name != nil && imp.Name.Name != "_" && imp.Name.Name != "." {
+				return imp.Name.Name
+			}
+			return def
+		}
+	}
+	return def
+}
+
+func exportedType(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Pkg() == nil || token.IsExported(named.Obj().Name())
+	}
+	return true
+}
+
+// pure reports whether evaluating expr has no side effects and does not depend on when it is
+// evaluated, so it may be duplicated, dropped or reordered.
+func (in *inliner) pure(info *types.Info, expr ast.Expr) bool {
+	if tv, ok := info.Types[expr]; ok && tv.Value != nil {
+		return true
+	}
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.BasicLit, *ast.FuncLit:
+		return true
+	case *ast.ParenExpr:
+		return in.pure(info, e.X)
+	case *ast.SelectorExpr:
+		return in.pure(info, e.X)
+	case *ast.UnaryExpr:
+		return e.Op == token.AND && in.pure(info, e.X)
+	}
+	return false
+}
+
+// capturedBy returns the first name used by the argument that the body declares locally, where
+// the inlined argument would refer to the body's variable instead.
+func (in *inliner) capturedBy(arg ast.Expr) string {
+	found := ""
+	ast.Inspect(arg, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && in.tmpl.locals[id.Name] && found == "" {
+			found = id.Name
+		}
+		return found == ""
+	})
+	return found
+}
+
+func isCallOrRecv(n ast.Node) bool {
+	switch e := n.(type) {
+	case *ast.CallExpr:
+		return true
+	case *ast.UnaryExpr:
+		return e.Op == token.ARROW
+	case *ast.ParenExpr:
+		return isCallOrRecv(e.X)
+	}
+	return false
+}
+
+// needsParens reports whether expr needs parentheses to replace n, a child of parent.
+func needsParens(parent, n, expr ast.Node) bool {
+	if bareContext(parent, n) {
+		return false
+	}
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		if p, ok := parent.(*ast.BinaryExpr); ok {
+			prec, outer := e.Op.Precedence(), p.Op.Precedence()
+			return prec < outer || prec == outer && p.Y == n
+		}
+		return true
+	case *ast.UnaryExpr, *ast.StarExpr:
+		// "a - -b" is fine, "- -b" would lex as "--".
+		_, ok := parent.(*ast.BinaryExpr)
+		return !ok
+	}
+	return false
+}
+
+// bareContext reports whether n, a child of parent, is in a position where any expression can
+// stand without parentheses.
+func bareContext(parent, n ast.Node) bool {
+	switch p := parent.(type) {
+	case *ast.ExprStmt, *ast.AssignStmt, *ast.ValueSpec, *ast.ReturnStmt, *ast.ParenExpr,
+		*ast.CompositeLit, *ast.SendStmt, *ast.IfStmt, *ast.SwitchStmt, *ast.CaseClause:
+		return true
+	case *ast.CallExpr:
+		return p.Fun != n
+	case *ast.KeyValueExpr:
+		return p.Value == n
+	case *ast.IndexExpr:
+		return p.Index == n
+	}
+	return false
+}
+
+// removal returns the byte range of the declaration to delete, including its doc comment and
+// the rest of its last line.
+func (in *inliner) removal() (int, int, error) {
+	var node ast.Node
+	var doc *ast.CommentGroup
+	switch {
+	case in.fn != nil:
+		node, doc = in.fn, in.fn.Doc
+	case len(in.decl.Specs) == 1:
+		node, doc = in.decl, in.decl.Doc
+		if doc == nil {
+			doc = in.spec.Doc
+		}
+	default:
+		node, doc = in.spec, in.spec.Doc
+	}
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	src, err := in.source(in.declTok.Name())
+	if err != nil {
+		return 0, 0, err
+	}
+	from := in.declTok.Offset(start)
+	for from > 0 && (src[from-1] == ' ' || src[from-1] == '\t') {
+		from--
+	}
+	to := in.declTok.Offset(node.End())
+	if in.spec != nil && in.spec.Comment != nil && in.spec.Comment.End() > node.End() {
+		to = in.declTok.Offset(in.spec.Comment.End())
+	}
+	for to < len(src) && src[to] != '\n' {
+		to++
+	}
+	if to < len(src) {
+		to++
+	}
+	return from, to, nil
+}
+
+func (in *inliner) source(filename string) ([]byte, error) {
+	if src, ok := in.sources[filename]; ok {
+		return src, nil
+	}
+	//nolint:gosec // G304: File path comes from the loaded package.
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	in.sources[filename] = src
+	return src, nil
+}
+
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "`", "'")
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,184 @@
+package inline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const mathxSrc = `package mathx
+
+import "strings"
+
+// Timeout is the default timeout in seconds.
+const Timeout = 30
+
+const Scale float64 = 2
+
+// Double returns twice n.
+func Double(n int) int {
+	return n * 2
+}
+
+func Add(a, b int) int { return a + b }
+
+func lower(s string) string { return strings.ToLower(s) }
+
+// Norm normalizes a name.
+func Norm(s string) string { return lower(s) }
+
+func Log(msg string) { println(msg) }
+
+func Uses() int {
+	x := Double(3) + 1
+	y := Add(x, 2) * 3
+	Log("hi")
+	return x + y + Timeout
+}
+
+func Twice(n int) int { return n + n }
+
+func next() int { return 1 }
+
+func UseTwice() int { return Twice(next()) }
+
+func Ident(n int) int { return n }
+
+var op = Ident
+`
+
+const mathxTestSrc = `package mathx
+
+import "testing"
+
+func TestDouble(t *testing.T) {
+	if Double(2) != 4 {
+		t.Fatal("bad")
+	}
+}
+`
+
+const mainSrc = `package main
+
+import (
+	"fmt"
+
+	"example.com/app/mathx"
+)
+
+func main() {
+	fmt.Println(mathx.Double(2), mathx.Timeout, mathx.Scale, mathx.Norm("A"))
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":              "module example.com/app\n\ngo 1.24\n",
+		"mathx/mathx.go":      mathxSrc,
+		"mathx/mathx_test.go": mathxTestSrc,
+		"app/main.go":         mainSrc,
+	})
+}
+
+func call(t *testing.T, params Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func read(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHandler_Function(t *testing.T) {
+	dir := setup(t)
+	if out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: "Double"}); isErr {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	if out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: "Add"}); isErr {
+		t.Fatalf("unexpected error: %s", out)
+	}
+
+	checks := map[string][]string{
+		"app/main.go":         {"fmt.Println(2*2, mathx.Timeout"},
+		"mathx/mathx.go":      {"x := 3*2 + 1", "y := (x + 2) * 3"},
+		"mathx/mathx_test.go": {"if 2*2 != 4 {"},
+	}
+	for name, wants := range checks {
+		got := read(t, dir, name)
+		for _, want := range wants {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: expected %q, got:\n%s", name, want, got)
+			}
+		}
+	}
+	if got := read(t, dir, "mathx/mathx.go"); strings.Contains(got, "Double") || strings.Contains(got, "func Add") {
+		t.Errorf("expected the declarations and their doc comments to be removed, got:\n%s", got)
+	}
+}
+
+func TestHandler_Statement(t *testing.T) {
+	dir := setup(t)
+	out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: "Log", DryRun: true})
+	if isErr || !strings.Contains(out, "+\tprintln(\"hi\")") {
+		t.Errorf("expected the call to become the body statement, got:\n%s", out)
+	}
+	if got := read(t, dir, "mathx/mathx.go"); got != mathxSrc {
+		t.Error("dry run modified the file")
+	}
+}
+
+func TestHandler_Constant(t *testing.T) {
+	dir := setup(t)
+	out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: "Scale", DryRun: true})
+	if isErr || !strings.Contains(out, "| `mathx.Scale` | `float64(2)` |") {
+		t.Errorf("expected a conversion to the declared type, got:\n%s", out)
+	}
+
+	if out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: "Timeout"}); isErr {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	if got := read(t, dir, "mathx/mathx.go"); !strings.Contains(got, "return x + y + 30") || strings.Contains(got, "Timeout") {
+		t.Errorf("expected the constant to be propagated and removed, got:\n%s", got)
+	}
+	if got := read(t, dir, "app/main.go"); !strings.Contains(got, "mathx.Double(2), 30, mathx.Scale") {
+		t.Errorf("expected the use in another package to be replaced, got:\n%s", got)
+	}
+}
+
+func TestHandler_Rejected(t *testing.T) {
+	dir := setup(t)
+	tests := []struct {
+		symbol, want string
+	}{
+		{"Norm", "refers to lower, which is not exported"},
+		{"Twice", "argument next() has side effects and the body uses it 2 times"},
+		{"Ident", "used as a value"},
+		{"Uses", "not trivial"},
+		{"op", "only package-level functions and constants"},
+		{"Missing", "not declared at package level"},
+	}
+	for _, tt := range tests {
+		out, isErr := call(t, Params{Dir: dir, Package: "./mathx", Symbol: tt.symbol})
+		if !isErr || !strings.Contains(out, tt.want) {
+			t.Errorf("%s: expected error %q, got: %s", tt.symbol, tt.want, out)
+		}
+	}
+	if got := read(t, dir, "mathx/mathx.go"); got != mathxSrc {
+		t.Error("a rejected inlining modified the file")
+	}
+}
@@ -0,0 +1,669 @@
+// Package paramobj implements the introduce_parameter_object tool, which replaces a run of
+// parameters of a function with a single struct parameter and updates every call in the module.
+package paramobj
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["introduce_parameter_object"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir      string   `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package  string   `json:"package" jsonschema:"Package declaring the function (e.g. ./internal/client)"`
+	Function string   `json:"function" jsonschema:"Function to refactor; use Type.Method for methods"`
+	Params   []string `json:"params,omitempty" jsonschema:"Consecutive parameters to move into the struct (default: all except a leading context.Context and a trailing variadic parameter)"`
+	TypeName string   `json:"type_name,omitempty" jsonschema:"Name of the generated struct (default: the function name followed by Params)"`
+	DryRun   bool     `json:"dry_run,omitempty" jsonschema:"If true, return a diff of the changes without writing any files"`
+}
+
+// maxDiffLines caps the preview diff.
+const maxDiffLines = 400
+
+// Plan is the outcome of introducing a parameter object.
+type Plan struct {
+	TypeName string
+	Fields   []string
+	Calls    int
+	Changes  shared.Changeset
+}
+
+// Handler handles the introduce_parameter_object tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Function == "" {
+		return errorResult("package and function are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	target, err := shared.LoadPackages(ctx, absDir, args.Package, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(target) != 1 {
+		return errorResult(fmt.Sprintf("%q matched %d packages; name exactly one", args.Package, len(target))), nil, nil
+	}
+	pkgs, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	plan, err := Introduce(pkgs, target[0].PkgPath, args.Function, args.Params, args.TypeName)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Parameter object `%s`\n\n", plan.TypeName)
+	if args.DryRun {
+		fmt.Fprintf(&sb, "Dry run: %s would take a `%s` with fields %s; %d call(s) would be updated.\n\n",
+			args.Function, plan.TypeName, fieldList(plan.Fields), plan.Calls)
+		sb.WriteString("## Diff\n\n```diff\n")
+		var lines []string
+		for _, path := range plan.Changes.Files() {
+			//nolint:gosec // G304: Path comes from the loaded package.
+			old, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, "--- "+rel(absDir, path), "+++ "+rel(absDir, path))
+			diff := textdiff.Unified(string(old), string(plan.Changes[path]))
+			lines = append(lines, strings.Split(strings.TrimSuffix(diff, "\n"), "\n")...)
+		}
+		if len(lines) > maxDiffLines {
+			lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxDiffLines))
+		}
+		sb.WriteString(strings.Join(lines, "\n") + "\n```\n")
+		return textResult(sb.String()), nil, nil
+	}
+	if err := plan.Changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	fmt.Fprintf(&sb, "✅ %s now takes a `%s` with fields %s. Updated %d call(s) in %d file(s); `go vet ./...` passes.\n\n",
+		args.Function, plan.TypeName, fieldList(plan.Fields), plan.Calls, len(plan.Changes))
+	sb.WriteString("Arguments that were zero values are left out of the literals, so the struct's zero value keeps the old defaults. Document the defaults on the fields if callers should rely on them.\n")
+	return textResult(sb.String()), nil, nil
+}
+
+// Introduce plans replacing the consecutive parameters names (default: all but a leading
+// context.Context and a trailing variadic parameter) of the function or Type.Method fn, declared
+// in the package pkgPath, with a struct typeName. pkgs must include every package of the module,
+// with tests, so that no call is missed. Every use of the function must be a call.
+func Introduce(pkgs []*packages.Package, pkgPath, fn string, names []string, typeName string) (*Plan, error) {
+	r := &refactoring{sources: make(map[string][]byte)}
+	if err := r.findDecl(pkgs, pkgPath, fn); err != nil {
+		return nil, err
+	}
+	if err := r.selectParams(names); err != nil {
+		return nil, err
+	}
+	if err := r.name(typeName); err != nil {
+		return nil, err
+	}
+
+	edits := make(map[string]map[int]shared.TextEdit)
+	add := func(filename string, e shared.TextEdit) {
+		if edits[filename] == nil {
+			edits[filename] = make(map[int]shared.TextEdit)
+		}
+		edits[filename][e.Start] = e
+	}
+	for _, e := range r.declEdits() {
+		add(r.declTok.Name(), e)
+	}
+
+	calls := 0
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			tokFile := pkg.Fset.File(file.Pos())
+			if tokFile == nil {
+				continue
+			}
+			for id, obj := range pkg.TypesInfo.Uses {
+				if id.Pos() < file.Pos() || id.Pos() > file.End() || !r.isTarget(pkg.Fset, obj) {
+					continue
+				}
+				e, err := r.callEdit(pkg, file, tokFile, id)
+				if err != nil {
+					return nil, err
+				}
+				if _, done := edits[tokFile.Name()][e.Start]; done {
+					continue // the same file in another package variant
+				}
+				add(tokFile.Name(), e)
+				calls++
+			}
+		}
+	}
+
+	plan := &Plan{TypeName: r.typeName, Calls: calls, Changes: make(shared.Changeset)}
+	for _, p := range r.params {
+		plan.Fields = append(plan.Fields, p.field)
+	}
+	for filename, byStart := range edits {
+		src, err := r.source(filename)
+		if err != nil {
+			return nil, err
+		}
+		var list []shared.TextEdit
+		for _, e := range byStart {
+			list = append(list, e)
+		}
+		out, err := shared.ApplyEdits(src, list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		if formatted, err := imports.Process(filename, out, nil); err == nil {
+			out = formatted
+		}
+		plan.Changes[filename] = out
+	}
+	return plan, nil
+}
+
+// param is one parameter moved into the struct.
+type param struct {
+	name  string
+	field string
+	typ   string // source text of the type
+	index int    // position in the signature
+	used  bool   // the body refers to it
+}
+
+type refactoring struct {
+	sources map[string][]byte
+
+	pkg     *packages.Package
+	decl    *ast.FuncDecl
+	declTok *token.File
+	obj     *types.Func
+	declPos token.Position
+	label   string // the function as given
+
+	params      []param
+	first, last int // indices of the moved parameters in the signature
+	typeName    string
+	varName     string // name of the struct parameter
+}
+
+// findDecl locates the function, preferring the package variant without tests.
+func (r *refactoring) findDecl(pkgs []*packages.Package, pkgPath, fn string) error {
+	recv, name, isMethod := strings.Cut(fn, ".")
+	if !isMethod {
+		name, recv = recv, ""
+	}
+	r.label = fn
+	var candidates []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == pkgPath && pkg.TypesInfo != nil {
+			if pkg.ID == pkgPath {
+				candidates = append([]*packages.Package{pkg}, candidates...)
+			} else {
+				candidates = append(candidates, pkg)
+			}
+		}
+	}
+	for _, pkg := range candidates {
+		for _, file := range pkg.Syntax {
+			for _, d := range file.Decls {
+				fd, ok := d.(*ast.FuncDecl)
+				if !ok || fd.Name.Name != name || receiverName(fd) != recv {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				r.pkg, r.decl, r.obj = pkg, fd, obj
+				r.declTok = pkg.Fset.File(file.Pos())
+				r.declPos = pkg.Fset.Position(obj.Pos())
+				switch {
+				case fd.Body == nil:
+					return fmt.Errorf("%s has no Go body", fn)
+				case fd.Type.TypeParams != nil || obj.Type().(*types.Signature).RecvTypeParams().Len() > 0:
+					return fmt.Errorf("%s is generic; introduce the parameter object by hand", fn)
+				}
+				_, err := r.source(r.declTok.Name())
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("function %s not found in %s", fn, pkgPath)
+}
+
+func receiverName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+	t := fd.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.IndexExpr:
+		return identName(t.X)
+	case *ast.IndexListExpr:
+		return identName(t.X)
+	}
+	return identName(t)
+}
+
+func identName(e ast.Expr) string {
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// selectParams picks the parameters to move and checks that they are consecutive and named.
+func (r *refactoring) selectParams(names []string) error {
+	sig := r.obj.Type().(*types.Signature)
+	all := sig.Params()
+	var candidates []int
+	if len(names) == 0 {
+		for i := range all.Len() {
+			if i == 0 && isContext(all.At(i).Type()) || sig.Variadic() && i == all.Len()-1 {
+				continue
+			}
+			candidates = append(candidates, i)
+		}
+	} else {
+		for _, n := range names {
+			found := -1
+			for i := range all.Len() {
+				if all.At(i).Name() == n {
+					found = i
+				}
+			}
+			if found < 0 {
+				return fmt.Errorf("%s has no parameter %q", r.label, n)
+			}
+			if sig.Variadic() && found == all.Len()-1 {
+				return fmt.Errorf("the variadic parameter %s cannot move into a struct", n)
+			}
+			candidates = append(candidates, found)
+		}
+		slices.Sort(candidates)
+		candidates = slices.Compact(candidates)
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("%s has no parameters to group", r.label)
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i] != candidates[i-1]+1 {
+			return fmt.Errorf("the parameters to group must be consecutive in the signature of %s", r.label)
+		}
+	}
+	r.first, r.last = candidates[0], candidates[len(candidates)-1]
+
+	used := make(map[types.Object]bool)
+	ast.Inspect(r.decl.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			used[r.pkg.TypesInfo.Uses[id]] = true
+		}
+		return true
+	})
+	exported := token.IsExported(r.decl.Name.Name)
+	fields := make(map[string]bool)
+	for _, i := range candidates {
+		v := all.At(i)
+		if v.Name() == "" || v.Name() == "_" {
+			return fmt.Errorf("parameter %d of %s has no name; name it first", i+1, r.label)
+		}
+		field := v.Name()
+		if exported {
+			field = exportName(field)
+		}
+		if fields[field] {
+			return fmt.Errorf("parameters of %s map to the same field %s", r.label, field)
+		}
+		fields[field] = true
+		r.params = append(r.params, param{
+			name:  v.Name(),
+			field: field,
+			typ:   r.typeText(i),
+			index: i,
+			used:  used[v],
+		})
+	}
+	return nil
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// typeText returns the source of the type of parameter i.
+func (r *refactoring) typeText(i int) string {
+	n := 0
+	for _, f := range r.decl.Type.Params.List {
+		count := max(len(f.Names), 1)
+		if i < n+count {
+			return r.text(f.Type)
+		}
+		n += count
+	}
+	return ""
+}
+
+// initialisms are spelled in capitals when a parameter name becomes an exported field.
+var initialisms = map[string]string{
+	"api": "API", "db": "DB", "dns": "DNS", "http": "HTTP", "id": "ID", "ip": "IP", "json": "JSON",
+	"sql": "SQL", "tls": "TLS", "ttl": "TTL", "uri": "URI", "url": "URL", "uuid": "UUID",
+}
+
+// exportName capitalizes a parameter name, spelling a leading initialism in capitals
+// (url → URL, userID → UserID, httpClient → HTTPClient).
+func exportName(name string) string {
+	end := len(name)
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			end = i
+			break
+		}
+	}
+	if up, ok := initialisms[name[:end]]; ok {
+		return up + name[end:]
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// name picks the names of the struct type and of the new parameter.
+func (r *refactoring) name(typeName string) error {
+	if typeName == "" {
+		typeName = r.decl.Name.Name + "Params"
+		if r.decl.Recv != nil {
+			typeName = receiverName(r.decl) + typeName
+		}
+		if !token.IsExported(r.decl.Name.Name) {
+			typeName = strings.ToLower(typeName[:1]) + typeName[1:]
+		} else {
+			typeName = strings.ToUpper(typeName[:1]) + typeName[1:]
+		}
+	}
+	if !token.IsIdentifier(typeName) {
+		return fmt.Errorf("invalid type name %q", typeName)
+	}
+	if obj := r.pkg.Types.Scope().Lookup(typeName); obj != nil {
+		return fmt.Errorf("%s is already declared in the package; choose another type_name", typeName)
+	}
+	r.typeName = typeName
+
+	// The new parameter must not capture or be captured by a name the body uses.
+	taken := make(map[string]bool)
+	ast.Inspect(r.decl, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			taken[id.Name] = true
+		}
+		return true
+	})
+	for _, v := range []string{"params", "p", "args", "opts"} {
+		if !taken[v] {
+			r.varName = v
+			return nil
+		}
+	}
+	return fmt.Errorf("could not pick a name for the new parameter of %s", r.label)
+}
+
+// declEdits rewrites the signature, binds the old parameter names at the top of the body so it
+// stays unchanged, and adds the struct before the function.
+func (r *refactoring) declEdits() []shared.TextEdit {
+	var edits []shared.TextEdit
+
+	// The struct goes before the function and its doc comment.
+	start := r.decl.Pos()
+	if r.decl.Doc != nil {
+		start = r.decl.Doc.Pos()
+	}
+	var def strings.Builder
+	fmt.Fprintf(&def, "// %s holds the parameters of %s.\ntype %s struct {\n", r.typeName, r.label, r.typeName)
+	for _, p := range r.params {
+		fmt.Fprintf(&def, "\t%s %s\n", p.field, p.typ)
+	}
+	def.WriteString("}\n\n")
+	edits = append(edits, shared.TextEdit{Start: r.off(start), End: r.off(start), New: def.String()})
+
+	// The parameter list is rebuilt field by field; fields outside the moved range keep their text,
+	// fields straddling its edges ("a, b int") are split.
+	var list []string
+	i := 0
+	for _, f := range r.decl.Type.Params.List {
+		lo := i
+		i += max(len(f.Names), 1)
+		if i-1 < r.first || lo > r.last {
+			list = append(list, r.text(f))
+			continue
+		}
+		var before, after []string
+		for k, n := range f.Names {
+			switch {
+			case lo+k < r.first:
+				before = append(before, n.Name)
+			case lo+k > r.last:
+				after = append(after, n.Name)
+			}
+		}
+		if len(before) > 0 {
+			list = append(list, strings.Join(before, ", ")+" "+r.text(f.Type))
+		}
+		if lo <= r.first {
+			list = append(list, r.varName+" "+r.typeName)
+		}
+		if len(after) > 0 {
+			list = append(list, strings.Join(after, ", ")+" "+r.text(f.Type))
+		}
+	}
+	params := r.decl.Type.Params
+	edits = append(edits, shared.TextEdit{
+		Start: r.off(params.Opening) + 1,
+		End:   r.off(params.Closing),
+		New:   strings.Join(list, ", "),
+	})
+
+	var names, values []string
+	for _, p := range r.params {
+		if p.used {
+			names = append(names, p.name)
+			values = append(values, r.varName+"."+p.field)
+		}
+	}
+	if len(names) > 0 {
+		lbrace := r.off(r.decl.Body.Lbrace) + 1
+		edits = append(edits, shared.TextEdit{
+			Start: lbrace,
+			End:   lbrace,
+			New:   "\n" + strings.Join(names, ", ") + " := " + strings.Join(values, ", ") + ";",
+		})
+	}
+	return edits
+}
+
+// isTarget reports whether obj, from any package variant, is the function being refactored.
+func (r *refactoring) isTarget(fset *token.FileSet, obj types.Object) bool {
+	if obj == nil || obj.Name() != r.obj.Name() || obj.Pkg() == nil || obj.Pkg().Path() != r.obj.Pkg().Path() {
+		return false
+	}
+	return fset.Position(obj.Pos()) == r.declPos
+}
+
+// callEdit rewrites the arguments of the call using id into a struct literal.
+func (r *refactoring) callEdit(pkg *packages.Package, file *ast.File, tokFile *token.File, id *ast.Ident) (shared.TextEdit, error) {
+	position := pkg.Fset.Position(id.Pos())
+	path, _ := astutil.PathEnclosingInterval(file, id.Pos(), id.End())
+	fun := ast.Node(id)
+	if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == id {
+		fun = sel
+	}
+	qual := ""
+	if declPkg := r.obj.Pkg(); pkg.Types.Path() != declPkg.Path() {
+		qual = importName(file, declPkg.Path(), declPkg.Name())
+	}
+	var call *ast.CallExpr
+	for _, n := range path[1:] {
+		if c, ok := n.(*ast.CallExpr); ok {
+			call = c
+			break
+		}
+	}
+	if call == nil || call.Fun != fun {
+		return shared.TextEdit{}, fmt.Errorf("%s is used as a value at %s:%d; only calls can be rewritten", r.label, filepath.Base(position.Filename), position.Line)
+	}
+	if len(call.Args) <= r.last {
+		return shared.TextEdit{}, fmt.Errorf("the call at %s:%d passes a multi-value expression; rewrite it by hand first", filepath.Base(position.Filename), position.Line)
+	}
+
+	src, err := r.source(tokFile.Name())
+	if err != nil {
+		return shared.TextEdit{}, err
+	}
+	text := func(n ast.Node) string {
+		return string(src[tokFile.Offset(n.Pos()):tokFile.Offset(n.End())])
+	}
+	var elts []string
+	for _, p := range r.params {
+		arg := call.Args[p.index]
+		if isZero(pkg.TypesInfo, arg) {
+			continue
+		}
+		elts = append(elts, p.field+": "+text(arg))
+	}
+	return shared.TextEdit{
+		Start: tokFile.Offset(call.Args[r.first].Pos()),
+		End:   tokFile.Offset(call.Args[r.last].End()),
+		New:   qual + r.typeName + "{" + strings.Join(elts, ", ") + "}",
+	}, nil
+}
+
+// importName returns the qualifier, with its dot, under which file refers to the package path;
+// goimports adds the import when the file does not have it yet.
+func importName(file *ast.File, path, name string) string {
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path && imp.Name != nil {
+			switch imp.Name.Name {
+			case ".":
+				return ""
+			case "_":
+			default:
+				return imp.Name.Name + "."
+			}
+		}
+	}
+	return name + "."
+}
+
+// isZero reports whether arg is a literal zero value: the field can be left out of the struct
+// literal without changing behavior.
+func isZero(info *types.Info, arg ast.Expr) bool {
+	tv, ok := info.Types[arg]
+	if !ok {
+		return false
+	}
+	if tv.IsNil() {
+		return true
+	}
+	if tv.Value == nil {
+		return false
+	}
+	switch tv.Value.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(tv.Value)
+	case constant.String:
+		return constant.StringVal(tv.Value) == ""
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Sign(tv.Value) == 0
+	}
+	return false
+}
+
+func (r *refactoring) off(pos token.Pos) int { return r.declTok.Offset(pos) }
+
+func (r *refactoring) text(n ast.Node) string {
+	src := r.sources[r.declTok.Name()]
+	return string(src[r.off(n.Pos()):r.off(n.End())])
+}
+
+func (r *refactoring) source(filename string) ([]byte, error) {
+	if src, ok := r.sources[filename]; ok {
+		return src, nil
+	}
+	//nolint:gosec // G304: File path comes from the loaded package.
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	r.sources[filename] = src
+	return src, nil
+}
+
+func fieldList(fields []string) string {
+	return "`" + strings.Join(fields, "`, `") + "`"
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
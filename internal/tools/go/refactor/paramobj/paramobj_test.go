@@ -0,0 +1,156 @@
+package paramobj
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const clientSrc = `package client
+
+import (
+	"context"
+	"time"
+)
+
+// Fetch downloads url.
+func Fetch(ctx context.Context, url string, retries int, timeout time.Duration, verbose bool, tags ...string) (string, error) {
+	if verbose {
+		println(url, retries, timeout)
+	}
+	_ = tags
+	return url, ctx.Err()
+}
+
+type Client struct{}
+
+func (c *Client) Do(method, path string, body []byte) error { return nil }
+
+func local(a, b int, c string) int { return a + b + len(c) }
+
+func use() int { return local(1, 2, "x") }
+
+func Hook(name string, n int) {}
+
+var hooks = []func(string, int){Hook}
+
+type ClientDoParams struct{}
+`
+
+const mainSrc = `package main
+
+import (
+	"context"
+	"time"
+
+	"example.com/app/client"
+)
+
+func main() {
+	client.Fetch(context.Background(), "u", 3, 0, false, "a", "b")
+	client.Fetch(context.TODO(), "v", 0, time.Second, true)
+	c := &client.Client{}
+	c.Do("GET", "/", nil)
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.24\n",
+		"client/client.go": clientSrc,
+		"app/main.go":      mainSrc,
+	})
+}
+
+func call(t *testing.T, params Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func read(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t)
+	if out, isErr := call(t, Params{Dir: dir, Package: "./client", Function: "Fetch"}); isErr {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	checks := map[string][]string{
+		"client/client.go": {
+			"// FetchParams holds the parameters of Fetch.\ntype FetchParams struct {\n\tURL     string\n\tRetries int\n\tTimeout time.Duration\n\tVerbose bool\n}\n\n// Fetch downloads url.",
+			"func Fetch(ctx context.Context, params FetchParams, tags ...string) (string, error) {\n\turl, retries, timeout, verbose := params.URL, params.Retries, params.Timeout, params.Verbose\n",
+		},
+		"app/main.go": {
+			// Zero-valued arguments are left to the struct's zero value.
+			`client.Fetch(context.Background(), client.FetchParams{URL: "u", Retries: 3}, "a", "b")`,
+			`client.Fetch(context.TODO(), client.FetchParams{URL: "v", Timeout: time.Second, Verbose: true})`,
+		},
+	}
+	for name, wants := range checks {
+		got := read(t, dir, name)
+		for _, want := range wants {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: expected %q, got:\n%s", name, want, got)
+			}
+		}
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := setup(t)
+	out, isErr := call(t, Params{Dir: dir, Package: "./client", Function: "local", Params: []string{"b", "c"}, DryRun: true})
+	for _, want := range []string{
+		"+func local(a int, params localParams) int {\n+\tb, c := params.b, params.c\n",
+		`+func use() int { return local(1, localParams{b: 2, c: "x"}) }`,
+	} {
+		if isErr || !strings.Contains(out, want) {
+			t.Errorf("expected %q, got:\n%s", want, out)
+		}
+	}
+
+	out, isErr = call(t, Params{Dir: dir, Package: "./client", Function: "Client.Do", TypeName: "DoRequest", DryRun: true})
+	if want := `+	c.Do(client.DoRequest{Method: "GET", Path: "/"})`; isErr || !strings.Contains(out, want) {
+		t.Errorf("expected %q, got:\n%s", want, out)
+	}
+	if got := read(t, dir, "client/client.go"); got != clientSrc {
+		t.Error("dry run modified the file")
+	}
+}
+
+func TestHandler_Rejected(t *testing.T) {
+	dir := setup(t)
+	tests := []struct {
+		params Params
+		want   string
+	}{
+		{Params{Function: "local", Params: []string{"a", "c"}}, "must be consecutive"},
+		{Params{Function: "local", Params: []string{"d"}}, `has no parameter "d"`},
+		{Params{Function: "Fetch", Params: []string{"tags"}}, "variadic parameter tags"},
+		{Params{Function: "Hook"}, "used as a value"},
+		{Params{Function: "Client.Do"}, "ClientDoParams is already declared"},
+		{Params{Function: "Missing"}, "not found"},
+	}
+	for _, tt := range tests {
+		tt.params.Dir, tt.params.Package = dir, "./client"
+		out, isErr := call(t, tt.params)
+		if !isErr || !strings.Contains(out, tt.want) {
+			t.Errorf("%s: expected error %q, got: %s", tt.params.Function, tt.want, out)
+		}
+	}
+}
@@ -0,0 +1,764 @@
+// Package rename implements the rename_symbols tool, which applies a map of old to new symbol
+// names across a module in one pass, after checking the whole batch for conflicts.
+package rename
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["rename_symbols"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string            `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Renames map[string]string `json:"renames" jsonschema:"Old to new names. Keys are Name, pkg.Name, Type.Member or pkg.Type.Member, where pkg is a package name or import path and Member a method or field"`
+	DryRun  bool              `json:"dry_run,omitempty" jsonschema:"If true, return the plan and a diff without writing any files"`
+}
+
+// maxDiffLines caps the preview diff.
+const maxDiffLines = 400
+
+// Rename is one entry of the map, resolved to a declaration.
+type Rename struct {
+	Key       string   `json:"key"`
+	Old       string   `json:"old"`
+	New       string   `json:"new"`
+	Kind      string   `json:"kind,omitempty"` // func, type, const, var, method, field
+	Position  string   `json:"position,omitempty"`
+	Uses      int      `json:"uses"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+
+	obj types.Object
+	pkg *packages.Package
+}
+
+// Plan is the validated batch. Changes is nil when any rename has a conflict.
+type Plan struct {
+	Renames []*Rename
+	Changes shared.Changeset
+	Uses    int
+}
+
+// Conflicts returns the number of conflicts in the plan.
+func (p *Plan) Conflicts() int {
+	n := 0
+	for _, r := range p.Renames {
+		n += len(r.Conflicts)
+	}
+	return n
+}
+
+// Handler handles the rename_symbols tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if len(args.Renames) == 0 {
+		return errorResult("renames cannot be empty"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, "./...", true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	plan, err := Prepare(absDir, pkgs, args.Renames)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Batch Rename\n\n")
+	if n := plan.Conflicts(); n > 0 {
+		fmt.Fprintf(&sb, "❌ %d conflict(s); no file was changed. Fix the map and call again.\n\n", n)
+		writeTable(&sb, plan, true)
+		return textResult(sb.String()), nil, nil
+	}
+	if args.DryRun {
+		fmt.Fprintf(&sb, "Dry run: %d rename(s), %d reference(s) in %d file(s), no conflicts.\n\n", len(plan.Renames), plan.Uses, len(plan.Changes))
+		writeTable(&sb, plan, false)
+		sb.WriteString("## Diff\n\n```diff\n")
+		var lines []string
+		for _, path := range plan.Changes.Files() {
+			//nolint:gosec // G304: Path comes from the loaded package.
+			old, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, "--- "+rel(absDir, path), "+++ "+rel(absDir, path))
+			diff := textdiff.Unified(string(old), string(plan.Changes[path]))
+			lines = append(lines, strings.Split(strings.TrimSuffix(diff, "\n"), "\n")...)
+		}
+		if len(lines) > maxDiffLines {
+			lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxDiffLines))
+		}
+		sb.WriteString(strings.Join(lines, "\n") + "\n```\n")
+		return textResult(sb.String()), nil, nil
+	}
+	if err := plan.Changes.ApplyVerified(ctx, absDir, []string{"vet", "./..."}); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	fmt.Fprintf(&sb, "✅ Renamed %d symbol(s): %d reference(s) in %d file(s); `go vet ./...` passes.\n\n", len(plan.Renames), plan.Uses, len(plan.Changes))
+	writeTable(&sb, plan, false)
+	return textResult(sb.String()), nil, nil
+}
+
+// posKey identifies a declaration across the variants of a package loaded with tests.
+type posKey struct {
+	file   string
+	offset int
+}
+
+func keyOf(fset *token.FileSet, obj types.Object) posKey {
+	p := fset.Position(obj.Pos())
+	return posKey{p.Filename, p.Offset}
+}
+
+// Prepare resolves every key of renames among pkgs, which must be every package of the module
+// loaded with tests, checks the batch for conflicts and, when there are none, computes the
+// changes: declarations, references, and the leading word of doc comments.
+func Prepare(root string, pkgs []*packages.Package, renames map[string]string) (*Plan, error) {
+	plan := &Plan{}
+	keys := make([]string, 0, len(renames))
+	for k := range renames {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	byKey := make(map[posKey]*Rename)
+	for _, k := range keys {
+		r := &Rename{Key: k, New: renames[k]}
+		plan.Renames = append(plan.Renames, r)
+		resolve(pkgs, r)
+		if r.obj == nil {
+			continue
+		}
+		r.Old = r.obj.Name()
+		r.Position = shared.RelPosition(root, r.pkg.Fset.Position(r.obj.Pos()))
+		key := keyOf(r.pkg.Fset, r.obj)
+		if other := byKey[key]; other != nil {
+			r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` names the same symbol", other.Key))
+			continue
+		}
+		byKey[key] = r
+	}
+
+	c := &checker{root: root, pkgs: pkgs, byKey: byKey}
+	refs := c.references()
+	for _, r := range plan.Renames {
+		if r.obj == nil {
+			continue
+		}
+		r.Uses = len(refs[keyOf(r.pkg.Fset, r.obj)])
+		c.check(r, refs[keyOf(r.pkg.Fset, r.obj)])
+	}
+	if plan.Conflicts() > 0 {
+		return plan, nil
+	}
+
+	changes, uses, err := c.rewrite(refs)
+	if err != nil {
+		return nil, err
+	}
+	plan.Changes, plan.Uses = changes, uses
+	return plan, nil
+}
+
+// resolve finds the declaration named by r.Key, or records why it cannot.
+func resolve(pkgs []*packages.Package, r *Rename) {
+	type query struct{ pkg, name, member string }
+	var queries []query
+	pkgPart, rest := "", r.Key
+	if i := strings.LastIndex(r.Key, "/"); i >= 0 {
+		// An import path: the package ends at the first dot after the last slash.
+		j := strings.Index(r.Key[i:], ".")
+		if j < 0 {
+			r.Conflicts = append(r.Conflicts, "the key names a package, not a symbol")
+			return
+		}
+		pkgPart, rest = r.Key[:i+j], r.Key[i+j+1:]
+	}
+	parts := strings.Split(rest, ".")
+	switch {
+	case pkgPart != "" && len(parts) == 1:
+		queries = []query{{pkgPart, parts[0], ""}}
+	case pkgPart != "" && len(parts) == 2:
+		queries = []query{{pkgPart, parts[0], parts[1]}}
+	case len(parts) == 1:
+		queries = []query{{"", parts[0], ""}}
+	case len(parts) == 2:
+		queries = []query{{parts[0], parts[1], ""}, {"", parts[0], parts[1]}}
+	case len(parts) == 3:
+		queries = []query{{parts[0], parts[1], parts[2]}}
+	default:
+		r.Conflicts = append(r.Conflicts, "the key must be Name, pkg.Name, Type.Member or pkg.Type.Member")
+		return
+	}
+
+	type found struct {
+		obj types.Object
+		pkg *packages.Package
+	}
+	seen := make(map[posKey]found)
+	var order []posKey
+	for _, q := range queries {
+		for _, pkg := range pkgs {
+			if pkg.Types == nil || (q.pkg != "" && pkg.Name != q.pkg && pkg.PkgPath != q.pkg && !strings.HasSuffix(pkg.PkgPath, "/"+q.pkg)) {
+				continue
+			}
+			obj := pkg.Types.Scope().Lookup(q.name)
+			if obj != nil && q.member != "" {
+				obj = member(obj, q.member)
+			}
+			if obj == nil {
+				continue
+			}
+			k := keyOf(pkg.Fset, obj)
+			if _, ok := seen[k]; !ok {
+				seen[k] = found{obj, pkg}
+				order = append(order, k)
+			}
+		}
+	}
+	switch len(order) {
+	case 0:
+		r.Conflicts = append(r.Conflicts, "no such symbol in the module")
+	case 1:
+		f := seen[order[0]]
+		r.obj, r.pkg = f.obj, f.pkg
+		r.Kind = kind(f.obj)
+	default:
+		var where []string
+		for _, k := range order {
+			f := seen[k]
+			where = append(where, fmt.Sprintf("%s (%s)", f.pkg.PkgPath, filepath.Base(f.pkg.Fset.Position(f.obj.Pos()).String())))
+		}
+		r.Conflicts = append(r.Conflicts, "ambiguous: declared in "+strings.Join(where, ", ")+"; qualify the key with the package")
+	}
+}
+
+// member returns the method or field name declared directly by the named type obj, or nil.
+func member(obj types.Object, name string) types.Object {
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == name {
+			return m
+		}
+	}
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Name() == name {
+				return f
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumExplicitMethods(); i++ {
+			if m := u.ExplicitMethod(i); m.Name() == name {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func kind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if o.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	}
+	return "symbol"
+}
+
+// ref is a reference to a renamed symbol.
+type ref struct {
+	pkg *packages.Package
+	id  *ast.Ident
+}
+
+type checker struct {
+	root  string
+	pkgs  []*packages.Package
+	byKey map[posKey]*Rename
+}
+
+// finalName returns the name obj will have after the batch.
+func (c *checker) finalName(fset *token.FileSet, obj types.Object) string {
+	if r := c.byKey[keyOf(fset, obj)]; r != nil {
+		return r.New
+	}
+	return obj.Name()
+}
+
+// references returns the identifiers referring to each renamed symbol in every package variant,
+// the uses of fields embedding a renamed type included.
+func (c *checker) references() map[posKey][]ref {
+	refs := make(map[posKey][]ref)
+	seen := make(map[posKey]bool)
+	for _, pkg := range c.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if v, ok := obj.(*types.Var); ok && v.Embedded() {
+				if named := namedOf(v.Type()); named != nil {
+					obj = named.Obj()
+				}
+			}
+			k := keyOf(pkg.Fset, obj)
+			if c.byKey[k] == nil {
+				continue
+			}
+			at := pkg.Fset.Position(id.Pos())
+			if seen[posKey{at.Filename, at.Offset}] {
+				continue
+			}
+			seen[posKey{at.Filename, at.Offset}] = true
+			refs[k] = append(refs[k], ref{pkg, id})
+		}
+	}
+	return refs
+}
+
+func namedOf(t types.Type) *types.Named {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// check records the conflicts and notes of r.
+func (c *checker) check(r *Rename, refs []ref) {
+	name := r.New
+	switch {
+	case !token.IsIdentifier(name) || name == "_":
+		r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` is not a valid identifier", name))
+		return
+	case name == r.Old:
+		r.Conflicts = append(r.Conflicts, "the new name is the old name")
+		return
+	}
+
+	if !token.IsExported(name) && token.IsExported(r.Old) {
+		for _, ref := range refs {
+			if ref.pkg.Types.Path() != r.obj.Pkg().Path() {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf("unexported, it is no longer visible to %s, which uses it at %s", ref.pkg.PkgPath, c.pos(ref.pkg, ref.id.Pos())))
+				break
+			}
+		}
+	}
+
+	switch obj := r.obj.(type) {
+	case *types.Func:
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			c.checkMember(r, recv.Type())
+			c.checkInterfaces(r)
+			return
+		}
+	case *types.Var:
+		if obj.IsField() {
+			if obj.Embedded() {
+				r.Conflicts = append(r.Conflicts, "embedded field: rename its type instead")
+				return
+			}
+			c.checkField(r)
+			return
+		}
+	}
+	c.checkPackageLevel(r, refs)
+}
+
+// checkPackageLevel checks a rename in a package scope: clashes with other declarations,
+// imports, and locals that would capture the references.
+func (c *checker) checkPackageLevel(r *Rename, refs []ref) {
+	name := r.New
+	if types.Universe.Lookup(name) != nil {
+		r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` would shadow the predeclared identifier", name))
+	}
+	path := r.obj.Pkg().Path()
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil || pkg.Types.Path() != path {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, other := range scope.Names() {
+			obj := scope.Lookup(other)
+			if keyOf(pkg.Fset, obj) != keyOf(r.pkg.Fset, r.obj) && c.finalName(pkg.Fset, obj) == name {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` is already declared at %s", name, c.pos(pkg, obj.Pos())))
+				return
+			}
+		}
+		for _, file := range pkg.Syntax {
+			for _, imp := range file.Imports {
+				if importedName(pkg, imp) == name {
+					r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` is the name of an import in %s", name, rel(c.root, pkg.Fset.Position(file.Pos()).Filename)))
+					return
+				}
+			}
+		}
+	}
+	for _, ref := range refs {
+		if ref.pkg.Types.Path() != path {
+			continue // qualified with the package name
+		}
+		scope := ref.pkg.Types.Scope().Innermost(ref.id.Pos())
+		if scope == nil {
+			continue
+		}
+		if _, other := scope.LookupParent(name, ref.id.Pos()); other != nil && other.Parent() != ref.pkg.Types.Scope() && c.finalName(ref.pkg.Fset, other) == name {
+			r.Conflicts = append(r.Conflicts, fmt.Sprintf("a local `%s` would capture the reference at %s", name, c.pos(ref.pkg, ref.id.Pos())))
+			return
+		}
+	}
+}
+
+// checkMember checks that no other method or field of the receiver type ends up with the name.
+func (c *checker) checkMember(r *Rename, recv types.Type) {
+	named := namedOf(recv)
+	if named == nil {
+		return
+	}
+	for _, sel := range []*types.MethodSet{types.NewMethodSet(types.NewPointer(named)), types.NewMethodSet(named)} {
+		for i := 0; i < sel.Len(); i++ {
+			m := sel.At(i).Obj()
+			if keyOf(r.pkg.Fset, m) != keyOf(r.pkg.Fset, r.obj) && c.finalName(r.pkg.Fset, m) == r.New {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` already has a method `%s`", named.Obj().Name(), r.New))
+				return
+			}
+		}
+	}
+	if st, ok := named.Underlying().(*types.Struct); ok {
+		for i := 0; i < st.NumFields(); i++ {
+			if f := st.Field(i); keyOf(r.pkg.Fset, f) != keyOf(r.pkg.Fset, r.obj) && c.finalName(r.pkg.Fset, f) == r.New {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` already has a field `%s`", named.Obj().Name(), r.New))
+				return
+			}
+		}
+	}
+}
+
+// checkField checks a field rename against the other fields and the methods of its struct.
+func (c *checker) checkField(r *Rename) {
+	var owner *types.Named
+	var tag string
+	var encoders []string // keys used in the tags of the struct
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil || pkg.Types.Path() != r.obj.Pkg().Path() {
+			continue
+		}
+		for _, n := range pkg.Types.Scope().Names() {
+			tn, ok := pkg.Types.Scope().Lookup(n).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			st, ok := named.Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < st.NumFields(); i++ {
+				if keyOf(pkg.Fset, st.Field(i)) == keyOf(r.pkg.Fset, r.obj) {
+					owner = named
+					tag = st.Tag(i)
+					for j := 0; j < st.NumFields(); j++ {
+						for _, enc := range []string{"json", "yaml", "xml", "toml", "db"} {
+							if _, ok := reflect.StructTag(st.Tag(j)).Lookup(enc); ok && !slices.Contains(encoders, enc) {
+								encoders = append(encoders, enc)
+							}
+						}
+					}
+				}
+			}
+		}
+		if owner != nil {
+			break
+		}
+	}
+	if owner == nil {
+		return // a field of an anonymous struct
+	}
+	c.checkMember(r, owner)
+	if !token.IsExported(r.Old) {
+		return
+	}
+	for _, enc := range encoders {
+		if _, ok := reflect.StructTag(tag).Lookup(enc); !ok {
+			r.Notes = append(r.Notes, fmt.Sprintf("no `%s` tag: the encoded key changes from `%s`", enc, r.Old))
+		}
+	}
+}
+
+// checkInterfaces checks that renaming a method keeps the module's types implementing the
+// module's interfaces: the implementations and the interface method must be renamed together.
+func (c *checker) checkInterfaces(r *Rename) {
+	fn := r.obj.(*types.Func)
+	recv := fn.Type().(*types.Signature).Recv().Type()
+	if types.IsInterface(recv) {
+		// An interface method: every implementation in the module must follow.
+		iface, _ := recv.Underlying().(*types.Interface)
+		for _, named := range c.namedTypes(false) {
+			if !implements(named, iface) {
+				continue
+			}
+			m, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, named.Obj().Pkg(), r.Old)
+			if m == nil || c.finalName(r.pkg.Fset, m) == r.New {
+				continue
+			}
+			r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` implements `%s` through `%s`: add `%s.%s.%s` to the map",
+				named.Obj().Name(), namedOf(recv).Obj().Name(), r.Old, named.Obj().Pkg().Name(), named.Obj().Name(), r.Old))
+		}
+		return
+	}
+	named := namedOf(recv)
+	if named == nil {
+		return
+	}
+	for _, ifaceNamed := range c.namedTypes(true) {
+		iface := ifaceNamed.Underlying().(*types.Interface)
+		if !implements(named, iface) {
+			continue
+		}
+		m, _, _ := types.LookupFieldOrMethod(ifaceNamed, false, ifaceNamed.Obj().Pkg(), r.Old)
+		if m == nil || c.finalName(r.pkg.Fset, m) == r.New {
+			continue
+		}
+		r.Conflicts = append(r.Conflicts, fmt.Sprintf("`%s` implements `%s` through `%s`: rename `%s.%s.%s` too, or keep the name",
+			named.Obj().Name(), ifaceNamed.Obj().Name(), r.Old, ifaceNamed.Obj().Pkg().Name(), ifaceNamed.Obj().Name(), r.Old))
+	}
+	for _, std := range []string{"Error", "String", "Read", "Write", "Close", "ServeHTTP", "MarshalJSON", "UnmarshalJSON", "Len", "Less", "Swap", "Unwrap", "Is", "As"} {
+		if r.Old == std {
+			r.Notes = append(r.Notes, fmt.Sprintf("`%s` is the method of a standard interface; values of `%s` stop satisfying it", r.Old, named.Obj().Name()))
+		}
+	}
+}
+
+// namedTypes returns the package-level named types of the module: the interfaces when ifaces is
+// true, the other types otherwise.
+func (c *checker) namedTypes(ifaces bool) []*types.Named {
+	seen := make(map[posKey]bool)
+	var out []*types.Named
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, n := range scope.Names() {
+			tn, ok := scope.Lookup(n).(*types.TypeName)
+			if !ok || tn.IsAlias() || seen[keyOf(pkg.Fset, tn)] {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || types.IsInterface(named) != ifaces || named.TypeParams().Len() > 0 {
+				continue
+			}
+			seen[keyOf(pkg.Fset, tn)] = true
+			out = append(out, named)
+		}
+	}
+	return out
+}
+
+func implements(named *types.Named, iface *types.Interface) bool {
+	if iface.NumMethods() == 0 {
+		return false
+	}
+	return types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface)
+}
+
+// rewrite computes the changes of the batch.
+func (c *checker) rewrite(refs map[posKey][]ref) (shared.Changeset, int, error) {
+	edits := make(map[string]map[int]shared.TextEdit)
+	add := func(fset *token.FileSet, pos token.Pos, old, text string) {
+		p := fset.Position(pos)
+		if edits[p.Filename] == nil {
+			edits[p.Filename] = make(map[int]shared.TextEdit)
+		}
+		edits[p.Filename][p.Offset] = shared.TextEdit{Start: p.Offset, End: p.Offset + len(old), New: text}
+	}
+	uses := 0
+	for k, r := range c.byKey {
+		add(r.pkg.Fset, r.obj.Pos(), r.Old, r.New)
+		if doc := c.docOf(r); doc != nil && strings.HasPrefix(doc.List[0].Text, "// "+r.Old+" ") {
+			add(r.pkg.Fset, doc.List[0].Pos()+3, r.Old, r.New)
+		}
+		for _, ref := range refs[k] {
+			add(ref.pkg.Fset, ref.id.Pos(), ref.id.Name, r.New)
+			uses++
+		}
+	}
+
+	changes := make(shared.Changeset)
+	for filename, byStart := range edits {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		var list []shared.TextEdit
+		for _, e := range byStart {
+			list = append(list, e)
+		}
+		out, err := shared.ApplyEdits(src, list)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+		changes[filename] = out
+	}
+	return changes, uses, nil
+}
+
+// docOf returns the doc comment of the declaration of r.
+func (c *checker) docOf(r *Rename) *ast.CommentGroup {
+	for _, file := range r.pkg.Syntax {
+		if file.Pos() > r.obj.Pos() || file.End() < r.obj.Pos() {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, r.obj.Pos(), r.obj.Pos())
+		for i, n := range path {
+			var doc *ast.CommentGroup
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				doc = n.Doc
+			case *ast.Field:
+				doc = n.Doc
+			case *ast.ValueSpec:
+				doc = n.Doc
+			case *ast.TypeSpec:
+				doc = n.Doc
+			case *ast.GenDecl:
+				if len(n.Specs) == 1 {
+					doc = n.Doc
+				}
+			default:
+				continue
+			}
+			if doc != nil || i+1 == len(path) {
+				return doc
+			}
+			if _, ok := path[i+1].(*ast.GenDecl); !ok {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func importedName(pkg *packages.Package, imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	if obj, ok := pkg.TypesInfo.Implicits[imp].(*types.PkgName); ok {
+		return obj.Name()
+	}
+	return ""
+}
+
+func (c *checker) pos(pkg *packages.Package, p token.Pos) string {
+	return shared.RelPosition(c.root, pkg.Fset.Position(p))
+}
+
+func writeTable(sb *strings.Builder, plan *Plan, problems bool) {
+	sb.WriteString("| Key | Rename | Kind | Declared at | References | ")
+	if problems {
+		sb.WriteString("Problem |\n|---|---|---|---|---:|---|\n")
+	} else {
+		sb.WriteString("Notes |\n|---|---|---|---|---:|---|\n")
+	}
+	for _, r := range plan.Renames {
+		status := strings.Join(r.Notes, "; ")
+		if problems {
+			status = "✅ ok"
+			if len(r.Conflicts) > 0 {
+				status = "❌ " + strings.Join(r.Conflicts, "; ")
+			}
+		}
+		rename := "→ `" + r.New + "`"
+		if r.Old != "" {
+			rename = "`" + r.Old + "` " + rename
+		}
+		fmt.Fprintf(sb, "| `%s` | %s | %s | %s | %d | %s |\n", r.Key, rename, r.Kind, r.Position, r.Uses, status)
+	}
+	sb.WriteString("\n")
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
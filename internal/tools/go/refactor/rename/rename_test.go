@@ -0,0 +1,232 @@
+package rename
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const storeSrc = `package store
+
+import "encoding/json"
+
+// Getter reads values.
+type Getter interface {
+	GetValue(key string) string
+}
+
+// MemStore keeps values in memory.
+type MemStore struct {
+	Data map[string]string ` + "`json:\"data\"`" + `
+	Hits int
+}
+
+// GetValue returns the value of key.
+func (m *MemStore) GetValue(key string) string {
+	m.Hits++
+	return m.Data[key]
+}
+
+// NewMemStore creates an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{Data: map[string]string{}}
+}
+
+const defaultLimit = 10
+
+func count(items []string) int {
+	total := 0
+	for range items {
+		total += defaultLimit
+	}
+	return total
+}
+
+func dump(m *MemStore) string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+`
+
+const storeTest = `package store_test
+
+import (
+	"testing"
+
+	"example.com/app/store"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemStore()
+	if s.GetValue("x") != "" {
+		t.Fatal("unexpected value")
+	}
+}
+`
+
+const apiSrc = `package api
+
+import "example.com/app/store"
+
+type Service struct {
+	store.Getter
+	backing *store.MemStore
+}
+
+func New() *Service {
+	s := store.NewMemStore()
+	return &Service{Getter: s, backing: s}
+}
+
+func (s *Service) Lookup(key string) string {
+	return s.Getter.GetValue(key) + s.backing.GetValue(key)
+}
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":              testutil.GoMod("example.com/app"),
+		"store/store.go":      storeSrc,
+		"store/store_test.go": storeTest,
+		"api/api.go":          apiSrc,
+	})
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func read(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHandler_Apply(t *testing.T) {
+	dir := writeModule(t)
+	text, isErr := call(t, Params{Dir: dir, Renames: map[string]string{
+		"store.MemStore":          "MemoryStore",
+		"NewMemStore":             "NewMemoryStore",
+		"Getter.GetValue":         "Get",
+		"store.MemStore.GetValue": "Get",
+		"MemStore.Hits":           "HitCount",
+		"store.Getter":            "Reader",
+	}})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"✅ Renamed 6 symbol(s)",
+		"| `MemStore.Hits` | `Hits` → `HitCount` | field | store/store.go:13:2 | 1 | no `json` tag: the encoded key changes from `Hits` |",
+		"| `store.MemStore` | `MemStore` → `MemoryStore` | type |",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	store := read(t, dir, "store/store.go")
+	for _, want := range []string{
+		"// Reader reads values.\ntype Reader interface {\n\tGet(key string) string",
+		"// MemoryStore keeps values in memory.\ntype MemoryStore struct",
+		"HitCount int",
+		"// Get returns the value of key.\nfunc (m *MemoryStore) Get(key string) string {\n\tm.HitCount++",
+		"// NewMemoryStore creates an empty MemStore.\nfunc NewMemoryStore() *MemoryStore {\n\treturn &MemoryStore{",
+		"func dump(m *MemoryStore) string",
+	} {
+		if !strings.Contains(store, want) {
+			t.Errorf("store.go missing %q:\n%s", want, store)
+		}
+	}
+	api := read(t, dir, "api/api.go")
+	for _, want := range []string{
+		"\tstore.Reader\n\tbacking *store.MemoryStore",
+		"return &Service{Reader: s, backing: s}",
+		"return s.Reader.Get(key) + s.backing.Get(key)",
+	} {
+		if !strings.Contains(api, want) {
+			t.Errorf("api.go missing %q:\n%s", want, api)
+		}
+	}
+	if test := read(t, dir, "store/store_test.go"); !strings.Contains(test, "s := store.NewMemoryStore()\n\tif s.Get(\"x\")") {
+		t.Errorf("external test not updated:\n%s", test)
+	}
+}
+
+func TestHandler_Conflicts(t *testing.T) {
+	dir := writeModule(t)
+	text, isErr := call(t, Params{Dir: dir, Renames: map[string]string{
+		"MemStore.GetValue": "Get",
+		"defaultLimit":      "total",
+		"NewMemStore":       "dump",
+		"store.MemStore":    "memStore",
+		"Missing":           "Found",
+		"count":             "len",
+		"store.Getter":      "Reader",
+	}})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"❌ 7 conflict(s); no file was changed.",
+		"| `MemStore.GetValue` | `GetValue` → `Get` | method | store/store.go:17:20 | 2 | ❌ `MemStore` implements `Getter` through `GetValue`: rename `store.Getter.GetValue` too, or keep the name |",
+		"❌ a local `total` would capture the reference at store/store.go:32:12",
+		"❌ unexported, it is no longer visible to example.com/app/api, which uses it at api/api.go:11:13; `dump` is already declared at store/store.go:37:6 |",
+		"❌ unexported, it is no longer visible to example.com/app/api, which uses it at api/api.go:7:17 |",
+		"| `Missing` | → `Found` |  |  | 0 | ❌ no such symbol in the module |",
+		"❌ `len` would shadow the predeclared identifier",
+		"| `store.Getter` | `Getter` → `Reader` | type | store/store.go:6:6 | 3 | ✅ ok |",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if got := read(t, dir, "store/store.go"); got != storeSrc {
+		t.Errorf("store.go changed despite conflicts:\n%s", got)
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := writeModule(t)
+	text, isErr := call(t, Params{Dir: dir, DryRun: true, Renames: map[string]string{"example.com/app/store.NewMemStore": "Open"}})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"Dry run: 1 rename(s), 2 reference(s) in 3 file(s), no conflicts.",
+		"--- api/api.go\n+++ api/api.go",
+		"-\ts := store.NewMemStore()\n+\ts := store.Open()",
+		"-// NewMemStore creates an empty MemStore.\n-func NewMemStore() *MemStore {\n+// Open creates an empty MemStore.\n+func Open() *MemStore {",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if got := read(t, dir, "store/store.go"); got != storeSrc {
+		t.Errorf("dry run wrote store.go:\n%s", got)
+	}
+}
+
+func TestHandler_Ambiguous(t *testing.T) {
+	dir := writeModule(t)
+	if err := os.WriteFile(filepath.Join(dir, "api", "limits.go"), []byte("package api\n\nconst defaultLimit = 5\n\nvar _ = defaultLimit\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	text, _ := call(t, Params{Dir: dir, Renames: map[string]string{"defaultLimit": "limit"}})
+	if !strings.Contains(text, "ambiguous: declared in ") || !strings.Contains(text, "qualify the key with the package") {
+		t.Errorf("expected an ambiguity, got:\n%s", text)
+	}
+}
@@ -0,0 +1,497 @@
+// Package replacedep implements the replace_dependency tool, which migrates a codebase from one
+// library to another using a mapping of symbol equivalences, then tidies go.mod and verifies the
+// build.
+package replacedep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["replace_dependency"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module root. Always pass absolute paths in multi-root workspaces."`
+	Mapping string `json:"mapping,omitempty" jsonschema:"Path to a JSON mapping file, absolute or relative to dir. See the tool description for the format."`
+	From    string `json:"from,omitempty" jsonschema:"Package to migrate away from when using a built-in mapping (e.g. github.com/pkg/errors)"`
+	DryRun  bool   `json:"dry_run,omitempty" jsonschema:"If true, list the rewrites without writing any files"`
+}
+
+// Mapping describes how to replace the exported symbols of one package.
+//
+// Each symbol maps to a Go expression. A plain replacement such as "errors.New" substitutes every
+// reference to the symbol. A template that refers to call arguments as $1, $2, ... (or $2... for
+// the second argument onwards) rewrites calls: "fmt.Errorf(\"%s: %w\", $2, $1)" turns
+// Wrap(err, "msg") into fmt.Errorf("%s: %w", "msg", err).
+type Mapping struct {
+	From    string            `json:"from"`              // import path of the package being replaced
+	To      string            `json:"to,omitempty"`      // module@version to require; empty if the targets are in the standard library
+	Imports []string          `json:"imports,omitempty"` // import paths the replacements refer to
+	Symbols map[string]string `json:"symbols"`
+	Notes   []string          `json:"notes,omitempty"` // semantic differences to review after migrating
+}
+
+// Builtin holds ready-made mappings, keyed by the package they replace.
+var Builtin = map[string]*Mapping{
+	"github.com/pkg/errors": {
+		From:    "github.com/pkg/errors",
+		Imports: []string{"errors", "fmt"},
+		Symbols: map[string]string{
+			"New":          "errors.New",
+			"Errorf":       "fmt.Errorf",
+			"Is":           "errors.Is",
+			"As":           "errors.As",
+			"Unwrap":       "errors.Unwrap",
+			"Wrap":         `fmt.Errorf("%s: %w", $2, $1)`,
+			"Wrapf":        `fmt.Errorf($2+": %w", $3..., $1)`,
+			"WithMessage":  `fmt.Errorf("%s: %w", $2, $1)`,
+			"WithMessagef": `fmt.Errorf($2+": %w", $3..., $1)`,
+			"WithStack":    "$1",
+		},
+		Notes: []string{
+			"Wrap, Wrapf, WithMessage and WithMessagef return nil for a nil error; fmt.Errorf does not. Check call sites that may wrap a nil error.",
+			"Stack traces are no longer recorded, so %+v no longer prints one.",
+			"errors.Cause has no standard equivalent; use errors.Is or errors.As instead.",
+		},
+	},
+}
+
+// Site is one rewritten reference.
+type Site struct {
+	Pos string `json:"pos"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Migration is the set of changes for one replacement.
+type Migration struct {
+	Mapping  *Mapping
+	Changes  shared.Changeset
+	Files    int // Go files rewritten
+	Sites    []Site
+	Unmapped map[string][]string // symbol to the positions that use it
+}
+
+var (
+	skippedDirs = map[string]bool{".git": true, "vendor": true, "testdata": true, "node_modules": true}
+	argRe       = regexp.MustCompile(`\$(\d+)(\.\.\.)?`)
+)
+
+// Handler handles the replace_dependency tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	m, err := loadMapping(absDir, args)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	mig, err := Prepare(absDir, m)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(mig.Sites) == 0 && len(mig.Unmapped) == 0 {
+		return textResult(fmt.Sprintf("No imports of `%s` found in %s.", m.From, absDir)), nil, nil
+	}
+	if len(mig.Unmapped) > 0 {
+		return errorResult(render(mig, args.DryRun)), nil, nil
+	}
+	if args.DryRun {
+		return textResult(render(mig, true)), nil, nil
+	}
+
+	// go.mod and go.sum join the changeset unchanged so that a failed tidy or build restores them.
+	for _, name := range []string{"go.mod", "go.sum"} {
+		path := filepath.Join(absDir, name)
+		if content, err := os.ReadFile(path); err == nil {
+			mig.Changes[path] = content
+		}
+	}
+	var verify [][]string
+	if m.To != "" {
+		verify = append(verify, []string{"get", m.To})
+	}
+	verify = append(verify, []string{"mod", "tidy"}, []string{"build", "./..."})
+	if err := mig.Changes.ApplyVerified(ctx, absDir, verify...); err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return textResult(render(mig, false)), nil, nil
+}
+
+func loadMapping(dir string, args Params) (*Mapping, error) {
+	if args.Mapping == "" {
+		if m, ok := Builtin[args.From]; ok {
+			return m, nil
+		}
+		var known []string
+		for from := range Builtin {
+			known = append(known, from)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("no built-in mapping for %q; pass a mapping file (built-in mappings: %s)", args.From, strings.Join(known, ", "))
+	}
+	path := args.Mapping
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %w", err)
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid mapping %s: %w", args.Mapping, err)
+	}
+	if m.From == "" || len(m.Symbols) == 0 {
+		return nil, fmt.Errorf("invalid mapping %s: 'from' and 'symbols' are required", args.Mapping)
+	}
+	if args.From != "" && args.From != m.From {
+		return nil, fmt.Errorf("mapping %s replaces %s, not %s", args.Mapping, m.From, args.From)
+	}
+	return &m, nil
+}
+
+// Prepare walks the module at root and rewrites every reference to a symbol of m.From. Nested
+// modules are skipped. References to symbols the mapping does not cover are collected in
+// Unmapped; the migration is only complete when there are none.
+func Prepare(root string, m *Mapping) (*Migration, error) {
+	mig := &Migration{Mapping: m, Changes: make(shared.Changeset), Unmapped: make(map[string][]string)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := mig.rewriteFile(root, path, src)
+		if err != nil {
+			return err
+		}
+		if out != nil {
+			mig.Changes[path] = out
+			mig.Files++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return mig, nil
+}
+
+// rewriter rewrites the references in one file. Matches are kept in source order; a template
+// call's arguments may contain further matches, which are rewritten into the template.
+type rewriter struct {
+	src     []byte
+	tf      *token.File
+	m       *Mapping
+	matches []match
+}
+
+type match struct {
+	start, end int
+	sym        string
+	call       *ast.CallExpr // set when a template rewrites the whole call
+}
+
+// rewriteFile returns the migrated source of one file, or nil if it does not import m.From.
+func (mig *Migration) rewriteFile(root, path string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		// Files that do not parse are left for the build to report.
+		return nil, nil
+	}
+	var spec *ast.ImportSpec
+	for _, s := range file.Imports {
+		if p, _ := strconv.Unquote(s.Path.Value); p == mig.Mapping.From {
+			spec = s
+		}
+	}
+	if spec == nil {
+		return nil, nil
+	}
+	name := filepath.Base(mig.Mapping.From)
+	if spec.Name != nil {
+		name = spec.Name.Name
+	}
+	pos := func(n ast.Node) string { return shared.RelPosition(root, fset.Position(n.Pos())) }
+	if name == "." {
+		return nil, fmt.Errorf("%s: dot imports of %s are not supported", pos(spec), mig.Mapping.From)
+	}
+
+	r := &rewriter{src: src, tf: fset.File(file.Pos()), m: mig.Mapping}
+	isOld := func(e ast.Expr) (string, bool) {
+		sel, ok := e.(*ast.SelectorExpr)
+		if !ok {
+			return "", false
+		}
+		// The import is only in scope when the identifier does not resolve to a local declaration.
+		if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+			return sel.Sel.Name, true
+		}
+		return "", false
+	}
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.CallExpr:
+			sym, ok := isOld(n.Fun)
+			if !ok || !isTemplate(r.m.Symbols[sym]) {
+				return true
+			}
+			if n.Ellipsis.IsValid() {
+				mig.Unmapped[sym+" (with a ... argument)"] = append(mig.Unmapped[sym+" (with a ... argument)"], pos(n))
+				return true
+			}
+			r.matches = append(r.matches, match{start: r.off(n.Pos()), end: r.off(n.End()), sym: sym, call: n})
+			for _, arg := range n.Args {
+				ast.Inspect(arg, visit)
+			}
+			return false
+		case *ast.SelectorExpr:
+			sym, ok := isOld(n)
+			if !ok {
+				return true
+			}
+			if repl, ok := r.m.Symbols[sym]; !ok || repl == "" || isTemplate(repl) {
+				mig.Unmapped[sym] = append(mig.Unmapped[sym], pos(n))
+				return false
+			}
+			r.matches = append(r.matches, match{start: r.off(n.Pos()), end: r.off(n.End()), sym: sym})
+			return false
+		}
+		return true
+	}
+	ast.Inspect(file, visit)
+
+	for _, mt := range r.matches {
+		mig.Sites = append(mig.Sites, Site{
+			Pos: shared.RelPosition(root, fset.Position(r.tf.Pos(mt.start))),
+			Old: string(src[mt.start:mt.end]),
+			New: r.replace(mt),
+		})
+	}
+	out := []byte(r.text(0, len(src)))
+	return fixImports(path, out, spec, mig.Mapping)
+}
+
+func (r *rewriter) off(p token.Pos) int { return r.tf.Offset(p) }
+
+// text returns src[start:end] with every match in it rewritten.
+func (r *rewriter) text(start, end int) string {
+	var sb strings.Builder
+	cursor := start
+	for _, mt := range r.matches {
+		if mt.start < cursor || mt.end > end {
+			continue
+		}
+		sb.Write(r.src[cursor:mt.start])
+		sb.WriteString(r.replace(mt))
+		cursor = mt.end
+	}
+	sb.Write(r.src[cursor:end])
+	return sb.String()
+}
+
+func (r *rewriter) replace(mt match) string {
+	repl := r.m.Symbols[mt.sym]
+	if mt.call == nil {
+		return repl
+	}
+	args := make([]string, len(mt.call.Args))
+	for i, a := range mt.call.Args {
+		args[i] = r.text(r.off(a.Pos()), r.off(a.End()))
+	}
+	return expand(repl, args)
+}
+
+func isTemplate(repl string) bool { return argRe.MatchString(repl) }
+
+// expand substitutes call arguments into a template. A missing argument expands to nothing, and
+// an empty $N... also drops the comma that separated it.
+func expand(tmpl string, args []string) string {
+	arg := func(n int, rest bool) string {
+		if n < 1 || n > len(args) {
+			return ""
+		}
+		if rest {
+			return strings.Join(args[n-1:], ", ")
+		}
+		return args[n-1]
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range argRe.FindAllStringSubmatchIndex(tmpl, -1) {
+		n, _ := strconv.Atoi(tmpl[loc[2]:loc[3]])
+		rest := loc[4] >= 0
+		val := arg(n, rest)
+		prefix := tmpl[last:loc[0]]
+		if rest && val == "" {
+			prefix = strings.TrimSuffix(strings.TrimRight(prefix, " "), ",")
+			if prefix == tmpl[last:loc[0]] {
+				// No comma before: drop the one after instead.
+				last = loc[1]
+				for last < len(tmpl) && (tmpl[last] == ',' || tmpl[last] == ' ') {
+					last++
+				}
+				sb.WriteString(prefix)
+				continue
+			}
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(val)
+		last = loc[1]
+	}
+	sb.WriteString(tmpl[last:])
+	return sb.String()
+}
+
+// fixImports removes the old import and adds the imports the mapping refers to. Imports that end
+// up unused are removed when the changeset is formatted with goimports.
+func fixImports(path string, src []byte, old *ast.ImportSpec, m *Mapping) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("rewriting %s produced invalid Go: %w", filepath.Base(path), err)
+	}
+	if old.Name != nil {
+		astutil.DeleteNamedImport(fset, file, old.Name.Name, m.From)
+	} else {
+		astutil.DeleteImport(fset, file, m.From)
+	}
+	for _, imp := range m.Imports {
+		astutil.AddImport(fset, file, imp)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", filepath.Base(path), err)
+	}
+	return buf.Bytes(), nil
+}
+
+func render(mig *Migration, dryRun bool) string {
+	var sb strings.Builder
+	m := mig.Mapping
+	target := m.To
+	if target == "" {
+		target = "the standard library"
+	}
+	switch {
+	case len(mig.Unmapped) > 0:
+		fmt.Fprintf(&sb, "# Dependency Replacement Blocked: `%s` → %s\n\n", m.From, target)
+	case dryRun:
+		fmt.Fprintf(&sb, "# Dependency Replacement (dry run): `%s` → %s\n\n", m.From, target)
+	default:
+		fmt.Fprintf(&sb, "# Dependency Replaced: `%s` → %s\n\n", m.From, target)
+	}
+	fmt.Fprintf(&sb, "- Files: %d\n- Rewrites: %d\n\n", mig.Files, len(mig.Sites))
+
+	if len(mig.Unmapped) > 0 {
+		var syms []string
+		for sym := range mig.Unmapped {
+			syms = append(syms, sym)
+		}
+		sort.Strings(syms)
+		sb.WriteString("## ❌ Symbols Without a Mapping\n\nThe dependency cannot be removed while these are used. Add them to the mapping, or rewrite these call sites by hand first. Nothing was written.\n\n")
+		for _, sym := range syms {
+			fmt.Fprintf(&sb, "- `%s`: %s\n", sym, strings.Join(mig.Unmapped[sym], ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if dryRun || len(mig.Unmapped) > 0 {
+		sb.WriteString("## Rewrites\n\n")
+		for _, s := range mig.Sites {
+			fmt.Fprintf(&sb, "- %s: `%s` → `%s`\n", s.Pos, s.Old, s.New)
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("✅ Applied and verified: `go mod tidy` and `go build ./...` succeeded.\n\n")
+	}
+
+	if len(m.Notes) > 0 {
+		sb.WriteString("## ⚠️ Review\n\n")
+		for _, n := range m.Notes {
+			fmt.Fprintf(&sb, "- %s\n", n)
+		}
+		sb.WriteString("\n")
+	}
+	if dryRun && len(mig.Unmapped) == 0 {
+		sb.WriteString("Run again without `dry_run` to apply. The module is tidied and built afterwards and all changes are rolled back if either fails.\n")
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package replacedep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func setup(t *testing.T, mainSrc string) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.22\n\nrequire github.com/pkg/errors v0.9.1\n\nreplace github.com/pkg/errors => ./pkgerrors\n",
+		"main.go": mainSrc,
+		// A local stand-in for github.com/pkg/errors, in a nested module the migration skips.
+		"pkgerrors/go.mod": testutil.GoMod("github.com/pkg/errors"),
+		"pkgerrors/errors.go": `package errors
+
+import "fmt"
+
+func New(msg string) error                                   { return fmt.Errorf("%s", msg) }
+func Wrap(err error, msg string) error                       { return fmt.Errorf("%s: %w", msg, err) }
+func Wrapf(err error, format string, args ...any) error      { return fmt.Errorf(format+": %w", append(args, err)...) }
+func Cause(err error) error                                  { return err }
+`,
+	})
+}
+
+const mainSrc = `package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+func load(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	return errors.Wrap(errors.Wrapf(errors.New("missing"), "open %s", name), "load")
+}
+
+func main() { fmt.Println(load("x")) }
+`
+
+func TestHandler_Apply(t *testing.T) {
+	dir := setup(t, mainSrc)
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, From: "github.com/pkg/errors"})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	if !strings.Contains(out, "- Files: 1\n- Rewrites: 4") || !strings.Contains(out, "✅ Applied and verified") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	got := string(b)
+	for _, want := range []string{
+		"import (\n\t\"errors\"\n\t\"fmt\"\n)",
+		`return errors.New("empty name")`,
+		`return fmt.Errorf("%s: %w", "load", fmt.Errorf("open %s"+": %w", name, errors.New("missing")))`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected main.go to contain %q, got:\n%s", want, got)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "go.mod")); strings.Contains(string(b), "require") {
+		t.Errorf("expected go mod tidy to drop the requirement, got:\n%s", b)
+	}
+}
+
+func TestHandler_DryRun(t *testing.T) {
+	dir := setup(t, mainSrc)
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, From: "github.com/pkg/errors", DryRun: true})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(out, "- main.go:11:10: `errors.New` → `errors.New`") {
+		t.Errorf("expected the first rewrite site, got:\n%s", out)
+	}
+	if !strings.Contains(out, "## ⚠️ Review") {
+		t.Errorf("expected the mapping notes, got:\n%s", out)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(b) != mainSrc {
+		t.Error("dry run modified main.go")
+	}
+}
+
+func TestHandler_Unmapped(t *testing.T) {
+	src := strings.Replace(mainSrc, `return errors.New("empty name")`, `return errors.Cause(errors.New("empty name"))`, 1)
+	dir := setup(t, src)
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, From: "github.com/pkg/errors"})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(out, "- `Cause`: main.go:11:10") {
+		t.Errorf("expected Cause to block the migration, got:\n%s", out)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(b) != src {
+		t.Error("blocked migration modified main.go")
+	}
+}
+
+func TestHandler_MappingFile(t *testing.T) {
+	dir := setup(t, mainSrc)
+	mapping := `{"from": "github.com/pkg/errors", "symbols": {"New": "errors.New"}}`
+	if err := os.WriteFile(filepath.Join(dir, "mapping.json"), []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Mapping: "mapping.json", DryRun: true})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(out, "- `Wrap`: main.go:13:9") || !strings.Contains(out, "- `Wrapf`: main.go:13:21") {
+		t.Errorf("expected Wrap and Wrapf to be reported as unmapped, got:\n%s", out)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		args []string
+		want string
+	}{
+		{`fmt.Errorf("%s: %w", $2, $1)`, []string{"err", `"msg"`}, `fmt.Errorf("%s: %w", "msg", err)`},
+		{`fmt.Errorf($2+": %w", $3..., $1)`, []string{"err", `"f %d"`, "n", "m"}, `fmt.Errorf("f %d"+": %w", n, m, err)`},
+		{`fmt.Errorf($2+": %w", $3..., $1)`, []string{"err", `"f"`}, `fmt.Errorf("f"+": %w", err)`},
+		{`f($1, $2...)`, []string{"a"}, `f(a)`},
+		{"$1", []string{"err"}, "err"},
+	}
+	for _, tt := range tests {
+		if got := expand(tt.tmpl, tt.args); got != tt.want {
+			t.Errorf("expand(%q, %q) = %q, want %q", tt.tmpl, tt.args, got, tt.want)
+		}
+	}
+}
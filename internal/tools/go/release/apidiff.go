@@ -0,0 +1,215 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"golang.org/x/mod/semver"
+)
+
+// APIEntry is one element of a module's exported API.
+type APIEntry struct {
+	Kind string // func, method, type, field, interface-method, const, var
+	Sig  string
+}
+
+// API maps "pkgpath.Name" (or "pkgpath.Type.Member") to its declaration.
+type API map[string]APIEntry
+
+// APIChange is a difference between two API snapshots.
+type APIChange struct {
+	Symbol     string `json:"symbol"`
+	Kind       string `json:"kind"`
+	Change     string `json:"change"` // added, removed, changed
+	Old        string `json:"old,omitempty"`
+	New        string `json:"new,omitempty"`
+	Compatible bool   `json:"compatible"`
+}
+
+// Snapshot returns the exported API of the importable packages under dir. Main packages and
+// internal packages are skipped, since no other module can import them.
+func Snapshot(ctx context.Context, dir string) (API, error) {
+	pkgs, err := shared.LoadPackages(ctx, dir, "./...", false)
+	if err != nil {
+		return nil, err
+	}
+	api := make(API)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.Name == "main" || isInternal(pkg.PkgPath) {
+			continue
+		}
+		qual := types.RelativeTo(pkg.Types)
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if !obj.Exported() {
+				continue
+			}
+			key := pkg.PkgPath + "." + name
+			switch o := obj.(type) {
+			case *types.Func:
+				api[key] = APIEntry{Kind: "func", Sig: types.TypeString(o.Type(), qual)}
+			case *types.Const:
+				api[key] = APIEntry{Kind: "const", Sig: types.TypeString(o.Type(), qual)}
+			case *types.Var:
+				api[key] = APIEntry{Kind: "var", Sig: types.TypeString(o.Type(), qual)}
+			case *types.TypeName:
+				addType(api, key, o, qual)
+			}
+		}
+	}
+	return api, nil
+}
+
+// addType records a named type together with its exported fields, interface methods and methods.
+func addType(api API, key string, obj *types.TypeName, qual types.Qualifier) {
+	under := obj.Type().Underlying()
+	switch u := under.(type) {
+	case *types.Struct:
+		api[key] = APIEntry{Kind: "type", Sig: "struct"}
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Exported() {
+				api[key+"."+f.Name()] = APIEntry{Kind: "field", Sig: types.TypeString(f.Type(), qual)}
+			}
+		}
+	case *types.Interface:
+		api[key] = APIEntry{Kind: "type", Sig: "interface"}
+		for i := 0; i < u.NumMethods(); i++ {
+			if m := u.Method(i); m.Exported() {
+				api[key+"."+m.Name()] = APIEntry{Kind: "interface-method", Sig: types.TypeString(m.Type(), qual)}
+			}
+		}
+	default:
+		sig := types.TypeString(under, qual)
+		if obj.IsAlias() {
+			sig = "= " + types.TypeString(obj.Type(), qual)
+		}
+		api[key] = APIEntry{Kind: "type", Sig: sig}
+	}
+	if _, isIface := under.(*types.Interface); isIface || obj.IsAlias() {
+		return
+	}
+	mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i).Obj()
+		if m.Exported() && mset.At(i).Kind() == types.MethodVal {
+			api[key+"."+m.Name()] = APIEntry{Kind: "method", Sig: types.TypeString(m.Type(), qual)}
+		}
+	}
+}
+
+func isInternal(path string) bool {
+	return strings.HasSuffix(path, "/internal") || strings.Contains(path, "/internal/") || strings.HasPrefix(path, "internal/")
+}
+
+// Diff compares two snapshots. Removals and signature changes break callers; additions do not,
+// except a new interface method, which breaks every implementation outside the module.
+func Diff(old, cur API) []APIChange {
+	var changes []APIChange
+	for key, o := range old {
+		n, ok := cur[key]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Symbol: key, Kind: o.Kind, Change: "removed", Old: o.Sig})
+		case n.Sig != o.Sig || n.Kind != o.Kind:
+			changes = append(changes, APIChange{Symbol: key, Kind: n.Kind, Change: "changed", Old: o.Sig, New: n.Sig})
+		}
+	}
+	for key, n := range cur {
+		if _, ok := old[key]; ok {
+			continue
+		}
+		_, parentExisted := old[parentKey(key)]
+		changes = append(changes, APIChange{
+			Symbol:     key,
+			Kind:       n.Kind,
+			Change:     "added",
+			New:        n.Sig,
+			Compatible: n.Kind != "interface-method" || !parentExisted,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Symbol < changes[j].Symbol })
+	return changes
+}
+
+func parentKey(key string) string {
+	if i := strings.LastIndex(key, "."); i > 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// Breaking returns the incompatible changes in changes.
+func Breaking(changes []APIChange) []APIChange {
+	var out []APIChange
+	for _, c := range changes {
+		if !c.Compatible {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// LatestTag returns the highest semantic version tag reachable from HEAD for the module at dir,
+// or "" if there is none. A module in a subdirectory of the repository is tagged "<subdir>/vX.Y.Z".
+func LatestTag(ctx context.Context, dir string) (string, error) {
+	prefix, err := tagPrefix(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	out, err := run(ctx, dir, nil, "git", "tag", "--merged", "HEAD", "--list", prefix+"v*")
+	if err != nil {
+		return "", fmt.Errorf("git tag failed: %s", strings.TrimSpace(out))
+	}
+	latest := ""
+	for _, tag := range strings.Fields(out) {
+		v := strings.TrimPrefix(tag, prefix)
+		if semver.IsValid(v) && semver.Prerelease(v) == "" && (latest == "" || semver.Compare(v, TagVersion(latest)) > 0) {
+			latest = tag
+		}
+	}
+	return latest, nil
+}
+
+// TagVersion strips the module subdirectory prefix from a tag, leaving its semantic version.
+func TagVersion(tag string) string {
+	return tag[strings.LastIndex(tag, "/")+1:]
+}
+
+// tagPrefix returns the tag prefix for the module at dir: its path relative to the repository
+// root followed by a slash, or "" for a module at the root.
+func tagPrefix(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, nil, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository: %s", dir, strings.TrimSpace(out))
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SnapshotAt checks out ref into a temporary worktree and returns the API of the module at dir as
+// it was at that revision.
+func SnapshotAt(ctx context.Context, dir, ref string) (API, error) {
+	prefix, err := tagPrefix(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "godoctor-api-*")
+	if err != nil {
+		return nil, err
+	}
+	worktree := filepath.Join(tmp, "tree")
+	defer func() {
+		_, _ = run(context.Background(), dir, nil, "git", "worktree", "remove", "--force", worktree)
+		_ = os.RemoveAll(tmp)
+	}()
+	if out, err := run(ctx, dir, nil, "git", "worktree", "add", "--detach", worktree, ref); err != nil {
+		return nil, fmt.Errorf("failed to check out %s: %s", ref, strings.TrimSpace(out))
+	}
+	return Snapshot(ctx, filepath.Join(worktree, prefix))
+}
@@ -0,0 +1,130 @@
+package prdesc
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileChange is one file of a unified diff.
+type FileChange struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"` // set for renames
+	Status  string `json:"status"`             // added, deleted, renamed, modified
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+
+	lines   []line   // added lines
+	removed []string // removed lines
+}
+
+// line is an added line and its number in the new file.
+type line struct {
+	n    int
+	text string
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseDiff splits a unified diff into its files. Git diffs start each file with "diff --git";
+// plain diffs are split on their "---" lines. Hunks are read by their line counts, so removed
+// lines that start with "--" are not taken for headers.
+func parseDiff(diff string) []*FileChange {
+	var files []*FileChange
+	var cur *FileChange
+	header := false // between a file's first line and its "+++" line
+	n, oldLeft, newLeft := 0, 0, 0
+	for _, l := range strings.Split(diff, "\n") {
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				cur.Added++
+				cur.lines = append(cur.lines, line{n, l[1:]})
+				n++
+				newLeft--
+			case strings.HasPrefix(l, "-"):
+				cur.Deleted++
+				cur.removed = append(cur.removed, l[1:])
+				oldLeft--
+			case strings.HasPrefix(l, `\`):
+				// "\ No newline at end of file"
+			default:
+				n++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			cur = &FileChange{Status: "modified"}
+			if _, b, ok := strings.Cut(l, " b/"); ok {
+				cur.Path = b
+			}
+			files = append(files, cur)
+			header = true
+		case strings.HasPrefix(l, "--- "):
+			if !header {
+				cur = &FileChange{Status: "modified"}
+				files = append(files, cur)
+				header = true
+			}
+			if diffPath(l) == "/dev/null" {
+				cur.Status = "added"
+			}
+		case strings.HasPrefix(l, "+++ ") && cur != nil:
+			header = false
+			if p := diffPath(l); p == "/dev/null" {
+				cur.Status = "deleted"
+			} else {
+				cur.Path = strings.TrimPrefix(p, "b/")
+			}
+		case header && strings.HasPrefix(l, "new file mode"):
+			cur.Status = "added"
+		case header && strings.HasPrefix(l, "deleted file mode"):
+			cur.Status = "deleted"
+		case header && strings.HasPrefix(l, "rename from "):
+			cur.Status, cur.OldPath = "renamed", strings.TrimPrefix(l, "rename from ")
+		case strings.HasPrefix(l, "@@") && cur != nil:
+			m := hunkHeader.FindStringSubmatch(l)
+			if m == nil {
+				continue
+			}
+			header = false
+			oldLeft, newLeft = count(m[1]), count(m[3])
+			n, _ = strconv.Atoi(m[2])
+		}
+	}
+	return files
+}
+
+// diffPath returns the path of a "---" or "+++" line, without a trailing timestamp.
+func diffPath(l string) string {
+	p, _, _ := strings.Cut(strings.TrimSpace(l[4:]), "\t")
+	return p
+}
+
+// count returns the line count of a hunk range, which defaults to 1.
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// isGo reports whether the file is Go source.
+func (f *FileChange) isGo() bool { return strings.HasSuffix(f.Path, ".go") }
+
+// isTest reports whether the file is a Go test file.
+func (f *FileChange) isTest() bool { return strings.HasSuffix(f.Path, "_test.go") }
+
+// pkgDir returns the directory of the file, as a package pattern.
+func (f *FileChange) pkgDir() string {
+	d := path.Dir(f.Path)
+	if d == "." {
+		return "."
+	}
+	return "./" + d
+}
@@ -0,0 +1,541 @@
+// Package prdesc implements the describe_pr tool, which turns a change and its review findings
+// into a structured pull request description.
+package prdesc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/release"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["describe_pr"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir        string    `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Base       string    `json:"base,omitempty" jsonschema:"The git ref the change merges into (default: origin/HEAD, main or master). The change is everything since the merge base, uncommitted and untracked files included."`
+	Diff       string    `json:"diff,omitempty" jsonschema:"A unified diff to describe instead of the git changes since base. API changes and commit messages are then not available."`
+	Summary    string    `json:"summary,omitempty" jsonschema:"What the change does, in a sentence or two (default: the commit subjects)"`
+	Motivation string    `json:"motivation,omitempty" jsonschema:"Why the change is needed (default: the commit message bodies)"`
+	Findings   []Finding `json:"findings,omitempty" jsonschema:"Review findings on the change, e.g. from the audit tools: open ones are listed as remaining risks, resolved ones as notable decisions"`
+	RunTests   bool      `json:"run_tests,omitempty" jsonschema:"Run go test on the changed packages and report the result as test evidence"`
+}
+
+// Finding is a review finding on the change. Its fields match the findings of the audit tools.
+type Finding struct {
+	Position string `json:"position,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+	Resolved bool   `json:"resolved,omitempty"`
+}
+
+// Commit is a commit of the change.
+type Commit struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+}
+
+// DepChange is a module requirement added, removed or changed in a go.mod file.
+type DepChange struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// TestRun is the result of running the tests of the changed packages.
+type TestRun struct {
+	Command string `json:"command"`
+	Passed  bool   `json:"passed"`
+	Output  string `json:"output,omitempty"` // the tail of the output when it failed
+}
+
+// Description holds what the pull request description is built from.
+type Description struct {
+	Base     string              `json:"base,omitempty"`
+	Commits  []Commit            `json:"commits,omitempty"`
+	Files    []*FileChange       `json:"files"`
+	API      []release.APIChange `json:"api,omitempty"`
+	APIError string              `json:"api_error,omitempty"`
+	Deps     []DepChange         `json:"deps,omitempty"`
+	NewTests map[string][]string `json:"new_tests,omitempty"` // test file → added test functions
+	Untested []string            `json:"untested,omitempty"`  // changed packages without test changes
+	Todos    []string            `json:"todos,omitempty"`
+	TestRun  *TestRun            `json:"test_run,omitempty"`
+	Packages []string            `json:"packages"`
+	Findings []Finding           `json:"findings,omitempty"`
+}
+
+// maxListed caps the entries of each list in the description.
+const maxListed = 20
+
+// Handler handles the describe_pr tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	d, err := Describe(ctx, absDir, args)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if len(d.Files) == 0 {
+		return errorResult("no changes to describe: the diff is empty"), nil, nil
+	}
+	return textResult(render(d, args)), nil, nil
+}
+
+// Describe collects the facts about the change: the files of args.Diff, or else the changes of
+// the module at dir since its merge base with args.Base, with the commits and exported API
+// changes since then.
+func Describe(ctx context.Context, dir string, args Params) (*Description, error) {
+	d := &Description{Findings: args.Findings}
+	diff := args.Diff
+	if diff == "" {
+		if _, err := git(ctx, dir, "rev-parse", "--show-toplevel"); err != nil {
+			return nil, fmt.Errorf("%s is not inside a git repository; pass the change as diff", dir)
+		}
+		base, err := mergeBase(ctx, dir, args.Base)
+		if err != nil {
+			return nil, err
+		}
+		d.Base = base
+		if diff, err = git(ctx, dir, "diff", "--relative", base); err != nil {
+			return nil, fmt.Errorf("git diff failed: %s", diff)
+		}
+		if diff, err = withUntracked(ctx, dir, diff); err != nil {
+			return nil, err
+		}
+		if d.Commits, err = commits(ctx, dir, base); err != nil {
+			return nil, err
+		}
+		if old, err := release.SnapshotAt(ctx, dir, base); err != nil {
+			d.APIError = err.Error()
+		} else if cur, err := release.Snapshot(ctx, dir); err != nil {
+			d.APIError = err.Error()
+		} else {
+			d.API = release.Diff(old, cur)
+		}
+	}
+
+	d.Files = parseDiff(diff)
+	d.NewTests = make(map[string][]string)
+	changed := make(map[string]bool)
+	tested := make(map[string]bool)
+	for _, f := range d.Files {
+		if filepath.Base(f.Path) == "go.mod" {
+			d.Deps = append(d.Deps, depChanges(f)...)
+		}
+		for _, l := range f.lines {
+			if m := todoLine.FindStringSubmatch(l.text); m != nil {
+				d.Todos = append(d.Todos, fmt.Sprintf("%s:%d: %s", f.Path, l.n, strings.TrimSpace(m[1])))
+			}
+			if f.isTest() {
+				if m := testFunc.FindStringSubmatch(l.text); m != nil {
+					d.NewTests[f.Path] = append(d.NewTests[f.Path], m[1])
+				}
+			}
+		}
+		if !f.isGo() || f.Status == "deleted" {
+			continue
+		}
+		if f.isTest() {
+			tested[f.pkgDir()] = true
+		}
+		changed[f.pkgDir()] = true
+	}
+	for p := range changed {
+		d.Packages = append(d.Packages, p)
+		if !tested[p] {
+			d.Untested = append(d.Untested, p)
+		}
+	}
+	sort.Strings(d.Packages)
+	sort.Strings(d.Untested)
+
+	if args.RunTests && len(d.Packages) > 0 {
+		d.TestRun = runTests(ctx, dir, d.Packages)
+	}
+	return d, nil
+}
+
+var (
+	todoLine = regexp.MustCompile(`//\s*((?:TODO|FIXME|XXX|HACK)\b.*)`)
+	testFunc = regexp.MustCompile(`^func ((?:Test|Benchmark|Fuzz|Example)\w*)\(`)
+	require  = regexp.MustCompile(`^\s*(?:require\s+)?([^\s()]+)\s+(v[^\s]+)`)
+)
+
+// mergeBase returns the merge base of HEAD with base, or with the first of origin/HEAD, main and
+// master that exists. Without any of them the change is the uncommitted work on top of HEAD.
+func mergeBase(ctx context.Context, dir, base string) (string, error) {
+	if base != "" {
+		out, err := git(ctx, dir, "merge-base", "HEAD", base)
+		if err != nil {
+			return "", fmt.Errorf("no merge base with %s: %s", base, out)
+		}
+		return out, nil
+	}
+	for _, ref := range []string{"origin/HEAD", "main", "master"} {
+		if out, err := git(ctx, dir, "merge-base", "HEAD", ref); err == nil {
+			return out, nil
+		}
+	}
+	return git(ctx, dir, "rev-parse", "HEAD")
+}
+
+// withUntracked appends the untracked files under dir to diff as new files, since git diff
+// leaves them out.
+func withUntracked(ctx context.Context, dir, diff string) (string, error) {
+	out, err := git(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return "", fmt.Errorf("git ls-files failed: %s", out)
+	}
+	var sb strings.Builder
+	sb.WriteString(diff)
+	for _, name := range strings.Fields(out) {
+		//nolint:gosec // G304: The path is listed by git inside the validated directory.
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || strings.ContainsRune(string(data), 0) {
+			continue
+		}
+		lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+		fmt.Fprintf(&sb, "\ndiff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n", name, name, name, len(lines))
+		for _, l := range lines {
+			sb.WriteString("+" + strings.TrimSuffix(l, "\n") + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// commits returns the commits since base, oldest first.
+func commits(ctx context.Context, dir, base string) ([]Commit, error) {
+	out, err := git(ctx, dir, "log", "--reverse", "--format=%s%x1f%b%x1e", base+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %s", out)
+	}
+	var list []Commit
+	for _, entry := range strings.Split(out, "\x1e") {
+		subject, body, _ := strings.Cut(strings.TrimSpace(entry), "\x1f")
+		if subject == "" {
+			continue
+		}
+		list = append(list, Commit{Subject: subject, Body: withoutTrailers(body)})
+	}
+	return list, nil
+}
+
+// withoutTrailers drops the trailer lines (Signed-off-by:, Co-authored-by:, ...) of a commit body.
+func withoutTrailers(body string) string {
+	var kept []string
+	for _, l := range strings.Split(strings.TrimSpace(body), "\n") {
+		if k, _, ok := strings.Cut(l, ": "); ok && !strings.Contains(k, " ") && strings.Contains(k, "-") {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// depChanges compares the requirements removed from and added to a go.mod file.
+func depChanges(f *FileChange) []DepChange {
+	old, cur := make(map[string]string), make(map[string]string)
+	collect := func(lines []string, into map[string]string) {
+		for _, l := range lines {
+			if strings.Contains(l, "=>") || strings.HasPrefix(strings.TrimSpace(l), "module ") {
+				continue
+			}
+			if m := require.FindStringSubmatch(l); m != nil && strings.Contains(m[1], ".") {
+				into[m[1]] = m[2]
+			}
+		}
+	}
+	collect(f.removed, old)
+	added := make([]string, len(f.lines))
+	for i, l := range f.lines {
+		added[i] = l.text
+	}
+	collect(added, cur)
+
+	var changes []DepChange
+	for p, v := range old {
+		if cur[p] != v {
+			changes = append(changes, DepChange{Path: p, Old: v, New: cur[p]})
+		}
+	}
+	for p, v := range cur {
+		if _, ok := old[p]; !ok {
+			changes = append(changes, DepChange{Path: p, New: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// runTests runs go test on pkgs and keeps the tail of the output when they fail.
+func runTests(ctx context.Context, dir string, pkgs []string) *TestRun {
+	args := append([]string{"test"}, pkgs...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	run := &TestRun{Command: "go " + strings.Join(args, " "), Passed: err == nil}
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(lines) > 30 {
+			lines = lines[len(lines)-30:]
+		}
+		run.Output = strings.Join(lines, "\n")
+	}
+	return run
+}
+
+func render(d *Description, args Params) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Summary\n\n")
+	switch {
+	case args.Summary != "":
+		sb.WriteString(strings.TrimSpace(args.Summary) + "\n\n")
+	case len(d.Commits) == 1:
+		sb.WriteString(d.Commits[0].Subject + "\n\n")
+	case len(d.Commits) > 1:
+		for _, c := range d.Commits {
+			sb.WriteString("- " + c.Subject + "\n")
+		}
+		sb.WriteString("\n")
+	default:
+		sb.WriteString("<!-- What does this change do? -->\n\n")
+	}
+	added, deleted := 0, 0
+	for _, f := range d.Files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	fmt.Fprintf(&sb, "Changes %d file(s) (+%d −%d)", len(d.Files), added, deleted)
+	if len(d.Packages) > 0 {
+		fmt.Fprintf(&sb, " in %d package(s): %s", len(d.Packages), codeList(d.Packages))
+	}
+	sb.WriteString(".\n\n")
+
+	sb.WriteString("## Motivation\n\n")
+	var bodies []string
+	for _, c := range d.Commits {
+		if c.Body != "" {
+			bodies = append(bodies, c.Body)
+		}
+	}
+	switch {
+	case args.Motivation != "":
+		sb.WriteString(strings.TrimSpace(args.Motivation) + "\n\n")
+	case len(bodies) > 0:
+		sb.WriteString(strings.Join(bodies, "\n\n") + "\n\n")
+	default:
+		sb.WriteString("<!-- Why is this change needed? Link the issue it addresses. -->\n\n")
+	}
+
+	sb.WriteString("## Notable decisions\n\n")
+	var decisions []string
+	for _, c := range limit(d.API) {
+		decisions = append(decisions, apiLine(c))
+	}
+	if n := len(d.API) - maxListed; n > 0 {
+		decisions = append(decisions, fmt.Sprintf("... and %d more API change(s)", n))
+	}
+	for _, c := range d.Deps {
+		switch {
+		case c.Old == "":
+			decisions = append(decisions, fmt.Sprintf("Adds the dependency `%s` %s", c.Path, c.New))
+		case c.New == "":
+			decisions = append(decisions, fmt.Sprintf("Drops the dependency `%s` (was %s)", c.Path, c.Old))
+		default:
+			decisions = append(decisions, fmt.Sprintf("Moves `%s` from %s to %s", c.Path, c.Old, c.New))
+		}
+	}
+	for _, f := range d.Files {
+		switch f.Status {
+		case "deleted":
+			decisions = append(decisions, fmt.Sprintf("Deletes `%s`", f.Path))
+		case "renamed":
+			decisions = append(decisions, fmt.Sprintf("Renames `%s` to `%s`", f.OldPath, f.Path))
+		}
+	}
+	for _, f := range d.Findings {
+		if f.Resolved {
+			decisions = append(decisions, "Addresses review finding: "+findingLine(f))
+		}
+	}
+	if len(decisions) == 0 {
+		sb.WriteString("<!-- Design choices and trade-offs a reviewer should know about. -->\n\n")
+	} else {
+		writeList(&sb, decisions)
+	}
+
+	sb.WriteString("## Test evidence\n\n")
+	var evidence []string
+	var testFiles []string
+	for file := range d.NewTests {
+		testFiles = append(testFiles, file)
+	}
+	sort.Strings(testFiles)
+	for _, file := range testFiles {
+		evidence = append(evidence, fmt.Sprintf("Adds %s in `%s`", codeList(d.NewTests[file]), file))
+	}
+	for _, f := range d.Files {
+		if f.isTest() && f.Status != "deleted" && len(d.NewTests[f.Path]) == 0 {
+			evidence = append(evidence, fmt.Sprintf("Updates `%s`", f.Path))
+		}
+	}
+	if d.TestRun != nil {
+		if d.TestRun.Passed {
+			evidence = append(evidence, fmt.Sprintf("`%s` passes", d.TestRun.Command))
+		} else {
+			evidence = append(evidence, fmt.Sprintf("❌ `%s` fails:\n\n  ```text\n  %s\n  ```", d.TestRun.Command, strings.ReplaceAll(d.TestRun.Output, "\n", "\n  ")))
+		}
+	}
+	if len(evidence) == 0 {
+		sb.WriteString("<!-- How was this verified? -->\n\n")
+	} else {
+		writeList(&sb, evidence)
+	}
+
+	sb.WriteString("## Remaining risks\n\n")
+	var risks []string
+	for _, f := range d.Findings {
+		if !f.Resolved {
+			risks = append(risks, findingLine(f))
+		}
+	}
+	if breaking := release.Breaking(d.API); len(breaking) > 0 {
+		risks = append(risks, fmt.Sprintf("%d breaking API change(s): code outside this module that uses them must be updated", len(breaking)))
+	}
+	if d.APIError != "" {
+		risks = append(risks, "API changes could not be computed: "+firstLine(d.APIError))
+	}
+	if len(d.Untested) > 0 {
+		risks = append(risks, "Changed without test changes: "+codeList(d.Untested))
+	}
+	if d.TestRun != nil && !d.TestRun.Passed {
+		risks = append(risks, "Tests of the changed packages fail")
+	}
+	for _, t := range limit(d.Todos) {
+		risks = append(risks, "Adds `"+t+"`")
+	}
+	if n := len(d.Todos) - maxListed; n > 0 {
+		risks = append(risks, fmt.Sprintf("... and %d more TODO(s)", n))
+	}
+	if len(risks) == 0 {
+		sb.WriteString("None known.\n")
+	} else {
+		writeList(&sb, risks)
+	}
+	return strings.TrimSuffix(sb.String(), "\n") + "\n"
+}
+
+func apiLine(c release.APIChange) string {
+	name := c.Symbol[strings.LastIndex(c.Symbol, "/")+1:]
+	var s string
+	switch c.Change {
+	case "added":
+		s = fmt.Sprintf("Adds %s `%s`", c.Kind, name)
+	case "removed":
+		s = fmt.Sprintf("Removes %s `%s`", c.Kind, name)
+	default:
+		s = fmt.Sprintf("Changes %s `%s` from `%s` to `%s`", c.Kind, name, c.Old, c.New)
+	}
+	if !c.Compatible {
+		s += " (breaking)"
+	}
+	return s
+}
+
+func findingLine(f Finding) string {
+	var sb strings.Builder
+	if f.Severity != "" {
+		fmt.Fprintf(&sb, "**%s** ", f.Severity)
+	}
+	if f.Position != "" {
+		fmt.Fprintf(&sb, "`%s` ", f.Position)
+	}
+	if f.Rule != "" {
+		fmt.Fprintf(&sb, "[%s] ", f.Rule)
+	}
+	sb.WriteString(f.Message)
+	return sb.String()
+}
+
+func limit[T any](list []T) []T {
+	if len(list) > maxListed {
+		return list[:maxListed]
+	}
+	return list
+}
+
+func writeList(sb *strings.Builder, items []string) {
+	for _, item := range items {
+		sb.WriteString("- " + item + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+func codeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = "`" + s + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func firstLine(s string) string {
+	l, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return l
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+func textResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
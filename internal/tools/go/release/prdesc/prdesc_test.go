@@ -0,0 +1,128 @@
+package prdesc
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestHandler_Git(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads packages at two revisions and runs go test")
+	}
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod":         testutil.GoMod("example.com/app"),
+		"store/store.go": "package store\n\n// Old is going away.\nfunc Old() {}\n\n// Get returns a value.\nfunc Get(key string) string { return key }\n",
+	})
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "Initial commit")
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+
+	testutil.WriteFiles(t, dir, map[string]string{
+		"store/store.go":      "package store\n\n// Get returns a value.\nfunc Get(key string) string { return key + \"!\" }\n\n// Len counts values.\nfunc Len() int { return 0 } // TODO: count for real\n",
+		"store/store_test.go": "package store\n\nimport \"testing\"\n\nfunc TestGet(t *testing.T) {\n\tif Get(\"a\") != \"a!\" {\n\t\tt.Fatal(\"unexpected value\")\n\t}\n}\n",
+	})
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "Mark values read from the store", "-m", "Callers could not tell stored values apart.\n\nSigned-off-by: t <t@example.com>")
+	// Untracked work is part of the change.
+	testutil.WriteFiles(t, dir, map[string]string{"api/api.go": "package api\n\nfunc Serve() {}\n"})
+
+	text, isErr := call(t, Params{Dir: dir, RunTests: true, Findings: []Finding{
+		{Position: "store/store.go:7:6", Severity: "warning", Message: "Len always returns 0"},
+		{Position: "store/store.go:4:1", Rule: "doc", Message: "Get has no doc comment", Resolved: true},
+	}})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"## Summary\n\nMark values read from the store\n\nChanges 3 file(s) (+16 −4) in 2 package(s): `./api`, `./store`.",
+		"## Motivation\n\nCallers could not tell stored values apart.\n\n## Notable decisions",
+		"- Adds func `api.Serve`\n",
+		"- Adds func `store.Len`\n",
+		"- Removes func `store.Old` (breaking)\n",
+		"- Addresses review finding: `store/store.go:4:1` [doc] Get has no doc comment\n",
+		"## Test evidence\n\n- Adds `TestGet` in `store/store_test.go`\n- `go test ./api ./store` passes\n",
+		"## Remaining risks\n\n- **warning** `store/store.go:7:6` Len always returns 0\n- 1 breaking API change(s)",
+		"- Changed without test changes: `./api`\n",
+		"- Adds `store/store.go:7: TODO: count for real`\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Signed-off-by") {
+		t.Errorf("commit trailers leaked into the description:\n%s", text)
+	}
+}
+
+const modDiff = `diff --git a/go.mod b/go.mod
+index 1111111..2222222 100644
+--- a/go.mod
++++ b/go.mod
+@@ -3,6 +3,6 @@ module example.com/app
+ go 1.22
+
+ require (
+-	github.com/pkg/errors v0.9.1
+-	golang.org/x/sync v0.6.0
++	golang.org/x/sync v0.7.0
++	golang.org/x/text v0.14.0 // indirect
+ )
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package app
+--- a comment that looks like a header
+`
+
+func TestHandler_Diff(t *testing.T) {
+	text, isErr := call(t, Params{Dir: t.TempDir(), Diff: modDiff, Summary: "Update dependencies."})
+	if isErr {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	for _, want := range []string{
+		"## Summary\n\nUpdate dependencies.\n\nChanges 2 file(s) (+2 −4).",
+		"<!-- Why is this change needed?",
+		"- Drops the dependency `github.com/pkg/errors` (was v0.9.1)\n- Moves `golang.org/x/sync` from v0.6.0 to v0.7.0\n- Adds the dependency `golang.org/x/text` v0.14.0\n- Deletes `old.go`\n",
+		"<!-- How was this verified? -->",
+		"## Remaining risks\n\nNone known.\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandler_NoGit(t *testing.T) {
+	if text, isErr := call(t, Params{Dir: t.TempDir()}); !isErr || !strings.Contains(text, "not inside a git repository") {
+		t.Errorf("expected an error outside git, got:\n%s", text)
+	}
+}
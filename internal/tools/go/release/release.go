@@ -0,0 +1,347 @@
+// Package release implements the release_check tool, a pre-release gauntlet that builds for every
+// target platform, runs tests, vet and govulncheck, diffs the exported API against the last tag and
+// checks for a changelog, then reports whether the module is ready to be released.
+package release
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/semver"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["release_check"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir          string   `json:"dir,omitempty" jsonschema:"The absolute module directory to check. Always pass absolute paths in multi-root workspaces."`
+	Checks       []string `json:"checks,omitempty" jsonschema:"Checks to run (default: all): build, test, vet, vulncheck, api, changelog"`
+	Platforms    []string `json:"platforms,omitempty" jsonschema:"GOOS/GOARCH pairs to build (default: linux/amd64, darwin/arm64, windows/amd64)"`
+	Base         string   `json:"base,omitempty" jsonschema:"Git ref to diff the API against (default: the latest semver tag)"`
+	OutputFormat string   `json:"output_format,omitempty" jsonschema:"Output format: 'markdown' (default) or 'json'"`
+}
+
+// Check statuses. Only StatusFail blocks a release.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// AllChecks lists the checks in the order they run.
+var AllChecks = []string{"build", "test", "vet", "vulncheck", "api", "changelog"}
+
+// DefaultPlatforms are the release targets built when none are given.
+var DefaultPlatforms = []string{"linux/amd64", "darwin/arm64", "windows/amd64"}
+
+var changelogNames = []string{"CHANGELOG.md", "CHANGELOG", "CHANGES.md", "HISTORY.md", "RELEASE_NOTES.md"}
+
+// Check is the outcome of one step of the gauntlet.
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Summary string `json:"summary"`
+	Details string `json:"details,omitempty"`
+}
+
+// Report is the full result.
+type Report struct {
+	Ready   bool    `json:"ready"`
+	BaseTag string  `json:"base_tag,omitempty"`
+	Checks  []Check `json:"checks"`
+}
+
+// Handler handles the release_check tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	format, err := shared.ParseFormat(args.OutputFormat)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	checks := args.Checks
+	if len(checks) == 0 {
+		checks = AllChecks
+	}
+	for _, c := range checks {
+		if !contains(AllChecks, c) {
+			return errorResult(fmt.Sprintf("unknown check %q: must be one of %s", c, strings.Join(AllChecks, ", "))), nil, nil
+		}
+	}
+	platforms := args.Platforms
+	if len(platforms) == 0 {
+		platforms = DefaultPlatforms
+	}
+	for _, p := range platforms {
+		if goos, goarch, ok := strings.Cut(p, "/"); !ok || goos == "" || goarch == "" {
+			return errorResult(fmt.Sprintf("invalid platform %q: expected GOOS/GOARCH", p)), nil, nil
+		}
+	}
+
+	report := Run(ctx, absDir, checks, platforms, args.Base)
+	output, err := render(report, locale.FromRequest(req)).Render(format)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output},
+		},
+	}, nil, nil
+}
+
+// Run executes the selected checks in order. base is the ref to diff the API against; when empty
+// the latest semver tag is used.
+func Run(ctx context.Context, dir string, checks, platforms []string, base string) *Report {
+	report := &Report{}
+	if base == "" {
+		base, _ = LatestTag(ctx, dir)
+	}
+	report.BaseTag = base
+
+	for _, name := range AllChecks {
+		if !contains(checks, name) {
+			continue
+		}
+		switch name {
+		case "build":
+			for _, p := range platforms {
+				report.Checks = append(report.Checks, checkBuild(ctx, dir, p))
+			}
+		case "test":
+			report.Checks = append(report.Checks, checkCommand(ctx, dir, "test", "go", "test", "./..."))
+		case "vet":
+			report.Checks = append(report.Checks, checkCommand(ctx, dir, "vet", "go", "vet", "./..."))
+		case "vulncheck":
+			report.Checks = append(report.Checks, checkVulns(ctx, dir))
+		case "api":
+			report.Checks = append(report.Checks, checkAPI(ctx, dir, base))
+		case "changelog":
+			report.Checks = append(report.Checks, checkChangelog(ctx, dir, base))
+		}
+	}
+
+	report.Ready = true
+	for _, c := range report.Checks {
+		if c.Status == StatusFail {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func checkBuild(ctx context.Context, dir, platform string) Check {
+	goos, goarch, _ := strings.Cut(platform, "/")
+	name := "build " + platform
+	out, err := run(ctx, dir, []string{"GOOS=" + goos, "GOARCH=" + goarch, "CGO_ENABLED=0"}, "go", "build", "./...")
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Summary: "does not compile", Details: out}
+	}
+	return Check{Name: name, Status: StatusPass, Summary: "compiles"}
+}
+
+func checkCommand(ctx context.Context, dir, name string, command ...string) Check {
+	out, err := run(ctx, dir, nil, command[0], command[1:]...)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Summary: fmt.Sprintf("`%s` failed", strings.Join(command, " ")), Details: out}
+	}
+	return Check{Name: name, Status: StatusPass, Summary: fmt.Sprintf("`%s` passed", strings.Join(command, " "))}
+}
+
+func checkVulns(ctx context.Context, dir string) Check {
+	if shared.LocalOnly() {
+		// govulncheck sends the module list to vuln.go.dev.
+		return Check{Name: "vulncheck", Status: StatusSkip,
+			Summary: "skipped in local-only mode (--no-cloud): govulncheck queries vuln.go.dev"}
+	}
+	if _, err := exec.LookPath("govulncheck"); err != nil {
+		return Check{Name: "vulncheck", Status: StatusWarn,
+			Summary: "govulncheck is not installed; run `go install golang.org/x/vuln/cmd/govulncheck@latest` and re-check"}
+	}
+	out, err := run(ctx, dir, nil, "govulncheck", "./...")
+	if err != nil {
+		return Check{Name: "vulncheck", Status: StatusFail, Summary: "known vulnerabilities are reachable from the code", Details: out}
+	}
+	return Check{Name: "vulncheck", Status: StatusPass, Summary: "no reachable known vulnerabilities"}
+}
+
+// checkAPI diffs the exported API against base. Breaking changes block a v1+ release, because they
+// require a new major version with a /vN module path; before v1 they are only a warning.
+func checkAPI(ctx context.Context, dir, base string) Check {
+	if base == "" {
+		return Check{Name: "api", Status: StatusSkip, Summary: "no previous release tag; this is the first release"}
+	}
+	old, err := SnapshotAt(ctx, dir, base)
+	if err != nil {
+		return Check{Name: "api", Status: StatusFail, Summary: fmt.Sprintf("could not load the API at %s", base), Details: err.Error()}
+	}
+	cur, err := Snapshot(ctx, dir)
+	if err != nil {
+		return Check{Name: "api", Status: StatusFail, Summary: "could not load the current API", Details: err.Error()}
+	}
+	changes := Diff(old, cur)
+	breaking := Breaking(changes)
+	details := formatChanges(changes)
+	switch {
+	case len(breaking) > 0 && semver.Major(TagVersion(base)) != "v0":
+		return Check{Name: "api", Status: StatusFail, Details: details,
+			Summary: fmt.Sprintf("%d breaking change(s) since %s; a compatible release cannot ship them", len(breaking), base)}
+	case len(breaking) > 0:
+		return Check{Name: "api", Status: StatusWarn, Details: details,
+			Summary: fmt.Sprintf("%d breaking change(s) since %s; allowed before v1, but bump the minor version", len(breaking), base)}
+	case len(changes) > 0:
+		return Check{Name: "api", Status: StatusPass, Details: details,
+			Summary: fmt.Sprintf("%d compatible addition(s) since %s", len(changes), base)}
+	}
+	return Check{Name: "api", Status: StatusPass, Summary: fmt.Sprintf("exported API unchanged since %s", base)}
+}
+
+func formatChanges(changes []APIChange) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		mark := "+"
+		if !c.Compatible {
+			mark = "!"
+		}
+		switch c.Change {
+		case "added":
+			fmt.Fprintf(&sb, "%s added   %s %s: %s\n", mark, c.Kind, c.Symbol, c.New)
+		case "removed":
+			fmt.Fprintf(&sb, "%s removed %s %s: %s\n", mark, c.Kind, c.Symbol, c.Old)
+		default:
+			fmt.Fprintf(&sb, "%s changed %s %s: %s -> %s\n", mark, c.Kind, c.Symbol, c.Old, c.New)
+		}
+	}
+	return sb.String()
+}
+
+// checkChangelog requires a changelog in the module or repository root, and warns when it has not
+// been touched since base.
+func checkChangelog(ctx context.Context, dir, base string) Check {
+	path := findChangelog(ctx, dir)
+	if path == "" {
+		return Check{Name: "changelog", Status: StatusFail, Summary: "no CHANGELOG.md found; add one describing this release"}
+	}
+	rel, _ := filepath.Rel(dir, path)
+	if base != "" {
+		if _, err := run(ctx, dir, nil, "git", "diff", "--quiet", base, "--", path); err == nil {
+			return Check{Name: "changelog", Status: StatusWarn, Summary: fmt.Sprintf("%s has not changed since %s", rel, base)}
+		}
+	}
+	return Check{Name: "changelog", Status: StatusPass, Summary: rel + " present"}
+}
+
+func findChangelog(ctx context.Context, dir string) string {
+	dirs := []string{dir}
+	if top, err := run(ctx, dir, nil, "git", "rev-parse", "--show-toplevel"); err == nil {
+		if top = strings.TrimSpace(top); top != dir {
+			dirs = append(dirs, top)
+		}
+	}
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			for _, name := range changelogNames {
+				if !e.IsDir() && strings.EqualFold(e.Name(), name) {
+					return filepath.Join(d, e.Name())
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// render lays the report out for the shared renderer: the blocking items, a table of every
+// check, and the output of the checks that produced some.
+func render(report *Report, lang string) *shared.Report {
+	rep := &shared.Report{Title: locale.T(lang, "release.title"), Lang: lang, Status: shared.StatusPass, Data: report}
+	var blocking []string
+	for _, c := range report.Checks {
+		if c.Status == StatusFail {
+			blocking = append(blocking, fmt.Sprintf("**%s**: %s", c.Name, c.Summary))
+		}
+	}
+	if !report.Ready {
+		rep.Status = shared.StatusFail
+		rep.Summary = locale.T(lang, "release.blocking", len(blocking))
+	}
+	if report.BaseTag != "" {
+		rep.Summary = strings.TrimSpace(rep.Summary + " " + locale.T(lang, "release.compared", report.BaseTag))
+	}
+	if len(blocking) > 0 {
+		rep.Add("Blocking Items", "").Items = blocking
+	}
+
+	checks := rep.Add("Checks", "")
+	checks.Table = &shared.Table{Columns: []string{"Check", "Status", "Summary"}}
+	for _, c := range report.Checks {
+		checks.Table.AddRow(c.Name, shared.Status(c.Status).Label(lang), c.Summary)
+	}
+	for _, c := range report.Checks {
+		if c.Details == "" || c.Status == StatusSkip {
+			continue
+		}
+		checks.Add(c.Name, shared.Status(c.Status)).Output = c.Details
+	}
+	return rep
+}
+
+// run executes a command in dir with extra environment variables and returns its combined output.
+func run(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
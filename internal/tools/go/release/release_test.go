@@ -0,0 +1,135 @@
+package release
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// setup creates a repository tagged v1.0.0 whose working tree then removes and adds API.
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/lib"),
+		"lib.go": `package lib
+
+type Store interface{ Get(key string) string }
+
+type Client struct{ Name string }
+
+func (c *Client) Do() error { return nil }
+
+func Old() {}
+`,
+	})
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	git(t, dir, "tag", "v1.0.0")
+	testutil.WriteFiles(t, dir, map[string]string{
+		"lib.go": `package lib
+
+type Store interface {
+	Get(key string) string
+	Put(key, value string)
+}
+
+type Client struct{ Name string }
+
+func (c *Client) Do(retries int) error { return nil }
+
+func New() *Client { return &Client{} }
+`,
+	})
+	git(t, dir, "commit", "-q", "-am", "change api")
+	return dir
+}
+
+func TestHandler_NotReady(t *testing.T) {
+	dir := setup(t)
+	res, _, err := Handler(context.Background(), nil, Params{
+		Dir:       dir,
+		Checks:    []string{"build", "vet", "api", "changelog"},
+		Platforms: []string{"linux/amd64"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"# Release Readiness\n\n**Status:** ❌ FAILED\n\n2 blocking item(s). Compared against `v1.0.0`.",
+		"- **api**: 3 breaking change(s) since v1.0.0",
+		"- **changelog**: no CHANGELOG.md found",
+		"| build linux/amd64 | ✅ PASS | compiles |",
+		"### api: ❌ FAILED",
+		"| vet | ✅ PASS |",
+		"! removed func example.com/lib.Old: func()",
+		"! changed method example.com/lib.Client.Do: func() error -> func(retries int) error",
+		"! added   interface-method example.com/lib.Store.Put: func(key string, value string)",
+		"+ added   func example.com/lib.New: func() *Client",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Ready(t *testing.T) {
+	dir := setup(t)
+	testutil.WriteFiles(t, dir, map[string]string{"CHANGELOG.md": "# v2.0.0\n"})
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "changelog")
+
+	res, _, err := Handler(context.Background(), nil, Params{
+		Dir:          dir,
+		Checks:       []string{"changelog", "api"},
+		Base:         "HEAD~1",
+		OutputFormat: "json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{`"status": "pass"`, `"ready": true`, `"summary": "exported API unchanged since HEAD~1"`, `"summary": "CHANGELOG.md present"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_InvalidCheck(t *testing.T) {
+	res, _, err := Handler(context.Background(), nil, Params{Dir: t.TempDir(), Checks: []string{"lint"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Error("expected an error result for an unknown check")
+	}
+}
+
+func TestCheckVulns_LocalOnly(t *testing.T) {
+	shared.SetLocalOnly(true)
+	defer shared.SetLocalOnly(false)
+
+	c := checkVulns(context.Background(), t.TempDir())
+	if c.Status != StatusSkip || !strings.Contains(c.Summary, "local-only") {
+		t.Errorf("checkVulns() = %+v, want a local-only skip", c)
+	}
+}
@@ -0,0 +1,293 @@
+// Package version implements the suggest_version tool, which recommends the next semantic version
+// of a module from the API changes since its last release tag, and can create that tag.
+package version
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/go/release"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["suggest_version"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Base    string `json:"base,omitempty" jsonschema:"Release tag to compare against (default: the latest semver tag)"`
+	Tag     bool   `json:"tag,omitempty" jsonschema:"Create an annotated git tag for the version. Only set this after the user has confirmed the version."`
+	Version string `json:"version,omitempty" jsonschema:"Version to tag instead of the recommendation (e.g. v1.3.0-rc.1)"`
+	Message string `json:"message,omitempty" jsonschema:"Annotation message for the tag (default: 'Release <version>')"`
+}
+
+// Bump is the part of the version a release increments.
+type Bump string
+
+// Bump kinds.
+const (
+	BumpNone  Bump = "none"
+	BumpPatch Bump = "patch"
+	BumpMinor Bump = "minor"
+	BumpMajor Bump = "major"
+)
+
+// Recommendation is the suggested next version and why.
+type Recommendation struct {
+	Base     string // previous tag, "" for a first release
+	Bump     Bump
+	Next     string // full tag name, including any module subdirectory prefix
+	Commits  int
+	Changes  []release.APIChange
+	Breaking int
+	Notes    []string
+}
+
+// Handler handles the suggest_version tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Version != "" && !semver.IsValid(args.Version) {
+		return errorResult(fmt.Sprintf("invalid version %q: must be a semantic version such as v1.2.3", args.Version)), nil, nil
+	}
+
+	rec, err := Recommend(ctx, absDir, args.Base)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	out := render(rec)
+	if !args.Tag {
+		if rec.Bump != BumpNone {
+			out += fmt.Sprintf("\nTo release, confirm with the user and call again with `tag=true` to create `%s`.\n", rec.Next)
+		}
+		return textResult(out), nil, nil
+	}
+
+	tag := rec.Next
+	if args.Version != "" {
+		prefix := prefixOf(rec.Base)
+		if rec.Base == "" {
+			prefix = prefixOf(rec.Next)
+		}
+		tag = prefix + args.Version
+	}
+	if err := checkTaggable(ctx, absDir, rec, tag); err != nil {
+		return errorResult(out + "\n❌ " + err.Error()), nil, nil
+	}
+	msg := args.Message
+	if msg == "" {
+		msg = "Release " + release.TagVersion(tag)
+	}
+	if o, err := git(ctx, absDir, "tag", "-a", tag, "-m", msg); err != nil {
+		return errorResult(out + "\n❌ git tag failed: " + o), nil, nil
+	}
+	out += fmt.Sprintf("\n✅ Created annotated tag `%s`. Push it with `git push origin %s`.\n", tag, tag)
+	return textResult(out), nil, nil
+}
+
+// Recommend computes the next version of the module at dir relative to base (default: the latest
+// semver tag). Breaking API changes need a major bump (a minor bump before v1), compatible additions a
+// minor bump, and any other commits a patch.
+func Recommend(ctx context.Context, dir, base string) (*Recommendation, error) {
+	if base == "" {
+		latest, err := release.LatestTag(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		base = latest
+	}
+	rec := &Recommendation{Base: base}
+	if base == "" {
+		prefix, _ := git(ctx, dir, "rev-parse", "--show-prefix")
+		rec.Bump = BumpMinor
+		rec.Next = strings.TrimSpace(prefix) + "v0.1.0"
+		rec.Notes = append(rec.Notes, "No release tag found, so this is the first release. Start at v0.1.0 while the API may still change; tag v1.0.0 once it is stable.")
+		return rec, nil
+	}
+	v := release.TagVersion(base)
+	if !semver.IsValid(v) {
+		return nil, fmt.Errorf("base %q is not a semantic version tag", base)
+	}
+
+	count, err := git(ctx, dir, "rev-list", "--count", base+"..HEAD", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list failed: %s", count)
+	}
+	rec.Commits, _ = strconv.Atoi(count)
+
+	old, err := release.SnapshotAt(ctx, dir, base)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := release.Snapshot(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	rec.Changes = release.Diff(old, cur)
+	rec.Breaking = len(release.Breaking(rec.Changes))
+
+	switch {
+	case rec.Breaking > 0 && semver.Major(v) == "v0":
+		rec.Bump = BumpMinor
+		rec.Notes = append(rec.Notes, "Breaking changes are allowed before v1; by convention they bump the minor version rather than the patch.")
+	case rec.Breaking > 0:
+		rec.Bump = BumpMajor
+	case len(rec.Changes) > 0:
+		rec.Bump = BumpMinor
+	case rec.Commits > 0:
+		rec.Bump = BumpPatch
+	default:
+		rec.Bump = BumpNone
+		rec.Notes = append(rec.Notes, "No commits touch this module since "+base+"; there is nothing to release.")
+		return rec, nil
+	}
+	rec.Next = prefixOf(base) + next(v, rec.Bump)
+	if rec.Bump == BumpMajor {
+		major := semver.Major(next(v, BumpMajor))
+		rec.Notes = append(rec.Notes, fmt.Sprintf("A %s release requires the module path in go.mod to end in /%s, and every import within the module to use it.", major, major))
+	}
+	return rec, nil
+}
+
+// next increments v, a valid semantic version, dropping any prerelease or build suffix.
+func next(v string, bump Bump) string {
+	var major, minor, patch int
+	fmt.Sscanf(semver.Canonical(v), "v%d.%d.%d", &major, &minor, &patch)
+	switch bump {
+	case BumpMajor:
+		return fmt.Sprintf("v%d.0.0", major+1)
+	case BumpMinor:
+		return fmt.Sprintf("v%d.%d.0", major, minor+1)
+	default:
+		return fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
+	}
+}
+
+func prefixOf(tag string) string {
+	return tag[:len(tag)-len(release.TagVersion(tag))]
+}
+
+// checkTaggable refuses to tag when the working tree is dirty, the tag exists or does not move
+// forward, or a v2+ tag does not match the module path's major version suffix.
+func checkTaggable(ctx context.Context, dir string, rec *Recommendation, tag string) error {
+	if rec.Bump == BumpNone && tag == rec.Next {
+		return fmt.Errorf("nothing to release since %s", rec.Base)
+	}
+	if status, err := git(ctx, dir, "status", "--porcelain"); err != nil || strings.TrimSpace(status) != "" {
+		return fmt.Errorf("the working tree has uncommitted changes; commit or stash them before tagging")
+	}
+	if _, err := git(ctx, dir, "rev-parse", "-q", "--verify", "refs/tags/"+tag); err == nil {
+		return fmt.Errorf("tag %s already exists", tag)
+	}
+	v := release.TagVersion(tag)
+	if rec.Base != "" && semver.Compare(v, release.TagVersion(rec.Base)) <= 0 {
+		return fmt.Errorf("%s is not newer than %s", v, rec.Base)
+	}
+	if major := semver.Major(v); major != "v0" && major != "v1" {
+		data, err := readGoMod(ctx, dir)
+		if err != nil {
+			return err
+		}
+		if path := modfile.ModulePath(data); !strings.HasSuffix(path, "/"+major) {
+			return fmt.Errorf("module path %s must end in /%s before tagging %s", path, major, v)
+		}
+	}
+	return nil
+}
+
+func readGoMod(ctx context.Context, dir string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOMOD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		return nil, fmt.Errorf("no go.mod found for %s", dir)
+	}
+	return os.ReadFile(strings.TrimSpace(string(out)))
+}
+
+func render(rec *Recommendation) string {
+	var sb strings.Builder
+	sb.WriteString("# Version Recommendation\n\n")
+	if rec.Base == "" {
+		fmt.Fprintf(&sb, "**Next version:** `%s` (first release)\n\n", rec.Next)
+	} else if rec.Bump == BumpNone {
+		fmt.Fprintf(&sb, "**Current version:** `%s` — no release needed.\n\n", rec.Base)
+	} else {
+		fmt.Fprintf(&sb, "**Next version:** `%s` (%s bump from `%s`, %d commit(s))\n\n", rec.Next, rec.Bump, rec.Base, rec.Commits)
+	}
+	for _, n := range rec.Notes {
+		fmt.Fprintf(&sb, "> %s\n\n", n)
+	}
+
+	if len(rec.Changes) > 0 {
+		compatible := len(rec.Changes) - rec.Breaking
+		fmt.Fprintf(&sb, "## API Changes (%d breaking, %d compatible)\n\n", rec.Breaking, compatible)
+		for _, c := range rec.Changes {
+			mark := "✅"
+			if !c.Compatible {
+				mark = "❌"
+			}
+			switch c.Change {
+			case "changed":
+				fmt.Fprintf(&sb, "- %s changed %s `%s`: `%s` → `%s`\n", mark, c.Kind, c.Symbol, c.Old, c.New)
+			case "removed":
+				fmt.Fprintf(&sb, "- %s removed %s `%s`\n", mark, c.Kind, c.Symbol)
+			default:
+				fmt.Fprintf(&sb, "- %s added %s `%s`\n", mark, c.Kind, c.Symbol)
+			}
+		}
+	} else if rec.Base != "" && rec.Bump != BumpNone {
+		sb.WriteString("The exported API is unchanged; the commits since the last tag are fixes or internal changes.\n")
+	}
+	return sb.String()
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+func textResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package version
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+const v1 = `package lib
+
+func Old() {}
+`
+
+func setup(t *testing.T, tag string) string {
+	t.Helper()
+	dir := testutil.WriteModule(t, map[string]string{"go.mod": testutil.GoMod("example.com/lib"), "lib.go": v1})
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	runGit(t, dir, "tag", tag)
+	return dir
+}
+
+func commit(t *testing.T, dir, src string) {
+	t.Helper()
+	testutil.WriteFiles(t, dir, map[string]string{"lib.go": src})
+	runGit(t, dir, "commit", "-q", "-am", "change")
+}
+
+func call(t *testing.T, args Params) (string, bool) {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Content[0].(*mcp.TextContent).Text, res.IsError
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		src  string
+		want string
+	}{
+		{"patch", "v1.2.3", v1 + "\n// Old does nothing.\n", "**Next version:** `v1.2.4` (patch bump from `v1.2.3`, 1 commit(s))"},
+		{"minor", "v1.2.3", v1 + "\nfunc New() {}\n", "**Next version:** `v1.3.0` (minor bump"},
+		{"major", "v1.2.3", "package lib\n", "**Next version:** `v2.0.0` (major bump"},
+		{"breaking before v1", "v0.4.1", "package lib\n", "**Next version:** `v0.5.0` (minor bump"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setup(t, tt.tag)
+			commit(t, dir, tt.src)
+			out, isErr := call(t, Params{Dir: dir})
+			if isErr || !strings.Contains(out, tt.want) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestTag(t *testing.T) {
+	dir := setup(t, "v1.0.0")
+	commit(t, dir, v1+"\nfunc New() {}\n")
+
+	out, isErr := call(t, Params{Dir: dir, Tag: true})
+	if isErr || !strings.Contains(out, "Created annotated tag `v1.1.0`") {
+		t.Fatalf("expected the tag to be created, got:\n%s", out)
+	}
+	if got := runGit(t, dir, "tag", "-n1", "v1.1.0"); !strings.Contains(got, "Release v1.1.0") {
+		t.Errorf("expected annotated tag, got %q", got)
+	}
+
+	// A major release needs a /v2 module path.
+	commit(t, dir, "package lib\n")
+	out, isErr = call(t, Params{Dir: dir, Tag: true})
+	if !isErr || !strings.Contains(out, "module path example.com/lib must end in /v2 before tagging v2.0.0") {
+		t.Errorf("expected the major tag to be refused, got:\n%s", out)
+	}
+}
@@ -0,0 +1,326 @@
+// Package snippet implements the eval_snippet tool, which builds and runs a small Go program in a
+// throwaway module so agents can check a hypothesis without touching the workspace.
+package snippet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["eval_snippet"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Code    string `json:"code" jsonschema:"Go code to run: a full program, top-level declarations with a main function, or just the statements of main"`
+	Stdin   string `json:"stdin,omitempty" jsonschema:"Optional standard input for the program"`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"Run timeout in seconds (default 5, max 30)"`
+	Share   bool   `json:"share,omitempty" jsonschema:"Also upload the program to the Go Playground and return a share link. This publishes the code; do not share proprietary code."`
+}
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxTimeout     = 30 * time.Second
+	buildTimeout   = 60 * time.Second
+	maxOutput      = 64 << 10
+	shareTimeout   = 10 * time.Second
+)
+
+// ShareEndpoint is the Go Playground share API. Posting a program to it returns the ID of a
+// snippet viewable at PlayURL + ID.
+var ShareEndpoint = "https://play.golang.org/share"
+
+// PlayURL is the prefix of Go Playground share links.
+const PlayURL = "https://go.dev/play/p/"
+
+var shareIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// blockedImports are packages a snippet may not use: network access and process control. This
+// is a guard rail against accidents, not a security sandbox: os stays importable, since snippets
+// read their stdin through it, so a snippet can still read and write files as the server's user.
+var blockedImports = []string{"net", "os/exec", "os/signal", "plugin", "syscall", "unsafe", "runtime/cgo", "C"}
+
+// Result is the outcome of running a snippet.
+type Result struct {
+	Source   string
+	BuildErr string
+	Frames   string // source frames for the build errors
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	TimedOut bool
+	Elapsed  time.Duration
+	ShareURL string
+	ShareErr string
+}
+
+// Handler handles the eval_snippet tool execution.
+func Handler(ctx context.Context, _ *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	if strings.TrimSpace(args.Code) == "" {
+		return errorResult("code cannot be empty"), nil, nil
+	}
+	timeout := defaultTimeout
+	if args.Timeout > 0 {
+		timeout = min(time.Duration(args.Timeout)*time.Second, maxTimeout)
+	}
+	res, err := Eval(ctx, args.Code, args.Stdin, timeout)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	if args.Share {
+		// Broken programs are shared too: a failing link is what a bug report needs.
+		if url, err := Share(ctx, res.Source); err != nil {
+			res.ShareErr = err.Error()
+		} else {
+			res.ShareURL = url
+		}
+	}
+	return &mcp.CallToolResult{
+		IsError: res.BuildErr != "" || res.ExitCode != 0,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: render(res, timeout)},
+		},
+	}, nil, nil
+}
+
+// Eval wraps code into a main package, builds it offline in a temporary module and runs it with
+// the given timeout. Build failures are reported in the result, not as an error.
+func Eval(ctx context.Context, code, stdin string, timeout time.Duration) (*Result, error) {
+	src, err := wrap(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImports(src); err != nil {
+		return nil, err
+	}
+	res := &Result{Source: string(src)}
+
+	dir, err := os.MkdirTemp("", "godoctor_snippet_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := writes.Write(ctx, filepath.Join(dir, "main.go"), src, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	buildCtx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+	env := append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOWORK=off")
+	for _, args := range [][]string{{"mod", "init", "snippet"}, {"build", "-o", "snippet", "."}} {
+		cmd := exec.CommandContext(buildCtx, "go", args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			res.BuildErr = strings.TrimSpace(string(out))
+			res.Frames = shared.FramesFromOutput(dir, res.BuildErr, 3, shared.FrameOptions{})
+			return res, nil
+		}
+	}
+
+	runCtx, cancelRun := context.WithTimeout(ctx, timeout)
+	defer cancelRun()
+	cmd := exec.CommandContext(runCtx, filepath.Join(dir, "snippet"))
+	cmd.Dir = dir
+	// A bare environment: no credentials from the server, and proxies that go nowhere.
+	cmd.Env = []string{
+		"HOME=" + dir, "TMPDIR=" + dir, "PATH=" + os.Getenv("PATH"),
+		"HTTP_PROXY=http://127.0.0.1:9", "HTTPS_PROXY=http://127.0.0.1:9", "NO_PROXY=",
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout, stderr := &cappedBuffer{}, &cappedBuffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	res.Elapsed = time.Since(start)
+	res.Stdout, res.Stderr = stdout.String(), stderr.String()
+	if runCtx.Err() == context.DeadlineExceeded {
+		res.TimedOut = true
+		res.ExitCode = -1
+		return res, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to run snippet: %w", err)
+	}
+	return res, nil
+}
+
+// Share uploads src to the Go Playground and returns its share link.
+func Share(ctx context.Context, src string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, shareTimeout)
+	defer cancel()
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	body, status, err := shared.HTTPRequest(ctx, http.MethodPost, ShareEndpoint, strings.NewReader(src), header, 1024)
+	if err != nil {
+		return "", fmt.Errorf("playground share failed: %w", err)
+	}
+	id := strings.TrimSpace(string(body))
+	if status != http.StatusOK {
+		return "", fmt.Errorf("playground share failed: %d %s: %s", status, http.StatusText(status), id)
+	}
+	if !shareIDRe.MatchString(id) {
+		return "", fmt.Errorf("playground share returned an unexpected response: %q", id)
+	}
+	return PlayURL + id, nil
+}
+
+// wrap turns code into a main package: a full file is used as is, declarations get a package
+// clause, and anything else becomes the body of main. Missing standard library imports are added.
+func wrap(code string) ([]byte, error) {
+	candidates := []string{
+		code,
+		"package main\n\n" + code,
+		"package main\n\nfunc main() {\n" + code + "\n}\n",
+	}
+	var firstErr error
+	for i, src := range candidates {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+		if err != nil {
+			if i == 0 && strings.HasPrefix(strings.TrimSpace(code), "package ") {
+				firstErr = err
+			}
+			continue
+		}
+		if i == 1 && !hasMain(file) {
+			continue
+		}
+		out, err := imports.Process("main.go", []byte(src), &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+		if err != nil {
+			return nil, fmt.Errorf("failed to format snippet: %w", err)
+		}
+		return out, nil
+	}
+	if firstErr != nil {
+		return nil, fmt.Errorf("snippet does not parse: %w", firstErr)
+	}
+	// Report the error for the statement form, which is the most common shape of a snippet.
+	_, err := parser.ParseFile(token.NewFileSet(), "main.go", candidates[2], 0)
+	return nil, fmt.Errorf("snippet does not parse as a program, declarations, or statements: %w", err)
+}
+
+func hasMain(file *ast.File) bool {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+func checkImports(src []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", src, parser.ImportsOnly)
+	if err != nil {
+		return fmt.Errorf("snippet does not parse: %w", err)
+	}
+	if file.Name.Name != "main" {
+		return fmt.Errorf("snippet must be package main, not %s", file.Name.Name)
+	}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		for _, blocked := range blockedImports {
+			if path == blocked || strings.HasPrefix(path, blocked+"/") {
+				return fmt.Errorf("import %q is not allowed in snippets: network and process packages are blocked as a best-effort guard rail, not a sandbox", path)
+			}
+		}
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			return fmt.Errorf("import %q is not allowed in snippets: only the standard library is available offline", path)
+		}
+	}
+	return nil
+}
+
+// cappedBuffer keeps the first maxOutput bytes written to it and counts the rest.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	dropped int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := max(maxOutput-b.buf.Len(), 0)
+	if room < len(p) {
+		b.dropped += len(p) - room
+		b.buf.Write(p[:room])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	if b.dropped > 0 {
+		return b.buf.String() + fmt.Sprintf("\n... (%d more bytes truncated)", b.dropped)
+	}
+	return b.buf.String()
+}
+
+func render(res *Result, timeout time.Duration) string {
+	var sb strings.Builder
+	switch {
+	case res.BuildErr != "":
+		sb.WriteString("# Snippet: ❌ BUILD FAILED\n\n")
+	case res.TimedOut:
+		fmt.Fprintf(&sb, "# Snippet: ⏱️ TIMED OUT after %s\n\n", timeout)
+	case res.ExitCode != 0:
+		fmt.Fprintf(&sb, "# Snippet: ❌ exit status %d (%s)\n\n", res.ExitCode, res.Elapsed.Round(time.Millisecond))
+	default:
+		fmt.Fprintf(&sb, "# Snippet: ✅ exit status 0 (%s)\n\n", res.Elapsed.Round(time.Millisecond))
+	}
+	if res.ShareURL != "" {
+		fmt.Fprintf(&sb, "**Playground:** %s\n\n", res.ShareURL)
+	} else if res.ShareErr != "" {
+		fmt.Fprintf(&sb, "⚠️ Could not share the snippet: %s\n\n", res.ShareErr)
+	}
+	if res.BuildErr != "" {
+		sb.WriteString("## Build Output\n\n```text\n" + res.BuildErr + "\n```\n\n")
+		if res.Frames != "" {
+			sb.WriteString(res.Frames + "\n")
+		}
+	} else {
+		fmt.Fprintf(&sb, "## Stdout\n\n```text\n%s\n```\n\n", strings.TrimRight(res.Stdout, "\n"))
+		if res.Stderr != "" {
+			fmt.Fprintf(&sb, "## Stderr\n\n```text\n%s\n```\n\n", strings.TrimRight(res.Stderr, "\n"))
+		}
+	}
+	sb.WriteString("<details>\n<summary>Program</summary>\n\n```go\n" + strings.TrimRight(res.Source, "\n") + "\n```\n\n</details>\n")
+	return sb.String()
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
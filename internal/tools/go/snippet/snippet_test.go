@@ -0,0 +1,161 @@
+package snippet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    Params
+		isError bool
+		want    []string
+	}{
+		{
+			name: "statements",
+			args: Params{Code: `fmt.Println(strings.ToUpper("hi"))`},
+			want: []string{"✅ exit status 0", "## Stdout\n\n```text\nHI\n```", "import (\n\t\"fmt\"\n\t\"strings\"\n)"},
+		},
+		{
+			name: "declarations",
+			args: Params{Code: "func double(n int) int { return n * 2 }\n\nfunc main() { fmt.Print(double(21)) }"},
+			want: []string{"```text\n42\n```"},
+		},
+		{
+			name: "full program with stdin",
+			args: Params{Code: "package main\n\nimport (\n\t\"io\"\n\t\"os\"\n)\n\nfunc main() { io.Copy(os.Stdout, os.Stdin) }\n", Stdin: "echo"},
+			want: []string{"```text\necho\n```"},
+		},
+		{
+			name:    "exit status and stderr",
+			args:    Params{Code: `fmt.Fprintln(os.Stderr, "boom"); os.Exit(3)`},
+			isError: true,
+			want:    []string{"❌ exit status 3", "## Stderr\n\n```text\nboom\n```"},
+		},
+		{
+			name:    "build error",
+			args:    Params{Code: `var x int = "s"; fmt.Println(x)`},
+			isError: true,
+			want:    []string{"❌ BUILD FAILED", "cannot use \"s\"", "> 6 | \tvar x int = \"s\""},
+		},
+		{
+			name:    "timeout",
+			args:    Params{Code: `for {}`, Timeout: 1},
+			isError: true,
+			want:    []string{"⏱️ TIMED OUT after 1s"},
+		},
+		{
+			name:    "network blocked",
+			args:    Params{Code: `http.Get("https://example.com")`},
+			isError: true,
+			want:    []string{`import "net/http" is not allowed`, "guard rail, not a sandbox"},
+		},
+		{
+			name:    "third-party blocked",
+			args:    Params{Code: "package main\n\nimport \"example.com/x\"\n\nfunc main() { x.Do() }\n"},
+			isError: true,
+			want:    []string{`import "example.com/x" is not allowed`},
+		},
+		{
+			name:    "does not parse",
+			args:    Params{Code: `fmt.Println(`},
+			isError: true,
+			want:    []string{"snippet does not parse"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _, err := Handler(context.Background(), nil, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := res.Content[0].(*mcp.TextContent).Text
+			if res.IsError != tt.isError {
+				t.Errorf("IsError = %v, want %v:\n%s", res.IsError, tt.isError, out)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("expected %q in:\n%s", w, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	var b cappedBuffer
+	n, err := b.Write([]byte(strings.Repeat("x", maxOutput+10)))
+	if err != nil || n != maxOutput+10 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if !strings.HasSuffix(b.String(), "(10 more bytes truncated)") {
+		t.Errorf("unexpected tail: %q", b.String()[maxOutput:])
+	}
+}
+
+func TestHandler_Share(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+		fmt.Fprint(w, "AbC-12_x")
+	}))
+	defer srv.Close()
+	old := ShareEndpoint
+	ShareEndpoint = srv.URL
+	defer func() { ShareEndpoint = old }()
+
+	res, _, _ := Handler(context.Background(), nil, Params{Code: `fmt.Println("shared")`, Share: true})
+	out := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(out, "**Playground:** https://go.dev/play/p/AbC-12_x") {
+		t.Errorf("missing share link in:\n%s", out)
+	}
+	if !strings.Contains(got, "func main() {\n\tfmt.Println(\"shared\")\n}") {
+		t.Errorf("shared the wrong program:\n%s", got)
+	}
+}
+
+func TestShare_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "snippet too large", http.StatusRequestEntityTooLarge)
+	}))
+	defer srv.Close()
+	old := ShareEndpoint
+	ShareEndpoint = srv.URL
+	defer func() { ShareEndpoint = old }()
+
+	if _, err := Share(context.Background(), "package main"); err == nil || !strings.Contains(err.Error(), "snippet too large") {
+		t.Errorf("Share error = %v", err)
+	}
+}
+
+func TestShare_LocalOnly(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		fmt.Fprint(w, "AbC-12_x")
+	}))
+	defer srv.Close()
+	old := ShareEndpoint
+	ShareEndpoint = srv.URL
+	defer func() { ShareEndpoint = old }()
+	shared.SetLocalOnly(true)
+	defer shared.SetLocalOnly(false)
+
+	if _, err := Share(context.Background(), "package main"); !errors.Is(err, shared.ErrLocalOnly) {
+		t.Errorf("Share error = %v, want ErrLocalOnly", err)
+	}
+	if called {
+		t.Error("the program was uploaded in local-only mode")
+	}
+}
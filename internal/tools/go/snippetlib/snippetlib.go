@@ -0,0 +1,336 @@
+// Package snippetlib implements the get_snippet tool, which serves a curated, versioned library of
+// Go patterns rendered with the caller's names and settings.
+package snippetlib
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// LibraryVersion is the version of the snippet library as a whole. Bump it whenever a snippet is
+// added or its Version changes.
+const LibraryVersion = "1.0.0"
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Param kinds.
+const (
+	KindIdent    = "ident"    // a Go identifier
+	KindType     = "type"     // a Go type expression
+	KindInt      = "int"      // a positive integer
+	KindDuration = "duration" // a time.Duration string such as "10s"
+	KindString   = "string"   // free text, rendered inside a Go string literal
+)
+
+// Param is a placeholder of a snippet.
+type Param struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
+// Snippet is one library entry.
+type Snippet struct {
+	Name        string
+	Version     int
+	Description string
+	File        string   // template in templates/
+	Params      []Param  // "package" is implicit
+	Requires    []string // modules the code imports besides the standard library
+	MinGo       string   // minimum Go version for the language features used
+
+	// derive adds values computed from the validated parameters.
+	derive func(values map[string]string)
+}
+
+// Library is the snippet catalog, in display order.
+var Library = []Snippet{
+	{
+		Name:        "worker_pool",
+		Version:     1,
+		Description: "Fixed-size worker pool with context-aware Submit and graceful Shutdown that drains queued jobs or cancels them when its context expires.",
+		File:        "worker_pool.go.tmpl",
+		Params: []Param{
+			{Name: "name", Kind: KindIdent, Default: "Pool", Description: "name of the pool type"},
+			{Name: "workers", Kind: KindInt, Default: "4", Description: "number of workers used when New is given less than one"},
+		},
+		derive: func(v map[string]string) { v["lname"] = strings.ToLower(v["name"]) },
+	},
+	{
+		Name:        "http_client",
+		Version:     1,
+		Description: "HTTP client with an overall timeout, context-bound requests, bounded retries with exponential backoff on 429 and 5xx, and size-limited JSON decoding.",
+		File:        "http_client.go.tmpl",
+		Params: []Param{
+			{Name: "name", Kind: KindIdent, Default: "Client", Description: "name of the client type"},
+			{Name: "base_url", Kind: KindString, Default: "https://api.example.com", Description: "base URL of the API"},
+			{Name: "timeout", Kind: KindDuration, Default: "10s", Description: "overall timeout of one request"},
+		},
+		derive: func(v map[string]string) { v["timeout_expr"] = durationExpr(v["timeout"]) },
+	},
+	{
+		Name:        "errgroup_fanout",
+		Version:     1,
+		Description: "Bounded concurrent fan-out with errgroup: results in input order, first error cancels the rest.",
+		File:        "errgroup_fanout.go.tmpl",
+		Params: []Param{
+			{Name: "name", Kind: KindIdent, Default: "ProcessAll", Description: "name of the function"},
+			{Name: "item_type", Kind: KindType, Default: "string", Description: "type of the input items"},
+			{Name: "result_type", Kind: KindType, Default: "string", Description: "type of the results"},
+			{Name: "limit", Kind: KindInt, Default: "8", Description: "maximum number of items processed at once"},
+		},
+		Requires: []string{"golang.org/x/sync"},
+		MinGo:    "1.22",
+	},
+	{
+		Name:        "table_test",
+		Version:     1,
+		Description: "Table-driven test skeleton with subtests, error expectations and input/output columns for a function returning (value, error).",
+		File:        "table_test.go.tmpl",
+		Params: []Param{
+			{Name: "func", Kind: KindIdent, Default: "Parse", Description: "function under test"},
+			{Name: "input_type", Kind: KindType, Default: "string", Description: "type of the function's argument"},
+			{Name: "want_type", Kind: KindType, Default: "string", Description: "type of the function's result (must be comparable)"},
+		},
+	},
+}
+
+// Lookup returns the snippet with the given name.
+func Lookup(name string) (Snippet, bool) {
+	for _, s := range Library {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Snippet{}, false
+}
+
+// Render fills the snippet's template with values, falling back to each parameter's default,
+// and returns the gofmt-ed source. pkg is the package clause (default "main").
+func (s Snippet) Render(pkg string, values map[string]string) (string, error) {
+	if pkg == "" {
+		pkg = "main"
+	}
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid package name %q", pkg)
+	}
+	known := make(map[string]bool, len(s.Params))
+	v := map[string]string{"package": pkg}
+	for _, p := range s.Params {
+		known[p.Name] = true
+		val, ok := values[p.Name]
+		if !ok || val == "" {
+			val = p.Default
+		}
+		val, err := validate(p, val)
+		if err != nil {
+			return "", err
+		}
+		v[p.Name] = val
+	}
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("unknown parameter %q for snippet %s (parameters: %s)", unknown[0], s.Name, s.paramNames())
+	}
+	if s.derive != nil {
+		s.derive(v)
+	}
+
+	tmpl, err := template.New(s.File).Option("missingkey=error").ParseFS(templates, "templates/"+s.File)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return "", err
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("snippet %s rendered invalid Go code: %w", s.Name, err)
+	}
+	return string(out), nil
+}
+
+func (s Snippet) paramNames() string {
+	var names []string
+	for _, p := range s.Params {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// validate checks a parameter value against its kind and returns it as it is substituted.
+func validate(p Param, val string) (string, error) {
+	val = strings.TrimSpace(val)
+	switch p.Kind {
+	case KindIdent:
+		if !token.IsIdentifier(val) {
+			return "", fmt.Errorf("parameter %s: %q is not a Go identifier", p.Name, val)
+		}
+	case KindType:
+		expr, err := parser.ParseExpr(val)
+		if err != nil || !isType(expr) {
+			return "", fmt.Errorf("parameter %s: %q is not a Go type", p.Name, val)
+		}
+	case KindInt:
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("parameter %s: %q is not a positive integer", p.Name, val)
+		}
+	case KindDuration:
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("parameter %s: %q is not a positive duration", p.Name, val)
+		}
+	case KindString:
+		// Substituted inside a string literal: quote and strip the surrounding quotes.
+		q := strconv.Quote(val)
+		return q[1 : len(q)-1], nil
+	}
+	return val, nil
+}
+
+// isType reports whether expr has the syntax of a type.
+func isType(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := e.X.(*ast.Ident)
+		return ok
+	case *ast.StarExpr:
+		return isType(e.X)
+	case *ast.IndexExpr:
+		return isType(e.X) && isType(e.Index)
+	case *ast.IndexListExpr:
+		for _, idx := range e.Indices {
+			if !isType(idx) {
+				return false
+			}
+		}
+		return isType(e.X)
+	}
+	return false
+}
+
+// durationExpr renders a duration as a Go expression in the largest exact unit.
+func durationExpr(s string) string {
+	d, _ := time.ParseDuration(s)
+	units := []struct {
+		d    time.Duration
+		name string
+	}{{time.Hour, "time.Hour"}, {time.Minute, "time.Minute"}, {time.Second, "time.Second"}, {time.Millisecond, "time.Millisecond"}}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d", d)
+}
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["get_snippet"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Name    string            `json:"name,omitempty" jsonschema:"Snippet to render; omit to list the library"`
+	Package string            `json:"package,omitempty" jsonschema:"Package clause of the rendered code (default: main)"`
+	Params  map[string]string `json:"params,omitempty" jsonschema:"Values for the snippet's parameters, e.g. {\"name\": \"Fetcher\", \"limit\": \"4\"}; omitted ones use their defaults"`
+}
+
+// Handler handles the get_snippet tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	if args.Name == "" {
+		return textResult(catalog()), nil, nil
+	}
+	s, ok := Lookup(args.Name)
+	if !ok {
+		var names []string
+		for _, s := range Library {
+			names = append(names, s.Name)
+		}
+		return errorResult(fmt.Sprintf("unknown snippet %q; available: %s", args.Name, strings.Join(names, ", "))), nil, nil
+	}
+	code, err := s.Render(args.Package, args.Params)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Snippet `%s` v%d\n\n%s\n\n", s.Name, s.Version, s.Description)
+	var notes []string
+	if len(s.Requires) > 0 {
+		notes = append(notes, fmt.Sprintf("Requires %s (install with `add_dependency`).", strings.Join(s.Requires, ", ")))
+	}
+	if s.MinGo != "" {
+		notes = append(notes, fmt.Sprintf("Needs `go %s` or later in go.mod.", s.MinGo))
+	}
+	for _, n := range notes {
+		fmt.Fprintf(&sb, "- %s\n", n)
+	}
+	if len(notes) > 0 {
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "```go\n%s```\n\nFrom the godoctor snippet library %s. Adapt names and add the code with `smart_edit`.\n", code, LibraryVersion)
+	return textResult(sb.String()), nil, nil
+}
+
+func catalog() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Snippet library %s\n\n", LibraryVersion)
+	for _, s := range Library {
+		fmt.Fprintf(&sb, "## `%s` v%d\n\n%s\n\n", s.Name, s.Version, s.Description)
+		for _, p := range s.Params {
+			fmt.Fprintf(&sb, "- `%s` (%s, default `%s`): %s\n", p.Name, p.Kind, p.Default, p.Description)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Render one with `get_snippet(name=\"...\", package=\"...\", params={...})`.\n")
+	return sb.String()
+}
+
+func textResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package snippetlib
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestLibraryCompiles vets every snippet, rendered with its defaults and with custom values, in a
+// throwaway module.
+func TestLibraryCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go vet")
+	}
+	sum, err := os.ReadFile("../../../../go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var syncSum []string
+	for _, line := range strings.Split(string(sum), "\n") {
+		if strings.HasPrefix(line, "golang.org/x/sync ") {
+			syncSum = append(syncSum, line)
+		}
+	}
+
+	files := map[string]string{
+		"go.mod": "module example.com/snippets\n\ngo 1.22\n\nrequire golang.org/x/sync v0.20.0\n",
+		"go.sum": strings.Join(syncSum, "\n") + "\n",
+	}
+	custom := map[string]map[string]string{
+		"worker_pool":     {"name": "Workers", "workers": "2"},
+		"http_client":     {"name": "API", "base_url": `https://example.com/"quoted"`, "timeout": "1500ms"},
+		"errgroup_fanout": {"name": "FetchAll", "item_type": "*url.URL", "result_type": "[]byte", "limit": "3"},
+		"table_test":      {"func": "Atoi", "input_type": "string", "want_type": "int"},
+	}
+	for _, s := range Library {
+		for variant, values := range []map[string]string{nil, custom[s.Name]} {
+			pkg := s.Name + []string{"_default", "_custom"}[variant]
+			code, err := s.Render(pkg, values)
+			if err != nil {
+				t.Fatalf("%s: %v", pkg, err)
+			}
+			name := "snippet.go"
+			if s.Name == "table_test" {
+				name = "snippet_test.go"
+				fn, in, want := "Parse", "string", "string"
+				if values != nil {
+					fn, in, want = values["func"], values["input_type"], values["want_type"]
+				}
+				files[filepath.Join(pkg, "stub.go")] = "package " + pkg + "\n\nfunc " + fn + "(" + in + ") (" + want + ", error) { panic(0) }\n"
+			}
+			if s.Name == "errgroup_fanout" && values != nil {
+				// The custom item type needs its import.
+				code = strings.Replace(code, "import (", "import (\n\t\"net/url\"", 1)
+			}
+			files[filepath.Join(pkg, name)] = code
+		}
+	}
+	dir := testutil.WriteModule(t, files)
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go vet failed: %v\n%s", err, out)
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	s, _ := Lookup("errgroup_fanout")
+	tests := []struct {
+		name   string
+		pkg    string
+		values map[string]string
+		want   string
+	}{
+		{"bad package", "my-pkg", nil, "invalid package name"},
+		{"bad ident", "", map[string]string{"name": "1st"}, "not a Go identifier"},
+		{"injected type", "", map[string]string{"item_type": "int) { os.Exit(1) }; func x(a int"}, "not a Go type"},
+		{"bad limit", "", map[string]string{"limit": "0"}, "not a positive integer"},
+		{"unknown param", "", map[string]string{"workers": "2"}, "unknown parameter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Render(tt.pkg, tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Render() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	res, _, _ := Handler(context.Background(), nil, Params{})
+	list := res.Content[0].(*mcp.TextContent).Text
+	for _, s := range Library {
+		if !strings.Contains(list, "`"+s.Name+"`") {
+			t.Errorf("catalog is missing %s", s.Name)
+		}
+	}
+
+	res, _, _ = Handler(context.Background(), nil, Params{Name: "http_client", Package: "api", Params: map[string]string{"timeout": "2m"}})
+	text := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError || !strings.Contains(text, "package api") || !strings.Contains(text, "Timeout: 2 * time.Minute") {
+		t.Errorf("unexpected snippet:\n%s", text)
+	}
+
+	res, _, _ = Handler(context.Background(), nil, Params{Name: "nope"})
+	if !res.IsError {
+		t.Error("expected an error for an unknown snippet")
+	}
+}
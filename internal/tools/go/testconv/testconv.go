@@ -0,0 +1,801 @@
+// Package testconv implements the convert_test tool, which turns a test into a benchmark skeleton
+// and a benchmark into a test, keeping the setup and fixtures of the original.
+package testconv
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"go/version"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["convert_test"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir     string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Package string `json:"package" jsonschema:"Package containing the test, as an import path or a ./relative pattern"`
+	Name    string `json:"name" jsonschema:"The TestXxx function to turn into a benchmark, or the BenchmarkXxx function to turn into a test"`
+	DryRun  bool   `json:"dry_run,omitempty" jsonschema:"Return the generated function without writing it"`
+}
+
+// Conversion is a generated function.
+type Conversion struct {
+	Name     string
+	Code     string
+	Filename string
+	Notes    []string
+
+	pkgPath string
+	end     int // offset in Filename after which the function is inserted
+}
+
+// benchSetup are the testing.B methods that only tune measurement; they are dropped from tests.
+var benchSetup = map[string]bool{
+	"ResetTimer": true, "StartTimer": true, "StopTimer": true, "ReportAllocs": true,
+	"SetBytes": true, "ReportMetric": true, "SetParallelism": true,
+}
+
+// assertions are the testing.T methods that check results.
+var assertions = map[string]bool{
+	"Error": true, "Errorf": true, "Fatal": true, "Fatalf": true, "Fail": true, "FailNow": true,
+	"Log": true, "Logf": true, "Skip": true, "Skipf": true, "SkipNow": true,
+}
+
+// Handler handles the convert_test tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	if args.Package == "" || args.Name == "" {
+		return errorResult("package and name are required"), nil, nil
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, args.Package, true)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	conv, err := Convert(pkgs, args.Name)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s → %s\n\n", args.Name, conv.Name)
+	if args.DryRun {
+		sb.WriteString("Dry run: nothing was written.\n\n")
+	} else {
+		//nolint:gosec // G304: File path comes from the loaded package.
+		src, err := os.ReadFile(conv.Filename)
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to read %s: %v", conv.Filename, err)), nil, nil
+		}
+		out := string(src[:conv.end]) + "\n\n" + conv.Code + string(src[conv.end:])
+		cs := shared.Changeset{conv.Filename: []byte(out)}
+		if err := cs.ApplyVerified(ctx, absDir, []string{"vet", strings.TrimSuffix(conv.pkgPath, "_test")}); err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "✅ Added `%s` to `%s` (go vet passes).\n\n", conv.Name, rel(absDir, conv.Filename))
+	}
+	for _, n := range conv.Notes {
+		fmt.Fprintf(&sb, "- %s\n", n)
+	}
+	if len(conv.Notes) > 0 {
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "```go\n%s```\n", conv.Code)
+	if strings.HasPrefix(conv.Name, "Benchmark") {
+		fmt.Fprintf(&sb, "\nRun it with `go test -run '^$' -bench '^%s$' -benchmem`, or compare revisions with `bench_compare`.\n", conv.Name)
+	}
+	return textResult(sb.String()), nil, nil
+}
+
+// Convert generates the benchmark for the test, or the test for the benchmark, named name.
+func Convert(pkgs []*packages.Package, name string) (*Conversion, error) {
+	toBench := strings.HasPrefix(name, "Test")
+	if !toBench && !strings.HasPrefix(name, "Benchmark") {
+		return nil, fmt.Errorf("%s is neither a TestXxx nor a BenchmarkXxx function", name)
+	}
+	target := "Benchmark" + strings.TrimPrefix(name, "Test")
+	if !toBench {
+		target = "Test" + strings.TrimPrefix(name, "Benchmark")
+	}
+
+	pkg, file, fd := findTest(pkgs, name)
+	if fd == nil {
+		return nil, fmt.Errorf("function %s not found in the test files of the package", name)
+	}
+	if pkg.Types.Scope().Lookup(target) != nil {
+		return nil, fmt.Errorf("%s already exists", target)
+	}
+	tokFile := pkg.Fset.File(file.Pos())
+	//nolint:gosec // G304: File path comes from the loaded package.
+	src, err := os.ReadFile(tokFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", tokFile.Name(), err)
+	}
+
+	c := &converter{
+		pkg:     pkg,
+		info:    pkg.TypesInfo,
+		tokFile: tokFile,
+		src:     src,
+		goVer:   pkg.TypesInfo.FileVersions[file],
+		toBench: toBench,
+		renames: make(map[*ast.Ident]string),
+	}
+	from, to := "T", "B"
+	if !toBench {
+		from, to = "B", "T"
+	}
+	c.param = c.freeName(fd, strings.ToLower(to), strings.ToLower(to)+strings.ToLower(to))
+	c.collectParams(fd, from, to)
+
+	var body string
+	if toBench {
+		body = c.testToBench(fd.Body.List, fd.Body.Lbrace+1)
+	} else {
+		body = c.benchToTest(fd.Body.List, fd.Body.Lbrace+1)
+	}
+	code := fmt.Sprintf("func %s(%s *testing.%s) {%s\n}\n", target, c.param, to, body)
+	if fd.Doc != nil {
+		code = fmt.Sprintf("// %s is generated from %s.\n", target, name) + code
+	}
+	if formatted, err := format.Source([]byte("package p\n\n" + code)); err == nil {
+		code = strings.TrimPrefix(string(formatted), "package p\n\n")
+	}
+
+	conv := &Conversion{
+		Name:     target,
+		Code:     code,
+		Filename: tokFile.Name(),
+		pkgPath:  pkg.PkgPath,
+		end:      tokFile.Offset(fd.End()),
+	}
+	if toBench {
+		conv.Notes = append(conv.Notes, "Assertions were dropped from the measured loop; error checks are kept so a failing call stops the benchmark.")
+		if len(c.measured) == 0 {
+			conv.Notes = append(conv.Notes, "No call into the package under test was found; the loop measures every non-assertion statement.")
+		}
+	} else {
+		conv.Notes = append(conv.Notes, "The benchmark body runs once. Add assertions on its results where the TODO marks them.")
+	}
+	for _, h := range c.helpers() {
+		conv.Notes = append(conv.Notes, fmt.Sprintf("`%s` takes a `*testing.%s`; change that parameter to `testing.TB` so both tests and benchmarks can call it.", h, from))
+	}
+	return conv, nil
+}
+
+// findTest returns the package variant, file and declaration of the test function name.
+func findTest(pkgs []*packages.Package, name string) (*packages.Package, *ast.File, *ast.FuncDecl) {
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			if !strings.HasSuffix(pkg.Fset.File(file.Pos()).Name(), "_test.go") {
+				continue
+			}
+			for _, decl := range file.Decls {
+				if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.Name == name && fd.Body != nil {
+					return pkg, file, fd
+				}
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+type converter struct {
+	pkg     *packages.Package
+	info    *types.Info
+	tokFile *token.File
+	src     []byte
+	goVer   string
+	toBench bool
+
+	param    string                // new name of the *testing.T or *testing.B parameters
+	params   map[types.Object]bool // those parameters
+	renames  map[*ast.Ident]string // identifier rewrites applied when rendering
+	tokEdits []token.Pos           // := operators rendered as =
+	measured []ast.Stmt            // calls into the package under test, for notes
+	helperFn map[string]bool       // functions called with a converted parameter
+}
+
+func (c *converter) offset(pos token.Pos) int { return c.tokFile.Offset(pos) }
+
+// collectParams finds the *testing.<from> parameters of fd and of the function literals inside it
+// and schedules their renaming and retyping to *testing.<to>.
+func (c *converter) collectParams(fd *ast.FuncDecl, from, to string) {
+	c.params = make(map[types.Object]bool)
+	c.helperFn = make(map[string]bool)
+	visit := func(ft *ast.FuncType) {
+		for _, field := range ft.Params.List {
+			star, ok := field.Type.(*ast.StarExpr)
+			if !ok {
+				continue
+			}
+			sel, ok := star.X.(*ast.SelectorExpr)
+			if !ok || !isTesting(c.info.TypeOf(star.X), from) {
+				continue
+			}
+			c.renames[sel.Sel] = to
+			for _, name := range field.Names {
+				if obj := c.info.Defs[name]; obj != nil {
+					c.params[obj] = true
+					c.renames[name] = c.param
+				}
+			}
+		}
+	}
+	visit(fd.Type)
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			visit(lit.Type)
+		}
+		return true
+	})
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if ok && c.params[c.info.Uses[id]] {
+			c.renames[id] = c.param
+		}
+		return true
+	})
+}
+
+// testToBench renders a test body as a benchmark body. Subtests become sub-benchmarks; in a body
+// without subtests the statements before the first call into the package under test are setup
+// and the rest, minus assertions, run in the benchmark loop.
+func (c *converter) testToBench(list []ast.Stmt, start token.Pos) string {
+	if !c.containsRun(list) {
+		return c.measure(list, start)
+	}
+	var sb strings.Builder
+	prev := start
+	for _, stmt := range list {
+		sb.WriteString(c.gap(prev, stmt.Pos()))
+		sb.WriteString(c.subtests(stmt, c.testToBench))
+		prev = stmt.End()
+	}
+	return sb.String()
+}
+
+// benchToTest renders a benchmark body as a test body: the benchmark loop runs once and timer
+// calls are dropped.
+func (c *converter) benchToTest(list []ast.Stmt, start token.Pos) string {
+	var sb strings.Builder
+	prev := start
+	for _, stmt := range list {
+		gap := c.gap(prev, stmt.Pos())
+		prev = stmt.End()
+		if c.isParamCall(stmt, benchSetup) {
+			continue
+		}
+		if loopBody, loopVar := c.benchLoop(stmt); loopBody != nil {
+			// The loop's comments stay; its body's first statement starts the next line.
+			sb.WriteString(strings.TrimRight(gap, " \t\n"))
+			if loopVar != "" {
+				fmt.Fprintf(&sb, "\n%s := 0", loopVar)
+			}
+			sb.WriteString(c.benchToTest(loopBody.List, loopBody.Lbrace+1))
+			sb.WriteString("\n// TODO: check the results.")
+			continue
+		}
+		sb.WriteString(gap)
+		sb.WriteString(c.subtests(stmt, c.benchToTest))
+	}
+	return sb.String()
+}
+
+// subtests renders stmt, converting the t.Run/b.Run calls it contains with convert.
+func (c *converter) subtests(stmt ast.Stmt, convert func([]ast.Stmt, token.Pos) string) string {
+	if call, lit := c.runCall(stmt); lit != nil {
+		return c.render(stmt.Pos(), lit.Body.Lbrace+1) + convert(lit.Body.List, lit.Body.Lbrace+1) + "\n" +
+			c.render(lit.Body.Rbrace, call.End())
+	}
+	var body *ast.BlockStmt
+	switch s := stmt.(type) {
+	case *ast.RangeStmt:
+		body = s.Body
+	case *ast.ForStmt:
+		body = s.Body
+	case *ast.BlockStmt:
+		body = s
+	}
+	if body == nil || !c.containsRun(body.List) {
+		return c.render(stmt.Pos(), stmt.End())
+	}
+	var sb strings.Builder
+	sb.WriteString(c.render(stmt.Pos(), body.Lbrace+1))
+	prev := body.Lbrace + 1
+	for _, inner := range body.List {
+		sb.WriteString(c.gap(prev, inner.Pos()))
+		sb.WriteString(c.subtests(inner, convert))
+		prev = inner.End()
+	}
+	sb.WriteString(c.gap(prev, body.Rbrace))
+	sb.WriteString(c.render(body.Rbrace, stmt.End()))
+	return sb.String()
+}
+
+// measure splits a subtest-free test body into setup and the benchmark loop.
+func (c *converter) measure(list []ast.Stmt, start token.Pos) string {
+	// Each statement keeps the comments between it and the statement before it in the original.
+	starts := make(map[ast.Stmt]token.Pos, len(list))
+	prev := start
+	for _, stmt := range list {
+		starts[stmt], prev = prev, stmt.End()
+	}
+	var kept []ast.Stmt
+	for _, stmt := range list {
+		if c.isParamCall(stmt, map[string]bool{"Parallel": true, "Helper": true}) {
+			continue
+		}
+		kept = append(kept, stmt)
+	}
+	act := -1
+	for i, stmt := range kept {
+		if c.callsPackage(stmt) {
+			act = i
+			c.measured = append(c.measured, stmt)
+			break
+		}
+	}
+	setup, loop := kept, []ast.Stmt(nil)
+	if act >= 0 {
+		setup, loop = kept[:act], kept[act:]
+	} else {
+		setup, loop = nil, kept
+	}
+	var measured []ast.Stmt
+	for _, stmt := range loop {
+		if c.isAssertion(stmt) {
+			continue
+		}
+		measured = append(measured, stmt)
+	}
+	// Setup statements whose variables are now unused only fed the assertions.
+	var live []ast.Stmt
+	for i, stmt := range setup {
+		if c.unusedDefine(stmt, append(append([]ast.Stmt(nil), setup[i+1:]...), measured...)) {
+			continue
+		}
+		live = append(live, stmt)
+	}
+	for i, stmt := range measured {
+		c.blankUnused(stmt, measured[i+1:])
+	}
+	for i, stmt := range live {
+		c.blankUnused(stmt, append(append([]ast.Stmt(nil), live[i+1:]...), measured...))
+	}
+
+	var sb strings.Builder
+	for _, stmt := range live {
+		sb.WriteString(c.gap(starts[stmt], stmt.Pos()))
+		sb.WriteString(c.render(stmt.Pos(), stmt.End()))
+	}
+	switch {
+	case version.Compare(c.goVer, "go1.24") >= 0:
+		fmt.Fprintf(&sb, "\nfor %s.Loop() {", c.param)
+	case version.Compare(c.goVer, "go1.22") >= 0:
+		fmt.Fprintf(&sb, "\n%s.ResetTimer()\nfor range %s.N {", c.param, c.param)
+	default:
+		fmt.Fprintf(&sb, "\n%s.ResetTimer()\nfor i := 0; i < %s.N; i++ {", c.param, c.param)
+	}
+	for _, stmt := range measured {
+		sb.WriteString(c.gap(starts[stmt], stmt.Pos()))
+		sb.WriteString(c.render(stmt.Pos(), stmt.End()))
+	}
+	sb.WriteString("\n}")
+	return sb.String()
+}
+
+// benchLoop returns the body of a "for i := 0; i < b.N; i++", "for range b.N" or "for b.Loop()"
+// loop, and the loop variable if the body uses it. b.RunParallel loops are unwrapped too.
+func (c *converter) benchLoop(stmt ast.Stmt) (*ast.BlockStmt, string) {
+	switch s := stmt.(type) {
+	case *ast.ForStmt:
+		if call, ok := s.Cond.(*ast.CallExpr); ok && c.isParamMethod(call, "Loop") {
+			return s.Body, ""
+		}
+		cond, ok := s.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.LSS || !c.isParamField(cond.Y, "N") {
+			return nil, ""
+		}
+		if id, ok := cond.X.(*ast.Ident); ok && c.usedIn(c.info.Uses[id], s.Body) {
+			return s.Body, id.Name
+		}
+		return s.Body, ""
+	case *ast.RangeStmt:
+		if !c.isParamField(s.X, "N") {
+			return nil, ""
+		}
+		if id, ok := s.Key.(*ast.Ident); ok && id.Name != "_" && c.usedIn(c.info.Defs[id], s.Body) {
+			return s.Body, id.Name
+		}
+		return s.Body, ""
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok || !c.isParamMethod(call, "RunParallel") || len(call.Args) != 1 {
+			return nil, ""
+		}
+		lit, ok := call.Args[0].(*ast.FuncLit)
+		if !ok {
+			return nil, ""
+		}
+		for _, inner := range lit.Body.List {
+			if loop, ok := inner.(*ast.ForStmt); ok && loop.Init == nil && loop.Post == nil {
+				if next, ok := loop.Cond.(*ast.CallExpr); ok {
+					if sel, ok := next.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Next" {
+						return loop.Body, ""
+					}
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// runCall returns the call and function literal of a t.Run(name, func(t *testing.T) {...})
+// statement.
+func (c *converter) runCall(stmt ast.Stmt) (*ast.CallExpr, *ast.FuncLit) {
+	var expr ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		expr = s.X
+	case *ast.IfStmt:
+		// if !t.Run(...) { ... }
+		if u, ok := s.Cond.(*ast.UnaryExpr); ok {
+			expr = u.X
+		}
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || !c.isParamMethod(call, "Run") || len(call.Args) != 2 {
+		return nil, nil
+	}
+	lit, ok := call.Args[1].(*ast.FuncLit)
+	if !ok {
+		return nil, nil
+	}
+	return call, lit
+}
+
+func (c *converter) containsRun(list []ast.Stmt) bool {
+	for _, stmt := range list {
+		found := false
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && c.isParamMethod(call, "Run") {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// callsPackage reports whether stmt calls a function declared in a non-test file of the package
+// under test.
+func (c *converter) callsPackage(stmt ast.Stmt) bool {
+	target := strings.TrimSuffix(c.pkg.PkgPath, "_test")
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return !found
+		}
+		fn := typeutil.Callee(c.info, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != target {
+			return !found
+		}
+		if _, isFunc := fn.(*types.Func); isFunc && !strings.HasSuffix(c.pkg.Fset.Position(fn.Pos()).Filename, "_test.go") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// isAssertion reports whether stmt checks results: it calls an assertion method of a test
+// parameter or passes one to another function (as assertion helpers do), and is not an error
+// check, which stays in the loop.
+func (c *converter) isAssertion(stmt ast.Stmt) bool {
+	if ifStmt, ok := stmt.(*ast.IfStmt); ok && c.isErrCheck(ifStmt.Cond) && ifStmt.Else == nil {
+		return false
+	}
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return !found
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && assertions[sel.Sel.Name] && c.params[c.objectOf(sel.X)] {
+			found = true
+		}
+		for _, arg := range call.Args {
+			if c.params[c.objectOf(arg)] {
+				if fn := typeutil.Callee(c.info, call); fn != nil && !strings.Contains(fn.Name(), "NoError") {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func (c *converter) isErrCheck(cond ast.Expr) bool {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.NEQ {
+		return false
+	}
+	id, ok := bin.Y.(*ast.Ident)
+	if !ok || id.Name != "nil" {
+		return false
+	}
+	t := c.info.TypeOf(bin.X)
+	return t != nil && types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// unusedDefine reports whether stmt only declares variables that rest does not use.
+func (c *converter) unusedDefine(stmt ast.Stmt, rest []ast.Stmt) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return false
+	}
+	for _, lhs := range assign.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		if obj := c.info.Defs[id]; obj != nil && c.usedInAny(obj, rest) {
+			return false
+		}
+	}
+	return true
+}
+
+// blankUnused renames the variables stmt declares but rest does not use to _, turning := into =
+// when no variable is left.
+func (c *converter) blankUnused(stmt ast.Stmt, rest []ast.Stmt) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return
+	}
+	blanked := 0
+	for _, lhs := range assign.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := c.info.Defs[id]
+		if id.Name == "_" || (obj != nil && !c.usedInAny(obj, rest)) {
+			c.renames[id] = "_"
+			blanked++
+		}
+	}
+	if blanked == len(assign.Lhs) {
+		c.tokEdits = append(c.tokEdits, assign.TokPos)
+	}
+}
+
+func (c *converter) usedInAny(obj types.Object, stmts []ast.Stmt) bool {
+	for _, s := range stmts {
+		if c.usedIn(obj, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *converter) usedIn(obj types.Object, n ast.Node) bool {
+	if obj == nil {
+		return false
+	}
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && c.info.Uses[id] == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (c *converter) isParamCall(stmt ast.Stmt, methods map[string]bool) bool {
+	es, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := es.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && methods[sel.Sel.Name] && c.params[c.objectOf(sel.X)]
+}
+
+func (c *converter) isParamMethod(call *ast.CallExpr, method string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method && c.params[c.objectOf(sel.X)]
+}
+
+func (c *converter) isParamField(expr ast.Expr, field string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == field && c.params[c.objectOf(sel.X)]
+}
+
+func (c *converter) objectOf(expr ast.Expr) types.Object {
+	if id, ok := ast.Unparen(expr).(*ast.Ident); ok {
+		return c.info.Uses[id]
+	}
+	return nil
+}
+
+// helpers returns the functions outside fd that are called with a converted parameter in the
+// generated code and would not accept its new type.
+func (c *converter) helpers() []string {
+	var names []string
+	for name := range c.helperFn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// render returns the source in [from, to) with the scheduled identifier rewrites applied.
+func (c *converter) render(from, to token.Pos) string {
+	start, end := c.offset(from), c.offset(to)
+	var edits []shared.TextEdit
+	for id, name := range c.renames {
+		if s := c.offset(id.Pos()); s >= start && s+len(id.Name) <= end {
+			edits = append(edits, shared.TextEdit{Start: s - start, End: s - start + len(id.Name), New: name})
+		}
+	}
+	for _, pos := range c.tokEdits {
+		if s := c.offset(pos); s >= start && s+2 <= end {
+			edits = append(edits, shared.TextEdit{Start: s - start, End: s - start + 2, New: "="})
+		}
+	}
+	c.noteHelpers(from, to)
+	out, err := shared.ApplyEdits(c.src[start:end], edits)
+	if err != nil {
+		return string(c.src[start:end])
+	}
+	return string(out)
+}
+
+// noteHelpers records calls in [from, to) that pass a converted parameter to a function whose
+// parameter still has the old type.
+func (c *converter) noteHelpers(from, to token.Pos) {
+	want := "T"
+	if !c.toBench {
+		want = "B"
+	}
+	ast.Inspect(c.fileNode(), func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || call.Pos() < from || call.End() > to {
+			return true
+		}
+		sig, ok := c.info.TypeOf(call.Fun).(*types.Signature)
+		if !ok {
+			return true
+		}
+		for i, arg := range call.Args {
+			if !c.params[c.objectOf(arg)] || i >= sig.Params().Len() {
+				continue
+			}
+			if p, ok := sig.Params().At(i).Type().(*types.Pointer); ok && isTesting(p.Elem(), want) {
+				if fn := typeutil.Callee(c.info, call); fn != nil {
+					c.helperFn[fn.Name()] = true
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (c *converter) fileNode() ast.Node {
+	for _, f := range c.pkg.Syntax {
+		if c.pkg.Fset.File(f.Pos()) == c.tokFile {
+			return f
+		}
+	}
+	return &ast.BadStmt{}
+}
+
+// gap returns the text between two statements: line breaks and comments.
+func (c *converter) gap(from, to token.Pos) string {
+	if from >= to {
+		return "\n"
+	}
+	return string(c.src[c.offset(from):c.offset(to)])
+}
+
+// freeName returns the first candidate not used as an identifier in fd, other than by the test
+// parameters themselves.
+func (c *converter) freeName(fd *ast.FuncDecl, candidates ...string) string {
+	used := make(map[string]bool)
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if v, ok := c.info.Uses[id].(*types.Var); !ok || !isTestingPtr(v.Type()) {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	for _, name := range candidates {
+		if !used[name] {
+			return name
+		}
+	}
+	return candidates[len(candidates)-1] + "2"
+}
+
+func isTesting(t types.Type, name string) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == name
+}
+
+func isTestingPtr(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	return ok && (isTesting(p.Elem(), "T") || isTesting(p.Elem(), "B"))
+}
+
+func rel(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,195 @@
+package testconv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const calcSrc = `package calc
+
+import "strings"
+
+func Normalize(s string) (string, error) { return strings.ToLower(strings.TrimSpace(s)), nil }
+`
+
+const calcTestSrc = `package calc
+
+import (
+	"strings"
+	"testing"
+)
+
+func newInput(t *testing.T) string {
+	t.Helper()
+	return strings.Repeat(" Hello ", 10)
+}
+
+// TestNormalize checks normalization.
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	// A long input.
+	in := strings.Repeat(" Hello ", 100)
+	want := strings.Repeat("hello ", 1)
+	got, err := Normalize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeTable(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "a", "a"},
+		{"upper", "A", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q", got)
+			}
+		})
+	}
+}
+
+func TestHelper(t *testing.T) {
+	in := newInput(t)
+	if _, err := Normalize(in); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkJoin(b *testing.B) {
+	parts := []string{"A", "B"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s, _ := Normalize(parts[i%2])
+		_ = s
+	}
+}
+
+func BenchmarkSub(b *testing.B) {
+	for _, n := range []int{1, 10} {
+		b.Run(strings.Repeat("x", n), func(b *testing.B) {
+			for b.Loop() {
+				Normalize(strings.Repeat("A", n))
+			}
+		})
+	}
+}
+`
+
+func setup(t *testing.T, goVersion string) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod":            "module example.com/app\n\ngo " + goVersion + "\n",
+		"calc/calc.go":      calcSrc,
+		"calc/calc_test.go": calcTestSrc,
+	})
+}
+
+func run(t *testing.T, params Params) string {
+	t.Helper()
+	res, _, err := Handler(context.Background(), nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	return out
+}
+
+func TestHandler_TestToBenchmark(t *testing.T) {
+	dir := setup(t, "1.24")
+	run(t, Params{Dir: dir, Package: "./calc", Name: "TestNormalize"})
+	run(t, Params{Dir: dir, Package: "./calc", Name: "TestNormalizeTable"})
+
+	src, err := os.ReadFile(filepath.Join(dir, "calc", "calc_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	for _, want := range []string{
+		// Setup and its comment are kept, the parallel call and the unused want are not.
+		"func BenchmarkNormalize(b *testing.B) {\n\t// A long input.\n\tin := strings.Repeat(\" Hello \", 100)\n\tfor b.Loop() {\n\t\t_, err := Normalize(in)\n\t\tif err != nil {\n\t\t\tb.Fatal(err)\n\t\t}\n\t}\n}",
+		"\t\tb.Run(tt.name, func(b *testing.B) {\n\t\t\tfor b.Loop() {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected file to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHandler_OlderGo(t *testing.T) {
+	dir := setup(t, "1.22")
+	out := run(t, Params{Dir: dir, Package: "./calc", Name: "TestNormalize", DryRun: true})
+	if !strings.Contains(out, "b.ResetTimer()\n\tfor range b.N {") {
+		t.Errorf("expected a b.N loop before Go 1.24, got:\n%s", out)
+	}
+}
+
+func TestHandler_Helper(t *testing.T) {
+	dir := setup(t, "1.24")
+	out := run(t, Params{Dir: dir, Package: "./calc", Name: "TestHelper", DryRun: true})
+	if !strings.Contains(out, "`newInput` takes a `*testing.T`; change that parameter to `testing.TB`") {
+		t.Errorf("expected a note about the helper, got:\n%s", out)
+	}
+}
+
+func TestHandler_BenchmarkToTest(t *testing.T) {
+	dir := setup(t, "1.24")
+	run(t, Params{Dir: dir, Package: "./calc", Name: "BenchmarkJoin"})
+	run(t, Params{Dir: dir, Package: "./calc", Name: "BenchmarkSub"})
+
+	src, err := os.ReadFile(filepath.Join(dir, "calc", "calc_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	for _, want := range []string{
+		"func TestJoin(t *testing.T) {\n\tparts := []string{\"A\", \"B\"}\n\ti := 0\n\ts, _ := Normalize(parts[i%2])\n\t_ = s\n\t// TODO: check the results.\n}",
+		"\t\tt.Run(strings.Repeat(\"x\", n), func(t *testing.T) {\n\t\t\tNormalize(strings.Repeat(\"A\", n))\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected file to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	dir := setup(t, "1.24")
+	tests := []struct {
+		name, want string
+	}{
+		{"Normalize", "neither a TestXxx nor a BenchmarkXxx"},
+		{"TestMissing", "not found"},
+		{"BenchmarkNormalize", "not found"},
+	}
+	for _, tt := range tests {
+		res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Package: "./calc", Name: tt.name})
+		if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, tt.want) {
+			t.Errorf("%s: expected error %q, got: %s", tt.name, tt.want, text)
+		}
+	}
+	run(t, Params{Dir: dir, Package: "./calc", Name: "TestNormalize"})
+	res, _, _ := Handler(context.Background(), nil, Params{Dir: dir, Package: "./calc", Name: "TestNormalize"})
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "BenchmarkNormalize already exists") {
+		t.Errorf("expected a conflict error, got: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/danicat/godoctor/internal/roots"
 	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -59,14 +60,20 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 	}
 
 	dbPath := filepath.Join(absDir, dbFile)
+	progress := shared.NewProgress(ctx, req)
 
 	// Build the DB if it doesn't exist or if rebuild is requested
 	if args.Rebuild || !fileExists(dbPath) {
+		progress.Step("Running the tests to build the test database")
 		buildCmd := exec.CommandContext(ctx, "go", "run", "github.com/danicat/testquery@latest",
 			"build", "--pkg", pkg, "--output", dbFile)
 		buildCmd.Dir = absDir
-		out, buildErr := buildCmd.CombinedOutput()
-		buildOutput := filterNoise(string(out))
+		out, buildErr := shared.RunLines(buildCmd, func(line string) {
+			if line = strings.TrimSpace(line); line != "" {
+				progress.Tick(line)
+			}
+		})
+		buildOutput := filterNoise(out)
 
 		if buildErr != nil {
 			// Build may fail if tests fail, but the DB might still be usable
@@ -78,6 +85,7 @@ func toolHandler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*m
 	}
 
 	// Query the persistent DB (offline mode)
+	progress.Step("Running the query")
 	cmd := exec.CommandContext(ctx, "go", "run", "github.com/danicat/testquery@latest",
 		"query", "--db", dbFile, "--format", "table", args.Query)
 	cmd.Dir = absDir
@@ -0,0 +1,577 @@
+// Package wiring implements the inspect_wiring tool. It treats every exported NewX function in the
+// module as a provider of its first result and a consumer of its parameters, reports who needs what,
+// and can generate the manual wiring function that main() would otherwise assemble by hand.
+package wiring
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danicat/godoctor/internal/roots"
+	"github.com/danicat/godoctor/internal/toolnames"
+	"github.com/danicat/godoctor/internal/tools/shared"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Register registers the tool with the server.
+func Register(server *mcp.Server) {
+	def := toolnames.Registry["inspect_wiring"]
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+	}, Handler)
+}
+
+// Params defines the input parameters.
+type Params struct {
+	Dir      string `json:"dir,omitempty" jsonschema:"The absolute module directory. Always pass absolute paths in multi-root workspaces."`
+	Packages string `json:"packages,omitempty" jsonschema:"Package pattern to scan for constructors (default: ./...)"`
+	Root     string `json:"root,omitempty" jsonschema:"Optional: type to build (e.g. 'server.Server' or '*example.com/app/server.Server'); generates a wiring function for it"`
+	FuncName string `json:"func_name,omitempty" jsonschema:"Name of the generated wiring function (default: wire<Type>)"`
+	Output   string `json:"output,omitempty" jsonschema:"Optional: absolute path of a file to write the wiring function to (build-verified, rolled back on failure)"`
+}
+
+// Constructor is a NewX function found in the module.
+type Constructor struct {
+	Func     *types.Func
+	Provides types.Type
+	Needs    []Dependency
+	HasError bool
+}
+
+// Dependency is one parameter of a constructor.
+type Dependency struct {
+	Name     string
+	Type     types.Type
+	Variadic bool
+}
+
+// Graph indexes constructors by the type they provide.
+type Graph struct {
+	Constructors []*Constructor
+	providers    map[string][]*Constructor
+}
+
+// Handler handles the inspect_wiring tool execution.
+func Handler(ctx context.Context, req *mcp.CallToolRequest, args Params) (*mcp.CallToolResult, any, error) {
+	var session *mcp.ServerSession
+	if req != nil {
+		session = req.Session
+	}
+	dir := args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := roots.Global.Validate(session, dir)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	pattern := args.Packages
+	if pattern == "" {
+		pattern = "./..."
+	}
+
+	pkgs, err := shared.LoadPackages(ctx, absDir, pattern, false)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+	g := Build(pkgs)
+
+	var sb strings.Builder
+	sb.WriteString(g.Report())
+
+	if args.Root != "" {
+		root, err := g.Lookup(args.Root)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		pkgName := "main"
+		if args.Output != "" {
+			outPath, err := roots.Global.Validate(session, args.Output)
+			if err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			args.Output = outPath
+			pkgName = packageNameIn(filepath.Dir(outPath))
+		}
+		fn := args.FuncName
+		if fn == "" {
+			fn = "wire" + typeName(root)
+		}
+		code, notes, err := g.Generate(root, fn, pkgName)
+		if err != nil {
+			return errorResult(err.Error()), nil, nil
+		}
+		fmt.Fprintf(&sb, "\n## Wiring for `%s`\n\n", types.TypeString(root, nil))
+		for _, n := range notes {
+			fmt.Fprintf(&sb, "- ⚠️ %s\n", n)
+		}
+		if len(notes) > 0 {
+			sb.WriteString("\n")
+		}
+		if args.Output != "" {
+			if _, err := os.Stat(args.Output); err == nil {
+				return errorResult(fmt.Sprintf("%s already exists; choose a new file for the wiring function", args.Output)), nil, nil
+			}
+			if err := (shared.Changeset{args.Output: code}).Apply(ctx, absDir); err != nil {
+				return errorResult(err.Error()), nil, nil
+			}
+			fmt.Fprintf(&sb, "✅ Wrote `%s` to `%s`.\n\n", fn, args.Output)
+		}
+		fmt.Fprintf(&sb, "```go\n%s```\n", code)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// Build collects the constructors of every non-main package in pkgs.
+func Build(pkgs []*packages.Package) *Graph {
+	g := &Graph{providers: make(map[string][]*Constructor)}
+	errType := types.Universe.Lookup("error").Type()
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.Name == "main" {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			fn, ok := scope.Lookup(name).(*types.Func)
+			if !ok || !fn.Exported() || !strings.HasPrefix(name, "New") {
+				continue
+			}
+			sig := fn.Type().(*types.Signature)
+			if sig.TypeParams().Len() > 0 {
+				continue
+			}
+			res := sig.Results()
+			if res.Len() == 0 || res.Len() > 2 || (res.Len() == 2 && !types.Identical(res.At(1).Type(), errType)) {
+				continue
+			}
+			c := &Constructor{Func: fn, Provides: res.At(0).Type(), HasError: res.Len() == 2}
+			for i := 0; i < sig.Params().Len(); i++ {
+				p := sig.Params().At(i)
+				c.Needs = append(c.Needs, Dependency{
+					Name:     p.Name(),
+					Type:     p.Type(),
+					Variadic: sig.Variadic() && i == sig.Params().Len()-1,
+				})
+			}
+			g.Constructors = append(g.Constructors, c)
+			key := types.TypeString(c.Provides, nil)
+			g.providers[key] = append(g.providers[key], c)
+		}
+	}
+	sort.Slice(g.Constructors, func(i, j int) bool {
+		return g.Constructors[i].Func.FullName() < g.Constructors[j].Func.FullName()
+	})
+	return g
+}
+
+// Providers returns the constructors that can satisfy t: exact matches first, then, for interface
+// types, constructors whose result implements the interface.
+func (g *Graph) Providers(t types.Type) []*Constructor {
+	if exact := g.providers[types.TypeString(t, nil)]; len(exact) > 0 {
+		return exact
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok || iface.Empty() {
+		return nil
+	}
+	var impls []*Constructor
+	for _, c := range g.Constructors {
+		if types.Implements(c.Provides, iface) {
+			impls = append(impls, c)
+		}
+	}
+	return impls
+}
+
+// Lookup resolves a user-supplied type name against the provided types. It accepts the full form
+// ("*example.com/app/server.Server"), the package-qualified form ("server.Server") or a bare name.
+func (g *Graph) Lookup(name string) (types.Type, error) {
+	want := strings.TrimPrefix(name, "*")
+	pointer := strings.HasPrefix(name, "*")
+	var matches []types.Type
+	seen := make(map[string]bool)
+	for _, c := range g.Constructors {
+		full := types.TypeString(c.Provides, nil)
+		if seen[full] {
+			continue
+		}
+		_, isPtr := c.Provides.(*types.Pointer)
+		if pointer && !isPtr {
+			continue
+		}
+		bare := strings.TrimPrefix(full, "*")
+		short := strings.TrimPrefix(types.TypeString(c.Provides, (*types.Package).Name), "*")
+		if bare == want || short == want || typeName(c.Provides) == want {
+			seen[full] = true
+			matches = append(matches, c.Provides)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no constructor provides %s", name)
+	case len(matches) > 1:
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = types.TypeString(m, nil)
+		}
+		return nil, fmt.Errorf("%s is ambiguous: %s", name, strings.Join(names, ", "))
+	}
+	return matches[0], nil
+}
+
+// Report renders the constructor table and the types nobody in the module provides.
+func (g *Graph) Report() string {
+	var sb strings.Builder
+	sb.WriteString("# Dependency Wiring Report\n\n")
+	if len(g.Constructors) == 0 {
+		sb.WriteString("No NewX constructors found.\n")
+		return sb.String()
+	}
+	short := func(t types.Type) string { return types.TypeString(t, (*types.Package).Name) }
+
+	neededBy := make(map[string][]string)
+	unprovided := make(map[string][]string)
+	fmt.Fprintf(&sb, "## Constructors (%d)\n\n| Constructor | Provides | Needs |\n| :--- | :--- | :--- |\n", len(g.Constructors))
+	for _, c := range g.Constructors {
+		cname := c.Func.Pkg().Name() + "." + c.Func.Name()
+		var needs []string
+		for _, d := range c.Needs {
+			if d.Variadic {
+				needs = append(needs, fmt.Sprintf("%s (optional)", short(d.Type)))
+				continue
+			}
+			needs = append(needs, short(d.Type))
+			providers := g.Providers(d.Type)
+			for _, p := range providers {
+				key := p.Func.Pkg().Name() + "." + p.Func.Name()
+				neededBy[key] = append(neededBy[key], cname)
+			}
+			if len(providers) == 0 {
+				unprovided[short(d.Type)] = append(unprovided[short(d.Type)], cname)
+			}
+		}
+		provides := short(c.Provides)
+		if c.HasError {
+			provides += ", error"
+		}
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %s |\n", cname, provides, codeList(needs))
+	}
+
+	sb.WriteString("\n## Who Needs What\n\n")
+	for _, c := range g.Constructors {
+		cname := c.Func.Pkg().Name() + "." + c.Func.Name()
+		users := neededBy[cname]
+		if len(users) == 0 {
+			fmt.Fprintf(&sb, "- `%s`: not used by any constructor (a root, or wired in main)\n", cname)
+			continue
+		}
+		fmt.Fprintf(&sb, "- `%s` → %s\n", cname, codeList(dedupe(users)))
+	}
+
+	if len(unprovided) > 0 {
+		sb.WriteString("\n## Supplied by the Caller\n\nNo constructor provides these types; main() must build them (configuration, clients, primitives).\n\n")
+		keys := make([]string, 0, len(unprovided))
+		for k := range unprovided {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "- `%s` ← %s\n", k, codeList(dedupe(unprovided[k])))
+		}
+	}
+	return sb.String()
+}
+
+// Generate writes a function that builds root by calling constructors in dependency order. Types
+// that no constructor provides become parameters of the function.
+func (g *Graph) Generate(root types.Type, funcName, pkgName string) ([]byte, []string, error) {
+	w := &writer{
+		g:        g,
+		built:    make(map[string]string),
+		params:   make(map[string]string),
+		visiting: make(map[string]bool),
+		names:    make(map[string]bool),
+		imports:  make(map[string]string),
+	}
+	w.names["err"] = true
+	// Reserve every package name the function may reference, so that no variable shadows an import.
+	reserve := func(p *types.Package) string {
+		w.names[p.Name()] = true
+		return p.Name()
+	}
+	for _, c := range g.Constructors {
+		reserve(c.Func.Pkg())
+		for _, d := range c.Needs {
+			types.TypeString(d.Type, reserve)
+		}
+	}
+	w.zero = zeroValue(root, w.qualifier)
+	result, err := w.build(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+	if len(w.imports) > 0 {
+		sb.WriteString("import (\n")
+		paths := make([]string, 0, len(w.imports))
+		for path := range w.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(&sb, "\t%q\n", path)
+		}
+		sb.WriteString(")\n\n")
+	}
+	rootStr := types.TypeString(root, w.qualifier)
+	fmt.Fprintf(&sb, "// %s builds a %s from its constructors.\n", funcName, rootStr)
+	fmt.Fprintf(&sb, "func %s(%s) (%s, error) {\n", funcName, strings.Join(w.paramList, ", "), rootStr)
+	sb.WriteString(w.body.String())
+	fmt.Fprintf(&sb, "\treturn %s, nil\n}\n", result)
+
+	out, err := imports.Process(funcName+".go", []byte(sb.String()), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format wiring function: %w", err)
+	}
+	return out, w.notes, nil
+}
+
+type writer struct {
+	g         *Graph
+	built     map[string]string // type -> variable holding it
+	params    map[string]string // param key -> parameter name
+	paramList []string
+	visiting  map[string]bool
+	names     map[string]bool
+	imports   map[string]string
+	zero      string // zero value of the root type, returned on error
+	body      strings.Builder
+	notes     []string
+}
+
+func (w *writer) qualifier(p *types.Package) string {
+	w.imports[p.Path()] = p.Name()
+	w.names[p.Name()] = true
+	return p.Name()
+}
+
+// build emits the statements that produce a value of type t and returns the expression for it.
+func (w *writer) build(t types.Type) (string, error) {
+	key := types.TypeString(t, nil)
+	if v, ok := w.built[key]; ok {
+		return v, nil
+	}
+	providers := w.g.Providers(t)
+	if len(providers) == 0 {
+		return "", nil
+	}
+	if w.visiting[key] {
+		return "", fmt.Errorf("dependency cycle through %s", key)
+	}
+	w.visiting[key] = true
+	defer delete(w.visiting, key)
+
+	c := providers[0]
+	if len(providers) > 1 {
+		var names []string
+		for _, p := range providers {
+			names = append(names, p.Func.Pkg().Name()+"."+p.Func.Name())
+		}
+		w.notes = append(w.notes, fmt.Sprintf("%s has several providers (%s); using %s.", types.TypeString(t, (*types.Package).Name), strings.Join(names, ", "), names[0]))
+	}
+
+	var args []string
+	for _, d := range c.Needs {
+		if d.Variadic {
+			continue
+		}
+		arg, err := w.build(d.Type)
+		if err != nil {
+			return "", err
+		}
+		if arg == "" {
+			arg = w.param(d)
+		}
+		args = append(args, arg)
+	}
+
+	w.qualifier(c.Func.Pkg())
+	v := w.varName(c.Provides)
+	call := fmt.Sprintf("%s.%s(%s)", c.Func.Pkg().Name(), c.Func.Name(), strings.Join(args, ", "))
+	if c.HasError {
+		fmt.Fprintf(&w.body, "\t%s, err := %s\n\tif err != nil {\n\t\treturn %s, err\n\t}\n", v, call, w.zero)
+	} else {
+		fmt.Fprintf(&w.body, "\t%s := %s\n", v, call)
+	}
+	w.built[key] = v
+	return v, nil
+}
+
+// param returns the wiring function parameter for an unprovided dependency. Named types are shared
+// by every constructor that needs them; primitives are keyed by parameter name as well, so that an
+// "addr string" and a "dsn string" stay separate.
+func (w *writer) param(d Dependency) string {
+	key := types.TypeString(d.Type, nil)
+	if _, named := types.Unalias(d.Type).(*types.Named); !named {
+		key = d.Name + " " + key
+	}
+	if name, ok := w.params[key]; ok {
+		return name
+	}
+	base := d.Name
+	if base == "" || base == "_" {
+		base = lowerFirst(typeName(d.Type))
+	}
+	name := w.unique(base)
+	w.params[key] = name
+	w.paramList = append(w.paramList, name+" "+types.TypeString(d.Type, w.qualifier))
+	return name
+}
+
+func (w *writer) varName(t types.Type) string {
+	return w.unique(lowerFirst(typeName(t)))
+}
+
+// unique reserves name, falling back to its initials and then to numbered variants when it collides
+// with an import or an earlier variable.
+func (w *writer) unique(name string) string {
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	if !w.names[name] {
+		w.names[name] = true
+		return name
+	}
+	if in := initials(name); !w.names[in] && !token.IsKeyword(in) {
+		w.names[in] = true
+		return in
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if !w.names[candidate] {
+			w.names[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// zeroValue returns the zero value of t as a Go expression.
+func zeroValue(t types.Type, qual types.Qualifier) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qual) + "{}"
+	}
+	return "nil"
+}
+
+func typeName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := types.Unalias(t).(*types.Named); ok {
+		return n.Obj().Name()
+	}
+	return "value"
+}
+
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i--
+	}
+	for j := 0; j < i; j++ {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	return string(runes)
+}
+
+// initials returns the lowercase initials of a camelCase name ("userStore" -> "us").
+func initials(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if i == 0 || unicode.IsUpper(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+func codeList(items []string) string {
+	if len(items) == 0 {
+		return "—"
+	}
+	quoted := make([]string, len(items))
+	for i, it := range items {
+		quoted[i] = "`" + it + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// packageNameIn returns the package name declared by the Go files in dir, or "main".
+func packageNameIn(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), m, nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name
+		}
+	}
+	return "main"
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: msg},
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package wiring
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/testutil"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	return testutil.WriteModule(t, map[string]string{
+		"go.mod": testutil.GoMod("example.com/app"),
+		"config/config.go": `package config
+
+type Config struct{ DSN, Addr string }
+`,
+		"store/store.go": `package store
+
+import "errors"
+
+type Store struct{ dsn string }
+
+func NewStore(dsn string) (*Store, error) {
+	if dsn == "" {
+		return nil, errors.New("empty dsn")
+	}
+	return &Store{dsn: dsn}, nil
+}
+
+func (s *Store) Get(id string) string { return id }
+`,
+		"users/users.go": `package users
+
+type Getter interface{ Get(id string) string }
+
+type Service struct{ g Getter }
+
+func NewService(g Getter) *Service { return &Service{g: g} }
+`,
+		"server/server.go": `package server
+
+import (
+	"example.com/app/config"
+	"example.com/app/users"
+)
+
+type Option func(*Server)
+
+type Server struct {
+	cfg   config.Config
+	users *users.Service
+}
+
+func NewServer(cfg config.Config, svc *users.Service, opts ...Option) *Server {
+	return &Server{cfg: cfg, users: svc}
+}
+`,
+		"cmd/app/main.go": "package main\n\nfunc main() {}\n",
+	})
+}
+
+func TestHandler_Report(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Content[0].(*mcp.TextContent).Text
+
+	wants := []string{
+		"| `server.NewServer` | `*server.Server` | `config.Config`, `*users.Service`, `[]server.Option (optional)` |",
+		"- `store.NewStore` → `users.NewService`",
+		"- `users.NewService` → `server.NewServer`",
+		"- `config.Config` ← `server.NewServer`",
+		"- `string` ← `store.NewStore`",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Generate(t *testing.T) {
+	dir := setup(t)
+	out := filepath.Join(dir, "cmd", "app", "wire.go")
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Root: "server.Server", Output: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wants := []string{
+		"package main",
+		"func wireServer(cfg config.Config, dsn string) (*server.Server, error) {",
+		"s, err := store.NewStore(dsn)",
+		"return nil, err",
+		"service := users.NewService(s)",
+		"server2 := server.NewServer(cfg, service)",
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, src)
+		}
+	}
+}
+
+func TestHandler_UnknownRoot(t *testing.T) {
+	dir := setup(t)
+
+	res, _, err := Handler(context.Background(), nil, Params{Dir: dir, Root: "Client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "no constructor provides Client") {
+		t.Errorf("expected an error for an unknown root, got: %s", res.Content[0].(*mcp.TextContent).Text)
+	}
+}
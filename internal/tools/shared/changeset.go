@@ -0,0 +1,111 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/writes"
+	"golang.org/x/tools/imports"
+)
+
+// Changeset maps absolute file paths to their complete new contents. A nil content deletes the file.
+// It is the unit of work for codemods: all files are written together or not at all.
+type Changeset map[string][]byte
+
+// Files returns the paths in the changeset in sorted order.
+func (c Changeset) Files() []string {
+	files := make([]string, 0, len(c))
+	for path := range c {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Apply formats Go files with goimports, writes every file, and verifies that the module in dir
+// still builds. If formatting, writing or the build fails, all files are restored to their previous
+// state (new files are removed) and an error containing the compiler output is returned.
+func (c Changeset) Apply(ctx context.Context, dir string) error {
+	return c.ApplyVerified(ctx, dir, []string{"build", "./..."})
+}
+
+// ApplyVerified is like Apply but verifies the change by running each of the given go commands
+// (e.g. {"test", "-run", "^TestNewServer$", "./server"}) in dir instead of only building. A command
+// starting with {"-C", otherDir} verifies another module, such as one the changeset creates.
+func (c Changeset) ApplyVerified(ctx context.Context, dir string, verify ...[]string) error {
+	formatted := make(map[string][]byte, len(c))
+	for _, path := range c.Files() {
+		content := c[path]
+		if content != nil && strings.HasSuffix(path, ".go") {
+			out, err := imports.Process(path, content, nil)
+			if err != nil {
+				snippet := ExtractErrorSnippet(string(content), err)
+				return fmt.Errorf("generated invalid Go code in %s: %v\n\nContext:\n```go\n%s```", filepath.Base(path), err, snippet)
+			}
+			content = out
+		}
+		formatted[path] = content
+	}
+
+	backups := make(map[string][]byte)
+	created := make(map[string]bool)
+	restore := func() {
+		for path := range formatted {
+			if created[path] {
+				_ = os.Remove(path)
+			} else if orig, ok := backups[path]; ok {
+				_ = writes.Write(ctx, path, orig, 0644)
+			}
+		}
+	}
+
+	for _, path := range c.Files() {
+		orig, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			backups[path] = orig
+		case os.IsNotExist(err):
+			created[path] = true
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				restore()
+				return fmt.Errorf("failed to create directory for %s: %w", path, err)
+			}
+		default:
+			restore()
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if formatted[path] == nil {
+			if err := os.Remove(path); err != nil {
+				restore()
+				return fmt.Errorf("failed to delete %s: %w", path, err)
+			}
+			continue
+		}
+		if err := writes.Write(ctx, path, formatted[path], 0644); err != nil {
+			restore()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	for _, args := range verify {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			restore()
+			verb := args[0]
+			if verb == "-C" && len(args) > 2 {
+				verb = args[2] + " (in " + args[1] + ")"
+			}
+			return fmt.Errorf("%s verification failed, all changes rolled back:\n%s", verb, strings.TrimSpace(string(out)))
+		}
+	}
+	for _, path := range c.Files() {
+		writes.Changed(ctx, path, backups[path], formatted[path])
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TextEdit replaces the bytes in [Start, End) of a file with New.
+type TextEdit struct {
+	Start, End int
+	New        string
+}
+
+// ApplyEdits applies non-overlapping edits to src and returns the result. Edits may be given in
+// any order; they are applied back to front so earlier offsets stay valid.
+func ApplyEdits(src []byte, edits []TextEdit) ([]byte, error) {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start > sorted[j].Start })
+	out := append([]byte(nil), src...)
+	prev := len(src) + 1
+	for _, e := range sorted {
+		if e.Start < 0 || e.End > len(src) || e.Start > e.End {
+			return nil, fmt.Errorf("edit [%d, %d) is out of range", e.Start, e.End)
+		}
+		if e.End > prev {
+			return nil, fmt.Errorf("edit [%d, %d) overlaps another edit", e.Start, e.End)
+		}
+		out = append(out[:e.Start], append([]byte(e.New), out[e.End:]...)...)
+		prev = e.Start
+	}
+	return out, nil
+}
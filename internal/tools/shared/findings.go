@@ -0,0 +1,10 @@
+package shared
+
+// Finding is a single location-bound diagnostic produced by an analysis tool.
+type Finding struct {
+	Pkg        string `json:"package"`
+	Position   string `json:"position"` // file:line:col relative to the analyzed directory
+	Rule       string `json:"rule"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
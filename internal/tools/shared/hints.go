@@ -1,10 +1,10 @@
 package shared
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/danicat/godoctor/internal/locale"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -15,32 +15,34 @@ var (
 	importErrorRe = regexp.MustCompile(`(?:could not import|package)\s+([a-zA-Z0-9_./-]+)`)
 )
 
-// GetDocHint checks a list of package errors for API usage issues and returns a generic doc hint.
-func GetDocHint(errs []packages.Error) string {
+// GetDocHint checks a list of package errors for API usage issues and returns a generic doc hint
+// in the given locale.
+func GetDocHint(lang string, errs []packages.Error) string {
 	for _, e := range errs {
-		if hint := generateHint(e.Msg); hint != "" {
+		if hint := generateHint(lang, e.Msg); hint != "" {
 			return hint
 		}
 	}
 	return ""
 }
 
-// GetDocHintFromOutput checks a raw output string for API usage issues and returns a generic doc hint.
-func GetDocHintFromOutput(output string) string {
-	return generateHint(output)
+// GetDocHintFromOutput checks a raw output string for API usage issues and returns a generic doc hint
+// in the given locale.
+func GetDocHintFromOutput(lang, output string) string {
+	return generateHint(lang, output)
 }
 
-func generateHint(msg string) string {
+func generateHint(lang, msg string) string {
 	// Check for "undefined: pkg.Symbol"
 	if matches := undefinedPkgRe.FindStringSubmatch(msg); len(matches) > 1 {
 		pkgName := matches[1]
-		return fmt.Sprintf("\n\n**HINT:** usage of '%s' failed. Try calling `go_docs` on that package to see the correct API.", pkgName)
+		return "\n\n" + locale.T(lang, "hint.undefined", pkgName)
 	}
 
 	// Check for "could not import ..."
 	if matches := importErrorRe.FindStringSubmatch(msg); len(matches) > 1 {
 		pkgPath := matches[1]
-		return fmt.Sprintf("\n\n**HINT:** import '%s' failed. Try calling `go_docs` on \"%s\" to verify the package path and exports.", pkgPath, pkgPath)
+		return "\n\n" + locale.T(lang, "hint.import", pkgPath, pkgPath)
 	}
 
 	return ""
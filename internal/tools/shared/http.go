@@ -0,0 +1,79 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPTimeout bounds each request made by HTTPRequest.
+const HTTPTimeout = 15 * time.Second
+
+// ErrLocalOnly is returned for requests to external services in local-only mode.
+var ErrLocalOnly = errors.New("godoctor runs in local-only mode (--no-cloud): requests to external services are disabled")
+
+var localOnly atomic.Bool
+
+// httpClient is the client behind HTTPRequest. Its transport bounds each phase of a request and
+// the idle connections kept, so a slow or misbehaving service cannot hold resources past
+// HTTPTimeout.
+var httpClient = &http.Client{
+	Timeout: HTTPTimeout,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	},
+}
+
+// SetLocalOnly turns local-only mode on or off. In local-only mode HTTPRequest sends nothing and
+// returns ErrLocalOnly, so every outbound request made through it is blocked in one place.
+func SetLocalOnly(on bool) {
+	localOnly.Store(on)
+}
+
+// LocalOnly reports whether local-only mode is on.
+func LocalOnly() bool {
+	return localOnly.Load()
+}
+
+// HTTPRequest sends a request to an external service and returns at most limit bytes of the
+// response body with the status code. Non-2xx statuses are not errors; callers decide. Every
+// request godoctor makes to an external service goes through it.
+func HTTPRequest(ctx context.Context, method, url string, body io.Reader, header http.Header, limit int64) ([]byte, int, error) {
+	if localOnly.Load() {
+		return nil, 0, ErrLocalOnly
+	}
+	ctx, cancel := context.WithTimeout(ctx, HTTPTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ReplacePathPrefix replaces every mention of the import path from in text, on its own, in a URL,
+// or followed by a subpath ("from/sub") or version ("from@v1"), with to. Mentions embedded in a
+// longer path ("x/from", "from2") are left alone. It returns the new text and the
+// number of replacements.
+func ReplacePathPrefix(text, from, to string) (string, int) {
+	if from == "" || from == to {
+		return text, 0
+	}
+	var sb strings.Builder
+	n := 0
+	i := 0
+	for {
+		j := strings.Index(text[i:], from)
+		if j < 0 {
+			sb.WriteString(text[i:])
+			break
+		}
+		start, end := i+j, i+j+len(from)
+		sb.WriteString(text[i:start])
+		if startsPath(text, start) && endsPath(text, end) {
+			sb.WriteString(to)
+			n++
+		} else {
+			sb.WriteString(from)
+		}
+		i = end
+	}
+	return sb.String(), n
+}
+
+// startsPath reports whether a path can start at text[i]: at the beginning, after a non-path
+// character, or right after a URL scheme ("https://").
+func startsPath(text string, i int) bool {
+	return i == 0 || !isPathByte(text[i-1]) || strings.HasSuffix(text[:i], "://")
+}
+
+// endsPath reports whether a path can end at text[i]: at the end, before a subpath or a non-path
+// character, or before a sentence-ending period.
+func endsPath(text string, i int) bool {
+	if i == len(text) || text[i] == '/' || !isPathByte(text[i]) {
+		return true
+	}
+	return text[i] == '.' && (i+1 == len(text) || !isPathByte(text[i+1]))
+}
+
+func isPathByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("._-~/", c) >= 0
+}
+
+// RewriteImports changes imports of from (and its subpackages) to to in a Go file, editing only the
+// import path literals so the rest of the file keeps its exact formatting. If comments is true,
+// mentions of the path in comments, such as //go:generate lines and import comments, are rewritten
+// too. It reports whether anything changed.
+func RewriteImports(filename string, src []byte, from, to string, comments bool) ([]byte, bool, error) {
+	if from == to {
+		return src, false, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	tf := fset.File(file.Pos())
+	var edits []TextEdit
+	for _, spec := range file.Imports {
+		imp, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp == from || strings.HasPrefix(imp, from+"/") {
+			edits = append(edits, TextEdit{
+				Start: tf.Offset(spec.Path.Pos()),
+				End:   tf.Offset(spec.Path.End()),
+				New:   strconv.Quote(to + strings.TrimPrefix(imp, from)),
+			})
+		}
+	}
+	if comments {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if text, n := ReplacePathPrefix(c.Text, from, to); n > 0 {
+					edits = append(edits, TextEdit{Start: tf.Offset(c.Pos()), End: tf.Offset(c.End()), New: text})
+				}
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return src, false, nil
+	}
+	out, err := ApplyEdits(src, edits)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
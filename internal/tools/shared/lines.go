@@ -2,6 +2,9 @@ package shared
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -41,46 +44,135 @@ func GetLineOffsets(content string, startLine, endLine int) (int, int, error) {
 	return startOffset, endOffset, nil
 }
 
-// GetSnippet returns a context window around the specified line number.
+// FrameOptions controls how CodeFrame renders a snippet.
+type FrameOptions struct {
+	// Context is the number of lines shown before and after the target line (default 5).
+	Context int
+	// Column is the 1-based byte column to mark with a caret under the target line; 0 for none.
+	Column int
+	// MaxWidth truncates longer lines to this many characters (default 120), keeping the marked
+	// column in view.
+	MaxWidth int
+}
+
+// GetSnippet returns a code frame of five lines of context around the specified line number.
 func GetSnippet(content string, lineNum int) string {
-	lines := strings.Split(content, "\n")
+	return CodeFrame(content, lineNum, FrameOptions{})
+}
+
+// CodeFrame renders the lines around lineNum with right-aligned line numbers, marks the target
+// line with ">" and, if opts.Column is set, places a caret under that column:
+//
+//	  12 | total := 0
+//	> 13 | for i := range n {
+//	     |     ^
+//	  14 | 	total += i
+//
+// Long lines are truncated on character boundaries with "…", and the caret line mirrors tabs in
+// the source so it stays aligned however the reader's terminal expands them.
+func CodeFrame(content string, lineNum int, opts FrameOptions) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
 	if lineNum < 1 || lineNum > len(lines) {
 		return ""
 	}
-
-	start := lineNum - 5
-	if start < 1 {
-		start = 1
+	if opts.Context <= 0 {
+		opts.Context = 5
 	}
-	end := lineNum + 5
-	if end > len(lines) {
-		end = len(lines)
+	if opts.MaxWidth <= 0 {
+		opts.MaxWidth = 120
 	}
 
+	start := max(lineNum-opts.Context, 1)
+	end := min(lineNum+opts.Context, len(lines))
+	width := len(fmt.Sprint(end))
+
 	var sb strings.Builder
 	for i := start; i <= end; i++ {
-		prefix := "  "
+		marker := "  "
 		if i == lineNum {
-			prefix = "-> "
+			marker = "> "
+		}
+		col := 0
+		if i == lineNum {
+			col = opts.Column
+		}
+		text, caretCol := truncateLine(strings.TrimRight(lines[i-1], "\r"), col, opts.MaxWidth)
+		fmt.Fprintf(&sb, "%s%*d | %s\n", marker, width, i, text)
+		if i == lineNum && caretCol > 0 {
+			fmt.Fprintf(&sb, "  %s | %s^\n", strings.Repeat(" ", width), caretPadding(text, caretCol))
 		}
-		fmt.Fprintf(&sb, "%s%d | %s\n", prefix, i, lines[i-1])
 	}
 	return sb.String()
 }
 
-// ExtractErrorSnippet attempts to parse a line number from an error message
-// and returns a snippet of the content around that line.
+// truncateLine shortens line to at most maxWidth characters around the 1-based byte column col,
+// marking removed text with "…". It returns the line and the column of col within it.
+func truncateLine(line string, col, maxWidth int) (string, int) {
+	runes := []rune(line)
+	// Convert the byte column to a rune index.
+	idx := -1
+	if col > 0 {
+		idx = len([]rune(line[:min(col-1, len(line))]))
+	}
+	if len(runes) <= maxWidth {
+		if idx < 0 {
+			return line, 0
+		}
+		return line, len(string(runes[:idx])) + 1
+	}
+
+	from := 0
+	if idx >= maxWidth-1 {
+		from = max(min(idx-maxWidth/2, len(runes)-maxWidth), 0)
+	}
+	to := min(from+maxWidth, len(runes))
+	out := string(runes[from:to])
+	prefix := ""
+	if from > 0 {
+		prefix = "…"
+	}
+	if to < len(runes) {
+		out += "…"
+	}
+	if idx < 0 {
+		return prefix + out, 0
+	}
+	return prefix + out, len(prefix) + len(string(runes[from:idx])) + 1
+}
+
+// caretPadding returns the whitespace that puts a caret under the 1-based byte column col of text,
+// copying tabs so the caret lines up regardless of tab width.
+func caretPadding(text string, col int) string {
+	var sb strings.Builder
+	for _, r := range text[:min(col-1, len(text))] {
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	return sb.String()
+}
+
+// positionRe matches "file.go:line" or "file.go:line:col" in compiler, vet, test and stack trace output.
+var positionRe = regexp.MustCompile(`(?m)((?:[A-Za-z]:)?[^\s:()"']+\.go):(\d+)(?::(\d+))?`)
+
+// ExtractErrorSnippet attempts to parse a line (and column) number from an error message
+// and returns a code frame of the content around that line.
 func ExtractErrorSnippet(content string, err error) string {
 	// Parse error string "filename:line:col: message" or ":line:col: message"
 	errMsg := err.Error()
 	parts := strings.Split(errMsg, ":")
 
-	var lineNum int
-	for _, part := range parts {
+	var lineNum, col int
+	for i, part := range parts {
 		var n int
 		// Try to parse the first number we find in the error parts
 		if _, e := fmt.Sscanf(strings.TrimSpace(part), "%d", &n); e == nil {
 			lineNum = n
+			if i+1 < len(parts) {
+				_, _ = fmt.Sscanf(strings.TrimSpace(parts[i+1]), "%d", &col)
+			}
 			break
 		}
 	}
@@ -89,7 +181,64 @@ func ExtractErrorSnippet(content string, err error) string {
 		return "Could not determine error line."
 	}
 
-	return GetSnippet(content, lineNum)
+	return CodeFrame(content, lineNum, FrameOptions{Column: col})
+}
+
+// FramesFromOutput finds the source positions mentioned in tool output (build errors, vet
+// diagnostics, test failures and panic stack traces), reads the files relative to dir, and returns
+// a code frame for each of the first limit distinct positions. Positions in files outside dir, such
+// as the standard library in a stack trace, are skipped.
+func FramesFromOutput(dir, output string, limit int, opts FrameOptions) string {
+	if opts.Context <= 0 {
+		opts.Context = 2
+	}
+	seen := make(map[string]bool)
+	cache := make(map[string]string)
+	var sb strings.Builder
+	count := 0
+	for _, m := range positionRe.FindAllStringSubmatch(output, -1) {
+		if count == limit {
+			break
+		}
+		file, line := m[1], m[2]
+		key := file + ":" + line
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		content, ok := cache[path]
+		if !ok {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			content = string(b)
+			cache[path] = content
+		}
+		var lineNum, col int
+		_, _ = fmt.Sscanf(line, "%d", &lineNum)
+		if m[3] != "" {
+			_, _ = fmt.Sscanf(m[3], "%d", &col)
+		}
+		o := opts
+		o.Column = col
+		frame := CodeFrame(content, lineNum, o)
+		if frame == "" {
+			continue
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(&sb, "%s:%d\n```go\n%s```\n", filepath.ToSlash(rel), lineNum, frame)
+		count++
+	}
+	return sb.String()
 }
 
 // GetLineFromOffset calculates the 1-based line number for a given byte offset.
@@ -0,0 +1,24 @@
+package shared
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase converts a Go identifier to snake_case, keeping initialisms together
+// ("HTTPServer" -> "http_server"). It is used to name generated files after types.
+func SnakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
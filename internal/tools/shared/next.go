@@ -0,0 +1,143 @@
+package shared
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// NextToolsKey is the result metadata key under which the suggested follow-up calls are reported.
+const NextToolsKey = "suggested_next_tools"
+
+// maxNextTools caps the suggestions attached to one result.
+const maxNextTools = 5
+
+// NextTool is a tool call that is likely to be the useful next step after a result, with
+// arguments ready to pass, so that agents need not infer the follow-up from the text.
+type NextTool struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Reason    string         `json:"reason"`
+}
+
+var (
+	// undefinedSymbolRe matches "undefined: pkgname.Symbol".
+	undefinedSymbolRe = regexp.MustCompile(`undefined:\s+([a-zA-Z0-9_]+)\.([a-zA-Z0-9_]+)`)
+	// missingModuleRe matches "no required module provides package github.com/foo/bar".
+	missingModuleRe = regexp.MustCompile(`no required module provides package ([a-zA-Z0-9_./-]+)`)
+	// badImportRe matches "could not import github.com/foo/bar" and "package foo/bar is not in std".
+	badImportRe = regexp.MustCompile(`(?:could not import ([a-zA-Z0-9_./-]+)|package ([a-zA-Z0-9_./-]+) is not in (?:std|GOROOT))`)
+	// majorSuffixRe matches the last element of versioned import paths: v2, yaml.v3.
+	majorSuffixRe = regexp.MustCompile(`^(?:v[0-9]+|(.+)\.v[0-9]+)$`)
+)
+
+// SuggestFromOutput returns follow-up calls for the problems in compiler or vet output run in dir,
+// using the heuristics behind GetDocHintFromOutput: read_docs for a symbol that does not exist in
+// the package it is used from, add_dependency for a package no module provides, and read_docs for
+// an import path that does not resolve. Package names are resolved to import paths through the
+// imports of the file the error is reported in.
+func SuggestFromOutput(dir, output string) []NextTool {
+	var next []NextTool
+	seen := make(map[string]bool)
+	add := func(t NextTool) {
+		key, _ := json.Marshal(t.Arguments)
+		if !seen[t.Tool+string(key)] && len(next) < maxNextTools {
+			seen[t.Tool+string(key)] = true
+			next = append(next, t)
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if m := undefinedSymbolRe.FindStringSubmatch(line); m != nil {
+			importPath := m[1]
+			if p := positionRe.FindStringSubmatch(line); p != nil {
+				importPath = resolveImport(dir, p[1], m[1])
+			}
+			add(NextTool{
+				Tool:      "read_docs",
+				Arguments: map[string]any{"import_path": importPath, "symbol_name": m[2]},
+				Reason:    m[1] + "." + m[2] + " is undefined; check the package API for the right name and signature",
+			})
+			continue
+		}
+		if m := missingModuleRe.FindStringSubmatch(line); m != nil {
+			add(NextTool{
+				Tool:      "add_dependency",
+				Arguments: map[string]any{"dir": dir, "packages": []string{m[1]}},
+				Reason:    "no module in go.mod provides " + m[1],
+			})
+			continue
+		}
+		if m := badImportRe.FindStringSubmatch(line); m != nil {
+			pkgPath := m[1] + m[2]
+			add(NextTool{
+				Tool:      "read_docs",
+				Arguments: map[string]any{"import_path": pkgPath},
+				Reason:    "import " + pkgPath + " failed; verify the package path",
+			})
+		}
+	}
+	return next
+}
+
+// resolveImport returns the import path that name refers to in file, or name itself if the file
+// cannot be read or does not import it (the standard library packages are their own path).
+func resolveImport(dir, file, name string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+	if err != nil {
+		return name
+	}
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return p
+			}
+			continue
+		}
+		if importName(p) == name {
+			return p
+		}
+	}
+	return name
+}
+
+// importName guesses the package name of an import path from its last element, skipping major
+// version suffixes: example.com/mod/v2 → mod, gopkg.in/yaml.v3 → yaml, go-foo → foo.
+func importName(p string) string {
+	base := path.Base(p)
+	if m := majorSuffixRe.FindStringSubmatch(base); m != nil {
+		if m[1] != "" {
+			base = m[1]
+		} else if dir := path.Dir(p); dir != "." {
+			base = path.Base(dir)
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	return strings.ReplaceAll(base, "-", "")
+}
+
+// WithNextTools attaches suggestions to res under NextToolsKey and returns res. Without
+// suggestions res is left untouched, so the field is only present when there is something to do.
+func WithNextTools(res *mcp.CallToolResult, next ...NextTool) *mcp.CallToolResult {
+	if res == nil || len(next) == 0 {
+		return res
+	}
+	if res.Meta == nil {
+		res.Meta = mcp.Meta{}
+	}
+	res.Meta[NextToolsKey] = next
+	return res
+}
@@ -0,0 +1,76 @@
+package shared
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuggestFromOutput(t *testing.T) {
+	dir := t.TempDir()
+	src := `package app
+
+import (
+	"fmt"
+	chi "github.com/go-chi/chi/v5"
+	"example.com/mod/v2"
+)
+
+var _ = fmt.Sprint
+var _ = chi.NewRouter
+var _ = mod.X
+`
+	if err := os.WriteFile(filepath.Join(dir, "app.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := `# example.com/app
+./app.go:10:13: undefined: chi.NewRouterX
+./app.go:11:13: undefined: mod.X
+./app.go:11:13: undefined: mod.X
+./app.go:9:13: undefined: fmt.Sprintx
+app.go:4:2: no required module provides package github.com/go-chi/chi/v5; to add it:
+	go get github.com/go-chi/chi/v5
+other.go:3:2: package example.com/typo is not in std (/usr/local/go/src/example.com/typo)
+`
+	got := SuggestFromOutput(dir, output)
+	want := []struct{ tool, path, symbol string }{
+		{"read_docs", "github.com/go-chi/chi/v5", "NewRouterX"},
+		{"read_docs", "example.com/mod/v2", "X"},
+		{"read_docs", "fmt", "Sprintx"},
+		{"add_dependency", "", ""},
+		{"read_docs", "example.com/typo", ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d suggestions, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Tool != w.tool || g.Reason == "" {
+			t.Errorf("suggestion %d = %+v, want tool %s", i, g, w.tool)
+			continue
+		}
+		if w.tool != "read_docs" {
+			continue
+		}
+		if g.Arguments["import_path"] != w.path || (w.symbol != "" && g.Arguments["symbol_name"] != w.symbol) {
+			t.Errorf("suggestion %d arguments = %v, want %s %s", i, g.Arguments, w.path, w.symbol)
+		}
+	}
+	if pkgs := got[3].Arguments["packages"].([]string); len(pkgs) != 1 || pkgs[0] != "github.com/go-chi/chi/v5" {
+		t.Errorf("add_dependency packages = %v", pkgs)
+	}
+}
+
+func TestImportName(t *testing.T) {
+	for path, want := range map[string]string{
+		"fmt":                         "fmt",
+		"net/http":                    "http",
+		"example.com/mod/v2":          "mod",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+	} {
+		if got := importName(path); got != want {
+			t.Errorf("importName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
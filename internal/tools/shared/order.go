@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ComparePositions orders "file:line:col" positions by file, then by line and column as numbers,
+// so that line 9 comes before line 10. The line and column are optional; positions that do not
+// end in numbers compare as plain strings. Tools sort their output with it so that results are
+// the same from run to run and read in source order.
+func ComparePositions(a, b string) int {
+	fa, la, ca := splitPosition(a)
+	fb, lb, cb := splitPosition(b)
+	return cmp.Or(strings.Compare(fa, fb), cmp.Compare(la, lb), cmp.Compare(ca, cb))
+}
+
+// splitPosition splits a position into its file, line and column.
+func splitPosition(pos string) (string, int, int) {
+	file, line, col := pos, 0, 0
+	for range 2 {
+		i := strings.LastIndexByte(file, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(file[i+1:])
+		if err != nil {
+			break
+		}
+		file, line, col = file[:i], n, line
+	}
+	return file, line, col
+}
+
+// SortFindings sorts findings by position, then rule, then message.
+func SortFindings(findings []Finding) {
+	slices.SortFunc(findings, func(a, b Finding) int {
+		return cmp.Or(ComparePositions(a.Position, b.Position), strings.Compare(a.Rule, b.Rule), strings.Compare(a.Message, b.Message))
+	})
+}
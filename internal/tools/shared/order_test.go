@@ -0,0 +1,33 @@
+package shared
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComparePositions(t *testing.T) {
+	got := []string{"b.go:1:1", "a.go:10:2", "a.go:9:30", "a.go:10:10", "a.go", "a.go:9"}
+	slices.SortFunc(got, ComparePositions)
+	want := []string{"a.go", "a.go:9", "a.go:9:30", "a.go:10:2", "a.go:10:10", "b.go:1:1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+}
+
+func TestSortFindings(t *testing.T) {
+	findings := []Finding{
+		{Position: "a.go:10:1", Rule: "b"},
+		{Position: "a.go:10:1", Rule: "a", Message: "y"},
+		{Position: "a.go:2:1", Rule: "z"},
+		{Position: "a.go:10:1", Rule: "a", Message: "x"},
+	}
+	SortFindings(findings)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Position+" "+f.Rule+" "+f.Message)
+	}
+	want := []string{"a.go:2:1 z ", "a.go:10:1 a x", "a.go:10:1 a y", "a.go:10:1 b "}
+	if !slices.Equal(got, want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+}
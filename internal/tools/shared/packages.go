@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadMode is the go/packages mode used by the analysis tools: syntax plus full type information.
+const LoadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+	packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports
+
+// LoadPackages loads and type-checks the packages matching pattern (default "./...") from dir.
+// If tests is true, test variants and external test packages are loaded as well.
+// Packages with errors are still returned so that analyses can report partial results.
+func LoadPackages(ctx context.Context, dir, pattern string, tests bool) ([]*packages.Package, error) {
+	if pattern == "" {
+		pattern = "./..."
+	}
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     dir,
+		Mode:    LoadMode,
+		Tests:   tests,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages matched %q in %s", pattern, dir)
+	}
+	return pkgs, nil
+}
+
+// RelPosition formats pos as "file:line:col" with the file relative to root when possible.
+func RelPosition(root string, pos token.Position) string {
+	rel, err := filepath.Rel(root, pos.Filename)
+	if err != nil {
+		rel = pos.Filename
+	}
+	return fmt.Sprintf("%s:%d:%d", rel, pos.Line, pos.Column)
+}
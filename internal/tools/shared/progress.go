@@ -0,0 +1,114 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressInterval bounds how often Tick sends a notification.
+const progressInterval = 250 * time.Millisecond
+
+// Progress reports the progress of a long-running tool call through MCP progress notifications,
+// so that clients can show live status and base their timeouts on it. A nil *Progress, returned
+// when the client sent no progress token, ignores every call.
+type Progress struct {
+	ctx     context.Context
+	session *mcp.ServerSession
+	token   any
+
+	mu   sync.Mutex
+	n    float64
+	last time.Time
+}
+
+// NewProgress returns the progress reporter of the call, or nil if the client did not ask for
+// progress notifications.
+func NewProgress(ctx context.Context, req *mcp.CallToolRequest) *Progress {
+	if req == nil || req.Session == nil || req.Params == nil {
+		return nil
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return nil
+	}
+	return &Progress{ctx: ctx, session: req.Session, token: token}
+}
+
+// Step reports the start of a phase. It is always sent.
+func (p *Progress) Step(msg string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.send(msg)
+}
+
+// Tick reports progress within a phase. Ticks closer than progressInterval to the previous
+// notification are dropped, so a phase may tick once per line of output.
+func (p *Progress) Tick(msg string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.last) < progressInterval {
+		return
+	}
+	p.send(msg)
+}
+
+// send notifies the client; p.mu is held. Failures are ignored: progress is best effort.
+func (p *Progress) send(msg string) {
+	p.n++
+	p.last = time.Now()
+	_ = p.session.NotifyProgress(p.ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Progress:      p.n,
+		Message:       msg,
+	})
+}
+
+// RunLines runs cmd and returns its combined output, passing each line to onLine as it is
+// printed, so that callers can report progress from the output of long commands.
+func RunLines(cmd *exec.Cmd, onLine func(string)) (string, error) {
+	w := &lineWriter{onLine: onLine}
+	// The same writer for both streams: exec then calls Write from one goroutine at a time.
+	cmd.Stdout, cmd.Stderr = w, w
+	err := cmd.Run()
+	w.flush()
+	return w.out.String(), err
+}
+
+// lineWriter keeps everything written to it and calls onLine for each complete line.
+type lineWriter struct {
+	out     bytes.Buffer
+	partial []byte
+	onLine  func(string)
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.out.Write(b)
+	w.partial = append(w.partial, b...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.onLine(string(w.partial))
+		w.partial = nil
+	}
+}
@@ -0,0 +1,24 @@
+package shared
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunLines(t *testing.T) {
+	var lines []string
+	out, err := RunLines(exec.Command("go", "env", "GOOS", "GOARCH"), func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{runtime.GOOS, runtime.GOARCH}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if want := runtime.GOOS + "\n" + runtime.GOARCH + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
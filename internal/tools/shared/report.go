@@ -0,0 +1,181 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/danicat/godoctor/internal/locale"
+)
+
+// Output formats accepted by tools that take an output_format parameter.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// ParseFormat normalizes a tool's format parameter, defaulting to markdown.
+func ParseFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		return FormatMarkdown, nil
+	case FormatMarkdown, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid format: must be '%s' or '%s'", FormatMarkdown, FormatJSON)
+}
+
+// Status is the outcome of a report or one of its sections.
+type Status string
+
+// Report and section statuses.
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Label returns the status as shown in markdown headings, in the given locale.
+func (s Status) Label(lang string) string {
+	if s == "" {
+		return ""
+	}
+	return locale.T(lang, "status."+string(s))
+}
+
+// Report is a structured tool result that renders as markdown for people and as JSON for
+// programs, so tools do not hand-roll either. Data carries the tool's own typed result, which
+// appears in the JSON rendering only.
+type Report struct {
+	Title    string     `json:"title"`
+	Lang     string     `json:"lang,omitempty"` // locale of the labels; "" renders them in English
+	Status   Status     `json:"status,omitempty"`
+	Summary  string     `json:"summary,omitempty"`
+	Sections []*Section `json:"sections,omitempty"`
+	Data     any        `json:"data,omitempty"`
+}
+
+// Section is one part of a report. Text is markdown; Output is verbatim tool output shown in a
+// code block. Collapsed sections render inside a <details> element, and a section without a
+// title renders without a heading.
+type Section struct {
+	Title     string     `json:"title,omitempty"`
+	Status    Status     `json:"status,omitempty"`
+	Text      string     `json:"text,omitempty"`
+	Items     []string   `json:"items,omitempty"`
+	Table     *Table     `json:"table,omitempty"`
+	Output    string     `json:"output,omitempty"`
+	Collapsed bool       `json:"collapsed,omitempty"`
+	Sections  []*Section `json:"sections,omitempty"`
+}
+
+// Table is a markdown table. Cells are markdown; pipes and newlines are escaped.
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// AddRow appends a row to the table.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Add appends a section to the report and returns it.
+func (r *Report) Add(title string, status Status) *Section {
+	s := &Section{Title: title, Status: status}
+	r.Sections = append(r.Sections, s)
+	return s
+}
+
+// Add appends a subsection and returns it.
+func (s *Section) Add(title string, status Status) *Section {
+	sub := &Section{Title: title, Status: status}
+	s.Sections = append(s.Sections, sub)
+	return sub
+}
+
+// Render renders the report in the given format, as returned by ParseFormat.
+func (r *Report) Render(format string) (string, error) {
+	if format == FormatJSON {
+		bytes, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(bytes), nil
+	}
+	return r.Markdown(), nil
+}
+
+// Markdown renders the report as markdown: a level-one title, then a level-two heading per
+// section with its status.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# " + r.Title + "\n\n")
+	if r.Status != "" {
+		fmt.Fprintf(&sb, "**%s:** %s\n\n", locale.T(r.Lang, "report.status"), r.Status.Label(r.Lang))
+	}
+	if r.Summary != "" {
+		sb.WriteString(strings.TrimSpace(r.Summary) + "\n\n")
+	}
+	for _, s := range r.Sections {
+		s.markdown(&sb, r.Lang, 2)
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func (s *Section) markdown(sb *strings.Builder, lang string, level int) {
+	heading := s.Title
+	if s.Status != "" {
+		heading += ": " + s.Status.Label(lang)
+	}
+	switch {
+	case s.Collapsed:
+		fmt.Fprintf(sb, "<details>\n<summary>%s</summary>\n\n", heading)
+	case heading != "":
+		fmt.Fprintf(sb, "%s %s\n\n", strings.Repeat("#", min(level, 6)), heading)
+	}
+	if s.Text != "" {
+		sb.WriteString(strings.TrimSpace(s.Text) + "\n\n")
+	}
+	for _, item := range s.Items {
+		sb.WriteString("- " + item + "\n")
+	}
+	if len(s.Items) > 0 {
+		sb.WriteString("\n")
+	}
+	if s.Table != nil && len(s.Table.Rows) > 0 {
+		s.Table.markdown(sb)
+	}
+	if out := strings.TrimSpace(s.Output); out != "" {
+		sb.WriteString("```text\n" + out + "\n```\n\n")
+	}
+	for _, sub := range s.Sections {
+		sub.markdown(sb, lang, level+1)
+	}
+	if s.Collapsed {
+		sb.WriteString("</details>\n\n")
+	}
+}
+
+func (t *Table) markdown(sb *strings.Builder) {
+	sb.WriteString("| " + strings.Join(t.Columns, " | ") + " |\n|")
+	for range t.Columns {
+		sb.WriteString(" :--- |")
+	}
+	sb.WriteString("\n")
+	for _, row := range t.Rows {
+		sb.WriteString("|")
+		for _, cell := range row {
+			sb.WriteString(" " + TableCell(cell) + " |")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+// TableCell escapes a markdown table cell: pipes are escaped and newlines become spaces.
+func TableCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\n", " "), "|", "\\|")
+}
@@ -0,0 +1,164 @@
+// Package transcript records the tool calls of each MCP session, together with the file changes
+// they made, so that a session can be exported as a reproducible trace.
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danicat/godoctor/internal/textdiff"
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxCalls bounds how many calls are kept per session; older calls are dropped first.
+	maxCalls = 1000
+	// maxResultBytes bounds the recorded text of a single result.
+	maxResultBytes = 64 << 10
+	// maxDiffLines caps the length of a recorded diff.
+	maxDiffLines = 2000
+)
+
+// FileChange is a file written by a tool call.
+type FileChange struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // "created", "modified" or "deleted"
+	Diff   string `json:"diff,omitempty"`
+}
+
+// Call is one recorded tool call.
+type Call struct {
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Started    time.Time       `json:"started"`
+	DurationMS int64           `json:"durationMs"`
+	IsError    bool            `json:"isError,omitempty"`
+	Result     string          `json:"result,omitempty"`
+	Files      []FileChange    `json:"files,omitempty"`
+	// BytesWritten is the total size of the writes the call made, scratch files included.
+	BytesWritten int64 `json:"bytesWritten,omitempty"`
+}
+
+// Recorder keeps the tool calls of each session.
+type Recorder struct {
+	mu      sync.Mutex
+	calls   map[*mcp.ServerSession][]*Call
+	dropped map[*mcp.ServerSession]int
+}
+
+// Global is the singleton instance for the entire application.
+var Global = &Recorder{
+	calls:   make(map[*mcp.ServerSession][]*Call),
+	dropped: make(map[*mcp.ServerSession]int),
+}
+
+// Middleware records every tools/call request handled by next. The call in progress listens to
+// the writes made in its context, so that the file changes are attached to it.
+func (r *Recorder) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		ctr, ok := req.(*mcp.CallToolRequest)
+		if !ok || ctr.Params == nil {
+			return next(ctx, method, req)
+		}
+		call := &Call{
+			Tool:      ctr.Params.Name,
+			Arguments: append(json.RawMessage(nil), ctr.Params.Arguments...),
+			Started:   time.Now(),
+		}
+		res, err := next(writes.With(ctx, listener{r, call}), method, req)
+		call.DurationMS = time.Since(call.Started).Milliseconds()
+		if err != nil {
+			call.IsError = true
+			call.Result = err.Error()
+		} else if tr, ok := res.(*mcp.CallToolResult); ok && tr != nil {
+			call.IsError = tr.IsError
+			call.Result = resultText(tr)
+		}
+		r.add(ctr.Session, call)
+		return res, err
+	}
+}
+
+func (r *Recorder) add(session *mcp.ServerSession, call *Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := append(r.calls[session], call)
+	if len(calls) > maxCalls {
+		r.dropped[session] += len(calls) - maxCalls
+		calls = append([]*Call(nil), calls[len(calls)-maxCalls:]...)
+	}
+	r.calls[session] = calls
+}
+
+// Calls returns a copy of the recorded calls of the session, oldest first, and the number of
+// older calls that were dropped to bound memory.
+func (r *Recorder) Calls(session *mcp.ServerSession) ([]Call, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Call, len(r.calls[session]))
+	for i, c := range r.calls[session] {
+		out[i] = *c
+		out[i].Files = append([]FileChange(nil), c.Files...)
+	}
+	return out, r.dropped[session]
+}
+
+// Delete removes the recorded calls of the session.
+func (r *Recorder) Delete(session *mcp.ServerSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.calls, session)
+	delete(r.dropped, session)
+}
+
+// listener attaches the writes of a call to it.
+type listener struct {
+	r    *Recorder
+	call *Call
+}
+
+func (l listener) Wrote(n int64) {
+	l.r.mu.Lock()
+	defer l.r.mu.Unlock()
+	l.call.BytesWritten += n
+}
+
+func (l listener) Changed(path string, before, after []byte) {
+	change := FileChange{Path: path, Status: "modified"}
+	switch {
+	case before == nil:
+		change.Status = "created"
+	case after == nil:
+		change.Status = "deleted"
+	}
+	change.Diff = textdiff.Unified(string(before), string(after))
+	if lines := strings.SplitAfter(change.Diff, "\n"); len(lines) > maxDiffLines+1 {
+		change.Diff = strings.Join(lines[:maxDiffLines], "") + fmt.Sprintf("... %d more line(s)\n", len(lines)-1-maxDiffLines)
+	}
+
+	l.r.mu.Lock()
+	defer l.r.mu.Unlock()
+	l.call.Files = append(l.call.Files, change)
+}
+
+func resultText(res *mcp.CallToolResult) string {
+	var sb strings.Builder
+	for _, c := range res.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(t.Text)
+		}
+	}
+	text := sb.String()
+	if len(text) > maxResultBytes {
+		text = text[:maxResultBytes] + "\n... (truncated)"
+	}
+	return text
+}
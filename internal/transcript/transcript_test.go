@@ -0,0 +1,64 @@
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danicat/godoctor/internal/writes"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := &Recorder{
+		calls:   make(map[*mcp.ServerSession][]*Call),
+		dropped: make(map[*mcp.ServerSession]int),
+	}
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		writes.Changed(ctx, "/p/main.go", []byte("package main\n"), []byte("package main\n\nfunc main() {}\n"))
+		writes.Count(ctx, 30)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil
+	}
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "smart_edit", Arguments: json.RawMessage(`{"filename":"/p/main.go"}`)}}
+	if _, err := r.Middleware(next)(context.Background(), "tools/call", req); err != nil {
+		t.Fatal(err)
+	}
+	// Other methods are not recorded.
+	if _, err := r.Middleware(next)(context.Background(), "tools/list", &mcp.ListToolsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls, dropped := r.Calls(nil)
+	if len(calls) != 1 || dropped != 0 {
+		t.Fatalf("got %d calls (%d dropped), want 1", len(calls), dropped)
+	}
+	c := calls[0]
+	if c.Tool != "smart_edit" || c.Result != "done" || c.IsError || string(c.Arguments) != `{"filename":"/p/main.go"}` {
+		t.Errorf("unexpected call: %+v", c)
+	}
+	if len(c.Files) != 1 || c.Files[0].Status != "modified" || !strings.Contains(c.Files[0].Diff, "+func main() {}") {
+		t.Errorf("unexpected file changes: %+v", c.Files)
+	}
+	if c.BytesWritten != 30 {
+		t.Errorf("BytesWritten = %d, want 30", c.BytesWritten)
+	}
+}
+
+func TestRecorder_Bounded(t *testing.T) {
+	r := &Recorder{
+		calls:   make(map[*mcp.ServerSession][]*Call),
+		dropped: make(map[*mcp.ServerSession]int),
+	}
+	for i := 0; i < maxCalls+5; i++ {
+		r.add(nil, &Call{Tool: "t"})
+	}
+	calls, dropped := r.Calls(nil)
+	if len(calls) != maxCalls || dropped != 5 {
+		t.Errorf("got %d calls and %d dropped, want %d and 5", len(calls), dropped, maxCalls)
+	}
+	r.Delete(nil)
+	if calls, _ := r.Calls(nil); len(calls) != 0 {
+		t.Errorf("Delete left %d calls", len(calls))
+	}
+}
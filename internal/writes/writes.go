@@ -0,0 +1,142 @@
+// Package writes is the single path through which tools write files, so that what each tool call
+// writes can be accounted: the write budget counts the bytes and the session transcript records
+// the changes. Listeners are attached to the context of a call; without one, the functions only
+// perform the writes.
+package writes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Listener is told about the writes of a tool call.
+type Listener interface {
+	// Wrote is called for every write to disk, with the number of bytes written. Scratch files,
+	// rewrites later rolled back and files written by subprocesses are included.
+	Wrote(n int64)
+	// Changed is called once per workspace file a call changes, with its content before and after.
+	// A nil before means the file was created and a nil after means it was deleted.
+	Changed(path string, before, after []byte)
+}
+
+type listenersKey struct{}
+
+// With returns a context whose writes are reported to l, in addition to the listeners of ctx.
+func With(ctx context.Context, l Listener) context.Context {
+	ls, _ := ctx.Value(listenersKey{}).([]Listener)
+	return context.WithValue(ctx, listenersKey{}, append(ls[:len(ls):len(ls)], l))
+}
+
+func listeners(ctx context.Context) []Listener {
+	ls, _ := ctx.Value(listenersKey{}).([]Listener)
+	return ls
+}
+
+// Count reports n bytes written on behalf of the call, for writes this package does not perform,
+// such as the output files of subprocesses.
+func Count(ctx context.Context, n int64) {
+	if n <= 0 {
+		return
+	}
+	for _, l := range listeners(ctx) {
+		l.Wrote(n)
+	}
+}
+
+// Changed reports the net change of a workspace file. Transactional writers, which write files
+// with Write and roll them back on failure, call it for each file once the change is kept.
+func Changed(ctx context.Context, path string, before, after []byte) {
+	for _, l := range listeners(ctx) {
+		l.Changed(path, before, after)
+	}
+}
+
+// Write writes data to path and counts the bytes, without recording a change. It is meant for
+// scratch files and for transactional writers that call Changed themselves.
+func Write(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	Count(ctx, int64(len(data)))
+	return nil
+}
+
+// WriteFile writes a workspace file, creating its directory if needed, and reports both the bytes
+// and the change.
+func WriteFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	before, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := Write(ctx, path, data, perm); err != nil {
+		return err
+	}
+	Changed(ctx, path, before, data)
+	return nil
+}
+
+// Watch snapshots the Go sources and module files (go.mod, go.sum, go.work) under dir and returns
+// a function that reports the files changed since, for writes made by subprocesses such as
+// go mod tidy or gofmt -w. Hidden directories, vendor and testdata are skipped. Without a listener
+// on ctx nothing is read.
+func Watch(ctx context.Context, dir string) func() {
+	if len(listeners(ctx)) == 0 {
+		return func() {}
+	}
+	before := snapshot(dir)
+	return func() {
+		after := snapshot(dir)
+		paths := make([]string, 0, len(before)+len(after))
+		for path := range before {
+			paths = append(paths, path)
+		}
+		for path := range after {
+			if _, ok := before[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			old, cur := before[path], after[path]
+			if bytes.Equal(old, cur) && (old == nil) == (cur == nil) {
+				continue
+			}
+			Count(ctx, int64(len(cur)))
+			Changed(ctx, path, old, cur)
+		}
+	}
+}
+
+func snapshot(dir string) map[string][]byte {
+	files := make(map[string][]byte)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" && name != "go.work" {
+			return nil
+		}
+		//nolint:gosec // G304: path comes from walking the watched directory.
+		if content, err := os.ReadFile(path); err == nil {
+			files[path] = content
+		}
+		return nil
+	})
+	return files
+}
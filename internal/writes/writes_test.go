@@ -0,0 +1,85 @@
+package writes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type recorder struct {
+	wrote   int64
+	changes []string
+}
+
+func (r *recorder) Wrote(n int64) { r.wrote += n }
+
+func (r *recorder) Changed(path string, before, after []byte) {
+	status := "modified"
+	switch {
+	case before == nil:
+		status = "created"
+	case after == nil:
+		status = "deleted"
+	}
+	r.changes = append(r.changes, filepath.Base(path)+" "+status)
+}
+
+func TestWriteFile(t *testing.T) {
+	var r recorder
+	ctx := With(context.Background(), &r)
+	path := filepath.Join(t.TempDir(), "sub", "a.txt")
+	if err := WriteFile(ctx, path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(ctx, path, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(ctx, filepath.Join(filepath.Dir(path), "scratch"), []byte("xyz"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r.wrote != 10 || len(r.changes) != 2 || r.changes[0] != "a.txt created" || r.changes[1] != "a.txt modified" {
+		t.Errorf("wrote %d, changes %v", r.wrote, r.changes)
+	}
+
+	// Without a listener the write still happens.
+	if err := WriteFile(context.Background(), path, []byte("bye"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "bye" {
+		t.Errorf("content = %q", data)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"go.mod": "module m\n", "a.go": "package m\n", "b.go": "package m\n", "notes.txt": "x", ".git/x.go": "package x\n"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var r recorder
+	done := Watch(With(context.Background(), &r), dir)
+	_ = os.WriteFile(filepath.Join(dir, "a.go"), []byte("package m\n\nvar A int\n"), 0644)
+	_ = os.Remove(filepath.Join(dir, "b.go"))
+	_ = os.WriteFile(filepath.Join(dir, "c.go"), []byte("package m\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("changed"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, ".git", "x.go"), []byte("package y\n"), 0644)
+	done()
+
+	want := []string{"a.go modified", "b.go deleted", "c.go created"}
+	if len(r.changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", r.changes, want)
+	}
+	for i := range want {
+		if r.changes[i] != want[i] {
+			t.Errorf("changes = %v, want %v", r.changes, want)
+		}
+	}
+	if r.wrote != int64(len("package m\n\nvar A int\n")+len("package m\n")) {
+		t.Errorf("wrote = %d", r.wrote)
+	}
+}